@@ -82,29 +82,133 @@ func main() {
 		logger.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// One-off command: upload local screenshots to the bucket and exit
+	if len(os.Args) > 1 && os.Args[1] == "migrate-screenshots" {
+		stats, err := services.MigrateScreenshotsToS3(context.Background(), db, services.NewFileStorage(cfg.Storage))
+		if err != nil {
+			logger.Fatalf("Failed to migrate screenshots: %v", err)
+		}
+		logger.Infof("Screenshot migration finished: %d scanned, %d uploaded, %d missing, %d failed",
+			stats.Scanned, stats.Uploaded, stats.Missing, stats.Failed)
+		return
+	}
+
+	// One-off command: compare statistics with check results, repair them unless --dry-run is given, and exit
+	if len(os.Args) > 1 && os.Args[1] == "reconcile-statistics" {
+		dryRun := len(os.Args) > 2 && os.Args[2] == "--dry-run"
+		report, err := services.NewStatisticsService(db).Reconcile(dryRun)
+		if err != nil {
+			logger.Fatalf("Failed to reconcile statistics: %v", err)
+		}
+		for _, discrepancy := range report.Samples {
+			logger.Infof("Phone %d, service %d: %s", discrepancy.PhoneNumberID, discrepancy.ServiceID,
+				strings.Join(discrepancy.Fields, ", "))
+		}
+		logger.Infof("Statistics reconciliation finished: %d pairs, %d discrepancies, %d repaired",
+			report.Pairs, report.Discrepancies, report.Repaired)
+		return
+	}
+
+	// Check pipeline knobs adjustable at runtime start from configuration
+	if err := database.SeedCheckTuningSettings(db, cfg.Check); err != nil {
+		logger.Fatalf("Failed to seed check tuning settings: %v", err)
+	}
+	if err := database.SeedOCRSettings(db, cfg.OCR); err != nil {
+		logger.Fatalf("Failed to seed OCR settings: %v", err)
+	}
+
+	// Results journaled while the database was unavailable are saved before new checks start
+	services.ConfigureResultJournal(cfg.Check)
+	if replay, err := services.ReplayResultJournal(db); err != nil {
+		logger.Errorf("Failed to replay result journal: %v", err)
+	} else if replay.Replayed+replay.Duplicate+replay.Dropped+replay.Remaining > 0 {
+		logger.Infof("Result journal replayed: %d saved, %d already saved, %d dropped, %d remaining",
+			replay.Replayed, replay.Duplicate, replay.Dropped, replay.Remaining)
+	}
+
+	// Fill normalized numbers for phones created before de-duplication
+	if updated, err := services.NewPhoneService(db).BackfillNormalizedNumbers(); err != nil {
+		logger.Errorf("Failed to backfill normalized phone numbers: %v", err)
+	} else if updated > 0 {
+		logger.Infof("Backfilled normalized numbers for %d phones", updated)
+	}
+
+	// Mark phones created by realtime checks before origin was recorded
+	if updated, err := services.NewPhoneService(db).BackfillPhoneOrigins(); err != nil {
+		logger.Errorf("Failed to backfill phone origins: %v", err)
+	} else if updated > 0 {
+		logger.Infof("Backfilled realtime origin for %d phones", updated)
+	}
+
+	// Fill campaigns from descriptions when a campaign pattern is configured
+	if updated, err := services.NewPhoneService(db).BackfillCampaigns(); err != nil {
+		logger.Errorf("Failed to backfill phone campaigns: %v", err)
+	} else if updated > 0 {
+		logger.Infof("Backfilled campaigns for %d phones", updated)
+	}
+
+	// Fill check config of services from legacy columns and built-in apps
+	if updated, err := services.NewServiceConfigService(db, cfg).BackfillServiceConfigs(); err != nil {
+		logger.Errorf("Failed to backfill service configs: %v", err)
+	} else if updated > 0 {
+		logger.Infof("Backfilled check config for %d services", updated)
+	}
+
+	// Load sample data for local development
+	if cfg.App.DevMode {
+		logger.Warn("Development mode enabled: sqlite database, mock Docker client and stub OCR")
+		if err := database.SeedDevData(db); err != nil {
+			logger.Fatalf("Failed to seed development data: %v", err)
+		}
+	}
+
+	// Shared Docker client, closed on shutdown
+	dockerClient := services.NewDockerClient(cfg)
+
 	// Initialize services
 	userService := services.NewUserService(db)
 	phoneService := services.NewPhoneService(db)
-	checkService := services.NewCheckService(db, cfg)
-	adbService := services.NewADBService(db, cfg)
+	phoneImportService := services.NewPhoneImportService(db, cfg)
+	checkService := services.NewCheckService(db, cfg, dockerClient)
+	adbService := services.NewADBService(db, cfg, dockerClient)
+	apkService := services.NewAPKService(db, cfg)
 	apiCheckService := services.NewAPICheckService(db)
 	settingsService := services.NewSettingsService(db)
+	settingsService.SyncPhoneMasking()
+	checkTuning := services.NewCheckTuning(db, cfg)
+	serviceConfigService := services.NewServiceConfigService(db, cfg)
 	statisticsService := services.NewStatisticsService(db)
 	notificationService := services.NewNotificationService(db)
 	asteriskService := services.NewAsteriskService(db)
+	configBundleService := services.NewConfigBundleService(db, adbService)
+	idempotencyService := services.NewIdempotencyService(db)
+	realtimeQuotaService := services.NewRealtimeQuotaService(db)
+
+	// Check jobs are not resumed after restart
+	if failed, err := checkService.FailInterruptedCheckJobs(); err != nil {
+		logger.Errorf("Failed to mark interrupted check jobs: %v", err)
+	} else if failed > 0 {
+		logger.Warnf("Marked %d check jobs interrupted by restart as failed", failed)
+	}
 
 	// Initialize scheduler
-	checkScheduler := scheduler.NewCheckScheduler(db, checkService, phoneService, notificationService, cfg)
+	checkScheduler := scheduler.NewCheckScheduler(db, checkService, phoneService, notificationService, dockerClient, cfg)
 	checkScheduler.Start()
 
+	// Resume background phone imports left unfinished by previous run
+	phoneImportService.Start()
+
+	publicStatusService := services.NewPublicStatusService(db, checkScheduler)
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		AppName:               cfg.App.Name,
 		DisableStartupMessage: false,
 		ErrorHandler:          customErrorHandler,
-		BodyLimit:             500 * 1024 * 1024, // 500 MB limit for APK files
-		ReadTimeout:           5 * time.Minute,   // Increase timeout for large uploads
-		WriteTimeout:          5 * time.Minute,
+		// Largest body of any route, NewBodyLimit below narrows it per route
+		BodyLimit:    max(cfg.App.APKBodyLimit, cfg.App.ImportBodyLimit, cfg.App.JSONBodyLimit),
+		ReadTimeout:  5 * time.Minute, // Increase timeout for large uploads
+		WriteTimeout: 5 * time.Minute,
 	})
 
 	// Middleware
@@ -125,21 +229,34 @@ func main() {
 		SkipPaths: []string{"/health", "/metrics"},
 	}))
 
+	// Config and other JSON endpoints get a much smaller body limit than uploads,
+	// multipart bodies larger than it are accepted only by upload routes
+	app.Use(middleware.NewBodyLimit(cfg.App.JSONBodyLimit,
+		middleware.UploadLimit{Method: fiber.MethodPost, Path: "/api/v1/apks", Limit: cfg.App.APKBodyLimit},
+		middleware.UploadLimit{Method: fiber.MethodPost, Path: "/api/v1/adb/gateways/docker", Limit: cfg.App.APKBodyLimit},
+		middleware.UploadLimit{Method: fiber.MethodPost, Path: "/api/v1/adb/gateways/docker/batch", Limit: cfg.App.APKBodyLimit},
+		middleware.UploadLimit{Method: fiber.MethodPost, Path: "/api/v1/adb/gateways/:id/install-apk", Limit: cfg.App.APKBodyLimit},
+		middleware.UploadLimit{Method: fiber.MethodPost, Path: "/api/v1/phones/import", Limit: cfg.App.ImportBodyLimit},
+		middleware.UploadLimit{Method: fiber.MethodPost, Path: "/api/v1/checks/import", Limit: cfg.App.ImportBodyLimit},
+	))
+
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Request-ID",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Request-ID, Idempotency-Key",
 		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS, PATCH",
 		AllowCredentials: true,
 	}))
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(cfg.JWT)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(idempotencyService)
 
 	// API routes
 	api := app.Group("/api/v1")
 
 	// Public routes
 	handlers.RegisterAuthRoutes(api, userService, cfg.JWT)
+	handlers.RegisterPublicStatusRoutes(api, publicStatusService)
 
 	// Swagger
 	app.Get("/swagger/*", swagger.HandlerDefault)
@@ -148,28 +265,37 @@ func main() {
 	protected := api.Use(authMiddleware.Protect())
 
 	// User routes
-	handlers.RegisterUserRoutes(protected, userService, authMiddleware)
+	handlers.RegisterUserRoutes(protected, userService, realtimeQuotaService, authMiddleware)
 
 	// Phone number routes
-	handlers.RegisterPhoneRoutes(protected, phoneService, authMiddleware)
+	handlers.RegisterPhoneRoutes(protected, phoneService, phoneImportService, checkService, checkScheduler, authMiddleware, idempotencyMiddleware)
 
 	// Check routes
-	handlers.RegisterCheckRoutes(protected, checkService, authMiddleware)
+	handlers.RegisterCheckRoutes(protected, checkService, realtimeQuotaService, authMiddleware)
 
 	// ADB Gateway routes
-	handlers.RegisterADBRoutes(protected, adbService, authMiddleware)
+	handlers.RegisterADBRoutes(protected, adbService, authMiddleware, idempotencyMiddleware)
+
+	// APK library routes
+	handlers.RegisterAPKRoutes(protected, apkService, adbService, authMiddleware)
 
 	// API Gateway routes
-	handlers.RegisterAPIServiceRoutes(protected, apiCheckService, authMiddleware)
+	handlers.RegisterAPIServiceRoutes(protected, apiCheckService, authMiddleware, idempotencyMiddleware)
 
 	// Settings routes
-	handlers.RegisterSettingsRoutes(protected, settingsService, authMiddleware)
+	handlers.RegisterSettingsRoutes(protected, settingsService, checkService, checkTuning, checkScheduler, authMiddleware, idempotencyMiddleware)
+
+	// Spam service config routes
+	handlers.RegisterServiceConfigRoutes(protected, serviceConfigService, authMiddleware)
 
 	// Statistics routes
 	handlers.RegisterStatisticsRoutes(protected, statisticsService, authMiddleware)
 
 	// Notification routes
-	handlers.RegisterNotificationRoutes(protected, notificationService, authMiddleware)
+	handlers.RegisterNotificationRoutes(protected, notificationService, authMiddleware, idempotencyMiddleware)
+
+	// Configuration bundle routes
+	handlers.RegisterConfigBundleRoutes(protected, configBundleService, authMiddleware)
 
 	// Asterisk routes (partially public)
 	handlers.RegisterAsteriskRoutes(api, asteriskService, authMiddleware)
@@ -177,10 +303,11 @@ func main() {
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
-			"status": "ok",
-			"app":    cfg.App.Name,
-			"env":    cfg.App.Environment,
-			"time":   time.Now().Unix(),
+			"status":    "ok",
+			"app":       cfg.App.Name,
+			"env":       cfg.App.Environment,
+			"time":      time.Now().Unix(),
+			"scheduler": checkScheduler.LeaderStatus(),
 		})
 	})
 
@@ -214,6 +341,10 @@ func main() {
 		checkScheduler.Stop()
 		logger.Info("Scheduler stopped")
 
+		// Save import progress so jobs resume from their cursor
+		phoneImportService.Stop()
+		logger.Info("Phone import worker stopped")
+
 		// Shutdown Fiber with timeout
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -224,6 +355,13 @@ func main() {
 			logger.Info("Server shutdown completed")
 		}
 
+		// Close Docker client
+		if err := dockerClient.Close(); err != nil {
+			logger.Warnf("Failed to close Docker client: %v", err)
+		} else {
+			logger.Info("Docker client closed")
+		}
+
 		// Close database connections
 		sqlDB, err := db.DB()
 		if err == nil {
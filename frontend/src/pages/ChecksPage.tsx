@@ -105,9 +105,9 @@ const ChecksPage: React.FC = observer(() => {
                 },
             });
 
-            const checkResults = response.data.results || [];
+            const checkResults = response.data.data || [];
             setResults(checkResults);
-            setTotalCount(response.data.count || 0);
+            setTotalCount(response.data.total || 0);
 
             // Load phone numbers for the results
             const phoneIdsSet = new Set<number>();
@@ -142,6 +142,9 @@ interface Notification {
     type: string;
     config: any;
     is_active: boolean;
+    last_sent_at?: string;
+    last_status?: string;
+    last_error?: string;
 }
 
 interface TabPanelProps {
@@ -236,15 +239,17 @@ const SettingsPage: React.FC = observer(() => {
 
     const loadSettings = async () => {
         setIsLoading(true);
+        // Settings tabs show whole lists, request the largest page list endpoints allow
+        const listParams = { params: { limit: 500 } };
         try {
             // Load all settings
             const [settingsRes, gatewaysRes, apisRes, keywordsRes, schedulesRes, notificationsRes] = await Promise.all([
                 axios.get('/settings'),
-                axios.get('/adb/gateways'),
-                axios.get('/api-services').catch(() => ({ data: [] })),
-                axios.get('/settings/keywords'),
-                axios.get('/settings/schedules'),
-                axios.get('/notifications'),
+                axios.get('/adb/gateways', listParams),
+                axios.get('/api-services', listParams).catch(() => ({ data: { data: [] } })),
+                axios.get('/settings/keywords', listParams),
+                axios.get('/settings/schedules', listParams),
+                axios.get('/notifications', listParams),
             ]);
 
             // Parse general settings
@@ -261,11 +266,12 @@ const SettingsPage: React.FC = observer(() => {
                 ...settings,
             });
 
-            setAdbGateways(gatewaysRes.data);
-            setApiServices(apisRes.data);
-            setKeywords(keywordsRes.data);
-            setSchedules(schedulesRes.data);
-            setNotifications(notificationsRes.data);
+            // List endpoints respond with a page: {data, total, page, limit}
+            setAdbGateways(gatewaysRes.data.data || []);
+            setApiServices(apisRes.data.data || []);
+            setKeywords(keywordsRes.data.data || []);
+            setSchedules(schedulesRes.data.data || []);
+            setNotifications(notificationsRes.data.data || []);
         } catch (error) {
             enqueueSnackbar(t('errors.loadFailed'), { variant: 'error' });
         } finally {
@@ -2024,6 +2030,18 @@ const SettingsPage: React.FC = observer(() => {
                                         } : null)}
                                     />
                                 </Grid>
+                                <Grid item xs={12}>
+                                    <TextField
+                                        fullWidth
+                                        label="Topic (Thread) ID"
+                                        helperText="Optional. Numeric ID of the forum topic to post into"
+                                        value={editingNotification?.config?.message_thread_id || ''}
+                                        onChange={(e) => setEditingNotification(editingNotification ? {
+                                            ...editingNotification,
+                                            config: { ...editingNotification.config, message_thread_id: e.target.value }
+                                        } : null)}
+                                    />
+                                </Grid>
                             </>
                         ) : (
                             <>
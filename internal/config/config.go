@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,24 +16,34 @@ type Config struct {
 	OCR      OCRConfig
 	Swagger  SwaggerConfig
 	Docker   DockerConfig
+	APK      APKConfig
+	Import   ImportConfig
+	Check    CheckTuningConfig
+	Storage  StorageConfig
 }
 
 type AppConfig struct {
-	Name        string
-	Port        string
-	Environment string
-	LogLevel    string
-	LogFormat   string
-	LogOutput   string
+	Name            string
+	Port            string
+	Environment     string
+	DevMode         bool // Run without external dependencies (sqlite, mock Docker, stub OCR)
+	LogLevel        string
+	LogFormat       string
+	LogOutput       string
+	JSONBodyLimit   int // Max body size in bytes of requests other than multipart uploads
+	APKBodyLimit    int // Max body size in bytes of APK uploads, also the server-wide limit
+	ImportBodyLimit int // Max body size in bytes of phone and check history import files
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	Name     string
-	SSLMode  string
+	Driver     string // postgres or sqlite
+	SQLitePath string // File path or ":memory:"
+	Host       string
+	Port       int
+	User       string
+	Password   string
+	Name       string
+	SSLMode    string
 }
 
 type JWTConfig struct {
@@ -56,8 +67,70 @@ type SwaggerConfig struct {
 }
 
 type DockerConfig struct {
-	Host string
-	Port string
+	Host          string
+	Port          string
+	VNCBasePort   int // First host port of VNC range
+	ADBBasePort   int // First host port of ADB range, each gateway takes two adjacent ports
+	PortRangeSize int // Number of gateway port slots
+}
+
+type APKConfig struct {
+	StoragePath string // Directory holding uploaded APK library files
+}
+
+type ImportConfig struct {
+	StoragePath string // Directory holding uploaded import files and error reports
+}
+
+// StorageConfig selects where screenshots are persisted
+type StorageConfig struct {
+	Backend   string // local or s3, backend new files are written to
+	LocalPath string // Root directory of local storage, legacy relative paths resolve against it
+	S3        S3Config
+}
+
+// S3Config holds connection settings of an S3-compatible bucket
+type S3Config struct {
+	Endpoint     string // Base URL, e.g. https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	Prefix       string // Key prefix prepended to every stored object
+	UsePathStyle bool   // Address bucket in path instead of host name, needed by most self-hosted servers
+}
+
+// Configured reports whether bucket connection settings are present
+func (c *S3Config) Configured() bool {
+	return c.Endpoint != "" && c.Bucket != ""
+}
+
+// CheckTuningConfig holds timings and limits of the check pipeline.
+// Runtime knobs seed system settings on first start, afterwards the settings win.
+type CheckTuningConfig struct {
+	ContainerStartupWait time.Duration // Wait after starting emulator container before setup
+	AppStartWait         time.Duration // Wait after launching app in the default check script
+	PostCallWait         time.Duration // Wait between incoming call and screenshot in the default check script
+	InterPhoneDelay      time.Duration // Pause between phones of a scheduled run
+	PhoneCheckTimeout    time.Duration // Scheduled run timeout for one phone (runtime)
+	MaxWorkers           int           // Gateways checked in parallel for one phone (runtime)
+	MaxRetries           int           // Retries per gateway and per API service (runtime)
+	MaxAPIConcurrency    int           // Outbound API check calls running at once across all phones (runtime)
+	RetryDelay           time.Duration // Pause before a retry (runtime)
+	ResultJournalPath    string        // File keeping results the database could not take, replayed once it is back
+	ResultJournalMaxSize int64         // Bytes, results are dropped once the journal reaches it
+}
+
+// defaultJWTSecret is used when JWT_SECRET is unset and is only acceptable in development
+const defaultJWTSecret = "your-secret-key"
+
+// IsDevelopment reports whether the environment is explicitly a development one
+func (c *AppConfig) IsDevelopment() bool {
+	switch c.Environment {
+	case "development", "dev":
+		return true
+	}
+	return false
 }
 
 func Load() (*Config, error) {
@@ -69,25 +142,39 @@ func Load() (*Config, error) {
 		}
 	}
 
+	environment := getEnv("APP_ENV", "development")
+	devMode := environment == "dev" || getEnvAsBool("DEV_MODE", false)
+
+	defaultDriver := "postgres"
+	if devMode {
+		defaultDriver = "sqlite"
+	}
+
 	cfg := &Config{
 		App: AppConfig{
-			Name:        getEnv("APP_NAME", "SpamChecker"),
-			Port:        getEnv("APP_PORT", "8080"),
-			Environment: getEnv("APP_ENV", "development"),
-			LogLevel:    getEnv("LOG_LEVEL", "info"),    // debug, info, warn, error
-			LogFormat:   getEnv("LOG_FORMAT", "json"),   // json или text
-			LogOutput:   getEnv("LOG_OUTPUT", "stdout"), // stdout, stderr или путь к файлу
+			Name:            getEnv("APP_NAME", "SpamChecker"),
+			Port:            getEnv("APP_PORT", "8080"),
+			Environment:     environment,
+			DevMode:         devMode,
+			LogLevel:        getEnv("LOG_LEVEL", "info"),    // debug, info, warn, error
+			LogFormat:       getEnv("LOG_FORMAT", "json"),   // json или text
+			LogOutput:       getEnv("LOG_OUTPUT", "stdout"), // stdout, stderr или путь к файлу
+			JSONBodyLimit:   getEnvAsInt("APP_JSON_BODY_LIMIT_KB", 1024) * 1024,
+			APKBodyLimit:    getEnvAsInt("APP_APK_BODY_LIMIT_MB", 500) * 1024 * 1024,
+			ImportBodyLimit: getEnvAsInt("APP_IMPORT_BODY_LIMIT_MB", 50) * 1024 * 1024,
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			Name:     getEnv("DB_NAME", "spamchecker"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Driver:     getEnv("DB_DRIVER", defaultDriver),
+			SQLitePath: getEnv("DB_SQLITE_PATH", "spamchecker-dev.db"),
+			Host:       getEnv("DB_HOST", "localhost"),
+			Port:       getEnvAsInt("DB_PORT", 5432),
+			User:       getEnv("DB_USER", "postgres"),
+			Password:   getEnv("DB_PASSWORD", "postgres"),
+			Name:       getEnv("DB_NAME", "spamchecker"),
+			SSLMode:    getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:                getEnv("JWT_SECRET", "your-secret-key"),
+			Secret:                getEnv("JWT_SECRET", defaultJWTSecret),
 			ExpirationHours:       getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
 			RefreshExpirationDays: getEnvAsInt("JWT_REFRESH_EXPIRATION_DAYS", 7),
 		},
@@ -104,19 +191,185 @@ func Load() (*Config, error) {
 			Version:     getEnv("SWAGGER_VERSION", "1.0.0"),
 		},
 		Docker: DockerConfig{
-			Host: getEnv("DOCKER_HOST", "tcp://localhost:2375"),
-			Port: getEnv("DOCKER_PORT", "2375"),
+			Host:          getEnv("DOCKER_HOST", "tcp://localhost:2375"),
+			Port:          getEnv("DOCKER_PORT", "2375"),
+			VNCBasePort:   getEnvAsInt("DOCKER_VNC_BASE_PORT", 6080),
+			ADBBasePort:   getEnvAsInt("DOCKER_ADB_BASE_PORT", 5554),
+			PortRangeSize: getEnvAsInt("DOCKER_PORT_RANGE_SIZE", 100),
 		},
+		APK: APKConfig{
+			StoragePath: getEnv("APK_STORAGE_PATH", "data/apks"),
+		},
+		Import: ImportConfig{
+			StoragePath: getEnv("IMPORT_STORAGE_PATH", "data/imports"),
+		},
+		Check: CheckTuningConfig{
+			ContainerStartupWait: getEnvAsDuration("CHECK_CONTAINER_STARTUP_WAIT", 30*time.Second),
+			AppStartWait:         getEnvAsDuration("CHECK_APP_START_WAIT", 2*time.Second),
+			PostCallWait:         getEnvAsDuration("CHECK_POST_CALL_WAIT", 5*time.Second),
+			InterPhoneDelay:      getEnvAsDuration("CHECK_INTER_PHONE_DELAY", time.Second),
+			PhoneCheckTimeout:    getEnvAsDuration("CHECK_PHONE_TIMEOUT", 30*time.Second),
+			MaxWorkers:           getEnvAsInt("CHECK_MAX_WORKERS", 5),
+			MaxRetries:           getEnvAsInt("CHECK_MAX_RETRIES", 3),
+			MaxAPIConcurrency:    getEnvAsInt("CHECK_API_MAX_CONCURRENT", 20),
+			RetryDelay:           getEnvAsDuration("CHECK_RETRY_DELAY", 2*time.Second),
+			ResultJournalPath:    getEnv("CHECK_RESULT_JOURNAL_PATH", "data/result-journal.jsonl"),
+			ResultJournalMaxSize: int64(getEnvAsInt("CHECK_RESULT_JOURNAL_MAX_MB", 64)) * 1024 * 1024,
+		},
+		Storage: StorageConfig{
+			Backend:   getEnv("STORAGE_BACKEND", "local"),
+			LocalPath: getEnv("STORAGE_LOCAL_PATH", "."),
+			S3: S3Config{
+				Endpoint:     getEnv("S3_ENDPOINT", ""),
+				Region:       getEnv("S3_REGION", "us-east-1"),
+				Bucket:       getEnv("S3_BUCKET", ""),
+				AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+				SecretKey:    getEnv("S3_SECRET_KEY", ""),
+				Prefix:       getEnv("S3_PREFIX", ""),
+				UsePathStyle: getEnvAsBool("S3_USE_PATH_STYLE", true),
+			},
+		},
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// Validate checks configuration consistency
+func (c *Config) Validate() error {
+	// Unsafe shortcuts are allowed only when the environment is explicitly
+	// development, so a typo or empty APP_ENV does not silently enable them
+	if !c.App.IsDevelopment() {
+		if c.App.DevMode {
+			return fmt.Errorf("development mode cannot be enabled in %q environment", c.App.Environment)
+		}
+		if c.Database.Driver != "postgres" {
+			return fmt.Errorf("database driver %q is not allowed in %q environment", c.Database.Driver, c.App.Environment)
+		}
+		if c.JWT.Secret == defaultJWTSecret {
+			return fmt.Errorf("default JWT secret is not allowed in %q environment", c.App.Environment)
+		}
+	}
+
+	switch c.Database.Driver {
+	case "postgres", "sqlite":
+	default:
+		return fmt.Errorf("unsupported database driver: %s", c.Database.Driver)
+	}
+
+	if err := c.Docker.validatePortRanges(); err != nil {
+		return err
+	}
+
+	if err := c.Storage.Validate(); err != nil {
+		return err
+	}
+
+	return c.Check.Validate()
+}
+
+// Validate checks that selected storage backend is fully configured
+func (c *StorageConfig) Validate() error {
+	switch c.Backend {
+	case "local":
+		return nil
+	case "s3":
+	default:
+		return fmt.Errorf("unsupported storage backend: %s", c.Backend)
+	}
+
+	if !c.S3.Configured() {
+		return fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required for s3 storage backend")
+	}
+	if c.S3.AccessKey == "" || c.S3.SecretKey == "" {
+		return fmt.Errorf("S3_ACCESS_KEY and S3_SECRET_KEY are required for s3 storage backend")
+	}
+	return nil
+}
+
+// Validate checks that check pipeline tuning is within sane ranges
+func (c *CheckTuningConfig) Validate() error {
+	durations := []struct {
+		name     string
+		value    time.Duration
+		min, max time.Duration
+	}{
+		{"CHECK_CONTAINER_STARTUP_WAIT", c.ContainerStartupWait, 0, 10 * time.Minute},
+		{"CHECK_APP_START_WAIT", c.AppStartWait, 0, time.Minute},
+		{"CHECK_POST_CALL_WAIT", c.PostCallWait, 0, time.Minute},
+		{"CHECK_INTER_PHONE_DELAY", c.InterPhoneDelay, 0, time.Minute},
+		{"CHECK_PHONE_TIMEOUT", c.PhoneCheckTimeout, 5 * time.Second, 30 * time.Minute},
+		{"CHECK_RETRY_DELAY", c.RetryDelay, 0, time.Minute},
+	}
+	for _, d := range durations {
+		if d.value < d.min || d.value > d.max {
+			return fmt.Errorf("%s must be between %s and %s, got %s", d.name, d.min, d.max, d.value)
+		}
+	}
+
+	if c.MaxWorkers < 1 || c.MaxWorkers > 50 {
+		return fmt.Errorf("CHECK_MAX_WORKERS must be between 1 and 50, got %d", c.MaxWorkers)
+	}
+	if c.MaxRetries < 0 || c.MaxRetries > 10 {
+		return fmt.Errorf("CHECK_MAX_RETRIES must be between 0 and 10, got %d", c.MaxRetries)
+	}
+	if c.MaxAPIConcurrency < 1 || c.MaxAPIConcurrency > 500 {
+		return fmt.Errorf("CHECK_API_MAX_CONCURRENT must be between 1 and 500, got %d", c.MaxAPIConcurrency)
+	}
+	if c.ResultJournalMaxSize < 1024*1024 || c.ResultJournalMaxSize > 1024*1024*1024 {
+		return fmt.Errorf("CHECK_RESULT_JOURNAL_MAX_MB must be between 1 and 1024, got %d", c.ResultJournalMaxSize/(1024*1024))
+	}
+
+	return nil
+}
+
+// VNCPortRange returns first and last host port available for VNC
+func (c *DockerConfig) VNCPortRange() (int, int) {
+	return c.VNCBasePort, c.VNCBasePort + c.PortRangeSize - 1
+}
+
+// ADBPortRange returns first and last host port available for ADB
+func (c *DockerConfig) ADBPortRange() (int, int) {
+	return c.ADBBasePort, c.ADBBasePort + c.PortRangeSize*2 - 1
+}
+
+// validatePortRanges checks that gateway port ranges fit and do not overlap
+func (c *DockerConfig) validatePortRanges() error {
+	if c.PortRangeSize < 1 {
+		return fmt.Errorf("DOCKER_PORT_RANGE_SIZE must be positive")
+	}
+
+	vncFirst, vncLast := c.VNCPortRange()
+	adbFirst, adbLast := c.ADBPortRange()
+	if vncFirst < 1 || vncLast > 65535 {
+		return fmt.Errorf("VNC port range %d-%d is out of bounds", vncFirst, vncLast)
+	}
+	if adbFirst < 1 || adbLast > 65535 {
+		return fmt.Errorf("ADB port range %d-%d is out of bounds", adbFirst, adbLast)
+	}
+	if vncFirst <= adbLast && adbFirst <= vncLast {
+		return fmt.Errorf("VNC port range %d-%d overlaps ADB port range %d-%d", vncFirst, vncLast, adbFirst, adbLast)
+	}
+
+	return nil
+}
+
 func (c *DatabaseConfig) DSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode)
 }
 
+// SQLiteDSN returns DSN for sqlite driver
+func (c *DatabaseConfig) SQLiteDSN() string {
+	if c.SQLitePath == ":memory:" {
+		return "file::memory:?cache=shared&_foreign_keys=on"
+	}
+	return fmt.Sprintf("file:%s?_busy_timeout=5000&_foreign_keys=on", c.SQLitePath)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -132,6 +385,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsDuration parses Go duration like 30s or 500ms
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := getEnv(key, "")
+	if value, err := time.ParseDuration(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	valueStr := getEnv(key, "")
 	if value, err := strconv.ParseBool(valueStr); err == nil {
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadRejectsDevModeOutsideDevelopment(t *testing.T) {
+	for _, env := range []string{"production", "staging", "prod "} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv("APP_ENV", env)
+			t.Setenv("DEV_MODE", "true")
+			t.Setenv("JWT_SECRET", "not-the-default")
+
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), "development mode") {
+				t.Fatalf("Load() error = %v, want development mode rejection", err)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsUnsafeDefaultsOutsideDevelopment(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("DEV_MODE", "")
+	t.Setenv("DB_DRIVER", "postgres")
+	t.Setenv("JWT_SECRET", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() in development: %v", err)
+	}
+
+	cfg.App.Environment = "prodution"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "JWT secret") {
+		t.Fatalf("Validate() error = %v, want default JWT secret rejection", err)
+	}
+
+	cfg.JWT.Secret = "not-the-default"
+	cfg.Database.Driver = "sqlite"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "database driver") {
+		t.Fatalf("Validate() error = %v, want sqlite rejection", err)
+	}
+
+	cfg.Database.Driver = "postgres"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() with safe settings: %v", err)
+	}
+}
+
+func TestValidateAllowsDevModeInDevelopment(t *testing.T) {
+	for _, env := range []string{"development", "dev"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv("APP_ENV", env)
+			t.Setenv("DEV_MODE", "true")
+			t.Setenv("JWT_SECRET", "")
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !cfg.App.DevMode || cfg.Database.Driver != "sqlite" {
+				t.Fatalf("DevMode = %v, driver = %q, want dev mode on sqlite", cfg.App.DevMode, cfg.Database.Driver)
+			}
+		})
+	}
+}
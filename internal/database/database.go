@@ -1,20 +1,32 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"spam-checker/internal/config"
 	"spam-checker/internal/logger"
 	"spam-checker/internal/models"
+	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 // Connect establishes database connection
 func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case "sqlite":
+		dialector = sqlite.Open(cfg.SQLiteDSN())
+	default:
+		dialector = postgres.Open(cfg.DSN())
+	}
+
 	// Use custom GORM logger
-	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger:                 logger.NewGormLogger(),
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
@@ -29,10 +41,15 @@ func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	}
 
 	// Set connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	if cfg.Driver == "sqlite" {
+		// SQLite allows a single writer, serialize access to avoid "database is locked"
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxIdleConns(10)
+		sqlDB.SetMaxOpenConns(100)
+	}
 
-	logger.Info("Successfully connected to database")
+	logger.WithField("driver", cfg.Driver).Info("Successfully connected to database")
 	return db, nil
 }
 
@@ -45,19 +62,54 @@ func Migrate(db *gorm.DB) error {
 		&models.PhoneNumber{},
 		&models.SpamService{},
 		&models.CheckResult{},
+		&models.CheckEvent{},
 		&models.ADBGateway{},
+		&models.GatewayEvent{},
 		&models.APIService{},
 		&models.SystemSettings{},
 		&models.Notification{},
 		&models.CheckSchedule{},
+		&models.SchedulePhone{},
+		&models.PhoneServiceExclusion{},
+		&models.ScheduleRun{},
+		&models.SchedulerHeartbeat{},
+		&models.Lease{},
+		&models.APKUpdateJob{},
+		&models.APKUpdateResult{},
+		&models.RunSpamRate{},
 		&models.SpamKeyword{},
 		&models.Statistics{},
 		&models.NumberAllocation{},
+		&models.SpamStatusTransition{},
+		&models.SpamVerification{},
+		&models.KeywordSnapshot{},
+		&models.NotificationDelivery{},
+		&models.APKFile{},
+		&models.PhoneImportJob{},
+		&models.CheckJob{},
+		&models.IdempotencyKey{},
+		&models.RealtimeQuotaUsage{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// Results saved before statuses existed get status from their verdict
+	if err := db.Exec(`UPDATE check_results SET status = CASE
+			WHEN is_spam THEN 'spam'
+			WHEN inconclusive THEN 'inconclusive'
+			ELSE 'clean' END
+		WHERE status IS NULL OR status = ''`).Error; err != nil {
+		return fmt.Errorf("failed to backfill check result statuses: %w", err)
+	}
+
+	// Results are filtered by found keyword with array containment
+	if db.Dialector.Name() == "postgres" {
+		if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_check_results_found_keywords ON check_results USING gin (found_keywords)").Error; err != nil {
+			return fmt.Errorf("failed to create found keywords index: %w", err)
+		}
+	}
+
 	// Seed initial data
 	if err := seedInitialData(db); err != nil {
 		return fmt.Errorf("failed to seed initial data: %w", err)
@@ -67,6 +119,82 @@ func Migrate(db *gorm.DB) error {
 	return nil
 }
 
+// SeedCheckTuningSettings creates runtime check pipeline settings from configuration.
+// Existing settings keep their values, only missing descriptions are filled in.
+func SeedCheckTuningSettings(db *gorm.DB, tuning config.CheckTuningConfig) error {
+	retries := strconv.Itoa(tuning.MaxRetries)
+
+	tuningSettings := []models.SystemSettings{
+		{
+			Key: "check_phone_timeout_seconds", Value: strconv.Itoa(int(tuning.PhoneCheckTimeout / time.Second)), Type: "int", Category: "performance",
+			Description: "Сколько секунд плановая проверка ждёт результат по одному номеру, после чего номер считается ошибочным (5-1800)",
+		},
+		{
+			Key: "adb_check_max_workers", Value: strconv.Itoa(tuning.MaxWorkers), Type: "int", Category: "performance",
+			Description: "Сколько ADB шлюзов проверяют один номер одновременно (1-50)",
+		},
+		{
+			Key: "check_retry_delay_ms", Value: strconv.Itoa(int(tuning.RetryDelay / time.Millisecond)), Type: "int", Category: "performance",
+			Description: "Пауза перед повтором проверки на шлюзе или в API сервисе, мс (0-60000)",
+		},
+		{
+			Key: "adb_check_max_retries", Value: retries, Type: "int", Category: "performance",
+			Description: "Максимум повторов проверки на одном ADB шлюзе (0-10)",
+		},
+		{
+			Key: "api_check_max_retries", Value: retries, Type: "int", Category: "performance",
+			Description: "Максимум повторов запроса к одному API сервису (0-10)",
+		},
+		{
+			Key: "api_check_max_concurrent", Value: strconv.Itoa(tuning.MaxAPIConcurrency), Type: "int", Category: "performance",
+			Description: "Сколько запросов к API сервисам выполняется одновременно по всем проверяемым номерам (1-500)",
+		},
+	}
+
+	return seedConfigSettings(db, tuningSettings)
+}
+
+// SeedOCRSettings creates OCR binary and language settings from configuration,
+// so they can be changed at runtime without restarting checks
+func SeedOCRSettings(db *gorm.DB, ocr config.OCRConfig) error {
+	return seedConfigSettings(db, []models.SystemSettings{
+		{
+			Key: "tesseract_path", Value: ocr.TesseractPath, Type: "string", Category: "ocr",
+			Description: "Путь к исполняемому файлу tesseract, изменение применяется к следующей проверке",
+		},
+		{
+			Key: "ocr_language", Value: ocr.Language, Type: "string", Category: "ocr",
+			Description: "Языки распознавания tesseract через +, например rus+eng",
+		},
+	})
+}
+
+// seedConfigSettings creates settings whose initial values come from configuration.
+// Existing settings keep their values, only missing descriptions are filled in.
+func seedConfigSettings(db *gorm.DB, settings []models.SystemSettings) error {
+	for _, setting := range settings {
+		var existing models.SystemSettings
+		err := db.Where("key = ?", setting.Key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := db.Create(&setting).Error; err != nil {
+				return fmt.Errorf("failed to create setting %s: %w", setting.Key, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get setting %s: %w", setting.Key, err)
+		}
+
+		if existing.Description == "" {
+			if err := db.Model(&existing).Update("description", setting.Description).Error; err != nil {
+				return fmt.Errorf("failed to update setting %s: %w", setting.Key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // seedInitialData seeds initial data
 func seedInitialData(db *gorm.DB) error {
 	// Seed spam services
@@ -100,7 +228,7 @@ func seedInitialData(db *gorm.DB) error {
 			adminUser = models.User{
 				Username: "admin",
 				Email:    adminEmail,
-				Password: "$2a$10$ZxK3bqGqOXj7YR2MJxPHPuQKpFkNE2Xk7JaG5LPqJgX6WUis2XAK.", // password: admin123
+				Password: "$2a$10$1iALI1RDqJ.Wqpu5Uw7nQ.sxLdvkRfVzMg.nEMCJ6eqYgiWxi.zN2", // password: admin123
 				Role:     models.RoleAdmin,
 				IsActive: true,
 			}
@@ -117,11 +245,59 @@ func seedInitialData(db *gorm.DB) error {
 	// Seed default settings
 	defaultSettings := []models.SystemSettings{
 		{Key: "check_interval_minutes", Value: "60", Type: "int", Category: "scheduler"},
+		{Key: "check_interval_alignment", Value: "relative", Type: "string", Category: "scheduler", Description: "Привязка проверки по интервалу: relative — отсчёт от запуска планировщика, wall_clock — к границам часов (ровно в начале часа для 60 минут, в :00 и :30 для 30)"},
 		{Key: "max_concurrent_checks", Value: "3", Type: "int", Category: "performance"},
+		{Key: "gateway_status_parallelism", Value: "4", Type: "int", Category: "performance"},
+		{Key: "gateway_status_timeout_seconds", Value: "30", Type: "int", Category: "performance"},
+		{Key: "gateway_status_jitter_seconds", Value: "60", Type: "int", Category: "performance"},
+		{Key: "check_retry_budget", Value: "6", Type: "int", Category: "performance"},
+		{Key: "gateway_min_call_interval_seconds", Value: "10", Type: "int", Category: "performance", Description: "Минимальная пауза между звонками на одном шлюзе, секунд (0-120, 0 — без паузы); шлюз может задать своё значение"},
+		{Key: "emulator_console_min_spacing_ms", Value: "500", Type: "int", Category: "performance", Description: "Минимальная пауза между командами консоли эмулятора (gsm call/cancel) на одном шлюзе, мс (0-10000)"},
+		{Key: "gateway_call_interval_policy", Value: "wait", Type: "string", Category: "performance", Description: "Если шлюз звонил недавно: wait — дождаться окончания паузы, fail — сразу завершить проверку на этом шлюзе ошибкой"},
 		{Key: "screenshot_quality", Value: "80", Type: "int", Category: "ocr"},
 		{Key: "ocr_confidence_threshold", Value: "70", Type: "int", Category: "ocr"},
+		{Key: "ocr_min_text_length", Value: "20", Type: "int", Category: "ocr"},
+		{Key: "ocr_max_concurrent", Value: "0", Type: "int", Category: "ocr", Description: "Сколько скриншотов распознаётся одновременно по всем проверкам (0-256); 0 — по числу CPU"},
+		{Key: "ocr_engine", Value: "exec", Type: "string", Category: "ocr", Description: "exec — процесс tesseract на каждый скриншот, persistent — переиспользуемые экземпляры libtesseract (сборка с тегом gosseract); без них используется exec"},
 		{Key: "notification_batch_size", Value: "50", Type: "int", Category: "notification"},
+		{Key: "notify_on_clean_runs", Value: "false", Type: "bool", Category: "notification"},
+		{Key: "notify_on_run_completion", Value: "false", Type: "bool", Category: "notification", Description: "Отправлять сводку после каждой проверки: сколько номеров проверено, найдено спама и ошибок"},
+		{Key: "notify_on_errors", Value: "false", Type: "bool", Category: "notification"},
+		{Key: "notify_error_count_threshold", Value: "5", Type: "int", Category: "notification"},
+		{Key: "notify_error_rate_percent", Value: "20", Type: "int", Category: "notification"},
+		{Key: "notification_degrade_after_failures", Value: "3", Type: "int", Category: "notification"},
+		{Key: "spam_verification_enabled", Value: "true", Type: "bool", Category: "notification", Description: "Уведомлять о новом спам-номере только после подтверждения повторной проверкой; false — уведомлять сразу"},
+		{Key: "spam_verification_delay_minutes", Value: "10", Type: "int", Category: "notification", Description: "Через сколько минут повторно проверять номер, впервые помеченный как спам (1-1440)"},
+		{Key: "anomaly_detection_enabled", Value: "true", Type: "bool", Category: "notification", Description: "Уведомлять, если доля спама за запуск проверки резко отличается от предыдущих запусков того же типа"},
+		{Key: "anomaly_baseline_runs", Value: "20", Type: "int", Category: "notification", Description: "Сколько последних запусков составляют базовую линию доли спама (3-500)"},
+		{Key: "anomaly_min_runs", Value: "10", Type: "int", Category: "notification", Description: "Сколько запусков должно накопиться, прежде чем отправлять уведомления об аномалиях (3-500)"},
+		{Key: "anomaly_threshold_mad", Value: "5", Type: "int", Category: "notification", Description: "Отклонение доли спама от медианы в масштабированных MAD, начиная с которого запуск считается аномальным (1-100)"},
+		{Key: "anomaly_min_change_percent", Value: "10", Type: "int", Category: "notification", Description: "Минимальное изменение доли спама в процентных пунктах для уведомления об аномалии (0-100)"},
 		{Key: "check_mode", Value: "adb_only", Type: "string", Category: "general"},
+		{Key: "check_restart_app_on_crash", Value: "true", Type: "bool", Category: "general", Description: "Перезапускать приложение сервиса, если во время проверки появился диалог сбоя или «не отвечает»"},
+		{Key: "check_event_log_enabled", Value: "false", Type: "bool", Category: "general", Description: "Записывать этапы каждой проверки (звонок, скриншот, OCR, вердикт) с таймингами в БД; пишет несколько строк на проверку"},
+		{Key: "public_status_enabled", Value: "true", Type: "bool", Category: "general"},
+		{Key: "phone_import_sync_max_rows", Value: "1000", Type: "int", Category: "general"},
+		{Key: "phone_campaign_description_pattern", Value: "", Type: "string", Category: "general", Description: "Регулярное выражение для заполнения кампании номера из описания (первая группа или всё совпадение), пустое — не заполнять"},
+		{Key: "realtime_quota_daily", Value: "1000", Type: "int", Category: "general", Description: "Сколько проверок в реальном времени пользователь может выполнить за сутки (UTC), 0 — без ограничения"},
+		{Key: "realtime_quota_per_minute", Value: "20", Type: "int", Category: "general", Description: "Сколько проверок в реальном времени пользователь может выполнить за минуту, 0 — без ограничения"},
+		{Key: "realtime_quota_cached_weight_percent", Value: "20", Type: "int", Category: "general", Description: "Стоимость ответа из кэша в процентах от проверки через шлюзы (0-100)"},
+		{Key: "realtime_phone_ttl_hours", Value: "168", Type: "int", Category: "general", Description: "Через сколько часов удалять неактивные номера, созданные проверкой в реальном времени, если оператор их не активировал и не изменил (0 — не удалять)"},
+		{Key: "idempotency_key_ttl_hours", Value: "24", Type: "int", Category: "general", Description: "Сколько часов хранить ответ запроса с заголовком Idempotency-Key для повторов"},
+		{Key: "result_max_age_hours", Value: "48", Type: "int", Category: "general", Description: "Через сколько часов результат проверки сервиса считается устаревшим (0 — никогда); сервис может задать своё значение"},
+		{Key: "keyword_negations", Value: "не,нет,not,no", Type: "string", Category: "general", Description: "Слова через запятую, отменяющие спам-ключевое слово, если стоят сразу перед ним или после него («не спам», «спам: нет»); пустое — не учитывать"},
+		{Key: "min_spam_confidence", Value: "0", Type: "int", Category: "general", Description: "Минимальная уверенность обнаружения спама в процентах (0-100): результаты ниже сохраняются как suspected и не считаются спамом; 0 — учитывать все"},
+		{Key: "phone_list_default_sort", Value: "created_at", Type: "string", Category: "general", Description: "Сортировка списка номеров по умолчанию: created_at, number, spam_status, last_checked или allocations"},
+		{Key: "phone_list_default_order", Value: "desc", Type: "string", Category: "general", Description: "Порядок сортировки списка номеров по умолчанию: asc или desc"},
+		{Key: "mask_phone_numbers", Value: "false", Type: "bool", Category: "general", Description: "Маскировать номера телефонов (+7912***4567) в логах и уведомлениях; в БД и ответах API номера хранятся полностью"},
+		{Key: "asterisk_errored_number_policy", Value: "allow", Type: "string", Category: "asterisk", Description: "Выдавать ли номера, последняя проверка которых завершилась ошибкой: allow — по последнему успешному результату, exclude — не выдавать до успешной проверки"},
+		{Key: "asterisk_require_clean_check", Value: "true", Type: "bool", Category: "asterisk", Description: "Выдавать только номера, прошедшие хотя бы одну успешную проверку без спама; выключено — новые непроверенные номера тоже считаются чистыми"},
+		{Key: "asterisk_allocation_strategy", Value: "weighted", Type: "string", Category: "asterisk", Description: "Выбор номера для Asterisk: weighted — случайно с приоритетом редко выдаваемых, round_robin — по очереди, lru — дольше всех не выдававшийся, random — равновероятно"},
+		{Key: "check_sample_size", Value: "0", Type: "int", Category: "scheduler", Description: "Сколько случайных номеров проверять за запуск проверки по интервалу, 0 — все (или check_sample_percent)"},
+		{Key: "check_sample_percent", Value: "0", Type: "int", Category: "scheduler", Description: "Какой процент номеров проверять за запуск проверки по интервалу (0-100), 0 — все (или check_sample_size)"},
+		{Key: "check_sample_max_staleness_hours", Value: "24", Type: "int", Category: "scheduler", Description: "При выборочной проверке каждый активный номер проверяется не реже чем раз в указанное число часов (1-8760)"},
+		{Key: "scheduler_watchdog_minutes", Value: "0", Type: "int", Category: "scheduler", Description: "Через сколько минут без завершённой проверки планировщик считается остановившимся и отправляется уведомление, 0 — три интервала проверки"},
+		{Key: "check_active_window", Value: `{"enabled":false,"days":["mon","tue","wed","thu","fri"],"start":"09:00","end":"18:00","timezone":"Europe/Moscow"}`, Type: "json", Category: "scheduler"},
 	}
 
 	for _, setting := range defaultSettings {
@@ -177,3 +353,66 @@ func seedInitialData(db *gorm.DB) error {
 
 	return nil
 }
+
+// SeedDevData seeds sample data for development mode
+func SeedDevData(db *gorm.DB) error {
+	var adminUser models.User
+	if err := db.Where("role = ?", models.RoleAdmin).First(&adminUser).Error; err != nil {
+		return fmt.Errorf("failed to find admin user: %w", err)
+	}
+
+	// Sample phones
+	phones := []models.PhoneNumber{
+		{Number: "79001234567", Description: "Dev sample phone 1", IsActive: true, CreatedBy: adminUser.ID},
+		{Number: "79007654321", Description: "Dev sample phone 2", IsActive: true, CreatedBy: adminUser.ID},
+		{Number: "79990001122", Description: "Dev sample phone 3", IsActive: true, CreatedBy: adminUser.ID},
+	}
+
+	for _, phone := range phones {
+		var existing models.PhoneNumber
+		if err := db.Where("number = ?", phone.Number).First(&existing).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				if err := db.Create(&phone).Error; err != nil {
+					return fmt.Errorf("failed to create phone %s: %w", logger.FormatPhone(phone.Number), err)
+				}
+			} else {
+				return fmt.Errorf("failed to check phone %s: %w", logger.FormatPhone(phone.Number), err)
+			}
+		}
+	}
+
+	// One mock gateway per spam service
+	var services []models.SpamService
+	if err := db.Find(&services).Error; err != nil {
+		return fmt.Errorf("failed to get spam services: %w", err)
+	}
+
+	for i, service := range services {
+		gateway := models.ADBGateway{
+			Name:        "dev-" + service.Code,
+			Host:        "localhost",
+			Port:        5555 + i*2,
+			ServiceCode: service.Code,
+			IsActive:    true,
+			Status:      "online",
+		}
+
+		var existing models.ADBGateway
+		if err := db.Where("name = ?", gateway.Name).First(&existing).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				if err := db.Create(&gateway).Error; err != nil {
+					return fmt.Errorf("failed to create gateway %s: %w", gateway.Name, err)
+				}
+			} else {
+				return fmt.Errorf("failed to check gateway %s: %w", gateway.Name, err)
+			}
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"phones":   len(phones),
+		"gateways": len(services),
+	}).Info("Development seed data loaded")
+
+	return nil
+}
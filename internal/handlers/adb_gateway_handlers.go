@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"spam-checker/internal/models"
 	"spam-checker/internal/services"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -19,15 +21,17 @@ type CreateADBGatewayRequest struct {
 	Port        int    `json:"port"`
 	ServiceCode string `json:"service_code" validate:"required,oneof=yandex_aon kaspersky getcontact"`
 	IsDocker    bool   `json:"is_docker"`
+	DockerHost  string `json:"docker_host"` // Docker daemon running the container, empty for the default host
 }
 
 // UpdateADBGatewayRequest represents ADB gateway update request
 type UpdateADBGatewayRequest struct {
-	Name        string `json:"name"`
-	Host        string `json:"host"`
-	Port        int    `json:"port"`
-	ServiceCode string `json:"service_code"`
-	IsActive    *bool  `json:"is_active"`
+	Name                   string `json:"name"`
+	Host                   string `json:"host"`
+	Port                   int    `json:"port"`
+	ServiceCode            string `json:"service_code"`
+	IsActive               *bool  `json:"is_active"`
+	MinCallIntervalSeconds *int   `json:"min_call_interval_seconds"` // 0 uses gateway_min_call_interval_seconds setting
 }
 
 // ExecuteCommandRequest represents ADB command execution request
@@ -35,19 +39,31 @@ type ExecuteCommandRequest struct {
 	Command string `json:"command" validate:"required"`
 }
 
+// ScreenshotBatchRequest represents request to capture screens of several gateways
+type ScreenshotBatchRequest struct {
+	GatewayIDs     []uint `json:"gateway_ids" validate:"required"`
+	TimeoutSeconds int    `json:"timeout_seconds"` // Per gateway, 30 by default
+}
+
 // GatewayStatusResponse represents gateway status response
 type GatewayStatusResponse struct {
 	Message string `json:"message"`
 	Status  string `json:"status,omitempty"`
 }
 
+// DockerGatewayResponse represents created Docker gateway with its host ports
+type DockerGatewayResponse struct {
+	*models.ADBGateway
+	Ports services.GatewayPorts `json:"ports"`
+}
+
 // CommandOutputResponse represents command output response
 type CommandOutputResponse struct {
 	Output string `json:"output"`
 }
 
 // RegisterADBRoutes registers ADB gateway routes
-func RegisterADBRoutes(api fiber.Router, adbService *services.ADBService, authMiddleware *middleware.AuthMiddleware) {
+func RegisterADBRoutes(api fiber.Router, adbService *services.ADBService, authMiddleware *middleware.AuthMiddleware, idempotency *middleware.IdempotencyMiddleware) {
 	adb := api.Group("/adb")
 
 	// All ADB routes require admin or supervisor role
@@ -55,39 +71,58 @@ func RegisterADBRoutes(api fiber.Router, adbService *services.ADBService, authMi
 
 	adb.Get("/gateways", listGatewaysHandler(adbService))
 	adb.Get("/gateways/:id", getGatewayHandler(adbService))
-	adb.Post("/gateways", authMiddleware.RequireRole(models.RoleAdmin), createGatewayHandler(adbService))
-	adb.Post("/gateways/docker", authMiddleware.RequireRole(models.RoleAdmin), createDockerGatewayHandler(adbService))
+	adb.Post("/gateways", authMiddleware.RequireRole(models.RoleAdmin), idempotency.Handle(), createGatewayHandler(adbService))
+	adb.Post("/gateways/docker", authMiddleware.RequireRole(models.RoleAdmin), idempotency.Handle(), createDockerGatewayHandler(adbService))
+	adb.Post("/gateways/docker/batch", authMiddleware.RequireRole(models.RoleAdmin), idempotency.Handle(), createDockerGatewayBatchHandler(adbService))
+	adb.Get("/gateways/docker/batch/:id", getDockerGatewayBatchHandler(adbService))
 	adb.Put("/gateways/:id", authMiddleware.RequireRole(models.RoleAdmin), updateGatewayHandler(adbService))
 	adb.Delete("/gateways/:id", authMiddleware.RequireRole(models.RoleAdmin), deleteGatewayHandler(adbService))
 	adb.Post("/gateways/:id/status", updateGatewayStatusHandler(adbService))
 	adb.Post("/gateways/status", updateAllGatewayStatusesHandler(adbService))
 	adb.Get("/gateways/:id/device-info", getDeviceInfoHandler(adbService))
+	adb.Get("/gateways/:id/logs", authMiddleware.RequireRole(models.RoleAdmin), getGatewayLogsHandler(adbService))
+	adb.Get("/gateways/:id/events", getGatewayEventsHandler(adbService))
 	adb.Post("/gateways/:id/execute", authMiddleware.RequireRole(models.RoleAdmin), executeCommandHandler(adbService))
 	adb.Post("/gateways/:id/restart", authMiddleware.RequireRole(models.RoleAdmin), restartDeviceHandler(adbService))
 	adb.Post("/gateways/:id/install-apk", authMiddleware.RequireRole(models.RoleAdmin), installAPKHandler(adbService))
+	adb.Post("/screenshots/batch", captureScreenshotsHandler(adbService))
 	adb.Get("/docker/status", checkDockerStatusHandler(adbService))
 	adb.Get("/docker/containers", listDockerContainersHandler(adbService))
+	adb.Get("/docker/ports", authMiddleware.RequireRole(models.RoleAdmin), listPortAssignmentsHandler(adbService))
 }
 
 // listGatewaysHandler godoc
 // @Summary List ADB gateways
-// @Description Get all ADB gateways
+// @Description Get ADB gateways with pagination
 // @Tags adb
 // @Accept json
 // @Produce json
-// @Success 200 {object} []models.ADBGateway
+// @Param page query int false "Page number" default(1)
+// @Param offset query int false "Items to skip, overrides page"
+// @Param limit query int false "Items per page" default(50)
+// @Param legacy query bool false "Respond with bare array of all gateways, kept for one release"
+// @Success 200 {object} ListResponse{data=[]models.ADBGateway}
 // @Security BearerAuth
 // @Router /adb/gateways [get]
 func listGatewaysHandler(adbService *services.ADBService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		gateways, err := adbService.ListGateways()
+		legacy := legacyList(c)
+		pagination := parsePagination(c)
+		if legacy {
+			pagination = Pagination{}
+		}
+
+		gateways, total, err := adbService.ListGatewaysPage(pagination.Offset, pagination.Limit)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get gateways",
 			})
 		}
 
-		return c.JSON(gateways)
+		if legacy {
+			return c.JSON(gateways)
+		}
+		return sendList(c, gateways, total, pagination)
 	}
 }
 
@@ -157,6 +192,7 @@ func createGatewayHandler(adbService *services.ADBService) fiber.Handler {
 			IsActive:    true,
 			Status:      "offline",
 			IsDocker:    false, // Always false for manual creation
+			DockerHost:  req.DockerHost,
 		}
 
 		if err := adbService.CreateGateway(gateway); err != nil {
@@ -177,8 +213,10 @@ func createGatewayHandler(adbService *services.ADBService) fiber.Handler {
 // @Produce json
 // @Param name formData string true "Gateway name"
 // @Param service_code formData string true "Service code (yandex_aon, kaspersky, getcontact)"
+// @Param docker_host formData string false "Docker host to run the container on, e.g. tcp://10.0.0.5:2375; default host when omitted"
 // @Param apk formData file false "APK file to install"
-// @Success 201 {object} models.ADBGateway
+// @Param apk_id formData int false "Library APK ID to install instead of upload, service default APK is used when both are omitted"
+// @Success 201 {object} DockerGatewayResponse
 // @Security BearerAuth
 // @Router /adb/gateways/docker [post]
 func createDockerGatewayHandler(adbService *services.ADBService) fiber.Handler {
@@ -225,21 +263,146 @@ func createDockerGatewayHandler(adbService *services.ADBService) fiber.Handler {
 			}
 		}
 
+		var apkID *uint
+		if value := c.FormValue("apk_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid APK ID",
+				})
+			}
+			parsed := uint(id)
+			apkID = &parsed
+		}
+
 		gateway := &models.ADBGateway{
 			Name:        name,
 			ServiceCode: serviceCode,
 			IsActive:    true,
 			Status:      "creating",
 			IsDocker:    true,
+			DockerHost:  c.FormValue("docker_host"),
 		}
 
-		if err := adbService.CreateDockerGateway(gateway, apkData); err != nil {
+		if err := adbService.CreateDockerGateway(gateway, apkData, apkID); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
-		return c.Status(fiber.StatusCreated).JSON(gateway)
+		return c.Status(fiber.StatusCreated).JSON(DockerGatewayResponse{
+			ADBGateway: gateway,
+			Ports:      adbService.GatewayPorts(gateway),
+		})
+	}
+}
+
+// createDockerGatewayBatchHandler godoc
+// @Summary Create Docker ADB gateways in bulk
+// @Description Reserve several Docker gateways of a service with distinct ports and start them in background. Gateways are named name_prefix-N.
+// @Tags adb
+// @Accept multipart/form-data
+// @Produce json
+// @Param service_code formData string true "Service code (yandex_aon, kaspersky, getcontact)"
+// @Param count formData int true "Number of gateways (1-20)"
+// @Param name_prefix formData string false "Gateway name prefix, defaults to service code"
+// @Param docker_host formData string false "Docker host to run the containers on; default host when omitted"
+// @Param apk formData file false "APK file to install on every gateway"
+// @Param apk_id formData int false "Library APK ID to install instead of upload, service default APK is used when both are omitted"
+// @Success 202 {object} services.DockerGatewayBatch
+// @Security BearerAuth
+// @Router /adb/gateways/docker/batch [post]
+func createDockerGatewayBatchHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		serviceCode := c.FormValue("service_code")
+		if serviceCode == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "service_code is required",
+			})
+		}
+
+		// Validate service code
+		validServices := map[string]bool{
+			"yandex_aon": true,
+			"kaspersky":  true,
+			"getcontact": true,
+		}
+
+		if !validServices[serviceCode] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service code",
+			})
+		}
+
+		count, err := strconv.Atoi(c.FormValue("count"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid count",
+			})
+		}
+
+		// Read APK file if provided
+		var apkData []byte
+		if file, err := c.FormFile("apk"); err == nil {
+			src, err := file.Open()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to open APK file",
+				})
+			}
+			defer src.Close()
+
+			apkData, err = io.ReadAll(src)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to read APK file",
+				})
+			}
+		}
+
+		var apkID *uint
+		if value := c.FormValue("apk_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid APK ID",
+				})
+			}
+			parsed := uint(id)
+			apkID = &parsed
+		}
+
+		batch, err := adbService.CreateDockerGatewayBatch(serviceCode, c.FormValue("name_prefix"), c.FormValue("docker_host"), count, apkData, apkID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(batch)
+	}
+}
+
+// getDockerGatewayBatchHandler godoc
+// @Summary Get Docker gateway batch
+// @Description Get creation progress of a Docker gateway batch
+// @Tags adb
+// @Accept json
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} services.DockerGatewayBatch
+// @Security BearerAuth
+// @Router /adb/gateways/docker/batch/{id} [get]
+func getDockerGatewayBatchHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		batch, err := adbService.GetDockerGatewayBatch(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Batch not found",
+			})
+		}
+
+		return c.JSON(batch)
 	}
 }
 
@@ -286,6 +449,9 @@ func updateGatewayHandler(adbService *services.ADBService) fiber.Handler {
 		if req.IsActive != nil {
 			updates["is_active"] = *req.IsActive
 		}
+		if req.MinCallIntervalSeconds != nil {
+			updates["min_call_interval_seconds"] = *req.MinCallIntervalSeconds
+		}
 
 		if err := adbService.UpdateGateway(uint(id), updates); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -301,12 +467,13 @@ func updateGatewayHandler(adbService *services.ADBService) fiber.Handler {
 
 // deleteGatewayHandler godoc
 // @Summary Delete ADB gateway
-// @Description Delete ADB gateway
+// @Description Delete ADB gateway with its Docker container, data volume and ports. With purge, screenshots of results checked on the gateway are deleted too. The response lists what was cleaned up and any step that failed and needs manual cleanup.
 // @Tags adb
 // @Accept json
 // @Produce json
 // @Param id path int true "Gateway ID"
-// @Success 200 {object} MessageResponse
+// @Param purge query bool false "Also delete screenshots taken through the gateway"
+// @Success 200 {object} services.GatewayCleanup
 // @Security BearerAuth
 // @Router /adb/gateways/{id} [delete]
 func deleteGatewayHandler(adbService *services.ADBService) fiber.Handler {
@@ -318,15 +485,14 @@ func deleteGatewayHandler(adbService *services.ADBService) fiber.Handler {
 			})
 		}
 
-		if err := adbService.DeleteGateway(uint(id)); err != nil {
+		cleanup, err := adbService.DeleteGateway(uint(id), c.QueryBool("purge"))
+		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to delete gateway",
 			})
 		}
 
-		return c.JSON(MessageResponse{
-			Message: "Gateway deleted successfully",
-		})
+		return c.JSON(cleanup)
 	}
 }
 
@@ -367,24 +533,58 @@ func updateGatewayStatusHandler(adbService *services.ADBService) fiber.Handler {
 
 // updateAllGatewayStatusesHandler godoc
 // @Summary Update all gateway statuses
-// @Description Update status for all ADB gateways
+// @Description Update status for all ADB gateways and return refresh summary
 // @Tags adb
 // @Accept json
 // @Produce json
-// @Success 200 {object} MessageResponse
+// @Success 200 {object} services.GatewayStatusSummary
 // @Security BearerAuth
 // @Router /adb/gateways/status [post]
 func updateAllGatewayStatusesHandler(adbService *services.ADBService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		if err := adbService.UpdateAllGatewayStatuses(); err != nil {
+		summary, err := adbService.UpdateAllGatewayStatuses(false)
+		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to update gateway statuses",
 			})
 		}
 
-		return c.JSON(MessageResponse{
-			Message: "All gateway statuses updated",
-		})
+		return c.JSON(summary)
+	}
+}
+
+// captureScreenshotsHandler godoc
+// @Summary Capture screenshots of several gateways
+// @Description Capture current screens of gateways concurrently for calibration. Gateways failing or not answering within timeout_seconds are reported with an error, others are still returned.
+// @Tags adb
+// @Accept json
+// @Produce json
+// @Param request body ScreenshotBatchRequest true "Gateways to capture"
+// @Success 200 {object} map[string]services.GatewayScreenshot
+// @Security BearerAuth
+// @Router /adb/screenshots/batch [post]
+func captureScreenshotsHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req ScreenshotBatchRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		screenshots, err := adbService.CaptureScreenshots(req.GatewayIDs, time.Duration(req.TimeoutSeconds)*time.Second)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidScreenshotBatch) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to capture screenshots",
+			})
+		}
+
+		return c.JSON(screenshots)
 	}
 }
 
@@ -418,6 +618,91 @@ func getDeviceInfoHandler(adbService *services.ADBService) fiber.Handler {
 	}
 }
 
+// getGatewayLogsHandler godoc
+// @Summary Get gateway logs
+// @Description Get tail of device logcat and/or Docker container logs as text
+// @Tags adb
+// @Produce plain
+// @Param id path int true "Gateway ID"
+// @Param lines query int false "Number of lines per source (default 500, max 5000)"
+// @Param source query string false "Log source: logcat, docker or all (default)"
+// @Success 200 {string} string
+// @Security BearerAuth
+// @Router /adb/gateways/{id}/logs [get]
+func getGatewayLogsHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid gateway ID",
+			})
+		}
+
+		lines := c.QueryInt("lines", services.DefaultGatewayLogLines)
+		if lines <= 0 || lines > services.MaxGatewayLogLines {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("lines must be between 1 and %d", services.MaxGatewayLogLines),
+			})
+		}
+
+		source := c.Query("source", services.GatewayLogSourceAll)
+		switch source {
+		case services.GatewayLogSourceLogcat, services.GatewayLogSourceDocker, services.GatewayLogSourceAll:
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "source must be one of: logcat, docker, all",
+			})
+		}
+
+		logs, err := adbService.GetGatewayLogs(uint(id), lines, source)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.SendString(logs)
+	}
+}
+
+// getGatewayEventsHandler godoc
+// @Summary Get gateway events
+// @Description Get latest gateway incidents such as lingering or force-stopped calls, newest first
+// @Tags adb
+// @Produce json
+// @Param id path int true "Gateway ID"
+// @Param limit query int false "Number of events (default 50, max 500)"
+// @Success 200 {array} models.GatewayEvent
+// @Security BearerAuth
+// @Router /adb/gateways/{id}/events [get]
+func getGatewayEventsHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid gateway ID",
+			})
+		}
+
+		limit := c.QueryInt("limit", 50)
+		if limit <= 0 || limit > services.MaxGatewayEvents {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("limit must be between 1 and %d", services.MaxGatewayEvents),
+			})
+		}
+
+		events, err := adbService.ListGatewayEvents(uint(id), limit)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(events)
+	}
+}
+
 // executeCommandHandler godoc
 // @Summary Execute ADB command
 // @Description Execute custom ADB command on gateway
@@ -491,12 +776,13 @@ func restartDeviceHandler(adbService *services.ADBService) fiber.Handler {
 
 // installAPKHandler godoc
 // @Summary Install APK
-// @Description Install APK on Android device
+// @Description Install uploaded APK or library APK on Android device
 // @Tags adb
 // @Accept multipart/form-data
 // @Produce json
 // @Param id path int true "Gateway ID"
-// @Param apk formData file true "APK file"
+// @Param apk formData file false "APK file"
+// @Param apk_id formData int false "Library APK ID, used instead of file"
 // @Success 200 {object} MessageResponse
 // @Security BearerAuth
 // @Router /adb/gateways/{id}/install-apk [post]
@@ -509,6 +795,26 @@ func installAPKHandler(adbService *services.ADBService) fiber.Handler {
 			})
 		}
 
+		// Install from library if APK is referenced by ID
+		if value := c.FormValue("apk_id"); value != "" {
+			apkID, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid APK ID",
+				})
+			}
+
+			if err := adbService.InstallLibraryAPK(uint(id), uint(apkID)); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+
+			return c.JSON(MessageResponse{
+				Message: "APK installed successfully",
+			})
+		}
+
 		// Get uploaded file
 		file, err := c.FormFile("apk")
 		if err != nil {
@@ -552,22 +858,45 @@ func installAPKHandler(adbService *services.ADBService) fiber.Handler {
 // @Router /adb/docker/status [get]
 func checkDockerStatusHandler(adbService *services.ADBService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		err := adbService.CheckDockerConnection()
+		dockerStatus := adbService.DockerStatus()
 		status := "connected"
 		message := "Docker daemon is accessible"
 
-		if err != nil {
+		if !dockerStatus.Connected {
 			status = "disconnected"
-			message = err.Error()
+			message = dockerStatus.LastError
 		}
 
 		return c.JSON(fiber.Map{
 			"status":  status,
 			"message": message,
+			"client":  dockerStatus,
 		})
 	}
 }
 
+// listPortAssignmentsHandler godoc
+// @Summary List port assignments
+// @Description Get configured VNC and ADB port ranges and host ports of Docker gateways on every Docker host
+// @Tags adb
+// @Accept json
+// @Produce json
+// @Success 200 {object} services.PortAssignments
+// @Security BearerAuth
+// @Router /adb/docker/ports [get]
+func listPortAssignmentsHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		assignments, err := adbService.PortAssignments()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get port assignments",
+			})
+		}
+
+		return c.JSON(assignments)
+	}
+}
+
 // listDockerContainersHandler godoc
 // @Summary List Docker containers
 // @Description List all Docker containers
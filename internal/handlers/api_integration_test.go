@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"spam-checker/internal/config"
+	"spam-checker/internal/database"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/middleware"
+	"spam-checker/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Initialize(logger.Config{Level: "error", Format: "text", Output: "stderr"}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newIntegrationApp wires auth, phone and API service routes against a fresh sqlite database
+// seeded the same way dev mode does on startup
+func newIntegrationApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	dbConfig := config.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "integration.db"),
+	}
+	db, err := database.Connect(dbConfig)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := database.SeedDevData(db); err != nil {
+		t.Fatalf("seed dev data: %v", err)
+	}
+
+	jwtConfig := config.JWTConfig{Secret: "integration-secret", ExpirationHours: 1, RefreshExpirationDays: 1}
+	authMiddleware := middleware.NewAuthMiddleware(jwtConfig)
+	idempotency := middleware.NewIdempotencyMiddleware(services.NewIdempotencyService(db))
+
+	app := fiber.New()
+	api := app.Group("/api/v1")
+	RegisterAuthRoutes(api, services.NewUserService(db), jwtConfig)
+	protected := api.Use(authMiddleware.Protect())
+	RegisterPhoneRoutes(protected, services.NewPhoneService(db), nil, nil, nil, authMiddleware, idempotency)
+	RegisterAPIServiceRoutes(protected, services.NewAPICheckService(db), authMiddleware, idempotency)
+
+	return app
+}
+
+func doJSON(t *testing.T, app *fiber.App, method, path, token string, body interface{}) (int, []byte) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp.StatusCode, data
+}
+
+func loginAsAdmin(t *testing.T, app *fiber.App) string {
+	t.Helper()
+
+	status, body := doJSON(t, app, http.MethodPost, "/api/v1/auth/login", "", LoginRequest{
+		Login:    "admin@spamchecker.com",
+		Password: "admin123",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("login status = %d, body = %s", status, body)
+	}
+
+	var resp LoginResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode login: %v", err)
+	}
+	if resp.AccessToken == "" {
+		t.Fatal("login returned empty access token")
+	}
+	return resp.AccessToken
+}
+
+func TestIntegrationLoginRejectsWrongPassword(t *testing.T) {
+	app := newIntegrationApp(t)
+
+	status, _ := doJSON(t, app, http.MethodPost, "/api/v1/auth/login", "", LoginRequest{
+		Login:    "admin@spamchecker.com",
+		Password: "wrong",
+	})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+func TestIntegrationProtectedRoutesRequireToken(t *testing.T) {
+	app := newIntegrationApp(t)
+
+	status, _ := doJSON(t, app, http.MethodGet, "/api/v1/phones", "", nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+func TestIntegrationCreateAndListPhones(t *testing.T) {
+	app := newIntegrationApp(t)
+	token := loginAsAdmin(t, app)
+
+	status, body := doJSON(t, app, http.MethodGet, "/api/v1/phones", token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("list status = %d, body = %s", status, body)
+	}
+	var before PhonesListResponse
+	if err := json.Unmarshal(body, &before); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if before.Total != 3 {
+		t.Fatalf("seeded phones = %d, want 3", before.Total)
+	}
+
+	status, body = doJSON(t, app, http.MethodPost, "/api/v1/phones", token, CreatePhoneRequest{
+		Number:      "79005550011",
+		Description: "integration",
+		IsActive:    true,
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, body = %s", status, body)
+	}
+
+	status, body = doJSON(t, app, http.MethodPost, "/api/v1/phones", token, CreatePhoneRequest{
+		Number:   "79005550011",
+		IsActive: true,
+	})
+	if status != http.StatusBadRequest {
+		t.Fatalf("duplicate create status = %d, body = %s", status, body)
+	}
+
+	status, body = doJSON(t, app, http.MethodGet, "/api/v1/phones?search=79005550011", token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("search status = %d, body = %s", status, body)
+	}
+	var after PhonesListResponse
+	if err := json.Unmarshal(body, &after); err != nil {
+		t.Fatalf("decode search: %v", err)
+	}
+	if after.Total != 1 || len(after.Phones) != 1 || after.Phones[0]["number"] != "79005550011" {
+		t.Fatalf("search = %+v, want the created phone", after)
+	}
+}
+
+func TestIntegrationAPIServiceSecretIsWriteOnly(t *testing.T) {
+	app := newIntegrationApp(t)
+	token := loginAsAdmin(t, app)
+	const secret = "top-secret-signing-key"
+
+	status, body := doJSON(t, app, http.MethodPost, "/api/v1/api-services", token, CreateAPIServiceRequest{
+		Name:        "Signed lookup",
+		ServiceCode: "kaspersky",
+		APIURL:      "https://lookup.example.com/{{.Phone}}",
+		Method:      "GET",
+		Secret:      secret,
+		Timeout:     10,
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, body = %s", status, body)
+	}
+	if bytes.Contains(body, []byte(secret)) {
+		t.Fatalf("create response leaks secret: %s", body)
+	}
+	var created struct {
+		ID        uint `json:"id"`
+		HasSecret bool `json:"has_secret"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("decode create: %v", err)
+	}
+	if !created.HasSecret {
+		t.Error("created service has_secret = false")
+	}
+
+	path := fmt.Sprintf("/api/v1/api-services/%d", created.ID)
+	for _, get := range []string{"/api/v1/api-services", "/api/v1/api-services?legacy=true", path} {
+		status, body = doJSON(t, app, http.MethodGet, get, token, nil)
+		if status != http.StatusOK {
+			t.Fatalf("GET %s status = %d, body = %s", get, status, body)
+		}
+		if bytes.Contains(body, []byte(secret)) || !bytes.Contains(body, []byte(`"has_secret":true`)) {
+			t.Errorf("GET %s exposes secret or misses has_secret: %s", get, body)
+		}
+	}
+
+	// Omitted secret is kept, empty string removes it
+	status, body = doJSON(t, app, http.MethodPut, path, token, map[string]interface{}{"name": "Signed lookup v2"})
+	if status != http.StatusOK {
+		t.Fatalf("update status = %d, body = %s", status, body)
+	}
+	if _, body = doJSON(t, app, http.MethodGet, path, token, nil); !bytes.Contains(body, []byte(`"has_secret":true`)) {
+		t.Errorf("secret dropped by update without it: %s", body)
+	}
+	status, body = doJSON(t, app, http.MethodPut, path, token, map[string]interface{}{"secret": ""})
+	if status != http.StatusOK {
+		t.Fatalf("clear secret status = %d, body = %s", status, body)
+	}
+	if _, body = doJSON(t, app, http.MethodGet, path, token, nil); !bytes.Contains(body, []byte(`"has_secret":false`)) {
+		t.Errorf("secret not removed: %s", body)
+	}
+}
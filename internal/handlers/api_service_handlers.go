@@ -13,46 +13,77 @@ import (
 
 // CreateAPIServiceRequest represents API service creation request
 type CreateAPIServiceRequest struct {
-	Name         string `json:"name" validate:"required"`
-	ServiceCode  string `json:"service_code" validate:"required"`
-	APIURL       string `json:"api_url" validate:"required"`
-	Headers      string `json:"headers"`
-	Method       string `json:"method" validate:"required,oneof=GET POST"`
-	RequestBody  string `json:"request_body"`
-	Timeout      int    `json:"timeout" validate:"min=1,max=300"`
-	KeywordPaths string `json:"keyword_paths"`
-	ResponsePath string `json:"response_path"`
+	Name          string `json:"name" validate:"required"`
+	ServiceCode   string `json:"service_code" validate:"required"`
+	Type          string `json:"type" validate:"omitempty,oneof=http telegram_bot"`
+	APIURL        string `json:"api_url" validate:"required"` // Relay base URL for telegram_bot
+	BotConfig     string `json:"bot_config"`                  // JSON, required for telegram_bot
+	Headers       string `json:"headers"`
+	Method        string `json:"method" validate:"required,oneof=GET POST"`
+	RequestBody   string `json:"request_body"` // Go template, legacy {{phone}} placeholders are supported
+	Secret        string `json:"secret"`       // Available to templates as .Secret, never returned
+	Timeout       int    `json:"timeout" validate:"min=1,max=300"`
+	KeywordPaths  string `json:"keyword_paths"`
+	ResponsePath  string `json:"response_path"`
+	Priority      int    `json:"priority"`
+	CacheTTL      int    `json:"cache_ttl" validate:"min=0"`
+	DecisionRules string `json:"decision_rules"` // JSON array of services.DecisionRule
 }
 
 // UpdateAPIServiceRequest represents API service update request
 type UpdateAPIServiceRequest struct {
-	Name         string `json:"name"`
-	ServiceCode  string `json:"service_code"`
-	APIURL       string `json:"api_url"`
-	Headers      string `json:"headers"`
-	Method       string `json:"method"`
-	RequestBody  string `json:"request_body"`
-	Timeout      *int   `json:"timeout"`
-	IsActive     *bool  `json:"is_active"`
-	KeywordPaths string `json:"keyword_paths"`
-	ResponsePath string `json:"response_path"`
+	Name          string  `json:"name"`
+	ServiceCode   string  `json:"service_code"`
+	Type          string  `json:"type"`
+	APIURL        string  `json:"api_url"`
+	BotConfig     string  `json:"bot_config"`
+	Headers       string  `json:"headers"`
+	Method        string  `json:"method"`
+	RequestBody   string  `json:"request_body"`
+	Secret        *string `json:"secret"` // Omitted keeps the secret, empty string removes it
+	Timeout       *int    `json:"timeout"`
+	IsActive      *bool   `json:"is_active"`
+	KeywordPaths  string  `json:"keyword_paths"`
+	ResponsePath  string  `json:"response_path"`
+	Priority      *int    `json:"priority"`
+	CacheTTL      *int    `json:"cache_ttl"`
+	DecisionRules *string `json:"decision_rules"` // Empty string removes rules
+}
+
+// UpdateAPIFailoverRequest represents failover settings of API services sharing a service code
+type UpdateAPIFailoverRequest struct {
+	Policy string `json:"policy" validate:"omitempty,oneof=call_all first_success"`
+	Order  []uint `json:"order"` // API service IDs, highest priority first
 }
 
 // TestAPIServiceRequest represents API service test request
 type TestAPIServiceRequest struct {
 	PhoneNumber string `json:"phone_number" validate:"required"`
+	Reply       string `json:"reply"` // telegram_bot only: parse this reply instead of querying the bot
+}
+
+// TestDecisionRulesRequest represents decision rules test request
+type TestDecisionRulesRequest struct {
+	Rules    []services.DecisionRule `json:"rules" validate:"required"`
+	Response string                  `json:"response"` // Sample raw API response
+	Text     string                  `json:"text"`     // Sample extracted or OCR text
+	Keywords []string                `json:"keywords"` // Keywords the keyword scan would have found
 }
 
 // RegisterAPIServiceRoutes registers API service routes
-func RegisterAPIServiceRoutes(api fiber.Router, apiService *services.APICheckService, authMiddleware *middleware.AuthMiddleware) {
+func RegisterAPIServiceRoutes(api fiber.Router, apiService *services.APICheckService, authMiddleware *middleware.AuthMiddleware, idempotency *middleware.IdempotencyMiddleware) {
 	apis := api.Group("/api-services")
 
 	// All API service routes require admin or supervisor role
 	apis.Use(authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor))
 
 	apis.Get("/", listAPIServicesHandler(apiService))
+	apis.Get("/stats", getAPIProviderStatsHandler(apiService))
+	apis.Get("/cache/stats", getAPICacheStatsHandler(apiService))
+	apis.Put("/failover/:code", authMiddleware.RequireRole(models.RoleAdmin), updateAPIFailoverHandler(apiService))
+	apis.Post("/decision-rules/test", testDecisionRulesHandler())
 	apis.Get("/:id", getAPIServiceHandler(apiService))
-	apis.Post("/", authMiddleware.RequireRole(models.RoleAdmin), createAPIServiceHandler(apiService))
+	apis.Post("/", authMiddleware.RequireRole(models.RoleAdmin), idempotency.Handle(), createAPIServiceHandler(apiService))
 	apis.Put("/:id", authMiddleware.RequireRole(models.RoleAdmin), updateAPIServiceHandler(apiService))
 	apis.Delete("/:id", authMiddleware.RequireRole(models.RoleAdmin), deleteAPIServiceHandler(apiService))
 	apis.Post("/:id/test", testAPIServiceHandler(apiService))
@@ -61,23 +92,36 @@ func RegisterAPIServiceRoutes(api fiber.Router, apiService *services.APICheckSer
 
 // listAPIServicesHandler godoc
 // @Summary List API services
-// @Description Get all API services
+// @Description Get API services ordered by service code and priority with pagination, with effective failover policy and position
 // @Tags api-services
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.APIService
+// @Param page query int false "Page number" default(1)
+// @Param offset query int false "Items to skip, overrides page"
+// @Param limit query int false "Items per page" default(50)
+// @Param legacy query bool false "Respond with bare array of all API services, kept for one release"
+// @Success 200 {object} ListResponse{data=[]models.APIService}
 // @Security BearerAuth
 // @Router /api-services [get]
 func listAPIServicesHandler(apiService *services.APICheckService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		services, err := apiService.ListAPIServices()
+		legacy := legacyList(c)
+		pagination := parsePagination(c)
+		if legacy {
+			pagination = Pagination{}
+		}
+
+		services, total, err := apiService.ListAPIServicesPage(pagination.Offset, pagination.Limit)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get API services",
 			})
 		}
 
-		return c.JSON(services)
+		if legacy {
+			return c.JSON(services)
+		}
+		return sendList(c, services, total, pagination)
 	}
 }
 
@@ -113,7 +157,7 @@ func getAPIServiceHandler(apiService *services.APICheckService) fiber.Handler {
 
 // createAPIServiceHandler godoc
 // @Summary Create API service
-// @Description Create a new API service
+// @Description Create a new API service. Type telegram_bot queries a Telegram lookup bot through relay at api_url, see bot_config.
 // @Tags api-services
 // @Accept json
 // @Produce json
@@ -145,6 +189,12 @@ func createAPIServiceHandler(apiService *services.APICheckService) fiber.Handler
 			}
 		}
 
+		if req.CacheTTL < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "cache_ttl must not be negative",
+			})
+		}
+
 		// Set default timeout if not provided
 		timeout := req.Timeout
 		if timeout == 0 {
@@ -157,17 +207,27 @@ func createAPIServiceHandler(apiService *services.APICheckService) fiber.Handler
 			headers = "{}"
 		}
 
+		if req.Type == "" {
+			req.Type = models.APIServiceTypeHTTP
+		}
+
 		service := &models.APIService{
-			Name:         req.Name,
-			ServiceCode:  req.ServiceCode,
-			APIURL:       req.APIURL,
-			Headers:      headers,
-			Method:       req.Method,
-			RequestBody:  req.RequestBody,
-			Timeout:      timeout,
-			IsActive:     true,
-			KeywordPaths: req.KeywordPaths,
-			ResponsePath: req.ResponsePath,
+			Name:          req.Name,
+			ServiceCode:   req.ServiceCode,
+			Type:          req.Type,
+			APIURL:        req.APIURL,
+			BotConfig:     req.BotConfig,
+			Headers:       headers,
+			Method:        req.Method,
+			RequestBody:   req.RequestBody,
+			Secret:        req.Secret,
+			Timeout:       timeout,
+			IsActive:      true,
+			KeywordPaths:  req.KeywordPaths,
+			ResponsePath:  req.ResponsePath,
+			Priority:      req.Priority,
+			CacheTTL:      req.CacheTTL,
+			DecisionRules: req.DecisionRules,
 		}
 
 		if err := apiService.CreateAPIService(service); err != nil {
@@ -226,9 +286,15 @@ func updateAPIServiceHandler(apiService *services.APICheckService) fiber.Handler
 			}
 			updates["service_code"] = req.ServiceCode
 		}
+		if req.Type != "" {
+			updates["type"] = req.Type
+		}
 		if req.APIURL != "" {
 			updates["api_url"] = req.APIURL
 		}
+		if req.BotConfig != "" {
+			updates["bot_config"] = req.BotConfig
+		}
 		if req.Headers != "" {
 			updates["headers"] = req.Headers
 		}
@@ -238,6 +304,9 @@ func updateAPIServiceHandler(apiService *services.APICheckService) fiber.Handler
 		if req.RequestBody != "" {
 			updates["request_body"] = req.RequestBody
 		}
+		if req.Secret != nil {
+			updates["secret"] = *req.Secret
+		}
 		if req.Timeout != nil {
 			updates["timeout"] = *req.Timeout
 		}
@@ -250,6 +319,20 @@ func updateAPIServiceHandler(apiService *services.APICheckService) fiber.Handler
 		if req.ResponsePath != "" {
 			updates["response_path"] = req.ResponsePath
 		}
+		if req.Priority != nil {
+			updates["priority"] = *req.Priority
+		}
+		if req.CacheTTL != nil {
+			if *req.CacheTTL < 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "cache_ttl must not be negative",
+				})
+			}
+			updates["cache_ttl"] = *req.CacheTTL
+		}
+		if req.DecisionRules != nil {
+			updates["decision_rules"] = *req.DecisionRules
+		}
 
 		if err := apiService.UpdateAPIService(uint(id), updates); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -296,7 +379,8 @@ func deleteAPIServiceHandler(apiService *services.APICheckService) fiber.Handler
 
 // testAPIServiceHandler godoc
 // @Summary Test API service
-// @Description Test API service with a phone number
+// @Description Test API service with a phone number. A telegram_bot service parses given reply without querying the bot,
+// @Description otherwise queries it if its rate limit allows and responds with retry_after seconds if not.
 // @Tags api-services
 // @Accept json
 // @Produce json
@@ -321,7 +405,7 @@ func testAPIServiceHandler(apiService *services.APICheckService) fiber.Handler {
 			})
 		}
 
-		result, err := apiService.TestAPIService(uint(id), req.PhoneNumber)
+		result, err := apiService.TestAPIService(uint(id), req.PhoneNumber, req.Reply)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": err.Error(),
@@ -379,3 +463,127 @@ func toggleAPIServiceHandler(apiService *services.APICheckService) fiber.Handler
 		})
 	}
 }
+
+// updateAPIFailoverHandler godoc
+// @Summary Update API failover
+// @Description Set policy (call_all or first_success) and priority order of API services sharing a service code
+// @Tags api-services
+// @Accept json
+// @Produce json
+// @Param code path string true "Service code"
+// @Param request body UpdateAPIFailoverRequest true "Failover settings"
+// @Success 200 {array} models.APIService
+// @Security BearerAuth
+// @Router /api-services/failover/{code} [put]
+func updateAPIFailoverHandler(apiService *services.APICheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		code := c.Params("code")
+
+		var req UpdateAPIFailoverRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		if err := apiService.UpdateFailover(code, req.Policy, req.Order); err != nil {
+			if err.Error() == "spam service not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		list, err := apiService.ListAPIServices()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get API services",
+			})
+		}
+
+		group := make([]models.APIService, 0)
+		for _, service := range list {
+			if service.ServiceCode == code {
+				group = append(group, service)
+			}
+		}
+
+		return c.JSON(group)
+	}
+}
+
+// testDecisionRulesHandler godoc
+// @Summary Test decision rules
+// @Description Evaluate decision rules against a sample response or text without saving anything. Every rule is evaluated with intermediate values of its expressions, rules that fail report their error.
+// @Tags api-services
+// @Accept json
+// @Produce json
+// @Param request body TestDecisionRulesRequest true "Rules and sample input"
+// @Success 200 {object} services.DecisionOutcome
+// @Security BearerAuth
+// @Router /api-services/decision-rules/test [post]
+func testDecisionRulesHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req TestDecisionRulesRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		outcome, err := services.TestDecisionRules(req.Rules, req.Response, req.Text, req.Keywords)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(outcome)
+	}
+}
+
+// getAPIProviderStatsHandler godoc
+// @Summary Get API provider statistics
+// @Description Get checks attributed to each API service
+// @Tags api-services
+// @Accept json
+// @Produce json
+// @Param days query int false "Number of days" default(30)
+// @Success 200 {array} services.APIProviderStats
+// @Security BearerAuth
+// @Router /api-services/stats [get]
+func getAPIProviderStatsHandler(apiService *services.APICheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		days := c.QueryInt("days", 30)
+		if days < 1 {
+			days = 30
+		}
+
+		stats, err := apiService.GetProviderStats(days)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get provider statistics",
+			})
+		}
+
+		return c.JSON(stats)
+	}
+}
+
+// getAPICacheStatsHandler godoc
+// @Summary Get API response cache statistics
+// @Description Get response cache hits, misses and live entries per API service since startup
+// @Tags api-services
+// @Accept json
+// @Produce json
+// @Success 200 {array} services.APICacheStats
+// @Security BearerAuth
+// @Router /api-services/cache/stats [get]
+func getAPICacheStatsHandler(apiService *services.APICheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(apiService.GetCacheStats())
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+	"spam-checker/internal/middleware"
+	"spam-checker/internal/models"
+	"spam-checker/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StartAPKUpdateRequest represents APK update start request
+type StartAPKUpdateRequest struct {
+	ServiceCode string `json:"service_code" validate:"required"`
+}
+
+// RegisterAPKRoutes registers APK library routes
+func RegisterAPKRoutes(api fiber.Router, apkService *services.APKService, adbService *services.ADBService, authMiddleware *middleware.AuthMiddleware) {
+	apks := api.Group("/apks")
+
+	apks.Use(authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor))
+
+	apks.Get("/", listAPKsHandler(apkService))
+	apks.Get("/updates", listAPKUpdatesHandler(adbService))
+	apks.Get("/updates/:id", getAPKUpdateHandler(adbService))
+	apks.Post("/updates", authMiddleware.RequireRole(models.RoleAdmin), startAPKUpdateHandler(adbService))
+	apks.Post("/", authMiddleware.RequireRole(models.RoleAdmin), uploadAPKHandler(apkService))
+	apks.Post("/:id/default", authMiddleware.RequireRole(models.RoleAdmin), setDefaultAPKHandler(apkService))
+	apks.Delete("/:id", authMiddleware.RequireRole(models.RoleAdmin), deleteAPKHandler(apkService))
+}
+
+// listAPKsHandler godoc
+// @Summary List APKs
+// @Description Get uploaded APK builds, newest version first
+// @Tags apks
+// @Accept json
+// @Produce json
+// @Param service_code query string false "Filter by service code"
+// @Success 200 {array} models.APKFile
+// @Security BearerAuth
+// @Router /apks [get]
+func listAPKsHandler(apkService *services.APKService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		apks, err := apkService.ListAPKs(c.Query("service_code"))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list APKs",
+			})
+		}
+
+		return c.JSON(apks)
+	}
+}
+
+// uploadAPKHandler godoc
+// @Summary Upload APK
+// @Description Upload APK build for a service. Package name must match the service app package. First build of a service becomes default.
+// @Tags apks
+// @Accept multipart/form-data
+// @Produce json
+// @Param service_code formData string true "Service code"
+// @Param apk formData file true "APK file"
+// @Success 201 {object} models.APKFile
+// @Security BearerAuth
+// @Router /apks [post]
+func uploadAPKHandler(apkService *services.APKService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		serviceCode := c.FormValue("service_code")
+		if serviceCode == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "service_code is required",
+			})
+		}
+
+		file, err := c.FormFile("apk")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "APK file is required",
+			})
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to open APK file",
+			})
+		}
+		defer src.Close()
+
+		apk, err := apkService.UploadAPK(serviceCode, file.Filename, src, middleware.GetUserID(c))
+		if err != nil {
+			if err.Error() == "service not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Service not found",
+				})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(apk)
+	}
+}
+
+// setDefaultAPKHandler godoc
+// @Summary Set default APK
+// @Description Make APK the build installed on new gateways of its service
+// @Tags apks
+// @Accept json
+// @Produce json
+// @Param id path int true "APK ID"
+// @Success 200 {object} MessageResponse
+// @Security BearerAuth
+// @Router /apks/{id}/default [post]
+func setDefaultAPKHandler(apkService *services.APKService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid APK ID",
+			})
+		}
+
+		if err := apkService.SetDefaultAPK(uint(id)); err != nil {
+			if err.Error() == "apk not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "APK not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to set default APK",
+			})
+		}
+
+		return c.JSON(MessageResponse{
+			Message: "Default APK updated successfully",
+		})
+	}
+}
+
+// deleteAPKHandler godoc
+// @Summary Delete APK
+// @Description Delete APK record and its file
+// @Tags apks
+// @Accept json
+// @Produce json
+// @Param id path int true "APK ID"
+// @Success 200 {object} MessageResponse
+// @Security BearerAuth
+// @Router /apks/{id} [delete]
+func deleteAPKHandler(apkService *services.APKService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid APK ID",
+			})
+		}
+
+		if err := apkService.DeleteAPK(uint(id)); err != nil {
+			if err.Error() == "apk not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "APK not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to delete APK",
+			})
+		}
+
+		return c.JSON(MessageResponse{
+			Message: "APK deleted successfully",
+		})
+	}
+}
+
+// startAPKUpdateHandler godoc
+// @Summary Start APK update
+// @Description Download the latest APK of a service from app.apk_source_url of its config and install it on gateways of the service in background. Gateways already having this or a newer version are skipped. Poll progress with GET /apks/updates/{id}. Returns 409 with the running job if an update of the service has not finished yet.
+// @Tags apks
+// @Accept json
+// @Produce json
+// @Param request body StartAPKUpdateRequest true "Service to update"
+// @Success 202 {object} models.APKUpdateJob
+// @Failure 409 {object} map[string]interface{} "Update already running"
+// @Security BearerAuth
+// @Router /apks/updates [post]
+func startAPKUpdateHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req StartAPKUpdateRequest
+		if err := c.BodyParser(&req); err != nil || req.ServiceCode == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "service_code is required",
+			})
+		}
+
+		job, err := adbService.StartAPKUpdate(req.ServiceCode, middleware.GetUserID(c))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrAPKUpdateRunning):
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "APK update is already running",
+					"job":   job,
+				})
+			case errors.Is(err, services.ErrAPKSourceNotConfigured):
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			case err.Error() == "service not found":
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Service not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to start APK update",
+			})
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(job)
+	}
+}
+
+// listAPKUpdatesHandler godoc
+// @Summary List APK updates
+// @Description Get recent APK update jobs, newest first
+// @Tags apks
+// @Accept json
+// @Produce json
+// @Param service_code query string false "Filter by service code"
+// @Param limit query int false "Maximum jobs" default(50)
+// @Success 200 {array} models.APKUpdateJob
+// @Security BearerAuth
+// @Router /apks/updates [get]
+func listAPKUpdatesHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := c.QueryInt("limit", 50)
+		if limit < 1 || limit > 500 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "limit must be between 1 and 500",
+			})
+		}
+
+		jobs, err := adbService.ListAPKUpdates(c.Query("service_code"), limit)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list APK updates",
+			})
+		}
+
+		return c.JSON(jobs)
+	}
+}
+
+// getAPKUpdateHandler godoc
+// @Summary Get APK update
+// @Description Get APK update job with the result of every gateway
+// @Tags apks
+// @Accept json
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} services.APKUpdateJobDetails
+// @Failure 404 {object} map[string]interface{} "Job not found"
+// @Security BearerAuth
+// @Router /apks/updates/{id} [get]
+func getAPKUpdateHandler(adbService *services.ADBService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid job ID",
+			})
+		}
+
+		details, err := adbService.GetAPKUpdate(uint(id))
+		if err != nil {
+			if err.Error() == "APK update not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "APK update not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get APK update",
+			})
+		}
+
+		return c.JSON(details)
+	}
+}
@@ -11,11 +11,14 @@ import (
 
 // GetCleanNumberRequest represents request for getting clean number
 type GetCleanNumberRequest struct {
-	Purpose  string                       `json:"purpose,omitempty"`
-	Metadata *services.AllocationMetadata `json:"metadata,omitempty"`
+	Purpose      string                       `json:"purpose,omitempty"`
+	Metadata     *services.AllocationMetadata `json:"metadata,omitempty"`
+	Verification bool                         `json:"verification,omitempty"` // Add summary of clean verdicts to the response
 }
 
 // GetAllocationHistoryResponse represents allocation history response
+//
+// Deprecated: history returns ListResponse unless legacy=true is requested
 type GetAllocationHistoryResponse struct {
 	Allocations []AllocationInfo `json:"allocations"`
 	Total       int              `json:"total"`
@@ -32,6 +35,23 @@ type AllocationInfo struct {
 	Metadata    string `json:"metadata,omitempty"`
 }
 
+// allocationInfos formats allocations for response
+func allocationInfos(allocations []models.NumberAllocation) []AllocationInfo {
+	allocationInfo := make([]AllocationInfo, len(allocations))
+	for i, alloc := range allocations {
+		allocationInfo[i] = AllocationInfo{
+			ID:          alloc.ID,
+			PhoneNumber: alloc.PhoneNumber.Number,
+			PhoneID:     alloc.PhoneNumberID,
+			AllocatedTo: alloc.AllocatedTo,
+			Purpose:     alloc.Purpose,
+			AllocatedAt: alloc.AllocatedAt.Format("2006-01-02 15:04:05"),
+			Metadata:    alloc.Metadata,
+		}
+	}
+	return allocationInfo
+}
+
 // RegisterAsteriskRoutes registers Asterisk integration routes
 func RegisterAsteriskRoutes(api fiber.Router, asteriskService *services.AsteriskService, authMiddleware *middleware.AuthMiddleware) {
 	asterisk := api.Group("/asterisk")
@@ -53,6 +73,7 @@ func RegisterAsteriskRoutes(api fiber.Router, asteriskService *services.Asterisk
 // @Tags asterisk
 // @Accept json
 // @Produce json
+// @Param verification query bool false "Add latest clean verdicts per service, their ages and confidence"
 // @Param request body GetCleanNumberRequest false "Optional allocation details"
 // @Success 200 {object} services.CleanNumberResponse
 // @Failure 404 {object} map[string]interface{} "No clean numbers available"
@@ -80,7 +101,8 @@ func getCleanNumberHandler(asteriskService *services.AsteriskService) fiber.Hand
 		req.Metadata.UserAgent = string(c.Request().Header.UserAgent())
 
 		// Get clean number
-		response, err := asteriskService.GetCleanNumber(clientIP, purpose, req.Metadata)
+		withVerification := req.Verification || c.QueryBool("verification")
+		response, err := asteriskService.GetCleanNumber(clientIP, purpose, req.Metadata, withVerification)
 		if err != nil {
 			statusCode := fiber.StatusInternalServerError
 			errorMsg := "Failed to allocate clean number"
@@ -107,8 +129,11 @@ func getCleanNumberHandler(asteriskService *services.AsteriskService) fiber.Hand
 // @Accept json
 // @Produce json
 // @Param phone_id path int true "Phone ID"
-// @Param limit query int false "Limit results" default(100)
-// @Success 200 {object} GetAllocationHistoryResponse
+// @Param page query int false "Page number" default(1)
+// @Param offset query int false "Items to skip, overrides page"
+// @Param limit query int false "Items per page" default(50)
+// @Param legacy query bool false "Respond with GetAllocationHistoryResponse, kept for one release"
+// @Success 200 {object} ListResponse{data=[]AllocationInfo}
 // @Security BearerAuth
 // @Router /asterisk/allocation-history/{phone_id} [get]
 func getAllocationHistoryHandler(asteriskService *services.AsteriskService) fiber.Handler {
@@ -120,36 +145,32 @@ func getAllocationHistoryHandler(asteriskService *services.AsteriskService) fibe
 			})
 		}
 
-		limit, _ := strconv.Atoi(c.Query("limit", "100"))
-		if limit <= 0 || limit > 1000 {
-			limit = 100
+		pagination := parsePagination(c)
+		legacy := legacyList(c)
+		if legacy {
+			// Legacy shape returned up to limit latest allocations, 100 by default
+			limit, _ := strconv.Atoi(c.Query("limit", "100"))
+			if limit <= 0 || limit > 1000 {
+				limit = 100
+			}
+			pagination = Pagination{Page: 1, Limit: limit}
 		}
 
-		allocations, err := asteriskService.GetAllocationHistory(uint(phoneID), limit)
+		allocations, total, err := asteriskService.GetAllocationHistory(uint(phoneID), pagination.Offset, pagination.Limit)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get allocation history",
 			})
 		}
 
-		// Format response
-		allocationInfo := make([]AllocationInfo, len(allocations))
-		for i, alloc := range allocations {
-			allocationInfo[i] = AllocationInfo{
-				ID:          alloc.ID,
-				PhoneNumber: alloc.PhoneNumber.Number,
-				PhoneID:     alloc.PhoneNumberID,
-				AllocatedTo: alloc.AllocatedTo,
-				Purpose:     alloc.Purpose,
-				AllocatedAt: alloc.AllocatedAt.Format("2006-01-02 15:04:05"),
-				Metadata:    alloc.Metadata,
-			}
+		allocationInfo := allocationInfos(allocations)
+		if legacy {
+			return c.JSON(GetAllocationHistoryResponse{
+				Allocations: allocationInfo,
+				Total:       len(allocationInfo),
+			})
 		}
-
-		return c.JSON(GetAllocationHistoryResponse{
-			Allocations: allocationInfo,
-			Total:       len(allocationInfo),
-		})
+		return sendList(c, allocationInfo, total, pagination)
 	}
 }
 
@@ -188,7 +209,11 @@ func getAllocationStatsHandler(asteriskService *services.AsteriskService) fiber.
 // @Accept json
 // @Produce json
 // @Param minutes query int false "Minutes to look back" default(60)
-// @Success 200 {array} AllocationInfo
+// @Param page query int false "Page number" default(1)
+// @Param offset query int false "Items to skip, overrides page"
+// @Param limit query int false "Items per page" default(50)
+// @Param legacy query bool false "Respond with bare array of all allocations, kept for one release"
+// @Success 200 {object} ListResponse{data=[]AllocationInfo}
 // @Security BearerAuth
 // @Router /asterisk/current-allocations [get]
 func getCurrentAllocationsHandler(asteriskService *services.AsteriskService) fiber.Handler {
@@ -198,28 +223,24 @@ func getCurrentAllocationsHandler(asteriskService *services.AsteriskService) fib
 			minutes = 60
 		}
 
-		allocations, err := asteriskService.GetCurrentAllocations(minutes)
+		legacy := legacyList(c)
+		pagination := parsePagination(c)
+		if legacy {
+			pagination = Pagination{}
+		}
+
+		allocations, total, err := asteriskService.GetCurrentAllocations(minutes, pagination.Offset, pagination.Limit)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get current allocations",
 			})
 		}
 
-		// Format response
-		allocationInfo := make([]AllocationInfo, len(allocations))
-		for i, alloc := range allocations {
-			allocationInfo[i] = AllocationInfo{
-				ID:          alloc.ID,
-				PhoneNumber: alloc.PhoneNumber.Number,
-				PhoneID:     alloc.PhoneNumberID,
-				AllocatedTo: alloc.AllocatedTo,
-				Purpose:     alloc.Purpose,
-				AllocatedAt: alloc.AllocatedAt.Format("2006-01-02 15:04:05"),
-				Metadata:    alloc.Metadata,
-			}
+		allocationInfo := allocationInfos(allocations)
+		if legacy {
+			return c.JSON(allocationInfo)
 		}
-
-		return c.JSON(allocationInfo)
+		return sendList(c, allocationInfo, total, pagination)
 	}
 }
 
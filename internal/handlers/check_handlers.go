@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
 	"spam-checker/internal/middleware"
 	"spam-checker/internal/models"
 	"spam-checker/internal/services"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -26,6 +32,8 @@ type CheckStartedResponse struct {
 }
 
 // CheckResultsResponse represents check results response
+//
+// Deprecated: results return ListResponse unless legacy=true is requested
 type CheckResultsResponse struct {
 	Results []models.CheckResult `json:"results"`
 	Count   int                  `json:"count"`
@@ -34,18 +42,31 @@ type CheckResultsResponse struct {
 // LatestResultsResponse represents latest results response
 type LatestResultsResponse struct {
 	Results []map[string]interface{} `json:"results"`
+	Total   int64                    `json:"total"`
+	Page    int                      `json:"page,omitempty"`
+	Limit   int                      `json:"limit,omitempty"`
 }
 
 // RegisterCheckRoutes registers check routes
-func RegisterCheckRoutes(api fiber.Router, checkService *services.CheckService, authMiddleware *middleware.AuthMiddleware) {
+func RegisterCheckRoutes(api fiber.Router, checkService *services.CheckService, quotaService *services.RealtimeQuotaService, authMiddleware *middleware.AuthMiddleware) {
 	checks := api.Group("/checks")
 
 	checks.Post("/phone/:id", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), checkPhoneHandler(checkService))
 	checks.Post("/all", authMiddleware.RequireRole(models.RoleAdmin), checkAllPhonesHandler(checkService))
-	checks.Post("/realtime", checkRealtimeHandler(checkService))
+	checks.Post("/recheck-spam", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), recheckSpamPhonesHandler(checkService))
+	checks.Post("/retry-errors", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), retryFailedResultsHandler(checkService))
+	checks.Get("/jobs/:id", getCheckJobHandler(checkService))
+	checks.Post("/realtime", checkRealtimeHandler(checkService, quotaService))
+	checks.Get("/plan", getCheckPlanHandler(checkService))
 	checks.Get("/results", getCheckResultsHandler(checkService))
 	checks.Get("/latest", getLatestResultsHandler(checkService))
 	checks.Get("/screenshot/:id", getScreenshotHandler(checkService))
+	checks.Get("/results/:id/evaluation", getCheckEvaluationHandler(checkService))
+	checks.Get("/results/:id/timeline", getCheckTimelineHandler(checkService))
+	checks.Get("/results/:id/raw", authMiddleware.RequireRole(models.RoleAdmin), getCheckResultRawHandler(checkService))
+	checks.Post("/import", authMiddleware.RequireRole(models.RoleAdmin), importHistoricalResultsHandler(checkService))
+	checks.Delete("/import", authMiddleware.RequireRole(models.RoleAdmin), deleteImportedResultsHandler(checkService))
+	checks.Get("/debug/locks", authMiddleware.RequireRole(models.RoleAdmin), getLockStatsHandler(checkService))
 }
 
 // checkPhoneHandler godoc
@@ -101,17 +122,119 @@ func checkAllPhonesHandler(checkService *services.CheckService) fiber.Handler {
 	}
 }
 
+// recheckSpamPhonesHandler godoc
+// @Summary Re-check spam phones
+// @Description Start background check of active phones whose overall verdict is spam. Poll progress with GET /checks/jobs/{id}. Returns 409 with the running job if a re-check has not finished yet.
+// @Tags checks
+// @Accept json
+// @Produce json
+// @Success 202 {object} models.CheckJob
+// @Failure 409 {object} map[string]interface{} "Re-check already running"
+// @Security BearerAuth
+// @Router /checks/recheck-spam [post]
+func recheckSpamPhonesHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		job, err := checkService.RecheckSpamPhones(middleware.GetUserID(c))
+		if err != nil {
+			if errors.Is(err, services.ErrCheckJobRunning) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Spam re-check is already running",
+					"job":   job,
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to start spam re-check",
+			})
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(job)
+	}
+}
+
+// retryFailedResultsHandler godoc
+// @Summary Retry failed results
+// @Description Start background re-check of phones whose latest result of a service is error or inconclusive, on those services only. Poll progress with GET /checks/jobs/{id}. Returns 409 with the running job if a retry has not finished yet.
+// @Tags checks
+// @Accept json
+// @Produce json
+// @Param service_code query string false "Retry only results of this service"
+// @Success 202 {object} models.CheckJob
+// @Failure 404 {object} map[string]interface{} "Service not found"
+// @Failure 409 {object} map[string]interface{} "Retry already running"
+// @Security BearerAuth
+// @Router /checks/retry-errors [post]
+func retryFailedResultsHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		job, err := checkService.RetryFailedResults(c.Query("service_code"), middleware.GetUserID(c))
+		if err != nil {
+			if errors.Is(err, services.ErrCheckJobRunning) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Retry of failed results is already running",
+					"job":   job,
+				})
+			}
+			if err.Error() == "service not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to start retry of failed results",
+			})
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(job)
+	}
+}
+
+// getCheckJobHandler godoc
+// @Summary Get check job
+// @Description Get progress of background check job
+// @Tags checks
+// @Accept json
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.CheckJob
+// @Failure 404 {object} map[string]interface{} "Job not found"
+// @Security BearerAuth
+// @Router /checks/jobs/{id} [get]
+func getCheckJobHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid job ID",
+			})
+		}
+
+		job, err := checkService.GetCheckJob(uint(id))
+		if err != nil {
+			if err.Error() == "check job not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Check job not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get check job",
+			})
+		}
+
+		return c.JSON(job)
+	}
+}
+
 // checkRealtimeHandler godoc
 // @Summary Check realtime
-// @Description Check phone number in real-time (without saving)
+// @Description Check phone number in real-time (without saving). Counts against the user's daily and per-minute realtime quota, cached results cost less.
 // @Tags checks
 // @Accept json
 // @Produce json
 // @Param request body CheckPhoneRequest true "Phone number to check"
 // @Success 200 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /checks/realtime [post]
-func checkRealtimeHandler(checkService *services.CheckService) fiber.Handler {
+func checkRealtimeHandler(checkService *services.CheckService, quotaService *services.RealtimeQuotaService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req CheckPhoneRequest
 		if err := c.BodyParser(&req); err != nil {
@@ -120,7 +243,35 @@ func checkRealtimeHandler(checkService *services.CheckService) fiber.Handler {
 			})
 		}
 
+		reservation, quota, err := quotaService.Reserve(middleware.GetUserID(c))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check realtime quota",
+			})
+		}
+		if reservation == nil {
+			window := quota.Daily
+			if quota.Exceeded == services.RealtimeQuotaWindowPerMinute {
+				window = quota.PerMinute
+			}
+			setRealtimeQuotaHeaders(c, quota)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(time.Until(window.ResetAt).Seconds()))))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":     "Realtime check quota exceeded",
+				"exceeded":  quota.Exceeded,
+				"limit":     window.Limit,
+				"used":      window.Used,
+				"remaining": window.Remaining,
+				"reset_at":  window.ResetAt,
+				"quota":     quota,
+			})
+		}
+
 		result, err := checkService.CheckPhoneRealtime(req.PhoneNumber)
+		cached, _ := result["cached"].(bool)
+		if quota := quotaService.Commit(reservation, err == nil && !cached); quota != nil {
+			setRealtimeQuotaHeaders(c, quota)
+		}
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": err.Error(),
@@ -131,6 +282,52 @@ func checkRealtimeHandler(checkService *services.CheckService) fiber.Handler {
 	}
 }
 
+// setRealtimeQuotaHeaders reports remaining daily realtime quota
+func setRealtimeQuotaHeaders(c *fiber.Ctx, quota *services.RealtimeQuotaStatus) {
+	if quota.Daily.Limit == 0 {
+		return
+	}
+	c.Set("X-RateLimit-Limit", strconv.Itoa(quota.Daily.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.FormatFloat(quota.Daily.Remaining, 'f', -1, 64))
+	c.Set("X-RateLimit-Reset", strconv.FormatInt(quota.Daily.ResetAt.Unix(), 10))
+}
+
+// getCheckPlanHandler godoc
+// @Summary Get check plan
+// @Description Show which gateways and API services a check of the phone would use with the current configuration, without running it. Targets that are not used carry the reason.
+// @Tags checks
+// @Accept json
+// @Produce json
+// @Param phone query string true "Phone number"
+// @Param mode query string false "Check mode override (adb_only, api_only, both), check_mode setting when omitted"
+// @Param service query string false "Limit to spam service code"
+// @Success 200 {object} services.CheckPlan
+// @Failure 400 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /checks/plan [get]
+func getCheckPlanHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		phone := strings.TrimSpace(c.Query("phone"))
+		if phone == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "phone is required",
+			})
+		}
+
+		plan, err := checkService.PlanCheck(phone, services.PhoneCheckOptions{
+			Mode:        models.CheckMode(c.Query("mode")),
+			ServiceCode: c.Query("service"),
+		})
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(plan)
+	}
+}
+
 // getCheckResultsHandler godoc
 // @Summary Get check results
 // @Description Get check results with filters
@@ -139,51 +336,187 @@ func checkRealtimeHandler(checkService *services.CheckService) fiber.Handler {
 // @Produce json
 // @Param phone_id query int false "Filter by phone ID"
 // @Param service_id query int false "Filter by service ID"
-// @Param limit query int false "Limit results" default(50)
-// @Success 200 {object} CheckResultsResponse
+// @Param gateway_id query int false "Filter by ADB gateway that produced the result"
+// @Param api_service_id query int false "Filter by API service that produced the result"
+// @Param source query string false "Filter by source (check, import)"
+// @Param status query string false "Filter by status (spam, clean, inconclusive, error)"
+// @Param keyword query string false "Only results that found this spam keyword, exact match"
+// @Param checked_after query string false "Only results checked at or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param checked_before query string false "Only results checked before this time (RFC3339 or YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param offset query int false "Items to skip, overrides page"
+// @Param limit query int false "Items per page" default(50)
+// @Param legacy query bool false "Respond with CheckResultsResponse, kept for one release"
+// @Success 200 {object} ListResponse{data=[]models.CheckResult}
 // @Security BearerAuth
 // @Router /checks/results [get]
 func getCheckResultsHandler(checkService *services.CheckService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		phoneID, _ := strconv.ParseUint(c.Query("phone_id", "0"), 10, 32)
 		serviceID, _ := strconv.ParseUint(c.Query("service_id", "0"), 10, 32)
-		limit, _ := strconv.Atoi(c.Query("limit", "50"))
+		gatewayID, _ := strconv.ParseUint(c.Query("gateway_id", "0"), 10, 32)
+		apiServiceID, _ := strconv.ParseUint(c.Query("api_service_id", "0"), 10, 32)
+		pagination := parsePagination(c)
 
-		results, err := checkService.GetCheckResults(uint(phoneID), uint(serviceID), limit)
+		filter := services.CheckResultsFilter{
+			PhoneID:      uint(phoneID),
+			ServiceID:    uint(serviceID),
+			GatewayID:    uint(gatewayID),
+			APIServiceID: uint(apiServiceID),
+			Source:       c.Query("source"),
+			Status:       c.Query("status"),
+			Keyword:      strings.TrimSpace(c.Query("keyword")),
+			Offset:       pagination.Offset,
+			Limit:        pagination.Limit,
+		}
+		for param, target := range map[string]**time.Time{
+			"checked_after":  &filter.CheckedAfter,
+			"checked_before": &filter.CheckedBefore,
+		} {
+			value := c.Query(param)
+			if value == "" {
+				continue
+			}
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				parsed, err = time.Parse("2006-01-02", value)
+			}
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid " + param + ", use RFC3339 or YYYY-MM-DD",
+				})
+			}
+			*target = &parsed
+		}
+
+		results, total, err := checkService.GetCheckResults(filter)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get results",
 			})
 		}
 
-		return c.JSON(CheckResultsResponse{
-			Results: results,
-			Count:   len(results),
-		})
+		if legacyList(c) {
+			return c.JSON(CheckResultsResponse{
+				Results: results,
+				Count:   len(results),
+			})
+		}
+		return sendList(c, results, total, pagination)
 	}
 }
 
 // getLatestResultsHandler godoc
 // @Summary Get latest results
-// @Description Get latest check results for all phones
+// @Description Get latest check result of every phone and service as JSON or CSV
 // @Tags checks
 // @Accept json
-// @Produce json
+// @Produce json,text/csv
+// @Param format query string false "Output format (json, csv)" default(json)
+// @Param columns query string false "Comma-separated columns: phone_id, phone_number, description, service_id, service_name, is_spam, status, found_keywords, checked_at, gateway_id, gateway_name, api_service_id, api_service_name"
+// @Param checked_after query string false "Only results checked after this time (RFC3339 or YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page, all results when omitted"
 // @Success 200 {object} LatestResultsResponse
 // @Security BearerAuth
 // @Router /checks/latest [get]
 func getLatestResultsHandler(checkService *services.CheckService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		results, err := checkService.GetLatestResults()
+		format := c.Query("format", "json")
+		if format != "json" && format != "csv" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid format, use json or csv",
+			})
+		}
+
+		var filter services.LatestResultsFilter
+
+		if columnsStr := c.Query("columns"); columnsStr != "" {
+			for _, column := range strings.Split(columnsStr, ",") {
+				column = strings.TrimSpace(column)
+				if !services.IsLatestResultColumn(column) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"error": "Unknown column: " + column,
+					})
+				}
+				filter.Columns = append(filter.Columns, column)
+			}
+		}
+
+		if checkedAfterStr := c.Query("checked_after"); checkedAfterStr != "" {
+			checkedAfter, err := time.Parse(time.RFC3339, checkedAfterStr)
+			if err != nil {
+				checkedAfter, err = time.Parse("2006-01-02", checkedAfterStr)
+			}
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid checked_after, use RFC3339 or YYYY-MM-DD",
+				})
+			}
+			filter.CheckedAfter = &checkedAfter
+		}
+
+		page, _ := strconv.Atoi(c.Query("page", "1"))
+		limit, _ := strconv.Atoi(c.Query("limit", "0"))
+		if page < 1 {
+			page = 1
+		}
+		if limit < 0 {
+			limit = 0
+		}
+		if limit > 10000 {
+			limit = 10000
+		}
+		if limit > 0 {
+			filter.Offset = (page - 1) * limit
+			filter.Limit = limit
+		}
+
+		if format == "csv" {
+			c.Set("Content-Type", "text/csv; charset=utf-8")
+			c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=latest-results-%s.csv", time.Now().Format("20060102-150405")))
+
+			c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+				if err := checkService.ExportLatestResults(w, filter); err != nil {
+					// Headers are already sent, the truncated file is all the client gets
+					w.WriteString("\n# export failed: " + err.Error() + "\n")
+				}
+				w.Flush()
+			})
+			return nil
+		}
+
+		results, total, err := checkService.GetLatestResults(filter)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get latest results",
 			})
 		}
 
-		return c.JSON(LatestResultsResponse{
+		response := LatestResultsResponse{
 			Results: results,
-		})
+			Total:   total,
+		}
+		if filter.Limit > 0 {
+			response.Page = page
+			response.Limit = limit
+		}
+
+		return c.JSON(response)
+	}
+}
+
+// getLockStatsHandler godoc
+// @Summary Get check lock stats
+// @Description Get number of phones and gateways with running or waiting checks outbound API calls and OCR runs holding or waiting for a slot
+// @Tags checks
+// @Produce json
+// @Success 200 {object} services.LockStats
+// @Security BearerAuth
+// @Router /checks/debug/locks [get]
+func getLockStatsHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(checkService.LockStats())
 	}
 }
 
@@ -195,6 +528,7 @@ func getLatestResultsHandler(checkService *services.CheckService) fiber.Handler
 // @Produce image/png
 // @Param id path int true "Check result ID"
 // @Success 200 {file} file
+// @Failure 404 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /checks/screenshot/{id} [get]
 func getScreenshotHandler(checkService *services.CheckService) fiber.Handler {
@@ -206,15 +540,210 @@ func getScreenshotHandler(checkService *services.CheckService) fiber.Handler {
 			})
 		}
 
-		// Get check result
-		var result models.CheckResult
-		if err := checkService.GetDB().First(&result, id).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Result not found",
+		data, err := checkService.GetScreenshot(c.UserContext(), uint(id))
+		if err != nil {
+			if err.Error() == "result not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Result not found",
+				})
+			}
+			if errors.Is(err, services.ErrStoredFileNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Screenshot not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to read screenshot",
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.Send(data)
+	}
+}
+
+// getCheckEvaluationHandler godoc
+// @Summary Get check evaluation
+// @Description Get text seen by the check and which keywords of the active set matched
+// @Tags checks
+// @Accept json
+// @Produce json
+// @Param id path int true "Result ID"
+// @Success 200 {object} services.CheckEvaluation
+// @Security BearerAuth
+// @Router /checks/results/{id}/evaluation [get]
+func getCheckEvaluationHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid result ID",
+			})
+		}
+
+		evaluation, err := checkService.GetCheckEvaluation(uint(id))
+		if err != nil {
+			if err.Error() == "result not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Result not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get evaluation",
+			})
+		}
+
+		return c.JSON(evaluation)
+	}
+}
+
+// getCheckTimelineHandler godoc
+// @Summary Get check timeline
+// @Description Get stages of the check that produced the result (started, call simulated, screenshot taken, OCR done, API response, verdict) with timings. Empty unless check_event_log_enabled was on during the check.
+// @Tags checks
+// @Accept json
+// @Produce json
+// @Param id path int true "Result ID"
+// @Success 200 {object} services.CheckTimeline
+// @Security BearerAuth
+// @Router /checks/results/{id}/timeline [get]
+func getCheckTimelineHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid result ID",
+			})
+		}
+
+		timeline, err := checkService.GetCheckTimeline(uint(id))
+		if err != nil {
+			if err.Error() == "result not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Result not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get timeline",
+			})
+		}
+
+		return c.JSON(timeline)
+	}
+}
+
+// getCheckResultRawHandler godoc
+// @Summary Get raw check text
+// @Description Get OCR text, API response and extracted text a result was classified on. Credentials in API responses are redacted.
+// @Tags checks
+// @Accept json
+// @Produce json
+// @Param id path int true "Result ID"
+// @Success 200 {object} services.CheckResultRaw
+// @Security BearerAuth
+// @Router /checks/results/{id}/raw [get]
+func getCheckResultRawHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid result ID",
+			})
+		}
+
+		raw, err := checkService.GetCheckResultRaw(uint(id))
+		if err != nil {
+			if err.Error() == "result not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Result not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get raw result",
+			})
+		}
+
+		return c.JSON(raw)
+	}
+}
+
+// importHistoricalResultsHandler godoc
+// @Summary Import historical results
+// @Description Import check results from the legacy tracker (CSV or JSON). Re-importing the same rows is skipped.
+// @Tags checks
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or JSON file"
+// @Success 200 {object} services.HistoryImportReport
+// @Security BearerAuth
+// @Router /checks/import [post]
+func importHistoricalResultsHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "File is required",
+			})
+		}
+
+		format := "csv"
+		if strings.HasSuffix(strings.ToLower(file.Filename), ".json") {
+			format = "json"
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to open file",
+			})
+		}
+		defer src.Close()
+
+		userID := middleware.GetUserID(c)
+		report, err := checkService.ImportHistoricalResults(src, format, userID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
 			})
 		}
 
-		// Send screenshot file
-		return c.SendFile(result.Screenshot)
+		return c.JSON(report)
+	}
+}
+
+// deleteImportedResultsHandler godoc
+// @Summary Delete imported results
+// @Description Delete imported historical results and rebuild statistics
+// @Tags checks
+// @Accept json
+// @Produce json
+// @Param service_code query string false "Delete only results of this service"
+// @Param since query string false "Delete only rows imported since this time (RFC3339)"
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /checks/import [delete]
+func deleteImportedResultsHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var since time.Time
+		if sinceStr := c.Query("since"); sinceStr != "" {
+			parsed, err := time.Parse(time.RFC3339, sinceStr)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid since, expected RFC3339",
+				})
+			}
+			since = parsed
+		}
+
+		deleted, err := checkService.DeleteImportedResults(c.Query("service_code"), since)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"deleted": deleted,
+		})
 	}
 }
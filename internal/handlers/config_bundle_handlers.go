@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/middleware"
+	"spam-checker/internal/models"
+	"spam-checker/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterConfigBundleRoutes registers disaster recovery export and import of full configuration
+func RegisterConfigBundleRoutes(api fiber.Router, bundleService *services.ConfigBundleService, authMiddleware *middleware.AuthMiddleware) {
+	bundle := api.Group("/config")
+
+	// Bundle exposes the whole installation, admin only
+	bundle.Use(authMiddleware.RequireRole(models.RoleAdmin))
+
+	bundle.Get("/export", exportConfigBundleHandler(bundleService))
+	bundle.Post("/import", importConfigBundleHandler(bundleService))
+}
+
+// exportConfigBundleHandler godoc
+// @Summary Export configuration bundle
+// @Description Export gateways, API services, spam services, keywords, schedules, notification channels and settings. Container runtime state is never exported.
+// @Tags config
+// @Produce json
+// @Param include_secrets query bool false "Include API headers, bot tokens and SMTP passwords"
+// @Success 200 {object} services.ConfigBundle
+// @Security BearerAuth
+// @Router /config/export [get]
+func exportConfigBundleHandler(bundleService *services.ConfigBundleService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bundle, err := bundleService.ExportBundle(c.QueryBool("include_secrets", false))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to export configuration",
+			})
+		}
+
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=spam-checker-config-%s.json",
+			bundle.ExportedAt.Format("20060102-150405")))
+
+		return c.JSON(bundle)
+	}
+}
+
+// importConfigBundleHandler godoc
+// @Summary Import configuration bundle
+// @Description Validate bundle and apply it in one transaction. Existing entities are matched by name or code and updated, nothing is deleted. Docker gateways get new containers and ports on this host.
+// @Tags config
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Only report what would be created, updated or skipped"
+// @Param request body services.ConfigBundle true "Configuration bundle"
+// @Success 200 {object} services.ConfigBundleImportReport
+// @Failure 400 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /config/import [post]
+func importConfigBundleHandler(bundleService *services.ConfigBundleService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var bundle services.ConfigBundle
+		if err := c.BodyParser(&bundle); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		report, err := bundleService.ImportBundle(&bundle, c.QueryBool("dry_run", false))
+		if err != nil {
+			var bundleErr *services.ConfigBundleError
+			if errors.As(err, &bundleErr) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":    "Invalid configuration bundle",
+					"problems": bundleErr.Problems,
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(report)
+	}
+}
@@ -26,8 +26,18 @@ type TestNotificationRequest struct {
 	Message string `json:"message"`
 }
 
+// NotificationsListResponse represents notification channels list response
+//
+// Deprecated: list returns ListResponse unless legacy=true is requested
+type NotificationsListResponse struct {
+	Notifications []models.Notification `json:"notifications"`
+	Total         int64                 `json:"total"`
+	Page          int                   `json:"page"`
+	Limit         int                   `json:"limit"`
+}
+
 // RegisterNotificationRoutes registers notification routes
-func RegisterNotificationRoutes(api fiber.Router, notificationService *services.NotificationService, authMiddleware *middleware.AuthMiddleware) {
+func RegisterNotificationRoutes(api fiber.Router, notificationService *services.NotificationService, authMiddleware *middleware.AuthMiddleware, idempotency *middleware.IdempotencyMiddleware) {
 	notifications := api.Group("/notifications")
 
 	// All notification routes require admin or supervisor role
@@ -35,7 +45,8 @@ func RegisterNotificationRoutes(api fiber.Router, notificationService *services.
 
 	notifications.Get("/", listNotificationsHandler(notificationService))
 	notifications.Get("/:id", getNotificationHandler(notificationService))
-	notifications.Post("/", authMiddleware.RequireRole(models.RoleAdmin), createNotificationHandler(notificationService))
+	notifications.Get("/:id/deliveries", getNotificationDeliveriesHandler(notificationService))
+	notifications.Post("/", authMiddleware.RequireRole(models.RoleAdmin), idempotency.Handle(), createNotificationHandler(notificationService))
 	notifications.Put("/:id", authMiddleware.RequireRole(models.RoleAdmin), updateNotificationHandler(notificationService))
 	notifications.Delete("/:id", authMiddleware.RequireRole(models.RoleAdmin), deleteNotificationHandler(notificationService))
 	notifications.Post("/:id/test", testNotificationHandler(notificationService))
@@ -44,23 +55,46 @@ func RegisterNotificationRoutes(api fiber.Router, notificationService *services.
 
 // listNotificationsHandler godoc
 // @Summary List notifications
-// @Description Get all notification channels
+// @Description Get notification channels with pagination, last delivery time and outcome, and channel health (consecutive failures, degraded)
 // @Tags notifications
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Notification
+// @Param type query string false "Filter by type (telegram, email)"
+// @Param is_active query bool false "Filter by active status"
+// @Param page query int false "Page number" default(1)
+// @Param offset query int false "Items to skip, overrides page"
+// @Param limit query int false "Items per page" default(50)
+// @Param legacy query bool false "Respond with NotificationsListResponse, kept for one release"
+// @Success 200 {object} ListResponse{data=[]models.Notification}
 // @Security BearerAuth
 // @Router /notifications [get]
 func listNotificationsHandler(notificationService *services.NotificationService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		notifications, err := notificationService.GetNotifications()
+		pagination := parsePagination(c)
+		notificationType := c.Query("type")
+
+		var isActive *bool
+		if activeStr := c.Query("is_active"); activeStr != "" {
+			active := activeStr == "true"
+			isActive = &active
+		}
+
+		notifications, total, err := notificationService.GetNotifications(pagination.Offset, pagination.Limit, notificationType, isActive)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get notifications",
 			})
 		}
 
-		return c.JSON(notifications)
+		if legacyList(c) {
+			return c.JSON(NotificationsListResponse{
+				Notifications: notifications,
+				Total:         total,
+				Page:          pagination.Page,
+				Limit:         pagination.Limit,
+			})
+		}
+		return sendList(c, notifications, total, pagination)
 	}
 }
 
@@ -94,6 +128,42 @@ func getNotificationHandler(notificationService *services.NotificationService) f
 	}
 }
 
+// getNotificationDeliveriesHandler godoc
+// @Summary Get notification deliveries
+// @Description Get recent send attempts of a notification channel
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Param limit query int false "Max deliveries" default(50)
+// @Success 200 {array} models.NotificationDelivery
+// @Security BearerAuth
+// @Router /notifications/{id}/deliveries [get]
+func getNotificationDeliveriesHandler(notificationService *services.NotificationService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid notification ID",
+			})
+		}
+
+		limit := c.QueryInt("limit", 50)
+		if limit < 1 || limit > 500 {
+			limit = 50
+		}
+
+		deliveries, err := notificationService.GetDeliveries(uint(id), limit)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(deliveries)
+	}
+}
+
 // createNotificationHandler godoc
 // @Summary Create notification
 // @Description Create a new notification channel
@@ -209,7 +279,7 @@ func deleteNotificationHandler(notificationService *services.NotificationService
 
 // testNotificationHandler godoc
 // @Summary Test notification
-// @Description Test notification channel
+// @Description Test notification channel. Success clears failure counters and re-enables a degraded channel
 // @Tags notifications
 // @Accept json
 // @Produce json
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page sizes of list endpoints using ListResponse
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// ListResponse represents a page of a list endpoint
+type ListResponse struct {
+	Data  interface{} `json:"data"`
+	Total int64       `json:"total"` // Items matching filters across all pages
+	Page  int         `json:"page"`
+	Limit int         `json:"limit"`
+}
+
+// Pagination represents page requested from a list endpoint
+type Pagination struct {
+	Page   int
+	Limit  int
+	Offset int
+}
+
+// parsePagination reads page and limit query params. offset, when given, takes precedence over page.
+// Invalid limit falls back to the default one.
+func parsePagination(c *fiber.Ctx) Pagination {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 || limit > maxListLimit {
+		limit = defaultListLimit
+	}
+
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset >= 0 {
+		return Pagination{Page: offset/limit + 1, Limit: limit, Offset: offset}
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	return Pagination{Page: page, Limit: limit, Offset: (page - 1) * limit}
+}
+
+// legacyList reports whether client requested the response shape a list endpoint had before ListResponse.
+// Legacy shapes are kept for one release.
+func legacyList(c *fiber.Ctx) bool {
+	return c.QueryBool("legacy")
+}
+
+// sendList responds with a page of items in ListResponse
+func sendList(c *fiber.Ctx, items interface{}, total int64, pagination Pagination) error {
+	return c.JSON(ListResponse{
+		Data:  items,
+		Total: total,
+		Page:  pagination.Page,
+		Limit: pagination.Limit,
+	})
+}
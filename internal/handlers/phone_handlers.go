@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"spam-checker/internal/middleware"
 	"spam-checker/internal/models"
+	"spam-checker/internal/scheduler"
 	"spam-checker/internal/services"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,14 +18,16 @@ import (
 type CreatePhoneRequest struct {
 	Number      string `json:"number" validate:"required"`
 	Description string `json:"description"`
+	Campaign    string `json:"campaign"` // Taken from description by phone_campaign_description_pattern when empty
 	IsActive    bool   `json:"is_active"`
 }
 
 // UpdatePhoneRequest represents phone update request
 type UpdatePhoneRequest struct {
-	Number      string `json:"number"`
-	Description string `json:"description"`
-	IsActive    *bool  `json:"is_active"`
+	Number      string  `json:"number"`
+	Description string  `json:"description"`
+	Campaign    *string `json:"campaign"` // Empty string clears campaign
+	IsActive    *bool   `json:"is_active"`
 }
 
 // PhonesListResponse represents phones list response
@@ -37,18 +44,77 @@ type ImportPhonesResponse struct {
 	Errors   []string `json:"errors"`
 }
 
+// BlockPhoneRequest represents phone blocklist request
+type BlockPhoneRequest struct {
+	Reason string `json:"reason"`
+}
+
+// BackfillCampaignsResponse represents campaign backfill response
+type BackfillCampaignsResponse struct {
+	Updated int `json:"updated"`
+}
+
+// MergeDuplicatesRequest represents duplicate phones merge request
+type MergeDuplicatesRequest struct {
+	DryRun *bool `json:"dry_run"` // Defaults to true
+}
+
+// GeneratePhonesRequest represents synthetic phone range request
+type GeneratePhonesRequest struct {
+	Prefix string `json:"prefix"` // Padded with zeros to a full number, e.g. 7900123 starts at 79001230000
+	Count  int    `json:"count"`
+}
+
+// PurgeGeneratedPhonesResponse represents generated phones purge response
+type PurgeGeneratedPhonesResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// ServiceExclusionRequest represents request excluding phone from checks of a spam service
+type ServiceExclusionRequest struct {
+	ServiceCode string `json:"service_code"`
+	Reason      string `json:"reason"`
+}
+
+// BulkServiceExclusionRequest represents request applying or removing exclusion for all phones of a campaign
+type BulkServiceExclusionRequest struct {
+	Campaign    string `json:"campaign"` // uncategorized selects phones without one
+	ServiceCode string `json:"service_code"`
+	Reason      string `json:"reason"` // Ignored when removing
+}
+
 // RegisterPhoneRoutes registers phone number routes
-func RegisterPhoneRoutes(api fiber.Router, phoneService *services.PhoneService, authMiddleware *middleware.AuthMiddleware) {
+func RegisterPhoneRoutes(api fiber.Router, phoneService *services.PhoneService, importService *services.PhoneImportService, checkService *services.CheckService, checkScheduler *scheduler.CheckScheduler, authMiddleware *middleware.AuthMiddleware, idempotency *middleware.IdempotencyMiddleware) {
 	phones := api.Group("/phones")
 
 	phones.Get("/", listPhonesHandler(phoneService))
 	phones.Get("/stats", getPhoneStatsHandler(phoneService))
 	phones.Get("/export", exportPhonesHandler(phoneService))
-	phones.Get("/:id", getPhoneByIDHandler(phoneService))
-	phones.Post("/", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), createPhoneHandler(phoneService))
+	phones.Get("/duplicates", authMiddleware.RequireRole(models.RoleAdmin), listDuplicatePhonesHandler(phoneService))
+	phones.Post("/duplicates/merge", authMiddleware.RequireRole(models.RoleAdmin), mergeDuplicatePhonesHandler(phoneService))
+	phones.Get("/blocked", authMiddleware.RequireRole(models.RoleAdmin), listBlockedPhonesHandler(phoneService))
+	phones.Post("/campaigns/backfill", authMiddleware.RequireRole(models.RoleAdmin), backfillCampaignsHandler(phoneService))
+	phones.Post("/generate", authMiddleware.RequireRole(models.RoleAdmin), generatePhonesHandler(phoneService))
+	phones.Delete("/generated", authMiddleware.RequireRole(models.RoleAdmin), purgeGeneratedPhonesHandler(phoneService))
+	phones.Post("/exclusions/bulk", authMiddleware.RequireRole(models.RoleAdmin), bulkServiceExclusionHandler(phoneService, true))
+	phones.Delete("/exclusions/bulk", authMiddleware.RequireRole(models.RoleAdmin), bulkServiceExclusionHandler(phoneService, false))
+	phones.Get("/:id", getPhoneByIDHandler(phoneService, checkScheduler))
+	phones.Get("/:id/next-check", getPhoneNextCheckHandler(checkScheduler))
+	phones.Get("/:id/transitions", getPhoneTransitionsHandler(phoneService))
+	phones.Get("/:id/diff", getPhoneResultDiffHandler(phoneService))
+	phones.Get("/:id/exclusions", listServiceExclusionsHandler(phoneService))
+	phones.Post("/:id/exclusions", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), addServiceExclusionHandler(phoneService))
+	phones.Delete("/:id/exclusions/:service", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), removeServiceExclusionHandler(phoneService))
+	phones.Post("/", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), idempotency.Handle(), createPhoneHandler(phoneService))
 	phones.Put("/:id", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), updatePhoneHandler(phoneService))
 	phones.Delete("/:id", authMiddleware.RequireRole(models.RoleAdmin), deletePhoneHandler(phoneService))
-	phones.Post("/import", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), importPhonesHandler(phoneService))
+	phones.Post("/import", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), importPhonesHandler(importService))
+	phones.Get("/import/jobs", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), listImportJobsHandler(importService))
+	phones.Get("/import/jobs/:id", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), getImportJobHandler(importService))
+	phones.Get("/import/jobs/:id/errors", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), downloadImportErrorsHandler(importService))
+	phones.Post("/:id/block", authMiddleware.RequireRole(models.RoleAdmin), blockPhoneHandler(phoneService, true))
+	phones.Delete("/:id/block", authMiddleware.RequireRole(models.RoleAdmin), blockPhoneHandler(phoneService, false))
+	phones.Post("/:id/check", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), checkPhoneNowHandler(checkService))
 }
 
 // listPhonesHandler godoc
@@ -61,7 +127,14 @@ func RegisterPhoneRoutes(api fiber.Router, phoneService *services.PhoneService,
 // @Param limit query int false "Items per page" default(20)
 // @Param search query string false "Search query"
 // @Param is_active query bool false "Filter by active status"
+// @Param campaign query string false "Filter by campaign, uncategorized selects phones without one"
+// @Param sort query string false "Sort column, phone_list_default_sort setting by default" Enums(created_at, number, spam_status, last_checked, allocations)
+// @Param order query string false "Sort order, phone_list_default_order setting by default" Enums(asc, desc)
+// @Param spam query string false "Filter by overall spam status" Enums(spam, clean, unchecked)
+// @Param include_realtime query bool false "Include inactive phones created by realtime checks"
+// @Param scope query string false "mine lists only phones created by the current user" Enums(all, mine)
 // @Success 200 {object} PhonesListResponse
+// @Failure 400 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /phones [get]
 func listPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
@@ -76,10 +149,30 @@ func listPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
 			isActive = &active
 		}
 
+		ownerID, err := services.PhoneOwnerFromScope(c.Query("scope"), middleware.GetUserID(c))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		opts := services.PhoneListOptions{
+			Sort:            c.Query("sort"),
+			Order:           strings.ToLower(c.Query("order")),
+			Spam:            c.Query("spam"),
+			IncludeRealtime: c.QueryBool("include_realtime"),
+			OwnerID:         ownerID,
+		}
+		if err := opts.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
 		offset := (page - 1) * limit
 
 		// Use the new method that returns detailed data
-		phones, total, err := phoneService.ListPhonesWithDetails(offset, limit, search, isActive)
+		phones, total, err := phoneService.ListPhonesWithDetails(offset, limit, search, isActive, c.Query("campaign"), opts)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get phones",
@@ -105,7 +198,7 @@ func listPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
 // @Success 200 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /phones/{id} [get]
-func getPhoneByIDHandler(phoneService *services.PhoneService) fiber.Handler {
+func getPhoneByIDHandler(phoneService *services.PhoneService, checkScheduler *scheduler.CheckScheduler) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 		if err != nil {
@@ -133,6 +226,7 @@ func getPhoneByIDHandler(phoneService *services.PhoneService) fiber.Handler {
 		}
 
 		// Format check results
+		freshness := phoneService.ResultFreshness(phone.ID)
 		checkResults := make([]map[string]interface{}, len(phone.CheckResults))
 		for i, result := range phone.CheckResults {
 			checkResults[i] = map[string]interface{}{
@@ -142,14 +236,23 @@ func getPhoneByIDHandler(phoneService *services.PhoneService) fiber.Handler {
 					"name": result.Service.Name,
 					"code": result.Service.Code,
 				},
-				"is_spam":        result.IsSpam,
-				"found_keywords": []string(result.FoundKeywords),
-				"screenshot":     result.Screenshot,
-				"raw_text":       result.RawText,
-				"checked_at":     result.CheckedAt,
+				"is_spam":          result.IsSpam,
+				"inconclusive":     result.Inconclusive,
+				"status":           result.Status,
+				"confidence":       result.Confidence,
+				"found_keywords":   []string(result.FoundKeywords),
+				"screenshot":       result.Screenshot,
+				"raw_text":         result.RawText,
+				"gateway_id":       result.GatewayID,
+				"gateway_name":     result.GatewayName,
+				"api_service_id":   result.APIServiceID,
+				"api_service_name": result.APIServiceName,
+				"checked_at":       result.CheckedAt,
+				"is_stale":         freshness.IsStale(result.ServiceID, result.CheckedAt),
 			}
 		}
 		response["check_results"] = checkResults
+		response["verdict_freshness"] = freshness.Verdict(phone.CheckResults)
 
 		// Calculate overall spam status
 		isSpam := false
@@ -161,10 +264,166 @@ func getPhoneByIDHandler(phoneService *services.PhoneService) fiber.Handler {
 		}
 		response["is_spam"] = isSpam
 
+		// Services the phone is never checked with
+		if exclusions, err := phoneService.ListServiceExclusions(phone.ID); err == nil {
+			response["service_exclusions"] = exclusions
+		}
+
+		// Estimated next automatic check
+		if nextCheck, err := checkScheduler.EstimateNextCheck(phone.ID); err == nil {
+			response["next_check"] = nextCheck
+		}
+
 		return c.JSON(response)
 	}
 }
 
+// getPhoneNextCheckHandler godoc
+// @Summary Get phone next check
+// @Description Get estimated time of the next automatic check of a phone and the schedule it comes from
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Success 200 {object} scheduler.NextCheckEstimate
+// @Security BearerAuth
+// @Router /phones/{id}/next-check [get]
+func getPhoneNextCheckHandler(checkScheduler *scheduler.CheckScheduler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		nextCheck, err := checkScheduler.EstimateNextCheck(uint(id))
+		if err != nil {
+			if err.Error() == "phone number not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to estimate next check",
+			})
+		}
+
+		return c.JSON(nextCheck)
+	}
+}
+
+// getPhoneTransitionsHandler godoc
+// @Summary Get phone status transitions
+// @Description Get history of spam status changes of a phone per service
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Param limit query int false "Max transitions" default(50)
+// @Success 200 {array} models.SpamStatusTransition
+// @Security BearerAuth
+// @Router /phones/{id}/transitions [get]
+func getPhoneTransitionsHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		limit := c.QueryInt("limit", 50)
+		if limit < 1 || limit > 500 {
+			limit = 50
+		}
+
+		transitions, err := phoneService.GetSpamTransitions(uint(id), limit)
+		if err != nil {
+			if err.Error() == "phone number not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get status transitions",
+			})
+		}
+
+		return c.JSON(transitions)
+	}
+}
+
+// getPhoneResultDiffHandler godoc
+// @Summary Compare phone results at two points in time
+// @Description For each service, compare the latest result at or before from with the latest at or before to: verdict change, added and removed keywords, and IDs of both results for screenshots
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Param from query string true "Earlier point in time (RFC3339, or YYYY-MM-DD for the end of that day)"
+// @Param to query string false "Later point in time (RFC3339, or YYYY-MM-DD for the end of that day), now when omitted"
+// @Success 200 {object} services.ResultDiff
+// @Security BearerAuth
+// @Router /phones/{id}/diff [get]
+func getPhoneResultDiffHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		from, err := parseDiffTime(c.Query("from"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from, use RFC3339 or YYYY-MM-DD",
+			})
+		}
+		to := time.Now()
+		if toStr := c.Query("to"); toStr != "" {
+			if to, err = parseDiffTime(toStr); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid to, use RFC3339 or YYYY-MM-DD",
+				})
+			}
+		}
+
+		diff, err := phoneService.DiffResults(uint(id), from, to)
+		if err != nil {
+			switch err.Error() {
+			case "phone number not found":
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			case "from must not be after to":
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to compare results",
+			})
+		}
+
+		return c.JSON(diff)
+	}
+}
+
+// parseDiffTime parses RFC3339 time or date. A date means the end of that day,
+// so results checked during the day are included.
+func parseDiffTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
 // createPhoneHandler godoc
 // @Summary Create phone
 // @Description Create a new phone number
@@ -188,6 +447,7 @@ func createPhoneHandler(phoneService *services.PhoneService) fiber.Handler {
 		phone := &models.PhoneNumber{
 			Number:      req.Number,
 			Description: req.Description,
+			Campaign:    req.Campaign,
 			IsActive:    req.IsActive,
 			CreatedBy:   userID,
 		}
@@ -236,6 +496,9 @@ func updatePhoneHandler(phoneService *services.PhoneService) fiber.Handler {
 		if req.Description != "" {
 			updates["description"] = req.Description
 		}
+		if req.Campaign != nil {
+			updates["campaign"] = *req.Campaign
+		}
 		if req.IsActive != nil {
 			updates["is_active"] = *req.IsActive
 		}
@@ -283,17 +546,427 @@ func deletePhoneHandler(phoneService *services.PhoneService) fiber.Handler {
 	}
 }
 
+// checkPhoneNowHandler godoc
+// @Summary Check phone now
+// @Description Run check for a phone number and return fresh results
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{} "Phone not found"
+// @Failure 409 {object} map[string]interface{} "Phone is already being checked or blocked"
+// @Security BearerAuth
+// @Router /phones/{id}/check [post]
+func checkPhoneNowHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		results, err := checkService.CheckPhoneNow(uint(id))
+		if err != nil {
+			if errors.Is(err, services.ErrPhoneNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Phone not found",
+				})
+			}
+			if errors.Is(err, services.ErrCheckInProgress) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Phone is already being checked",
+				})
+			}
+			if errors.Is(err, services.ErrPhoneBlocked) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Phone is blocked",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(results)
+	}
+}
+
+// listBlockedPhonesHandler godoc
+// @Summary List blocked phones
+// @Description Get phones excluded from checks and allocation
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.PhoneNumber
+// @Security BearerAuth
+// @Router /phones/blocked [get]
+func listBlockedPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		phones, err := phoneService.ListBlockedPhones()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list blocked phones",
+			})
+		}
+
+		return c.JSON(phones)
+	}
+}
+
+// blockPhoneHandler godoc
+// @Summary Block or unblock phone
+// @Description POST adds phone to blocklist, DELETE removes it. Blocked phones keep history but are never checked or allocated.
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Param request body BlockPhoneRequest false "Block reason"
+// @Success 200 {object} MessageResponse
+// @Failure 404 {object} map[string]interface{} "Phone not found"
+// @Security BearerAuth
+// @Router /phones/{id}/block [post]
+// @Router /phones/{id}/block [delete]
+func blockPhoneHandler(phoneService *services.PhoneService, blocked bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		var req BlockPhoneRequest
+		if blocked && len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid request body",
+				})
+			}
+		}
+
+		if err := phoneService.SetPhoneBlocked(uint(id), blocked, req.Reason); err != nil {
+			if err.Error() == "phone not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Phone not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update phone blocklist",
+			})
+		}
+
+		message := "Phone unblocked successfully"
+		if blocked {
+			message = "Phone blocked successfully"
+		}
+		return c.JSON(MessageResponse{
+			Message: message,
+		})
+	}
+}
+
+// backfillCampaignsHandler godoc
+// @Summary Backfill phone campaigns
+// @Description Fill campaign of phones without one from description using phone_campaign_description_pattern setting
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Success 200 {object} BackfillCampaignsResponse
+// @Security BearerAuth
+// @Router /phones/campaigns/backfill [post]
+func backfillCampaignsHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		updated, err := phoneService.BackfillCampaigns()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(BackfillCampaignsResponse{Updated: updated})
+	}
+}
+
+// listDuplicatePhonesHandler godoc
+// @Summary List duplicate phones
+// @Description Get groups of phone rows whose normalized numbers collide
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Success 200 {array} services.DuplicatePhoneGroup
+// @Security BearerAuth
+// @Router /phones/duplicates [get]
+func listDuplicatePhonesHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		groups, err := phoneService.FindDuplicatePhones()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(groups)
+	}
+}
+
+// generatePhonesHandler godoc
+// @Summary Generate phone range
+// @Description Create count sequential synthetic phones for testing, starting from prefix padded with zeros to 11 digits. Existing numbers are skipped, count is capped at 10000
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param request body GeneratePhonesRequest true "Range"
+// @Success 201 {object} services.PhoneGenerationReport
+// @Failure 400 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /phones/generate [post]
+func generatePhonesHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req GeneratePhonesRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		report, err := phoneService.GenerateRange(req.Prefix, req.Count, middleware.GetUserID(c))
+		if err != nil {
+			status := fiber.StatusInternalServerError
+			if errors.Is(err, services.ErrInvalidPhoneRange) {
+				status = fiber.StatusBadRequest
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(report)
+	}
+}
+
+// purgeGeneratedPhonesHandler godoc
+// @Summary Purge generated phones
+// @Description Delete all phones created by range generation along with their results
+// @Tags phones
+// @Produce json
+// @Success 200 {object} PurgeGeneratedPhonesResponse
+// @Security BearerAuth
+// @Router /phones/generated [delete]
+func purgeGeneratedPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		deleted, err := phoneService.PurgeGeneratedPhones()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(PurgeGeneratedPhonesResponse{Deleted: deleted})
+	}
+}
+
+// listServiceExclusionsHandler godoc
+// @Summary List phone service exclusions
+// @Description Get spam services the phone is never checked with
+// @Tags phones
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Success 200 {array} models.PhoneServiceExclusion
+// @Security BearerAuth
+// @Router /phones/{id}/exclusions [get]
+func listServiceExclusionsHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		exclusions, err := phoneService.ListServiceExclusions(uint(id))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(exclusions)
+	}
+}
+
+// addServiceExclusionHandler godoc
+// @Summary Exclude phone from spam service
+// @Description Never check the phone with gateways and API providers of the spam service. Skipped checks are reported with status excluded and the service verdict is not applicable to the phone. Existing exclusion gets the new reason
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Param request body ServiceExclusionRequest true "Spam service and reason"
+// @Success 201 {object} models.PhoneServiceExclusion
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{} "Phone not found"
+// @Security BearerAuth
+// @Router /phones/{id}/exclusions [post]
+func addServiceExclusionHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		var req ServiceExclusionRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		exclusion, err := phoneService.AddServiceExclusion(uint(id), req.ServiceCode, req.Reason, middleware.GetUserID(c))
+		if err != nil {
+			return c.Status(serviceExclusionErrorStatus(err)).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(exclusion)
+	}
+}
+
+// removeServiceExclusionHandler godoc
+// @Summary Remove phone service exclusion
+// @Description Allow checking the phone with the spam service again
+// @Tags phones
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Param service path string true "Spam service code"
+// @Success 200 {object} MessageResponse
+// @Failure 404 {object} map[string]interface{} "Exclusion not found"
+// @Security BearerAuth
+// @Router /phones/{id}/exclusions/{service} [delete]
+func removeServiceExclusionHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		if err := phoneService.RemoveServiceExclusion(uint(id), c.Params("service")); err != nil {
+			return c.Status(serviceExclusionErrorStatus(err)).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(MessageResponse{
+			Message: "Service exclusion removed successfully",
+		})
+	}
+}
+
+// bulkServiceExclusionHandler godoc
+// @Summary Apply or remove service exclusion for a campaign
+// @Description POST excludes every phone of the campaign from the spam service, DELETE removes the exclusion from them. Phones added to the campaign later are not excluded automatically
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param request body BulkServiceExclusionRequest true "Campaign and spam service"
+// @Success 200 {object} services.ServiceExclusionBulkReport
+// @Failure 400 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /phones/exclusions/bulk [post]
+// @Router /phones/exclusions/bulk [delete]
+func bulkServiceExclusionHandler(phoneService *services.PhoneService, excluded bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req BulkServiceExclusionRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		var report *services.ServiceExclusionBulkReport
+		var err error
+		if excluded {
+			report, err = phoneService.BulkAddServiceExclusions(req.Campaign, req.ServiceCode, req.Reason, middleware.GetUserID(c))
+		} else {
+			report, err = phoneService.BulkRemoveServiceExclusions(req.Campaign, req.ServiceCode)
+		}
+		if err != nil {
+			return c.Status(serviceExclusionErrorStatus(err)).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(report)
+	}
+}
+
+// serviceExclusionErrorStatus maps service exclusion error to HTTP status
+func serviceExclusionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidServiceExclusion):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrServiceExclusionNotFound):
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// mergeDuplicatePhonesHandler godoc
+// @Summary Merge duplicate phones
+// @Description Merge duplicate phone rows into the oldest one. Runs in dry-run mode unless dry_run is false
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param request body MergeDuplicatesRequest false "Merge options"
+// @Success 200 {object} services.PhoneMergeReport
+// @Security BearerAuth
+// @Router /phones/duplicates/merge [post]
+func mergeDuplicatePhonesHandler(phoneService *services.PhoneService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req MergeDuplicatesRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid request body",
+				})
+			}
+		}
+
+		dryRun := true
+		if req.DryRun != nil {
+			dryRun = *req.DryRun
+		}
+
+		report, err := phoneService.MergeDuplicatePhones(dryRun)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(report)
+	}
+}
+
 // importPhonesHandler godoc
 // @Summary Import phones
-// @Description Import phone numbers from CSV file
+// @Description Import phone numbers from CSV file. Files up to phone_import_sync_max_rows rows are imported immediately, larger ones become a background job.
 // @Tags phones
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "CSV file"
 // @Success 200 {object} ImportPhonesResponse
+// @Success 202 {object} models.PhoneImportJob
 // @Security BearerAuth
 // @Router /phones/import [post]
-func importPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
+func importPhonesHandler(importService *services.PhoneImportService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		file, err := c.FormFile("file")
 		if err != nil {
@@ -311,8 +984,26 @@ func importPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
 		}
 		defer src.Close()
 
+		path, rows, err := importService.SaveUpload(src)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to save file",
+			})
+		}
+
 		userID := middleware.GetUserID(c)
-		imported, errors, err := phoneService.ImportPhones(src, userID)
+		if rows > importService.SyncMaxRows() {
+			job, err := importService.CreateJob(file.Filename, path, rows, userID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to create import job",
+				})
+			}
+
+			return c.Status(fiber.StatusAccepted).JSON(job)
+		}
+
+		imported, errors, err := importService.ImportStored(path, userID)
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": err.Error(),
@@ -326,6 +1017,119 @@ func importPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
 	}
 }
 
+// listImportJobsHandler godoc
+// @Summary List import jobs
+// @Description Get latest background phone import jobs
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param limit query int false "Max jobs" default(20)
+// @Success 200 {array} models.PhoneImportJob
+// @Security BearerAuth
+// @Router /phones/import/jobs [get]
+func listImportJobsHandler(importService *services.PhoneImportService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := c.QueryInt("limit", 20)
+		if limit < 1 || limit > 100 {
+			limit = 20
+		}
+
+		jobs, err := importService.ListJobs(limit)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list import jobs",
+			})
+		}
+
+		return c.JSON(jobs)
+	}
+}
+
+// getImportJobHandler godoc
+// @Summary Get import job
+// @Description Get progress of background phone import job
+// @Tags phones
+// @Accept json
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.PhoneImportJob
+// @Failure 404 {object} map[string]interface{} "Job not found"
+// @Security BearerAuth
+// @Router /phones/import/jobs/{id} [get]
+func getImportJobHandler(importService *services.PhoneImportService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid job ID",
+			})
+		}
+
+		job, err := importService.GetJob(uint(id))
+		if err != nil {
+			if err.Error() == "import job not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Import job not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get import job",
+			})
+		}
+
+		return c.JSON(job)
+	}
+}
+
+// downloadImportErrorsHandler godoc
+// @Summary Download import errors
+// @Description Download CSV of lines that failed to import (line, number, error)
+// @Tags phones
+// @Produce text/csv
+// @Param id path int true "Job ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]interface{} "Job or report not found"
+// @Failure 409 {object} map[string]interface{} "Report is stored on another instance"
+// @Security BearerAuth
+// @Router /phones/import/jobs/{id}/errors [get]
+func downloadImportErrorsHandler(importService *services.PhoneImportService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid job ID",
+			})
+		}
+
+		job, err := importService.GetJob(uint(id))
+		if err != nil {
+			if err.Error() == "import job not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Import job not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get import job",
+			})
+		}
+
+		path, err := importService.ErrorReportPath(job)
+		if errors.Is(err, services.ErrImportFileElsewhere) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":    "Error report is stored on another instance",
+				"instance": job.Instance,
+			})
+		}
+		if path == "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Import job has no failed lines",
+			})
+		}
+
+		return c.Download(path, fmt.Sprintf("phone_import_%d_errors.csv", job.ID))
+	}
+}
+
 // exportPhonesHandler godoc
 // @Summary Export phones
 // @Description Export phone numbers to CSV file
@@ -363,12 +1167,21 @@ func exportPhonesHandler(phoneService *services.PhoneService) fiber.Handler {
 // @Tags phones
 // @Accept json
 // @Produce json
+// @Param scope query string false "mine counts only phones created by the current user" Enums(all, mine)
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /phones/stats [get]
 func getPhoneStatsHandler(phoneService *services.PhoneService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		stats, err := phoneService.GetPhoneStats()
+		ownerID, err := services.PhoneOwnerFromScope(c.Query("scope"), middleware.GetUserID(c))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		stats, err := phoneService.GetPhoneStats(ownerID)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get statistics",
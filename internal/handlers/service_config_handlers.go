@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"spam-checker/internal/middleware"
+	"spam-checker/internal/models"
+	"spam-checker/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterServiceConfigRoutes registers check configuration routes of spam services
+func RegisterServiceConfigRoutes(api fiber.Router, configService *services.ServiceConfigService, authMiddleware *middleware.AuthMiddleware) {
+	spamServices := api.Group("/services")
+
+	spamServices.Get("/:id/config", getServiceConfigHandler(configService))
+	spamServices.Put("/:id/config", authMiddleware.RequireRole(models.RoleAdmin), updateServiceConfigHandler(configService))
+}
+
+// getServiceConfigHandler godoc
+// @Summary Get service check configuration
+// @Description Get stored check configuration of a spam service and the effective one with defaults merged
+// @Tags services
+// @Produce json
+// @Param id path int true "Service ID"
+// @Success 200 {object} services.ServiceConfigState
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /services/{id}/config [get]
+func getServiceConfigHandler(configService *services.ServiceConfigService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service ID",
+			})
+		}
+
+		state, err := configService.GetServiceConfig(uint(id))
+		if err != nil {
+			if err.Error() == "service not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get service config",
+			})
+		}
+
+		return c.JSON(state)
+	}
+}
+
+// updateServiceConfigHandler godoc
+// @Summary Update service check configuration
+// @Description Replace check configuration of a spam service. Unknown fields are rejected and validation errors name the offending field.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Param request body services.ServiceConfig true "Service configuration"
+// @Success 200 {object} services.ServiceConfigState
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /services/{id}/config [put]
+func updateServiceConfigHandler(configService *services.ServiceConfigService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service ID",
+			})
+		}
+
+		state, err := configService.UpdateServiceConfig(uint(id), c.Body())
+		if err != nil {
+			var configErr *services.ServiceConfigError
+			switch {
+			case errors.As(err, &configErr):
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": configErr.Error(),
+					"field": configErr.Field,
+				})
+			case err.Error() == "service not found":
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update service config",
+			})
+		}
+
+		return c.JSON(state)
+	}
+}
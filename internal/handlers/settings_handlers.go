@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"spam-checker/internal/middleware"
 	"spam-checker/internal/models"
+	"spam-checker/internal/scheduler"
 	"spam-checker/internal/services"
 	"strconv"
 
@@ -25,14 +28,18 @@ type CreateSettingRequest struct {
 // CreateKeywordRequest represents keyword creation request
 type CreateKeywordRequest struct {
 	Keyword   string `json:"keyword" validate:"required"`
+	Negations string `json:"negations"`  // Comma-separated tokens, e.g. "не,not"
+	WholeWord bool   `json:"whole_word"` // Match only whole words, substrings by default
 	ServiceID *uint  `json:"service_id"`
 }
 
 // UpdateKeywordRequest represents keyword update request
 type UpdateKeywordRequest struct {
-	Keyword   string `json:"keyword"`
-	ServiceID *uint  `json:"service_id"`
-	IsActive  *bool  `json:"is_active"`
+	Keyword   string  `json:"keyword"`
+	Negations *string `json:"negations"`
+	WholeWord *bool   `json:"whole_word"`
+	ServiceID *uint   `json:"service_id"`
+	IsActive  *bool   `json:"is_active"`
 }
 
 // CreateScheduleRequest represents schedule creation request
@@ -40,17 +47,52 @@ type CreateScheduleRequest struct {
 	Name           string `json:"name" validate:"required"`
 	CronExpression string `json:"cron_expression" validate:"required"`
 	IsActive       bool   `json:"is_active"`
+	CheckMode      string `json:"check_mode"`     // adb_only, api_only or both; empty uses check_mode setting
+	ServiceCode    string `json:"service_code"`   // Check only this spam service, empty checks all
+	OverlapPolicy  string `json:"overlap_policy"` // skip (default), queue or cancel_restart
+	// Random sampling, leave size and percent unset for a full sweep
+	SampleSize              int `json:"sample_size"`
+	SamplePercent           int `json:"sample_percent"`
+	SampleMaxStalenessHours int `json:"sample_max_staleness_hours"` // 0 uses check_sample_max_staleness_hours
 }
 
 // UpdateScheduleRequest represents schedule update request
 type UpdateScheduleRequest struct {
-	Name           string `json:"name"`
-	CronExpression string `json:"cron_expression"`
-	IsActive       *bool  `json:"is_active"`
+	Name           string  `json:"name"`
+	CronExpression string  `json:"cron_expression"`
+	IsActive       *bool   `json:"is_active"`
+	CheckMode      *string `json:"check_mode"`     // Empty string clears the override
+	ServiceCode    *string `json:"service_code"`   // Empty string clears the filter
+	OverlapPolicy  *string `json:"overlap_policy"` // skip, queue or cancel_restart
+	// Random sampling, 0 clears the value
+	SampleSize              *int `json:"sample_size"`
+	SamplePercent           *int `json:"sample_percent"`
+	SampleMaxStalenessHours *int `json:"sample_max_staleness_hours"`
+}
+
+// SchedulePhonesRequest represents schedule phone list modification request
+type SchedulePhonesRequest struct {
+	PhoneIDs []uint `json:"phone_ids" validate:"required"`
+}
+
+// UpdateCheckScriptRequest represents service check script update request
+type UpdateCheckScriptRequest struct {
+	Steps []services.CheckScriptStep `json:"steps"`
+}
+
+// UpdateReadinessProbeRequest represents service readiness probe update request
+type UpdateReadinessProbeRequest struct {
+	Enabled    bool   `json:"enabled"`
+	AppPackage string `json:"app_package"`
+}
+
+// UpdateMaxResultAgeRequest represents service result max age update request
+type UpdateMaxResultAgeRequest struct {
+	Hours int `json:"hours"` // 0 uses result_max_age_hours setting
 }
 
 // RegisterSettingsRoutes registers settings routes
-func RegisterSettingsRoutes(api fiber.Router, settingsService *services.SettingsService, authMiddleware *middleware.AuthMiddleware) {
+func RegisterSettingsRoutes(api fiber.Router, settingsService *services.SettingsService, checkService *services.CheckService, checkTuning *services.CheckTuning, checkScheduler *scheduler.CheckScheduler, authMiddleware *middleware.AuthMiddleware, idempotency *middleware.IdempotencyMiddleware) {
 	settings := api.Group("/settings")
 
 	// All settings routes require admin or supervisor role
@@ -60,8 +102,9 @@ func RegisterSettingsRoutes(api fiber.Router, settingsService *services.Settings
 	settings.Get("/category/:category", getSettingsByCategoryHandler(settingsService))
 	settings.Get("/groups", getSettingsGroupsHandler(settingsService))
 	settings.Get("/database/config", getDatabaseConfigHandler(settingsService))
-	settings.Get("/ocr/config", getOCRConfigHandler(settingsService))
-	settings.Put("/ocr/config", authMiddleware.RequireRole(models.RoleAdmin), updateOCRConfigHandler(settingsService))
+	settings.Get("/ocr/config", getOCRConfigHandler(checkService))
+	settings.Put("/ocr/config", authMiddleware.RequireRole(models.RoleAdmin), updateOCRConfigHandler(checkService))
+	settings.Get("/ocr/self-test", ocrSelfTestHandler(checkService))
 	settings.Get("/intervals", getCheckIntervalsHandler(settingsService))
 	settings.Get("/export", authMiddleware.RequireRole(models.RoleAdmin), exportSettingsHandler(settingsService))
 	settings.Post("/import", authMiddleware.RequireRole(models.RoleAdmin), importSettingsHandler(settingsService))
@@ -70,9 +113,21 @@ func RegisterSettingsRoutes(api fiber.Router, settingsService *services.Settings
 	settings.Put("/keywords/:id", authMiddleware.RequireRole(models.RoleAdmin), updateSpamKeywordHandler(settingsService))
 	settings.Delete("/keywords/:id", authMiddleware.RequireRole(models.RoleAdmin), deleteSpamKeywordHandler(settingsService))
 	settings.Get("/schedules", getCheckSchedulesHandler(settingsService))
-	settings.Post("/schedules", authMiddleware.RequireRole(models.RoleAdmin), createCheckScheduleHandler(settingsService))
+	settings.Get("/schedules/status", getScheduleStatusHandler(checkScheduler))
+	settings.Get("/schedules/heartbeat", getSchedulerHeartbeatHandler(checkScheduler))
+	settings.Get("/schedules/:id", getCheckScheduleHandler(settingsService))
+	settings.Post("/schedules", authMiddleware.RequireRole(models.RoleAdmin), idempotency.Handle(), createCheckScheduleHandler(settingsService))
+	settings.Get("/schedules/:id/runs", getScheduleRunsHandler(settingsService))
+	settings.Post("/schedules/:id/phones", authMiddleware.RequireRole(models.RoleAdmin), addSchedulePhonesHandler(settingsService))
+	settings.Delete("/schedules/:id/phones", authMiddleware.RequireRole(models.RoleAdmin), removeSchedulePhonesHandler(settingsService))
 	settings.Put("/schedules/:id", authMiddleware.RequireRole(models.RoleAdmin), updateCheckScheduleHandler(settingsService))
 	settings.Delete("/schedules/:id", authMiddleware.RequireRole(models.RoleAdmin), deleteCheckScheduleHandler(settingsService))
+	settings.Get("/services/:id/check-script", getServiceCheckScriptHandler(settingsService, checkTuning))
+	settings.Put("/services/:id/check-script", authMiddleware.RequireRole(models.RoleAdmin), updateServiceCheckScriptHandler(settingsService))
+	settings.Get("/services/:id/readiness-probe", getServiceReadinessProbeHandler(settingsService))
+	settings.Put("/services/:id/readiness-probe", authMiddleware.RequireRole(models.RoleAdmin), updateServiceReadinessProbeHandler(settingsService))
+	settings.Get("/services/:id/max-result-age", getServiceMaxResultAgeHandler(settingsService))
+	settings.Put("/services/:id/max-result-age", authMiddleware.RequireRole(models.RoleAdmin), updateServiceMaxResultAgeHandler(settingsService))
 	settings.Get("/:key", getSettingHandler(settingsService))
 	settings.Put("/:key", authMiddleware.RequireRole(models.RoleAdmin), updateSettingHandler(settingsService))
 	settings.Post("/", authMiddleware.RequireRole(models.RoleAdmin), createSettingHandler(settingsService))
@@ -81,11 +136,11 @@ func RegisterSettingsRoutes(api fiber.Router, settingsService *services.Settings
 
 // getAllSettingsHandler godoc
 // @Summary Get all settings
-// @Description Get all system settings
+// @Description Get all system settings with constraints of known settings, unknown settings have validated=false
 // @Tags settings
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.SystemSettings
+// @Success 200 {array} services.SettingWithConstraints
 // @Security BearerAuth
 // @Router /settings [get]
 func getAllSettingsHandler(settingsService *services.SettingsService) fiber.Handler {
@@ -97,18 +152,18 @@ func getAllSettingsHandler(settingsService *services.SettingsService) fiber.Hand
 			})
 		}
 
-		return c.JSON(settings)
+		return c.JSON(services.DescribeSettings(settings))
 	}
 }
 
 // getSettingsByCategoryHandler godoc
 // @Summary Get settings by category
-// @Description Get all settings in a category
+// @Description Get all settings in a category with constraints of known settings
 // @Tags settings
 // @Accept json
 // @Produce json
 // @Param category path string true "Category name"
-// @Success 200 {array} models.SystemSettings
+// @Success 200 {array} services.SettingWithConstraints
 // @Security BearerAuth
 // @Router /settings/category/{category} [get]
 func getSettingsByCategoryHandler(settingsService *services.SettingsService) fiber.Handler {
@@ -121,7 +176,7 @@ func getSettingsByCategoryHandler(settingsService *services.SettingsService) fib
 			})
 		}
 
-		return c.JSON(settings)
+		return c.JSON(services.DescribeSettings(settings))
 	}
 }
 
@@ -291,16 +346,16 @@ func getDatabaseConfigHandler(settingsService *services.SettingsService) fiber.H
 
 // getOCRConfigHandler godoc
 // @Summary Get OCR config
-// @Description Get OCR configuration
+// @Description Get persisted OCR configuration and values the next check will use
 // @Tags settings
 // @Accept json
 // @Produce json
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} services.OCRConfigState
 // @Security BearerAuth
 // @Router /settings/ocr/config [get]
-func getOCRConfigHandler(settingsService *services.SettingsService) fiber.Handler {
+func getOCRConfigHandler(checkService *services.CheckService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		config, err := settingsService.GetOCRConfig()
+		config, err := checkService.GetOCRConfigState()
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get OCR config",
@@ -311,9 +366,31 @@ func getOCRConfigHandler(settingsService *services.SettingsService) fiber.Handle
 	}
 }
 
+// ocrSelfTestHandler godoc
+// @Summary Run OCR self-test
+// @Description Run tesseract against bundled sample image and report whether binary, language data and recognition work
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Success 200 {object} services.OCRSelfTestResult
+// @Security BearerAuth
+// @Router /settings/ocr/self-test [get]
+func ocrSelfTestHandler(checkService *services.CheckService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		result, err := checkService.RunOCRSelfTest()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(result)
+	}
+}
+
 // updateOCRConfigHandler godoc
 // @Summary Update OCR config
-// @Description Update OCR configuration
+// @Description Update OCR configuration. New tesseract binary and language are tested before saving and apply to the next check.
 // @Tags settings
 // @Accept json
 // @Produce json
@@ -321,7 +398,7 @@ func getOCRConfigHandler(settingsService *services.SettingsService) fiber.Handle
 // @Success 200 {object} MessageResponse
 // @Security BearerAuth
 // @Router /settings/ocr/config [put]
-func updateOCRConfigHandler(settingsService *services.SettingsService) fiber.Handler {
+func updateOCRConfigHandler(checkService *services.CheckService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var config map[string]interface{}
 		if err := c.BodyParser(&config); err != nil {
@@ -330,7 +407,7 @@ func updateOCRConfigHandler(settingsService *services.SettingsService) fiber.Han
 			})
 		}
 
-		if err := settingsService.UpdateOCRConfig(config); err != nil {
+		if err := checkService.UpdateOCRConfig(config); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": err.Error(),
 			})
@@ -391,23 +468,36 @@ func exportSettingsHandler(settingsService *services.SettingsService) fiber.Hand
 
 // getSpamKeywordsHandler godoc
 // @Summary Get spam keywords
-// @Description Get all spam keywords
+// @Description Get spam keywords with pagination
 // @Tags settings
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.SpamKeyword
+// @Param page query int false "Page number" default(1)
+// @Param offset query int false "Items to skip, overrides page"
+// @Param limit query int false "Items per page" default(50)
+// @Param legacy query bool false "Respond with bare array of all keywords, kept for one release"
+// @Success 200 {object} ListResponse{data=[]models.SpamKeyword}
 // @Security BearerAuth
 // @Router /settings/keywords [get]
 func getSpamKeywordsHandler(settingsService *services.SettingsService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		keywords, err := settingsService.GetSpamKeywords()
+		legacy := legacyList(c)
+		pagination := parsePagination(c)
+		if legacy {
+			pagination = Pagination{}
+		}
+
+		keywords, total, err := settingsService.GetSpamKeywords(pagination.Offset, pagination.Limit)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get keywords",
 			})
 		}
 
-		return c.JSON(keywords)
+		if legacy {
+			return c.JSON(keywords)
+		}
+		return sendList(c, keywords, total, pagination)
 	}
 }
 
@@ -432,6 +522,8 @@ func createSpamKeywordHandler(settingsService *services.SettingsService) fiber.H
 
 		keyword := &models.SpamKeyword{
 			Keyword:   req.Keyword,
+			Negations: req.Negations,
+			WholeWord: req.WholeWord,
 			ServiceID: req.ServiceID,
 			IsActive:  true,
 		}
@@ -477,6 +569,12 @@ func updateSpamKeywordHandler(settingsService *services.SettingsService) fiber.H
 		if req.Keyword != "" {
 			updates["keyword"] = req.Keyword
 		}
+		if req.Negations != nil {
+			updates["negations"] = *req.Negations
+		}
+		if req.WholeWord != nil {
+			updates["whole_word"] = *req.WholeWord
+		}
 		if req.ServiceID != nil {
 			updates["service_id"] = req.ServiceID
 		}
@@ -529,23 +627,115 @@ func deleteSpamKeywordHandler(settingsService *services.SettingsService) fiber.H
 
 // getCheckSchedulesHandler godoc
 // @Summary Get check schedules
-// @Description Get all check schedules
+// @Description Get check schedules with pagination
 // @Tags settings
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.CheckSchedule
+// @Param page query int false "Page number" default(1)
+// @Param offset query int false "Items to skip, overrides page"
+// @Param limit query int false "Items per page" default(50)
+// @Param legacy query bool false "Respond with bare array of all schedules, kept for one release"
+// @Success 200 {object} ListResponse{data=[]models.CheckSchedule}
 // @Security BearerAuth
 // @Router /settings/schedules [get]
 func getCheckSchedulesHandler(settingsService *services.SettingsService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		schedules, err := settingsService.GetCheckSchedules()
+		legacy := legacyList(c)
+		pagination := parsePagination(c)
+		if legacy {
+			pagination = Pagination{}
+		}
+
+		schedules, total, err := settingsService.GetCheckSchedules(pagination.Offset, pagination.Limit)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get schedules",
 			})
 		}
 
-		return c.JSON(schedules)
+		if legacy {
+			return c.JSON(schedules)
+		}
+		return sendList(c, schedules, total, pagination)
+	}
+}
+
+// getScheduleStatusHandler godoc
+// @Summary Get schedule status
+// @Description Get default interval check with its alignment mode and next wall-clock boundary, and status of custom schedules
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Success 200 {array} object
+// @Security BearerAuth
+// @Router /settings/schedules/status [get]
+func getScheduleStatusHandler(checkScheduler *scheduler.CheckScheduler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		status := checkScheduler.GetScheduleStatus()
+		if status == nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get schedule status",
+			})
+		}
+
+		return c.JSON(status)
+	}
+}
+
+// getSchedulerHeartbeatHandler godoc
+// @Summary Get scheduler heartbeat
+// @Description Get time of the last completed check sweep and whether the scheduler is stale, i.e. no sweep completed within scheduler_watchdog_minutes
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Success 200 {object} scheduler.HeartbeatStatus
+// @Security BearerAuth
+// @Router /settings/schedules/heartbeat [get]
+func getSchedulerHeartbeatHandler(checkScheduler *scheduler.CheckScheduler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(checkScheduler.Heartbeat())
+	}
+}
+
+// getScheduleRunsHandler godoc
+// @Summary Get schedule runs
+// @Description Get latest triggers of a schedule with the overlap policy decision taken for each, newest first
+// @Tags settings
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param limit query int false "Number of runs (default 50, max 500)"
+// @Success 200 {array} models.ScheduleRun
+// @Security BearerAuth
+// @Router /settings/schedules/{id}/runs [get]
+func getScheduleRunsHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid schedule ID",
+			})
+		}
+
+		limit := c.QueryInt("limit", 50)
+		if limit <= 0 || limit > services.MaxScheduleRuns {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("limit must be between 1 and %d", services.MaxScheduleRuns),
+			})
+		}
+
+		runs, err := settingsService.GetScheduleRuns(uint(id), limit)
+		if err != nil {
+			if err.Error() == "schedule not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get schedule runs",
+			})
+		}
+
+		return c.JSON(runs)
 	}
 }
 
@@ -569,9 +759,15 @@ func createCheckScheduleHandler(settingsService *services.SettingsService) fiber
 		}
 
 		schedule := &models.CheckSchedule{
-			Name:           req.Name,
-			CronExpression: req.CronExpression,
-			IsActive:       req.IsActive,
+			Name:                    req.Name,
+			CronExpression:          req.CronExpression,
+			IsActive:                req.IsActive,
+			CheckMode:               models.CheckMode(req.CheckMode),
+			ServiceCode:             req.ServiceCode,
+			OverlapPolicy:           req.OverlapPolicy,
+			SampleSize:              req.SampleSize,
+			SamplePercent:           req.SamplePercent,
+			SampleMaxStalenessHours: req.SampleMaxStalenessHours,
 		}
 
 		if err := settingsService.CreateCheckSchedule(schedule); err != nil {
@@ -621,6 +817,24 @@ func updateCheckScheduleHandler(settingsService *services.SettingsService) fiber
 		if req.IsActive != nil {
 			updates["is_active"] = *req.IsActive
 		}
+		if req.CheckMode != nil {
+			updates["check_mode"] = models.CheckMode(*req.CheckMode)
+		}
+		if req.ServiceCode != nil {
+			updates["service_code"] = *req.ServiceCode
+		}
+		if req.OverlapPolicy != nil {
+			updates["overlap_policy"] = *req.OverlapPolicy
+		}
+		if req.SampleSize != nil {
+			updates["sample_size"] = *req.SampleSize
+		}
+		if req.SamplePercent != nil {
+			updates["sample_percent"] = *req.SamplePercent
+		}
+		if req.SampleMaxStalenessHours != nil {
+			updates["sample_max_staleness_hours"] = *req.SampleMaxStalenessHours
+		}
 
 		if err := settingsService.UpdateCheckSchedule(uint(id), updates); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -664,28 +878,356 @@ func deleteCheckScheduleHandler(settingsService *services.SettingsService) fiber
 	}
 }
 
+// getCheckScheduleHandler godoc
+// @Summary Get check schedule
+// @Description Get check schedule with explicit phone list and resolved phone count
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /settings/schedules/{id} [get]
+func getCheckScheduleHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid schedule ID",
+			})
+		}
+
+		details, err := settingsService.GetCheckScheduleDetails(uint(id))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(details)
+	}
+}
+
+// addSchedulePhonesHandler godoc
+// @Summary Add schedule phones
+// @Description Add phones to schedule's explicit phone list
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body SchedulePhonesRequest true "Phone IDs"
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /settings/schedules/{id}/phones [post]
+func addSchedulePhonesHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid schedule ID",
+			})
+		}
+
+		var req SchedulePhonesRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		added, err := settingsService.AddSchedulePhones(uint(id), req.PhoneIDs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Schedule phones updated successfully",
+			"added":   added,
+		})
+	}
+}
+
+// removeSchedulePhonesHandler godoc
+// @Summary Remove schedule phones
+// @Description Remove phones from schedule's explicit phone list
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body SchedulePhonesRequest true "Phone IDs"
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /settings/schedules/{id}/phones [delete]
+func removeSchedulePhonesHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid schedule ID",
+			})
+		}
+
+		var req SchedulePhonesRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		removed, err := settingsService.RemoveSchedulePhones(uint(id), req.PhoneIDs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Schedule phones updated successfully",
+			"removed": removed,
+		})
+	}
+}
+
+// getServiceCheckScriptHandler godoc
+// @Summary Get service check script
+// @Description Get ADB interaction script used to check phones with a service
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /settings/services/{id}/check-script [get]
+func getServiceCheckScriptHandler(settingsService *services.SettingsService, checkTuning *services.CheckTuning) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service ID",
+			})
+		}
+
+		script, err := settingsService.GetServiceCheckScript(uint(id), services.DefaultCheckScript(checkTuning))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(script)
+	}
+}
+
+// updateServiceCheckScriptHandler godoc
+// @Summary Update service check script
+// @Description Set ADB interaction script for a service (empty steps restore default)
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Param request body UpdateCheckScriptRequest true "Script steps"
+// @Success 200 {object} MessageResponse
+// @Security BearerAuth
+// @Router /settings/services/{id}/check-script [put]
+func updateServiceCheckScriptHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service ID",
+			})
+		}
+
+		var req UpdateCheckScriptRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		if err := settingsService.UpdateServiceCheckScript(uint(id), req.Steps); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(MessageResponse{
+			Message: "Check script updated successfully",
+		})
+	}
+}
+
+// getServiceReadinessProbeHandler godoc
+// @Summary Get service readiness probe
+// @Description Get app readiness probe used before marking gateways of a service online
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /settings/services/{id}/readiness-probe [get]
+func getServiceReadinessProbeHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service ID",
+			})
+		}
+
+		probe, err := settingsService.GetServiceReadinessProbe(uint(id))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(probe)
+	}
+}
+
+// updateServiceReadinessProbeHandler godoc
+// @Summary Update service readiness probe
+// @Description Enable app readiness probe for gateways of a service (empty package uses built-in one)
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Param request body UpdateReadinessProbeRequest true "Probe configuration"
+// @Success 200 {object} MessageResponse
+// @Security BearerAuth
+// @Router /settings/services/{id}/readiness-probe [put]
+func updateServiceReadinessProbeHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service ID",
+			})
+		}
+
+		var req UpdateReadinessProbeRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		if err := settingsService.UpdateServiceReadinessProbe(uint(id), req.Enabled, req.AppPackage); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(MessageResponse{
+			Message: "Readiness probe updated successfully",
+		})
+	}
+}
+
+// getServiceMaxResultAgeHandler godoc
+// @Summary Get service result max age
+// @Description Get age after which check results of a service are considered stale
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /settings/services/{id}/max-result-age [get]
+func getServiceMaxResultAgeHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service ID",
+			})
+		}
+
+		maxAge, err := settingsService.GetServiceMaxResultAge(uint(id))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(maxAge)
+	}
+}
+
+// updateServiceMaxResultAgeHandler godoc
+// @Summary Update service result max age
+// @Description Set age in hours after which check results of a service are stale (0 uses result_max_age_hours setting)
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Param request body UpdateMaxResultAgeRequest true "Max age"
+// @Success 200 {object} MessageResponse
+// @Security BearerAuth
+// @Router /settings/services/{id}/max-result-age [put]
+func updateServiceMaxResultAgeHandler(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid service ID",
+			})
+		}
+
+		var req UpdateMaxResultAgeRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		if err := settingsService.UpdateServiceMaxResultAge(uint(id), req.Hours); err != nil {
+			status := fiber.StatusBadRequest
+			if err.Error() == "service not found" {
+				status = fiber.StatusNotFound
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(MessageResponse{
+			Message: "Result max age updated successfully",
+		})
+	}
+}
+
 // importSettingsHandler godoc
 // @Summary Import settings
-// @Description Import settings from JSON
+// @Description Import settings from JSON. Each setting is validated against its type and rules, any invalid setting rejects the whole import. With dry_run only the preview of created, updated, unchanged and invalid settings is returned.
 // @Tags settings
 // @Accept json
 // @Produce json
+// @Param dry_run query bool false "Preview changes without applying"
 // @Param settings body []models.SystemSettings true "Settings to import"
-// @Success 200 {object} MessageResponse
+// @Success 200 {object} services.SettingsImportReport
+// @Failure 400 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /settings/import [post]
 func importSettingsHandler(settingsService *services.SettingsService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		data := c.Body()
-
-		if err := settingsService.ImportSettings(data); err != nil {
+		report, err := settingsService.ImportSettings(c.Body(), c.QueryBool("dry_run", false))
+		if err != nil {
+			if errors.Is(err, services.ErrSettingsImportInvalid) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":  err.Error(),
+					"report": report,
+				})
+			}
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
-		return c.JSON(MessageResponse{
-			Message: "Settings imported successfully",
-		})
+		return c.JSON(report)
 	}
 }
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"spam-checker/internal/middleware"
+	"spam-checker/internal/models"
 	"spam-checker/internal/services"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,11 +19,16 @@ func RegisterStatisticsRoutes(api fiber.Router, statisticsService *services.Stat
 	stats.Get("/dashboard", getDashboardStatsHandler(statisticsService))
 	stats.Get("/timeseries", getTimeSeriesStatsHandler(statisticsService))
 	stats.Get("/services", getServiceStatsHandler(statisticsService))
+	stats.Get("/campaigns", getCampaignStatsHandler(statisticsService))
+	stats.Get("/anomaly-baselines", getSpamBaselinesHandler(statisticsService))
 	stats.Get("/keywords", getTopSpamKeywordsHandler(statisticsService))
 	stats.Get("/phone-history", getPhoneSpamHistoryHandler(statisticsService))
+	stats.Get("/phone/:id/timeline", getPhoneTimelineHandler(statisticsService))
 	stats.Get("/trends", getSpamTrendsHandler(statisticsService))
 	stats.Get("/recent-spam", getRecentSpamDetectionsHandler(statisticsService))
 	stats.Get("/export", exportStatisticsHandler(statisticsService))
+	stats.Post("/rebuild", authMiddleware.RequireRole(models.RoleAdmin), rebuildStatisticsHandler(statisticsService))
+	stats.Post("/reconcile", authMiddleware.RequireRole(models.RoleAdmin), reconcileStatisticsHandler(statisticsService))
 }
 
 // getOverviewStatsHandler godoc
@@ -30,12 +37,21 @@ func RegisterStatisticsRoutes(api fiber.Router, statisticsService *services.Stat
 // @Tags statistics
 // @Accept json
 // @Produce json
+// @Param scope query string false "mine counts only phones created by the current user and their checks" Enums(all, mine)
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /statistics/overview [get]
 func getOverviewStatsHandler(statisticsService *services.StatisticsService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		stats, err := statisticsService.GetOverviewStats()
+		ownerID, err := services.PhoneOwnerFromScope(c.Query("scope"), middleware.GetUserID(c))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		stats, err := statisticsService.GetOverviewStats(ownerID)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get overview statistics",
@@ -52,12 +68,21 @@ func getOverviewStatsHandler(statisticsService *services.StatisticsService) fibe
 // @Tags statistics
 // @Accept json
 // @Produce json
+// @Param scope query string false "mine counts only phones created by the current user and their checks" Enums(all, mine)
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /statistics/dashboard [get]
 func getDashboardStatsHandler(statisticsService *services.StatisticsService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		stats, err := statisticsService.GetDashboardStats()
+		ownerID, err := services.PhoneOwnerFromScope(c.Query("scope"), middleware.GetUserID(c))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		stats, err := statisticsService.GetDashboardStats(ownerID)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to get dashboard statistics",
@@ -128,6 +153,56 @@ func getServiceStatsHandler(statisticsService *services.StatisticsService) fiber
 	}
 }
 
+// getCampaignStatsHandler godoc
+// @Summary Get campaign statistics
+// @Description Get spam statistics per phone campaign. Trend compares spam rate of checks in the last days with the preceding period of the same length. Phones without a campaign are grouped as uncategorized.
+// @Tags statistics
+// @Accept json
+// @Produce json
+// @Param days query int false "Trend window in days" default(7)
+// @Success 200 {array} services.CampaignStats
+// @Security BearerAuth
+// @Router /statistics/campaigns [get]
+func getCampaignStatsHandler(statisticsService *services.StatisticsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		days, _ := strconv.Atoi(c.Query("days", "7"))
+		if days < 1 || days > 365 {
+			days = 7
+		}
+
+		stats, err := statisticsService.GetCampaignStats(days)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get campaign statistics",
+			})
+		}
+
+		return c.JSON(stats)
+	}
+}
+
+// getSpamBaselinesHandler godoc
+// @Summary Get spam rate baselines
+// @Description Get median and MAD of spam rates of recent runs per check type and schedule, overall and per service, which spam rate anomaly notifications compare runs with. Ready is false until anomaly_min_runs runs are recorded.
+// @Tags statistics
+// @Accept json
+// @Produce json
+// @Success 200 {array} services.SpamBaseline
+// @Security BearerAuth
+// @Router /statistics/anomaly-baselines [get]
+func getSpamBaselinesHandler(statisticsService *services.StatisticsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		baselines, err := statisticsService.GetSpamBaselines()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get spam rate baselines",
+			})
+		}
+
+		return c.JSON(baselines)
+	}
+}
+
 // getTopSpamKeywordsHandler godoc
 // @Summary Get top spam keywords
 // @Description Get most common spam keywords
@@ -196,6 +271,65 @@ func getPhoneSpamHistoryHandler(statisticsService *services.StatisticsService) f
 	}
 }
 
+// getPhoneTimelineHandler godoc
+// @Summary Get phone check timeline
+// @Description Get check results of a phone bucketed by day with spam, clean, inconclusive and error counts per service. Every day of the range is listed, for a calendar or heatmap view.
+// @Tags statistics
+// @Accept json
+// @Produce json
+// @Param id path int true "Phone ID"
+// @Param from query string false "First day (YYYY-MM-DD or RFC3339), 30 days before to when omitted"
+// @Param to query string false "Last day (YYYY-MM-DD or RFC3339), today when omitted"
+// @Success 200 {object} services.PhoneTimeline
+// @Security BearerAuth
+// @Router /statistics/phone/{id}/timeline [get]
+func getPhoneTimelineHandler(statisticsService *services.StatisticsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		phoneID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid phone ID",
+			})
+		}
+
+		to := time.Now()
+		if toStr := c.Query("to"); toStr != "" {
+			if to, err = parseDiffTime(toStr); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid to, use RFC3339 or YYYY-MM-DD",
+				})
+			}
+		}
+		from := to.AddDate(0, 0, -30)
+		if fromStr := c.Query("from"); fromStr != "" {
+			if from, err = parseDiffTime(fromStr); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid from, use RFC3339 or YYYY-MM-DD",
+				})
+			}
+		}
+
+		timeline, err := statisticsService.GetPhoneTimeline(uint(phoneID), from, to)
+		if err != nil {
+			if err.Error() == "phone number not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			if err.Error() == "from must not be after to" || strings.HasPrefix(err.Error(), "timeline range") {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get phone timeline",
+			})
+		}
+
+		return c.JSON(timeline)
+	}
+}
+
 // getSpamTrendsHandler godoc
 // @Summary Get spam trends
 // @Description Get spam trends over time
@@ -321,3 +455,46 @@ func exportStatisticsHandler(statisticsService *services.StatisticsService) fibe
 		})
 	}
 }
+
+// rebuildStatisticsHandler godoc
+// @Summary Rebuild statistics
+// @Description Replace per phone and service statistics with counters recomputed from check results
+// @Tags statistics
+// @Produce json
+// @Success 200 {object} services.StatisticsRebuildResult
+// @Security BearerAuth
+// @Router /statistics/rebuild [post]
+func rebuildStatisticsHandler(statisticsService *services.StatisticsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		result, err := statisticsService.Rebuild()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to rebuild statistics",
+			})
+		}
+
+		return c.JSON(result)
+	}
+}
+
+// reconcileStatisticsHandler godoc
+// @Summary Reconcile statistics
+// @Description Compare per phone and service statistics with check results and repair differing rows. With dry_run discrepancies are only reported
+// @Tags statistics
+// @Produce json
+// @Param dry_run query bool false "Only report discrepancies"
+// @Success 200 {object} services.StatisticsReconcileReport
+// @Security BearerAuth
+// @Router /statistics/reconcile [post]
+func reconcileStatisticsHandler(statisticsService *services.StatisticsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report, err := statisticsService.Reconcile(c.QueryBool("dry_run", false))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to reconcile statistics",
+			})
+		}
+
+		return c.JSON(report)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"spam-checker/internal/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RegisterPublicStatusRoutes registers unauthenticated status page route
+func RegisterPublicStatusRoutes(api fiber.Router, statusService *services.PublicStatusService) {
+	api.Get("/status", limiter.New(limiter.Config{
+		Max:        60,
+		Expiration: time.Minute,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too many requests",
+			})
+		},
+	}), getPublicStatusHandler(statusService))
+}
+
+// getPublicStatusHandler godoc
+// @Summary Public status
+// @Description Get non-sensitive checker health aggregates without authentication. Supports If-None-Match and If-Modified-Since.
+// @Tags status
+// @Produce json
+// @Success 200 {object} services.PublicStatus
+// @Success 304 "Not modified"
+// @Failure 404 {object} map[string]interface{} "Public status disabled"
+// @Router /status [get]
+func getPublicStatusHandler(statusService *services.PublicStatusService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !statusService.IsEnabled() {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Not found",
+			})
+		}
+
+		snapshot, err := statusService.GetSnapshot()
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Status unavailable",
+			})
+		}
+
+		maxAge := int(time.Until(snapshot.ExpiresAt).Seconds())
+		if maxAge < 0 {
+			maxAge = 0
+		}
+
+		c.Set(fiber.HeaderETag, snapshot.ETag)
+		c.Set(fiber.HeaderLastModified, snapshot.LastModified.UTC().Format(http.TimeFormat))
+		c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", maxAge))
+
+		if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+			if match == snapshot.ETag {
+				return c.SendStatus(fiber.StatusNotModified)
+			}
+		} else if since := c.Get(fiber.HeaderIfModifiedSince); since != "" {
+			if t, err := http.ParseTime(since); err == nil && !snapshot.LastModified.Truncate(time.Second).After(t) {
+				return c.SendStatus(fiber.StatusNotModified)
+			}
+		}
+
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(snapshot.Body)
+	}
+}
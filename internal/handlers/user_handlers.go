@@ -39,6 +39,12 @@ type UpdateProfileRequest struct {
 	Email    string `json:"email"`
 }
 
+// UpdateRealtimeQuotaRequest sets realtime check quota overrides of a user, null restores settings defaults
+type UpdateRealtimeQuotaRequest struct {
+	DailyLimit     *int `json:"daily_limit"`
+	PerMinuteLimit *int `json:"per_minute_limit"`
+}
+
 // ChangeMyPasswordRequest represents current user password change request
 type ChangeMyPasswordRequest struct {
 	OldPassword string `json:"old_password" validate:"required"`
@@ -59,7 +65,7 @@ type MessageResponse struct {
 }
 
 // RegisterUserRoutes registers user management routes
-func RegisterUserRoutes(api fiber.Router, userService *services.UserService, authMiddleware *middleware.AuthMiddleware) {
+func RegisterUserRoutes(api fiber.Router, userService *services.UserService, quotaService *services.RealtimeQuotaService, authMiddleware *middleware.AuthMiddleware) {
 	users := api.Group("/users")
 
 	users.Get("/", authMiddleware.RequireRole(models.RoleAdmin, models.RoleSupervisor), listUsersHandler(userService))
@@ -72,6 +78,9 @@ func RegisterUserRoutes(api fiber.Router, userService *services.UserService, aut
 	users.Put("/:id", authMiddleware.RequireRole(models.RoleAdmin), updateUserHandler(userService))
 	users.Delete("/:id", authMiddleware.RequireRole(models.RoleAdmin), deleteUserHandler(userService))
 	users.Put("/:id/password", authMiddleware.RequireRole(models.RoleAdmin), changeUserPasswordHandler(userService))
+	users.Get("/:id/realtime-quota", authMiddleware.RequireRole(models.RoleAdmin), getRealtimeQuotaHandler(quotaService))
+	users.Put("/:id/realtime-quota", authMiddleware.RequireRole(models.RoleAdmin), updateRealtimeQuotaHandler(quotaService))
+	users.Delete("/:id/realtime-quota", authMiddleware.RequireRole(models.RoleAdmin), resetRealtimeQuotaHandler(quotaService))
 }
 
 // listUsersHandler godoc
@@ -441,3 +450,121 @@ func getUserStatsHandler(userService *services.UserService) fiber.Handler {
 		return c.JSON(stats)
 	}
 }
+
+// getRealtimeQuotaHandler godoc
+// @Summary Get realtime quota
+// @Description Get user's realtime check limits and consumption for today and current minute
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} services.RealtimeQuotaStatus
+// @Security BearerAuth
+// @Router /users/{id}/realtime-quota [get]
+func getRealtimeQuotaHandler(quotaService *services.RealtimeQuotaService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+
+		quota, err := quotaService.GetUsage(uint(id))
+		if err != nil {
+			if err.Error() == "user not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "User not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get realtime quota",
+			})
+		}
+
+		return c.JSON(quota)
+	}
+}
+
+// updateRealtimeQuotaHandler godoc
+// @Summary Update realtime quota limits
+// @Description Override user's daily and per-minute realtime check limits, null restores settings defaults, 0 means unlimited
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body UpdateRealtimeQuotaRequest true "Quota limits"
+// @Success 200 {object} services.RealtimeQuotaStatus
+// @Security BearerAuth
+// @Router /users/{id}/realtime-quota [put]
+func updateRealtimeQuotaHandler(quotaService *services.RealtimeQuotaService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+
+		var req UpdateRealtimeQuotaRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		if err := quotaService.SetUserLimits(uint(id), req.DailyLimit, req.PerMinuteLimit); err != nil {
+			if err.Error() == "user not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "User not found",
+				})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		quota, err := quotaService.GetUsage(uint(id))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get realtime quota",
+			})
+		}
+
+		return c.JSON(quota)
+	}
+}
+
+// resetRealtimeQuotaHandler godoc
+// @Summary Reset realtime quota
+// @Description Clear user's realtime check consumption for today and current minute
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} MessageResponse
+// @Security BearerAuth
+// @Router /users/{id}/realtime-quota [delete]
+func resetRealtimeQuotaHandler(quotaService *services.RealtimeQuotaService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+
+		if err := quotaService.Reset(uint(id)); err != nil {
+			if err.Error() == "user not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "User not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to reset realtime quota",
+			})
+		}
+
+		return c.JSON(MessageResponse{
+			Message: "Realtime quota reset successfully",
+		})
+	}
+}
@@ -19,6 +19,9 @@ var (
 
 	// Context key for request ID
 	RequestIDKey = "request_id"
+
+	// Context key for check trace ID
+	TraceIDKey = "trace_id"
 )
 
 // Config holds logger configuration
@@ -80,6 +83,9 @@ func Initialize(cfg Config) error {
 	// Add hook for caller information
 	Log.AddHook(&CallerHook{})
 
+	// Add hook masking phone numbers when privacy mode is enabled
+	Log.AddHook(&PhoneScrubberHook{})
+
 	return nil
 }
 
@@ -183,16 +189,44 @@ func isGormPackage(name string) bool {
 
 // WithContext creates an entry with context
 func WithContext(ctx context.Context) *logrus.Entry {
-	entry := Log.WithContext(ctx)
+	return EntryWithContext(logrus.NewEntry(Log), ctx)
+}
+
+// EntryWithContext adds request and trace IDs from context to existing entry
+func EntryWithContext(entry *logrus.Entry, ctx context.Context) *logrus.Entry {
+	if ctx == nil {
+		return entry
+	}
+
+	entry = entry.WithContext(ctx)
 
 	// Add request ID from context if present
 	if requestID := ctx.Value(RequestIDKey); requestID != nil {
 		entry = entry.WithField(RequestIDKey, requestID)
 	}
 
+	// Add trace ID from context if present
+	if traceID := ctx.Value(TraceIDKey); traceID != nil {
+		entry = entry.WithField(TraceIDKey, traceID)
+	}
+
 	return entry
 }
 
+// ContextWithTraceID returns context carrying check trace ID
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, TraceIDKey, traceID)
+}
+
+// TraceIDFromContext returns check trace ID stored in context
+func TraceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	traceID, _ := ctx.Value(TraceIDKey).(string)
+	return traceID
+}
+
 // WithField creates an entry with a single field
 func WithField(key string, value interface{}) *logrus.Entry {
 	return Log.WithField(key, value)
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// phoneMasking enables masking of phone numbers in logs and notifications
+var phoneMasking atomic.Bool
+
+// phonePattern matches phone numbers in free-form text: Russian numbers as stored
+// (79123456789) or typed (+7 (912) 345-67-89, 8-912-345-67-89), ten digit mobile
+// numbers without country code, and international numbers written with a plus.
+// Bare digit runs of other shapes, such as timestamps and chat or job IDs, are left intact.
+var phonePattern = regexp.MustCompile(
+	`(?:\+7|\b[78])[ -]?\(?\d{3}\)?[ -]?\d{3}[ -]?\d{2}[ -]?\d{2}\b` +
+		`|\b9\d{9}\b` +
+		`|\+\d{10,14}\b`)
+
+// phoneFieldKeys are log fields holding a phone number, masked whatever their format
+var phoneFieldKeys = map[string]bool{
+	"phone":        true,
+	"phone_number": true,
+	"number":       true,
+}
+
+// maskedPhoneMarker is left by MaskPhone, values carrying it are not masked again
+const maskedPhoneMarker = "***"
+
+// SetPhoneMasking turns masking of phone numbers on or off
+func SetPhoneMasking(enabled bool) {
+	phoneMasking.Store(enabled)
+}
+
+// PhoneMaskingEnabled reports whether phone numbers are masked
+func PhoneMaskingEnabled() bool {
+	return phoneMasking.Load()
+}
+
+// MaskPhone masks middle digits of a phone number, e.g. +79121234567 becomes +7912***4567.
+// Separators are dropped, a leading plus is kept.
+func MaskPhone(number string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	if digits == "" {
+		return number
+	}
+
+	prefix := ""
+	if strings.HasPrefix(strings.TrimSpace(number), "+") {
+		prefix = "+"
+	}
+
+	// Short numbers keep too few digits to be identifiable on both sides
+	if len(digits) <= 8 {
+		keep := min(2, len(digits)-1)
+		return prefix + maskedPhoneMarker + digits[len(digits)-keep:]
+	}
+	return prefix + digits[:4] + maskedPhoneMarker + digits[len(digits)-4:]
+}
+
+// FormatPhone returns phone number as it should appear in logs and notifications
+func FormatPhone(number string) string {
+	if !PhoneMaskingEnabled() {
+		return number
+	}
+	return MaskPhone(number)
+}
+
+// ScrubPhones masks phone numbers found in free-form text when masking is enabled
+func ScrubPhones(text string) string {
+	if !PhoneMaskingEnabled() {
+		return text
+	}
+	return phonePattern.ReplaceAllStringFunc(text, MaskPhone)
+}
+
+// scrubPhoneField masks value of a phone field entirely unless it is masked already
+func scrubPhoneField(value string) string {
+	if strings.Contains(value, maskedPhoneMarker) {
+		return value
+	}
+	return MaskPhone(value)
+}
+
+// PhoneScrubberHook masks phone fields and phone numbers left in log messages and string or error fields
+type PhoneScrubberHook struct{}
+
+func (hook *PhoneScrubberHook) Fire(entry *logrus.Entry) error {
+	if !PhoneMaskingEnabled() {
+		return nil
+	}
+
+	entry.Message = ScrubPhones(entry.Message)
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			if phoneFieldKeys[key] {
+				entry.Data[key] = scrubPhoneField(v)
+				continue
+			}
+			entry.Data[key] = ScrubPhones(v)
+		case error:
+			if scrubbed := ScrubPhones(v.Error()); scrubbed != v.Error() {
+				entry.Data[key] = errors.New(scrubbed)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (hook *PhoneScrubberHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
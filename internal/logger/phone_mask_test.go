@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func withPhoneMasking(t *testing.T) {
+	t.Helper()
+	SetPhoneMasking(true)
+	t.Cleanup(func() { SetPhoneMasking(false) })
+}
+
+func TestMaskPhone(t *testing.T) {
+	tests := map[string]string{
+		"79123456789":        "7912***6789",
+		"+79123456789":       "+7912***6789",
+		"+7 (912) 345-67-89": "+7912***6789",
+		"12345":              "***45",
+		"":                   "",
+	}
+	for in, want := range tests {
+		if got := MaskPhone(in); got != want {
+			t.Errorf("MaskPhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestScrubPhonesMasksPhoneFormats(t *testing.T) {
+	withPhoneMasking(t)
+
+	tests := map[string]string{
+		"calling 79123456789 now":              "calling 7912***6789 now",
+		"calling +79123456789":                 "calling +7912***6789",
+		"calling +7 (912) 345-67-89 now":       "calling +7912***6789 now",
+		"calling +7 912 345 67 89":             "calling +7912***6789",
+		"calling 8-912-345-67-89":              "calling 8912***6789",
+		"calling 8 (912) 345-67-89":            "calling 8912***6789",
+		"mobile 9123456789 without code":       "mobile 9123***6789 without code",
+		"international +380501234567":          "international +3805***4567",
+		"phone=79123456789,service=kaspersky":  "phone=7912***6789,service=kaspersky",
+		"two 79123456789 and +7 912 345-67-00": "two 7912***6789 and +7912***6700",
+	}
+	for in, want := range tests {
+		if got := ScrubPhones(in); got != want {
+			t.Errorf("ScrubPhones(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestScrubPhonesKeepsOtherNumbers(t *testing.T) {
+	withPhoneMasking(t)
+
+	for _, text := range []string{
+		"unix time 1760601600",
+		"unix millis 1760601600123",
+		"telegram chat -1001234567890",
+		"job 550e8400-e29b-41d4-a716-446655440000",
+		"import job 123456789012 done",
+		"gateway gw79123456789",
+		"took 12345678 ns",
+		"at 2026-10-16 09:15:02",
+	} {
+		if got := ScrubPhones(text); got != text {
+			t.Errorf("ScrubPhones(%q) = %q, want unchanged", text, got)
+		}
+	}
+}
+
+func TestScrubPhonesDisabled(t *testing.T) {
+	SetPhoneMasking(false)
+
+	if got := ScrubPhones("calling 79123456789"); got != "calling 79123456789" {
+		t.Errorf("ScrubPhones with masking off = %q", got)
+	}
+}
+
+func TestPhoneScrubberHookMasksFields(t *testing.T) {
+	withPhoneMasking(t)
+
+	entry := &logrus.Entry{
+		Message: "check of +7 (912) 345-67-89 failed",
+		Data: logrus.Fields{
+			"phone":        "+7 912 3456789",
+			"phone_number": FormatPhone("79123456789"),
+			"chat_id":      "1234567890123",
+			"error":        errors.New("dial 89123456789: busy"),
+			"attempt":      3,
+		},
+	}
+	if err := (&PhoneScrubberHook{}).Fire(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Message != "check of +7912***6789 failed" {
+		t.Errorf("message = %q", entry.Message)
+	}
+	if got := entry.Data["phone"]; got != "+7912***6789" {
+		t.Errorf("phone field = %v", got)
+	}
+	if got := entry.Data["phone_number"]; got != "7912***6789" {
+		t.Errorf("already masked field = %v, want it left as is", got)
+	}
+	if got := entry.Data["chat_id"]; got != "1234567890123" {
+		t.Errorf("chat_id field = %v, want unchanged", got)
+	}
+	if got := entry.Data["error"].(error).Error(); got != "dial 8912***6789: busy" {
+		t.Errorf("error field = %q", got)
+	}
+	if got := entry.Data["attempt"]; got != 3 {
+		t.Errorf("attempt field = %v", got)
+	}
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// errBodyTooLarge is returned when body or one of its decoded stages exceeds the limit
+	errBodyTooLarge = errors.New("request body is too large")
+	// errUnsupportedEncoding is returned for content codings the server cannot decode
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
+)
+
+// UploadLimit allows multipart bodies up to Limit bytes on one route
+type UploadLimit struct {
+	Method string
+	Path   string // Route pattern, :param segments match any value
+	Limit  int
+}
+
+// matches reports whether request method and path belong to upload route
+func (u UploadLimit) matches(method, path string) bool {
+	if method != u.Method {
+		return false
+	}
+	pattern := strings.Split(strings.Trim(u.Path, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, part := range pattern {
+		if strings.HasPrefix(part, ":") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// NewBodyLimit rejects requests whose body exceeds limit bytes with 413. Multipart bodies get the
+// limit of the matching upload route and limit elsewhere. Compressed bodies are decoded here through
+// a bounded reader, so a small gzip payload cannot expand past the limit, and handlers receive
+// the decoded body without Content-Encoding.
+func NewBodyLimit(limit int, uploads ...UploadLimit) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if limit <= 0 {
+			return c.Next()
+		}
+
+		effective := limit
+		if strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMEMultipartForm) {
+			for _, upload := range uploads {
+				if upload.matches(c.Method(), c.Path()) {
+					effective = upload.Limit
+					break
+				}
+			}
+		}
+
+		body := c.Request().Body()
+		if c.Request().Header.ContentLength() > effective || len(body) > effective {
+			return bodyTooLarge(c, effective)
+		}
+
+		encodings := contentEncodings(c.Get(fiber.HeaderContentEncoding))
+		if len(encodings) == 0 {
+			return c.Next()
+		}
+
+		decoded, err := decodeBody(body, encodings, effective)
+		switch {
+		case errors.Is(err, errBodyTooLarge):
+			return bodyTooLarge(c, effective)
+		case errors.Is(err, errUnsupportedEncoding):
+			return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case err != nil:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to decode request body",
+			})
+		}
+
+		c.Request().SetBody(decoded)
+		c.Request().Header.Del(fiber.HeaderContentEncoding)
+		return c.Next()
+	}
+}
+
+func bodyTooLarge(c *fiber.Ctx, limit int) error {
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+		"error": "Request body is too large",
+		"limit": limit,
+	})
+}
+
+// contentEncodings lists codings of Content-Encoding in the order they were applied, identity is dropped
+func contentEncodings(header string) []string {
+	var encodings []string
+	for _, encoding := range strings.Split(header, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding != "" && encoding != "identity" {
+			encodings = append(encodings, encoding)
+		}
+	}
+	return encodings
+}
+
+// decodeBody undoes codings in reverse order of application. Every stage is read through
+// a reader bounded to limit bytes, so decoding stops as soon as output passes the limit.
+func decodeBody(body []byte, encodings []string, limit int) ([]byte, error) {
+	for i := len(encodings) - 1; i >= 0; i-- {
+		reader, err := newBodyDecoder(encodings[i], bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		decoded, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+		if closer, ok := reader.(io.Closer); ok {
+			closer.Close()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s body: %w", encodings[i], err)
+		}
+		if len(decoded) > limit {
+			return nil, errBodyTooLarge
+		}
+		body = decoded
+	}
+	return body, nil
+}
+
+// newBodyDecoder returns reader decoding one content coding, deflate is zlib-wrapped as in HTTP
+func newBodyDecoder(encoding string, reader io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(reader)
+	case "deflate":
+		return zlib.NewReader(reader)
+	case "br", "brotli":
+		return brotli.NewReader(reader), nil
+	}
+	return nil, fmt.Errorf("%w: %s", errUnsupportedEncoding, encoding)
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+)
+
+const testBodyLimit = 1024
+
+// newBodyLimitApp echoes received body so tests can see what handlers get
+func newBodyLimitApp() *fiber.App {
+	app := fiber.New()
+	app.Use(NewBodyLimit(testBodyLimit,
+		UploadLimit{Method: fiber.MethodPost, Path: "/uploads/:id/file", Limit: 4 * testBodyLimit},
+	))
+	app.Post("/*", func(c *fiber.Ctx) error {
+		return c.Send(c.Body())
+	})
+	return app
+}
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "deflate":
+		writer = zlib.NewWriter(&buf)
+	case "br":
+		writer = brotli.NewWriter(&buf)
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func send(t *testing.T, app *fiber.App, path, contentType, encoding string, body []byte) (int, []byte) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, data
+}
+
+func TestBodyLimitPlainBody(t *testing.T) {
+	app := newBodyLimitApp()
+
+	if status, _ := send(t, app, "/settings", fiber.MIMEApplicationJSON, "", bytes.Repeat([]byte("a"), testBodyLimit)); status != http.StatusOK {
+		t.Fatalf("body at limit: status = %d", status)
+	}
+	if status, _ := send(t, app, "/settings", fiber.MIMEApplicationJSON, "", bytes.Repeat([]byte("a"), testBodyLimit+1)); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body over limit: status = %d", status)
+	}
+}
+
+func TestBodyLimitDecodesCompressedBody(t *testing.T) {
+	app := newBodyLimitApp()
+	payload := []byte(`{"value":"` + strings.Repeat("x", 100) + `"}`)
+
+	for _, encoding := range []string{"gzip", "deflate", "br"} {
+		t.Run(encoding, func(t *testing.T) {
+			status, body := send(t, app, "/settings", fiber.MIMEApplicationJSON, encoding, compress(t, encoding, payload))
+			if status != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", status, body)
+			}
+			if !bytes.Equal(body, payload) {
+				t.Fatalf("handler got %q, want decoded payload", body)
+			}
+		})
+	}
+}
+
+func TestBodyLimitRejectsCompressionBomb(t *testing.T) {
+	app := newBodyLimitApp()
+
+	// Compresses to about a hundred bytes, far below the limit
+	bomb := compress(t, "gzip", bytes.Repeat([]byte{0}, 64*testBodyLimit))
+	if len(bomb) >= testBodyLimit {
+		t.Fatalf("compressed size %d is not below the limit", len(bomb))
+	}
+	if status, _ := send(t, app, "/settings", fiber.MIMEApplicationJSON, "gzip", bomb); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", status)
+	}
+
+	// Decoded size exactly at the limit passes
+	atLimit := compress(t, "gzip", bytes.Repeat([]byte{0}, testBodyLimit))
+	if status, _ := send(t, app, "/settings", fiber.MIMEApplicationJSON, "gzip", atLimit); status != http.StatusOK {
+		t.Fatalf("decoded body at limit: status = %d", status)
+	}
+}
+
+func TestBodyLimitStackedEncodings(t *testing.T) {
+	app := newBodyLimitApp()
+	payload := []byte(`{"stacked":true}`)
+
+	// Content-Encoding lists codings in the order they were applied
+	body := compress(t, "br", compress(t, "gzip", payload))
+	status, got := send(t, app, "/settings", fiber.MIMEApplicationJSON, "gzip, br", body)
+	if status != http.StatusOK || !bytes.Equal(got, payload) {
+		t.Fatalf("status = %d, body = %q", status, got)
+	}
+}
+
+func TestBodyLimitBadEncodings(t *testing.T) {
+	app := newBodyLimitApp()
+
+	if status, _ := send(t, app, "/settings", fiber.MIMEApplicationJSON, "compress", []byte("x")); status != http.StatusUnsupportedMediaType {
+		t.Fatalf("unknown coding: status = %d, want 415", status)
+	}
+	if status, _ := send(t, app, "/settings", fiber.MIMEApplicationJSON, "gzip", []byte("not gzip")); status != http.StatusBadRequest {
+		t.Fatalf("corrupt gzip: status = %d, want 400", status)
+	}
+	if status, _ := send(t, app, "/settings", fiber.MIMEApplicationJSON, "identity", []byte("{}")); status != http.StatusOK {
+		t.Fatalf("identity: status = %d, want 200", status)
+	}
+}
+
+func multipartBody(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile("file", "data.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(bytes.Repeat([]byte("1"), size))
+	writer.Close()
+	return writer.FormDataContentType(), buf.Bytes()
+}
+
+func TestBodyLimitMultipartOnlyOnUploadRoutes(t *testing.T) {
+	app := newBodyLimitApp()
+	contentType, body := multipartBody(t, 2*testBodyLimit)
+
+	if status, _ := send(t, app, "/uploads/7/file", contentType, "", body); status != http.StatusOK {
+		t.Fatalf("upload route: status = %d, want 200", status)
+	}
+	if status, _ := send(t, app, "/settings", contentType, "", body); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("multipart to other route: status = %d, want 413", status)
+	}
+
+	contentType, body = multipartBody(t, 5*testBodyLimit)
+	if status, _ := send(t, app, "/uploads/7/file", contentType, "", body); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("upload over its limit: status = %d, want 413", status)
+	}
+}
+
+func TestUploadLimitMatches(t *testing.T) {
+	upload := UploadLimit{Method: fiber.MethodPost, Path: "/api/v1/adb/gateways/:id/install-apk"}
+
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{fiber.MethodPost, "/api/v1/adb/gateways/3/install-apk", true},
+		{fiber.MethodPost, "/api/v1/adb/gateways/3/install-apk/", true},
+		{fiber.MethodPut, "/api/v1/adb/gateways/3/install-apk", false},
+		{fiber.MethodPost, "/api/v1/adb/gateways//install-apk", false},
+		{fiber.MethodPost, "/api/v1/adb/gateways/3/install-apk/extra", false},
+		{fiber.MethodPost, "/api/v1/adb/gateways/3", false},
+	}
+	for _, tt := range tests {
+		if got := upload.matches(tt.method, tt.path); got != tt.want {
+			t.Errorf("matches(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
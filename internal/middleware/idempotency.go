@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"errors"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/services"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// IdempotencyKeyHeader is the request header carrying client generated idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength limits stored key size
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware replays stored responses of create requests retried with the same Idempotency-Key
+type IdempotencyMiddleware struct {
+	service *services.IdempotencyService
+	log     *logrus.Entry
+}
+
+func NewIdempotencyMiddleware(service *services.IdempotencyService) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		service: service,
+		log:     logger.WithField("middleware", "Idempotency"),
+	}
+}
+
+// Handle executes request once per key: a retry with the same key and body gets stored response,
+// reuse of the key with a different body is rejected. Requests without the header pass through.
+// Must run after Protect, keys are scoped to the authenticated user.
+func (m *IdempotencyMiddleware) Handle() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get(IdempotencyKeyHeader))
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Idempotency-Key is too long",
+			})
+		}
+
+		userID := GetUserID(c)
+		stored, err := m.service.Begin(userID, key, c.Method(), c.Path(), c.Body())
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrIdempotencyKeyMismatch):
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": "Idempotency-Key was already used with a different request",
+				})
+			case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Request with this Idempotency-Key is still in progress",
+				})
+			}
+			m.log.Errorf("Failed to check idempotency key: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check idempotency key",
+			})
+		}
+
+		if stored != nil {
+			c.Set("Idempotent-Replayed", "true")
+			if stored.ContentType != "" {
+				c.Set(fiber.HeaderContentType, stored.ContentType)
+			}
+			return c.Status(stored.StatusCode).Send(stored.ResponseBody)
+		}
+
+		// Reservation is dropped unless a response gets stored, so failed requests can be retried
+		completed := false
+		defer func() {
+			if !completed {
+				m.service.Release(userID, key)
+			}
+		}()
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		// Server errors are not replayed, retry may succeed
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			return nil
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		if err := m.service.Complete(userID, key, status, string(c.Response().Header.ContentType()), body); err != nil {
+			m.log.Warnf("Failed to store response for idempotency key %q: %v", key, err)
+			return nil
+		}
+		completed = true
+
+		return nil
+	}
+}
@@ -2,7 +2,10 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 	"strings"
 	"time"
 )
@@ -18,6 +21,10 @@ type User struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Realtime check quota overrides, nil uses realtime_quota_* settings
+	RealtimeDailyLimit     *int `json:"realtime_daily_limit,omitempty"`
+	RealtimePerMinuteLimit *int `json:"realtime_per_minute_limit,omitempty"`
 }
 
 // UserRole represents user role in system
@@ -31,27 +38,46 @@ const (
 
 // PhoneNumber represents company phone number
 type PhoneNumber struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	Number       string         `gorm:"unique;not null" json:"number"`
-	Description  string         `json:"description"`
-	IsActive     bool           `gorm:"default:true" json:"is_active"`
-	CreatedBy    uint           `json:"created_by"`
-	User         User           `gorm:"foreignKey:CreatedBy" json:"-"`
-	CheckResults []CheckResult  `json:"check_results,omitempty"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	Number           string         `gorm:"unique;not null" json:"number"`
+	NormalizedNumber *string        `gorm:"uniqueIndex:idx_phone_normalized_number" json:"-"` // NULL for deleted rows
+	Description      string         `json:"description"`
+	Campaign         string         `gorm:"size:100;index" json:"campaign,omitempty"` // Empty groups under "uncategorized" in statistics
+	IsActive         bool           `gorm:"default:true" json:"is_active"`
+	Blocked          bool           `gorm:"default:false;index" json:"blocked"` // Never checked or allocated, kept for history
+	BlockedReason    string         `json:"blocked_reason,omitempty"`
+	BlockedAt        *time.Time     `json:"blocked_at,omitempty"`
+	Origin           string         `gorm:"size:20;default:manual;index" json:"origin"` // How the row was created, see PhoneOrigin* constants
+	CreatedBy        uint           `json:"created_by"`
+	User             User           `gorm:"foreignKey:CreatedBy" json:"-"`
+	CheckResults     []CheckResult  `json:"check_results,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// Phone number origins
+const (
+	PhoneOriginManual    = "manual"    // Added by an operator or imported
+	PhoneOriginRealtime  = "realtime"  // Created by a realtime check of an unknown number
+	PhoneOriginGenerated = "generated" // Synthetic number created by range generation for testing
+)
+
 // SpamService represents spam check service
 type SpamService struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"unique;not null" json:"name"`
-	Code      string    `gorm:"unique;not null" json:"code"`
-	IsActive  bool      `gorm:"default:true" json:"is_active"`
-	IsCustom  bool      `gorm:"default:false" json:"is_custom"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	Name              string    `gorm:"unique;not null" json:"name"`
+	Code              string    `gorm:"unique;not null" json:"code"`
+	IsActive          bool      `gorm:"default:true" json:"is_active"`
+	IsCustom          bool      `gorm:"default:false" json:"is_custom"`
+	CheckScript       string    `gorm:"type:text" json:"check_script,omitempty"` // JSON array of ADB steps
+	ReadinessProbe    bool      `gorm:"default:false" json:"readiness_probe"`    // Verify app before marking gateway online
+	AppPackage        string    `json:"app_package,omitempty"`                   // Overrides built-in app package
+	APIPolicy         string    `gorm:"default:call_all" json:"api_policy"`      // How API services of this code are called
+	MaxResultAgeHours int       `gorm:"default:0" json:"max_result_age_hours"`   // Results older than this are stale, 0 uses result_max_age_hours setting
+	Config            string    `gorm:"type:text" json:"config,omitempty"`       // JSON check configuration, see services.ServiceConfig
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 // StringArray custom type for PostgreSQL text[] array
@@ -136,6 +162,14 @@ func (a *StringArray) scanBytes(src []byte) error {
 	return nil
 }
 
+// GormDBDataType stores array literal as plain text on databases without array support
+func (StringArray) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "sqlite" {
+		return "text"
+	}
+	return "text[]"
+}
+
 // Value implements driver.Valuer interface for StringArray
 func (a StringArray) Value() (driver.Value, error) {
 	if len(a) == 0 {
@@ -159,95 +193,537 @@ func (a StringArray) Value() (driver.Value, error) {
 	return "{" + strings.Join(elements, ",") + "}", nil
 }
 
+// Texts a keyword match was found in
+const (
+	KeywordMatchText     = "text"     // OCR text or text extracted from API response
+	KeywordMatchResponse = "response" // Raw API response
+	KeywordMatchKeywords = "keywords" // Value extracted by keyword paths of API service
+)
+
+// KeywordMatch is an occurrence of a found spam keyword
+type KeywordMatch struct {
+	Keyword string `json:"keyword"`
+	Source  string `json:"source"`  // text, response or keywords
+	Start   int    `json:"start"`   // Offset of the match in characters of the source
+	End     int    `json:"end"`     // Offset right after the match
+	Snippet string `json:"snippet"` // Match with up to 40 characters around it
+}
+
+// KeywordMatches is stored as JSON text
+type KeywordMatches []KeywordMatch
+
+// Scan implements sql.Scanner interface for KeywordMatches
+func (m *KeywordMatches) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported keyword matches type %T", value)
+	}
+	if len(data) == 0 {
+		*m = nil
+		return nil
+	}
+	return json.Unmarshal(data, m)
+}
+
+// Value implements driver.Valuer interface for KeywordMatches
+func (m KeywordMatches) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal([]KeywordMatch(m))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
 // CheckResult represents spam check result
 type CheckResult struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	PhoneNumberID   uint           `json:"phone_number_id"`
+	PhoneNumber     PhoneNumber    `gorm:"foreignKey:PhoneNumberID" json:"-"`
+	ServiceID       uint           `json:"service_id"`
+	Service         SpamService    `gorm:"foreignKey:ServiceID" json:"service"`
+	IsSpam          bool           `json:"is_spam"`
+	Inconclusive    bool           `gorm:"default:false;index" json:"inconclusive"` // OCR text too short to trust a clean verdict
+	Status          string         `gorm:"size:20;index" json:"status"`             // spam, clean, inconclusive, suspected or error
+	Confidence      float64        `gorm:"default:1" json:"confidence"`             // Trust in the spam detection from 0 to 1, 1 without detection
+	Error           string         `json:"error,omitempty"`                         // Why the check failed, for error status
+	FoundKeywords   StringArray    `gorm:"type:text[]" json:"found_keywords"`
+	KeywordMatches  KeywordMatches `gorm:"type:text" json:"keyword_matches,omitempty"` // Where found keywords occur, see KeywordMatch
+	CleanPhrases    StringArray    `gorm:"type:text[]" json:"clean_phrases,omitempty"` // Clean labels of the service app recognized on screen
+	Screenshot      string         `json:"screenshot"`                                 // Storage reference, e.g. local://screenshots/... or s3://...
+	ScreenshotError string         `json:"screenshot_error,omitempty"`                 // Why screenshot could not be persisted
+	RawText         string         `json:"raw_text"`
+	RawResponse     string         `json:"raw_response"`                                                                        // For API responses
+	APIServiceID    *uint          `gorm:"index" json:"api_service_id,omitempty"`                                               // API provider that produced the result
+	GatewayID       *uint          `gorm:"index" json:"gateway_id,omitempty"`                                                   // ADB gateway that produced the result
+	APIServiceName  string         `gorm:"-:all" json:"api_service_name,omitempty"`                                             // Filled on read, see APIServiceID
+	GatewayName     string         `gorm:"-:all" json:"gateway_name,omitempty"`                                                 // Filled on read, see GatewayID
+	KeywordsHash    string         `gorm:"column:keywords_snapshot_hash;size:64;index" json:"keywords_snapshot_hash,omitempty"` // Active keyword set, see KeywordSnapshot
+	KeywordsCount   int            `json:"keywords_count"`
+	Source          string         `gorm:"default:check;index" json:"source"`                            // check, import
+	ImportKey       *string        `gorm:"uniqueIndex:idx_check_result_import_key" json:"-"`             // Deduplicates imported rows
+	CorrelationID   *string        `gorm:"size:36;uniqueIndex:idx_check_result_correlation_id" json:"-"` // Deduplicates retried and journaled saves of a check
+	Note            string         `json:"note,omitempty"`
+	Verification    string         `gorm:"size:20;index" json:"verification,omitempty"` // Re-check state of a new spam flag, see SpamVerification
+	DecisionSource  string         `gorm:"size:20" json:"decision_source,omitempty"`    // rules or keywords when decision rules are configured, keywords if they failed
+	DecisionRule    string         `gorm:"size:100" json:"decision_rule,omitempty"`     // Decision rule that flagged spam
+	DecisionError   string         `gorm:"type:text" json:"decision_error,omitempty"`   // Why decision rules failed
+	CheckedAt       time.Time      `json:"checked_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// Verification states of a check result that flagged a phone as spam
+const (
+	VerificationPending     = "pending"     // Waiting for re-check, not notified
+	VerificationConfirmed   = "confirmed"   // Re-check found spam again
+	VerificationUnconfirmed = "unconfirmed" // Re-check found the phone clean, flag ignored
+	VerificationUnverified  = "unverified"  // Re-check gave no verdict in time, flag notified anyway
+)
+
+// SpamVerification is a new spam flag waiting for a re-check of the phone on the same service
+// before it is notified about. The row is removed once the flag is resolved.
+type SpamVerification struct {
 	ID            uint        `gorm:"primaryKey" json:"id"`
-	PhoneNumberID uint        `json:"phone_number_id"`
+	PhoneNumberID uint        `gorm:"index" json:"phone_number_id"`
 	PhoneNumber   PhoneNumber `gorm:"foreignKey:PhoneNumberID" json:"-"`
-	ServiceID     uint        `json:"service_id"`
+	ServiceID     uint        `gorm:"index" json:"service_id"`
 	Service       SpamService `gorm:"foreignKey:ServiceID" json:"service"`
-	IsSpam        bool        `json:"is_spam"`
-	FoundKeywords StringArray `gorm:"type:text[]" json:"found_keywords"`
-	Screenshot    string      `json:"screenshot"`
-	RawText       string      `json:"raw_text"`
-	RawResponse   string      `json:"raw_response"` // For API responses
-	CheckedAt     time.Time   `json:"checked_at"`
+	CheckResultID uint        `json:"check_result_id"`            // Result that raised the flag
+	FromStatus    string      `gorm:"size:20" json:"from_status"` // Verdict before the flag, unknown or clean
+	Attempts      int         `json:"attempts"`
+	DueAt         time.Time   `gorm:"index" json:"due_at"`
 	CreatedAt     time.Time   `json:"created_at"`
 }
 
+// CheckEvent is a stage of a single gateway or API check, written when check event log is enabled
+type CheckEvent struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	RunID         string    `gorm:"size:36;index" json:"run_id"`   // Correlates events of one gateway or API check, including retries
+	TraceID       string    `gorm:"size:36;index" json:"trace_id"` // Phone check the run belongs to
+	CheckResultID *uint     `gorm:"index" json:"check_result_id"`  // Set once the run saved a result
+	PhoneNumberID uint      `gorm:"index" json:"phone_number_id"`
+	ServiceID     uint      `json:"service_id"`
+	GatewayID     *uint     `json:"gateway_id,omitempty"`
+	APIServiceID  *uint     `json:"api_service_id,omitempty"`
+	Stage         string    `gorm:"size:30;not null" json:"stage"`
+	Message       string    `gorm:"type:text" json:"message,omitempty"`
+	ElapsedMs     int64     `json:"elapsed_ms"`  // Since the run started
+	DurationMs    int64     `json:"duration_ms"` // Since the previous event of the run
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Check result sources
+const (
+	CheckSourceCheck  = "check"
+	CheckSourceImport = "import"
+)
+
+// KeywordSnapshot represents a keyword set used for matching, keyed by its hash.
+// A row is written only when the active set changes.
+type KeywordSnapshot struct {
+	Hash      string      `gorm:"primaryKey;size:64" json:"hash"`
+	Keywords  StringArray `gorm:"type:text[]" json:"keywords"`
+	Count     int         `json:"count"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
 // ADBGateway represents Android Debug Bridge gateway
 type ADBGateway struct {
+	ID                     uint       `gorm:"primaryKey" json:"id"`
+	Name                   string     `gorm:"unique;not null" json:"name"`
+	Host                   string     `gorm:"not null" json:"host"`
+	Port                   int        `gorm:"not null" json:"port"`
+	DeviceID               string     `json:"device_id"`
+	ServiceCode            string     `json:"service_code"`
+	IsActive               bool       `gorm:"default:true" json:"is_active"`
+	Status                 string     `gorm:"default:offline" json:"status"`
+	IsDocker               bool       `gorm:"default:false" json:"is_docker"`
+	DockerHost             string     `json:"docker_host,omitempty"` // Docker daemon running the container, empty for the default host
+	ContainerID            string     `json:"container_id"`
+	VNCPort                int        `json:"vnc_port"`
+	ADBPort1               int        `json:"adb_port1"`
+	ADBPort2               int        `json:"adb_port2"`
+	MinCallIntervalSeconds int        `json:"min_call_interval_seconds"`         // Minimum seconds between simulated calls, 0 uses gateway_min_call_interval_seconds setting
+	ConsoleFailures        int64      `gorm:"default:0" json:"console_failures"` // Emulator console failures that required reconnect
+	LastConsoleFailureAt   *time.Time `json:"last_console_failure_at,omitempty"`
+	LastCallAt             *time.Time `json:"last_call_at,omitempty"` // End of the last simulated call, spaces calls of all instances
+	LastPing               *time.Time `json:"last_ping"`
+	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+}
+
+// GatewayEvent records a notable incident on a gateway, such as forced cleanup of a stuck call
+type GatewayEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	GatewayID uint      `gorm:"not null;index" json:"gateway_id"`
+	Type      string    `gorm:"size:50;not null" json:"type"`
+	Message   string    `gorm:"type:text" json:"message"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// APKFile represents an uploaded APK build of a service app.
+// Binary is kept on disk under APK storage path, StorageKey is relative to it.
+type APKFile struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ServiceCode string    `gorm:"not null;index" json:"service_code"`
+	PackageName string    `gorm:"not null" json:"package_name"`
+	VersionName string    `json:"version_name"`
+	VersionCode int64     `json:"version_code"`
+	FileName    string    `json:"file_name"` // Original upload name
+	StorageKey  string    `gorm:"not null;unique" json:"-"`
+	Size        int64     `json:"size"`
+	SHA256      string    `gorm:"size:64;index" json:"sha256"`
+	IsDefault   bool      `gorm:"default:false" json:"is_default"` // Installed on new gateways of the service
+	UploadedBy  uint      `json:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// APK update job statuses
+const (
+	APKUpdatePending   = "pending"
+	APKUpdateRunning   = "running"
+	APKUpdateCompleted = "completed"
+	APKUpdateFailed    = "failed"
+)
+
+// APK update results of a single gateway
+const (
+	APKUpdateGatewayUpdated = "updated"
+	APKUpdateGatewaySkipped = "skipped" // Already has this or a newer version, or cannot be updated
+	APKUpdateGatewayFailed  = "failed"
+)
+
+// APKUpdateJob represents download of the latest APK of a service from its source URL
+// and its installation on gateways of the service
+type APKUpdateJob struct {
 	ID          uint       `gorm:"primaryKey" json:"id"`
-	Name        string     `gorm:"unique;not null" json:"name"`
-	Host        string     `gorm:"not null" json:"host"`
-	Port        int        `gorm:"not null" json:"port"`
-	DeviceID    string     `json:"device_id"`
-	ServiceCode string     `json:"service_code"`
-	IsActive    bool       `gorm:"default:true" json:"is_active"`
-	Status      string     `gorm:"default:offline" json:"status"`
-	IsDocker    bool       `gorm:"default:false" json:"is_docker"`
-	ContainerID string     `json:"container_id"`
-	VNCPort     int        `json:"vnc_port"`
-	ADBPort1    int        `json:"adb_port1"`
-	ADBPort2    int        `json:"adb_port2"`
-	LastPing    *time.Time `json:"last_ping"`
+	ServiceCode string     `gorm:"size:50;index" json:"service_code"`
+	SourceURL   string     `gorm:"size:500" json:"source_url"`
+	APKID       *uint      `json:"apk_id,omitempty"` // Library APK downloaded by the job
+	Status      string     `gorm:"size:20;index;default:pending" json:"status"`
+	Gateways    int        `json:"gateways"`
+	Updated     int        `json:"updated"`
+	Skipped     int        `json:"skipped"`
+	Failed      int        `json:"failed"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	Instance    string     `gorm:"size:150" json:"instance,omitempty"` // Application instance running the job
+	CreatedBy   uint       `json:"created_by"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
+// APKUpdateResult records what an APK update job did on a gateway
+type APKUpdateResult struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	JobID           uint      `gorm:"not null;index" json:"job_id"`
+	GatewayID       uint      `gorm:"index" json:"gateway_id"`
+	GatewayName     string    `json:"gateway_name"`
+	Status          string    `gorm:"size:20" json:"status"`
+	PreviousVersion int64     `json:"previous_version"` // Version code installed before, 0 when the app was missing
+	Version         int64     `json:"version"`          // Version code installed after the job
+	Error           string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
 // APIService represents external API service for spam checking
 type APIService struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Name         string    `gorm:"unique;not null" json:"name"`
-	ServiceCode  string    `gorm:"not null" json:"service_code"`
-	APIURL       string    `gorm:"not null" json:"api_url"`
-	Headers      string    `gorm:"type:jsonb" json:"headers"`
-	Method       string    `gorm:"default:GET" json:"method"`
-	RequestBody  string    `json:"request_body,omitempty"`
-	IsActive     bool      `gorm:"default:true" json:"is_active"`
-	Timeout      int       `gorm:"default:30" json:"timeout"` // seconds
-	KeywordPaths string    `json:"keyword_paths,omitempty"`
-	ResponsePath string    `json:"response_path,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"unique;not null" json:"name"`
+	ServiceCode   string    `gorm:"not null" json:"service_code"`
+	Type          string    `gorm:"default:http" json:"type"`              // http or telegram_bot
+	APIURL        string    `gorm:"not null" json:"api_url"`               // Relay base URL for telegram_bot
+	BotConfig     string    `gorm:"type:text" json:"bot_config,omitempty"` // JSON settings of telegram_bot, see services.TelegramBotConfig
+	Headers       string    `gorm:"type:jsonb" json:"headers"`
+	Method        string    `gorm:"default:GET" json:"method"`
+	RequestBody   string    `json:"request_body,omitempty"` // Go template, see services.APITemplateData
+	Secret        string    `gorm:"type:text" json:"-"`     // Available to URL and body templates for signatures, write-only in API
+	HasSecret     bool      `gorm:"-" json:"has_secret"`    // Filled on read and save, see Secret
+	IsActive      bool      `gorm:"default:true" json:"is_active"`
+	Timeout       int       `gorm:"default:30" json:"timeout"` // seconds
+	KeywordPaths  string    `json:"keyword_paths,omitempty"`
+	ResponsePath  string    `json:"response_path,omitempty"`
+	Priority      int       `gorm:"default:0" json:"priority"`                 // Lower is called first within service code
+	CacheTTL      int       `gorm:"default:0" json:"cache_ttl"`                // Seconds to reuse response per phone, 0 disables
+	DecisionRules string    `gorm:"type:text" json:"decision_rules,omitempty"` // JSON array of services.DecisionRule deciding verdict instead of keywords
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Effective failover settings, filled when listing
+	FailoverPolicy   string `gorm:"-" json:"failover_policy,omitempty"`
+	FailoverPosition int    `gorm:"-" json:"failover_position,omitempty"` // 1-based among active services of the code
+}
+
+// AfterFind fills HasSecret, the secret itself is never sent to clients
+func (s *APIService) AfterFind(tx *gorm.DB) error {
+	s.HasSecret = s.Secret != ""
+	return nil
 }
 
+// AfterSave fills HasSecret of created or saved service
+func (s *APIService) AfterSave(tx *gorm.DB) error {
+	s.HasSecret = s.Secret != ""
+	return nil
+}
+
+// API service types
+const (
+	APIServiceTypeHTTP        = "http"         // HTTP request to a lookup API
+	APIServiceTypeTelegramBot = "telegram_bot" // Message to a Telegram lookup bot through relay
+)
+
+// API service policies of a spam service
+const (
+	APIPolicyCallAll      = "call_all"      // Call every active API service
+	APIPolicyFirstSuccess = "first_success" // Call by priority, fall back only on failure
+)
+
 // SystemSettings represents system configuration
 type SystemSettings struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Key       string    `gorm:"unique;not null" json:"key"`
-	Value     string    `json:"value"`
-	Type      string    `json:"type"` // string, int, bool, json
-	Category  string    `json:"category"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Key         string    `gorm:"unique;not null" json:"key"`
+	Value       string    `json:"value"`
+	Type        string    `json:"type"` // string, int, bool, json
+	Category    string    `json:"category"`
+	Description string    `json:"description,omitempty"` // Help text shown in UI
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // Notification represents notification configuration
 type Notification struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Type      string    `gorm:"not null" json:"type"` // telegram, email
-	Config    string    `gorm:"type:jsonb" json:"config"`
-	IsActive  bool      `gorm:"default:true" json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                        uint       `gorm:"primaryKey" json:"id"`
+	Type                      string     `gorm:"not null" json:"type"` // telegram, email
+	Config                    string     `gorm:"type:jsonb" json:"config"`
+	IsActive                  bool       `gorm:"default:true" json:"is_active"`
+	LastSentAt                *time.Time `json:"last_sent_at"`
+	LastStatus                string     `json:"last_status,omitempty"` // success, failed
+	LastError                 string     `json:"last_error,omitempty"`
+	ConsecutiveFailures       int        `gorm:"default:0" json:"consecutive_failures"`
+	ConsecutiveConfigFailures int        `gorm:"default:0" json:"consecutive_config_failures"` // 400/401/403 and invalid config
+	Degraded                  bool       `gorm:"default:false;index" json:"degraded"`          // Skipped until a successful test
+	DegradedAt                *time.Time `json:"degraded_at,omitempty"`
+	CreatedAt                 time.Time  `json:"created_at"`
+	UpdatedAt                 time.Time  `json:"updated_at"`
+}
+
+// Notification delivery statuses
+const (
+	DeliveryStatusSuccess = "success"
+	DeliveryStatusFailed  = "failed"
+)
+
+// NotificationDelivery represents a single send attempt to a notification channel
+type NotificationDelivery struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	NotificationID uint      `gorm:"index" json:"notification_id"`
+	Type           string    `json:"type"`
+	Subject        string    `json:"subject"`
+	Status         string    `gorm:"not null" json:"status"` // success, failed
+	Error          string    `json:"error,omitempty"`
+	IsTest         bool      `json:"is_test"`
+	SentAt         time.Time `gorm:"index" json:"sent_at"`
+}
+
+// Phone import job statuses
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// PhoneImportJob represents background import of a large phone CSV.
+// Cursor is the last processed CSV line, an interrupted job resumes after it.
+type PhoneImportJob struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Status          string     `gorm:"size:20;index;default:pending" json:"status"`
+	FileName        string     `json:"file_name"`
+	FilePath        string     `json:"-"`
+	ErrorReportPath string     `json:"-"`
+	Instance        string     `gorm:"size:150;index" json:"instance,omitempty"` // Application instance holding the upload and error report on its disk
+	TotalRows       int        `json:"total_rows"`                               // Estimated from line count of the upload
+	ProcessedRows   int        `json:"processed_rows"`
+	ImportedRows    int        `json:"imported_rows"`
+	FailedRows      int        `json:"failed_rows"`
+	Cursor          int        `json:"cursor"`
+	Error           string     `json:"error,omitempty"`
+	CreatedBy       uint       `json:"created_by"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Check job kinds
+const (
+	CheckJobRecheckSpam = "recheck_spam" // Re-check of phones whose overall verdict is spam
+	CheckJobRetryErrors = "retry_errors" // Re-check of services whose latest result of a phone is error or inconclusive
+)
+
+// Check job statuses
+const (
+	CheckJobPending   = "pending"
+	CheckJobRunning   = "running"
+	CheckJobCompleted = "completed"
+	CheckJobFailed    = "failed"
+)
+
+// CheckJob represents on-demand background check of a set of phones
+type CheckJob struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Kind          string     `gorm:"size:30;index" json:"kind"`
+	Status        string     `gorm:"size:20;index;default:pending" json:"status"`
+	TotalPhones   int        `json:"total_phones"`
+	CheckedPhones int        `json:"checked_phones"`
+	FailedPhones  int        `json:"failed_phones"`
+	StillSpam     int        `json:"still_spam"`                            // Checked phones whose overall verdict is still spam
+	Cleaned       int        `json:"cleaned"`                               // Checked phones no longer spam
+	ServiceCode   string     `gorm:"size:50" json:"service_code,omitempty"` // retry_errors: only results of this service
+	TotalResults  int        `json:"total_results,omitempty"`               // retry_errors: failed results being retried
+	Recovered     int        `json:"recovered,omitempty"`                   // retry_errors: retried results that got a verdict
+	StillFailing  int        `json:"still_failing,omitempty"`               // retry_errors: retried results that failed again
+	Error         string     `json:"error,omitempty"`
+	Instance      string     `gorm:"size:150" json:"instance,omitempty"` // Application instance running the job
+	CreatedBy     uint       `json:"created_by"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 // CheckSchedule represents check schedule configuration
 type CheckSchedule struct {
-	ID             uint       `gorm:"primaryKey" json:"id"`
-	Name           string     `gorm:"not null" json:"name"`
-	CronExpression string     `gorm:"not null" json:"cron_expression"`
-	IsActive       bool       `gorm:"default:true" json:"is_active"`
-	LastRun        *time.Time `json:"last_run"`
-	NextRun        *time.Time `json:"next_run"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Name           string    `gorm:"not null" json:"name"`
+	CronExpression string    `gorm:"not null" json:"cron_expression"`
+	IsActive       bool      `gorm:"default:true" json:"is_active"`
+	CheckMode      CheckMode `gorm:"size:20" json:"check_mode,omitempty"`        // Overrides check_mode setting, empty uses it
+	ServiceCode    string    `gorm:"size:50" json:"service_code,omitempty"`      // Checks only this spam service, empty checks all
+	OverlapPolicy  string    `gorm:"size:20;default:skip" json:"overlap_policy"` // What a trigger does while another check runs
+	// Random sampling, both unset checks every phone each run
+	SampleSize              int        `json:"sample_size,omitempty"`                // Phones per run
+	SamplePercent           int        `json:"sample_percent,omitempty"`             // Percent of phones per run
+	SampleMaxStalenessHours int        `json:"sample_max_staleness_hours,omitempty"` // Every phone checked at least once per window, 0 uses check_sample_max_staleness_hours
+	LastRun                 *time.Time `json:"last_run"`
+	NextRun                 *time.Time `json:"next_run"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+}
+
+// Schedule overlap policies decide what a trigger does while another check is running
+const (
+	ScheduleOverlapSkip          = "skip"           // Drop the trigger
+	ScheduleOverlapQueue         = "queue"          // Run right after the current check finishes
+	ScheduleOverlapCancelRestart = "cancel_restart" // Abort in-flight run of the same schedule and start fresh
+)
+
+// Schedule run decisions taken by overlap policy
+const (
+	ScheduleRunDecisionRun       = "run"       // Nothing else was running
+	ScheduleRunDecisionSkipped   = "skipped"   // Dropped, see Reason
+	ScheduleRunDecisionQueued    = "queued"    // Waited for the check in progress
+	ScheduleRunDecisionRestarted = "restarted" // Cancelled previous run of the schedule
+)
+
+// Schedule run statuses
+const (
+	ScheduleRunStatusWaiting   = "waiting"
+	ScheduleRunStatusRunning   = "running"
+	ScheduleRunStatusCompleted = "completed"
+	ScheduleRunStatusCancelled = "cancelled"
+	ScheduleRunStatusSkipped   = "skipped"
+)
+
+// ScheduleRun records a trigger of a check schedule and the overlap policy decision taken for it
+type ScheduleRun struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ScheduleID    uint       `gorm:"not null;index" json:"schedule_id"`
+	TriggeredAt   time.Time  `gorm:"index" json:"triggered_at"`
+	Policy        string     `gorm:"size:20" json:"policy"`
+	Decision      string     `gorm:"size:20;not null" json:"decision"`
+	Reason        string     `json:"reason,omitempty"`
+	Status        string     `gorm:"size:20;not null" json:"status"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	PhonesChecked int        `json:"phones_checked"`
+	// Set when the run checked a random sample of phones
+	Sampled           bool   `json:"sampled"`
+	PhonesEligible    int    `json:"phones_eligible,omitempty"`    // Active phones the sample was drawn from
+	PhonesOverdue     int    `json:"phones_overdue,omitempty"`     // Phones included to keep the coverage guarantee
+	CoverageGuarantee string `json:"coverage_guarantee,omitempty"` // Guarantee the sample was drawn under
+}
+
+// RunSpamRate records spam rates of a completed check run. Runs of the same check type and
+// schedule form the baseline spam rate anomalies are detected against.
+type RunSpamRate struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	CheckType     string    `gorm:"size:20;index:idx_run_spam_rates_baseline" json:"check_type"`
+	ScheduleID    uint      `gorm:"index:idx_run_spam_rates_baseline" json:"schedule_id,omitempty"`
+	PhonesChecked int       `json:"phones_checked"`
+	SpamPhones    int       `json:"spam_phones"`
+	SpamRate      float64   `json:"spam_rate"`                       // Percent of checked phones
+	ServiceRates  string    `gorm:"type:jsonb" json:"service_rates"` // Checked, spam and rate per service name
+	Anomaly       bool      `json:"anomaly"`
+	CompletedAt   time.Time `gorm:"index" json:"completed_at"`
+}
+
+// SchedulerHeartbeat is the single row updated after every completed scheduler sweep.
+// It survives restarts, so a silently stopped scheduler is visible from the database.
+type SchedulerHeartbeat struct {
+	ID                uint      `gorm:"primaryKey" json:"-"`
+	LastSuccessfulRun time.Time `json:"last_successful_run"`
+	CheckType         string    `gorm:"size:20" json:"check_type"`
+	ScheduleID        uint      `json:"schedule_id,omitempty"`
+	PhonesChecked     int       `json:"phones_checked"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Lease is a named lock shared by application instances running against one database.
+// It is held by Holder until ExpiresAt unless renewed, so a lease of a stopped instance frees itself.
+type Lease struct {
+	Name       string    `gorm:"primaryKey;size:150" json:"name"`
+	Holder     string    `gorm:"size:150;not null" json:"holder"`
+	ExpiresAt  time.Time `gorm:"index" json:"expires_at"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// SchedulePhone represents explicit phone membership of a check schedule
+type SchedulePhone struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ScheduleID    uint      `gorm:"not null;uniqueIndex:idx_schedule_phone" json:"schedule_id"`
+	PhoneNumberID uint      `gorm:"not null;uniqueIndex:idx_schedule_phone;index" json:"phone_number_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PhoneServiceExclusion forbids checking a phone with a spam service, its gateways and API providers
+// are skipped and the service verdict is not applicable to the phone
+type PhoneServiceExclusion struct {
+	ID            uint         `gorm:"primaryKey" json:"id"`
+	PhoneNumberID uint         `gorm:"not null;uniqueIndex:idx_phone_service_exclusion" json:"phone_number_id"`
+	ServiceID     uint         `gorm:"not null;uniqueIndex:idx_phone_service_exclusion;index" json:"service_id"`
+	Service       *SpamService `gorm:"foreignKey:ServiceID" json:"service,omitempty"`
+	Reason        string       `json:"reason,omitempty"`
+	CreatedBy     uint         `json:"created_by"`
+	CreatedAt     time.Time    `json:"created_at"`
 }
 
 // SpamKeyword represents keywords for spam detection
 type SpamKeyword struct {
 	ID        uint         `gorm:"primaryKey" json:"id"`
 	Keyword   string       `gorm:"not null" json:"keyword"`
+	Negations string       `json:"negations"`                       // Comma-separated tokens cancelling the keyword right before or after it, in addition to keyword_negations
+	WholeWord bool         `gorm:"default:false" json:"whole_word"` // Match only whole words, not inside longer ones
 	ServiceID *uint        `json:"service_id,omitempty"`
 	Service   *SpamService `gorm:"foreignKey:ServiceID" json:"service,omitempty"`
 	IsActive  bool         `gorm:"default:true" json:"is_active"`
@@ -257,16 +733,44 @@ type SpamKeyword struct {
 
 // Statistics represents check statistics
 type Statistics struct {
+	ID                uint        `gorm:"primaryKey" json:"id"`
+	PhoneNumberID     uint        `json:"phone_number_id"`
+	PhoneNumber       PhoneNumber `gorm:"foreignKey:PhoneNumberID" json:"-"`
+	ServiceID         uint        `json:"service_id"`
+	Service           SpamService `gorm:"foreignKey:ServiceID" json:"service"`
+	FirstSpamDate     *time.Time  `json:"first_spam_date"`
+	TotalChecks       int         `json:"total_checks"`
+	SpamCount         int         `json:"spam_count"`
+	InconclusiveCount int         `json:"inconclusive_count"`
+	SuspectedCount    int         `json:"suspected_count"`
+	ErrorCount        int         `json:"error_count"` // Failed checks, not included in TotalChecks
+	LastCheckDate     time.Time   `json:"last_check_date"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// Check result statuses, spam and clean are also used in status transitions
+const (
+	SpamStatusUnknown      = "unknown"
+	SpamStatusClean        = "clean"
+	SpamStatusSpam         = "spam"
+	SpamStatusInconclusive = "inconclusive"
+	SpamStatusSuspected    = "suspected" // Spam detection below min_spam_confidence, does not count as spam
+	SpamStatusError        = "error"
+)
+
+// SpamStatusTransition represents change of phone spam verdict for a service
+type SpamStatusTransition struct {
 	ID            uint        `gorm:"primaryKey" json:"id"`
-	PhoneNumberID uint        `json:"phone_number_id"`
+	PhoneNumberID uint        `gorm:"index" json:"phone_number_id"`
 	PhoneNumber   PhoneNumber `gorm:"foreignKey:PhoneNumberID" json:"-"`
 	ServiceID     uint        `json:"service_id"`
 	Service       SpamService `gorm:"foreignKey:ServiceID" json:"service"`
-	FirstSpamDate *time.Time  `json:"first_spam_date"`
-	TotalChecks   int         `json:"total_checks"`
-	SpamCount     int         `json:"spam_count"`
-	LastCheckDate time.Time   `json:"last_check_date"`
-	UpdatedAt     time.Time   `json:"updated_at"`
+	CheckResultID uint        `json:"check_result_id"`
+	FromStatus    string      `gorm:"not null" json:"from_status"` // unknown, clean or spam
+	ToStatus      string      `gorm:"not null;index" json:"to_status"`
+	Keywords      StringArray `gorm:"type:text[]" json:"keywords"`
+	NotifiedAt    *time.Time  `json:"notified_at,omitempty"` // Set once "became spam" notification is sent
+	CreatedAt     time.Time   `gorm:"index" json:"created_at"`
 }
 
 // CheckMode represents the mode for checking phones
@@ -299,3 +803,32 @@ type PhoneNumberUsageStats struct {
 	DailyAllocations int64      `json:"daily_allocations"`
 	IsClean          bool       `json:"is_clean"`
 }
+
+// IdempotencyKey stores response of a mutating request so a retry with the same key is not executed twice
+type IdempotencyKey struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Key          string    `gorm:"not null;uniqueIndex:idx_idempotency_user_key" json:"key"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_idempotency_user_key" json:"user_id"`
+	Method       string    `gorm:"not null" json:"method"`
+	Path         string    `gorm:"not null" json:"path"`
+	RequestHash  string    `gorm:"not null" json:"request_hash"` // SHA-256 of method, path and body
+	Completed    bool      `gorm:"default:false" json:"completed"`
+	StatusCode   int       `json:"status_code"`
+	ContentType  string    `json:"content_type"`
+	ResponseBody []byte    `json:"-"`
+	ExpiresAt    time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RealtimeQuotaUsage is realtime check consumption of a user during one UTC day
+type RealtimeQuotaUsage struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;uniqueIndex:idx_realtime_quota_user_day" json:"user_id"`
+	Day          string     `gorm:"size:10;not null;uniqueIndex:idx_realtime_quota_user_day" json:"day"` // YYYY-MM-DD in UTC
+	Used         float64    `gorm:"not null;default:0" json:"used"`                                      // Quota units, cached checks cost less than one
+	Checks       int        `gorm:"not null;default:0" json:"checks"`                                    // Checks that hit gateways or APIs
+	CachedChecks int        `gorm:"not null;default:0" json:"cached_checks"`
+	Minute       *time.Time `json:"minute,omitempty"`                      // Start of the minute counted by MinuteUsed, UTC
+	MinuteUsed   float64    `gorm:"not null;default:0" json:"minute_used"` // Quota units within Minute
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
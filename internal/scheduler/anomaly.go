@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"fmt"
+	"spam-checker/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+// detectSpamAnomaly records spam rates of a completed run and notifies when they deviate from
+// previous runs of the same check type and schedule
+func (s *CheckScheduler) detectSpamAnomaly(checkType string, scheduleID uint, results map[uint]*PhoneCheckSummary) {
+	log := s.log.WithFields(logrus.Fields{
+		"method":     "detectSpamAnomaly",
+		"checkType":  checkType,
+		"scheduleID": scheduleID,
+	})
+
+	sample := services.RunSpamSample{
+		CheckType:     checkType,
+		ScheduleID:    scheduleID,
+		PhonesChecked: len(results),
+		Services:      make(map[string]services.ServiceSpamRate),
+	}
+	for _, summary := range results {
+		if summary.IsSpam {
+			sample.SpamPhones++
+		}
+		for serviceName, result := range summary.Services {
+			rate := sample.Services[serviceName]
+			rate.Checked++
+			if result.IsSpam {
+				rate.Spam++
+			}
+			sample.Services[serviceName] = rate
+		}
+	}
+
+	anomaly, err := services.NewSpamAnomalyDetector(s.db).RecordRun(sample)
+	if err != nil {
+		log.Warnf("Failed to record run spam rates: %v", err)
+		return
+	}
+	if anomaly == nil {
+		return
+	}
+	log.Warnf("Spam rate anomaly in %d rates", len(anomaly.Deviations))
+
+	if !services.NewSettingsService(s.db).GetCachedBool("enable_notifications", true) {
+		return
+	}
+
+	title := "📈 Аномальная доля спама"
+	message := fmt.Sprintf("%s\n\n%s\nДоля спама резко отличается от предыдущих запусков:\n", title, s.notificationTitle(checkType, scheduleID))
+	for _, deviation := range anomaly.Deviations {
+		name := "Все сервисы"
+		if deviation.Service != "" {
+			name = deviation.Service
+		}
+		message += fmt.Sprintf("  • %s: %.1f%% (обычно %.1f%%, %+.1f п.п., отклонение %.1f MAD)\n",
+			name, deviation.Rate, deviation.Median, deviation.Change, deviation.Deviation)
+	}
+	message += "\nПроверьте ключевые слова, изменения у сервисов и настройки кампаний.\n"
+
+	s.dispatchNotification(log, title, message)
+}
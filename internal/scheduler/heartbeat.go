@@ -0,0 +1,223 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"spam-checker/internal/services"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// watchdogSettingKey is the setting with minutes without a completed sweep after which scheduler is stale
+const watchdogSettingKey = "scheduler_watchdog_minutes"
+
+// defaultWatchdogIntervals is number of default check intervals without a completed sweep
+// after which scheduler is stale, used when watchdog setting is zero
+const defaultWatchdogIntervals = 3
+
+// watchdogCheckInterval is how often watchdog compares heartbeat with expected window.
+// Watchdog runs on its own ticker, so it keeps working when gocron jobs are stuck.
+const watchdogCheckInterval = time.Minute
+
+// heartbeatRowID is primary key of the single heartbeat row
+const heartbeatRowID = 1
+
+// HeartbeatStatus describes scheduler liveness: the running flag alone does not show
+// whether sweeps still complete
+type HeartbeatStatus struct {
+	Running           bool       `json:"running"`
+	Role              string     `json:"role"`             // Role of this instance, jobs run on the leader only
+	Leader            string     `json:"leader,omitempty"` // Instance running jobs, empty when none does
+	LastSuccessfulRun *time.Time `json:"last_successful_run,omitempty"`
+	CheckType         string     `json:"check_type,omitempty"`
+	ScheduleID        uint       `json:"schedule_id,omitempty"`
+	PhonesChecked     int        `json:"phones_checked"`
+	CheckInProgress   bool       `json:"check_in_progress"`
+	StaleAfterMinutes int        `json:"stale_after_minutes"`
+	Stale             bool       `json:"stale"`
+	WatchdogAlerted   bool       `json:"watchdog_alerted"`
+}
+
+// loadHeartbeat restores heartbeat persisted before restart
+func (s *CheckScheduler) loadHeartbeat() {
+	var heartbeat models.SchedulerHeartbeat
+	if err := s.db.First(&heartbeat, heartbeatRowID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.log.Warnf("Failed to load scheduler heartbeat: %v", err)
+		}
+		return
+	}
+
+	s.heartbeatMutex.Lock()
+	s.heartbeat = &heartbeat
+	s.heartbeatMutex.Unlock()
+}
+
+// recordHeartbeat stores time of a completed sweep and notifies about recovery
+// if watchdog reported the scheduler as stale
+func (s *CheckScheduler) recordHeartbeat(checkType string, scheduleID uint, phonesChecked int) {
+	heartbeat := models.SchedulerHeartbeat{
+		ID:                heartbeatRowID,
+		LastSuccessfulRun: time.Now(),
+		CheckType:         checkType,
+		ScheduleID:        scheduleID,
+		PhonesChecked:     phonesChecked,
+	}
+
+	s.heartbeatMutex.Lock()
+	s.heartbeat = &heartbeat
+	recovered := s.watchdogAlerted
+	s.watchdogAlerted = false
+	s.heartbeatMutex.Unlock()
+
+	if err := s.db.Save(&heartbeat).Error; err != nil {
+		s.log.Warnf("Failed to save scheduler heartbeat: %v", err)
+	}
+
+	if recovered {
+		s.log.Info("Scheduler completed a check again after watchdog alert")
+		s.sendWatchdogNotification("✅ Планировщик снова работает",
+			fmt.Sprintf("Проверка завершена в %s, проверено номеров: %d.",
+				heartbeat.LastSuccessfulRun.Format("2006-01-02 15:04:05"), phonesChecked))
+	}
+}
+
+// runWatchdog checks heartbeat until scheduler stops
+func (s *CheckScheduler) runWatchdog(stop <-chan struct{}) {
+	ticker := time.NewTicker(watchdogCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			s.checkHeartbeat(now)
+		}
+	}
+}
+
+// checkHeartbeat notifies once when no sweep has completed within the expected window.
+// Time outside the active window does not count, as no sweeps run then.
+func (s *CheckScheduler) checkHeartbeat(now time.Time) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "checkHeartbeat",
+	})
+
+	if !services.NewSettingsService(s.db).GetActiveWindow().Contains(now) {
+		s.heartbeatMutex.Lock()
+		s.watchdogBaseline = now
+		s.heartbeatMutex.Unlock()
+		return
+	}
+
+	staleAfter := s.watchdogStaleAfter()
+
+	s.heartbeatMutex.Lock()
+	since := s.watchdogBaseline
+	if s.heartbeat != nil && s.heartbeat.LastSuccessfulRun.After(since) {
+		since = s.heartbeat.LastSuccessfulRun
+	}
+	alert := !s.watchdogAlerted && now.Sub(since) > staleAfter
+	if alert {
+		s.watchdogAlerted = true
+	}
+	s.heartbeatMutex.Unlock()
+
+	if !alert {
+		return
+	}
+
+	s.checkMutex.Lock()
+	checking := s.isCheckingNow
+	lastStart := s.lastCheckTime
+	s.checkMutex.Unlock()
+
+	log.WithFields(logrus.Fields{
+		"since":       since.Format("2006-01-02 15:04:05"),
+		"stale_after": staleAfter.String(),
+		"checking":    checking,
+	}).Error("No check completed within expected window, scheduler may be stuck")
+
+	message := fmt.Sprintf("Ни одна проверка не завершилась с %s (ожидалось не реже раза в %s).\n",
+		since.Format("2006-01-02 15:04:05"), staleAfter)
+	if checking {
+		message += fmt.Sprintf("Текущая проверка идёт с %s — возможно, она зависла.\n", lastStart.Format("2006-01-02 15:04:05"))
+	} else {
+		message += "Проверка сейчас не выполняется — задания планировщика, возможно, остановились.\n"
+	}
+	s.sendWatchdogNotification("⚠️ Планировщик не завершает проверки", message)
+}
+
+// watchdogStaleAfter returns time without a completed sweep after which scheduler is stale
+func (s *CheckScheduler) watchdogStaleAfter() time.Duration {
+	if minutes := services.NewSettingsService(s.db).GetCachedInt(watchdogSettingKey, 0); minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+
+	s.checkMutex.Lock()
+	interval := s.currentInterval
+	s.checkMutex.Unlock()
+	if interval <= 0 {
+		interval = 60
+	}
+	return time.Duration(defaultWatchdogIntervals*interval) * time.Minute
+}
+
+// sendWatchdogNotification sends watchdog alert or recovery notice when notifications are enabled
+func (s *CheckScheduler) sendWatchdogNotification(title, message string) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "sendWatchdogNotification",
+	})
+
+	if !services.NewSettingsService(s.db).GetCachedBool("enable_notifications", true) {
+		log.Debug("Notifications are disabled in settings")
+		return
+	}
+	s.dispatchNotification(log, title, title+"\n\n"+message)
+}
+
+// Heartbeat returns scheduler liveness with the last completed sweep. On a follower
+// the sweep recorded by the leader is read from the database.
+func (s *CheckScheduler) Heartbeat() HeartbeatStatus {
+	leader := s.LeaderStatus()
+	if leader.Role != services.RoleLeader {
+		s.loadHeartbeat()
+	}
+	staleAfter := s.watchdogStaleAfter()
+	now := time.Now()
+
+	s.checkMutex.Lock()
+	checking := s.isCheckingNow
+	s.checkMutex.Unlock()
+
+	status := HeartbeatStatus{
+		Running:           s.IsRunning(),
+		Role:              leader.Role,
+		Leader:            leader.Leader,
+		CheckInProgress:   checking,
+		StaleAfterMinutes: int(staleAfter / time.Minute),
+	}
+
+	s.heartbeatMutex.Lock()
+	defer s.heartbeatMutex.Unlock()
+
+	since := s.watchdogBaseline
+	if s.heartbeat != nil {
+		lastRun := s.heartbeat.LastSuccessfulRun
+		status.LastSuccessfulRun = &lastRun
+		status.CheckType = s.heartbeat.CheckType
+		status.ScheduleID = s.heartbeat.ScheduleID
+		status.PhonesChecked = s.heartbeat.PhonesChecked
+		if lastRun.After(since) {
+			since = lastRun
+		}
+	}
+	status.WatchdogAlerted = s.watchdogAlerted
+	status.Stale = status.Leader == "" || (status.Role == services.RoleLeader && !status.Running) ||
+		(!since.IsZero() && now.Sub(since) > staleAfter)
+	return status
+}
@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"spam-checker/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Next check estimate statuses
+const (
+	NextCheckScheduled        = "scheduled"
+	NextCheckPhoneInactive    = "phone_inactive"
+	NextCheckPhoneBlocked     = "phone_blocked"
+	NextCheckSchedulerStopped = "scheduler_stopped"
+	NextCheckNotScheduled     = "not_scheduled"
+)
+
+// NextCheckEstimate represents estimated next automatic check of a phone.
+// NextCheckAt is set only when Status is "scheduled".
+type NextCheckEstimate struct {
+	PhoneID      uint       `json:"phone_id"`
+	Status       string     `json:"status"`
+	NextCheckAt  *time.Time `json:"next_check_at,omitempty"`
+	ScheduleID   uint       `json:"schedule_id"` // 0 for default interval check
+	ScheduleName string     `json:"schedule_name,omitempty"`
+	IsChecking   bool       `json:"is_checking"`
+}
+
+// ScheduleNextRuns is a snapshot of next runs known to the scheduler
+type ScheduleNextRuns struct {
+	Running         bool
+	IsChecking      bool
+	DefaultInterval int
+	// DefaultAlignment is relative or wall_clock
+	DefaultAlignment string
+	DefaultNextRun   time.Time
+	Schedules        map[uint]time.Time
+}
+
+// setScheduleNextRun stores next run of a custom schedule, zero time removes it
+func (s *CheckScheduler) setScheduleNextRun(scheduleID uint, nextRun time.Time) {
+	s.nextRunsMutex.Lock()
+	defer s.nextRunsMutex.Unlock()
+
+	if nextRun.IsZero() {
+		delete(s.scheduleNextRuns, scheduleID)
+		return
+	}
+	s.scheduleNextRuns[scheduleID] = nextRun
+}
+
+// NextRuns returns next run of default interval check and of every active schedule.
+// Safe to call from handlers while scheduler is running.
+func (s *CheckScheduler) NextRuns() ScheduleNextRuns {
+	snapshot := ScheduleNextRuns{
+		Running:   s.IsRunning(),
+		Schedules: make(map[uint]time.Time),
+	}
+
+	s.checkMutex.Lock()
+	snapshot.IsChecking = s.isCheckingNow
+	snapshot.DefaultNextRun = s.nextCheckTime
+	snapshot.DefaultInterval = s.currentInterval
+	snapshot.DefaultAlignment = s.currentAlignment
+	s.checkMutex.Unlock()
+
+	// Skipped runs leave next check time in the past, move it to the next tick
+	if snapshot.DefaultInterval > 0 && !snapshot.DefaultNextRun.IsZero() {
+		now := time.Now()
+		if snapshot.DefaultAlignment == services.IntervalAlignmentWallClock {
+			if snapshot.DefaultNextRun.Before(now) {
+				snapshot.DefaultNextRun = services.NextIntervalBoundary(now, snapshot.DefaultInterval)
+			}
+		} else {
+			interval := time.Duration(snapshot.DefaultInterval) * time.Minute
+			for snapshot.DefaultNextRun.Before(now) {
+				snapshot.DefaultNextRun = snapshot.DefaultNextRun.Add(interval)
+			}
+		}
+	}
+
+	s.nextRunsMutex.RLock()
+	for id, nextRun := range s.scheduleNextRuns {
+		snapshot.Schedules[id] = nextRun
+	}
+	s.nextRunsMutex.RUnlock()
+
+	return snapshot
+}
+
+// EstimateNextCheck returns the earliest upcoming automatic check of a phone
+// from the default interval check and schedules that include the phone
+func (s *CheckScheduler) EstimateNextCheck(phoneID uint) (*NextCheckEstimate, error) {
+	var phone models.PhoneNumber
+	if err := s.db.Select("id", "is_active", "blocked").First(&phone, phoneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("phone number not found")
+		}
+		return nil, fmt.Errorf("failed to get phone number: %w", err)
+	}
+
+	runs := s.NextRuns()
+	estimate := &NextCheckEstimate{
+		PhoneID:    phone.ID,
+		IsChecking: runs.IsChecking,
+	}
+
+	switch {
+	case phone.Blocked:
+		estimate.Status = NextCheckPhoneBlocked
+		return estimate, nil
+	case !phone.IsActive:
+		estimate.Status = NextCheckPhoneInactive
+		return estimate, nil
+	case !runs.Running:
+		estimate.Status = NextCheckSchedulerStopped
+		return estimate, nil
+	}
+
+	var earliest time.Time
+	if runs.DefaultInterval > 0 && !runs.DefaultNextRun.IsZero() {
+		earliest = runs.DefaultNextRun
+		estimate.ScheduleName = "Default Interval Check"
+	}
+
+	for scheduleID, nextRun := range runs.Schedules {
+		if !earliest.IsZero() && !nextRun.Before(earliest) {
+			continue
+		}
+
+		included, err := s.phoneService.ScheduleIncludesPhone(scheduleID, phone.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !included {
+			continue
+		}
+
+		var schedule models.CheckSchedule
+		if err := s.db.First(&schedule, scheduleID).Error; err != nil {
+			return nil, fmt.Errorf("failed to get schedule: %w", err)
+		}
+
+		earliest = nextRun
+		estimate.ScheduleID = scheduleID
+		estimate.ScheduleName = schedule.Name
+	}
+
+	if earliest.IsZero() {
+		estimate.Status = NextCheckNotScheduled
+		estimate.ScheduleName = ""
+		return estimate, nil
+	}
+
+	estimate.Status = NextCheckScheduled
+	estimate.NextCheckAt = &earliest
+	return estimate, nil
+}
@@ -0,0 +1,205 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"spam-checker/internal/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ScheduleTriggerStats counts triggers of a schedule by overlap policy decision since scheduler start
+type ScheduleTriggerStats struct {
+	Started   int64 `json:"started"`
+	Skipped   int64 `json:"skipped"`
+	Queued    int64 `json:"queued"`
+	Restarted int64 `json:"restarted"`
+}
+
+// triggerStatsLocked returns counters of a schedule. Must be called with checkMutex held.
+func (s *CheckScheduler) triggerStatsLocked(scheduleID uint) *ScheduleTriggerStats {
+	stats, exists := s.triggerStats[scheduleID]
+	if !exists {
+		stats = &ScheduleTriggerStats{}
+		s.triggerStats[scheduleID] = stats
+	}
+	return stats
+}
+
+// TriggerStats returns trigger counters of every schedule that fired since scheduler start
+func (s *CheckScheduler) TriggerStats() map[uint]ScheduleTriggerStats {
+	s.checkMutex.Lock()
+	defer s.checkMutex.Unlock()
+
+	stats := make(map[uint]ScheduleTriggerStats, len(s.triggerStats))
+	for scheduleID, item := range s.triggerStats {
+		stats[scheduleID] = *item
+	}
+	return stats
+}
+
+// stopping reports whether scheduler was stopped
+func (s *CheckScheduler) stopping() bool {
+	select {
+	case <-s.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// startRunLocked marks a check as running and returns context cancelled when the run is aborted.
+// Must be called with checkMutex held.
+func (s *CheckScheduler) startRunLocked(scheduleID uint) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.isCheckingNow = true
+	s.lastCheckTime = time.Now()
+	s.runningScheduleID = scheduleID
+	s.cancelRun = cancel
+	return ctx
+}
+
+// finishRunLocked clears running check and wakes queued triggers. Must be called with checkMutex held.
+func (s *CheckScheduler) finishRunLocked() {
+	if s.cancelRun != nil {
+		s.cancelRun()
+		s.cancelRun = nil
+	}
+	s.isCheckingNow = false
+	s.runningScheduleID = 0
+	s.checkIdle.Broadcast()
+}
+
+// describeRunLocked names check in progress for run history. Must be called with checkMutex held.
+func (s *CheckScheduler) describeRunLocked() string {
+	if s.runningScheduleID == 0 {
+		return "default interval check"
+	}
+	return fmt.Sprintf("schedule %d run", s.runningScheduleID)
+}
+
+// admitScheduledRun applies overlap policy of the schedule to its trigger and records the decision
+// in run history. It returns the run and its context, or false when the trigger was dropped.
+// Queued and restarting triggers block until the check in progress finishes.
+func (s *CheckScheduler) admitScheduledRun(schedule *models.CheckSchedule, log *logrus.Entry) (*models.ScheduleRun, context.Context, bool) {
+	policy := schedule.OverlapPolicy
+	if policy == "" {
+		policy = models.ScheduleOverlapSkip
+	}
+	run := &models.ScheduleRun{
+		ScheduleID:  schedule.ID,
+		TriggeredAt: time.Now(),
+		Policy:      policy,
+	}
+
+	s.checkMutex.Lock()
+	stats := s.triggerStatsLocked(schedule.ID)
+
+	if !s.isCheckingNow {
+		ctx := s.startRunLocked(schedule.ID)
+		stats.Started++
+		s.checkMutex.Unlock()
+
+		run.Decision = models.ScheduleRunDecisionRun
+		run.Status = models.ScheduleRunStatusRunning
+		run.StartedAt = &run.TriggeredAt
+		s.saveScheduleRun(run, log)
+		return run, ctx, true
+	}
+
+	alreadyQueued := s.queuedSchedules[schedule.ID]
+	switch {
+	case policy == models.ScheduleOverlapCancelRestart && !alreadyQueued &&
+		s.runningScheduleID == schedule.ID && s.cancelRun != nil:
+		s.cancelRun()
+		run.Decision = models.ScheduleRunDecisionRestarted
+		run.Reason = "previous run of this schedule was cancelled"
+		stats.Restarted++
+
+	// Run of another schedule or the default check is never cancelled, restart waits for it instead
+	case (policy == models.ScheduleOverlapQueue || policy == models.ScheduleOverlapCancelRestart) && !alreadyQueued:
+		run.Decision = models.ScheduleRunDecisionQueued
+		run.Reason = fmt.Sprintf("waiting for %s", s.describeRunLocked())
+		stats.Queued++
+
+	default:
+		run.Decision = models.ScheduleRunDecisionSkipped
+		run.Reason = fmt.Sprintf("%s is in progress", s.describeRunLocked())
+		if alreadyQueued {
+			run.Reason = "another trigger of this schedule is already queued"
+		}
+		stats.Skipped++
+		skipped := stats.Skipped
+		s.checkMutex.Unlock()
+
+		run.Status = models.ScheduleRunStatusSkipped
+		log.WithFields(logrus.Fields{
+			"policy":        policy,
+			"reason":        run.Reason,
+			"skipped_total": skipped,
+		}).Warn("Scheduled check trigger skipped")
+		s.saveScheduleRun(run, log)
+		return nil, nil, false
+	}
+
+	s.queuedSchedules[schedule.ID] = true
+	queued, restarted := stats.Queued, stats.Restarted
+	s.checkMutex.Unlock()
+
+	run.Status = models.ScheduleRunStatusWaiting
+	log.WithFields(logrus.Fields{
+		"policy":          policy,
+		"decision":        run.Decision,
+		"reason":          run.Reason,
+		"queued_total":    queued,
+		"restarted_total": restarted,
+	}).Warn("Scheduled check trigger waits for the check in progress")
+	s.saveScheduleRun(run, log)
+
+	s.checkMutex.Lock()
+	for s.isCheckingNow && !s.stopping() {
+		s.checkIdle.Wait()
+	}
+	delete(s.queuedSchedules, schedule.ID)
+	if s.stopping() {
+		s.checkMutex.Unlock()
+		s.finishScheduleRun(run, models.ScheduleRunStatusCancelled, 0, log)
+		return nil, nil, false
+	}
+	ctx := s.startRunLocked(schedule.ID)
+	stats.Started++
+	s.checkMutex.Unlock()
+
+	now := time.Now()
+	run.StartedAt = &now
+	run.Status = models.ScheduleRunStatusRunning
+	if err := s.db.Model(run).Updates(map[string]interface{}{
+		"started_at": now,
+		"status":     run.Status,
+	}).Error; err != nil {
+		log.Warnf("Failed to update schedule run: %v", err)
+	}
+	return run, ctx, true
+}
+
+// saveScheduleRun stores schedule trigger in run history
+func (s *CheckScheduler) saveScheduleRun(run *models.ScheduleRun, log *logrus.Entry) {
+	if err := s.db.Create(run).Error; err != nil {
+		log.Warnf("Failed to record schedule run: %v", err)
+	}
+}
+
+// finishScheduleRun stores outcome of a schedule run
+func (s *CheckScheduler) finishScheduleRun(run *models.ScheduleRun, status string, phonesChecked int, log *logrus.Entry) {
+	if run.ID == 0 {
+		return
+	}
+	if err := s.db.Model(run).Updates(map[string]interface{}{
+		"status":         status,
+		"finished_at":    time.Now(),
+		"phones_checked": phonesChecked,
+	}).Error; err != nil {
+		log.Warnf("Failed to update schedule run: %v", err)
+	}
+}
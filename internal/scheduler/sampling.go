@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"spam-checker/internal/models"
+	"spam-checker/internal/services"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runNextTime returns next run of schedule or, for 0, of the default check; now if unknown
+func (s *CheckScheduler) runNextTime(scheduleID uint) time.Time {
+	now := time.Now()
+	var nextRun time.Time
+	if scheduleID == 0 {
+		s.checkMutex.Lock()
+		nextRun = s.nextCheckTime
+		s.checkMutex.Unlock()
+	} else if job, exists := s.jobs[scheduleID]; exists {
+		nextRun = job.NextScheduledTime()
+	}
+
+	if nextRun.Before(now) {
+		return now
+	}
+	return nextRun
+}
+
+// samplePhones narrows phones of a run to a random sample when sampling is enabled and records
+// the sample on run, nil for the default check. On error the run falls back to a full sweep.
+func (s *CheckScheduler) samplePhones(phones []models.PhoneNumber, sampling services.CheckSampling, scheduleID uint, opts services.PhoneCheckOptions, run *models.ScheduleRun, log *logrus.Entry) []models.PhoneNumber {
+	if !sampling.Enabled() || len(phones) == 0 {
+		return phones
+	}
+
+	sample, err := services.SamplePhones(s.db, phones, sampling, opts.ServiceCode, s.runNextTime(scheduleID))
+	if err != nil {
+		log.Warnf("Failed to sample phones, checking all of them: %v", err)
+		return phones
+	}
+
+	log.WithFields(logrus.Fields{
+		"eligible": sample.Eligible,
+		"sampled":  len(sample.Phones),
+		"overdue":  sample.Overdue,
+	}).Infof("Checking random sample of phones, %s", sampling.Guarantee())
+
+	if run != nil && run.ID != 0 {
+		run.Sampled = true
+		run.PhonesEligible = sample.Eligible
+		run.PhonesOverdue = sample.Overdue
+		run.CoverageGuarantee = sampling.Guarantee()
+		if err := s.db.Model(run).Updates(map[string]interface{}{
+			"sampled":            run.Sampled,
+			"phones_eligible":    run.PhonesEligible,
+			"phones_overdue":     run.PhonesOverdue,
+			"coverage_guarantee": run.CoverageGuarantee,
+		}).Error; err != nil {
+			log.Warnf("Failed to update schedule run: %v", err)
+		}
+	}
+
+	return sample.Phones
+}
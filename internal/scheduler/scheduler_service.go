@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"spam-checker/internal/config"
 	"spam-checker/internal/logger"
@@ -21,15 +22,22 @@ type CheckScheduler struct {
 	checkService        *services.CheckService
 	phoneService        *services.PhoneService
 	notificationService *services.NotificationService
+	dockerClient        *services.DockerClient
 	db                  *gorm.DB
 	jobs                map[uint]*gocron.Job
 	cfg                 *config.Config
+	tuning              *services.CheckTuning
 	log                 *logrus.Entry
 	defaultIntervalJob  *gocron.Job
 	currentInterval     int
+	currentAlignment    string // guarded by checkMutex
 	isRunning           bool
 	runningMutex        sync.RWMutex
 	stopChan            chan struct{}
+	cronStop            chan bool // Stops gocron loop, jobs only run on the leader
+
+	// Only one of instances sharing the database runs jobs, others take over when it stops
+	elector *services.LeaderElector
 
 	// Fixed: Single check control with proper timing
 	checkMutex       sync.Mutex
@@ -37,30 +45,81 @@ type CheckScheduler struct {
 	lastCheckTime    time.Time
 	nextCheckTime    time.Time // Track when next check should occur
 	minCheckInterval time.Duration
+
+	// Overlap handling of schedule triggers, guarded by checkMutex
+	checkIdle         *sync.Cond // Signalled when running check finishes
+	runningScheduleID uint       // 0 for default interval check
+	cancelRun         context.CancelFunc
+	queuedSchedules   map[uint]bool
+	triggerStats      map[uint]*ScheduleTriggerStats
+
+	// Next run of each custom schedule, readable from handlers
+	nextRunsMutex    sync.RWMutex
+	scheduleNextRuns map[uint]time.Time
+
+	// Summary of the last completed run, guarded by checkMutex
+	lastRun *services.RunSummary
+
+	// Held while spam flags are re-checked, so a slow pass is not started twice
+	verifyMutex sync.Mutex
+
+	// Liveness tracking, guarded by heartbeatMutex
+	heartbeatMutex   sync.Mutex
+	heartbeat        *models.SchedulerHeartbeat
+	watchdogBaseline time.Time // Scheduler start or end of inactive hours, whichever is later
+	watchdogAlerted  bool
 }
 
-func NewCheckScheduler(db *gorm.DB, checkService *services.CheckService, phoneService *services.PhoneService, notificationService *services.NotificationService, cfg *config.Config) *CheckScheduler {
-	return &CheckScheduler{
+func NewCheckScheduler(db *gorm.DB, checkService *services.CheckService, phoneService *services.PhoneService, notificationService *services.NotificationService, dockerClient *services.DockerClient, cfg *config.Config) *CheckScheduler {
+	s := &CheckScheduler{
 		scheduler:           gocron.NewScheduler(),
 		checkService:        checkService,
 		phoneService:        phoneService,
 		notificationService: notificationService,
+		dockerClient:        dockerClient,
 		db:                  db,
 		jobs:                make(map[uint]*gocron.Job),
+		scheduleNextRuns:    make(map[uint]time.Time),
 		cfg:                 cfg,
+		tuning:              services.NewCheckTuning(db, cfg),
 		log:                 logger.WithField("service", "CheckScheduler"),
 		currentInterval:     -1,
+		currentAlignment:    services.IntervalAlignmentRelative,
 		isRunning:           false,
 		stopChan:            make(chan struct{}),
+		elector:             services.NewLeaderElector(db, schedulerLeaseName),
 		isCheckingNow:       false,
 		minCheckInterval:    5 * time.Minute,
+		queuedSchedules:     make(map[uint]bool),
+		triggerStats:        make(map[uint]*ScheduleTriggerStats),
 	}
+	s.checkIdle = sync.NewCond(&s.checkMutex)
+	return s
 }
 
-// Start starts the scheduler
+// schedulerLeaseName is the lease held by the instance running scheduler jobs
+const schedulerLeaseName = "scheduler"
+
+// Start campaigns for scheduler leadership, jobs start once this instance is elected
+// and stop when it loses the lease
 func (s *CheckScheduler) Start() {
+	s.elector.Run(s.startJobs, s.stopJobs)
+}
+
+// Stop stops jobs and resigns, so another instance takes over without waiting for the lease to expire
+func (s *CheckScheduler) Stop() {
+	s.elector.Stop()
+}
+
+// LeaderStatus returns role of this instance in scheduler election
+func (s *CheckScheduler) LeaderStatus() services.LeaderStatus {
+	return s.elector.Status()
+}
+
+// startJobs starts the scheduler jobs
+func (s *CheckScheduler) startJobs() {
 	log := s.log.WithFields(logrus.Fields{
-		"method": "Start",
+		"method": "startJobs",
 	})
 
 	s.runningMutex.Lock()
@@ -70,10 +129,17 @@ func (s *CheckScheduler) Start() {
 		return
 	}
 	s.isRunning = true
+	s.stopChan = make(chan struct{})
 	s.runningMutex.Unlock()
 
 	log.Info("Starting check scheduler...")
 
+	// Restore last completed sweep, watchdog counts from now so restart does not raise an alert
+	s.loadHeartbeat()
+	s.heartbeatMutex.Lock()
+	s.watchdogBaseline = time.Now()
+	s.heartbeatMutex.Unlock()
+
 	// Load schedules from database
 	s.loadSchedules()
 
@@ -81,13 +147,84 @@ func (s *CheckScheduler) Start() {
 	s.startDefaultIntervalCheck()
 
 	// Start scheduler in background
-	go s.scheduler.Start()
+	s.cronStop = s.scheduler.Start()
 
 	// Monitor gateway statuses every 5 minutes
-	s.scheduler.Every(5).Minutes().Do(func() {
-		adbService := services.NewADBService(s.db, s.cfg)
-		if err := adbService.UpdateAllGatewayStatuses(); err != nil {
+	s.scheduler.Every(uint64(services.GatewayStatusRefreshInterval / time.Minute)).Minutes().Do(func() {
+		adbService := services.NewADBService(s.db, s.cfg, s.dockerClient)
+		summary, err := adbService.UpdateAllGatewayStatuses(true)
+		if err != nil {
 			log.Errorf("Failed to update gateway statuses: %v", err)
+			return
+		}
+		log.WithFields(logrus.Fields{
+			"total":    summary.Total,
+			"online":   summary.Online,
+			"degraded": summary.Degraded,
+			"offline":  summary.Offline,
+			"errored":  summary.Errored,
+			"duration": summary.Duration.String(),
+		}).Info("Gateway statuses refreshed")
+	})
+
+	// Report degraded notification channels once a day
+	s.scheduler.Every(1).Day().At("09:00").Do(func() {
+		if !services.NewSettingsService(s.db).GetCachedBool("enable_notifications", true) {
+			return
+		}
+		if err := s.notificationService.SendDegradedSummary(); err != nil {
+			log.Warnf("Failed to send degraded notification channels summary: %v", err)
+		}
+	})
+
+	// Audit statistics against check results once a week, drift is only reported, never repaired
+	s.scheduler.Every(1).Sunday().At("04:00").Do(func() {
+		report, err := services.NewStatisticsService(s.db).Reconcile(true)
+		if err != nil {
+			log.Warnf("Failed to audit statistics: %v", err)
+			return
+		}
+		if !services.NewSettingsService(s.db).GetCachedBool("enable_notifications", true) {
+			return
+		}
+		if err := s.notificationService.SendStatisticsAudit(report); err != nil {
+			log.Warnf("Failed to send statistics audit: %v", err)
+		}
+	})
+
+	// Fail check jobs of instances that stopped without finishing them
+	s.scheduler.Every(5).Minutes().Do(func() {
+		failed, err := s.checkService.FailInterruptedCheckJobs()
+		if err != nil {
+			log.Warnf("Failed to mark interrupted check jobs: %v", err)
+			return
+		}
+		if failed > 0 {
+			log.Warnf("Marked %d check jobs of stopped instances as failed", failed)
+		}
+	})
+
+	// Drop expired idempotency keys every hour
+	s.scheduler.Every(1).Hour().Do(func() {
+		deleted, err := services.NewIdempotencyService(s.db).DeleteExpired()
+		if err != nil {
+			log.Warnf("Failed to delete expired idempotency keys: %v", err)
+			return
+		}
+		if deleted > 0 {
+			log.Debugf("Deleted %d expired idempotency keys", deleted)
+		}
+	})
+
+	// Delete stale phones created by realtime checks every hour
+	s.scheduler.Every(1).Hour().Do(func() {
+		deleted, err := s.phoneService.SweepRealtimePhones(time.Now())
+		if err != nil {
+			log.Warnf("Failed to sweep realtime phones: %v", err)
+			return
+		}
+		if deleted > 0 {
+			log.Infof("Deleted %d stale realtime phones", deleted)
 		}
 	})
 
@@ -96,13 +233,21 @@ func (s *CheckScheduler) Start() {
 		s.checkForConfigurationChanges()
 	})
 
+	// Re-check new spam flags that are due for verification
+	s.scheduler.Every(1).Minutes().Do(func() {
+		s.verifySpamFlags()
+	})
+
+	// Watch for sweeps that stopped completing
+	go s.runWatchdog(s.stopChan)
+
 	log.Info("Check scheduler started successfully")
 }
 
-// Stop stops the scheduler
-func (s *CheckScheduler) Stop() {
+// stopJobs stops the scheduler jobs
+func (s *CheckScheduler) stopJobs() {
 	log := s.log.WithFields(logrus.Fields{
-		"method": "Stop",
+		"method": "stopJobs",
 	})
 
 	s.runningMutex.Lock()
@@ -118,15 +263,24 @@ func (s *CheckScheduler) Stop() {
 	// Signal stop
 	close(s.stopChan)
 
-	// Clear all jobs
+	// Stop gocron loop and clear all jobs
+	s.cronStop <- true
 	s.scheduler.Clear()
 
 	// Reset state
 	s.isRunning = false
-	s.currentInterval = -1
 	s.defaultIntervalJob = nil
 	s.jobs = make(map[uint]*gocron.Job)
-	s.isCheckingNow = false
+
+	// Abort running check and release queued triggers
+	s.checkMutex.Lock()
+	s.currentInterval = -1
+	s.finishRunLocked()
+	s.checkMutex.Unlock()
+
+	s.nextRunsMutex.Lock()
+	s.scheduleNextRuns = make(map[uint]time.Time)
+	s.nextRunsMutex.Unlock()
 
 	log.Info("Check scheduler stopped")
 }
@@ -174,21 +328,31 @@ func (s *CheckScheduler) canStartCheck() bool {
 
 	// Calculate next check time based on current interval (for default check)
 	if s.currentInterval > 0 {
-		s.nextCheckTime = now.Add(time.Duration(s.currentInterval) * time.Minute)
+		s.nextCheckTime = s.nextDefaultCheckTime(now)
 		s.log.WithFields(logrus.Fields{
 			"next_check": s.nextCheckTime.Format("15:04:05"),
 			"interval":   s.currentInterval,
+			"alignment":  s.currentAlignment,
 		}).Info("Next default check scheduled")
 	}
 
 	return true
 }
 
+// nextDefaultCheckTime returns when default interval check started at now should run again.
+// Must be called with checkMutex held.
+func (s *CheckScheduler) nextDefaultCheckTime(now time.Time) time.Time {
+	if s.currentAlignment == services.IntervalAlignmentWallClock {
+		return services.NextIntervalBoundary(now, s.currentInterval)
+	}
+	return now.Add(time.Duration(s.currentInterval) * time.Minute)
+}
+
 // markCheckComplete marks check as complete
 func (s *CheckScheduler) markCheckComplete() {
 	s.checkMutex.Lock()
 	defer s.checkMutex.Unlock()
-	s.isCheckingNow = false
+	s.finishRunLocked()
 }
 
 // runDefaultCheck runs the default interval check
@@ -197,6 +361,10 @@ func (s *CheckScheduler) runDefaultCheck() {
 		"method": "runDefaultCheck",
 	})
 
+	if !s.withinActiveWindow(log) {
+		return
+	}
+
 	// Check if we can start
 	if !s.canStartCheck() {
 		return
@@ -206,7 +374,7 @@ func (s *CheckScheduler) runDefaultCheck() {
 	log.Info("Starting default interval check")
 
 	// Perform the check with unified method
-	s.performPhoneCheck("default", 0)
+	s.performPhoneCheck(context.Background(), "default", 0, services.PhoneCheckOptions{}, services.DefaultCheckSampling(s.db), nil)
 }
 
 // runScheduledCheck runs a scheduled check
@@ -218,31 +386,41 @@ func (s *CheckScheduler) runScheduledCheck(scheduleID uint) {
 
 	// Get schedule details for logging
 	var schedule models.CheckSchedule
-	if err := s.db.First(&schedule, scheduleID).Error; err == nil {
-		log = log.WithFields(logrus.Fields{
-			"scheduleName": schedule.Name,
-			"expression":   schedule.CronExpression,
-		})
+	if err := s.db.First(&schedule, scheduleID).Error; err != nil {
+		log.Errorf("Failed to get schedule: %v", err)
+		return
 	}
+	log = log.WithFields(logrus.Fields{
+		"scheduleName": schedule.Name,
+		"expression":   schedule.CronExpression,
+	})
 
-	// For scheduled checks, we don't use canStartCheck() because
-	// they should run independently of the default interval check
-	s.checkMutex.Lock()
-	if s.isCheckingNow {
-		s.checkMutex.Unlock()
-		log.Warn("Another check is already in progress, skipping scheduled check")
+	if !s.withinActiveWindow(log) {
+		s.updateScheduleNextRun(scheduleID, log)
 		return
 	}
 
-	// Mark as checking
-	s.isCheckingNow = true
-	s.lastCheckTime = time.Now()
-	s.checkMutex.Unlock()
+	// For scheduled checks, we don't use canStartCheck() because
+	// they should run independently of the default interval check.
+	// Overlap with a check in progress is resolved by the schedule's policy.
+	run, ctx, admitted := s.admitScheduledRun(&schedule, log)
+	if !admitted {
+		s.updateScheduleNextRun(scheduleID, log)
+		return
+	}
 
+	phonesChecked := 0
+	completed := false
 	defer func() {
 		s.checkMutex.Lock()
-		s.isCheckingNow = false
+		s.finishRunLocked()
 		s.checkMutex.Unlock()
+
+		status := models.ScheduleRunStatusCompleted
+		if !completed {
+			status = models.ScheduleRunStatusCancelled
+		}
+		s.finishScheduleRun(run, status, phonesChecked, log)
 	}()
 
 	log.Infof("Starting scheduled check ID: %d (%s)", scheduleID, schedule.Name)
@@ -254,38 +432,82 @@ func (s *CheckScheduler) runScheduledCheck(scheduleID uint) {
 	}
 
 	// Perform the check with unified method
-	s.performPhoneCheck("scheduled", scheduleID)
+	phonesChecked, completed = s.performPhoneCheck(ctx, "scheduled", scheduleID, services.PhoneCheckOptions{
+		Mode:        schedule.CheckMode,
+		ServiceCode: schedule.ServiceCode,
+	}, services.ScheduleCheckSampling(s.db, &schedule), run)
 
-	// Update next run time
+	s.updateScheduleNextRun(scheduleID, log)
+}
+
+// updateScheduleNextRun stores next run time of a custom schedule
+func (s *CheckScheduler) updateScheduleNextRun(scheduleID uint, log *logrus.Entry) {
 	if job, exists := s.jobs[scheduleID]; exists {
 		nextRun := job.NextScheduledTime()
+		s.setScheduleNextRun(scheduleID, nextRun)
 		s.db.Model(&models.CheckSchedule{}).Where("id = ?", scheduleID).Update("next_run", &nextRun)
-		log.Infof("Scheduled check completed. Next run scheduled for: %s", nextRun.Format("2006-01-02 15:04:05"))
+		log.Infof("Next run scheduled for: %s", nextRun.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// withinActiveWindow reports whether automatic checks may run now, logging skipped runs.
+// Manual and realtime checks don't go through the scheduler and are not restricted.
+func (s *CheckScheduler) withinActiveWindow(log *logrus.Entry) bool {
+	window := services.NewSettingsService(s.db).GetActiveWindow()
+	if window.Contains(time.Now()) {
+		return true
 	}
+
+	log.WithFields(logrus.Fields{
+		"days":     window.Days,
+		"start":    window.Start,
+		"end":      window.End,
+		"timezone": window.Timezone,
+	}).Info("Outside active window, skipping check")
+	return false
 }
 
-// performPhoneCheck performs the actual phone checking with proper result aggregation
-func (s *CheckScheduler) performPhoneCheck(checkType string, scheduleID uint) {
+// performPhoneCheck performs the actual phone checking with proper result aggregation.
+// Schedules may override check mode and limit the run to one spam service through opts.
+// Enabled sampling checks a random subset of phones, recorded on run of a schedule.
+// It returns number of phones checked and false when the run was aborted by ctx or scheduler stop.
+func (s *CheckScheduler) performPhoneCheck(ctx context.Context, checkType string, scheduleID uint, opts services.PhoneCheckOptions, sampling services.CheckSampling, run *models.ScheduleRun) (int, bool) {
 	log := s.log.WithFields(logrus.Fields{
 		"method":     "performPhoneCheck",
 		"checkType":  checkType,
 		"scheduleID": scheduleID,
 	})
+	if opts.Mode != "" {
+		log = log.WithField("checkMode", opts.Mode)
+	}
+	if opts.ServiceCode != "" {
+		log = log.WithField("serviceCode", opts.ServiceCode)
+	}
 
 	startTime := time.Now()
 
-	// Get active phones
-	phones, err := s.phoneService.GetActivePhones()
+	// Get active phones (limited to schedule's explicit list if it has one)
+	var phones []models.PhoneNumber
+	var err error
+	if scheduleID > 0 {
+		phones, err = s.phoneService.GetActivePhonesForSchedule(scheduleID)
+	} else {
+		phones, err = s.phoneService.GetActivePhones()
+	}
 	if err != nil {
 		log.Errorf("Failed to get active phones: %v", err)
-		return
+		return 0, true
 	}
 
 	if len(phones) == 0 {
 		log.Info("No active phones to check")
-		return
+		s.recordHeartbeat(checkType, scheduleID, 0)
+		s.sendRunCompletionNotification(checkType, scheduleID, runCompletionStats{Duration: time.Since(startTime)})
+		return 0, true
 	}
 
+	phones = s.samplePhones(phones, sampling, scheduleID, opts, run, log)
+
 	log.Infof("Starting check for %d phones", len(phones))
 
 	// Track all results for single notification
@@ -293,31 +515,51 @@ func (s *CheckScheduler) performPhoneCheck(checkType string, scheduleID uint) {
 	totalSpamCount := 0
 	successCount := 0
 	var checkErrors []error
+	checked := 0
+	excludedChecks := 0
+	persistedAfterRetry := 0
+	journaledResults := 0
 
 	// Check each phone sequentially to avoid conflicts
 	for _, phone := range phones {
-		// Check if we're stopping
+		// Check if we're stopping or the run was cancelled
 		select {
 		case <-s.stopChan:
 			log.Info("Scheduler stopping, aborting check")
-			return
+			return checked, false
+		case <-ctx.Done():
+			log.Infof("Run cancelled after %d of %d phones, aborting check", checked, len(phones))
+			return checked, false
 		default:
 		}
 
 		// Perform check with timeout
-		checkDone := make(chan error, 1)
+		checkDone := make(chan phoneCheckOutcome, 1)
 		go func(p models.PhoneNumber) {
-			checkDone <- s.checkService.CheckPhoneNumber(p.ID)
+			report, err := s.checkService.CheckPhoneNumberDetailedWithOptions(p.ID, opts)
+			if err != nil {
+				checkDone <- phoneCheckOutcome{err: err}
+				return
+			}
+			checkDone <- phoneCheckOutcome{
+				err:       report.Err,
+				excluded:  report.ExcludedCount(),
+				retried:   report.PersistedAfterRetry,
+				journaled: report.Journaled,
+			}
 		}(phone)
 
 		select {
-		case err := <-checkDone:
-			if err != nil {
+		case outcome := <-checkDone:
+			excludedChecks += outcome.excluded
+			persistedAfterRetry += outcome.retried
+			journaledResults += outcome.journaled
+			if err := outcome.err; err != nil {
 				// Check if it's a "already checking" error - don't count as error
 				if strings.Contains(err.Error(), "already being checked") {
-					log.Debugf("Phone %s is already being checked by another process", phone.Number)
+					log.Debugf("Phone %s is already being checked by another process", logger.FormatPhone(phone.Number))
 				} else {
-					log.Errorf("Failed to check phone %s: %v", phone.Number, err)
+					log.Errorf("Failed to check phone %s: %v", logger.FormatPhone(phone.Number), err)
 					checkErrors = append(checkErrors, err)
 				}
 			} else {
@@ -331,42 +573,112 @@ func (s *CheckScheduler) performPhoneCheck(checkType string, scheduleID uint) {
 					}
 				}
 			}
-		case <-time.After(30 * time.Second):
-			log.Warnf("Check timeout for phone %s", phone.Number)
-			checkErrors = append(checkErrors, fmt.Errorf("timeout checking phone %s", phone.Number))
+		case <-time.After(s.tuning.PhoneCheckTimeout()):
+			log.Warnf("Check timeout for phone %s", logger.FormatPhone(phone.Number))
+			checkErrors = append(checkErrors, fmt.Errorf("timeout checking phone %s", logger.FormatPhone(phone.Number)))
 		case <-s.stopChan:
 			log.Info("Scheduler stopping, aborting check")
-			return
+			return checked, false
+		case <-ctx.Done():
+			// Check of the current phone finishes in background and keeps its phone lock until then
+			log.Infof("Run cancelled while checking phone %s, aborting check", logger.FormatPhone(phone.Number))
+			return checked, false
 		}
+		checked++
 
 		// Small delay between checks to avoid overwhelming the system
-		time.Sleep(1 * time.Second)
+		time.Sleep(s.tuning.InterPhoneDelay())
 	}
 
 	// Calculate duration
 	duration := time.Since(startTime)
 
+	// Spam flags are left out of the results until a re-check confirms them
+	pendingVerification, err := s.phoneService.CountPendingSpamVerifications()
+	if err != nil {
+		log.Warnf("Failed to count spam flags pending verification: %v", err)
+	}
+
 	// Log summary
-	log.Infof("%s check completed in %v. Checked %d phones, found %d spam, %d succeeded, %d errors",
-		checkType, duration, len(phones), totalSpamCount, successCount, len(checkErrors))
+	log.Infof("%s check completed in %v. Checked %d phones, found %d spam, %d pending verification, %d succeeded, %d errors, %d service checks excluded",
+		checkType, duration, len(phones), totalSpamCount, pendingVerification, successCount, len(checkErrors), excludedChecks)
+	if persistedAfterRetry > 0 || journaledResults > 0 {
+		log.Warnf("Database was unavailable during %s check: %d results persisted after retry, %d journaled",
+			checkType, persistedAfterRetry, journaledResults)
+	}
+
+	s.checkMutex.Lock()
+	s.lastRun = &services.RunSummary{
+		CheckType:           checkType,
+		CompletedAt:         time.Now(),
+		PhonesChecked:       len(phones),
+		SpamFound:           totalSpamCount,
+		PendingVerification: pendingVerification,
+		ChecksExcluded:      excludedChecks,
+		PersistedAfterRetry: persistedAfterRetry,
+		Journaled:           journaledResults,
+		Sampled:             sampling.Enabled(),
+	}
+	s.checkMutex.Unlock()
+	s.recordHeartbeat(checkType, scheduleID, len(phones))
+	s.detectSpamAnomaly(checkType, scheduleID, allResults)
+
+	// Services without a single fresh result, likely all their gateways were down
+	coverageGaps, err := s.checkService.ServicesWithoutFreshResults(startTime, opts)
+	if err != nil {
+		log.Warnf("Failed to find services without fresh results: %v", err)
+	} else if len(coverageGaps) > 0 {
+		log.Warnf("No fresh results during run from services: %s", strings.Join(coverageGaps, ", "))
+	}
 
-	// Send single consolidated notification if spam found
-	if totalSpamCount > 0 {
-		s.sendConsolidatedNotification(checkType, scheduleID, totalSpamCount, len(phones), allResults)
+	// Send single consolidated notification if spam found, otherwise optional error or clean-run summary
+	switch {
+	case totalSpamCount > 0:
+		s.sendConsolidatedNotification(checkType, scheduleID, totalSpamCount, len(phones), allResults, coverageGaps, pendingVerification)
+	case s.errorThresholdExceeded(len(phones), len(checkErrors)):
+		s.sendRunErrorNotification(checkType, scheduleID, len(phones), checkErrors, duration)
+	default:
+		s.sendCleanRunNotification(checkType, scheduleID, len(phones), len(checkErrors), duration, coverageGaps, pendingVerification)
 	}
+
+	// Heartbeat summary of every finished run, independent of the notifications above
+	s.sendRunCompletionNotification(checkType, scheduleID, runCompletionStats{
+		Checked:             len(phones),
+		Spam:                totalSpamCount,
+		Errors:              len(checkErrors),
+		Excluded:            excludedChecks,
+		PersistedAfterRetry: persistedAfterRetry,
+		Journaled:           journaledResults,
+		PendingVerification: pendingVerification,
+		CoverageGaps:        coverageGaps,
+		Duration:            duration,
+	})
+
+	return checked, true
+}
+
+// phoneCheckOutcome is outcome of a single phone check within a run
+type phoneCheckOutcome struct {
+	err       error
+	excluded  int // Service checks skipped by phone exclusions
+	retried   int // Results saved once the database came back
+	journaled int // Results kept in the journal until the database is back
 }
 
 // PhoneCheckSummary holds summary of check results for a phone
 type PhoneCheckSummary struct {
 	PhoneNumber string
+	Campaign    string
 	IsSpam      bool
 	Services    map[string]*ServiceResult
 }
 
 // ServiceResult holds result for a specific service
 type ServiceResult struct {
-	IsSpam   bool
-	Keywords []string
+	IsSpam     bool
+	Keywords   []string
+	Snippet    string // Text around the first keyword match
+	Confidence float64
 }
 
 // getPhoneSummary gets summary of latest check results for a phone
@@ -379,14 +691,22 @@ func (s *CheckScheduler) getPhoneSummary(phoneID uint) *PhoneCheckSummary {
 
 	summary := &PhoneCheckSummary{
 		PhoneNumber: phone.Number,
+		Campaign:    phone.Campaign,
 		Services:    make(map[string]*ServiceResult),
 	}
 
-	// Get latest check results grouped by service
+	// Get latest check results grouped by service, flags waiting for re-check or not confirmed by it are left out.
+	// Services the phone is excluded from are not applicable, their old results are left out too.
 	var results []models.CheckResult
+	excludedServices := s.db.Model(&models.PhoneServiceExclusion{}).
+		Select("service_id").
+		Where("phone_number_id = ?", phoneID)
 	subQuery := s.db.Model(&models.CheckResult{}).
 		Select("MAX(id) as id").
-		Where("phone_number_id = ?", phoneID).
+		Where("phone_number_id = ? AND source <> ? AND status <> ? AND COALESCE(verification, '') NOT IN ?",
+			phoneID, models.CheckSourceImport, models.SpamStatusError,
+			[]string{models.VerificationPending, models.VerificationUnconfirmed}).
+		Where("service_id NOT IN (?)", excludedServices).
 		Group("service_id")
 
 	err := s.db.
@@ -407,8 +727,12 @@ func (s *CheckScheduler) getPhoneSummary(phoneID uint) *PhoneCheckSummary {
 		}
 
 		summary.Services[serviceName] = &ServiceResult{
-			IsSpam:   result.IsSpam,
-			Keywords: []string(result.FoundKeywords),
+			IsSpam:     result.IsSpam,
+			Keywords:   []string(result.FoundKeywords),
+			Confidence: result.Confidence,
+		}
+		if len(result.KeywordMatches) > 0 {
+			summary.Services[serviceName].Snippet = result.KeywordMatches[0].Snippet
 		}
 
 		if result.IsSpam {
@@ -420,53 +744,27 @@ func (s *CheckScheduler) getPhoneSummary(phoneID uint) *PhoneCheckSummary {
 }
 
 // sendConsolidatedNotification sends a single notification with all results
-func (s *CheckScheduler) sendConsolidatedNotification(checkType string, scheduleID uint, spamCount, totalCount int, results map[uint]*PhoneCheckSummary) {
+func (s *CheckScheduler) sendConsolidatedNotification(checkType string, scheduleID uint, spamCount, totalCount int, results map[uint]*PhoneCheckSummary, coverageGaps []string, pendingVerification int64) {
 	log := s.log.WithFields(logrus.Fields{
 		"method": "sendConsolidatedNotification",
 	})
 
-	// Check if notifications are enabled
-	var enableNotificationsSetting models.SystemSettings
-	enableNotifications := true // Default to true
-
-	if err := s.db.Where("key = ?", "enable_notifications").First(&enableNotificationsSetting).Error; err == nil {
-		if enableNotificationsSetting.Value == "false" || enableNotificationsSetting.Value == "0" {
-			enableNotifications = false
-		}
-	}
+	settingsService := services.NewSettingsService(s.db)
 
-	if !enableNotifications {
+	// Check if notifications are enabled
+	if !settingsService.GetCachedBool("enable_notifications", true) {
 		log.Debug("Notifications are disabled in settings")
 		return
 	}
 
 	// Check if notifications for spam detection are enabled
-	var notifyOnSpamSetting models.SystemSettings
-	notifyOnSpam := true // Default to true
-
-	if err := s.db.Where("key = ?", "notify_on_spam_detection").First(&notifyOnSpamSetting).Error; err == nil {
-		if notifyOnSpamSetting.Value == "false" || notifyOnSpamSetting.Value == "0" {
-			notifyOnSpam = false
-		}
-	}
-
-	if !notifyOnSpam {
+	if !settingsService.GetCachedBool("notify_on_spam_detection", true) {
 		log.Debug("Spam detection notifications are disabled")
 		return
 	}
 
 	// Build notification message
-	var title string
-	if checkType == "scheduled" && scheduleID > 0 {
-		var schedule models.CheckSchedule
-		if err := s.db.First(&schedule, scheduleID).Error; err == nil {
-			title = fmt.Sprintf("📋 %s Results", schedule.Name)
-		} else {
-			title = "📋 Результат проверки по расписанию"
-		}
-	} else {
-		title = "🔍 Результат проверки"
-	}
+	title := s.notificationTitle(checkType, scheduleID)
 
 	message := fmt.Sprintf(
 		"%s\n\n"+
@@ -475,6 +773,8 @@ func (s *CheckScheduler) sendConsolidatedNotification(checkType string, schedule
 			"Чистые: %d\n",
 		title, totalCount, spamCount, totalCount-spamCount,
 	)
+	message += pendingVerificationMessage(pendingVerification)
+	message += coverageGapsMessage(coverageGaps)
 
 	// Group spam results by service
 	serviceSpamMap := make(map[string][]string)
@@ -484,14 +784,28 @@ func (s *CheckScheduler) sendConsolidatedNotification(checkType string, schedule
 			continue
 		}
 
+		// Context of the first match explains the flag, once per number keeps the message short
+		snippetShown := false
 		for serviceName, result := range summary.Services {
 			if result.IsSpam {
-				phoneInfo := fmt.Sprintf("%s: %v", summary.PhoneNumber, result.Keywords)
+				phoneInfo := fmt.Sprintf("%s%s: %v, уверенность %.0f%%",
+					summary.PhoneNumber, campaignLabel(summary.Campaign), result.Keywords, result.Confidence*100)
+				if result.Snippet != "" && !snippetShown {
+					phoneInfo += fmt.Sprintf(" — «%s»", result.Snippet)
+					snippetShown = true
+				}
 				serviceSpamMap[serviceName] = append(serviceSpamMap[serviceName], phoneInfo)
 			}
 		}
 	}
 
+	// Numbers that became spam since the last notification
+	transitions, err := s.phoneService.GetPendingSpamTransitions()
+	if err != nil {
+		log.Warnf("Failed to get status transitions: %v", err)
+	}
+	message += newSpamNumbersMessage(transitions)
+
 	// Add spam details grouped by service
 	if len(serviceSpamMap) > 0 {
 		message += "\n⚠️🚨 Обнаружение спама по сервисам:\n"
@@ -503,6 +817,266 @@ func (s *CheckScheduler) sendConsolidatedNotification(checkType string, schedule
 		}
 	}
 
+	if s.dispatchNotification(log, title, message) {
+		s.markTransitionsNotified(log, transitions)
+	}
+}
+
+// newSpamNumbersMessage lists numbers that became spam since the last notification
+func newSpamNumbersMessage(transitions []models.SpamStatusTransition) string {
+	if len(transitions) == 0 {
+		return ""
+	}
+
+	const maxListedTransitions = 20
+	message := "\n🆕 Новые спам-номера:\n"
+	for i, transition := range transitions {
+		if i == maxListedTransitions {
+			message += fmt.Sprintf("  … и ещё %d\n", len(transitions)-maxListedTransitions)
+			break
+		}
+		message += fmt.Sprintf("  • %s%s (%s): %v\n",
+			logger.FormatPhone(transition.PhoneNumber.Number), campaignLabel(transition.PhoneNumber.Campaign),
+			transition.Service.Name, []string(transition.Keywords))
+	}
+	return message
+}
+
+// markTransitionsNotified marks transitions included in a sent notification
+func (s *CheckScheduler) markTransitionsNotified(log *logrus.Entry, transitions []models.SpamStatusTransition) {
+	if len(transitions) == 0 {
+		return
+	}
+
+	ids := make([]uint, len(transitions))
+	for i, transition := range transitions {
+		ids[i] = transition.ID
+	}
+	if err := s.phoneService.MarkSpamTransitionsNotified(ids); err != nil {
+		log.Warnf("Failed to mark transitions notified: %v", err)
+	}
+}
+
+// verifySpamFlags re-checks due spam flags and notifies about flags the re-check confirmed
+func (s *CheckScheduler) verifySpamFlags() {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "verifySpamFlags",
+	})
+
+	if !s.verifyMutex.TryLock() {
+		log.Debug("Previous verification pass is still running")
+		return
+	}
+	defer s.verifyMutex.Unlock()
+
+	resolved, err := s.checkService.VerifySpamFlags(time.Now())
+	if err != nil {
+		log.Errorf("Failed to verify spam flags: %v", err)
+	}
+	if resolved == 0 {
+		return
+	}
+	log.Infof("Resolved %d spam flags pending verification", resolved)
+
+	settingsService := services.NewSettingsService(s.db)
+	if !settingsService.GetCachedBool("enable_notifications", true) ||
+		!settingsService.GetCachedBool("notify_on_spam_detection", true) {
+		return
+	}
+
+	// Unconfirmed flags leave no transitions, so only confirmed ones are listed
+	transitions, err := s.phoneService.GetPendingSpamTransitions()
+	if err != nil {
+		log.Warnf("Failed to get status transitions: %v", err)
+		return
+	}
+	if len(transitions) == 0 {
+		return
+	}
+
+	title := "🔁 Спам подтверждён повторной проверкой"
+	if s.dispatchNotification(log, title, title+"\n"+newSpamNumbersMessage(transitions)) {
+		s.markTransitionsNotified(log, transitions)
+	}
+}
+
+// pendingVerificationMessage reports spam flags left out of the run results until re-checked
+func pendingVerificationMessage(pendingVerification int64) string {
+	if pendingVerification == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Ожидают подтверждения повторной проверкой: %d\n", pendingVerification)
+}
+
+// notificationTitle builds notification title for scheduled or default run
+func (s *CheckScheduler) notificationTitle(checkType string, scheduleID uint) string {
+	if checkType == "scheduled" && scheduleID > 0 {
+		var schedule models.CheckSchedule
+		if err := s.db.First(&schedule, scheduleID).Error; err == nil {
+			return fmt.Sprintf("📋 %s Results", schedule.Name)
+		}
+		return "📋 Результат проверки по расписанию"
+	}
+	return "🔍 Результат проверки"
+}
+
+// errorThresholdExceeded reports whether run errors should trigger a notification
+func (s *CheckScheduler) errorThresholdExceeded(totalCount, errorCount int) bool {
+	if errorCount == 0 || totalCount == 0 {
+		return false
+	}
+
+	settingsService := services.NewSettingsService(s.db)
+	if !settingsService.GetCachedBool("notify_on_errors", false) {
+		return false
+	}
+
+	countThreshold := settingsService.GetCachedInt("notify_error_count_threshold", 5)
+	ratePercent := settingsService.GetCachedInt("notify_error_rate_percent", 20)
+
+	if countThreshold > 0 && errorCount >= countThreshold {
+		return true
+	}
+	return ratePercent > 0 && errorCount*100 >= ratePercent*totalCount
+}
+
+// sendRunErrorNotification notifies about a run with too many errors
+func (s *CheckScheduler) sendRunErrorNotification(checkType string, scheduleID uint, totalCount int, checkErrors []error, duration time.Duration) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "sendRunErrorNotification",
+	})
+
+	if !services.NewSettingsService(s.db).GetCachedBool("enable_notifications", true) {
+		log.Debug("Notifications are disabled in settings")
+		return
+	}
+
+	title := s.notificationTitle(checkType, scheduleID)
+	message := fmt.Sprintf(
+		"%s\n\n"+
+			"❗ Проверка завершилась с ошибками\n"+
+			"Всего номеров: %d\n"+
+			"Ошибок: %d (%d%%)\n"+
+			"Длительность: %s\n",
+		title, totalCount, len(checkErrors), len(checkErrors)*100/totalCount, duration.Round(time.Second),
+	)
+
+	// Show only first errors to keep message compact
+	const maxListedErrors = 5
+	for i, err := range checkErrors {
+		if i == maxListedErrors {
+			message += fmt.Sprintf("  … и ещё %d\n", len(checkErrors)-maxListedErrors)
+			break
+		}
+		message += fmt.Sprintf("  • %v\n", err)
+	}
+
+	s.dispatchNotification(log, title, message)
+}
+
+// sendCleanRunNotification sends compact summary of a run without spam
+func (s *CheckScheduler) sendCleanRunNotification(checkType string, scheduleID uint, totalCount, errorCount int, duration time.Duration, coverageGaps []string, pendingVerification int64) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "sendCleanRunNotification",
+	})
+
+	settingsService := services.NewSettingsService(s.db)
+	if !settingsService.GetCachedBool("enable_notifications", true) ||
+		!settingsService.GetCachedBool("notify_on_clean_runs", false) {
+		return
+	}
+	// Run completion summary already reports clean runs
+	if settingsService.GetCachedBool("notify_on_run_completion", false) {
+		log.Debug("Clean run is reported by run completion summary")
+		return
+	}
+
+	title := s.notificationTitle(checkType, scheduleID)
+	message := fmt.Sprintf(
+		"%s\n\n"+
+			"✅ Спам не обнаружен\n"+
+			"Проверено номеров: %d\n"+
+			"Ошибок: %d\n"+
+			"Длительность: %s\n",
+		title, totalCount, errorCount, duration.Round(time.Second),
+	)
+	message += pendingVerificationMessage(pendingVerification)
+	message += coverageGapsMessage(coverageGaps)
+
+	s.dispatchNotification(log, title, message)
+}
+
+// runCompletionStats holds counters of a finished run for the completion summary
+type runCompletionStats struct {
+	Checked             int
+	Spam                int
+	Errors              int
+	Excluded            int // Service checks skipped by phone exclusions
+	PersistedAfterRetry int // Results saved once the database came back
+	Journaled           int // Results kept in the journal until the database is back
+	PendingVerification int64
+	CoverageGaps        []string
+	Duration            time.Duration
+}
+
+// sendRunCompletionNotification sends "run finished" summary after every run when notify_on_run_completion
+// is enabled, so teams get a heartbeat that checks are running even when nothing is found
+func (s *CheckScheduler) sendRunCompletionNotification(checkType string, scheduleID uint, stats runCompletionStats) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "sendRunCompletionNotification",
+	})
+
+	settingsService := services.NewSettingsService(s.db)
+	if !settingsService.GetCachedBool("enable_notifications", true) ||
+		!settingsService.GetCachedBool("notify_on_run_completion", false) {
+		return
+	}
+
+	title := s.notificationTitle(checkType, scheduleID)
+	message := fmt.Sprintf(
+		"%s\n\n"+
+			"🏁 Проверка завершена\n"+
+			"Проверено номеров: %d\n"+
+			"Обнаружено спама: %d\n"+
+			"Ошибок: %d\n"+
+			"Длительность: %s\n",
+		title, stats.Checked, stats.Spam, stats.Errors, stats.Duration.Round(time.Second),
+	)
+	if stats.Excluded > 0 {
+		message += fmt.Sprintf("Пропущено по исключениям: %d\n", stats.Excluded)
+	}
+	if stats.PersistedAfterRetry > 0 {
+		message += fmt.Sprintf("Сохранено после повторов (БД была недоступна): %d\n", stats.PersistedAfterRetry)
+	}
+	if stats.Journaled > 0 {
+		message += fmt.Sprintf("⚠️ Отложено в журнал до восстановления БД: %d\n", stats.Journaled)
+	}
+	message += pendingVerificationMessage(stats.PendingVerification)
+	message += coverageGapsMessage(stats.CoverageGaps)
+
+	s.dispatchNotification(log, title, message)
+}
+
+// campaignLabel formats phone campaign for notification lines, empty for phones without one
+func campaignLabel(campaign string) string {
+	if campaign == "" {
+		return ""
+	}
+	return fmt.Sprintf(" [%s]", campaign)
+}
+
+// coverageGapsMessage lists services without fresh results in the run, their verdicts are stale
+func coverageGapsMessage(coverageGaps []string) string {
+	if len(coverageGaps) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n📵 Нет свежих результатов от сервисов: %s\nВероятно, все их шлюзы были недоступны, вердикты по ним устарели.\n",
+		strings.Join(coverageGaps, ", "))
+}
+
+// dispatchNotification sends notification, logging failures without failing the run.
+// Returns true if the notification was sent.
+func (s *CheckScheduler) dispatchNotification(log *logrus.Entry, title, message string) bool {
 	// Send notification with error handling
 	if err := s.notificationService.SendNotification(title, message); err != nil {
 		// Check if it's a critical error or just a temporary issue
@@ -516,9 +1090,11 @@ func (s *CheckScheduler) sendConsolidatedNotification(checkType string, schedule
 
 		// Don't fail the entire check process because of notification errors
 		// The check results are already saved in the database
-	} else {
-		log.Info("Notification sent successfully")
+		return false
 	}
+
+	log.Info("Notification sent successfully")
+	return true
 }
 
 // Helper function to check if we should send notifications for this check type
@@ -552,15 +1128,26 @@ func (s *CheckScheduler) checkForConfigurationChanges() {
 		"method": "checkForConfigurationChanges",
 	})
 
-	// Check if check_interval_minutes has changed
+	// Check if check_interval_minutes or its alignment has changed
+	alignment := services.NewSettingsService(s.db).GetIntervalAlignment()
+	s.checkMutex.Lock()
+	currentAlignment := s.currentAlignment
+	s.checkMutex.Unlock()
+
 	var setting models.SystemSettings
 	if err := s.db.Where("key = ?", "check_interval_minutes").First(&setting).Error; err == nil {
 		intervalMinutes, err := strconv.Atoi(setting.Value)
 		if err == nil && intervalMinutes > 0 {
-			// Only restart if interval actually changed
-			if intervalMinutes != s.currentInterval {
+			// Only restart if interval or alignment actually changed
+			switch {
+			case intervalMinutes != s.currentInterval:
 				log.Infof("Check interval changed from %d to %d minutes", s.currentInterval, intervalMinutes)
-				s.updateDefaultIntervalCheck(intervalMinutes)
+				s.updateDefaultIntervalCheck(intervalMinutes, alignment)
+			case alignment != currentAlignment:
+				log.Infof("Check interval alignment changed from %s to %s", currentAlignment, alignment)
+				s.updateDefaultIntervalCheck(intervalMinutes, alignment)
+			case alignment == services.IntervalAlignmentWallClock:
+				s.correctDefaultIntervalDrift(log)
 			}
 		}
 	}
@@ -589,14 +1176,16 @@ func (s *CheckScheduler) startDefaultIntervalCheck() {
 		}
 	}
 
-	s.updateDefaultIntervalCheck(intervalMinutes)
+	s.updateDefaultIntervalCheck(intervalMinutes, services.NewSettingsService(s.db).GetIntervalAlignment())
 }
 
-// updateDefaultIntervalCheck updates the default interval check job
-func (s *CheckScheduler) updateDefaultIntervalCheck(intervalMinutes int) {
+// updateDefaultIntervalCheck updates the default interval check job. With wall-clock alignment
+// the first run is placed on the next interval boundary instead of one interval from now.
+func (s *CheckScheduler) updateDefaultIntervalCheck(intervalMinutes int, alignment string) {
 	log := s.log.WithFields(logrus.Fields{
-		"method":   "updateDefaultIntervalCheck",
-		"interval": intervalMinutes,
+		"method":    "updateDefaultIntervalCheck",
+		"interval":  intervalMinutes,
+		"alignment": alignment,
 	})
 
 	// Remove old job if exists
@@ -605,9 +1194,6 @@ func (s *CheckScheduler) updateDefaultIntervalCheck(intervalMinutes int) {
 		s.defaultIntervalJob = nil
 	}
 
-	// Update current interval
-	s.currentInterval = intervalMinutes
-
 	// Update minimum check interval to be at least 1/4 of the interval
 	minInterval := time.Duration(intervalMinutes/4) * time.Minute
 	if minInterval < 5*time.Minute {
@@ -615,19 +1201,58 @@ func (s *CheckScheduler) updateDefaultIntervalCheck(intervalMinutes int) {
 	}
 	s.minCheckInterval = minInterval
 
-	// Set next check time
+	// Set current interval and next check time
 	s.checkMutex.Lock()
-	s.nextCheckTime = time.Now().Add(time.Duration(intervalMinutes) * time.Minute)
+	s.currentInterval = intervalMinutes
+	s.currentAlignment = alignment
+	s.nextCheckTime = s.nextDefaultCheckTime(time.Now())
+	firstRun := s.nextCheckTime
 	s.checkMutex.Unlock()
 
-	log.Infof("Setting default interval check to every %d minutes (min interval: %v)", intervalMinutes, minInterval)
+	log.Infof("Setting default interval check to every %d minutes (min interval: %v, first run: %s)",
+		intervalMinutes, minInterval, firstRun.Format("2006-01-02 15:04:05"))
 
 	// Create new job
 	job := s.scheduler.Every(uint64(intervalMinutes)).Minutes()
+	if alignment == services.IntervalAlignmentWallClock {
+		job.From(&firstRun)
+	}
 	job.Do(s.runDefaultCheck)
 	s.defaultIntervalJob = job
 }
 
+// correctDefaultIntervalDrift moves next run of the default interval job back onto the wall-clock
+// boundary. gocron schedules the following run from the moment a job actually fired, so every run
+// lands slightly later than the previous one and the delay accumulates.
+func (s *CheckScheduler) correctDefaultIntervalDrift(log *logrus.Entry) {
+	job := s.defaultIntervalJob
+	if job == nil {
+		return
+	}
+
+	now := time.Now()
+	nextRun := job.NextScheduledTime()
+	// Job is due and about to fire, leave it alone
+	if !nextRun.After(now) {
+		return
+	}
+
+	s.checkMutex.Lock()
+	boundary := services.NextIntervalBoundary(now, s.currentInterval)
+	s.checkMutex.Unlock()
+
+	if nextRun.Equal(boundary) {
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"scheduled": nextRun.Format("2006-01-02 15:04:05.000"),
+		"boundary":  boundary.Format("2006-01-02 15:04:05"),
+		"drift":     nextRun.Sub(boundary),
+	}).Debug("Realigning default interval check to wall-clock boundary")
+	job.From(&boundary)
+}
+
 // loadSchedules loads schedules from database
 func (s *CheckScheduler) loadSchedules() {
 	log := s.log.WithFields(logrus.Fields{
@@ -674,6 +1299,7 @@ func (s *CheckScheduler) AddSchedule(schedule *models.CheckSchedule) error {
 
 	// Update next run time
 	nextRun := job.NextScheduledTime()
+	s.setScheduleNextRun(schedule.ID, nextRun)
 	s.db.Model(schedule).Update("next_run", &nextRun)
 
 	log.Infof("Added schedule: %s (%s), next run: %s",
@@ -692,6 +1318,7 @@ func (s *CheckScheduler) RemoveSchedule(scheduleID uint) {
 	if job, exists := s.jobs[scheduleID]; exists {
 		s.scheduler.Remove(job)
 		delete(s.jobs, scheduleID)
+		s.setScheduleNextRun(scheduleID, time.Time{})
 		log.Infof("Removed schedule ID: %d", scheduleID)
 	}
 }
@@ -975,29 +1602,46 @@ func (s *CheckScheduler) GetScheduleStatus() []map[string]interface{} {
 	nextCheck := s.nextCheckTime
 	lastCheck := s.lastCheckTime
 	isChecking := s.isCheckingNow
+	alignment := s.currentAlignment
 	s.checkMutex.Unlock()
 
+	expression := fmt.Sprintf("Every %d minutes", intervalMinutes)
+	// Boundary is reported only for aligned checks, relative ones have none
+	var nextBoundary *time.Time
+	if alignment == services.IntervalAlignmentWallClock {
+		expression += " aligned to wall clock"
+		boundary := services.NextIntervalBoundary(time.Now(), intervalMinutes)
+		nextBoundary = &boundary
+	}
+
 	status = append(status, map[string]interface{}{
-		"id":         0,
-		"name":       "Default Interval Check",
-		"expression": fmt.Sprintf("Every %d minutes", intervalMinutes),
-		"is_active":  s.defaultIntervalJob != nil,
-		"last_run":   lastCheck,
-		"next_run":   nextCheck,
-		"is_running": isChecking,
-		"is_default": true,
+		"id":            0,
+		"name":          "Default Interval Check",
+		"expression":    expression,
+		"alignment":     alignment,
+		"is_active":     s.defaultIntervalJob != nil,
+		"last_run":      lastCheck,
+		"next_run":      nextCheck,
+		"next_boundary": nextBoundary,
+		"is_running":    isChecking,
+		"is_default":    true,
 	})
 
-	// Add custom schedules
+	// Add custom schedules with trigger counters, skipped and queued triggers expose lack of capacity
+	triggerStats := s.TriggerStats()
 	for _, schedule := range schedules {
 		item := map[string]interface{}{
-			"id":         schedule.ID,
-			"name":       schedule.Name,
-			"expression": schedule.CronExpression,
-			"is_active":  schedule.IsActive,
-			"last_run":   schedule.LastRun,
-			"next_run":   schedule.NextRun,
-			"is_default": false,
+			"id":             schedule.ID,
+			"name":           schedule.Name,
+			"expression":     schedule.CronExpression,
+			"check_mode":     schedule.CheckMode,
+			"service_code":   schedule.ServiceCode,
+			"overlap_policy": schedule.OverlapPolicy,
+			"triggers":       triggerStats[schedule.ID],
+			"is_active":      schedule.IsActive,
+			"last_run":       schedule.LastRun,
+			"next_run":       schedule.NextRun,
+			"is_default":     false,
 		}
 
 		if _, exists := s.jobs[schedule.ID]; exists {
@@ -1018,3 +1662,14 @@ func (s *CheckScheduler) IsRunning() bool {
 	defer s.runningMutex.RUnlock()
 	return s.isRunning
 }
+
+// LastCompletedRun returns summary of the last run that checked all its phones
+func (s *CheckScheduler) LastCompletedRun() (services.RunSummary, bool) {
+	s.checkMutex.Lock()
+	defer s.checkMutex.Unlock()
+
+	if s.lastRun == nil {
+		return services.RunSummary{}, false
+	}
+	return *s.lastRun, true
+}
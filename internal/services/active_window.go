@@ -0,0 +1,155 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// activeWindowSettingKey is the setting holding scheduler working hours
+const activeWindowSettingKey = "check_active_window"
+
+// ActiveWindow represents working hours during which automatic checks run.
+// End before Start means the window spans midnight and belongs to the day it starts.
+type ActiveWindow struct {
+	Enabled  bool     `json:"enabled"`
+	Days     []string `json:"days"`  // mon, tue, wed, thu, fri, sat, sun
+	Start    string   `json:"start"` // HH:MM
+	End      string   `json:"end"`   // HH:MM
+	Timezone string   `json:"timezone"`
+}
+
+var activeWindowDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseClock parses HH:MM into minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks window fields
+func (w *ActiveWindow) Validate() error {
+	if !w.Enabled {
+		return nil
+	}
+
+	if len(w.Days) == 0 {
+		return errors.New("at least one day is required")
+	}
+	for _, day := range w.Days {
+		if _, ok := activeWindowDays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid day %q", day)
+		}
+	}
+
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errors.New("start and end must differ")
+	}
+
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", w.Timezone)
+	}
+
+	return nil
+}
+
+// Contains reports whether automatic checks may run at t. Disabled window always allows.
+func (w *ActiveWindow) Contains(t time.Time) bool {
+	if w == nil || !w.Enabled {
+		return true
+	}
+
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return true
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return true
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return true
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+
+	if start < end {
+		return w.hasDay(day) && minute >= start && minute < end
+	}
+
+	// Overnight window: evening part belongs to today, morning part to yesterday
+	if minute >= start {
+		return w.hasDay(day)
+	}
+	if minute < end {
+		return w.hasDay((day + 6) % 7)
+	}
+	return false
+}
+
+// hasDay reports whether window is active on weekday
+func (w *ActiveWindow) hasDay(day time.Weekday) bool {
+	for _, name := range w.Days {
+		if activeWindowDays[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseActiveWindow decodes and validates window setting value
+func parseActiveWindow(value string) (*ActiveWindow, error) {
+	var window ActiveWindow
+	if err := json.Unmarshal([]byte(value), &window); err != nil {
+		return nil, fmt.Errorf("invalid active window: %w", err)
+	}
+	if err := window.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid active window: %w", err)
+	}
+	return &window, nil
+}
+
+// GetActiveWindow returns scheduler working hours from settings cache.
+// Missing or invalid setting means no restriction.
+func (s *SettingsService) GetActiveWindow() *ActiveWindow {
+	value, err := s.GetCachedSettingValue(activeWindowSettingKey)
+	if err != nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	window, err := parseActiveWindow(string(raw))
+	if err != nil {
+		s.log.Warnf("Ignoring %s setting: %v", activeWindowSettingKey, err)
+		return nil
+	}
+	return window
+}
@@ -7,11 +7,14 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"io"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"spam-checker/internal/config"
 	"spam-checker/internal/logger"
 	"spam-checker/internal/models"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,123 +24,60 @@ import (
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"gorm.io/gorm"
 )
 
 type ADBService struct {
 	db           *gorm.DB
-	dockerClient *client.Client
+	dockerClient dockerAPI
 	cfg          *config.Config
 	portManager  *PortManager
+	apkService   *APKService
+	batches      *dockerGatewayBatches
+	tuning       *CheckTuning
 	log          *logrus.Entry
 }
 
-// PortManager manages port allocation for containers
-type PortManager struct {
-	mu        sync.Mutex
-	usedPorts map[int]bool
-	baseVNC   int
-	baseADB1  int
-	baseADB2  int
+func NewADBService(db *gorm.DB, cfg *config.Config, dockerClient *DockerClient) *ADBService {
+	return NewADBServiceWithConfig(db, cfg, dockerClient)
 }
 
-func NewPortManager() *PortManager {
-	return &PortManager{
-		usedPorts: make(map[int]bool),
-		baseVNC:   6080,
-		baseADB1:  5554,
-		baseADB2:  5555,
+func NewADBServiceWithConfig(db *gorm.DB, cfg *config.Config, dockerClient *DockerClient) *ADBService {
+	service := &ADBService{
+		db:          db,
+		cfg:         cfg,
+		portManager: sharedPortManager(db, cfg),
+		apkService:  NewAPKService(db, cfg),
+		batches:     newDockerGatewayBatches(),
+		tuning:      NewCheckTuning(db, cfg),
+		log:         logger.WithField("service", "ADBService"),
 	}
-}
-
-func (pm *PortManager) AllocatePorts(gatewayID uint) (vncPort, adbPort1, adbPort2 int, err error) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	// Calculate port offset based on gateway ID
-	offset := int(gatewayID)
 
-	// Find available ports
-	for i := 0; i < 100; i++ {
-		vncPort = pm.baseVNC + offset + i
-		adbPort1 = pm.baseADB1 + (offset+i)*2
-		adbPort2 = pm.baseADB2 + (offset+i)*2
-
-		if !pm.usedPorts[vncPort] && !pm.usedPorts[adbPort1] && !pm.usedPorts[adbPort2] {
-			pm.usedPorts[vncPort] = true
-			pm.usedPorts[adbPort1] = true
-			pm.usedPorts[adbPort2] = true
-			return vncPort, adbPort1, adbPort2, nil
-		}
+	// Keep interface nil when no shared client is provided
+	if dockerClient != nil {
+		service.dockerClient = dockerClient
 	}
 
-	return 0, 0, 0, fmt.Errorf("no available ports found")
+	return service
 }
 
-func (pm *PortManager) ReleasePorts(vncPort, adbPort1, adbPort2 int) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	delete(pm.usedPorts, vncPort)
-	delete(pm.usedPorts, adbPort1)
-	delete(pm.usedPorts, adbPort2)
-}
-
-func NewADBService(db *gorm.DB, cfg *config.Config) *ADBService {
-	return NewADBServiceWithConfig(db, cfg)
+// WithContext returns service copy whose log entries carry trace ID from context
+func (s *ADBService) WithContext(ctx context.Context) *ADBService {
+	clone := *s
+	clone.log = logger.EntryWithContext(s.log, ctx)
+	return &clone
 }
 
-func NewADBServiceWithConfig(db *gorm.DB, cfg *config.Config) *ADBService {
-	initLog := logger.WithFields(logrus.Fields{
-		"service": "ADBService",
-		"method":  "NewADBServiceWithConfig",
-	})
-
-	// Initialize Docker client
-	dockerHost := "unix:///var/run/docker.sock"
-	if cfg != nil && cfg.Docker.Host != "" {
-		dockerHost = fmt.Sprintf("tcp://%s:%s", cfg.Docker.Host, cfg.Docker.Port)
-	}
-
-	dockerClient, err := client.NewClientWithOpts(
-		client.WithHost(dockerHost),
-		client.WithAPIVersionNegotiation(),
-	)
+// CreateGateway creates a new ADB gateway
+func (s *ADBService) CreateGateway(gateway *models.ADBGateway) error {
+	dockerHost, err := s.normalizeDockerHost(gateway.DockerHost)
 	if err != nil {
-		initLog.Errorf("Failed to create Docker client: %v", err)
-	}
-
-	// Initialize port manager and load used ports from existing gateways
-	portManager := NewPortManager()
-
-	// Load existing gateways to mark used ports
-	var gateways []models.ADBGateway
-	if err := db.Find(&gateways).Error; err == nil {
-		for _, gw := range gateways {
-			if gw.VNCPort > 0 {
-				portManager.usedPorts[gw.VNCPort] = true
-			}
-			if gw.ADBPort1 > 0 {
-				portManager.usedPorts[gw.ADBPort1] = true
-			}
-			if gw.ADBPort2 > 0 {
-				portManager.usedPorts[gw.ADBPort2] = true
-			}
-		}
-	}
-
-	return &ADBService{
-		db:           db,
-		dockerClient: dockerClient,
-		cfg:          cfg,
-		portManager:  portManager,
-		log:          logger.WithField("service", "ADBService"),
+		return err
 	}
-}
+	gateway.DockerHost = dockerHost
 
-// CreateGateway creates a new ADB gateway
-func (s *ADBService) CreateGateway(gateway *models.ADBGateway) error {
 	if err := s.db.Create(gateway).Error; err != nil {
 		return fmt.Errorf("failed to create gateway: %w", err)
 	}
@@ -148,23 +88,52 @@ func (s *ADBService) CreateGateway(gateway *models.ADBGateway) error {
 	return nil
 }
 
-// CreateDockerGateway creates a new Docker-based ADB gateway
-func (s *ADBService) CreateDockerGateway(gateway *models.ADBGateway, apkData []byte) error {
-	log := s.log.WithFields(logrus.Fields{
-		"method": "CreateDockerGateway",
-	})
-
+// CreateDockerGateway creates a new Docker-based ADB gateway.
+// Uploaded apkData wins over library APK apkID; with neither, service's default APK is installed.
+func (s *ADBService) CreateDockerGateway(gateway *models.ADBGateway, apkData []byte, apkID *uint) error {
 	if s.dockerClient == nil {
 		return fmt.Errorf("Docker client is not initialized")
 	}
 
+	var libraryAPK *models.APKFile
+	if len(apkData) == 0 {
+		var err error
+		libraryAPK, err = s.resolveGatewayAPK(gateway.ServiceCode, apkID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.reserveDockerGateway(gateway); err != nil {
+		return err
+	}
+
+	return s.startDockerGateway(gateway, apkData, libraryAPK)
+}
+
+// resolveGatewayAPK returns library APK to install on new gateway: the referenced one or service default
+func (s *ADBService) resolveGatewayAPK(serviceCode string, apkID *uint) (*models.APKFile, error) {
+	if apkID != nil {
+		return s.libraryAPKFor(*apkID, serviceCode)
+	}
+	return s.apkService.GetDefaultAPK(serviceCode)
+}
+
+// reserveDockerGateway saves gateway and allocates its ports on its Docker host
+func (s *ADBService) reserveDockerGateway(gateway *models.ADBGateway) error {
+	dockerHost, err := s.normalizeDockerHost(gateway.DockerHost)
+	if err != nil {
+		return err
+	}
+	gateway.DockerHost = dockerHost
+
 	// Save gateway first to get ID
 	if err := s.db.Create(gateway).Error; err != nil {
 		return fmt.Errorf("failed to create gateway: %w", err)
 	}
 
 	// Allocate ports using the new gateway ID
-	vncPort, adbPort1, adbPort2, err := s.portManager.AllocatePorts(gateway.ID)
+	vncPort, adbPort1, adbPort2, err := s.portManager.AllocatePorts(gateway.DockerHost, gateway.ID)
 	if err != nil {
 		s.db.Delete(gateway)
 		return fmt.Errorf("failed to allocate ports: %w", err)
@@ -174,19 +143,39 @@ func (s *ADBService) CreateDockerGateway(gateway *models.ADBGateway, apkData []b
 	gateway.ADBPort1 = adbPort1
 	gateway.ADBPort2 = adbPort2
 	gateway.Host = s.cfg.Docker.Host
+	if gateway.DockerHost != "" {
+		gateway.Host = dockerHostname(gateway.DockerHost)
+	}
 	gateway.Port = adbPort1
 	gateway.IsDocker = true
 
 	// Update gateway with port information
 	if err := s.db.Save(gateway).Error; err != nil {
-		s.portManager.ReleasePorts(vncPort, adbPort1, adbPort2)
+		s.portManager.ReleasePorts(gateway.DockerHost, vncPort, adbPort1, adbPort2)
 		s.db.Delete(gateway)
 		return fmt.Errorf("failed to update gateway: %w", err)
 	}
 
+	return nil
+}
+
+// releaseDockerGateway undoes reserveDockerGateway
+func (s *ADBService) releaseDockerGateway(gateway *models.ADBGateway) {
+	s.portManager.ReleasePorts(gateway.DockerHost, gateway.VNCPort, gateway.ADBPort1, gateway.ADBPort2)
+	s.db.Delete(gateway)
+}
+
+// startDockerGateway creates and starts container of reserved gateway, then sets it up in background
+func (s *ADBService) startDockerGateway(gateway *models.ADBGateway, apkData []byte, libraryAPK *models.APKFile) error {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "startDockerGateway",
+	})
+
+	vncPort, adbPort1, adbPort2 := gateway.VNCPort, gateway.ADBPort1, gateway.ADBPort2
+
 	// Create container
 	containerName := fmt.Sprintf("spam_checker_android_%s", strings.ToLower(strings.ReplaceAll(gateway.Name, " ", "_")))
-	volumeName := fmt.Sprintf("android_%s_data", strings.ToLower(strings.ReplaceAll(gateway.Name, " ", "_")))
+	volumeName := dockerGatewayVolumeName(gateway.Name)
 
 	// Container configuration
 	config := &container.Config{
@@ -233,30 +222,31 @@ func (s *ADBService) CreateDockerGateway(gateway *models.ADBGateway, apkData []b
 	// Network configuration
 	networkConfig := &network.NetworkingConfig{}
 
-	// Create container
+	// Create container on Docker host of the gateway
+	docker := s.dockerFor(gateway)
 	ctx := context.Background()
-	resp, err := s.dockerClient.ContainerCreate(ctx, config, hostConfig, networkConfig, nil, containerName)
+	resp, err := docker.ContainerCreate(ctx, config, hostConfig, networkConfig, nil, containerName)
 	if err != nil {
 		s.db.Delete(gateway)
-		s.portManager.ReleasePorts(vncPort, adbPort1, adbPort2)
-		return fmt.Errorf("failed to create container: %w", err)
+		s.portManager.ReleasePorts(gateway.DockerHost, vncPort, adbPort1, adbPort2)
+		return fmt.Errorf("failed to create container: %w", s.portManager.portConflictError(gateway, err))
 	}
 
 	// Update gateway with container ID
 	gateway.DeviceID = containerName
 	gateway.ContainerID = resp.ID
 	if err := s.db.Save(gateway).Error; err != nil {
-		s.dockerClient.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
-		s.portManager.ReleasePorts(vncPort, adbPort1, adbPort2)
+		docker.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+		s.portManager.ReleasePorts(gateway.DockerHost, vncPort, adbPort1, adbPort2)
 		return fmt.Errorf("failed to update gateway: %w", err)
 	}
 
 	// Start container
-	if err := s.dockerClient.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		s.dockerClient.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+	if err := docker.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		docker.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
 		s.db.Delete(gateway)
-		s.portManager.ReleasePorts(vncPort, adbPort1, adbPort2)
-		return fmt.Errorf("failed to start container: %w", err)
+		s.portManager.ReleasePorts(gateway.DockerHost, vncPort, adbPort1, adbPort2)
+		return fmt.Errorf("failed to start container: %w", s.portManager.portConflictError(gateway, err))
 	}
 
 	log.Infof("Created Docker container %s for gateway %s", containerName, gateway.Name)
@@ -266,7 +256,7 @@ func (s *ADBService) CreateDockerGateway(gateway *models.ADBGateway, apkData []b
 		log.Infof("Starting setup process for gateway ID: %d", gwID)
 
 		// Initial wait for container to start
-		time.Sleep(30 * time.Second)
+		time.Sleep(s.tuning.ContainerStartupWait())
 
 		// Update gateway status first
 		if err := s.UpdateGatewayStatus(gwID); err != nil {
@@ -282,10 +272,8 @@ func (s *ADBService) CreateDockerGateway(gateway *models.ADBGateway, apkData []b
 			return
 		}
 
-		containerName := s.getContainerName(gateway)
-
 		// Quick check if ADB is available
-		output, err := s.executeInContainer(containerName, []string{"adb", "devices"})
+		output, err := s.executeInContainer(gateway, []string{"adb", "devices"})
 		if err == nil && strings.Contains(output, "device") {
 			log.Info("ADB is available, proceeding with setup")
 		} else {
@@ -308,6 +296,11 @@ func (s *ADBService) CreateDockerGateway(gateway *models.ADBGateway, apkData []b
 			if err := s.installAPKFromData(gwID, apkData); err != nil {
 				log.Errorf("Failed to install APK for gateway ID %d: %v", gwID, err)
 			}
+		} else if libraryAPK != nil {
+			log.Infof("Installing library APK %s (%s) for gateway ID: %d", libraryAPK.PackageName, libraryAPK.VersionName, gwID)
+			if err := s.InstallAPK(gwID, s.apkService.FilePath(libraryAPK)); err != nil {
+				log.Errorf("Failed to install APK for gateway ID %d: %v", gwID, err)
+			}
 		}
 
 		// Final status update
@@ -337,7 +330,7 @@ func (s *ADBService) waitForEmulatorReady(gatewayID uint) error {
 	for i := 0; i < maxAttempts; i++ {
 		// First check if container is running
 		ctx := context.Background()
-		containerInfo, err := s.dockerClient.ContainerInspect(ctx, gateway.ContainerID)
+		containerInfo, err := s.dockerFor(gateway).ContainerInspect(ctx, gateway.ContainerID)
 		if err != nil {
 			log.Errorf("Failed to inspect container: %v", err)
 			time.Sleep(5 * time.Second)
@@ -351,7 +344,7 @@ func (s *ADBService) waitForEmulatorReady(gatewayID uint) error {
 		}
 
 		// Check if ADB is responding
-		output, err := s.executeInContainer(containerName, []string{"adb", "devices"})
+		output, err := s.executeInContainer(gateway, []string{"adb", "devices"})
 		if err != nil {
 			log.Debugf("ADB not ready yet (attempt %d/%d): %v", i+1, maxAttempts, err)
 			time.Sleep(5 * time.Second)
@@ -364,14 +357,14 @@ func (s *ADBService) waitForEmulatorReady(gatewayID uint) error {
 		if strings.Contains(output, "emulator") || strings.Contains(output, "device") {
 			// Sometimes the emulator doesn't show as "emulator" but as a generic device
 			// Check if boot is completed
-			bootOutput, err := s.executeInContainer(containerName, []string{"adb", "shell", "getprop", "sys.boot_completed"})
+			bootOutput, err := s.executeInContainer(gateway, []string{"adb", "shell", "getprop", "sys.boot_completed"})
 			if err != nil {
 				log.Debugf("Failed to check boot_completed (attempt %d/%d): %v", i+1, maxAttempts, err)
 			} else {
 				log.Debugf("boot_completed: %s", strings.TrimSpace(bootOutput))
 				if strings.TrimSpace(bootOutput) == "1" {
 					// Additional check for package manager
-					pmOutput, err := s.executeInContainer(containerName, []string{"adb", "shell", "pm", "list", "packages", "-3"})
+					pmOutput, err := s.executeInContainer(gateway, []string{"adb", "shell", "pm", "list", "packages", "-3"})
 					if err != nil {
 						log.Debugf("Package manager not ready (attempt %d/%d): %v", i+1, maxAttempts, err)
 					} else if pmOutput != "" {
@@ -379,7 +372,7 @@ func (s *ADBService) waitForEmulatorReady(gatewayID uint) error {
 						return nil
 					} else {
 						// Even if no third-party packages, check for system packages
-						pmOutput, err = s.executeInContainer(containerName, []string{"adb", "shell", "pm", "list", "packages", "android"})
+						pmOutput, err = s.executeInContainer(gateway, []string{"adb", "shell", "pm", "list", "packages", "android"})
 						if err == nil && strings.Contains(pmOutput, "package:") {
 							log.Info("Android emulator is ready (system packages found)!")
 							return nil
@@ -411,10 +404,8 @@ func (s *ADBService) configureAndroidSystem(gatewayID uint) error {
 		return fmt.Errorf("failed to get gateway: %w", err)
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Check if ADB is available before trying to configure
-	output, err := s.executeInContainer(containerName, []string{"adb", "devices"})
+	output, err := s.executeInContainer(gateway, []string{"adb", "devices"})
 	if err != nil || !strings.Contains(output, "device") {
 		log.Warnf("ADB not ready, skipping Android configuration")
 		return fmt.Errorf("ADB not ready")
@@ -434,7 +425,7 @@ func (s *ADBService) configureAndroidSystem(gatewayID uint) error {
 	successCount := 0
 	for _, cmd := range commands {
 		fullCmd := append([]string{"adb", "shell"}, strings.Fields(cmd)...)
-		if _, err := s.executeInContainer(containerName, fullCmd); err != nil {
+		if _, err := s.executeInContainer(gateway, fullCmd); err != nil {
 			log.Warnf("Failed to execute command '%s': %v", cmd, err)
 		} else {
 			successCount++
@@ -443,7 +434,7 @@ func (s *ADBService) configureAndroidSystem(gatewayID uint) error {
 
 	if successCount > 0 {
 		// Some commands succeeded, try to restart system UI
-		s.executeInContainer(containerName, []string{"adb", "shell", "am", "restart"})
+		s.executeInContainer(gateway, []string{"adb", "shell", "am", "restart"})
 		log.Infof("Android system configured with %d/%d successful commands", successCount, len(commands))
 		return nil
 	}
@@ -451,6 +442,33 @@ func (s *ADBService) configureAndroidSystem(gatewayID uint) error {
 	return fmt.Errorf("all configuration commands failed")
 }
 
+// libraryAPKFor returns library APK after checking it was built for service
+func (s *ADBService) libraryAPKFor(apkID uint, serviceCode string) (*models.APKFile, error) {
+	apk, err := s.apkService.GetAPK(apkID)
+	if err != nil {
+		return nil, err
+	}
+	if apk.ServiceCode != serviceCode {
+		return nil, fmt.Errorf("APK %d belongs to service %s, not %s", apk.ID, apk.ServiceCode, serviceCode)
+	}
+	return apk, nil
+}
+
+// InstallLibraryAPK installs APK from library on gateway
+func (s *ADBService) InstallLibraryAPK(gatewayID, apkID uint) error {
+	gateway, err := s.GetGatewayByID(gatewayID)
+	if err != nil {
+		return err
+	}
+
+	apk, err := s.libraryAPKFor(apkID, gateway.ServiceCode)
+	if err != nil {
+		return err
+	}
+
+	return s.InstallAPK(gatewayID, s.apkService.FilePath(apk))
+}
+
 // installAPKFromData installs APK from byte data
 func (s *ADBService) installAPKFromData(gatewayID uint, apkData []byte) error {
 	log := s.log.WithFields(logrus.Fields{
@@ -475,38 +493,6 @@ func (s *ADBService) installAPKFromData(gatewayID uint, apkData []byte) error {
 	return s.InstallAPK(gatewayID, tempFile.Name())
 }
 
-// DeleteDockerGateway deletes a Docker-based gateway and its container
-func (s *ADBService) DeleteDockerGateway(gateway *models.ADBGateway) error {
-	log := s.log.WithFields(logrus.Fields{
-		"method": "DeleteDockerGateway",
-	})
-
-	if !gateway.IsDocker || gateway.ContainerID == "" {
-		return nil
-	}
-
-	ctx := context.Background()
-
-	// Stop container
-	if err := s.dockerClient.ContainerStop(ctx, gateway.ContainerID, container.StopOptions{}); err != nil {
-		log.Warnf("Failed to stop container: %v", err)
-	}
-
-	// Remove container
-	if err := s.dockerClient.ContainerRemove(ctx, gateway.ContainerID, container.RemoveOptions{
-		Force:         true,
-		RemoveVolumes: true,
-	}); err != nil {
-		log.Warnf("Failed to remove container: %v", err)
-	}
-
-	// Release ports
-	s.portManager.ReleasePorts(gateway.VNCPort, gateway.ADBPort1, gateway.ADBPort2)
-
-	log.Infof("Deleted Docker container for gateway %s", gateway.Name)
-	return nil
-}
-
 // GetGatewayByID gets gateway by ID
 func (s *ADBService) GetGatewayByID(id uint) (*models.ADBGateway, error) {
 	var gateway models.ADBGateway
@@ -518,11 +504,18 @@ func (s *ADBService) GetGatewayByID(id uint) (*models.ADBGateway, error) {
 
 // ListGateways lists all gateways
 func (s *ADBService) ListGateways() ([]models.ADBGateway, error) {
+	gateways, _, err := s.ListGatewaysPage(0, 0)
+	return gateways, err
+}
+
+// ListGatewaysPage lists gateways ordered by ID with total count, zero limit lists all
+func (s *ADBService) ListGatewaysPage(offset, limit int) ([]models.ADBGateway, int64, error) {
 	var gateways []models.ADBGateway
-	if err := s.db.Find(&gateways).Error; err != nil {
-		return nil, fmt.Errorf("failed to list gateways: %w", err)
+	total, err := findPage(s.db.Order("id"), offset, limit, &gateways)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list gateways: %w", err)
 	}
-	return gateways, nil
+	return gateways, total, nil
 }
 
 // GetActiveGateways gets all active gateways
@@ -536,6 +529,10 @@ func (s *ADBService) GetActiveGateways() ([]models.ADBGateway, error) {
 
 // UpdateGateway updates gateway information
 func (s *ADBService) UpdateGateway(id uint, updates map[string]interface{}) error {
+	if interval, ok := updates["min_call_interval_seconds"].(int); ok && (interval < 0 || interval > maxMinCallIntervalSeconds) {
+		return fmt.Errorf("min call interval must be between 0 and %d seconds", maxMinCallIntervalSeconds)
+	}
+
 	if err := s.db.Model(&models.ADBGateway{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 		return fmt.Errorf("failed to update gateway: %w", err)
 	}
@@ -546,88 +543,109 @@ func (s *ADBService) UpdateGateway(id uint, updates map[string]interface{}) erro
 	return nil
 }
 
-// DeleteGateway deletes a gateway
-func (s *ADBService) DeleteGateway(id uint) error {
-	log := s.log.WithFields(logrus.Fields{
-		"method": "DeleteGateway",
-	})
-
+// DeleteGateway deletes gateway with its Docker container, data volume and ports. With purge,
+// screenshots of results checked on the gateway are removed as well. Cleanup failures are listed
+// in the report and don't stop the deletion.
+func (s *ADBService) DeleteGateway(id uint, purge bool) (*GatewayCleanup, error) {
 	gateway, err := s.GetGatewayByID(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Delete Docker container if it's a Docker gateway
+	report := &GatewayCleanup{GatewayID: gateway.ID}
 	if gateway.IsDocker {
-		if err := s.DeleteDockerGateway(gateway); err != nil {
-			log.Errorf("Failed to delete Docker container: %v", err)
-		}
+		s.DeleteDockerGateway(gateway, report)
+	}
+	if purge {
+		s.purgeGatewayScreenshots(gateway.ID, report)
 	}
 
+	if err := s.db.Where("gateway_id = ?", id).Delete(&models.GatewayEvent{}).Error; err != nil {
+		return report, fmt.Errorf("failed to delete gateway events: %w", err)
+	}
 	if err := s.db.Delete(&models.ADBGateway{}, id).Error; err != nil {
-		return fmt.Errorf("failed to delete gateway: %w", err)
+		return report, fmt.Errorf("failed to delete gateway: %w", err)
 	}
-	return nil
+
+	if len(report.Failures) > 0 {
+		s.log.WithField("method", "DeleteGateway").Warnf("Gateway %s deleted, cleanup needs attention: %s",
+			gateway.Name, strings.Join(report.Failures, "; "))
+	}
+	return report, nil
+}
+
+// GatewayStatusSummary represents the result of a bulk gateway status refresh
+type GatewayStatusSummary struct {
+	Total      int           `json:"total"`
+	Online     int           `json:"online"`
+	Degraded   int           `json:"degraded"`
+	Offline    int           `json:"offline"`
+	Errored    int           `json:"errored"`
+	Errors     []string      `json:"errors,omitempty"`
+	Duration   time.Duration `json:"-"`
+	DurationMs int64         `json:"duration_ms"`
 }
 
 // UpdateGatewayStatus checks and updates gateway status
 func (s *ADBService) UpdateGatewayStatus(gatewayID uint) error {
-	log := s.log.WithFields(logrus.Fields{
-		"method": "UpdateGatewayStatus",
-	})
-
 	gateway, err := s.GetGatewayByID(gatewayID)
 	if err != nil {
 		return err
 	}
 
+	_, err = s.updateGatewayStatusWithContext(context.Background(), gateway)
+	return err
+}
+
+// updateGatewayStatusWithContext probes gateway container and stores resulting status
+func (s *ADBService) updateGatewayStatusWithContext(ctx context.Context, gateway *models.ADBGateway) (string, error) {
+	log := s.log.WithFields(logrus.Fields{
+		"method":    "UpdateGatewayStatus",
+		"gatewayID": gateway.ID,
+	})
+
 	status := "offline"
 	containerName := s.getContainerName(gateway)
 
 	// Check if Docker client is available
 	if s.dockerClient == nil {
 		log.Error("Docker client is not initialized")
-		return fmt.Errorf("Docker client is not initialized")
+		return "", fmt.Errorf("Docker client is not initialized")
 	}
 
-	// Check if container is running
-	ctx := context.Background()
-
+	// Docker gateways are looked up by container ID, manual ones by name
+	containerRef := containerName
 	if gateway.IsDocker && gateway.ContainerID != "" {
-		// Check container by ID for Docker gateways
-		containerInfo, err := s.dockerClient.ContainerInspect(ctx, gateway.ContainerID)
-		if err == nil && containerInfo.State.Running {
-			// Test ADB connection
-			output, err := s.executeInContainer(containerName, []string{"adb", "devices"})
-			if err == nil && strings.Contains(output, "emulator") && strings.Contains(output, "device") {
-				status = "online"
-			}
-		}
-	} else {
-		// Check by name for manual gateways
-		containers, err := s.dockerClient.ContainerList(ctx, container.ListOptions{})
-		if err != nil {
-			log.Errorf("Failed to list containers: %v", err)
-			return err
+		containerRef = gateway.ContainerID
+	}
+
+	containerInfo, err := s.dockerFor(gateway).ContainerInspect(ctx, containerRef)
+	if err != nil && !client.IsErrNotFound(err) {
+		return "", fmt.Errorf("failed to inspect container %s: %w", containerRef, err)
+	}
+
+	// Skip ADB probe if container is missing or not running
+	if err == nil && containerInfo.State != nil && containerInfo.State.Running {
+		output, err := s.executeInContainerWithContext(ctx, gateway, []string{"adb", "devices"})
+		if err == nil && strings.Contains(output, "emulator") && strings.Contains(output, "device") {
+			status = "online"
 		}
+	}
 
-		for _, cont := range containers {
-			for _, name := range cont.Names {
-				// Container names in Docker have leading slash
-				if strings.TrimPrefix(name, "/") == containerName {
-					if cont.State == "running" {
-						// Test ADB connection inside container
-						output, err := s.executeInContainer(containerName, []string{"adb", "devices"})
-						if err == nil && strings.Contains(output, "emulator") && strings.Contains(output, "device") {
-							status = "online"
-						}
-					}
-					break
-				}
+	// Device is up, but the caller-ID app may not be ready yet
+	if status == "online" {
+		if appPackage, enabled := s.readinessProbeFor(gateway.ServiceCode); enabled {
+			if err := s.probeAppReadiness(ctx, gateway, appPackage); err != nil {
+				log.Warnf("Readiness probe failed: %v", err)
+				status = "degraded"
 			}
 		}
 	}
 
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("gateway status check timed out: %w", ctx.Err())
+	}
+
 	// Update status
 	now := time.Now()
 	updates := map[string]interface{}{
@@ -637,34 +655,183 @@ func (s *ADBService) UpdateGatewayStatus(gatewayID uint) error {
 	}
 
 	if err := s.db.Model(gateway).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to update gateway status: %w", err)
+		return "", fmt.Errorf("failed to update gateway status: %w", err)
 	}
 
 	log.Infof("Gateway %s (%s) status updated: %s", gateway.Name, containerName, status)
 
-	return nil
+	return status, nil
 }
 
-// UpdateAllGatewayStatuses updates status for all gateways
-func (s *ADBService) UpdateAllGatewayStatuses() error {
+// GatewayStatusRefreshInterval is the period of the scheduled gateway status refresh
+const GatewayStatusRefreshInterval = 5 * time.Minute
+
+// gatewayStatusJitterShare is the part of the refresh interval probe offsets are spread over,
+// so a pass finishes well before the next one starts
+const gatewayStatusJitterShare = 4
+
+// UpdateAllGatewayStatuses updates status for all gateways concurrently.
+// When applyJitter is set each probe starts at a random offset from the start of the pass
+// so that periodic refreshes don't hit Docker API all at once.
+func (s *ADBService) UpdateAllGatewayStatuses(applyJitter bool) (*GatewayStatusSummary, error) {
 	log := s.log.WithFields(logrus.Fields{
 		"method": "UpdateAllGatewayStatuses",
 	})
 
+	startTime := time.Now()
+
 	gateways, err := s.ListGateways()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, gateway := range gateways {
-		if err := s.UpdateGatewayStatus(gateway.ID); err != nil {
-			log.Errorf("Failed to update gateway %s status: %v", gateway.Name, err)
+	parallelism, timeout, maxJitter := s.getStatusRefreshSettings()
+	if !applyJitter {
+		maxJitter = 0
+	}
+
+	summary := &GatewayStatusSummary{
+		Total: len(gateways),
+	}
+	var summaryMu sync.Mutex
+
+	// Offsets are drawn once per gateway and measured from the start of the pass, so waits
+	// of a worker do not add up however many gateways it probes
+	window := min(maxJitter, GatewayStatusRefreshInterval/gatewayStatusJitterShare)
+	probes := make([]gatewayStatusProbe, len(gateways))
+	for i, gateway := range gateways {
+		probes[i] = gatewayStatusProbe{gateway: gateway, at: startTime}
+		if window > 0 {
+			probes[i].at = startTime.Add(time.Duration(rand.Int63n(int64(window))))
 		}
 	}
+	sort.Slice(probes, func(i, j int) bool { return probes[i].at.Before(probes[j].at) })
+
+	workChan := make(chan gatewayStatusProbe, len(probes))
+	for _, probe := range probes {
+		workChan <- probe
+	}
+	close(workChan)
+
+	if parallelism > len(gateways) {
+		parallelism = len(gateways)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for probe := range workChan {
+				gateway := probe.gateway
+				if wait := time.Until(probe.at); wait > 0 {
+					time.Sleep(wait)
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				status, err := s.updateGatewayStatusWithContext(ctx, &gateway)
+				cancel()
+
+				summaryMu.Lock()
+				switch {
+				case err != nil:
+					summary.Errored++
+					summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", gateway.Name, err))
+					log.Errorf("Failed to update gateway %s status: %v", gateway.Name, err)
+				case status == "online":
+					summary.Online++
+				case status == "degraded":
+					summary.Degraded++
+				default:
+					summary.Offline++
+				}
+				summaryMu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	summary.Duration = time.Since(startTime)
+	summary.DurationMs = summary.Duration.Milliseconds()
+
+	return summary, nil
+}
+
+// readinessProbeFor returns app package to probe and whether the probe is enabled for a service
+func (s *ADBService) readinessProbeFor(serviceCode string) (string, bool) {
+	var service models.SpamService
+	if err := s.db.Where("code = ?", serviceCode).First(&service).Error; err != nil {
+		return "", false
+	}
+
+	cfg := storedServiceConfig(&service)
+	if !cfg.App.ReadinessProbe {
+		return "", false
+	}
+
+	appPackage, _ := cfg.appInfo(serviceCode)
+	return appPackage, true
+}
+
+// probeAppReadiness verifies that app package is installed and has a launchable activity
+func (s *ADBService) probeAppReadiness(ctx context.Context, gateway *models.ADBGateway, appPackage string) error {
+	if appPackage == "" {
+		return fmt.Errorf("app package is not configured")
+	}
+
+	output, err := s.executeInContainerWithContext(ctx, gateway, []string{"adb", "shell", "pm", "path", appPackage})
+	if err != nil {
+		return fmt.Errorf("failed to check package %s: %w", appPackage, err)
+	}
+	if !strings.Contains(output, "package:") {
+		return fmt.Errorf("package %s is not installed", appPackage)
+	}
+
+	output, err = s.executeInContainerWithContext(ctx, gateway, []string{"adb", "shell", "cmd", "package", "resolve-activity", "--brief", appPackage})
+	if err != nil {
+		return fmt.Errorf("failed to resolve launch activity of %s: %w", appPackage, err)
+	}
+	if !strings.Contains(output, appPackage+"/") {
+		return fmt.Errorf("package %s has no launchable activity", appPackage)
+	}
 
 	return nil
 }
 
+// gatewayStatusProbe is a gateway probed by status refresh no earlier than at
+type gatewayStatusProbe struct {
+	gateway models.ADBGateway
+	at      time.Time
+}
+
+// getStatusRefreshSettings returns parallelism, per-gateway timeout and max jitter for status refresh
+func (s *ADBService) getStatusRefreshSettings() (int, time.Duration, time.Duration) {
+	parallelism := 4
+	timeoutSeconds := 30
+	jitterSeconds := 60
+
+	settingsService := NewSettingsService(s.db)
+	if value, err := settingsService.GetSettingValue("gateway_status_parallelism"); err == nil {
+		if val, ok := value.(int); ok && val > 0 {
+			parallelism = val
+		}
+	}
+	if value, err := settingsService.GetSettingValue("gateway_status_timeout_seconds"); err == nil {
+		if val, ok := value.(int); ok && val > 0 {
+			timeoutSeconds = val
+		}
+	}
+	if value, err := settingsService.GetSettingValue("gateway_status_jitter_seconds"); err == nil {
+		if val, ok := value.(int); ok && val >= 0 {
+			jitterSeconds = val
+		}
+	}
+
+	return parallelism, time.Duration(timeoutSeconds) * time.Second, time.Duration(jitterSeconds) * time.Second
+}
+
 // ExecuteCommand executes ADB command on gateway
 func (s *ADBService) ExecuteCommand(gatewayID uint, command string) (string, error) {
 	gateway, err := s.GetGatewayByID(gatewayID)
@@ -672,10 +839,8 @@ func (s *ADBService) ExecuteCommand(gatewayID uint, command string) (string, err
 		return "", err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Check if container and ADB are ready directly instead of relying on DB status
-	output, err := s.executeInContainer(containerName, []string{"adb", "devices"})
+	output, err := s.executeInContainer(gateway, []string{"adb", "devices"})
 	if err != nil || !strings.Contains(output, "device") {
 		return "", fmt.Errorf("ADB is not ready on gateway %s", gateway.Name)
 	}
@@ -684,7 +849,7 @@ func (s *ADBService) ExecuteCommand(gatewayID uint, command string) (string, err
 	fullCommand := []string{"adb", "shell"}
 	fullCommand = append(fullCommand, strings.Fields(command)...)
 
-	return s.executeInContainer(containerName, fullCommand)
+	return s.executeInContainer(gateway, fullCommand)
 }
 
 // GetDeviceInfo gets device information
@@ -705,10 +870,8 @@ func (s *ADBService) GetDeviceInfo(gatewayID uint) (map[string]string, error) {
 		info["gateway_type"] = "manual"
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Get device state
-	output, err := s.executeInContainer(containerName, []string{"adb", "get-state"})
+	output, err := s.executeInContainer(gateway, []string{"adb", "get-state"})
 	if err == nil {
 		info["state"] = strings.TrimSpace(output)
 	}
@@ -726,14 +889,14 @@ func (s *ADBService) GetDeviceInfo(gatewayID uint) (map[string]string, error) {
 	}
 
 	for key, prop := range props {
-		output, err = s.executeInContainer(containerName, []string{"adb", "shell", "getprop", prop})
+		output, err = s.executeInContainer(gateway, []string{"adb", "shell", "getprop", prop})
 		if err == nil {
 			info[key] = strings.TrimSpace(output)
 		}
 	}
 
 	// Get battery info
-	output, err = s.executeInContainer(containerName, []string{"adb", "shell", "dumpsys", "battery"})
+	output, err = s.executeInContainer(gateway, []string{"adb", "shell", "dumpsys", "battery"})
 	if err == nil {
 		lines := strings.Split(output, "\n")
 		for _, line := range lines {
@@ -750,7 +913,7 @@ func (s *ADBService) GetDeviceInfo(gatewayID uint) (map[string]string, error) {
 	}
 
 	// Get screen resolution
-	output, err = s.executeInContainer(containerName, []string{"adb", "shell", "wm", "size"})
+	output, err = s.executeInContainer(gateway, []string{"adb", "shell", "wm", "size"})
 	if err == nil {
 		if idx := strings.Index(output, "Physical size:"); idx != -1 {
 			size := strings.TrimSpace(output[idx+14:])
@@ -764,6 +927,131 @@ func (s *ADBService) GetDeviceInfo(gatewayID uint) (map[string]string, error) {
 	return info, nil
 }
 
+// Gateway log sources
+const (
+	GatewayLogSourceLogcat = "logcat"
+	GatewayLogSourceDocker = "docker"
+	GatewayLogSourceAll    = "all"
+)
+
+// Gateway log limits
+const (
+	DefaultGatewayLogLines = 500
+	MaxGatewayLogLines     = 5000
+	maxGatewayLogBytes     = 1 << 20 // Per source
+)
+
+// GetGatewayLogs returns tail of device logcat and/or container logs as text
+func (s *ADBService) GetGatewayLogs(gatewayID uint, lines int, source string) (string, error) {
+	log := s.log.WithFields(logrus.Fields{
+		"method":    "GetGatewayLogs",
+		"gatewayID": gatewayID,
+	})
+
+	if s.dockerClient == nil {
+		return "", fmt.Errorf("Docker client is not initialized")
+	}
+
+	if lines <= 0 {
+		lines = DefaultGatewayLogLines
+	}
+	if lines > MaxGatewayLogLines {
+		lines = MaxGatewayLogLines
+	}
+
+	switch source {
+	case "":
+		source = GatewayLogSourceAll
+	case GatewayLogSourceLogcat, GatewayLogSourceDocker, GatewayLogSourceAll:
+	default:
+		return "", fmt.Errorf("invalid log source: %s", source)
+	}
+
+	gateway, err := s.GetGatewayByID(gatewayID)
+	if err != nil {
+		return "", err
+	}
+
+	containerName := s.getContainerName(gateway)
+	containerRef := containerName
+	if gateway.IsDocker && gateway.ContainerID != "" {
+		containerRef = gateway.ContainerID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var b strings.Builder
+
+	if source == GatewayLogSourceDocker || source == GatewayLogSourceAll {
+		b.WriteString(fmt.Sprintf("===== docker logs %s (last %d lines) =====\n", containerRef, lines))
+		output, err := s.getContainerLogs(ctx, s.dockerFor(gateway), containerRef, lines)
+		if err != nil {
+			log.Warnf("Failed to get container logs: %v", err)
+			b.WriteString(fmt.Sprintf("error: %v\n", err))
+		} else {
+			b.WriteString(output)
+		}
+	}
+
+	if source == GatewayLogSourceLogcat || source == GatewayLogSourceAll {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("===== adb logcat (last %d lines) =====\n", lines))
+		// -t implies -d, so logcat dumps recent lines and exits
+		output, err := s.executeInContainerWithContext(ctx, gateway, []string{"adb", "logcat", "-d", "-t", strconv.Itoa(lines)})
+		if err != nil {
+			log.Warnf("Failed to get logcat: %v", err)
+			b.WriteString(fmt.Sprintf("error: %v\n", err))
+		}
+		b.WriteString(truncateLog(output, maxGatewayLogBytes))
+	}
+
+	return b.String(), nil
+}
+
+// getContainerLogs reads bounded tail of container stdout/stderr
+func (s *ADBService) getContainerLogs(ctx context.Context, docker dockerAPI, containerRef string, lines int) (string, error) {
+	info, err := docker.ContainerInspect(ctx, containerRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	reader, err := docker.ContainerLogs(ctx, containerRef, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get container logs: %w", err)
+	}
+	defer reader.Close()
+
+	limited := io.LimitReader(reader, maxGatewayLogBytes+1)
+	output := new(bytes.Buffer)
+
+	// Without TTY stdout and stderr are multiplexed into a single stream
+	if info.Config != nil && info.Config.Tty {
+		_, err = io.Copy(output, limited)
+	} else {
+		_, err = stdcopy.StdCopy(output, output, limited)
+	}
+	if err != nil && output.Len() == 0 {
+		return "", fmt.Errorf("failed to read container logs: %w", err)
+	}
+
+	return truncateLog(output.String(), maxGatewayLogBytes), nil
+}
+
+// truncateLog keeps the last maxBytes of log output
+func truncateLog(output string, maxBytes int) string {
+	if len(output) <= maxBytes {
+		return output
+	}
+	return "... (truncated)\n" + output[len(output)-maxBytes:]
+}
+
 // RestartDevice restarts Android device
 func (s *ADBService) RestartDevice(gatewayID uint) error {
 	gateway, err := s.GetGatewayByID(gatewayID)
@@ -771,10 +1059,8 @@ func (s *ADBService) RestartDevice(gatewayID uint) error {
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Reboot device
-	_, err = s.executeInContainer(containerName, []string{"adb", "reboot"})
+	_, err = s.executeInContainer(gateway, []string{"adb", "reboot"})
 	if err != nil {
 		return fmt.Errorf("failed to restart device: %w", err)
 	}
@@ -809,7 +1095,7 @@ func (s *ADBService) InstallAPK(gatewayID uint, apkPath string) error {
 	containerName := s.getContainerName(gateway)
 
 	// Check if ADB is ready
-	output, err := s.executeInContainer(containerName, []string{"adb", "devices"})
+	output, err := s.executeInContainer(gateway, []string{"adb", "devices"})
 	if err != nil || !strings.Contains(output, "device") {
 		return fmt.Errorf("ADB is not ready on gateway %s", gateway.Name)
 	}
@@ -852,13 +1138,13 @@ func (s *ADBService) InstallAPK(gatewayID uint, apkPath string) error {
 
 	// Copy to container
 	ctx := context.Background()
-	err = s.dockerClient.CopyToContainer(ctx, containerName, "/tmp/", &buf, container.CopyToContainerOptions{})
+	err = s.dockerFor(gateway).CopyToContainer(ctx, containerName, "/tmp/", &buf, container.CopyToContainerOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to copy APK to container: %w", err)
 	}
 
 	// Install APK
-	output, err = s.executeInContainer(containerName, []string{"adb", "install", "-r", "/tmp/app.apk"})
+	output, err = s.executeInContainer(gateway, []string{"adb", "install", "-r", "/tmp/app.apk"})
 	if err != nil {
 		return fmt.Errorf("failed to install APK: %w, output: %s", err, output)
 	}
@@ -868,7 +1154,7 @@ func (s *ADBService) InstallAPK(gatewayID uint, apkPath string) error {
 	}
 
 	// Clean up
-	s.executeInContainer(containerName, []string{"rm", "/tmp/app.apk"})
+	s.executeInContainer(gateway, []string{"rm", "/tmp/app.apk"})
 
 	log.Infof("APK installed successfully on gateway %s", gateway.Name)
 
@@ -885,20 +1171,20 @@ func (s *ADBService) TakeScreenshot(gatewayID uint) ([]byte, error) {
 	containerName := s.getContainerName(gateway)
 
 	// Take screenshot inside container and save to file
-	_, err = s.executeInContainer(containerName, []string{"adb", "shell", "screencap", "-p", "/sdcard/screenshot.png"})
+	_, err = s.executeInContainer(gateway, []string{"adb", "shell", "screencap", "-p", "/sdcard/screenshot.png"})
 	if err != nil {
 		return nil, fmt.Errorf("failed to take screenshot: %w", err)
 	}
 
 	// Pull screenshot from device to container filesystem
-	_, err = s.executeInContainer(containerName, []string{"adb", "pull", "/sdcard/screenshot.png", "/tmp/screenshot.png"})
+	_, err = s.executeInContainer(gateway, []string{"adb", "pull", "/sdcard/screenshot.png", "/tmp/screenshot.png"})
 	if err != nil {
 		return nil, fmt.Errorf("failed to pull screenshot: %w", err)
 	}
 
 	// Read screenshot from container
 	ctx := context.Background()
-	reader, _, err := s.dockerClient.CopyFromContainer(ctx, containerName, "/tmp/screenshot.png")
+	reader, _, err := s.dockerFor(gateway).CopyFromContainer(ctx, containerName, "/tmp/screenshot.png")
 	if err != nil {
 		return nil, fmt.Errorf("failed to copy screenshot from container: %w", err)
 	}
@@ -923,8 +1209,8 @@ func (s *ADBService) TakeScreenshot(gatewayID uint) ([]byte, error) {
 			}
 
 			// Clean up
-			s.executeInContainer(containerName, []string{"rm", "/tmp/screenshot.png"})
-			s.executeInContainer(containerName, []string{"adb", "shell", "rm", "/sdcard/screenshot.png"})
+			s.executeInContainer(gateway, []string{"rm", "/tmp/screenshot.png"})
+			s.executeInContainer(gateway, []string{"adb", "shell", "rm", "/sdcard/screenshot.png"})
 
 			return data, nil
 		}
@@ -940,13 +1226,11 @@ func (s *ADBService) InputText(gatewayID uint, text string) error {
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Escape special characters for shell
 	text = strings.ReplaceAll(text, "'", "'\"'\"'")
 
 	// Input text
-	_, err = s.executeInContainer(containerName, []string{"adb", "shell", "input", "text", "'" + text + "'"})
+	_, err = s.executeInContainer(gateway, []string{"adb", "shell", "input", "text", "'" + text + "'"})
 	if err != nil {
 		return fmt.Errorf("failed to input text: %w", err)
 	}
@@ -961,10 +1245,8 @@ func (s *ADBService) SendKeyEvent(gatewayID uint, keyCode string) error {
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Send key event
-	_, err = s.executeInContainer(containerName, []string{"adb", "shell", "input", "keyevent", keyCode})
+	_, err = s.executeInContainer(gateway, []string{"adb", "shell", "input", "keyevent", keyCode})
 	if err != nil {
 		return fmt.Errorf("failed to send key event: %w", err)
 	}
@@ -979,10 +1261,8 @@ func (s *ADBService) StartApp(gatewayID uint, packageName, activityName string)
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Start app
-	output, err := s.executeInContainer(containerName, []string{"adb", "shell", "am", "start", "-n", packageName + "/" + activityName})
+	output, err := s.executeInContainer(gateway, []string{"adb", "shell", "am", "start", "-n", packageName + "/" + activityName})
 	if err != nil {
 		return fmt.Errorf("failed to start app: %w, output: %s", err, output)
 	}
@@ -1001,8 +1281,6 @@ func (s *ADBService) SimulateIncomingCall(gatewayID uint, phoneNumber string) er
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Normalize phone number for GSM emulator - only digits allowed
 	// Remove all non-digit characters
 	normalizedNumber := strings.Map(func(r rune) rune {
@@ -1013,7 +1291,7 @@ func (s *ADBService) SimulateIncomingCall(gatewayID uint, phoneNumber string) er
 	}, phoneNumber)
 
 	// Simulate incoming call using emulator console
-	output, err := s.executeInContainer(containerName, []string{"adb", "emu", "gsm", "call", normalizedNumber})
+	output, err := s.runConsoleCommand(gateway, []string{"adb", "emu", "gsm", "call", normalizedNumber})
 	if err != nil {
 		return fmt.Errorf("failed to simulate call: %w, output: %s", err, output)
 	}
@@ -1023,7 +1301,8 @@ func (s *ADBService) SimulateIncomingCall(gatewayID uint, phoneNumber string) er
 	return nil
 }
 
-// EndCall ends current call
+// EndCall ends current call and waits until the device reports idle call state,
+// escalating from graceful hang-up to force-stopping in-call packages
 func (s *ADBService) EndCall(gatewayID uint, phoneNumber string) error {
 	log := s.log.WithFields(logrus.Fields{
 		"method": "EndCall",
@@ -1034,32 +1313,17 @@ func (s *ADBService) EndCall(gatewayID uint, phoneNumber string) error {
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
-	// Try different methods to end call
-	// Method 1: Try to cancel via GSM emulator (without phone number)
-	output, err := s.executeInContainer(containerName, []string{"adb", "emu", "gsm", "cancel", phoneNumber})
+	teardown, err := s.endCall(gateway, phoneNumber)
 	if err != nil {
-		log.Warnf("Failed to cancel call via GSM emulator: %v", err)
-
-		// Method 2: Use key event as fallback
-		err = s.SendKeyEvent(gatewayID, "KEYCODE_ENDCALL")
-		if err != nil {
-			log.Warnf("Failed to end call via KEYCODE_ENDCALL: %v", err)
-
-			// Method 3: Try HOME key to dismiss call screen
-			err = s.SendKeyEvent(gatewayID, "KEYCODE_HOME")
-			if err != nil {
-				return fmt.Errorf("failed to end call using all methods")
-			}
-			log.Info("Dismissed call screen using HOME key")
-			return nil
-		}
-		log.Info("Ended call using KEYCODE_ENDCALL")
-		return nil
+		return fmt.Errorf("failed to end call on gateway %s: %w", gateway.Name, err)
 	}
 
-	log.Infof("Ended call on gateway %s: %s", gateway.Name, output)
+	log.WithFields(logrus.Fields{
+		"initial_state": teardown.InitialState,
+		"final_state":   teardown.FinalState,
+		"methods":       strings.Join(teardown.Methods, ","),
+		"forced":        teardown.Forced,
+	}).Infof("Ended call on gateway %s", gateway.Name)
 	return nil
 }
 
@@ -1087,10 +1351,8 @@ func (s *ADBService) ClearAppData(gatewayID uint, serviceCode string) error {
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Clear app data
-	output, err := s.executeInContainer(containerName, []string{"adb", "shell", "pm", "clear", appPackage})
+	output, err := s.executeInContainer(gateway, []string{"adb", "shell", "pm", "clear", appPackage})
 	if err != nil {
 		return fmt.Errorf("failed to clear app data: %w, output: %s", err, output)
 	}
@@ -1111,10 +1373,8 @@ func (s *ADBService) TapScreen(gatewayID uint, x, y int) error {
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Tap screen
-	_, err = s.executeInContainer(containerName, []string{"adb", "shell", "input", "tap", fmt.Sprintf("%d", x), fmt.Sprintf("%d", y)})
+	_, err = s.executeInContainer(gateway, []string{"adb", "shell", "input", "tap", fmt.Sprintf("%d", x), fmt.Sprintf("%d", y)})
 	if err != nil {
 		return fmt.Errorf("failed to tap screen: %w", err)
 	}
@@ -1129,10 +1389,8 @@ func (s *ADBService) SwipeScreen(gatewayID uint, x1, y1, x2, y2, duration int) e
 		return err
 	}
 
-	containerName := s.getContainerName(gateway)
-
 	// Swipe screen
-	_, err = s.executeInContainer(containerName, []string{"adb", "shell", "input", "swipe",
+	_, err = s.executeInContainer(gateway, []string{"adb", "shell", "input", "swipe",
 		fmt.Sprintf("%d", x1), fmt.Sprintf("%d", y1),
 		fmt.Sprintf("%d", x2), fmt.Sprintf("%d", y2),
 		fmt.Sprintf("%d", duration)})
@@ -1143,13 +1401,18 @@ func (s *ADBService) SwipeScreen(gatewayID uint, x1, y1, x2, y2, duration int) e
 	return nil
 }
 
-// executeInContainer executes command inside Docker container
-func (s *ADBService) executeInContainer(containerName string, cmd []string) (string, error) {
-	if s.dockerClient == nil {
+// executeInContainer executes command inside Docker container of gateway
+func (s *ADBService) executeInContainer(gateway *models.ADBGateway, cmd []string) (string, error) {
+	return s.executeInContainerWithContext(context.Background(), gateway, cmd)
+}
+
+// executeInContainerWithContext executes command inside Docker container of gateway, aborting when ctx is done
+func (s *ADBService) executeInContainerWithContext(ctx context.Context, gateway *models.ADBGateway, cmd []string) (string, error) {
+	docker := s.dockerFor(gateway)
+	if docker == nil {
 		return "", fmt.Errorf("Docker client is not initialized")
 	}
-
-	ctx := context.Background()
+	containerName := s.getContainerName(gateway)
 
 	// Create exec configuration
 	execConfig := container.ExecOptions{
@@ -1160,18 +1423,29 @@ func (s *ADBService) executeInContainer(containerName string, cmd []string) (str
 	}
 
 	// Create exec
-	execID, err := s.dockerClient.ContainerExecCreate(ctx, containerName, execConfig)
+	execID, err := docker.ContainerExecCreate(ctx, containerName, execConfig)
 	if err != nil {
 		return "", fmt.Errorf("failed to create exec: %w", err)
 	}
 
 	// Start exec
-	resp, err := s.dockerClient.ContainerExecAttach(ctx, execID.ID, container.ExecAttachOptions{})
+	resp, err := docker.ContainerExecAttach(ctx, execID.ID, container.ExecAttachOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to start exec: %w", err)
 	}
 	defer resp.Close()
 
+	// Close hijacked connection if context is cancelled while reading
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Close()
+		case <-done:
+		}
+	}()
+
 	// Read output
 	output := new(bytes.Buffer)
 	_, err = io.Copy(output, resp.Reader)
@@ -1180,7 +1454,7 @@ func (s *ADBService) executeInContainer(containerName string, cmd []string) (str
 	}
 
 	// Check exec result
-	execInspect, err := s.dockerClient.ContainerExecInspect(ctx, execID.ID)
+	execInspect, err := docker.ContainerExecInspect(ctx, execID.ID)
 	if err != nil {
 		return output.String(), fmt.Errorf("failed to inspect exec: %w", err)
 	}
@@ -1192,6 +1466,27 @@ func (s *ADBService) executeInContainer(containerName string, cmd []string) (str
 	return output.String(), nil
 }
 
+// dockerFor returns client of Docker host running gateway container, nil when Docker is not initialized
+func (s *ADBService) dockerFor(gateway *models.ADBGateway) dockerAPI {
+	shared, ok := s.dockerClient.(*DockerClient)
+	if !ok || gateway.DockerHost == "" {
+		return s.dockerClient
+	}
+	return shared.ForHost(gateway.DockerHost)
+}
+
+// normalizeDockerHost validates Docker host of a gateway, empty result means the default host
+func (s *ADBService) normalizeDockerHost(value string) (string, error) {
+	shared, ok := s.dockerClient.(*DockerClient)
+	if !ok {
+		if value != "" {
+			return "", fmt.Errorf("Docker client is not initialized")
+		}
+		return "", nil
+	}
+	return shared.NormalizeHost(value)
+}
+
 // getContainerName returns Docker container name for gateway
 func (s *ADBService) getContainerName(gateway *models.ADBGateway) string {
 	// For Docker gateways, use the stored device ID
@@ -1242,10 +1537,34 @@ func (s *ADBService) ListDockerContainers() ([]types.Container, error) {
 	return containers, nil
 }
 
-// Close closes Docker client connection
-func (s *ADBService) Close() error {
-	if s.dockerClient != nil {
-		return s.dockerClient.Close()
+// GatewayPorts returns host ports bound by Docker gateway container
+func (s *ADBService) GatewayPorts(gateway *models.ADBGateway) GatewayPorts {
+	return s.portManager.gatewayPorts(gateway)
+}
+
+// PortAssignments returns ports of Docker gateways across all Docker hosts
+func (s *ADBService) PortAssignments() (*PortAssignments, error) {
+	return s.portManager.Assignments()
+}
+
+// DockerStatus returns connection state of the shared Docker client and other Docker hosts
+func (s *ADBService) DockerStatus() DockerClientStatus {
+	shared, ok := s.dockerClient.(*DockerClient)
+	if !ok {
+		return DockerClientStatus{LastError: "Docker client is not initialized"}
 	}
-	return nil
+
+	// Report every host gateways run on, not only those used since start
+	var hosts []string
+	if err := s.db.Model(&models.ADBGateway{}).Where("docker_host <> ''").Distinct().Pluck("docker_host", &hosts).Error; err != nil {
+		s.log.Warnf("Failed to get gateway Docker hosts: %v", err)
+	}
+	for _, host := range hosts {
+		shared.ForHost(host)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return shared.StatusWithHosts(ctx)
 }
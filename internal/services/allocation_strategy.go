@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"spam-checker/internal/models"
+	"time"
+)
+
+// allocationStrategySettingKey is the setting choosing how Asterisk picks a number from the clean pool
+const allocationStrategySettingKey = "asterisk_allocation_strategy"
+
+// Number allocation strategies
+const (
+	// AllocationStrategyWeighted prefers numbers with fewer allocations and longer idle time, randomly
+	AllocationStrategyWeighted = "weighted"
+	// AllocationStrategyRoundRobin hands out numbers in turn, following the last allocated one
+	AllocationStrategyRoundRobin = "round_robin"
+	// AllocationStrategyLRU hands out the number idle for the longest time, never allocated ones first
+	AllocationStrategyLRU = "lru"
+	// AllocationStrategyRandom picks any clean number with equal probability
+	AllocationStrategyRandom = "random"
+)
+
+// validateAllocationStrategy checks allocation strategy setting value
+func validateAllocationStrategy(strategy string) error {
+	switch strategy {
+	case AllocationStrategyWeighted, AllocationStrategyRoundRobin, AllocationStrategyLRU, AllocationStrategyRandom:
+		return nil
+	}
+	return fmt.Errorf("%s must be one of %s, %s, %s, %s", allocationStrategySettingKey,
+		AllocationStrategyWeighted, AllocationStrategyRoundRobin, AllocationStrategyLRU, AllocationStrategyRandom)
+}
+
+// allocationStrategy selects a number from non-empty clean pool ordered by phone ID.
+// Strategies derive their state from allocation stats, so they survive restarts and work across replicas.
+type allocationStrategy interface {
+	Select(numbers []models.PhoneNumberUsageStats) *models.PhoneNumberUsageStats
+}
+
+// newAllocationStrategies returns every strategy keyed by setting value
+func newAllocationStrategies(rng *rand.Rand) map[string]allocationStrategy {
+	return map[string]allocationStrategy{
+		AllocationStrategyWeighted:   weightedStrategy{rng: rng},
+		AllocationStrategyRoundRobin: roundRobinStrategy{},
+		AllocationStrategyLRU:        lruStrategy{},
+		AllocationStrategyRandom:     randomStrategy{rng: rng},
+	}
+}
+
+// weightedStrategy selects a number using weighted random selection
+type weightedStrategy struct {
+	rng *rand.Rand
+}
+
+func (st weightedStrategy) Select(numbers []models.PhoneNumberUsageStats) *models.PhoneNumberUsageStats {
+	// If only one number, return it
+	if len(numbers) == 1 {
+		return &numbers[0]
+	}
+
+	// Calculate weights for each number
+	weights := make([]float64, len(numbers))
+	totalWeight := 0.0
+
+	// Find the maximum allocations to normalize weights
+	maxAllocations := int64(0)
+	for _, num := range numbers {
+		if num.TotalAllocations > maxAllocations {
+			maxAllocations = num.TotalAllocations
+		}
+	}
+
+	// Calculate weights (inverse of allocation count for load balancing)
+	for i, num := range numbers {
+		// Base weight - higher for numbers with fewer allocations
+		weight := 1.0
+		if maxAllocations > 0 {
+			// Normalize allocation count and invert (fewer allocations = higher weight)
+			normalizedAlloc := float64(num.TotalAllocations) / float64(maxAllocations+1)
+			weight = 1.0 - normalizedAlloc + 0.1 // Add 0.1 to ensure non-zero weight
+		}
+
+		// Boost weight for numbers not used today
+		if num.DailyAllocations == 0 {
+			weight *= 2.0
+		}
+
+		// Boost weight for numbers not used recently
+		if num.LastAllocatedAt == nil {
+			weight *= 3.0
+		} else {
+			hoursSinceLastUse := time.Since(*num.LastAllocatedAt).Hours()
+			if hoursSinceLastUse > 24 {
+				weight *= 2.0
+			} else if hoursSinceLastUse > 1 {
+				weight *= 1.5
+			}
+		}
+
+		weights[i] = weight
+		totalWeight += weight
+	}
+
+	// Weighted random selection
+	if totalWeight <= 0 {
+		// Fallback to random selection if all weights are zero
+		return &numbers[st.rng.Intn(len(numbers))]
+	}
+
+	randomValue := st.rng.Float64() * totalWeight
+	currentWeight := 0.0
+
+	for i, weight := range weights {
+		currentWeight += weight
+		if randomValue <= currentWeight {
+			return &numbers[i]
+		}
+	}
+
+	// Fallback (should not reach here)
+	return &numbers[len(numbers)-1]
+}
+
+// roundRobinStrategy continues after the most recently allocated number of the pool,
+// wrapping around to the lowest phone ID
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Select(numbers []models.PhoneNumberUsageStats) *models.PhoneNumberUsageStats {
+	last := -1
+	for i, num := range numbers {
+		if num.LastAllocatedAt == nil {
+			continue
+		}
+		if last < 0 || num.LastAllocatedAt.After(*numbers[last].LastAllocatedAt) {
+			last = i
+		}
+	}
+	return &numbers[(last+1)%len(numbers)]
+}
+
+// lruStrategy selects the least recently allocated number, lowest phone ID wins ties
+type lruStrategy struct{}
+
+func (lruStrategy) Select(numbers []models.PhoneNumberUsageStats) *models.PhoneNumberUsageStats {
+	selected := 0
+	for i, num := range numbers {
+		if num.LastAllocatedAt == nil {
+			return &numbers[i]
+		}
+		if num.LastAllocatedAt.Before(*numbers[selected].LastAllocatedAt) {
+			selected = i
+		}
+	}
+	return &numbers[selected]
+}
+
+// randomStrategy selects a number uniformly at random
+type randomStrategy struct {
+	rng *rand.Rand
+}
+
+func (st randomStrategy) Select(numbers []models.PhoneNumberUsageStats) *models.PhoneNumberUsageStats {
+	return &numbers[st.rng.Intn(len(numbers))]
+}
+
+// allocationStrategyName returns configured allocation strategy, weighted if unset or invalid
+func (s *AsteriskService) allocationStrategyName() string {
+	value, err := NewSettingsService(s.db).GetCachedSettingValue(allocationStrategySettingKey)
+	if err != nil {
+		return AllocationStrategyWeighted
+	}
+	strategy, _ := value.(string)
+	if validateAllocationStrategy(strategy) != nil {
+		s.log.Warnf("Ignoring invalid %s setting %q", allocationStrategySettingKey, strategy)
+		return AllocationStrategyWeighted
+	}
+	return strategy
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// apiCacheSweepInterval defines how often expired responses are dropped
+const apiCacheSweepInterval = time.Minute
+
+type apiCacheKey struct {
+	apiServiceID uint
+	number       string // Normalized phone number
+}
+
+type apiCacheEntry struct {
+	body      string
+	expiresAt time.Time
+}
+
+// APICacheStats represents response cache metrics of a single API service
+type APICacheStats struct {
+	APIServiceID uint    `json:"api_service_id"`
+	Hits         int64   `json:"hits"`
+	Misses       int64   `json:"misses"`
+	Entries      int     `json:"entries"`
+	HitRate      float64 `json:"hit_rate"`
+}
+
+// apiResponseCache keeps validated API responses per service and phone for service's cache TTL.
+// It is shared by all APICheckService instances so every check path benefits from it.
+type apiResponseCache struct {
+	mu        sync.Mutex
+	entries   map[apiCacheKey]apiCacheEntry
+	stats     map[uint]*APICacheStats
+	lastSweep time.Time
+}
+
+var sharedAPIResponseCache = &apiResponseCache{
+	entries: make(map[apiCacheKey]apiCacheEntry),
+	stats:   make(map[uint]*APICacheStats),
+}
+
+func (c *apiResponseCache) statsFor(apiServiceID uint) *APICacheStats {
+	stats, exists := c.stats[apiServiceID]
+	if !exists {
+		stats = &APICacheStats{APIServiceID: apiServiceID}
+		c.stats[apiServiceID] = stats
+	}
+	return stats
+}
+
+// Get returns cached response and records hit or miss
+func (c *apiResponseCache) Get(apiServiceID uint, number string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := apiCacheKey{apiServiceID: apiServiceID, number: number}
+	stats := c.statsFor(apiServiceID)
+
+	entry, exists := c.entries[key]
+	if exists && time.Now().Before(entry.expiresAt) {
+		stats.Hits++
+		return entry.body, true
+	}
+	if exists {
+		delete(c.entries, key)
+	}
+
+	stats.Misses++
+	return "", false
+}
+
+// Set stores response for ttl
+func (c *apiResponseCache) Set(apiServiceID uint, number, body string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastSweep) >= apiCacheSweepInterval {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	c.entries[apiCacheKey{apiServiceID: apiServiceID, number: number}] = apiCacheEntry{
+		body:      body,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// Invalidate drops cached responses of API service, e.g. after its configuration changed
+func (c *apiResponseCache) Invalidate(apiServiceID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if key.apiServiceID == apiServiceID {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Stats returns metrics of every API service that used the cache
+func (c *apiResponseCache) Stats() []APICacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entries := make(map[uint]int)
+	for key, entry := range c.entries {
+		if now.Before(entry.expiresAt) {
+			entries[key.apiServiceID]++
+		}
+	}
+
+	result := make([]APICacheStats, 0, len(c.stats))
+	for id, stats := range c.stats {
+		item := *stats
+		item.Entries = entries[id]
+		if total := item.Hits + item.Misses; total > 0 {
+			item.HitRate = float64(item.Hits) / float64(total) * 100
+		}
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].APIServiceID < result[j].APIServiceID
+	})
+	return result
+}
+
+// GetCacheStats returns response cache metrics per API service
+func (s *APICheckService) GetCacheStats() []APICacheStats {
+	return sharedAPIResponseCache.Stats()
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidAPIResponse is returned when provider answered with an error or unparsable body
+var ErrInvalidAPIResponse = errors.New("invalid API response")
+
+// APIProviderStats represents checks attributed to a concrete API service
+type APIProviderStats struct {
+	APIServiceID uint       `json:"api_service_id"`
+	Name         string     `json:"name"`
+	ServiceCode  string     `json:"service_code"`
+	TotalChecks  int64      `json:"total_checks"`
+	SpamCount    int64      `json:"spam_count"`
+	ErrorCount   int64      `json:"error_count"`
+	LastCheckAt  *time.Time `json:"last_check_at,omitempty"`
+}
+
+// validateAPIResponse rejects responses that must not be stored as a verdict,
+// so that failover can try the next provider
+func validateAPIResponse(apiService *models.APIService, statusCode int, body string) error {
+	if statusCode < 200 || statusCode >= 300 {
+		return fmt.Errorf("%w: HTTP %d", ErrInvalidAPIResponse, statusCode)
+	}
+
+	hasPathExtraction := apiService.ResponsePath != "" || apiService.KeywordPaths != ""
+	if hasPathExtraction && !gjson.Valid(body) {
+		return fmt.Errorf("%w: body is not valid JSON", ErrInvalidAPIResponse)
+	}
+
+	return nil
+}
+
+// groupAPIServices splits services sorted by service code into groups of the same code
+func groupAPIServices(services []models.APIService) [][]models.APIService {
+	var groups [][]models.APIService
+	index := make(map[string]int)
+	for _, service := range services {
+		i, exists := index[service.ServiceCode]
+		if !exists {
+			i = len(groups)
+			index[service.ServiceCode] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], service)
+	}
+	return groups
+}
+
+// GetAPIPolicies returns API policy of every spam service by code
+func (s *APICheckService) GetAPIPolicies() (map[string]string, error) {
+	var spamServices []models.SpamService
+	if err := s.db.Select("code", "api_policy", "config").Find(&spamServices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API policies: %w", err)
+	}
+
+	policies := make(map[string]string, len(spamServices))
+	for i := range spamServices {
+		cfg := storedServiceConfig(&spamServices[i])
+		policies[spamServices[i].Code] = cfg.apiPolicy()
+	}
+	return policies, nil
+}
+
+// annotateFailover fills effective policy and position of listed services. positions holds
+// active services per service code listed before services and is advanced in place.
+func (s *APICheckService) annotateFailover(services []models.APIService, positions map[string]int) error {
+	policies, err := s.GetAPIPolicies()
+	if err != nil {
+		return err
+	}
+
+	for i := range services {
+		service := &services[i]
+		service.FailoverPolicy = policies[service.ServiceCode]
+		if service.FailoverPolicy == "" {
+			service.FailoverPolicy = models.APIPolicyCallAll
+		}
+		if service.IsActive {
+			positions[service.ServiceCode]++
+			service.FailoverPosition = positions[service.ServiceCode]
+		}
+	}
+	return nil
+}
+
+// UpdateFailover sets API policy of a service code and, if order is given,
+// priorities of its API services. Services missing from order keep their
+// relative order after the listed ones.
+func (s *APICheckService) UpdateFailover(serviceCode string, policy string, order []uint) error {
+	if policy != "" && policy != models.APIPolicyCallAll && policy != models.APIPolicyFirstSuccess {
+		return fmt.Errorf("invalid policy: %s", policy)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var spamService models.SpamService
+		if err := tx.Where("code = ?", serviceCode).First(&spamService).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("spam service not found")
+			}
+			return fmt.Errorf("failed to get spam service: %w", err)
+		}
+
+		if policy != "" {
+			if err := updateServiceConfigInTx(tx, &spamService, func(cfg *ServiceConfig) {
+				cfg.APIPolicy = policy
+			}); err != nil {
+				return err
+			}
+		}
+
+		if len(order) == 0 {
+			return nil
+		}
+
+		var apiServices []models.APIService
+		if err := tx.Where("service_code = ?", serviceCode).Order("priority, id").Find(&apiServices).Error; err != nil {
+			return fmt.Errorf("failed to get API services: %w", err)
+		}
+
+		byID := make(map[uint]bool, len(apiServices))
+		for _, api := range apiServices {
+			byID[api.ID] = true
+		}
+
+		ordered := make([]uint, 0, len(apiServices))
+		listed := make(map[uint]bool, len(order))
+		for _, id := range order {
+			if !byID[id] {
+				return fmt.Errorf("API service %d does not belong to service %s", id, serviceCode)
+			}
+			if listed[id] {
+				continue
+			}
+			listed[id] = true
+			ordered = append(ordered, id)
+		}
+		for _, api := range apiServices {
+			if !listed[api.ID] {
+				ordered = append(ordered, api.ID)
+			}
+		}
+
+		for i, id := range ordered {
+			if err := tx.Model(&models.APIService{}).Where("id = ?", id).Update("priority", i+1).Error; err != nil {
+				return fmt.Errorf("failed to update API service priority: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetProviderStats returns checks attributed to each API service since given number of days
+func (s *APICheckService) GetProviderStats(days int) ([]APIProviderStats, error) {
+	var stats []APIProviderStats
+	if err := s.db.Table("api_services").
+		Select(`api_services.id as api_service_id,
+			api_services.name,
+			api_services.service_code,
+			COALESCE(SUM(CASE WHEN check_results.status <> 'error' THEN 1 ELSE 0 END), 0) as total_checks,
+			COALESCE(SUM(CASE WHEN check_results.is_spam THEN 1 ELSE 0 END), 0) as spam_count,
+			COALESCE(SUM(CASE WHEN check_results.status = 'error' THEN 1 ELSE 0 END), 0) as error_count,
+			MAX(check_results.checked_at) as last_check_at`).
+		Joins("LEFT JOIN check_results ON check_results.api_service_id = api_services.id AND check_results.checked_at >= ?",
+			time.Now().AddDate(0, 0, -days)).
+		Group("api_services.id, api_services.name, api_services.service_code, api_services.priority").
+		Order("api_services.service_code, api_services.priority, api_services.id").
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get provider statistics: %w", err)
+	}
+	return stats, nil
+}
@@ -1,7 +1,7 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -19,6 +19,7 @@ import (
 type APICheckService struct {
 	db  *gorm.DB
 	log *logrus.Entry
+	ctx context.Context // Set by WithContext, carries check timeline
 }
 
 func NewAPICheckService(db *gorm.DB) *APICheckService {
@@ -28,6 +29,14 @@ func NewAPICheckService(db *gorm.DB) *APICheckService {
 	}
 }
 
+// WithContext returns service copy whose log entries carry trace ID from context
+func (s *APICheckService) WithContext(ctx context.Context) *APICheckService {
+	clone := *s
+	clone.log = logger.EntryWithContext(s.log, ctx)
+	clone.ctx = ctx
+	return &clone
+}
+
 // CreateAPIService creates a new API service
 func (s *APICheckService) CreateAPIService(service *models.APIService) error {
 	// Validate headers JSON
@@ -38,6 +47,16 @@ func (s *APICheckService) CreateAPIService(service *models.APIService) error {
 		}
 	}
 
+	if err := validateAPIServiceType(service); err != nil {
+		return err
+	}
+	if err := validateAPIRequestTemplates(service); err != nil {
+		return err
+	}
+	if _, err := ParseDecisionRules(service.DecisionRules); err != nil {
+		return err
+	}
+
 	// For custom API services, ensure the spam service exists
 	if service.ServiceCode == "custom" || strings.HasPrefix(service.ServiceCode, "custom_") {
 		// Check if spam service exists, if not create it
@@ -74,19 +93,53 @@ func (s *APICheckService) GetAPIServiceByID(id uint) (*models.APIService, error)
 	return &service, nil
 }
 
-// ListAPIServices lists all API services
+// ListAPIServices lists all API services with effective failover ordering
 func (s *APICheckService) ListAPIServices() ([]models.APIService, error) {
+	services, _, err := s.ListAPIServicesPage(0, 0)
+	return services, err
+}
+
+// ListAPIServicesPage lists API services with effective failover ordering and total count, zero limit lists all
+func (s *APICheckService) ListAPIServicesPage(offset, limit int) ([]models.APIService, int64, error) {
 	var services []models.APIService
-	if err := s.db.Find(&services).Error; err != nil {
-		return nil, fmt.Errorf("failed to list API services: %w", err)
+	total, err := findPage(s.db.Order("service_code, priority, id"), offset, limit, &services)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list API services: %w", err)
 	}
-	return services, nil
+
+	// Failover positions continue from active services on previous pages
+	positions := make(map[string]int)
+	if limit > 0 && offset > 0 {
+		var before []struct {
+			ServiceCode string
+			Active      int
+		}
+		previous := s.db.Model(&models.APIService{}).
+			Select("service_code, is_active").
+			Order("service_code, priority, id").
+			Limit(offset)
+		if err := s.db.Table("(?) AS previous", previous).
+			Select("service_code, COUNT(*) AS active").
+			Where("is_active = ?", true).
+			Group("service_code").
+			Scan(&before).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to count API services on previous pages: %w", err)
+		}
+		for _, row := range before {
+			positions[row.ServiceCode] = row.Active
+		}
+	}
+
+	if err := s.annotateFailover(services, positions); err != nil {
+		return nil, 0, err
+	}
+	return services, total, nil
 }
 
-// GetActiveAPIServices gets all active API services
+// GetActiveAPIServices gets all active API services in failover order
 func (s *APICheckService) GetActiveAPIServices() ([]models.APIService, error) {
 	var services []models.APIService
-	if err := s.db.Where("is_active = ?", true).Find(&services).Error; err != nil {
+	if err := s.db.Where("is_active = ?", true).Order("service_code, priority, id").Find(&services).Error; err != nil {
 		return nil, fmt.Errorf("failed to get active API services: %w", err)
 	}
 	return services, nil
@@ -102,6 +155,46 @@ func (s *APICheckService) UpdateAPIService(id uint, updates map[string]interface
 		}
 	}
 
+	if rules, ok := updates["decision_rules"].(string); ok {
+		if _, err := ParseDecisionRules(rules); err != nil {
+			return err
+		}
+	}
+
+	// Type, bot config and templates are validated together with the stored ones
+	_, typeUpdated := updates["type"]
+	_, botConfigUpdated := updates["bot_config"]
+	_, urlUpdated := updates["api_url"]
+	_, bodyUpdated := updates["request_body"]
+	_, secretUpdated := updates["secret"]
+	if typeUpdated || botConfigUpdated || urlUpdated || bodyUpdated || secretUpdated {
+		current, err := s.GetAPIServiceByID(id)
+		if err != nil {
+			return err
+		}
+		if value, ok := updates["type"].(string); ok {
+			current.Type = value
+		}
+		if value, ok := updates["bot_config"].(string); ok {
+			current.BotConfig = value
+		}
+		if value, ok := updates["api_url"].(string); ok {
+			current.APIURL = value
+		}
+		if value, ok := updates["request_body"].(string); ok {
+			current.RequestBody = value
+		}
+		if value, ok := updates["secret"].(string); ok {
+			current.Secret = value
+		}
+		if err := validateAPIServiceType(current); err != nil {
+			return err
+		}
+		if err := validateAPIRequestTemplates(current); err != nil {
+			return err
+		}
+	}
+
 	// If service code is being updated, ensure spam service exists
 	if serviceCode, ok := updates["service_code"].(string); ok {
 		if serviceCode == "custom" || strings.HasPrefix(serviceCode, "custom_") {
@@ -133,6 +226,9 @@ func (s *APICheckService) UpdateAPIService(id uint, updates map[string]interface
 		return fmt.Errorf("failed to update API service: %w", err)
 	}
 
+	// Cached responses may not match new URL, body or headers
+	sharedAPIResponseCache.Invalidate(id)
+
 	return nil
 }
 
@@ -141,6 +237,7 @@ func (s *APICheckService) DeleteAPIService(id uint) error {
 	if err := s.db.Delete(&models.APIService{}, id).Error; err != nil {
 		return fmt.Errorf("failed to delete API service: %w", err)
 	}
+	sharedAPIResponseCache.Invalidate(id)
 	return nil
 }
 
@@ -176,38 +273,126 @@ func (s *APICheckService) CheckPhoneViaAPI(phone *models.PhoneNumber, apiService
 		return nil, fmt.Errorf("failed to get spam service: %w", err)
 	}
 
-	log.Infof("Checking %s via API service %s", phone.Number, apiService.Name)
+	var botConfig *TelegramBotConfig
+	if apiService.Type == models.APIServiceTypeTelegramBot {
+		if botConfig, err = parseTelegramBotConfig(apiService.BotConfig); err != nil {
+			return nil, err
+		}
+	}
 
-	// Replace placeholders in URL
-	url := s.replacePhonePlaceholder(apiService.APIURL, phone.Number)
+	log.Infof("Checking %s via API service %s", logger.FormatPhone(phone.Number), apiService.Name)
 
-	// Create request
-	var req *http.Request
-	var reqErr error
+	// Serve response from cache within service's TTL, errors are never cached
+	cacheKey := NewPhoneService(s.db).normalizePhoneNumber(phone.Number)
+	rawResponse, cached := "", false
+	if apiService.CacheTTL > 0 {
+		rawResponse, cached = sharedAPIResponseCache.Get(apiService.ID, cacheKey)
+	}
 
-	if apiService.Method == "POST" && apiService.RequestBody != "" {
-		// Replace placeholders in request body
-		body := s.replacePhonePlaceholder(apiService.RequestBody, phone.Number)
-		req, reqErr = http.NewRequest(apiService.Method, url, bytes.NewBuffer([]byte(body)))
-		if reqErr != nil {
-			return nil, fmt.Errorf("failed to create request: %w", reqErr)
-		}
-		req.Header.Set("Content-Type", "application/json")
+	timeline := checkTimelineFromContext(s.ctx)
+	if cached {
+		log.Debugf("Using cached API response for %s", logger.FormatPhone(phone.Number))
+		timeline.record(CheckEventAPIResponse, "cached response, %d bytes", len(rawResponse))
 	} else {
-		req, reqErr = http.NewRequest(apiService.Method, url, nil)
-		if reqErr != nil {
-			return nil, fmt.Errorf("failed to create request: %w", reqErr)
+		var err error
+		if botConfig != nil {
+			rawResponse, err = s.fetchBotReply(apiService, botConfig, phone.Number)
+		} else {
+			rawResponse, err = s.fetchAPIResponse(apiService, phone.Number)
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("API response for %s: %s", logger.FormatPhone(phone.Number), rawResponse)
+		timeline.record(CheckEventAPIResponse, "%d bytes", len(rawResponse))
+
+		if apiService.CacheTTL > 0 {
+			sharedAPIResponseCache.Set(apiService.ID, cacheKey, rawResponse, time.Duration(apiService.CacheTTL)*time.Second)
 		}
 	}
 
-	// Add headers
-	if apiService.Headers != "" {
-		var headers map[string]string
-		if err := json.Unmarshal([]byte(apiService.Headers), &headers); err == nil {
-			for key, value := range headers {
-				req.Header.Set(key, value)
-			}
+	var extractedText string
+	var isSpam bool
+	var foundKeywords []string
+	var keywordMatches []models.KeywordMatch
+	confidence := 1.0
+	if botConfig != nil {
+		// Bot reply is plain text, parsing rules of the bot apply to all of it
+		extractedText = rawResponse
+		isSpam, foundKeywords, keywordMatches = s.analyzeBotReply(botConfig, rawResponse, service.ID)
+		if isSpam {
+			confidence = ConfidenceExtractedText
+		}
+	} else {
+		// Extract data using JSONPath if configured
+		if apiService.ResponsePath != "" {
+			extractedText = s.extractWithJSONPath(rawResponse, apiService.ResponsePath)
+			log.Debugf("Extracted text using path '%s': %s", apiService.ResponsePath, extractedText)
 		}
+
+		// Extract keywords using JSONPath if configured
+		var extractedKeywords []string
+		if apiService.KeywordPaths != "" {
+			extractedKeywords = s.extractKeywordsWithJSONPath(rawResponse, apiService.KeywordPaths)
+			log.Debugf("Extracted keywords using path '%s': %v", apiService.KeywordPaths, extractedKeywords)
+		}
+
+		// Analyze response for spam - pass whether we have path-based extraction
+		hasPathExtraction := apiService.ResponsePath != "" || apiService.KeywordPaths != ""
+		isSpam, foundKeywords, keywordMatches = s.analyzeAPIResponse(rawResponse, extractedText, extractedKeywords, service.ID, hasPathExtraction)
+		confidence = apiDetectionConfidence(extractedKeywords, foundKeywords, hasPathExtraction)
+	}
+
+	// Save result
+	result := &models.CheckResult{
+		PhoneNumberID:  phone.ID,
+		ServiceID:      service.ID,
+		IsSpam:         isSpam,
+		FoundKeywords:  models.StringArray(foundKeywords),
+		KeywordMatches: models.KeywordMatches(keywordMatches),
+		RawResponse:    rawResponse,
+		RawText:        extractedText, // Store extracted text in RawText field
+		Confidence:     confidence,
+		APIServiceID:   &apiService.ID,
+		CheckedAt:      time.Now(),
+	}
+
+	// Decision rules of the service override the keyword verdict
+	if rules, err := ParseDecisionRules(apiService.DecisionRules); err != nil {
+		result.DecisionSource = DecisionSourceKeywords
+		result.DecisionError = err.Error()
+	} else {
+		applyDecisionRules(result, rules, decisionEnv(rawResponse, extractedText, foundKeywords))
+	}
+	if result.DecisionError != "" {
+		log.Warnf("Decision rules of %s failed, keyword verdict kept: %s", apiService.Name, result.DecisionError)
+	}
+
+	// Statistics are updated with the result, as on the ADB path; a journaled result has no ID yet
+	persisted, err := persistCheckResult(s.ctx, s.db, result)
+	if err != nil {
+		return nil, err
+	}
+	if persisted == PersistJournaled {
+		log.Warnf("API check result for %s on %s journaled until the database is back", phone.Number, apiService.Name)
+	}
+
+	log.Infof("API check completed for %s on %s: isSpam=%v, keywords=%v",
+		phone.Number, apiService.Name, result.IsSpam, foundKeywords)
+
+	return result, nil
+}
+
+// fetchAPIResponse calls external API for phone number and returns validated response body
+func (s *APICheckService) fetchAPIResponse(apiService *models.APIService, number string) (string, error) {
+	// Render URL and body templates
+	rendered, err := renderAPIRequest(apiService, number, time.Now())
+	if err != nil {
+		return "", err
+	}
+	req, err := rendered.httpRequest()
+	if err != nil {
+		return "", err
 	}
 
 	// Set timeout
@@ -218,57 +403,22 @@ func (s *APICheckService) CheckPhoneViaAPI(phone *models.PhoneNumber, apiService
 	// Execute request
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("API request failed: %w", err)
+		return "", fmt.Errorf("API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Process response
 	rawResponse := string(body)
-	log.Debugf("API response for %s: %s", phone.Number, rawResponse)
-
-	// Extract data using JSONPath if configured
-	extractedText := ""
-	if apiService.ResponsePath != "" {
-		extractedText = s.extractWithJSONPath(rawResponse, apiService.ResponsePath)
-		log.Debugf("Extracted text using path '%s': %s", apiService.ResponsePath, extractedText)
-	}
-
-	// Extract keywords using JSONPath if configured
-	var extractedKeywords []string
-	if apiService.KeywordPaths != "" {
-		extractedKeywords = s.extractKeywordsWithJSONPath(rawResponse, apiService.KeywordPaths)
-		log.Debugf("Extracted keywords using path '%s': %v", apiService.KeywordPaths, extractedKeywords)
-	}
-
-	// Analyze response for spam - pass whether we have path-based extraction
-	hasPathExtraction := apiService.ResponsePath != "" || apiService.KeywordPaths != ""
-	isSpam, foundKeywords := s.analyzeAPIResponse(rawResponse, extractedText, extractedKeywords, service.ID, hasPathExtraction)
-
-	// Save result
-	result := &models.CheckResult{
-		PhoneNumberID: phone.ID,
-		ServiceID:     service.ID,
-		IsSpam:        isSpam,
-		FoundKeywords: models.StringArray(foundKeywords),
-		RawResponse:   rawResponse,
-		RawText:       extractedText, // Store extracted text in RawText field
-		CheckedAt:     time.Now(),
-	}
-
-	if err := s.db.Create(result).Error; err != nil {
-		return nil, fmt.Errorf("failed to save check result: %w", err)
+	if err := validateAPIResponse(apiService, resp.StatusCode, rawResponse); err != nil {
+		return "", err
 	}
 
-	log.Infof("API check completed for %s on %s: isSpam=%v, keywords=%v",
-		phone.Number, apiService.Name, isSpam, foundKeywords)
-
-	return result, nil
+	return rawResponse, nil
 }
 
 // extractWithJSONPath extracts data using JSONPath
@@ -372,17 +522,14 @@ func (s *APICheckService) convertToGJSONPath(jsonPath string) string {
 	return path
 }
 
-// analyzeAPIResponse analyzes API response for spam indicators
-func (s *APICheckService) analyzeAPIResponse(rawResponse string, extractedText string, extractedKeywords []string, serviceID uint, hasPathExtraction bool) (bool, []string) {
+// analyzeAPIResponse analyzes API response for spam indicators, returning found keywords along with where they occur
+func (s *APICheckService) analyzeAPIResponse(rawResponse string, extractedText string, extractedKeywords []string, serviceID uint, hasPathExtraction bool) (bool, []string, []models.KeywordMatch) {
 	log := s.log.WithFields(logrus.Fields{
 		"method":            "analyzeAPIResponse",
 		"serviceID":         serviceID,
 		"hasPathExtraction": hasPathExtraction,
 	})
 
-	var foundKeywords []string
-	foundKeywordsSet := make(map[string]bool) // To avoid duplicates
-
 	// Get spam keywords from database
 	var dbKeywords []models.SpamKeyword
 	query := s.db.Where("is_active = ?", true)
@@ -390,69 +537,78 @@ func (s *APICheckService) analyzeAPIResponse(rawResponse string, extractedText s
 
 	if err := query.Find(&dbKeywords).Error; err != nil {
 		log.Errorf("Failed to get spam keywords: %v", err)
-		return false, foundKeywords
+		return false, nil, nil
 	}
 
 	// Create keyword set for quick lookup
 	keywordSet := make(map[string]string) // lowercase -> original
+	negations := globalKeywordNegations(s.db)
+	keywordNegations := make(map[string][]string) // lowercase -> negation tokens
+	keywordWholeWord := make(map[string]bool)     // lowercase -> whole word only, unless a duplicate matches substrings
 	for _, kw := range dbKeywords {
-		keywordSet[strings.ToLower(kw.Keyword)] = kw.Keyword
+		lower := strings.ToLower(kw.Keyword)
+		wholeWord, seen := keywordWholeWord[lower]
+		keywordWholeWord[lower] = kw.WholeWord && (wholeWord || !seen)
+		keywordSet[lower] = kw.Keyword
+		keywordNegations[lower] = append(keywordNegations[lower], spamKeywordNegations(negations, kw)...)
 	}
 
-	// Helper function to add keyword without duplicates
-	addKeyword := func(keyword string) {
-		if !foundKeywordsSet[keyword] {
-			foundKeywordsSet[keyword] = true
-			foundKeywords = append(foundKeywords, keyword)
-		}
-	}
+	found := newFoundKeywords()
 
 	// Check extracted keywords against database keywords
 	for _, extractedKw := range extractedKeywords {
-		extractedLower := strings.ToLower(extractedKw)
+		extracted := newKeywordText(extractedKw, models.KeywordMatchKeywords)
 
 		// Direct match
-		if original, exists := keywordSet[extractedLower]; exists {
-			addKeyword(original)
+		if original, exists := keywordSet[extracted.lower]; exists {
+			found.add(original, []models.KeywordMatch{extracted.match(original, 0, len(extracted.runes))})
 		}
 
 		// Partial match - check if extracted keyword contains any database keywords
 		for dbKwLower, dbKwOriginal := range keywordSet {
-			if strings.Contains(extractedLower, dbKwLower) {
-				addKeyword(dbKwOriginal)
+			if dbKwLower == extracted.lower {
+				continue
+			}
+			if matches := extracted.find(dbKwOriginal, keywordNegations[dbKwLower], keywordWholeWord[dbKwLower]); len(matches) > 0 {
+				found.add(dbKwOriginal, matches)
 			}
 		}
 	}
 
 	// Search for keywords in the appropriate text based on extraction configuration
-	var searchText string
+	var searched *keywordText
 	if hasPathExtraction {
 		// If we have path extraction configured, search only in extracted text
-		searchText = strings.ToLower(extractedText)
+		searched = newKeywordText(extractedText, models.KeywordMatchText)
 	} else {
 		// If no path extraction, search in the entire raw response
-		searchText = strings.ToLower(rawResponse)
+		searched = newKeywordText(rawResponse, models.KeywordMatchResponse)
 	}
 
 	// Search for database keywords in the text
-	if searchText != "" {
+	if searched.lower != "" {
 		for dbKwLower, dbKwOriginal := range keywordSet {
-			if strings.Contains(searchText, dbKwLower) {
-				addKeyword(dbKwOriginal)
+			if matches := searched.find(dbKwOriginal, keywordNegations[dbKwLower], keywordWholeWord[dbKwLower]); len(matches) > 0 {
+				found.add(dbKwOriginal, matches)
 			}
 		}
 	}
 
 	// Determine if it's spam based on found keywords
-	isSpam := len(foundKeywords) > 0
+	isSpam := len(found.keywords) > 0
 
-	log.Debugf("Analysis complete: isSpam=%v, foundKeywords=%v", isSpam, foundKeywords)
+	log.Debugf("Analysis complete: isSpam=%v, foundKeywords=%v", isSpam, found.keywords)
 
-	return isSpam, foundKeywords
+	return isSpam, found.keywords, found.matches
 }
 
 // replacePhonePlaceholder replaces phone number placeholders in string
 func (s *APICheckService) replacePhonePlaceholder(str string, phoneNumber string) string {
+	return replacePhonePlaceholder(str, phoneNumber)
+}
+
+// replacePhonePlaceholder replaces digits, +digits and formatted Russian number placeholders in string
+func replacePhonePlaceholder(str string, phoneNumber string) string {
 	// Remove non-digits from phone number
 	digitsOnly := strings.Map(func(r rune) rune {
 		if r >= '0' && r <= '9' {
@@ -496,41 +652,33 @@ func (s *APICheckService) replacePhonePlaceholder(str string, phoneNumber string
 	return str
 }
 
-// TestAPIService tests an API service with a sample phone number
-func (s *APICheckService) TestAPIService(id uint, testPhone string) (map[string]interface{}, error) {
+// TestAPIService tests an API service with a sample phone number. Reply, if given, is parsed by a
+// telegram_bot service instead of querying the bot.
+func (s *APICheckService) TestAPIService(id uint, testPhone string, reply string) (map[string]interface{}, error) {
 	apiService, err := s.GetAPIServiceByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	if apiService.Type == models.APIServiceTypeTelegramBot {
+		var service models.SpamService
+		s.db.Where("code = ?", apiService.ServiceCode).First(&service)
+		return s.testTelegramBot(apiService, service.ID, testPhone, reply)
+	}
+
 	// Test the API
 	startTime := time.Now()
 
-	url := s.replacePhonePlaceholder(apiService.APIURL, testPhone)
-
-	var req *http.Request
-	if apiService.Method == "POST" && apiService.RequestBody != "" {
-		body := s.replacePhonePlaceholder(apiService.RequestBody, testPhone)
-		req, err = http.NewRequest(apiService.Method, url, bytes.NewBuffer([]byte(body)))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-		req.Header.Set("Content-Type", "application/json")
-	} else {
-		req, err = http.NewRequest(apiService.Method, url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
+	rendered, err := renderAPIRequest(apiService, testPhone, startTime)
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		}, nil
 	}
-
-	// Add headers
-	if apiService.Headers != "" {
-		var headers map[string]string
-		if err := json.Unmarshal([]byte(apiService.Headers), &headers); err == nil {
-			for key, value := range headers {
-				req.Header.Set(key, value)
-			}
-		}
+	req, err := rendered.httpRequest()
+	if err != nil {
+		return nil, err
 	}
 
 	client := &http.Client{
@@ -545,6 +693,7 @@ func (s *APICheckService) TestAPIService(id uint, testPhone string) (map[string]
 			"success":       false,
 			"error":         err.Error(),
 			"response_time": responseTime,
+			"request":       rendered,
 		}, nil
 	}
 	defer resp.Body.Close()
@@ -585,9 +734,10 @@ func (s *APICheckService) TestAPIService(id uint, testPhone string) (map[string]
 
 	// Analyze for spam - indicate we have path extraction if configured
 	hasPathExtraction := apiService.ResponsePath != "" || apiService.KeywordPaths != ""
-	isSpam, keywords := s.analyzeAPIResponse(responseStr, extractedText, extractedKeywords, service.ID, hasPathExtraction)
+	isSpam, keywords, matches := s.analyzeAPIResponse(responseStr, extractedText, extractedKeywords, service.ID, hasPathExtraction)
 
 	return map[string]interface{}{
+		"confidence":         apiDetectionConfidence(extractedKeywords, keywords, hasPathExtraction),
 		"success":            true,
 		"status_code":        resp.StatusCode,
 		"response_time":      responseTime,
@@ -596,6 +746,8 @@ func (s *APICheckService) TestAPIService(id uint, testPhone string) (map[string]
 		"extracted_keywords": extractedKeywords,
 		"is_spam":            isSpam,
 		"keywords":           keywords,
-		"url":                url,
+		"keyword_matches":    matches,
+		"url":                rendered.URL,
+		"request":            rendered,
 	}, nil
 }
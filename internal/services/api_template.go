@@ -0,0 +1,177 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"spam-checker/internal/models"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateSamplePhone is rendered into templates when they are validated
+const templateSamplePhone = "79001234567"
+
+// APITemplateData is available to APIURL and RequestBody templates of HTTP API services
+type APITemplateData struct {
+	Phone       string // Digits only, 79121234567
+	E164        string // +79121234567
+	CountryCode string // 7
+	National    string // 9121234567
+	Formatted   string // +7 (912) 123-45-67, digits for non-Russian numbers
+	Timestamp   int64  // Unix seconds, the same across URL and body of one request
+	TimestampMs int64  // Unix milliseconds
+	Secret      string // Per-service secret for signatures
+}
+
+// apiTemplateFuncs are the only functions available to templates besides text/template builtins.
+// None of them touches files, network or environment.
+var apiTemplateFuncs = template.FuncMap{
+	"md5":         func(s string) string { return hashHex(md5.New(), s) },
+	"sha1":        func(s string) string { return hashHex(sha1.New(), s) },
+	"sha256":      func(s string) string { return hashHex(sha256.New(), s) },
+	"hmac_md5":    func(key, s string) string { return hashHex(hmac.New(md5.New, []byte(key)), s) },
+	"hmac_sha1":   func(key, s string) string { return hashHex(hmac.New(sha1.New, []byte(key)), s) },
+	"hmac_sha256": func(key, s string) string { return hashHex(hmac.New(sha256.New, []byte(key)), s) },
+	"base64":      func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"upper":       strings.ToUpper,
+	"lower":       strings.ToLower,
+	"json":        jsonString,
+}
+
+func hashHex(h hash.Hash, s string) string {
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// jsonString quotes value for embedding into JSON body
+func jsonString(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// newAPITemplateData builds template data of phone number at the given moment
+func newAPITemplateData(phoneNumber, secret string, now time.Time) APITemplateData {
+	digits := onlyDigits(phoneNumber)
+	data := APITemplateData{
+		Phone:       digits,
+		E164:        "+" + digits,
+		National:    digits,
+		Formatted:   digits,
+		Timestamp:   now.Unix(),
+		TimestampMs: now.UnixMilli(),
+		Secret:      secret,
+	}
+	if len(digits) == 11 && digits[0] == '7' {
+		data.CountryCode = digits[:1]
+		data.National = digits[1:]
+		data.Formatted = fmt.Sprintf("+%s (%s) %s-%s-%s", digits[0:1], digits[1:4], digits[4:7], digits[7:9], digits[9:11])
+	}
+	return data
+}
+
+// renderAPITemplate replaces legacy phone placeholders, then renders the rest as a Go template.
+// Strings without template actions are returned as they are after placeholder replacement, so
+// {{phone}} style placeholders keep working without template data.
+func renderAPITemplate(text string, data APITemplateData) (string, error) {
+	text = replacePhonePlaceholder(text, data.Phone)
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("api").Funcs(apiTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return out.String(), nil
+}
+
+// validateAPIRequestTemplates renders URL and body of HTTP API service with a sample phone,
+// so template errors are reported when the service is saved rather than on every check
+func validateAPIRequestTemplates(service *models.APIService) error {
+	if service.Type != "" && service.Type != models.APIServiceTypeHTTP {
+		return nil
+	}
+
+	data := newAPITemplateData(templateSamplePhone, service.Secret, time.Now())
+	if _, err := renderAPITemplate(service.APIURL, data); err != nil {
+		return fmt.Errorf("api_url: %w", err)
+	}
+	if _, err := renderAPITemplate(service.RequestBody, data); err != nil {
+		return fmt.Errorf("request_body: %w", err)
+	}
+	return nil
+}
+
+// RenderedAPIRequest is HTTP request of an API service for a phone, as it is sent
+type RenderedAPIRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// renderAPIRequest renders URL and body templates of API service for phone number
+func renderAPIRequest(service *models.APIService, phoneNumber string, now time.Time) (*RenderedAPIRequest, error) {
+	data := newAPITemplateData(phoneNumber, service.Secret, now)
+
+	url, err := renderAPITemplate(service.APIURL, data)
+	if err != nil {
+		return nil, fmt.Errorf("api_url: %w", err)
+	}
+	rendered := &RenderedAPIRequest{Method: service.Method, URL: url}
+
+	if service.Method == "POST" && service.RequestBody != "" {
+		body, err := renderAPITemplate(service.RequestBody, data)
+		if err != nil {
+			return nil, fmt.Errorf("request_body: %w", err)
+		}
+		rendered.Body = body
+	}
+
+	if service.Headers != "" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(service.Headers), &headers); err == nil {
+			rendered.Headers = headers
+		}
+	}
+	return rendered, nil
+}
+
+// httpRequest creates HTTP request from rendered request
+func (r *RenderedAPIRequest) httpRequest() (*http.Request, error) {
+	var req *http.Request
+	var err error
+	if r.Body != "" {
+		req, err = http.NewRequest(r.Method, r.URL, strings.NewReader(r.Body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	} else {
+		req, err = http.NewRequest(r.Method, r.URL, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, value := range r.Headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// Binary XML chunk types used by compiled AndroidManifest.xml
+const (
+	axmlStringPoolType   = 0x0001
+	axmlResourceMapType  = 0x0180
+	axmlStartElementType = 0x0102
+
+	axmlUTF8Flag      = 1 << 8
+	axmlNoIndex       = 0xFFFFFFFF
+	axmlTypeString    = 0x03
+	axmlTypeIntDec    = 0x10
+	axmlTypeIntHex    = 0x11
+	axmlVersionCodeID = 0x0101021b
+	axmlVersionNameID = 0x0101021c
+)
+
+// apkManifest represents identity of an APK read from its manifest
+type apkManifest struct {
+	Package     string
+	VersionCode int64
+	VersionName string
+}
+
+// parseAPKManifest reads package name and version from APK file
+func parseAPKManifest(path string) (*apkManifest, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APK archive: %w", err)
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.Name != "AndroidManifest.xml" {
+			continue
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open manifest: %w", err)
+		}
+		defer src.Close()
+
+		data, err := io.ReadAll(io.LimitReader(src, 16<<20))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		return parseBinaryManifest(data)
+	}
+
+	return nil, errors.New("APK has no AndroidManifest.xml")
+}
+
+// parseBinaryManifest extracts attributes of the root <manifest> element from compiled XML
+func parseBinaryManifest(data []byte) (*apkManifest, error) {
+	le := binary.LittleEndian
+	if len(data) < 8 {
+		return nil, errors.New("manifest is too short")
+	}
+
+	var pool []string
+	var resourceIDs []uint32
+
+	offset := int(le.Uint16(data[2:4]))
+	for offset+8 <= len(data) {
+		chunkType := le.Uint16(data[offset:])
+		headerSize := int(le.Uint16(data[offset+2:]))
+		chunkSize := int(le.Uint32(data[offset+4:]))
+		if chunkSize < 8 || offset+chunkSize > len(data) {
+			return nil, errors.New("manifest chunk is out of bounds")
+		}
+		chunk := data[offset : offset+chunkSize]
+
+		switch chunkType {
+		case axmlStringPoolType:
+			parsed, err := parseAXMLStringPool(chunk, headerSize)
+			if err != nil {
+				return nil, err
+			}
+			pool = parsed
+
+		case axmlResourceMapType:
+			for i := headerSize; i+4 <= len(chunk); i += 4 {
+				resourceIDs = append(resourceIDs, le.Uint32(chunk[i:]))
+			}
+
+		case axmlStartElementType:
+			// First element of a manifest is always <manifest>
+			return parseAXMLManifestElement(chunk, headerSize, pool, resourceIDs)
+		}
+
+		offset += chunkSize
+	}
+
+	return nil, errors.New("manifest element not found")
+}
+
+// parseAXMLStringPool decodes all strings of a string pool chunk
+func parseAXMLStringPool(chunk []byte, headerSize int) ([]string, error) {
+	le := binary.LittleEndian
+	if len(chunk) < 28 {
+		return nil, errors.New("string pool is too short")
+	}
+
+	count := int(le.Uint32(chunk[8:]))
+	flags := le.Uint32(chunk[16:])
+	stringsStart := int(le.Uint32(chunk[20:]))
+	if headerSize+count*4 > len(chunk) {
+		return nil, errors.New("string pool is out of bounds")
+	}
+
+	pool := make([]string, count)
+	for i := 0; i < count; i++ {
+		pos := stringsStart + int(le.Uint32(chunk[headerSize+i*4:]))
+		if pos >= len(chunk) {
+			return nil, errors.New("string is out of bounds")
+		}
+
+		var err error
+		if flags&axmlUTF8Flag != 0 {
+			pool[i], err = decodeAXMLUTF8(chunk[pos:])
+		} else {
+			pool[i], err = decodeAXMLUTF16(chunk[pos:])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pool, nil
+}
+
+func decodeAXMLUTF8(data []byte) (string, error) {
+	// Character count is followed by byte count, each one or two bytes long
+	readLength := func(pos int) (int, int, error) {
+		if pos >= len(data) {
+			return 0, 0, errors.New("string is out of bounds")
+		}
+		if data[pos]&0x80 == 0 {
+			return int(data[pos]), pos + 1, nil
+		}
+		if pos+1 >= len(data) {
+			return 0, 0, errors.New("string is out of bounds")
+		}
+		return int(data[pos]&0x7F)<<8 | int(data[pos+1]), pos + 2, nil
+	}
+
+	_, pos, err := readLength(0)
+	if err != nil {
+		return "", err
+	}
+	length, pos, err := readLength(pos)
+	if err != nil {
+		return "", err
+	}
+	if pos+length > len(data) {
+		return "", errors.New("string is out of bounds")
+	}
+	return string(data[pos : pos+length]), nil
+}
+
+func decodeAXMLUTF16(data []byte) (string, error) {
+	le := binary.LittleEndian
+	if len(data) < 2 {
+		return "", errors.New("string is out of bounds")
+	}
+
+	pos := 2
+	length := int(le.Uint16(data))
+	if length&0x8000 != 0 {
+		if len(data) < 4 {
+			return "", errors.New("string is out of bounds")
+		}
+		length = (length&0x7FFF)<<16 | int(le.Uint16(data[2:]))
+		pos = 4
+	}
+	if pos+length*2 > len(data) {
+		return "", errors.New("string is out of bounds")
+	}
+
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = le.Uint16(data[pos+i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// parseAXMLManifestElement reads package and version attributes of start element chunk
+func parseAXMLManifestElement(chunk []byte, headerSize int, pool []string, resourceIDs []uint32) (*apkManifest, error) {
+	le := binary.LittleEndian
+	ext := headerSize
+	if ext+20 > len(chunk) {
+		return nil, errors.New("manifest element is too short")
+	}
+
+	attrStart := int(le.Uint16(chunk[ext+8:]))
+	attrSize := int(le.Uint16(chunk[ext+10:]))
+	attrCount := int(le.Uint16(chunk[ext+12:]))
+	if attrSize < 20 || ext+attrStart+attrCount*attrSize > len(chunk) {
+		return nil, errors.New("manifest attributes are out of bounds")
+	}
+
+	str := func(index uint32) string {
+		if index == axmlNoIndex || int(index) >= len(pool) {
+			return ""
+		}
+		return pool[index]
+	}
+
+	manifest := &apkManifest{}
+	for i := 0; i < attrCount; i++ {
+		attr := chunk[ext+attrStart+i*attrSize:]
+		nameIndex := le.Uint32(attr[4:])
+		rawValue := le.Uint32(attr[8:])
+		dataType := attr[15]
+		value := le.Uint32(attr[16:])
+
+		// Names may be stripped by obfuscators, resource IDs are not
+		var resourceID uint32
+		if int(nameIndex) < len(resourceIDs) {
+			resourceID = resourceIDs[nameIndex]
+		}
+
+		text := str(rawValue)
+		if text == "" && dataType == axmlTypeString {
+			text = str(value)
+		}
+
+		switch {
+		case str(nameIndex) == "package":
+			manifest.Package = text
+		case str(nameIndex) == "versionCode" || resourceID == axmlVersionCodeID:
+			if dataType == axmlTypeIntDec || dataType == axmlTypeIntHex {
+				manifest.VersionCode = int64(value)
+			}
+		case str(nameIndex) == "versionName" || resourceID == axmlVersionNameID:
+			manifest.VersionName = text
+		}
+	}
+
+	if manifest.Package == "" {
+		return nil, errors.New("manifest has no package name")
+	}
+	return manifest, nil
+}
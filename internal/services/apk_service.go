@@ -0,0 +1,310 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"spam-checker/internal/config"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// APK download limits, size matches the upload body limit
+const (
+	apkDownloadTimeout = 10 * time.Minute
+	maxAPKDownloadSize = 500 * 1024 * 1024
+)
+
+// APKService manages library of APK builds per spam service
+type APKService struct {
+	db          *gorm.DB
+	storagePath string
+	log         *logrus.Entry
+}
+
+func NewAPKService(db *gorm.DB, cfg *config.Config) *APKService {
+	return &APKService{
+		db:          db,
+		storagePath: cfg.APK.StoragePath,
+		log:         logger.WithField("service", "APKService"),
+	}
+}
+
+// FilePath returns location of APK binary on disk
+func (s *APKService) FilePath(apk *models.APKFile) string {
+	return filepath.Join(s.storagePath, filepath.FromSlash(apk.StorageKey))
+}
+
+// expectedPackage returns android package configured for service, empty if unknown
+func (s *APKService) expectedPackage(serviceCode string) (string, error) {
+	var service models.SpamService
+	if err := s.db.Where("code = ?", serviceCode).First(&service).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("service not found")
+		}
+		return "", fmt.Errorf("failed to get service: %w", err)
+	}
+
+	cfg := storedServiceConfig(&service)
+	appPackage, _ := cfg.appInfo(serviceCode)
+	return appPackage, nil
+}
+
+// UploadAPK stores APK in the library after validating its package against service.
+// First APK of a service becomes its default.
+func (s *APKService) UploadAPK(serviceCode, fileName string, src io.Reader, uploadedBy uint) (*models.APKFile, error) {
+	expected, err := s.expectedPackage(serviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(s.storagePath, serviceCode), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create APK storage: %w", err)
+	}
+
+	// Upload to a temp file next to the final location so rename stays on one filesystem
+	tempFile, err := os.CreateTemp(filepath.Join(s.storagePath, serviceCode), "upload-*.apk")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath)
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tempFile, hasher), src)
+	tempFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write APK: %w", err)
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	manifest, err := parseAPKManifest(tempPath)
+	if err != nil {
+		return nil, err
+	}
+	if expected != "" && manifest.Package != expected {
+		return nil, fmt.Errorf("APK package %s does not match service package %s", manifest.Package, expected)
+	}
+
+	var duplicates int64
+	if err := s.db.Model(&models.APKFile{}).
+		Where("service_code = ? AND sha256 = ?", serviceCode, hash).
+		Count(&duplicates).Error; err != nil {
+		return nil, fmt.Errorf("failed to check APK duplicates: %w", err)
+	}
+	if duplicates > 0 {
+		return nil, errors.New("APK already uploaded")
+	}
+
+	apk := &models.APKFile{
+		ServiceCode: serviceCode,
+		PackageName: manifest.Package,
+		VersionName: manifest.VersionName,
+		VersionCode: manifest.VersionCode,
+		FileName:    filepath.Base(fileName),
+		StorageKey:  fmt.Sprintf("%s/%d-%s.apk", serviceCode, manifest.VersionCode, hash[:16]),
+		Size:        size,
+		SHA256:      hash,
+		UploadedBy:  uploadedBy,
+	}
+
+	if err := os.Rename(tempPath, s.FilePath(apk)); err != nil {
+		return nil, fmt.Errorf("failed to store APK: %w", err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var defaults int64
+		if err := tx.Model(&models.APKFile{}).
+			Where("service_code = ? AND is_default = ?", serviceCode, true).
+			Count(&defaults).Error; err != nil {
+			return err
+		}
+		apk.IsDefault = defaults == 0
+
+		return tx.Create(apk).Error
+	})
+	if err != nil {
+		os.Remove(s.FilePath(apk))
+		return nil, fmt.Errorf("failed to save APK: %w", err)
+	}
+
+	s.log.Infof("Uploaded APK %s %s (%d) for service %s", apk.PackageName, apk.VersionName, apk.VersionCode, serviceCode)
+	return apk, nil
+}
+
+// DownloadAPK fetches APK from sourceURL into the library. The build is validated like an upload;
+// if the library already has it, the existing record is returned.
+func (s *APKService) DownloadAPK(serviceCode, sourceURL string, uploadedBy uint) (*models.APKFile, error) {
+	if err := os.MkdirAll(filepath.Join(s.storagePath, serviceCode), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create APK storage: %w", err)
+	}
+
+	client := &http.Client{Timeout: apkDownloadTimeout}
+	resp, err := client.Get(sourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download APK: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download APK: source returned status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxAPKDownloadSize {
+		return nil, fmt.Errorf("APK is larger than %d bytes", maxAPKDownloadSize)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Join(s.storagePath, serviceCode), "download-*.apk")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath)
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tempFile, hasher), io.LimitReader(resp.Body, maxAPKDownloadSize+1))
+	tempFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download APK: %w", err)
+	}
+	if size > maxAPKDownloadSize {
+		return nil, fmt.Errorf("APK is larger than %d bytes", maxAPKDownloadSize)
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	var existing models.APKFile
+	err = s.db.Where("service_code = ? AND sha256 = ?", serviceCode, hash).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check APK duplicates: %w", err)
+	}
+
+	downloaded, err := os.Open(tempPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open downloaded APK: %w", err)
+	}
+	defer downloaded.Close()
+
+	return s.UploadAPK(serviceCode, apkDownloadFileName(sourceURL), downloaded, uploadedBy)
+}
+
+// apkDownloadFileName returns file name from the last segment of source URL path
+func apkDownloadFileName(sourceURL string) string {
+	name := "download.apk"
+	if parsed, err := url.Parse(sourceURL); err == nil {
+		if base := path.Base(parsed.Path); base != "." && base != "/" {
+			name = base
+		}
+	}
+	return name
+}
+
+// ListAPKs returns APKs of a service, or of all services if code is empty
+func (s *APKService) ListAPKs(serviceCode string) ([]models.APKFile, error) {
+	query := s.db.Order("service_code, version_code DESC, id DESC")
+	if serviceCode != "" {
+		query = query.Where("service_code = ?", serviceCode)
+	}
+
+	var apks []models.APKFile
+	if err := query.Find(&apks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list APKs: %w", err)
+	}
+	return apks, nil
+}
+
+// GetAPK returns APK by ID
+func (s *APKService) GetAPK(id uint) (*models.APKFile, error) {
+	var apk models.APKFile
+	if err := s.db.First(&apk, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("apk not found")
+		}
+		return nil, fmt.Errorf("failed to get APK: %w", err)
+	}
+	return &apk, nil
+}
+
+// GetDefaultAPK returns default APK of a service, nil if service has none
+func (s *APKService) GetDefaultAPK(serviceCode string) (*models.APKFile, error) {
+	var apk models.APKFile
+	err := s.db.Where("service_code = ? AND is_default = ?", serviceCode, true).First(&apk).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default APK: %w", err)
+	}
+	return &apk, nil
+}
+
+// SetDefaultAPK makes APK the one installed on new gateways of its service
+func (s *APKService) SetDefaultAPK(id uint) error {
+	apk, err := s.GetAPK(id)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.APKFile{}).
+			Where("service_code = ? AND id <> ?", apk.ServiceCode, apk.ID).
+			Update("is_default", false).Error; err != nil {
+			return fmt.Errorf("failed to reset default APK: %w", err)
+		}
+		if err := tx.Model(apk).Update("is_default", true).Error; err != nil {
+			return fmt.Errorf("failed to set default APK: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteAPK removes APK record and its file. If it was the default,
+// the newest remaining build of the service becomes default.
+func (s *APKService) DeleteAPK(id uint) error {
+	apk, err := s.GetAPK(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(apk).Error; err != nil {
+			return fmt.Errorf("failed to delete APK: %w", err)
+		}
+		if !apk.IsDefault {
+			return nil
+		}
+
+		var next models.APKFile
+		err := tx.Where("service_code = ?", apk.ServiceCode).
+			Order("version_code DESC, id DESC").
+			First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find next default APK: %w", err)
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.FilePath(apk)); err != nil && !os.IsNotExist(err) {
+		s.log.Warnf("Failed to remove APK file %s: %v", apk.StorageKey, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"spam-checker/internal/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Errors of APK update jobs
+var (
+	ErrAPKSourceNotConfigured = errors.New("APK source URL is not configured")
+	ErrAPKUpdateRunning       = errors.New("APK update is already running")
+)
+
+// installedVersionPattern matches version code in dumpsys package output
+var installedVersionPattern = regexp.MustCompile(`versionCode=(\d+)`)
+
+// apkUpdateMutex serializes start of APK update jobs, ADB services are created per request
+var apkUpdateMutex sync.Mutex
+
+// APKUpdateJobDetails is APK update job along with its per gateway results
+type APKUpdateJobDetails struct {
+	models.APKUpdateJob
+	Results []models.APKUpdateResult `json:"results"`
+}
+
+// StartAPKUpdate downloads the latest APK of a service from app.apk_source_url of its config and
+// installs it on gateways of the service in background. While an update of the service runs
+// it is returned along with ErrAPKUpdateRunning instead of starting another.
+func (s *ADBService) StartAPKUpdate(serviceCode string, userID uint) (*models.APKUpdateJob, error) {
+	var service models.SpamService
+	if err := s.db.Where("code = ?", serviceCode).First(&service).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("service not found")
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	sourceURL := storedServiceConfig(&service).App.APKSourceURL
+	if sourceURL == "" {
+		return nil, fmt.Errorf("%w for service %s", ErrAPKSourceNotConfigured, serviceCode)
+	}
+
+	apkUpdateMutex.Lock()
+	defer apkUpdateMutex.Unlock()
+
+	var running models.APKUpdateJob
+	err := s.db.Where("service_code = ? AND status IN ?", serviceCode, []string{models.APKUpdatePending, models.APKUpdateRunning}).
+		Order("id DESC").
+		First(&running).Error
+	switch {
+	case err == nil && (running.Instance == instanceID || InstanceAlive(s.db, running.Instance)):
+		return &running, ErrAPKUpdateRunning
+	case err == nil:
+		// Instance running the job stopped, it will never finish
+		s.finishAPKUpdate(&running, models.APKUpdateFailed, "interrupted by restart")
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to get running APK update: %w", err)
+	}
+
+	job := &models.APKUpdateJob{
+		ServiceCode: serviceCode,
+		SourceURL:   sourceURL,
+		Status:      models.APKUpdatePending,
+		Instance:    instanceID,
+		CreatedBy:   userID,
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create APK update: %w", err)
+	}
+
+	go s.runAPKUpdate(*job)
+	return job, nil
+}
+
+// runAPKUpdate downloads APK and installs it on gateways one by one, so checks keep
+// running on the rest of the fleet
+func (s *ADBService) runAPKUpdate(job models.APKUpdateJob) {
+	log := s.log.WithFields(logrus.Fields{
+		"method":  "runAPKUpdate",
+		"job_id":  job.ID,
+		"service": job.ServiceCode,
+	})
+
+	now := time.Now()
+	job.Status = models.APKUpdateRunning
+	job.StartedAt = &now
+	if err := s.db.Model(&job).Updates(map[string]interface{}{
+		"status":     job.Status,
+		"started_at": job.StartedAt,
+	}).Error; err != nil {
+		log.Errorf("Failed to start APK update: %v", err)
+	}
+
+	apk, err := s.apkService.DownloadAPK(job.ServiceCode, job.SourceURL, job.CreatedBy)
+	if err != nil {
+		log.Errorf("Failed to download APK from %s: %v", job.SourceURL, err)
+		s.finishAPKUpdate(&job, models.APKUpdateFailed, err.Error())
+		return
+	}
+	job.APKID = &apk.ID
+	if err := s.db.Model(&job).Update("apk_id", apk.ID).Error; err != nil {
+		log.Warnf("Failed to save APK of update: %v", err)
+	}
+	log.Infof("Downloaded APK %s %s (%d)", apk.PackageName, apk.VersionName, apk.VersionCode)
+
+	// New gateways of the service get the latest build too
+	if current, err := s.apkService.GetDefaultAPK(job.ServiceCode); err == nil && (current == nil || current.VersionCode <= apk.VersionCode) {
+		if err := s.apkService.SetDefaultAPK(apk.ID); err != nil {
+			log.Warnf("Failed to make downloaded APK default: %v", err)
+		}
+	}
+
+	var gateways []models.ADBGateway
+	if err := s.db.Where("service_code = ?", job.ServiceCode).Order("id").Find(&gateways).Error; err != nil {
+		s.finishAPKUpdate(&job, models.APKUpdateFailed, fmt.Sprintf("failed to get gateways: %v", err))
+		return
+	}
+	job.Gateways = len(gateways)
+	if err := s.db.Model(&job).Update("gateways", job.Gateways).Error; err != nil {
+		log.Warnf("Failed to save APK update gateways: %v", err)
+	}
+
+	for i := range gateways {
+		result := s.updateGatewayAPK(&gateways[i], apk)
+		result.JobID = job.ID
+		if err := s.db.Create(&result).Error; err != nil {
+			log.Warnf("Failed to save APK update result of gateway %s: %v", gateways[i].Name, err)
+		}
+
+		switch result.Status {
+		case models.APKUpdateGatewayUpdated:
+			job.Updated++
+		case models.APKUpdateGatewaySkipped:
+			job.Skipped++
+		default:
+			job.Failed++
+			log.Warnf("Failed to update APK on gateway %s: %s", gateways[i].Name, result.Error)
+		}
+		if err := s.db.Model(&job).Updates(map[string]interface{}{
+			"updated": job.Updated,
+			"skipped": job.Skipped,
+			"failed":  job.Failed,
+		}).Error; err != nil {
+			log.Warnf("Failed to update APK update progress: %v", err)
+		}
+	}
+
+	s.finishAPKUpdate(&job, models.APKUpdateCompleted, "")
+	log.Infof("APK update completed: %d updated, %d skipped, %d failed", job.Updated, job.Skipped, job.Failed)
+}
+
+// updateGatewayAPK installs apk on gateway unless it already has this or a newer version
+func (s *ADBService) updateGatewayAPK(gateway *models.ADBGateway, apk *models.APKFile) models.APKUpdateResult {
+	result := models.APKUpdateResult{
+		GatewayID:   gateway.ID,
+		GatewayName: gateway.Name,
+		Status:      models.APKUpdateGatewayFailed,
+	}
+
+	if !gateway.IsDocker {
+		result.Status = models.APKUpdateGatewaySkipped
+		result.Error = "not a Docker gateway, install APK manually"
+		return result
+	}
+
+	installed, err := s.installedVersionCode(gateway, apk.PackageName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.PreviousVersion = installed
+	result.Version = installed
+	if installed >= apk.VersionCode {
+		result.Status = models.APKUpdateGatewaySkipped
+		return result
+	}
+
+	if err := s.InstallAPK(gateway.ID, s.apkService.FilePath(apk)); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// Confirm the package manager reports the new version
+	installed, err = s.installedVersionCode(gateway, apk.PackageName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Version = installed
+	if installed != apk.VersionCode {
+		result.Error = fmt.Sprintf("gateway reports version %d after install, expected %d", installed, apk.VersionCode)
+		return result
+	}
+
+	result.Status = models.APKUpdateGatewayUpdated
+	return result
+}
+
+// installedVersionCode returns version code of package on gateway, 0 when it is not installed
+func (s *ADBService) installedVersionCode(gateway *models.ADBGateway, packageName string) (int64, error) {
+	output, err := s.executeInContainer(gateway, []string{"adb", "shell", "dumpsys", "package", packageName})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get installed version: %w", err)
+	}
+
+	match := installedVersionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid installed version %q: %w", match[1], err)
+	}
+	return version, nil
+}
+
+// finishAPKUpdate stores final status of job
+func (s *ADBService) finishAPKUpdate(job *models.APKUpdateJob, status, message string) {
+	now := time.Now()
+	job.Status = status
+	job.Error = message
+	job.CompletedAt = &now
+	if err := s.db.Model(&models.APKUpdateJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":       job.Status,
+		"error":        job.Error,
+		"completed_at": job.CompletedAt,
+	}).Error; err != nil {
+		s.log.Errorf("Failed to finish APK update %d: %v", job.ID, err)
+	}
+}
+
+// GetAPKUpdate returns APK update job with its gateway results
+func (s *ADBService) GetAPKUpdate(id uint) (*APKUpdateJobDetails, error) {
+	var details APKUpdateJobDetails
+	if err := s.db.First(&details.APKUpdateJob, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("APK update not found")
+		}
+		return nil, fmt.Errorf("failed to get APK update: %w", err)
+	}
+	if err := s.db.Where("job_id = ?", id).Order("id").Find(&details.Results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get APK update results: %w", err)
+	}
+	return &details, nil
+}
+
+// ListAPKUpdates returns recent APK update jobs, newest first, of a service or of all if code is empty
+func (s *ADBService) ListAPKUpdates(serviceCode string, limit int) ([]models.APKUpdateJob, error) {
+	query := s.db.Order("id DESC").Limit(limit)
+	if serviceCode != "" {
+		query = query.Where("service_code = ?", serviceCode)
+	}
+
+	var jobs []models.APKUpdateJob
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list APK updates: %w", err)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"spam-checker/internal/models"
+	"strings"
+)
+
+// restartAppOnCrashSettingKey is the setting deciding whether caller-ID app is restarted after a crash dialog
+const restartAppOnCrashSettingKey = "check_restart_app_on_crash"
+
+// App failure kinds
+const (
+	AppCrashKindCrash = "crash" // "App has stopped" dialog
+	AppCrashKindANR   = "anr"   // "App isn't responding" dialog
+)
+
+// ErrAppCrashed is returned when caller-ID app crashed or stopped responding during a check,
+// the screenshot shows a system dialog instead of caller information
+var ErrAppCrashed = errors.New("app crashed during check")
+
+var (
+	// focusedWindowPattern matches focused window line of dumpsys window
+	focusedWindowPattern = regexp.MustCompile(`mCurrentFocus=Window\{[^}]*\}`)
+	// crashWindowPattern matches title of crash and ANR dialog windows
+	crashWindowPattern = regexp.MustCompile(`(Application Error|Application Not Responding): ?([\w.]*)`)
+)
+
+// crashTextPatterns are fragments of crash and ANR dialogs as read by OCR, lower case
+var crashTextPatterns = map[string][]string{
+	AppCrashKindCrash: {
+		"has stopped", "keeps stopping", "unfortunately,",
+		"остановлено", "снова произошел сбой", "снова произошёл сбой", "произошла ошибка в приложении",
+	},
+	AppCrashKindANR: {
+		"isn't responding", "is not responding",
+		"не отвечает",
+	},
+}
+
+// AppCrash describes crash or ANR dialog found during a check
+type AppCrash struct {
+	Kind    string // crash or anr
+	Package string // Crashed package when device reported it
+	Source  string // window or ocr, how the dialog was detected
+}
+
+func (c *AppCrash) Error() string {
+	subject := "app"
+	if c.Package != "" {
+		subject = c.Package
+	}
+	if c.Kind == AppCrashKindANR {
+		return fmt.Sprintf("%v: %s is not responding (detected by %s)", ErrAppCrashed, subject, c.Source)
+	}
+	return fmt.Sprintf("%v: %s has stopped (detected by %s)", ErrAppCrashed, subject, c.Source)
+}
+
+// Unwrap makes detected crash match ErrAppCrashed
+func (c *AppCrash) Unwrap() error {
+	return ErrAppCrashed
+}
+
+// parseCrashWindow looks for crash or ANR dialog in focused window of dumpsys window output
+func parseCrashWindow(output string) *AppCrash {
+	focused := focusedWindowPattern.FindString(output)
+	if focused == "" {
+		return nil
+	}
+
+	match := crashWindowPattern.FindStringSubmatch(focused)
+	if match == nil {
+		return nil
+	}
+
+	crash := &AppCrash{Kind: AppCrashKindCrash, Package: match[2], Source: "window"}
+	if match[1] == "Application Not Responding" {
+		crash.Kind = AppCrashKindANR
+	}
+	return crash
+}
+
+// detectCrashText looks for crash or ANR dialog phrases in OCR text.
+// Block phrases configured for the service are treated as crash phrases.
+func detectCrashText(text string, blockPhrases []string) *AppCrash {
+	text = strings.ToLower(text)
+	for _, kind := range []string{AppCrashKindANR, AppCrashKindCrash} {
+		for _, pattern := range crashTextPatterns[kind] {
+			if strings.Contains(text, pattern) {
+				return &AppCrash{Kind: kind, Source: "ocr"}
+			}
+		}
+	}
+	for _, phrase := range blockPhrases {
+		if strings.Contains(text, strings.ToLower(phrase)) {
+			return &AppCrash{Kind: AppCrashKindCrash, Source: "ocr"}
+		}
+	}
+	return nil
+}
+
+// detectCrashDialog asks device whether a crash or ANR dialog has focus
+func detectCrashDialog(exec adbCommandExecutor) *AppCrash {
+	output, err := exec([]string{"adb", "shell", "dumpsys", "window", "windows"})
+	if err != nil {
+		return nil
+	}
+	return parseCrashWindow(output)
+}
+
+// dismissCrashDialog closes crash dialog and, when appPackage is given, restarts the app
+func dismissCrashDialog(exec adbCommandExecutor, crash *AppCrash, appPackage, appActivity string) error {
+	// Close dialogs broadcast dismisses both dialog kinds, BACK covers devices ignoring it
+	commands := [][]string{
+		{"adb", "shell", "am", "broadcast", "-a", "android.intent.action.CLOSE_SYSTEM_DIALOGS"},
+		{"adb", "shell", "input", "keyevent", "KEYCODE_BACK"},
+	}
+
+	crashed := crash.Package
+	if crashed == "" {
+		crashed = appPackage
+	}
+	if crashed != "" {
+		commands = append(commands, []string{"adb", "shell", "am", "force-stop", crashed})
+	}
+	if appPackage != "" && appActivity != "" {
+		commands = append(commands, []string{"adb", "shell", "am", "start", "-n", appPackage + "/" + appActivity})
+	}
+
+	var errs []error
+	for _, cmd := range commands {
+		if _, err := exec(cmd); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// handleAppCrash dismisses crash dialog on gateway, restarts service app when enabled
+// and records the crash as a gateway event
+func (s *CheckService) handleAppCrash(gateway *models.ADBGateway, service *models.SpamService, crash *AppCrash) {
+	var appPackage, appActivity string
+	if NewSettingsService(s.db).GetCachedBool(restartAppOnCrashSettingKey, true) {
+		appPackage, appActivity = s.getAppInfo(service.Code)
+	}
+
+	subject := "app"
+	if crash.Package != "" {
+		subject = crash.Package
+	}
+	message := fmt.Sprintf("%s %s during %s check, detected by %s", subject, crash.Kind, service.Code, crash.Source)
+	if err := dismissCrashDialog(s.adbService.gatewayExecutor(gateway), crash, appPackage, appActivity); err != nil {
+		s.log.Warnf("Failed to dismiss crash dialog on gateway %s: %v", gateway.Name, err)
+		message += fmt.Sprintf(", dismissing the dialog failed: %v", err)
+	} else if appPackage != "" {
+		message += ", dialog dismissed and app restarted"
+	} else {
+		message += ", dialog dismissed"
+	}
+
+	eventType := GatewayEventAppCrash
+	if crash.Kind == AppCrashKindANR {
+		eventType = GatewayEventAppNotResponding
+	}
+	s.adbService.recordGatewayEvent(gateway.ID, eventType, message)
+}
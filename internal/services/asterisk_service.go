@@ -13,11 +13,35 @@ import (
 	"gorm.io/gorm"
 )
 
+// erroredNumberPolicySettingKey is the setting deciding whether numbers with errored latest check are allocated
+const erroredNumberPolicySettingKey = "asterisk_errored_number_policy"
+
+// requireCleanCheckSettingKey is the setting withholding numbers without a successful check from allocation
+const requireCleanCheckSettingKey = "asterisk_require_clean_check"
+
+// Errored number policies
+const (
+	// ErroredNumberPolicyAllow ignores errored checks and relies on the last successful verdict.
+	// Pool stays available during outages, but a number that turned spam meanwhile may be handed out.
+	ErroredNumberPolicyAllow = "allow"
+	// ErroredNumberPolicyExclude withholds numbers whose latest check of any service errored
+	// until a successful re-check. Safer, but an outage of one service can empty the pool.
+	ErroredNumberPolicyExclude = "exclude"
+)
+
+// validateErroredNumberPolicy checks errored number policy setting value
+func validateErroredNumberPolicy(policy string) error {
+	if policy != ErroredNumberPolicyAllow && policy != ErroredNumberPolicyExclude {
+		return fmt.Errorf("%s must be %q or %q", erroredNumberPolicySettingKey, ErroredNumberPolicyAllow, ErroredNumberPolicyExclude)
+	}
+	return nil
+}
+
 type AsteriskService struct {
 	db              *gorm.DB
 	log             *logrus.Entry
 	allocationMutex sync.Mutex
-	rng             *rand.Rand
+	strategies      map[string]allocationStrategy // Guarded by allocationMutex, strategies share rng
 }
 
 // AllocationMetadata stores additional information about allocation
@@ -34,18 +58,21 @@ type CleanNumberResponse struct {
 	Description  string    `json:"description,omitempty"`
 	AllocatedAt  time.Time `json:"allocated_at"`
 	AllocationID uint      `json:"allocation_id"`
+	// Verdicts the number was considered clean on, only when requested
+	Verification *NumberVerification `json:"verification,omitempty"`
 }
 
 func NewAsteriskService(db *gorm.DB) *AsteriskService {
 	return &AsteriskService{
-		db:  db,
-		log: logger.WithField("service", "AsteriskService"),
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+		db:         db,
+		log:        logger.WithField("service", "AsteriskService"),
+		strategies: newAllocationStrategies(rand.New(rand.NewSource(time.Now().UnixNano()))),
 	}
 }
 
-// GetCleanNumber returns a clean (non-spam) phone number with load balancing
-func (s *AsteriskService) GetCleanNumber(clientIP string, purpose string, metadata *AllocationMetadata) (*CleanNumberResponse, error) {
+// GetCleanNumber returns a clean (non-spam) phone number chosen by configured allocation strategy.
+// withVerification adds summary of the clean verdicts, allocation does not fail if it can't be built.
+func (s *AsteriskService) GetCleanNumber(clientIP string, purpose string, metadata *AllocationMetadata, withVerification bool) (*CleanNumberResponse, error) {
 	s.allocationMutex.Lock()
 	defer s.allocationMutex.Unlock()
 
@@ -65,8 +92,8 @@ func (s *AsteriskService) GetCleanNumber(clientIP string, purpose string, metada
 		return nil, fmt.Errorf("no clean numbers available")
 	}
 
-	// Select number using weighted random selection based on usage
-	selectedNumber := s.selectNumberWithLoadBalancing(cleanNumbers)
+	// Select number using configured allocation strategy
+	selectedNumber, strategy := s.selectNumber(cleanNumbers)
 	if selectedNumber == nil {
 		return nil, fmt.Errorf("failed to select number")
 	}
@@ -95,34 +122,76 @@ func (s *AsteriskService) GetCleanNumber(clientIP string, purpose string, metada
 		return nil, fmt.Errorf("failed to get phone details: %w", err)
 	}
 
-	log.Infof("Allocated number %s (ID: %d) to %s", phone.Number, phone.ID, clientIP)
+	log.Infof("Allocated number %s (ID: %d) to %s using %s strategy", phone.Number, phone.ID, clientIP, strategy)
 
-	return &CleanNumberResponse{
+	response := &CleanNumberResponse{
 		Number:       phone.Number,
 		PhoneID:      phone.ID,
 		Description:  phone.Description,
 		AllocatedAt:  allocation.AllocatedAt,
 		AllocationID: allocation.ID,
-	}, nil
+	}
+	if withVerification {
+		if response.Verification, err = s.numberVerification(phone.ID); err != nil {
+			log.Warnf("Failed to build verification of %s: %v", phone.Number, err)
+		}
+	}
+	return response, nil
 }
 
-// getCleanNumbersWithStats gets all clean active numbers with usage statistics
+// erroredNumberPolicy returns configured errored number policy, allow if unset or invalid
+func (s *AsteriskService) erroredNumberPolicy() string {
+	value, err := NewSettingsService(s.db).GetCachedSettingValue(erroredNumberPolicySettingKey)
+	if err != nil {
+		return ErroredNumberPolicyAllow
+	}
+	policy, _ := value.(string)
+	if validateErroredNumberPolicy(policy) != nil {
+		s.log.Warnf("Ignoring invalid %s setting %q", erroredNumberPolicySettingKey, policy)
+		return ErroredNumberPolicyAllow
+	}
+	return policy
+}
+
+// getCleanNumbersWithStats gets all clean active numbers with usage statistics.
+// Numbers whose latest check errored are eligible depending on errored number policy,
+// numbers never checked successfully only when clean check is not required.
 func (s *AsteriskService) getCleanNumbersWithStats() ([]models.PhoneNumberUsageStats, error) {
+	allowErrored := s.erroredNumberPolicy() == ErroredNumberPolicyAllow
+	allowUnchecked := !NewSettingsService(s.db).GetCachedBool(requireCleanCheckSettingKey, true)
+
 	// SQL query to get clean numbers with usage stats
 	query := `
-		WITH latest_checks AS (
+		WITH latest_attempts AS (
+			SELECT DISTINCT ON (phone_number_id, service_id)
+				phone_number_id,
+				status
+			FROM check_results
+			ORDER BY phone_number_id, service_id, checked_at DESC
+		),
+		error_status AS (
+			SELECT
+				phone_number_id,
+				BOOL_OR(status = 'error') as has_error
+			FROM latest_attempts
+			GROUP BY phone_number_id
+		),
+		latest_checks AS (
 			SELECT DISTINCT ON (phone_number_id, service_id)
 				phone_number_id,
 				service_id,
 				is_spam,
+				inconclusive,
 				checked_at
 			FROM check_results
+			WHERE status <> 'error'
 			ORDER BY phone_number_id, service_id, checked_at DESC
 		),
 		spam_status AS (
 			SELECT 
 				phone_number_id,
-				BOOL_OR(is_spam) as has_spam
+				BOOL_OR(is_spam) as has_spam,
+				BOOL_OR(inconclusive) as has_inconclusive
 			FROM latest_checks
 			GROUP BY phone_number_id
 		),
@@ -153,110 +222,48 @@ func (s *AsteriskService) getCleanNumbersWithStats() ([]models.PhoneNumberUsageS
 		LEFT JOIN spam_status ss ON ss.phone_number_id = pn.id
 		LEFT JOIN total_allocations ta ON ta.phone_number_id = pn.id
 		LEFT JOIN daily_allocations da ON da.phone_number_id = pn.id
+		LEFT JOIN error_status es ON es.phone_number_id = pn.id
 		WHERE pn.is_active = true
+			AND pn.blocked = false
 			AND pn.deleted_at IS NULL
 			AND (ss.has_spam IS NULL OR ss.has_spam = false)
+			AND (ss.has_inconclusive IS NULL OR ss.has_inconclusive = false)
+			AND (? OR es.has_error IS NULL OR es.has_error = false)
+			AND (? OR ss.phone_number_id IS NOT NULL)
 		ORDER BY pn.id
 	`
 
 	var stats []models.PhoneNumberUsageStats
-	if err := s.db.Raw(query).Scan(&stats).Error; err != nil {
+	if err := s.db.Raw(query, allowErrored, allowUnchecked).Scan(&stats).Error; err != nil {
 		return nil, err
 	}
 
 	return stats, nil
 }
 
-// selectNumberWithLoadBalancing selects a number using weighted random selection
-func (s *AsteriskService) selectNumberWithLoadBalancing(numbers []models.PhoneNumberUsageStats) *models.PhoneNumberUsageStats {
+// selectNumber picks a number from clean pool using configured allocation strategy
+func (s *AsteriskService) selectNumber(numbers []models.PhoneNumberUsageStats) (*models.PhoneNumberUsageStats, string) {
+	name := s.allocationStrategyName()
 	if len(numbers) == 0 {
-		return nil
-	}
-
-	// If only one number, return it
-	if len(numbers) == 1 {
-		return &numbers[0]
-	}
-
-	// Calculate weights for each number
-	weights := make([]float64, len(numbers))
-	totalWeight := 0.0
-
-	// Find the maximum allocations to normalize weights
-	maxAllocations := int64(0)
-	for _, num := range numbers {
-		if num.TotalAllocations > maxAllocations {
-			maxAllocations = num.TotalAllocations
-		}
+		return nil, name
 	}
-
-	// Calculate weights (inverse of allocation count for load balancing)
-	for i, num := range numbers {
-		// Base weight - higher for numbers with fewer allocations
-		weight := 1.0
-		if maxAllocations > 0 {
-			// Normalize allocation count and invert (fewer allocations = higher weight)
-			normalizedAlloc := float64(num.TotalAllocations) / float64(maxAllocations+1)
-			weight = 1.0 - normalizedAlloc + 0.1 // Add 0.1 to ensure non-zero weight
-		}
-
-		// Boost weight for numbers not used today
-		if num.DailyAllocations == 0 {
-			weight *= 2.0
-		}
-
-		// Boost weight for numbers not used recently
-		if num.LastAllocatedAt == nil {
-			weight *= 3.0
-		} else {
-			hoursSinceLastUse := time.Since(*num.LastAllocatedAt).Hours()
-			if hoursSinceLastUse > 24 {
-				weight *= 2.0
-			} else if hoursSinceLastUse > 1 {
-				weight *= 1.5
-			}
-		}
-
-		weights[i] = weight
-		totalWeight += weight
-	}
-
-	// Weighted random selection
-	if totalWeight <= 0 {
-		// Fallback to random selection if all weights are zero
-		return &numbers[s.rng.Intn(len(numbers))]
-	}
-
-	randomValue := s.rng.Float64() * totalWeight
-	currentWeight := 0.0
-
-	for i, weight := range weights {
-		currentWeight += weight
-		if randomValue <= currentWeight {
-			return &numbers[i]
-		}
-	}
-
-	// Fallback (should not reach here)
-	return &numbers[len(numbers)-1]
+	return s.strategies[name].Select(numbers), name
 }
 
-// GetAllocationHistory gets allocation history for a specific phone number
-func (s *AsteriskService) GetAllocationHistory(phoneID uint, limit int) ([]models.NumberAllocation, error) {
+// GetAllocationHistory gets allocation history for a specific phone number with total count, zero limit gets all
+func (s *AsteriskService) GetAllocationHistory(phoneID uint, offset, limit int) ([]models.NumberAllocation, int64, error) {
 	var allocations []models.NumberAllocation
 
 	query := s.db.Where("phone_number_id = ?", phoneID).
-		Order("allocated_at DESC")
+		Order("allocated_at DESC, id DESC").
+		Preload("PhoneNumber")
 
-	if limit > 0 {
-		query = query.Limit(limit)
-	}
-
-	if err := query.Preload("PhoneNumber").Find(&allocations).Error; err != nil {
-		return nil, fmt.Errorf("failed to get allocation history: %w", err)
+	total, err := findPage(query, offset, limit, &allocations)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get allocation history: %w", err)
 	}
 
-	return allocations, nil
+	return allocations, total, nil
 }
 
 // GetAllocationStats gets allocation statistics
@@ -336,21 +343,21 @@ func (s *AsteriskService) GetAllocationStats(days int) (map[string]interface{},
 	return stats, nil
 }
 
-// GetCurrentAllocations gets current allocations for monitoring
-func (s *AsteriskService) GetCurrentAllocations(minutes int) ([]models.NumberAllocation, error) {
+// GetCurrentAllocations gets current allocations for monitoring with total count, zero limit gets all
+func (s *AsteriskService) GetCurrentAllocations(minutes, offset, limit int) ([]models.NumberAllocation, int64, error) {
 	since := time.Now().Add(-time.Duration(minutes) * time.Minute)
 
 	var allocations []models.NumberAllocation
-	err := s.db.Where("allocated_at >= ?", since).
-		Order("allocated_at DESC").
-		Preload("PhoneNumber").
-		Find(&allocations).Error
+	query := s.db.Where("allocated_at >= ?", since).
+		Order("allocated_at DESC, id DESC").
+		Preload("PhoneNumber")
 
+	total, err := findPage(query, offset, limit, &allocations)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current allocations: %w", err)
+		return nil, 0, fmt.Errorf("failed to get current allocations: %w", err)
 	}
 
-	return allocations, nil
+	return allocations, total, nil
 }
 
 // CleanupOldAllocations removes old allocation records (for maintenance)
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Settings controlling spacing of simulated calls on one gateway
+const (
+	minCallIntervalSettingKey    = "gateway_min_call_interval_seconds"
+	callIntervalPolicySettingKey = "gateway_call_interval_policy"
+)
+
+// Policies applied when a gateway was called more recently than the minimum interval
+const (
+	// CallIntervalPolicyWait delays the call until the interval has passed
+	CallIntervalPolicyWait = "wait"
+	// CallIntervalPolicyFail fails the check on this gateway right away
+	CallIntervalPolicyFail = "fail"
+)
+
+// defaultMinCallInterval is used when the setting is missing
+const defaultMinCallInterval = 10 * time.Second
+
+// maxMinCallIntervalSeconds bounds the wait, other checks of the gateway queue for its lock meanwhile
+const maxMinCallIntervalSeconds = 120
+
+// ErrGatewayCalledRecently is returned by fail policy when the previous call on the gateway
+// ended less than the minimum interval ago
+var ErrGatewayCalledRecently = errors.New("gateway called too recently")
+
+// callSpacer tracks when the last simulated call on each gateway ended. The time is kept on
+// the gateway row, so calls placed by different instances are spaced as well.
+// Caller-ID apps may merge or ignore calls that follow each other within seconds.
+type callSpacer struct {
+	db *gorm.DB
+}
+
+func newCallSpacer(db *gorm.DB) *callSpacer {
+	return &callSpacer{db: db}
+}
+
+// remaining returns how long gateway must stay idle before the next call
+func (c *callSpacer) remaining(gatewayID uint, interval time.Duration, now time.Time) (time.Duration, error) {
+	var gateway models.ADBGateway
+	if err := c.db.Select("id, last_call_at").First(&gateway, gatewayID).Error; err != nil {
+		return 0, fmt.Errorf("failed to get last call of gateway %d: %w", gatewayID, err)
+	}
+	if gateway.LastCallAt == nil {
+		return 0, nil
+	}
+	return max(gateway.LastCallAt.Add(interval).Sub(now), 0), nil
+}
+
+// markCall records end of a call on gateway
+func (c *callSpacer) markCall(gatewayID uint, at time.Time) error {
+	if err := c.db.Model(&models.ADBGateway{}).Where("id = ?", gatewayID).UpdateColumn("last_call_at", at).Error; err != nil {
+		return fmt.Errorf("failed to record last call of gateway %d: %w", gatewayID, err)
+	}
+	return nil
+}
+
+// minCallInterval returns minimum time between calls on gateway, its own value overrides the setting
+func (s *CheckService) minCallInterval(gateway *models.ADBGateway) time.Duration {
+	if gateway.MinCallIntervalSeconds > 0 {
+		return time.Duration(gateway.MinCallIntervalSeconds) * time.Second
+	}
+	seconds := NewSettingsService(s.db).GetCachedInt(minCallIntervalSettingKey, int(defaultMinCallInterval/time.Second))
+	return time.Duration(max(seconds, 0)) * time.Second
+}
+
+// awaitCallSlot enforces minimum interval since the previous call on gateway, waiting for it
+// or failing fast depending on the policy setting. Must be called with the gateway lock held.
+func (s *CheckService) awaitCallSlot(ctx context.Context, gateway *models.ADBGateway) error {
+	interval := s.minCallInterval(gateway)
+	if interval <= 0 {
+		return nil
+	}
+
+	wait, err := s.callSpacing.remaining(gateway.ID, interval, time.Now())
+	if err != nil {
+		return err
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	policy, _ := NewSettingsService(s.db).GetCachedSettingValue(callIntervalPolicySettingKey)
+	if policy == CallIntervalPolicyFail {
+		return fmt.Errorf("%w: %s must stay idle for another %s", ErrGatewayCalledRecently, gateway.Name, wait.Round(time.Millisecond))
+	}
+
+	logger.EntryWithContext(s.log, ctx).Debugf("Waiting %s before next call on gateway %s", wait.Round(time.Millisecond), gateway.Name)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
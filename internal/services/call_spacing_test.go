@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"spam-checker/internal/models"
+)
+
+func TestCallSpacerSharedAcrossInstances(t *testing.T) {
+	db := newTestDB(t)
+	gateway := models.ADBGateway{Name: "gw-spacing", Host: "10.0.0.7", Port: 5555}
+	if err := db.Create(&gateway).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Spacers of two instances sharing the database
+	a, b := newCallSpacer(db), newCallSpacer(db)
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	const interval = 10 * time.Second
+
+	if wait, err := b.remaining(gateway.ID, interval, now); err != nil || wait != 0 {
+		t.Fatalf("remaining() of never called gateway = %s, %v", wait, err)
+	}
+
+	if err := a.markCall(gateway.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if wait, err := b.remaining(gateway.ID, interval, now.Add(3*time.Second)); err != nil || wait != 7*time.Second {
+		t.Errorf("remaining() after call on another instance = %s, %v, want 7s", wait, err)
+	}
+	if wait, err := b.remaining(gateway.ID, interval, now.Add(interval)); err != nil || wait != 0 {
+		t.Errorf("remaining() after interval = %s, %v", wait, err)
+	}
+
+	if _, err := b.remaining(gateway.ID+100, interval, now); err == nil {
+		t.Error("remaining() of missing gateway succeeded")
+	}
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+)
+
+// CallState is telephony state of a gateway device
+type CallState string
+
+const (
+	CallStateIdle    CallState = "idle"
+	CallStateRinging CallState = "ringing"
+	CallStateOffhook CallState = "offhook" // Call answered or dialing
+	CallStateUnknown CallState = "unknown" // Device did not report its state
+)
+
+const (
+	// callTeardownTimeout bounds graceful termination attempts before in-call packages are force-stopped
+	callTeardownTimeout = 10 * time.Second
+	// callMethodWait is how long a termination method gets to take effect before the next one is tried
+	callMethodWait = 2 * time.Second
+	// callStatePollInterval is delay between call state queries
+	callStatePollInterval = 500 * time.Millisecond
+)
+
+// callTeardownTiming bounds how long call teardown waits, see the constants above
+type callTeardownTiming struct {
+	timeout    time.Duration
+	methodWait time.Duration
+	poll       time.Duration
+}
+
+var defaultCallTeardownTiming = callTeardownTiming{
+	timeout:    callTeardownTimeout,
+	methodWait: callMethodWait,
+	poll:       callStatePollInterval,
+}
+
+// Call termination methods, in the order they are reported in CallTeardown.Methods
+const (
+	callMethodGSMCancel  = "gsm_cancel"
+	callMethodEndCallKey = "keyevent_endcall"
+	callMethodForceStop  = "force_stop"
+)
+
+// inCallPackages draw incoming call screens, force-stopped when the call cannot be ended gracefully
+var inCallPackages = []string{"com.android.incallui", "com.android.dialer", "com.google.android.dialer"}
+
+// ErrCallNotEnded is returned when a gateway still has a call after all termination methods
+var ErrCallNotEnded = errors.New("call could not be ended")
+
+var (
+	// registryCallStatePattern matches call state of a SIM slot in dumpsys telephony.registry
+	registryCallStatePattern = regexp.MustCompile(`mCallState=(\d)`)
+	// telecomCallStatePattern matches state of a call listed by dumpsys telecom
+	telecomCallStatePattern = regexp.MustCompile(`(?i)\bstate[=:]\s*(RINGING|ACTIVE|DIALING|CONNECTING|ON_HOLD|DISCONNECTING|NEW)\b`)
+	// emulatorCallPattern matches a call listed by emulator console "gsm list"
+	emulatorCallPattern = regexp.MustCompile(`(?m)(?:inbound|outbound)\s+(?:from|to)\s+(\+?\d+)`)
+)
+
+// adbCommandExecutor runs adb command on a gateway. Call teardown takes it as a parameter
+// so its state machine can run against a fake device.
+type adbCommandExecutor func(cmd []string) (string, error)
+
+// CallTeardown describes how a call was ended on a gateway
+type CallTeardown struct {
+	InitialState CallState `json:"initial_state"`
+	FinalState   CallState `json:"final_state"`
+	Methods      []string  `json:"methods,omitempty"` // Termination methods in the order they were tried
+	Forced       bool      `json:"forced"`            // In-call packages had to be force-stopped
+}
+
+// parseRegistryCallState reads call state from dumpsys telephony.registry output.
+// Multi-SIM devices list a state per slot, any busy slot counts.
+func parseRegistryCallState(output string) CallState {
+	matches := registryCallStatePattern.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return CallStateUnknown
+	}
+
+	state := CallStateIdle
+	for _, match := range matches {
+		switch match[1] {
+		case "1":
+			return CallStateRinging
+		case "2":
+			state = CallStateOffhook
+		}
+	}
+	return state
+}
+
+// parseTelecomCallState reads call state from dumpsys telecom output
+func parseTelecomCallState(output string) CallState {
+	if strings.TrimSpace(output) == "" {
+		return CallStateUnknown
+	}
+
+	state := CallStateIdle
+	for _, match := range telecomCallStatePattern.FindAllStringSubmatch(output, -1) {
+		if strings.EqualFold(match[1], "RINGING") || strings.EqualFold(match[1], "NEW") {
+			return CallStateRinging
+		}
+		state = CallStateOffhook
+	}
+	return state
+}
+
+// queryCallState asks the device for its call state, telecom service is used
+// when telephony registry does not report one
+func queryCallState(exec adbCommandExecutor) (CallState, error) {
+	output, err := exec([]string{"adb", "shell", "dumpsys", "telephony.registry"})
+	if err == nil {
+		if state := parseRegistryCallState(output); state != CallStateUnknown {
+			return state, nil
+		}
+	}
+
+	output, err = exec([]string{"adb", "shell", "dumpsys", "telecom"})
+	if err != nil {
+		return CallStateUnknown, fmt.Errorf("failed to query call state: %w", err)
+	}
+	return parseTelecomCallState(output), nil
+}
+
+// emulatorCallNumbers returns numbers of calls known to emulator console plus the given one
+func emulatorCallNumbers(exec adbCommandExecutor, phoneNumber string) []string {
+	var numbers []string
+	seen := make(map[string]bool)
+	add := func(number string) {
+		if number != "" && !seen[number] {
+			seen[number] = true
+			numbers = append(numbers, number)
+		}
+	}
+
+	add(phoneNumber)
+	if output, err := exec([]string{"adb", "emu", "gsm", "list"}); err == nil {
+		for _, match := range emulatorCallPattern.FindAllStringSubmatch(output, -1) {
+			add(match[1])
+		}
+	}
+	return numbers
+}
+
+// runCallMethod executes one termination method, error means none of its commands succeeded
+func runCallMethod(exec adbCommandExecutor, method, phoneNumber string) error {
+	var commands [][]string
+	switch method {
+	case callMethodGSMCancel:
+		for _, number := range emulatorCallNumbers(exec, phoneNumber) {
+			commands = append(commands, []string{"adb", "emu", "gsm", "cancel", number})
+		}
+	case callMethodEndCallKey:
+		commands = append(commands, []string{"adb", "shell", "input", "keyevent", "KEYCODE_ENDCALL"})
+	case callMethodForceStop:
+		for _, pkg := range inCallPackages {
+			commands = append(commands, []string{"adb", "shell", "am", "force-stop", pkg})
+		}
+		// Return to launcher in case an overlay survived
+		commands = append(commands, []string{"adb", "shell", "input", "keyevent", "KEYCODE_HOME"})
+	}
+
+	if len(commands) == 0 {
+		return fmt.Errorf("%s: nothing to terminate", method)
+	}
+
+	var lastErr error
+	succeeded := false
+	for _, cmd := range commands {
+		if _, err := exec(cmd); err != nil {
+			lastErr = err
+			continue
+		}
+		succeeded = true
+	}
+	if !succeeded {
+		return fmt.Errorf("%s: %w", method, lastErr)
+	}
+	return nil
+}
+
+// callMethodsFor returns graceful termination methods suited to call state.
+// Ringing emulated call is cancelled on modem side, answered one is hung up from the device.
+func callMethodsFor(state CallState) []string {
+	if state == CallStateOffhook {
+		return []string{callMethodEndCallKey, callMethodGSMCancel}
+	}
+	return []string{callMethodGSMCancel, callMethodEndCallKey}
+}
+
+// waitForCallIdle polls call state until device is idle or wait elapses, returns the last state seen
+func waitForCallIdle(exec adbCommandExecutor, wait, poll time.Duration) CallState {
+	deadline := time.Now().Add(wait)
+	for {
+		time.Sleep(poll)
+		state, err := queryCallState(exec)
+		if err == nil && state == CallStateIdle {
+			return state
+		}
+		if !time.Now().Before(deadline) {
+			return state
+		}
+	}
+}
+
+// terminateCall ends call on a device: graceful methods suited to current state are retried
+// until the device is idle or timeout passes, then in-call packages are force-stopped.
+// When the device cannot report its state, every method is tried once and FinalState stays unknown.
+func terminateCall(exec adbCommandExecutor, phoneNumber string, timing callTeardownTiming) (*CallTeardown, error) {
+	teardown := &CallTeardown{}
+
+	state, err := queryCallState(exec)
+	teardown.InitialState = state
+	teardown.FinalState = state
+	if err != nil || state == CallStateUnknown {
+		for _, method := range callMethodsFor(CallStateRinging) {
+			teardown.Methods = append(teardown.Methods, method)
+			_ = runCallMethod(exec, method, phoneNumber)
+		}
+		return teardown, nil
+	}
+	if state == CallStateIdle {
+		return teardown, nil
+	}
+
+	deadline := time.Now().Add(timing.timeout)
+	methods := callMethodsFor(state)
+	for attempt := 0; time.Now().Before(deadline); attempt++ {
+		method := methods[attempt%len(methods)]
+		teardown.Methods = append(teardown.Methods, method)
+		_ = runCallMethod(exec, method, phoneNumber)
+
+		wait := timing.methodWait
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		teardown.FinalState = waitForCallIdle(exec, wait, timing.poll)
+		if teardown.FinalState == CallStateIdle {
+			return teardown, nil
+		}
+	}
+
+	teardown.Methods = append(teardown.Methods, callMethodForceStop)
+	teardown.Forced = true
+	if err := runCallMethod(exec, callMethodForceStop, phoneNumber); err != nil {
+		return teardown, fmt.Errorf("%w: %v", ErrCallNotEnded, err)
+	}
+
+	teardown.FinalState = waitForCallIdle(exec, timing.methodWait, timing.poll)
+	if teardown.FinalState != CallStateIdle {
+		return teardown, fmt.Errorf("%w: device is still %s", ErrCallNotEnded, teardown.FinalState)
+	}
+	return teardown, nil
+}
+
+// gatewayExecutor runs adb commands inside gateway container, emulator console commands
+// go through the gateway's console queue
+func (s *ADBService) gatewayExecutor(gateway *models.ADBGateway) adbCommandExecutor {
+	return func(cmd []string) (string, error) {
+		if isConsoleCommand(cmd) {
+			return s.runConsoleCommand(gateway, cmd)
+		}
+		return s.executeInContainer(gateway, cmd)
+	}
+}
+
+// endCall terminates call on gateway and records forced cleanup as a gateway event
+func (s *ADBService) endCall(gateway *models.ADBGateway, phoneNumber string) (*CallTeardown, error) {
+	teardown, err := terminateCall(s.gatewayExecutor(gateway), phoneNumber, defaultCallTeardownTiming)
+	if teardown.Forced {
+		s.recordGatewayEvent(gateway.ID, GatewayEventCallForceStopped,
+			fmt.Sprintf("call was %s after %s, in-call packages force-stopped, final state %s",
+				teardown.InitialState, strings.Join(teardown.Methods[:len(teardown.Methods)-1], ", "), teardown.FinalState))
+	}
+	return teardown, err
+}
+
+// EnsureCallIdle verifies gateway has no ringing or active call before a new one is simulated.
+// A call left over from a previous check is ended and recorded as a gateway event.
+// Devices that cannot report call state are assumed idle.
+func (s *ADBService) EnsureCallIdle(gatewayID uint) (*CallTeardown, error) {
+	gateway, err := s.GetGatewayByID(gatewayID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := queryCallState(s.gatewayExecutor(gateway))
+	if err != nil || state == CallStateUnknown {
+		s.log.Warnf("Cannot verify call state of gateway %s, assuming idle: %v", gateway.Name, err)
+		return &CallTeardown{InitialState: CallStateUnknown, FinalState: CallStateUnknown}, nil
+	}
+	if state == CallStateIdle {
+		return &CallTeardown{InitialState: state, FinalState: state}, nil
+	}
+
+	s.log.Warnf("Gateway %s has a lingering %s call, ending it before the check", gateway.Name, state)
+	teardown, err := s.endCall(gateway, "")
+	message := fmt.Sprintf("%s call left from a previous check, ended with %s", state, strings.Join(teardown.Methods, ", "))
+	if err != nil {
+		message = fmt.Sprintf("%s call left from a previous check could not be ended: %v", state, err)
+	}
+	s.recordGatewayEvent(gateway.ID, GatewayEventLingeringCall, message)
+
+	return teardown, err
+}
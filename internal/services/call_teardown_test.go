@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// teardownTestTiming leaves room for a few graceful attempts before force stop
+var teardownTestTiming = callTeardownTiming{
+	timeout:    60 * time.Millisecond,
+	methodWait: 10 * time.Millisecond,
+	poll:       time.Millisecond,
+}
+
+// fakeCallDevice answers adb commands of call teardown like a gateway with a call in progress
+type fakeCallDevice struct {
+	mu       sync.Mutex
+	state    CallState
+	registry bool            // Reports state in dumpsys telephony.registry
+	telecom  bool            // Reports state in dumpsys telecom
+	endsCall map[string]bool // Termination methods that actually end the call
+	caller   string          // Number listed by emulator console
+	commands []string
+}
+
+func (d *fakeCallDevice) exec(cmd []string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	line := strings.Join(cmd, " ")
+	d.commands = append(d.commands, line)
+
+	switch {
+	case line == "adb shell dumpsys telephony.registry":
+		if !d.registry {
+			return "mServiceState=0", nil
+		}
+		codes := map[CallState]string{CallStateIdle: "0", CallStateRinging: "1", CallStateOffhook: "2"}
+		return "mCallState=0\nmCallState=" + codes[d.state], nil
+	case line == "adb shell dumpsys telecom":
+		if !d.telecom {
+			return "", errors.New("telecom service not found")
+		}
+		switch d.state {
+		case CallStateRinging:
+			return "Call id=1 state=RINGING", nil
+		case CallStateOffhook:
+			return "Call id=1 state=ACTIVE", nil
+		}
+		return "mCalls: none", nil
+	case line == "adb emu gsm list":
+		if d.state == CallStateIdle || d.caller == "" {
+			return "OK", nil
+		}
+		return "inbound from " + d.caller + " : incoming\nOK", nil
+	case strings.HasPrefix(line, "adb emu gsm cancel "):
+		d.end(callMethodGSMCancel)
+	case line == "adb shell input keyevent KEYCODE_ENDCALL":
+		d.end(callMethodEndCallKey)
+	case strings.HasPrefix(line, "adb shell am force-stop "):
+		d.end(callMethodForceStop)
+	}
+	return "OK", nil
+}
+
+func (d *fakeCallDevice) end(method string) {
+	if d.endsCall[method] {
+		d.state = CallStateIdle
+	}
+}
+
+func (d *fakeCallDevice) ran(prefix string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var matched []string
+	for _, command := range d.commands {
+		if strings.HasPrefix(command, prefix) {
+			matched = append(matched, command)
+		}
+	}
+	return matched
+}
+
+func TestTerminateCallIdleDevice(t *testing.T) {
+	device := &fakeCallDevice{state: CallStateIdle, registry: true}
+
+	teardown, err := terminateCall(device.exec, "79123456789", teardownTestTiming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teardown.InitialState != CallStateIdle || teardown.FinalState != CallStateIdle || len(teardown.Methods) != 0 {
+		t.Fatalf("teardown = %+v, want idle without methods", teardown)
+	}
+}
+
+func TestTerminateCallGracefulMethods(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    CallState
+		endsCall string
+		want     []string
+	}{
+		{"ringing cancelled on modem", CallStateRinging, callMethodGSMCancel, []string{callMethodGSMCancel}},
+		{"ringing needs end call key", CallStateRinging, callMethodEndCallKey, []string{callMethodGSMCancel, callMethodEndCallKey}},
+		{"answered hung up by key", CallStateOffhook, callMethodEndCallKey, []string{callMethodEndCallKey}},
+		{"answered needs modem cancel", CallStateOffhook, callMethodGSMCancel, []string{callMethodEndCallKey, callMethodGSMCancel}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := &fakeCallDevice{state: tt.state, registry: true, endsCall: map[string]bool{tt.endsCall: true}}
+
+			teardown, err := terminateCall(device.exec, "79123456789", teardownTestTiming)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if teardown.InitialState != tt.state || teardown.FinalState != CallStateIdle || teardown.Forced {
+				t.Fatalf("teardown = %+v", teardown)
+			}
+			if !reflect.DeepEqual(teardown.Methods, tt.want) {
+				t.Fatalf("methods = %v, want %v", teardown.Methods, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminateCallCancelsListedCalls(t *testing.T) {
+	device := &fakeCallDevice{
+		state:    CallStateRinging,
+		registry: true,
+		caller:   "+79990001122",
+		endsCall: map[string]bool{callMethodGSMCancel: true},
+	}
+
+	if _, err := terminateCall(device.exec, "79123456789", teardownTestTiming); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"adb emu gsm cancel 79123456789", "adb emu gsm cancel +79990001122"}
+	if got := device.ran("adb emu gsm cancel"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("cancel commands = %v, want %v", got, want)
+	}
+}
+
+func TestTerminateCallRetriesUntilTimeoutThenForceStops(t *testing.T) {
+	device := &fakeCallDevice{state: CallStateRinging, registry: true, endsCall: map[string]bool{callMethodForceStop: true}}
+
+	teardown, err := terminateCall(device.exec, "79123456789", teardownTestTiming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !teardown.Forced || teardown.FinalState != CallStateIdle {
+		t.Fatalf("teardown = %+v, want forced to idle", teardown)
+	}
+
+	methods := teardown.Methods
+	if len(methods) < 2 || methods[len(methods)-1] != callMethodForceStop {
+		t.Fatalf("methods = %v, want graceful attempts followed by force stop", methods)
+	}
+	for i, method := range methods[:len(methods)-1] {
+		want := callMethodsFor(CallStateRinging)[i%2]
+		if method != want {
+			t.Fatalf("method %d = %s, want %s alternating, all = %v", i, method, want, methods)
+		}
+	}
+	if got := len(device.ran("adb shell am force-stop")); got != len(inCallPackages) {
+		t.Fatalf("force-stopped %d packages, want %d", got, len(inCallPackages))
+	}
+}
+
+func TestTerminateCallNotEnded(t *testing.T) {
+	device := &fakeCallDevice{state: CallStateOffhook, registry: true}
+
+	teardown, err := terminateCall(device.exec, "79123456789", teardownTestTiming)
+	if !errors.Is(err, ErrCallNotEnded) {
+		t.Fatalf("error = %v, want ErrCallNotEnded", err)
+	}
+	if !teardown.Forced || teardown.FinalState != CallStateOffhook {
+		t.Fatalf("teardown = %+v", teardown)
+	}
+}
+
+func TestTerminateCallUnknownStateTriesEveryMethodOnce(t *testing.T) {
+	device := &fakeCallDevice{state: CallStateRinging, endsCall: map[string]bool{callMethodGSMCancel: true}}
+
+	teardown, err := terminateCall(device.exec, "79123456789", teardownTestTiming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teardown.InitialState != CallStateUnknown || teardown.FinalState != CallStateUnknown || teardown.Forced {
+		t.Fatalf("teardown = %+v", teardown)
+	}
+	if want := []string{callMethodGSMCancel, callMethodEndCallKey}; !reflect.DeepEqual(teardown.Methods, want) {
+		t.Fatalf("methods = %v, want %v", teardown.Methods, want)
+	}
+}
+
+func TestTerminateCallFallsBackToTelecom(t *testing.T) {
+	device := &fakeCallDevice{state: CallStateOffhook, telecom: true, endsCall: map[string]bool{callMethodEndCallKey: true}}
+
+	teardown, err := terminateCall(device.exec, "79123456789", teardownTestTiming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teardown.InitialState != CallStateOffhook || teardown.FinalState != CallStateIdle {
+		t.Fatalf("teardown = %+v", teardown)
+	}
+}
+
+func TestParseRegistryCallState(t *testing.T) {
+	tests := map[string]CallState{
+		"":                             CallStateUnknown,
+		"mCallState=0":                 CallStateIdle,
+		"mCallState=0\nmCallState=2":   CallStateOffhook,
+		"mCallState=2\nmCallState=1":   CallStateRinging,
+		"mCallState=0\n  mCallState=0": CallStateIdle,
+	}
+	for output, want := range tests {
+		if got := parseRegistryCallState(output); got != want {
+			t.Errorf("parseRegistryCallState(%q) = %s, want %s", output, got, want)
+		}
+	}
+}
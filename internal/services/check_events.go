@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// checkEventsSettingKey is the setting enabling check event log, off by default as it writes several rows per check
+const checkEventsSettingKey = "check_event_log_enabled"
+
+// Check event stages
+const (
+	CheckEventStarted         = "started"
+	CheckEventRetry           = "retry"
+	CheckEventCallSimulated   = "call_simulated"
+	CheckEventScreenshotTaken = "screenshot_taken"
+	CheckEventOCRDone         = "ocr_done"
+	CheckEventAPIResponse     = "api_response"
+	CheckEventVerdict         = "verdict"
+	CheckEventFailed          = "failed"
+)
+
+// checkTimeline writes events of one gateway or API check. A nil timeline records nothing,
+// so stages are recorded unconditionally and the setting is read once per check.
+type checkTimeline struct {
+	db  *gorm.DB
+	log *logrus.Entry
+
+	mu        sync.Mutex
+	event     models.CheckEvent // Template of the next event
+	startedAt time.Time
+	lastAt    time.Time
+}
+
+// checkTimelineKey is context key of the running check timeline
+type checkTimelineKey struct{}
+
+// newCheckTimeline starts timeline of a check on gateway or API service, nil when check event log is disabled
+func (s *CheckService) newCheckTimeline(ctx context.Context, phoneID uint, serviceCode string, gatewayID, apiServiceID *uint) *checkTimeline {
+	if !NewSettingsService(s.db).GetCachedBool(checkEventsSettingKey, false) {
+		return nil
+	}
+
+	service, err := s.getServiceByCode(serviceCode)
+	if err != nil {
+		logger.EntryWithContext(s.log, ctx).Warnf("Check events not recorded: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	return &checkTimeline{
+		db:  s.db,
+		log: logger.EntryWithContext(s.log, ctx),
+		event: models.CheckEvent{
+			RunID:         uuid.New().String(),
+			TraceID:       logger.TraceIDFromContext(ctx),
+			PhoneNumberID: phoneID,
+			ServiceID:     service.ID,
+			GatewayID:     gatewayID,
+			APIServiceID:  apiServiceID,
+		},
+		startedAt: now,
+		lastAt:    now,
+	}
+}
+
+// contextWithCheckTimeline returns context carrying timeline to the stages of the check
+func contextWithCheckTimeline(ctx context.Context, timeline *checkTimeline) context.Context {
+	if timeline == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, checkTimelineKey{}, timeline)
+}
+
+// checkTimelineFromContext returns timeline of the running check, nil if events are not recorded
+func checkTimelineFromContext(ctx context.Context) *checkTimeline {
+	if ctx == nil {
+		return nil
+	}
+	timeline, _ := ctx.Value(checkTimelineKey{}).(*checkTimeline)
+	return timeline
+}
+
+// record writes check stage with time since the check started and since the previous stage.
+// Failure to write is logged, it never fails the check.
+func (t *checkTimeline) record(stage, format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	event := t.event
+	event.Stage = stage
+	event.Message = fmt.Sprintf(format, args...)
+	event.ElapsedMs = now.Sub(t.startedAt).Milliseconds()
+	event.DurationMs = now.Sub(t.lastAt).Milliseconds()
+	event.CreatedAt = now
+	t.lastAt = now
+
+	if err := t.db.Create(&event).Error; err != nil {
+		t.log.Warnf("Failed to record check event %s: %v", stage, err)
+	}
+}
+
+// attach links events of the check, including later ones, to the result it saved
+func (t *checkTimeline) attach(resultID uint) {
+	if t == nil || resultID == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.event.CheckResultID = &resultID
+	if err := t.db.Model(&models.CheckEvent{}).Where("run_id = ?", t.event.RunID).
+		Update("check_result_id", resultID).Error; err != nil {
+		t.log.Warnf("Failed to link check events to result %d: %v", resultID, err)
+	}
+}
+
+// CheckTimeline is the recorded course of the check that produced a result
+type CheckTimeline struct {
+	ResultID uint                `json:"result_id"`
+	RunID    string              `json:"run_id,omitempty"`
+	TotalMs  int64               `json:"total_ms"`
+	Events   []models.CheckEvent `json:"events"`
+}
+
+// GetCheckTimeline returns events of the check that produced result. Events are empty
+// when check event log was disabled at the time of the check.
+func (s *CheckService) GetCheckTimeline(resultID uint) (*CheckTimeline, error) {
+	if err := s.db.Select("id").First(&models.CheckResult{}, resultID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("result not found")
+		}
+		return nil, fmt.Errorf("failed to get check result: %w", err)
+	}
+
+	timeline := &CheckTimeline{ResultID: resultID, Events: []models.CheckEvent{}}
+	if err := s.db.Where("check_result_id = ?", resultID).
+		Order("created_at ASC, id ASC").
+		Find(&timeline.Events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get check events: %w", err)
+	}
+
+	if len(timeline.Events) > 0 {
+		last := timeline.Events[len(timeline.Events)-1]
+		timeline.RunID = last.RunID
+		timeline.TotalMs = last.ElapsedMs
+	}
+	return timeline, nil
+}
@@ -0,0 +1,506 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// historyImportBatchSize defines how many rows are inserted per transaction
+const historyImportBatchSize = 500
+
+// historyTimeLayouts lists accepted checked_at formats
+var historyTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02.01.2006",
+}
+
+// HistoryImportRecord represents a single historical check result from the legacy tracker
+type HistoryImportRecord struct {
+	ExternalID  string   `json:"external_id"`
+	Number      string   `json:"number"`
+	ServiceCode string   `json:"service_code"`
+	IsSpam      bool     `json:"is_spam"`
+	Keywords    []string `json:"keywords"`
+	CheckedAt   string   `json:"checked_at"`
+	Note        string   `json:"note"`
+}
+
+// HistoryImportBatch represents progress of a single import batch
+type HistoryImportBatch struct {
+	Batch    int    `json:"batch"`
+	Rows     int    `json:"rows"`
+	Imported int    `json:"imported"`
+	Skipped  int    `json:"skipped"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HistoryImportReport represents result of historical results import
+type HistoryImportReport struct {
+	Total    int                  `json:"total"`
+	Imported int                  `json:"imported"`
+	Skipped  int                  `json:"skipped"` // Already imported earlier
+	Failed   int                  `json:"failed"`
+	Batches  []HistoryImportBatch `json:"batches"`
+	Errors   []string             `json:"errors,omitempty"`
+}
+
+// historyRow is a validated import row
+type historyRow struct {
+	line      int
+	number    string
+	serviceID uint
+	isSpam    bool
+	keywords  []string
+	checkedAt time.Time
+	note      string
+	importKey string
+}
+
+// ImportHistoricalResults imports historical check results from CSV or JSON.
+// Rows are deduplicated by external ID or content hash, so the same file can be imported again safely.
+func (s *CheckService) ImportHistoricalResults(reader io.Reader, format string, userID uint) (*HistoryImportReport, error) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "ImportHistoricalResults",
+		"format": format,
+	})
+
+	var records []HistoryImportRecord
+	var lines []int
+	var err error
+	switch format {
+	case "json":
+		records, lines, err = parseHistoryJSON(reader)
+	case "csv":
+		records, lines, err = parseHistoryCSV(reader)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var services []models.SpamService
+	if err := s.db.Find(&services).Error; err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	serviceIDs := make(map[string]uint, len(services))
+	for _, service := range services {
+		serviceIDs[service.Code] = service.ID
+	}
+
+	report := &HistoryImportReport{
+		Total:   len(records),
+		Batches: []HistoryImportBatch{},
+	}
+
+	// Validate all rows before writing anything
+	phoneService := NewPhoneService(s.db)
+	now := time.Now()
+	var rows []historyRow
+	for i, record := range records {
+		row, err := validateHistoryRecord(record, serviceIDs, phoneService, now)
+		if err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, fmt.Sprintf("Line %d: %v", lines[i], err))
+			continue
+		}
+		row.line = lines[i]
+		rows = append(rows, *row)
+	}
+
+	phoneIDs := make(map[string]uint)
+	seenKeys := make(map[string]bool)
+	batchCount := (len(rows) + historyImportBatchSize - 1) / historyImportBatchSize
+
+	for start := 0; start < len(rows); start += historyImportBatchSize {
+		end := start + historyImportBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		batch := HistoryImportBatch{
+			Batch: len(report.Batches) + 1,
+			Rows:  end - start,
+		}
+
+		var rowErrors []string
+		var batchKeys map[string]bool
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			batch.Imported, batch.Skipped, batchKeys, rowErrors, err = importHistoryBatch(tx, rows[start:end], phoneIDs, seenKeys, userID)
+			return err
+		})
+		if err != nil {
+			// Whole batch is rolled back, phones created in it must be resolved again
+			phoneIDs = make(map[string]uint)
+			batch.Imported = 0
+			batch.Skipped = 0
+			batch.Error = err.Error()
+			report.Failed += batch.Rows
+			log.Errorf("Batch %d/%d failed: %v", batch.Batch, batchCount, err)
+		} else {
+			for key := range batchKeys {
+				seenKeys[key] = true
+			}
+			report.Imported += batch.Imported
+			report.Skipped += batch.Skipped
+			report.Failed += len(rowErrors)
+			report.Errors = append(report.Errors, rowErrors...)
+			log.Infof("Batch %d/%d: imported %d, skipped %d", batch.Batch, batchCount, batch.Imported, batch.Skipped)
+		}
+
+		report.Batches = append(report.Batches, batch)
+	}
+
+	log.Infof("Imported %d historical results, skipped %d, failed %d", report.Imported, report.Skipped, report.Failed)
+
+	return report, nil
+}
+
+// importHistoryBatch inserts batch rows, skipping rows imported before.
+// Returns keys seen in the batch, which the caller keeps only if the batch is committed.
+func importHistoryBatch(tx *gorm.DB, rows []historyRow, phoneIDs map[string]uint, seenKeys map[string]bool, userID uint) (int, int, map[string]bool, []string, error) {
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		keys[i] = row.importKey
+	}
+
+	var existing []string
+	if err := tx.Model(&models.CheckResult{}).Where("import_key IN ?", keys).Pluck("import_key", &existing).Error; err != nil {
+		return 0, 0, nil, nil, fmt.Errorf("failed to check imported rows: %w", err)
+	}
+	batchKeys := make(map[string]bool, len(rows))
+	for _, key := range existing {
+		batchKeys[key] = true
+	}
+
+	imported := 0
+	skipped := 0
+	var rowErrors []string
+	for _, row := range rows {
+		if seenKeys[row.importKey] || batchKeys[row.importKey] {
+			skipped++
+			continue
+		}
+
+		phoneID, err := resolveHistoryPhone(tx, row.number, phoneIDs, userID)
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("Line %d: %v", row.line, err))
+			continue
+		}
+
+		importKey := row.importKey
+		result := &models.CheckResult{
+			PhoneNumberID: phoneID,
+			ServiceID:     row.serviceID,
+			IsSpam:        row.isSpam,
+			Status:        spamStatusOf(row.isSpam),
+			FoundKeywords: models.StringArray(row.keywords),
+			Source:        models.CheckSourceImport,
+			ImportKey:     &importKey,
+			Note:          row.note,
+			CheckedAt:     row.checkedAt,
+		}
+		if err := tx.Create(result).Error; err != nil {
+			return 0, 0, nil, nil, fmt.Errorf("failed to save result from line %d: %w", row.line, err)
+		}
+
+		if err := updateStatisticsAtInTx(tx, phoneID, row.serviceID, spamStatusOf(row.isSpam), row.checkedAt); err != nil {
+			return 0, 0, nil, nil, fmt.Errorf("failed to update statistics from line %d: %w", row.line, err)
+		}
+
+		batchKeys[row.importKey] = true
+		imported++
+	}
+
+	return imported, skipped, batchKeys, rowErrors, nil
+}
+
+// resolveHistoryPhone finds phone by normalized number or creates an inactive one
+func resolveHistoryPhone(tx *gorm.DB, number string, phoneIDs map[string]uint, userID uint) (uint, error) {
+	if id, ok := phoneIDs[number]; ok {
+		return id, nil
+	}
+
+	var phone models.PhoneNumber
+	err := tx.Unscoped().Where("number = ? OR normalized_number = ?", number, number).First(&phone).Error
+	switch {
+	case err == nil:
+		if phone.DeletedAt.Valid {
+			return 0, errors.New("phone number was deleted")
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// Imported numbers are not checked until an operator activates them
+		normalized := number
+		phone = models.PhoneNumber{
+			Number:           number,
+			NormalizedNumber: &normalized,
+			Description:      "Imported from check history",
+			CreatedBy:        userID,
+		}
+		if err := tx.Create(&phone).Error; err != nil {
+			return 0, fmt.Errorf("failed to create phone: %w", err)
+		}
+		if err := tx.Model(&phone).Update("is_active", false).Error; err != nil {
+			return 0, fmt.Errorf("failed to create phone: %w", err)
+		}
+	default:
+		return 0, fmt.Errorf("failed to get phone: %w", err)
+	}
+
+	phoneIDs[number] = phone.ID
+	return phone.ID, nil
+}
+
+// validateHistoryRecord validates record and computes its import key
+func validateHistoryRecord(record HistoryImportRecord, serviceIDs map[string]uint, phoneService *PhoneService, now time.Time) (*historyRow, error) {
+	number := phoneService.normalizePhoneNumber(record.Number)
+	if number == "" {
+		return nil, errors.New("empty phone number")
+	}
+
+	code := strings.TrimSpace(record.ServiceCode)
+	serviceID, ok := serviceIDs[code]
+	if !ok {
+		return nil, fmt.Errorf("unknown service %q", code)
+	}
+
+	checkedAt, err := parseHistoryTime(record.CheckedAt)
+	if err != nil {
+		return nil, err
+	}
+	if checkedAt.After(now) {
+		return nil, fmt.Errorf("checked_at %s is in the future", record.CheckedAt)
+	}
+
+	var keywords []string
+	for _, keyword := range record.Keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	row := &historyRow{
+		number:    number,
+		serviceID: serviceID,
+		isSpam:    record.IsSpam,
+		keywords:  keywords,
+		checkedAt: checkedAt,
+		note:      strings.TrimSpace(record.Note),
+	}
+
+	// External ID wins, otherwise identical rows are the same result
+	var key string
+	if externalID := strings.TrimSpace(record.ExternalID); externalID != "" {
+		key = "ext|" + externalID
+	} else {
+		sorted := append([]string(nil), keywords...)
+		sort.Strings(sorted)
+		key = fmt.Sprintf("row|%s|%s|%s|%t|%s", number, code, checkedAt.UTC().Format(time.RFC3339), record.IsSpam, strings.Join(sorted, ","))
+	}
+	sum := sha256.Sum256([]byte(key))
+	row.importKey = hex.EncodeToString(sum[:])
+
+	return row, nil
+}
+
+// parseHistoryTime parses checked_at in one of accepted layouts, local time zone is used when missing
+func parseHistoryTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("empty checked_at")
+	}
+
+	for _, layout := range historyTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid checked_at %q", value)
+}
+
+// parseHistoryBool parses is_spam column value
+func parseHistoryBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "y", "spam", "да":
+		return true, nil
+	case "0", "false", "no", "n", "clean", "нет", "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid is_spam %q", value)
+	}
+}
+
+// parseHistoryCSV reads records from CSV with header row
+func parseHistoryCSV(reader io.Reader) ([]HistoryImportRecord, []int, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(col))
+		switch col {
+		case "number", "phone", "phone_number", "номер", "телефон":
+			columns["number"] = i
+		case "service", "service_code":
+			columns["service_code"] = i
+		case "is_spam", "spam":
+			columns["is_spam"] = i
+		case "keywords":
+			columns["keywords"] = i
+		case "checked_at", "date":
+			columns["checked_at"] = i
+		case "note", "comment":
+			columns["note"] = i
+		case "external_id", "id":
+			columns["external_id"] = i
+		}
+	}
+
+	for _, required := range []string{"number", "service_code", "is_spam", "checked_at"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("%s column not found in CSV", required)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		if idx, ok := columns[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+
+	var records []HistoryImportRecord
+	var lines []int
+	for lineNum := 2; ; lineNum++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		isSpam, err := parseHistoryBool(column(record, "is_spam"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		// Keywords are separated by semicolon or pipe
+		keywords := strings.FieldsFunc(column(record, "keywords"), func(r rune) bool {
+			return r == ';' || r == '|'
+		})
+
+		records = append(records, HistoryImportRecord{
+			ExternalID:  column(record, "external_id"),
+			Number:      column(record, "number"),
+			ServiceCode: column(record, "service_code"),
+			IsSpam:      isSpam,
+			Keywords:    keywords,
+			CheckedAt:   column(record, "checked_at"),
+			Note:        column(record, "note"),
+		})
+		lines = append(lines, lineNum)
+	}
+
+	return records, lines, nil
+}
+
+// parseHistoryJSON reads records from JSON array
+func parseHistoryJSON(reader io.Reader) ([]HistoryImportRecord, []int, error) {
+	var records []HistoryImportRecord
+	if err := json.NewDecoder(reader).Decode(&records); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	// Array positions are reported as line numbers
+	lines := make([]int, len(records))
+	for i := range records {
+		lines[i] = i + 1
+	}
+
+	return records, lines, nil
+}
+
+// DeleteImportedResults deletes imported check results and rebuilds affected statistics.
+// Empty service code and zero since delete all imported rows.
+func (s *CheckService) DeleteImportedResults(serviceCode string, since time.Time) (int64, error) {
+	log := s.log.WithFields(logrus.Fields{
+		"method":      "DeleteImportedResults",
+		"serviceCode": serviceCode,
+	})
+
+	query := s.db.Model(&models.CheckResult{}).Where("source = ?", models.CheckSourceImport)
+	if serviceCode != "" {
+		var service models.SpamService
+		if err := s.db.Where("code = ?", serviceCode).First(&service).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, fmt.Errorf("unknown service %q", serviceCode)
+			}
+			return 0, fmt.Errorf("failed to get service: %w", err)
+		}
+		query = query.Where("service_id = ?", service.ID)
+	}
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+
+	var phoneIDs []uint
+	if err := query.Session(&gorm.Session{}).Distinct("phone_number_id").Pluck("phone_number_id", &phoneIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to get imported results: %w", err)
+	}
+
+	var deleted int64
+	rebuilt := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockStatistics(tx); err != nil {
+			return err
+		}
+
+		result := tx.Where("id IN (?)", query.Session(&gorm.Session{}).Select("id")).Delete(&models.CheckResult{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete imported results: %w", result.Error)
+		}
+		deleted = result.RowsAffected
+
+		// Statistics of affected phones are recomputed the same way as by a full rebuild
+		for start := 0; start < len(phoneIDs); start += statisticsRebuildBatchSize {
+			rows, _, err := rebuildPhoneStatisticsInTx(tx, phoneIDs[start:min(start+statisticsRebuildBatchSize, len(phoneIDs))])
+			if err != nil {
+				return err
+			}
+			rebuilt += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Deleted %d imported results, rebuilt %d statistics rows of %d phones", deleted, rebuilt, len(phoneIDs))
+
+	return deleted, nil
+}
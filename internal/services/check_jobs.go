@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrCheckJobRunning is returned when a check job of the same kind has not finished yet
+var ErrCheckJobRunning = errors.New("check job is already running")
+
+// spamPhonesQuery returns IDs of active phones whose overall verdict is spam
+func spamPhonesQuery(db *gorm.DB) *gorm.DB {
+	return db.Table("(?) AS pv", phoneVerdictsQuery(db)).
+		Select("pv.phone_number_id").
+		Where("pv.is_spam = 1")
+}
+
+// RecheckSpamPhones starts background check of active phones whose overall verdict is spam.
+// While such a job runs it is returned along with ErrCheckJobRunning instead of starting another.
+func (s *CheckService) RecheckSpamPhones(userID uint) (*models.CheckJob, error) {
+	s.checkJobMutex.Lock()
+	defer s.checkJobMutex.Unlock()
+
+	if running, err := s.runningCheckJob(models.CheckJobRecheckSpam); err != nil || running != nil {
+		return running, err
+	}
+
+	var phones []models.PhoneNumber
+	if err := s.db.Where("is_active = ? AND blocked = ?", true, false).
+		Where("id IN (?)", spamPhonesQuery(s.db)).
+		Order("id").
+		Find(&phones).Error; err != nil {
+		return nil, fmt.Errorf("failed to get spam phones: %w", err)
+	}
+
+	job := &models.CheckJob{
+		Kind:        models.CheckJobRecheckSpam,
+		Status:      models.CheckJobPending,
+		TotalPhones: len(phones),
+		Instance:    instanceID,
+		CreatedBy:   userID,
+	}
+	if len(phones) == 0 {
+		now := time.Now()
+		job.Status = models.CheckJobCompleted
+		job.StartedAt = &now
+		job.CompletedAt = &now
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create check job: %w", err)
+	}
+
+	if len(phones) > 0 {
+		go s.runCheckJob(*job, phones)
+	}
+	return job, nil
+}
+
+// runningCheckJob returns unfinished job of kind along with ErrCheckJobRunning, nil when there is none
+func (s *CheckService) runningCheckJob(kind string) (*models.CheckJob, error) {
+	var running models.CheckJob
+	err := s.db.Where("kind = ? AND status IN ?", kind, []string{models.CheckJobPending, models.CheckJobRunning}).
+		Order("id DESC").
+		First(&running).Error
+	if err == nil {
+		return &running, ErrCheckJobRunning
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get running check job: %w", err)
+	}
+	return nil, nil
+}
+
+// startCheckJob marks job running
+func (s *CheckService) startCheckJob(log *logrus.Entry, job *models.CheckJob) {
+	now := time.Now()
+	job.Status = models.CheckJobRunning
+	job.StartedAt = &now
+	if err := s.db.Model(job).Updates(map[string]interface{}{
+		"status":     job.Status,
+		"started_at": job.StartedAt,
+	}).Error; err != nil {
+		log.Errorf("Failed to start check job: %v", err)
+	}
+}
+
+// completeCheckJob marks job completed
+func (s *CheckService) completeCheckJob(log *logrus.Entry, job *models.CheckJob) {
+	completed := time.Now()
+	if err := s.db.Model(&models.CheckJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":       models.CheckJobCompleted,
+		"completed_at": &completed,
+	}).Error; err != nil {
+		log.Errorf("Failed to complete check job: %v", err)
+	}
+}
+
+// runCheckJob checks phones of job with max_concurrent_checks workers and records progress after every phone
+func (s *CheckService) runCheckJob(job models.CheckJob, phones []models.PhoneNumber) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "runCheckJob",
+		"job_id": job.ID,
+		"kind":   job.Kind,
+	})
+
+	s.startCheckJob(log, &job)
+
+	log.Infof("Re-checking %d spam phones", len(phones))
+
+	workChan := make(chan models.PhoneNumber, len(phones))
+	for _, phone := range phones {
+		workChan <- phone
+	}
+	close(workChan)
+
+	var mu sync.Mutex // Guards job counters
+	var wg sync.WaitGroup
+	for i := 0; i < s.maxConcurrentChecks(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for phone := range workChan {
+				err := s.CheckPhoneNumber(phone.ID)
+				if err != nil && !errors.Is(err, ErrCheckInProgress) {
+					log.Warnf("Failed to re-check phone %s: %v", phone.Number, err)
+				}
+				stillSpam := err == nil && s.isSpamPhone(phone.ID)
+
+				mu.Lock()
+				job.CheckedPhones++
+				switch {
+				case err != nil:
+					job.FailedPhones++
+				case stillSpam:
+					job.StillSpam++
+				default:
+					job.Cleaned++
+				}
+				progress := map[string]interface{}{
+					"checked_phones": job.CheckedPhones,
+					"failed_phones":  job.FailedPhones,
+					"still_spam":     job.StillSpam,
+					"cleaned":        job.Cleaned,
+				}
+				mu.Unlock()
+
+				if err := s.db.Model(&models.CheckJob{}).Where("id = ?", job.ID).Updates(progress).Error; err != nil {
+					log.Warnf("Failed to update check job progress: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.completeCheckJob(log, &job)
+	log.Infof("Spam re-check completed: %d still spam, %d cleaned, %d failed", job.StillSpam, job.Cleaned, job.FailedPhones)
+}
+
+// isSpamPhone reports whether overall verdict of phone is spam
+func (s *CheckService) isSpamPhone(phoneID uint) bool {
+	var count int64
+	if err := s.db.Table("(?) AS spam", spamPhonesQuery(s.db)).
+		Where("phone_number_id = ?", phoneID).
+		Count(&count).Error; err != nil {
+		s.log.Warnf("Failed to get verdict of phone %d: %v", phoneID, err)
+		return false
+	}
+	return count > 0
+}
+
+// GetCheckJob returns check job by ID
+func (s *CheckService) GetCheckJob(id uint) (*models.CheckJob, error) {
+	var job models.CheckJob
+	if err := s.db.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("check job not found")
+		}
+		return nil, fmt.Errorf("failed to get check job: %w", err)
+	}
+	return &job, nil
+}
+
+// FailInterruptedCheckJobs marks check jobs left unfinished by a stopped instance as failed,
+// jobs of instances still running are kept. Check jobs are not resumed, the operator starts a new one.
+func (s *CheckService) FailInterruptedCheckJobs() (int64, error) {
+	var jobs []models.CheckJob
+	if err := s.db.Select("id, instance").
+		Where("status IN ?", []string{models.CheckJobPending, models.CheckJobRunning}).
+		Find(&jobs).Error; err != nil {
+		return 0, fmt.Errorf("failed to get unfinished check jobs: %w", err)
+	}
+
+	var interrupted []uint
+	for _, job := range jobs {
+		if job.Instance != instanceID && !InstanceAlive(s.db, job.Instance) {
+			interrupted = append(interrupted, job.ID)
+		}
+	}
+	if len(interrupted) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	result := s.db.Model(&models.CheckJob{}).
+		Where("id IN ? AND status IN ?", interrupted, []string{models.CheckJobPending, models.CheckJobRunning}).
+		Updates(map[string]interface{}{
+			"status":       models.CheckJobFailed,
+			"error":        "interrupted by restart",
+			"completed_at": &now,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to fail interrupted check jobs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API service roles in a check plan
+const (
+	PlanRoleCall     = "call"     // Called along with every other provider of the service
+	PlanRolePrimary  = "primary"  // Called first under first_success policy
+	PlanRoleFallback = "fallback" // Called only if providers before it fail
+)
+
+// PlannedGateway is an ADB gateway considered by a check plan
+type PlannedGateway struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	ServiceCode string `json:"service_code"`
+	Status      string `json:"status"`
+	IsActive    bool   `json:"is_active"`
+	Selected    bool   `json:"selected"`
+	Reason      string `json:"reason,omitempty"` // Why the gateway is not used
+	// Seconds the check would wait for minimum interval since the previous call on the gateway
+	CallWaitSeconds int `json:"call_wait_seconds,omitempty"`
+}
+
+// PlannedAPIService is an API service considered by a check plan
+type PlannedAPIService struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	ServiceCode string `json:"service_code"`
+	Priority    int    `json:"priority"`
+	IsActive    bool   `json:"is_active"`
+	Selected    bool   `json:"selected"`
+	Role        string `json:"role,omitempty"` // call, primary or fallback
+	Reason      string `json:"reason,omitempty"`
+}
+
+// CheckPlan describes what a check of a phone would exercise with the current configuration
+type CheckPlan struct {
+	PhoneNumber string           `json:"phone_number"`
+	PhoneID     *uint            `json:"phone_id,omitempty"` // Empty when the number is not registered
+	PhoneActive bool             `json:"phone_active"`       // Scheduled checks skip inactive phones
+	Blocked     bool             `json:"blocked"`            // Blocked phones are never checked
+	CheckMode   models.CheckMode `json:"check_mode"`
+	ModeSource  string           `json:"mode_source"` // setting or request
+	ServiceCode string           `json:"service_code,omitempty"`
+
+	Gateways    []PlannedGateway    `json:"gateways"`
+	APIServices []PlannedAPIService `json:"api_services"`
+
+	// Active spam services that no selected gateway or API service would check
+	UncoveredServices []string `json:"uncovered_services"`
+	// Why the check would fail or skip the phone as a whole
+	Problems []string `json:"problems"`
+}
+
+// PlanCheck returns gateways and API services a check of phone would use without running it.
+// Options narrow the plan the same way they narrow the check.
+func (s *CheckService) PlanCheck(phoneNumber string, opts PhoneCheckOptions) (*CheckPlan, error) {
+	if opts.Mode != "" {
+		if err := validateCheckMode(opts.Mode); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ServiceCode != "" {
+		if err := validateServiceCodeExists(s.db, opts.ServiceCode); err != nil {
+			return nil, err
+		}
+	}
+
+	number := NewPhoneService(s.db).normalizePhoneNumber(phoneNumber)
+	if number == "" {
+		return nil, errors.New("phone number is required")
+	}
+
+	plan := &CheckPlan{
+		PhoneNumber:       number,
+		CheckMode:         opts.Mode,
+		ModeSource:        "request",
+		ServiceCode:       opts.ServiceCode,
+		Gateways:          []PlannedGateway{},
+		APIServices:       []PlannedAPIService{},
+		UncoveredServices: []string{},
+		Problems:          []string{},
+	}
+	if plan.CheckMode == "" {
+		plan.CheckMode = s.getCheckMode()
+		plan.ModeSource = "setting"
+	}
+
+	var phone models.PhoneNumber
+	err := s.db.Where("number = ? OR normalized_number = ?", number, number).First(&phone).Error
+	switch {
+	case err == nil:
+		plan.PhoneNumber = phone.Number
+		plan.PhoneID = &phone.ID
+		plan.PhoneActive = phone.IsActive
+		plan.Blocked = phone.Blocked
+		if phone.Blocked {
+			plan.Problems = append(plan.Problems, "phone is blocked and will not be checked")
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		plan.Problems = append(plan.Problems, "phone is not registered, only a realtime check can check it")
+	default:
+		return nil, fmt.Errorf("failed to get phone: %w", err)
+	}
+
+	useADB := plan.CheckMode == models.CheckModeADBOnly || plan.CheckMode == models.CheckModeBoth
+	useAPI := plan.CheckMode == models.CheckModeAPIOnly || plan.CheckMode == models.CheckModeBoth
+
+	covered := make(map[string]bool)
+
+	var gateways []models.ADBGateway
+	if err := s.db.Order("id").Find(&gateways).Error; err != nil {
+		return nil, fmt.Errorf("failed to get gateways: %w", err)
+	}
+	for i := range gateways {
+		gateway := &gateways[i]
+		planned := PlannedGateway{
+			ID:          gateway.ID,
+			Name:        gateway.Name,
+			ServiceCode: gateway.ServiceCode,
+			Status:      gateway.Status,
+			IsActive:    gateway.IsActive,
+		}
+		switch {
+		case !useADB:
+			planned.Reason = fmt.Sprintf("check mode %s does not use ADB gateways", plan.CheckMode)
+		case opts.ServiceCode != "" && gateway.ServiceCode != opts.ServiceCode:
+			planned.Reason = fmt.Sprintf("check is limited to service %s", opts.ServiceCode)
+		case !gateway.IsActive:
+			planned.Reason = "gateway is inactive"
+		case gateway.Status != "online":
+			planned.Reason = fmt.Sprintf("gateway is %s", gateway.Status)
+		default:
+			planned.Selected = true
+			covered[gateway.ServiceCode] = true
+			wait, _ := s.callSpacing.remaining(gateway.ID, s.minCallInterval(gateway), time.Now())
+			planned.CallWaitSeconds = int(wait.Round(time.Second) / time.Second)
+		}
+		plan.Gateways = append(plan.Gateways, planned)
+	}
+
+	var apiServices []models.APIService
+	if err := s.db.Order("service_code, priority, id").Find(&apiServices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API services: %w", err)
+	}
+	policies, err := s.apiService.GetAPIPolicies()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groupAPIServices(apiServices) {
+		selected := 0
+		for _, api := range group {
+			planned := PlannedAPIService{
+				ID:          api.ID,
+				Name:        api.Name,
+				ServiceCode: api.ServiceCode,
+				Priority:    api.Priority,
+				IsActive:    api.IsActive,
+			}
+			switch {
+			case !useAPI:
+				planned.Reason = fmt.Sprintf("check mode %s does not use API services", plan.CheckMode)
+			case opts.ServiceCode != "" && api.ServiceCode != opts.ServiceCode:
+				planned.Reason = fmt.Sprintf("check is limited to service %s", opts.ServiceCode)
+			case !api.IsActive:
+				planned.Reason = "API service is inactive"
+			default:
+				planned.Selected = true
+				planned.Role = PlanRoleCall
+				if policies[api.ServiceCode] == models.APIPolicyFirstSuccess {
+					planned.Role = PlanRolePrimary
+					if selected > 0 {
+						planned.Role = PlanRoleFallback
+					}
+				}
+				selected++
+				covered[api.ServiceCode] = true
+			}
+			plan.APIServices = append(plan.APIServices, planned)
+		}
+	}
+
+	// Same errors the check would return when it has nothing to call
+	if useADB && !anyGatewaySelected(plan.Gateways) {
+		plan.Problems = append(plan.Problems, noTargetsProblem("ADB gateways", opts.ServiceCode))
+	}
+	if useAPI && !anyAPIServiceSelected(plan.APIServices) {
+		plan.Problems = append(plan.Problems, noTargetsProblem("API services", opts.ServiceCode))
+	}
+
+	var spamServices []models.SpamService
+	query := s.db.Where("is_active = ?", true)
+	if opts.ServiceCode != "" {
+		query = query.Where("code = ?", opts.ServiceCode)
+	}
+	if err := query.Order("id").Find(&spamServices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get spam services: %w", err)
+	}
+	for _, service := range spamServices {
+		if !covered[service.Code] {
+			plan.UncoveredServices = append(plan.UncoveredServices, service.Code)
+		}
+	}
+
+	return plan, nil
+}
+
+// noTargetsProblem describes a check source without anything to call
+func noTargetsProblem(targets, serviceCode string) string {
+	if serviceCode != "" {
+		return fmt.Sprintf("no active %s available for service %s", targets, serviceCode)
+	}
+	return fmt.Sprintf("no active %s available", targets)
+}
+
+func anyGatewaySelected(gateways []PlannedGateway) bool {
+	for _, gateway := range gateways {
+		if gateway.Selected {
+			return true
+		}
+	}
+	return false
+}
+
+func anyAPIServiceSelected(apiServices []PlannedAPIService) bool {
+	for _, api := range apiServices {
+		if api.Selected {
+			return true
+		}
+	}
+	return false
+}
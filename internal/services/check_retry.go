@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// defaultRetryBudget is used when check_retry_budget setting is missing
+const defaultRetryBudget = 6
+
+// checkRetryPolicy limits retries during a single phone check.
+//
+// Every gateway and API service always gets its first attempt. Each retry
+// after that must be allowed by both the per-layer limit (ADBMaxRetries for
+// gateways, APIMaxRetries for API services) and the shared per-phone budget.
+// The budget is consumed by retries of all gateways and API services of the
+// check, so in "both" mode the total number of attempts for a phone is at most
+// gateways + API services + budget, regardless of per-layer limits.
+type checkRetryPolicy struct {
+	ADBMaxRetries int
+	APIMaxRetries int
+	budget        int64
+}
+
+type retryPolicyKey struct{}
+
+// getRetryPolicy builds retry policy from check tuning and system settings
+func (s *CheckService) getRetryPolicy() *checkRetryPolicy {
+	settingsService := NewSettingsService(s.db)
+
+	policy := &checkRetryPolicy{
+		ADBMaxRetries: s.tuning.ADBMaxRetries(),
+		APIMaxRetries: s.tuning.APIMaxRetries(),
+		budget:        defaultRetryBudget,
+	}
+
+	if value, err := settingsService.GetSettingValue("check_retry_budget"); err == nil {
+		if budget, ok := value.(int); ok && budget >= 0 {
+			policy.budget = int64(budget)
+		}
+	}
+
+	return policy
+}
+
+// takeRetry consumes one retry from the shared budget
+func (p *checkRetryPolicy) takeRetry() bool {
+	if atomic.AddInt64(&p.budget, -1) >= 0 {
+		return true
+	}
+	atomic.AddInt64(&p.budget, 1)
+	return false
+}
+
+// contextWithRetryPolicy returns context carrying retry policy
+func contextWithRetryPolicy(ctx context.Context, policy *checkRetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// retryPolicyFromContext returns retry policy of the check, falling back to service defaults
+func (s *CheckService) retryPolicyFromContext(ctx context.Context) *checkRetryPolicy {
+	if ctx != nil {
+		if policy, ok := ctx.Value(retryPolicyKey{}).(*checkRetryPolicy); ok {
+			return policy
+		}
+	}
+
+	return &checkRetryPolicy{
+		ADBMaxRetries: s.tuning.ADBMaxRetries(),
+		APIMaxRetries: s.tuning.APIMaxRetries(),
+		budget:        int64(s.tuning.ADBMaxRetries()),
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Settings of sampling in the default interval check
+const (
+	sampleSizeSettingKey         = "check_sample_size"
+	samplePercentSettingKey      = "check_sample_percent"
+	sampleMaxStalenessSettingKey = "check_sample_max_staleness_hours"
+)
+
+// Sampling limits
+const (
+	maxSampleSize              = 100000
+	defaultSampleMaxStaleness  = 24
+	maxSampleMaxStalenessHours = maxResultAgeHours
+)
+
+// CheckSampling makes a run check a random subset of phones instead of all of them.
+// Phones whose latest verdict would be older than MaxStaleness by the next run are always
+// included, so every phone is still checked at least once per MaxStaleness.
+type CheckSampling struct {
+	Size         int           // Phones per run, 0 uses Percent
+	Percent      int           // Percent of phones per run
+	MaxStaleness time.Duration // Coverage guarantee window
+}
+
+// Enabled reports whether run samples phones, zero sampling is a full sweep
+func (c CheckSampling) Enabled() bool {
+	return c.Size > 0 || c.Percent > 0
+}
+
+// target returns number of phones to pick from total, rounding percent up so a sample is never empty
+func (c CheckSampling) target(total int) int {
+	target := c.Size
+	if target == 0 {
+		target = (total*c.Percent + 99) / 100
+	}
+	if target > total {
+		return total
+	}
+	return target
+}
+
+// Guarantee describes coverage guarantee of sampling for run records
+func (c CheckSampling) Guarantee() string {
+	return fmt.Sprintf("every active phone is checked at least once per %s", c.MaxStaleness)
+}
+
+// validateCheckSampling checks sample size, percent and staleness window of a schedule or the default check
+func validateCheckSampling(size, percent, maxStalenessHours int) error {
+	if size < 0 || size > maxSampleSize {
+		return fmt.Errorf("sample size must be between 0 and %d", maxSampleSize)
+	}
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("sample percent must be between 0 and 100")
+	}
+	if size > 0 && percent > 0 {
+		return fmt.Errorf("set either sample size or sample percent, not both")
+	}
+	if maxStalenessHours < 0 || maxStalenessHours > maxSampleMaxStalenessHours {
+		return fmt.Errorf("sample max staleness must be between 0 and %d hours", maxSampleMaxStalenessHours)
+	}
+	return nil
+}
+
+// defaultSampleMaxStalenessHours returns staleness window used when sampling leaves it unset
+func defaultSampleMaxStalenessHours(db *gorm.DB) int {
+	hours := NewSettingsService(db).GetCachedInt(sampleMaxStalenessSettingKey, defaultSampleMaxStaleness)
+	if hours < 1 || hours > maxSampleMaxStalenessHours {
+		return defaultSampleMaxStaleness
+	}
+	return hours
+}
+
+// DefaultCheckSampling returns sampling of the default interval check from settings
+func DefaultCheckSampling(db *gorm.DB) CheckSampling {
+	settings := NewSettingsService(db)
+	size := settings.GetCachedInt(sampleSizeSettingKey, 0)
+	percent := settings.GetCachedInt(samplePercentSettingKey, 0)
+	if validateCheckSampling(size, percent, 0) != nil {
+		return CheckSampling{}
+	}
+	return CheckSampling{
+		Size:         size,
+		Percent:      percent,
+		MaxStaleness: time.Duration(defaultSampleMaxStalenessHours(db)) * time.Hour,
+	}
+}
+
+// ScheduleCheckSampling returns sampling of a check schedule, unset window uses check_sample_max_staleness_hours
+func ScheduleCheckSampling(db *gorm.DB, schedule *models.CheckSchedule) CheckSampling {
+	if schedule.SampleSize <= 0 && schedule.SamplePercent <= 0 {
+		return CheckSampling{}
+	}
+	hours := schedule.SampleMaxStalenessHours
+	if hours <= 0 {
+		hours = defaultSampleMaxStalenessHours(db)
+	}
+	return CheckSampling{
+		Size:         schedule.SampleSize,
+		Percent:      schedule.SamplePercent,
+		MaxStaleness: time.Duration(hours) * time.Hour,
+	}
+}
+
+// samplingMaxStaleness returns the longest staleness window of enabled sampling, 0 if nothing samples.
+// Verdicts younger than it are expected between checks and must not be reported stale.
+func samplingMaxStaleness(db *gorm.DB) time.Duration {
+	var longest time.Duration
+	if sampling := DefaultCheckSampling(db); sampling.Enabled() {
+		longest = sampling.MaxStaleness
+	}
+
+	var schedules []models.CheckSchedule
+	if err := db.Where("is_active = ? AND (sample_size > 0 OR sample_percent > 0)", true).Find(&schedules).Error; err != nil {
+		return longest
+	}
+	for i := range schedules {
+		if window := ScheduleCheckSampling(db, &schedules[i]).MaxStaleness; window > longest {
+			longest = window
+		}
+	}
+	return longest
+}
+
+// PhoneSample is the phones picked for a sampled run
+type PhoneSample struct {
+	Phones   []models.PhoneNumber
+	Eligible int // Active phones the sample was drawn from
+	Overdue  int // Phones included to keep the coverage guarantee
+}
+
+// SamplePhones picks phones for a sampled run. Phones never checked, or whose latest verdict would be
+// older than the staleness window at nextRun, come first, oldest first; the rest of the sample is random.
+// Overdue phones are all included even when they exceed the sample size. serviceCode limits verdicts
+// considered to one spam service.
+func SamplePhones(db *gorm.DB, phones []models.PhoneNumber, sampling CheckSampling, serviceCode string, nextRun time.Time) (*PhoneSample, error) {
+	lastChecked, err := latestVerdictTimes(db, serviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := nextRun.Add(-sampling.MaxStaleness)
+	var overdue, rest []models.PhoneNumber
+	for _, phone := range phones {
+		if checkedAt, ok := lastChecked[phone.ID]; ok && checkedAt.After(deadline) {
+			rest = append(rest, phone)
+		} else {
+			overdue = append(overdue, phone)
+		}
+	}
+
+	// Never checked phones have zero time and sort first
+	sort.SliceStable(overdue, func(i, j int) bool {
+		return lastChecked[overdue[i].ID].Before(lastChecked[overdue[j].ID])
+	})
+	rand.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+
+	sample := &PhoneSample{
+		Phones:   overdue,
+		Eligible: len(phones),
+		Overdue:  len(overdue),
+	}
+	if remaining := sampling.target(len(phones)) - len(overdue); remaining > 0 {
+		sample.Phones = append(sample.Phones, rest[:remaining]...)
+	}
+	return sample, nil
+}
+
+// latestVerdictTimes returns time of the latest spam or clean result of every phone
+func latestVerdictTimes(db *gorm.DB, serviceCode string) (map[uint]time.Time, error) {
+	latest := db.Model(&models.CheckResult{}).
+		Select("MAX(id) AS max_id").
+		Where("status IN ?", []string{models.SpamStatusSpam, models.SpamStatusClean}).
+		Group("phone_number_id")
+	if serviceCode != "" {
+		latest = latest.Where("service_id IN (?)", db.Model(&models.SpamService{}).Select("id").Where("code = ?", serviceCode))
+	}
+
+	var results []models.CheckResult
+	if err := db.Table("check_results cr").
+		Select("cr.phone_number_id, cr.checked_at").
+		Joins("JOIN (?) latest ON cr.id = latest.max_id", latest).
+		Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get latest verdicts: %w", err)
+	}
+
+	times := make(map[uint]time.Time, len(results))
+	for _, result := range results {
+		times[result.PhoneNumberID] = result.CheckedAt
+	}
+	return times, nil
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Supported check script actions
+const (
+	ScriptActionStartApp   = "start_app"
+	ScriptActionCall       = "call"
+	ScriptActionEndCall    = "end_call"
+	ScriptActionTap        = "tap"
+	ScriptActionSwipe      = "swipe"
+	ScriptActionKeyEvent   = "keyevent"
+	ScriptActionWait       = "wait"
+	ScriptActionScreenshot = "screenshot"
+	ScriptActionInput      = "input"
+)
+
+// maxScriptWait limits a single wait step
+const maxScriptWait = 60 * time.Second
+
+// CheckScriptStep represents a single ADB interaction step of a service check script
+type CheckScriptStep struct {
+	Action   string `json:"action"`
+	X        int    `json:"x,omitempty"`
+	Y        int    `json:"y,omitempty"`
+	X2       int    `json:"x2,omitempty"`
+	Y2       int    `json:"y2,omitempty"`
+	Duration int    `json:"duration,omitempty"` // Swipe duration or wait time in milliseconds
+	KeyCode  string `json:"key_code,omitempty"`
+	Text     string `json:"text,omitempty"` // Supports {phone} and {phone_digits} placeholders
+	// Step is skipped unless a previous start_app step had a service app to start, even if it failed to start
+	IfAppStarted bool `json:"if_app_started,omitempty"`
+}
+
+// DefaultCheckScript returns script replicating the built-in call simulation flow
+func DefaultCheckScript(tuning *CheckTuning) []CheckScriptStep {
+	return buildDefaultCheckScript(tuning.AppStartWait(), tuning.PostCallWait())
+}
+
+// buildDefaultCheckScript returns the built-in call simulation flow with given waits.
+// App start wait is skipped for services without an app, as in the flow before check scripts.
+func buildDefaultCheckScript(appStartWait, postCallWait time.Duration) []CheckScriptStep {
+	return []CheckScriptStep{
+		{Action: ScriptActionStartApp},
+		{Action: ScriptActionWait, Duration: int(appStartWait / time.Millisecond), IfAppStarted: true},
+		{Action: ScriptActionCall},
+		{Action: ScriptActionWait, Duration: int(postCallWait / time.Millisecond)},
+		{Action: ScriptActionScreenshot},
+		{Action: ScriptActionEndCall},
+	}
+}
+
+// ParseCheckScript parses and validates check script JSON
+func ParseCheckScript(data string) ([]CheckScriptStep, error) {
+	var steps []CheckScriptStep
+	if err := json.Unmarshal([]byte(data), &steps); err != nil {
+		return nil, fmt.Errorf("invalid check script: %w", err)
+	}
+
+	if err := ValidateCheckScript(steps); err != nil {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// ValidateCheckScript validates check script steps
+func ValidateCheckScript(steps []CheckScriptStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("check script must contain at least one step")
+	}
+
+	hasScreenshot := false
+	hasStartApp := false
+	for i, step := range steps {
+		if step.IfAppStarted && !hasStartApp {
+			return fmt.Errorf("step %d: if_app_started requires a start_app step before it", i+1)
+		}
+
+		switch step.Action {
+		case ScriptActionStartApp:
+			hasStartApp = true
+		case ScriptActionCall, ScriptActionEndCall:
+		case ScriptActionTap:
+			if step.X < 0 || step.Y < 0 {
+				return fmt.Errorf("step %d: tap coordinates must be non-negative", i+1)
+			}
+		case ScriptActionSwipe:
+			if step.X < 0 || step.Y < 0 || step.X2 < 0 || step.Y2 < 0 {
+				return fmt.Errorf("step %d: swipe coordinates must be non-negative", i+1)
+			}
+		case ScriptActionKeyEvent:
+			if step.KeyCode == "" {
+				return fmt.Errorf("step %d: key_code is required", i+1)
+			}
+		case ScriptActionWait:
+			wait := time.Duration(step.Duration) * time.Millisecond
+			if wait <= 0 || wait > maxScriptWait {
+				return fmt.Errorf("step %d: wait duration must be between 1 and %d ms", i+1, maxScriptWait.Milliseconds())
+			}
+		case ScriptActionScreenshot:
+			hasScreenshot = true
+		case ScriptActionInput:
+			if step.Text == "" {
+				return fmt.Errorf("step %d: text is required", i+1)
+			}
+		default:
+			return fmt.Errorf("step %d: unknown action %q", i+1, step.Action)
+		}
+	}
+
+	if !hasScreenshot {
+		return fmt.Errorf("check script must contain a screenshot step")
+	}
+
+	return nil
+}
+
+// getCheckScript returns service's custom script or the default one built from its call timings
+func (s *CheckService) getCheckScript(service *models.SpamService) []CheckScriptStep {
+	return s.serviceConfig(service).CheckScript
+}
+
+// runCheckScript executes script steps on gateway and returns the last captured screenshot
+func (s *CheckService) runCheckScript(ctx context.Context, steps []CheckScriptStep, phone *models.PhoneNumber, gateway *models.ADBGateway) ([]byte, error) {
+	log := logger.EntryWithContext(s.log, ctx).WithFields(logrus.Fields{
+		"method":  "runCheckScript",
+		"phone":   phone.Number,
+		"gateway": gateway.Name,
+	})
+
+	// ADB calls log with the same trace ID
+	adbService := s.adbService.WithContext(ctx)
+
+	var screenshot []byte
+	callActive := false
+	appStarted := false
+
+	// Always hang up if script failed mid-call
+	defer func() {
+		if callActive {
+			if err := adbService.EndCall(gateway.ID, onlyDigits(phone.Number)); err != nil {
+				log.Warnf("Failed to end call: %v", err)
+			}
+		}
+	}()
+
+	replacer := strings.NewReplacer("{phone}", phone.Number, "{phone_digits}", onlyDigits(phone.Number))
+
+	for i, step := range steps {
+		if step.IfAppStarted && !appStarted {
+			continue
+		}
+
+		var err error
+
+		switch step.Action {
+		case ScriptActionStartApp:
+			appPackage, appActivity := s.getAppInfo(gateway.ServiceCode)
+			appStarted = appPackage != "" && appActivity != ""
+			if appStarted {
+				if err := adbService.StartApp(gateway.ID, appPackage, appActivity); err != nil {
+					log.Warnf("Failed to start app: %v", err)
+				}
+			}
+
+		case ScriptActionCall:
+			log.Infof("Simulating incoming call from %s", logger.FormatPhone(phone.Number))
+			if err = adbService.SimulateIncomingCall(gateway.ID, phone.Number); err == nil {
+				callActive = true
+				checkTimelineFromContext(ctx).record(CheckEventCallSimulated, "incoming call from %s", phone.Number)
+			}
+
+		case ScriptActionEndCall:
+			if err := adbService.EndCall(gateway.ID, onlyDigits(phone.Number)); err != nil {
+				log.Warnf("Failed to end call: %v", err)
+			}
+			callActive = false
+
+		case ScriptActionTap:
+			err = adbService.TapScreen(gateway.ID, step.X, step.Y)
+
+		case ScriptActionSwipe:
+			err = adbService.SwipeScreen(gateway.ID, step.X, step.Y, step.X2, step.Y2, step.Duration)
+
+		case ScriptActionKeyEvent:
+			err = adbService.SendKeyEvent(gateway.ID, step.KeyCode)
+
+		case ScriptActionWait:
+			time.Sleep(time.Duration(step.Duration) * time.Millisecond)
+
+		case ScriptActionScreenshot:
+			data, screenshotErr := adbService.TakeScreenshot(gateway.ID)
+			if screenshotErr != nil {
+				log.Errorf("Failed to take screenshot: %v", screenshotErr)
+				checkTimelineFromContext(ctx).record(CheckEventScreenshotTaken, "screenshot failed: %v", screenshotErr)
+			} else {
+				screenshot = data
+				checkTimelineFromContext(ctx).record(CheckEventScreenshotTaken, "%d bytes", len(data))
+			}
+
+		case ScriptActionInput:
+			err = adbService.InputText(gateway.ID, replacer.Replace(step.Text))
+
+		default:
+			err = fmt.Errorf("unknown action %q", step.Action)
+		}
+
+		if err != nil {
+			return screenshot, fmt.Errorf("script step %d (%s) failed: %w", i+1, step.Action, err)
+		}
+	}
+
+	return screenshot, nil
+}
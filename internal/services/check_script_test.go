@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"spam-checker/internal/config"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+)
+
+func TestDefaultCheckScriptWaitsOnlyForStartedApp(t *testing.T) {
+	steps := buildDefaultCheckScript(2*time.Second, 5*time.Second)
+	if err := ValidateCheckScript(steps); err != nil {
+		t.Fatalf("default script is invalid: %v", err)
+	}
+	if steps[1].Action != ScriptActionWait || steps[1].Duration != 2000 || !steps[1].IfAppStarted {
+		t.Errorf("app start wait = %+v, want 2000 ms only if app started", steps[1])
+	}
+	if steps[3].Action != ScriptActionWait || steps[3].Duration != 5000 || steps[3].IfAppStarted {
+		t.Errorf("post call wait = %+v, want unconditional 5000 ms", steps[3])
+	}
+
+	invalid := []CheckScriptStep{
+		{Action: ScriptActionWait, Duration: 100, IfAppStarted: true},
+		{Action: ScriptActionStartApp},
+		{Action: ScriptActionScreenshot},
+	}
+	if err := ValidateCheckScript(invalid); err == nil {
+		t.Error("if_app_started before start_app passed validation")
+	}
+}
+
+func TestRunCheckScriptSkipsAppWaitWithoutApp(t *testing.T) {
+	db := newTestDB(t)
+	service := &CheckService{
+		db:         db,
+		adbService: NewADBServiceWithConfig(db, &config.Config{}, nil),
+		log:        logger.WithField("service", "CheckService"),
+	}
+
+	steps := []CheckScriptStep{
+		{Action: ScriptActionStartApp},
+		{Action: ScriptActionWait, Duration: 2000, IfAppStarted: true},
+	}
+	phone := &models.PhoneNumber{Number: "79001234567"}
+	gateway := &models.ADBGateway{Name: "gw-no-app", ServiceCode: "service_without_app"}
+
+	started := time.Now()
+	if _, err := service.runCheckScript(context.Background(), steps, phone, gateway); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed >= time.Second {
+		t.Errorf("script of service without app took %s, app start wait was not skipped", elapsed)
+	}
+}
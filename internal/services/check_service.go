@@ -3,12 +3,11 @@ package services
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/png"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"path"
 	"regexp"
 	"spam-checker/internal/config"
 	"spam-checker/internal/logger"
@@ -16,7 +15,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -26,23 +27,41 @@ type CheckService struct {
 	cfg              *config.Config
 	adbService       *ADBService
 	apiService       *APICheckService
-	gatewayLocks     map[uint]*sync.Mutex
-	gatewayLocksMu   sync.RWMutex
-	gatewayBusy      map[uint]bool
-	phoneCheckLocks  map[uint]*sync.Mutex
-	phoneCheckMu     sync.RWMutex
-	phoneCheckActive map[uint]bool // Track active phone checks
+	phoneLocks       *distributedLocks // One running check per phone across instances
+	gatewayLocks     *distributedLocks // One task at a time per gateway across instances
+	callSpacing      *callSpacer       // Minimum interval between calls per gateway
 	resultWriteMutex sync.Mutex
+	checkJobMutex    sync.Mutex // Serializes start of check jobs
+	tuning           *CheckTuning
+	storage          *FileStorage // Screenshot storage
 	log              *logrus.Entry
 
-	// New fields for better concurrency control
-	gatewayQueue   map[uint]chan struct{} // Queue for each gateway
-	gatewayQueueMu sync.RWMutex
-	maxRetries     int
-	retryDelay     time.Duration
-	checkTimeout   time.Duration // Global timeout for phone check
+	checkTimeout time.Duration // Global timeout for phone check
 }
 
+// LockStats represents number of phones and gateways with active or waiting checks
+type LockStats struct {
+	TrackedPhones    int      `json:"tracked_phones"`
+	TrackedGateways  int      `json:"tracked_gateways"`
+	ActiveAPIChecks  int      `json:"active_api_checks"`  // Outbound API calls running now
+	WaitingAPIChecks int      `json:"waiting_api_checks"` // API calls waiting for api_check_max_concurrent slot
+	ActiveOCR        int      `json:"active_ocr"`         // Screenshots being recognized now
+	WaitingOCR       int      `json:"waiting_ocr"`        // Screenshots waiting for ocr_max_concurrent slot
+	OCR              OCRStats `json:"ocr"`                // OCR engine throughput since start
+}
+
+// ErrCheckInProgress is returned when a check for the phone is already running
+var ErrCheckInProgress = errors.New("already being checked")
+
+// ErrPhoneBlocked is returned when a check is requested for a blocklisted phone
+var ErrPhoneBlocked = errors.New("blocked")
+
+// ErrPhoneNotFound is returned when a check is requested for a phone that does not exist
+var ErrPhoneNotFound = errors.New("phone not found")
+
+// devOCRText is returned instead of OCR output in development mode
+const devOCRText = "Входящий вызов\nВозможно спам\nIncoming call: possible spam"
+
 // CheckTask represents a task for checking phone on specific gateway/service
 type CheckTask struct {
 	PhoneID   uint
@@ -53,13 +72,62 @@ type CheckTask struct {
 	Context   context.Context // Add context for cancellation
 }
 
+// Per-service check statuses
+const (
+	ServiceStatusOK          = "ok"
+	ServiceStatusFailed      = "failed"
+	ServiceStatusTimeout     = "timeout"
+	ServiceStatusUnsupported = "unsupported"
+	ServiceStatusExcluded    = "excluded" // Phone is excluded from checks of the service
+)
+
+// ServiceCheckStatus represents outcome of checking a phone with a single service
+type ServiceCheckStatus struct {
+	Service string `json:"service"`
+	Source  string `json:"source,omitempty"` // adb or api
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PhoneCheckReport represents per-service outcomes of a phone check
+type PhoneCheckReport struct {
+	Services            []ServiceCheckStatus `json:"services"`
+	Degraded            bool                 `json:"degraded"`
+	PersistedAfterRetry int                  `json:"persisted_after_retry,omitempty"` // Results saved once the database came back
+	Journaled           int                  `json:"journaled,omitempty"`             // Results kept in the journal, see ReplayResultJournal
+	Err                 error                `json:"-"`                               // Overall error with CheckPhoneNumber semantics
+}
+
+// SucceededCount returns number of services checked successfully
+func (r *PhoneCheckReport) SucceededCount() int {
+	count := 0
+	for _, status := range r.Services {
+		if status.Status == ServiceStatusOK {
+			count++
+		}
+	}
+	return count
+}
+
+// ExcludedCount returns number of checks skipped because the phone is excluded from the service
+func (r *PhoneCheckReport) ExcludedCount() int {
+	count := 0
+	for _, status := range r.Services {
+		if status.Status == ServiceStatusExcluded {
+			count++
+		}
+	}
+	return count
+}
+
 // CheckResult for concurrent processing
 type ConcurrentCheckResult struct {
-	PhoneID uint
-	Gateway *models.ADBGateway
-	Service *models.SpamService
-	Error   error
-	Result  *models.CheckResult
+	PhoneID   uint
+	GatewayID uint
+	Gateway   *models.ADBGateway
+	Service   *models.SpamService
+	Error     error
+	Result    *models.CheckResult
 }
 
 // APICheckResult for concurrent API processing
@@ -71,150 +139,183 @@ type APICheckResult struct {
 	Result     *models.CheckResult
 }
 
-func NewCheckService(db *gorm.DB, cfg *config.Config) *CheckService {
-	service := &CheckService{
-		db:               db,
-		cfg:              cfg,
-		adbService:       NewADBServiceWithConfig(db, cfg),
-		apiService:       NewAPICheckService(db),
-		gatewayLocks:     make(map[uint]*sync.Mutex),
-		gatewayBusy:      make(map[uint]bool),
-		phoneCheckLocks:  make(map[uint]*sync.Mutex),
-		phoneCheckActive: make(map[uint]bool),
-		gatewayQueue:     make(map[uint]chan struct{}),
-		log:              logger.WithField("service", "CheckService"),
-		maxRetries:       3,
-		retryDelay:       2 * time.Second,
-		checkTimeout:     5 * time.Minute, // Total timeout for checking one phone
-	}
-
-	// Initialize gateway queues
-	service.initGatewayQueues()
-
-	return service
-}
-
-// initGatewayQueues initializes queue channels for each gateway
-func (s *CheckService) initGatewayQueues() {
-	gateways, err := s.adbService.ListGateways()
-	if err != nil {
-		s.log.Errorf("Failed to initialize gateway queues: %v", err)
-		return
+func NewCheckService(db *gorm.DB, cfg *config.Config, dockerClient *DockerClient) *CheckService {
+	return &CheckService{
+		db:           db,
+		cfg:          cfg,
+		adbService:   NewADBServiceWithConfig(db, cfg, dockerClient),
+		apiService:   NewAPICheckService(db),
+		phoneLocks:   newDistributedLocks(db, phoneLockPrefix),
+		gatewayLocks: newDistributedLocks(db, gatewayLockPrefix),
+		callSpacing:  newCallSpacer(db),
+		tuning:       NewCheckTuning(db, cfg),
+		storage:      NewFileStorage(cfg.Storage),
+		log:          logger.WithField("service", "CheckService"),
+		checkTimeout: 5 * time.Minute, // Total timeout for checking one phone
 	}
+}
 
-	s.gatewayQueueMu.Lock()
-	defer s.gatewayQueueMu.Unlock()
-
-	for _, gateway := range gateways {
-		// Create a buffered channel that acts as a semaphore (1 = only one task at a time)
-		s.gatewayQueue[gateway.ID] = make(chan struct{}, 1)
+// LockStats returns number of phones and gateways currently tracked by lock registries
+func (s *CheckService) LockStats() LockStats {
+	activeAPIChecks, waitingAPIChecks := sharedAPICheckLimiter.stats()
+	activeOCR, waitingOCR := sharedOCRLimiter.stats()
+	return LockStats{
+		TrackedPhones:    s.phoneLocks.Len(),
+		TrackedGateways:  s.gatewayLocks.Len(),
+		ActiveAPIChecks:  activeAPIChecks,
+		WaitingAPIChecks: waitingAPIChecks,
+		ActiveOCR:        activeOCR,
+		WaitingOCR:       waitingOCR,
+		OCR:              sharedOCRPool.stats(ocrEngine(s.db)),
 	}
 }
 
-// getGatewayQueue returns or creates a queue for gateway
-func (s *CheckService) getGatewayQueue(gatewayID uint) chan struct{} {
-	s.gatewayQueueMu.RLock()
-	if queue, exists := s.gatewayQueue[gatewayID]; exists {
-		s.gatewayQueueMu.RUnlock()
-		return queue
+// CheckPhoneNumber checks a single phone number across all services
+func (s *CheckService) CheckPhoneNumber(phoneID uint) error {
+	report, err := s.CheckPhoneNumberDetailed(phoneID)
+	if err != nil {
+		return err
 	}
-	s.gatewayQueueMu.RUnlock()
+	return report.Err
+}
 
-	// Create new queue if doesn't exist
-	s.gatewayQueueMu.Lock()
-	defer s.gatewayQueueMu.Unlock()
+// PhoneCheckOptions narrow a phone check, zero value checks all services in check_mode setting
+type PhoneCheckOptions struct {
+	Mode        models.CheckMode // Empty uses check_mode setting
+	ServiceCode string           // Only gateways and API services of this spam service, empty checks all
+}
 
-	if queue, exists := s.gatewayQueue[gatewayID]; exists {
-		return queue
+// CheckPhoneNumberWithOptions checks a single phone number with check mode or service overridden
+func (s *CheckService) CheckPhoneNumberWithOptions(phoneID uint, opts PhoneCheckOptions) error {
+	report, err := s.checkPhoneNumberDetailed(phoneID, opts)
+	if err != nil {
+		return err
 	}
+	return report.Err
+}
 
-	queue := make(chan struct{}, 1)
-	s.gatewayQueue[gatewayID] = queue
-	return queue
+// CheckPhoneNumberDetailed checks a single phone number and reports per-service outcomes.
+// Error is returned only if the check could not be started at all.
+func (s *CheckService) CheckPhoneNumberDetailed(phoneID uint) (*PhoneCheckReport, error) {
+	return s.checkPhoneNumberDetailed(phoneID, PhoneCheckOptions{})
 }
 
-// CheckPhoneNumber checks a single phone number across all services
-func (s *CheckService) CheckPhoneNumber(phoneID uint) error {
+// CheckPhoneNumberDetailedWithOptions checks a phone narrowed by options and reports per-service outcomes
+func (s *CheckService) CheckPhoneNumberDetailedWithOptions(phoneID uint, opts PhoneCheckOptions) (*PhoneCheckReport, error) {
+	return s.checkPhoneNumberDetailed(phoneID, opts)
+}
+
+// checkPhoneNumberDetailed checks a phone narrowed by options
+func (s *CheckService) checkPhoneNumberDetailed(phoneID uint, opts PhoneCheckOptions) (*PhoneCheckReport, error) {
+	// Trace ID correlates all log entries of this check across services
+	traceID := uuid.New().String()
 	log := s.log.WithFields(logrus.Fields{
-		"method":  "CheckPhoneNumber",
-		"phoneID": phoneID,
+		"method":          "CheckPhoneNumber",
+		"phoneID":         phoneID,
+		logger.TraceIDKey: traceID,
 	})
 
 	// Check if phone is already being checked
-	s.phoneCheckMu.Lock()
-	if s.phoneCheckActive[phoneID] {
-		s.phoneCheckMu.Unlock()
+	release, acquired, err := s.phoneLocks.TryAcquire(phoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock phone %d: %w", phoneID, err)
+	}
+	if !acquired {
 		log.Warnf("Phone %d is already being checked, skipping", phoneID)
-		return fmt.Errorf("phone %d is already being checked", phoneID)
+		return nil, fmt.Errorf("phone %d is %w", phoneID, ErrCheckInProgress)
 	}
-	s.phoneCheckActive[phoneID] = true
-	s.phoneCheckMu.Unlock()
-
-	// Ensure we clear the active flag when done
-	defer func() {
-		s.phoneCheckMu.Lock()
-		delete(s.phoneCheckActive, phoneID)
-		s.phoneCheckMu.Unlock()
-	}()
+	defer release()
 
 	// Get phone number
 	var phone models.PhoneNumber
 	if err := s.db.First(&phone, phoneID).Error; err != nil {
-		return fmt.Errorf("phone not found: %w", err)
+		return nil, fmt.Errorf("phone not found: %w", err)
 	}
 
-	// Use phone-level lock to serialize checks for the same phone
-	phoneCheckLock := s.getPhoneCheckLock(phoneID)
-
-	// Try to acquire lock with timeout
-	lockAcquired := make(chan bool, 1)
-	go func() {
-		phoneCheckLock.Lock()
-		lockAcquired <- true
-	}()
-
-	select {
-	case <-lockAcquired:
-		defer phoneCheckLock.Unlock()
-	case <-time.After(10 * time.Second):
-		return fmt.Errorf("timeout acquiring lock for phone %d", phoneID)
+	if phone.Blocked {
+		log.Warnf("Phone %s is blocked, skipping check", logger.FormatPhone(phone.Number))
+		return nil, fmt.Errorf("phone %d is %w", phoneID, ErrPhoneBlocked)
 	}
 
 	// Create context with timeout for the entire phone check
-	ctx, cancel := context.WithTimeout(context.Background(), s.checkTimeout)
+	ctx, cancel := context.WithTimeout(logger.ContextWithTraceID(context.Background(), traceID), s.checkTimeout)
 	defer cancel()
 
-	// Get check mode setting
-	checkMode := s.getCheckMode()
+	// Retries of all gateways and API services share a single budget
+	ctx = contextWithRetryPolicy(ctx, s.getRetryPolicy())
 
-	log.Infof("Starting check for phone %s with mode: %s", phone.Number, checkMode)
+	// Results that needed retries or the journal are counted in the report
+	tally := &persistTally{}
+	ctx = contextWithPersistTally(ctx, tally)
 
-	// Create error channel to collect errors
-	errChan := make(chan error, 2)
-	var wg sync.WaitGroup
+	// Services the phone is excluded from are skipped, not attempted
+	excluded, err := excludedServiceCodes(s.db, phone.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(excluded) > 0 {
+		ctx = contextWithServiceExclusions(ctx, excluded)
+	}
+
+	// Get check mode setting unless the caller chose one
+	checkMode := opts.Mode
+	if checkMode == "" {
+		checkMode = s.getCheckMode()
+	}
+
+	if opts.ServiceCode != "" {
+		ctx = contextWithServiceFilter(ctx, opts.ServiceCode)
+		log.Infof("Starting check for phone %s with mode: %s, service: %s", logger.FormatPhone(phone.Number), checkMode, opts.ServiceCode)
+	} else {
+		log.Infof("Starting check for phone %s with mode: %s", logger.FormatPhone(phone.Number), checkMode)
+	}
+
+	report := &PhoneCheckReport{}
+	var reportMu sync.Mutex
+	reportClosed := false
+	addStatuses := func(statuses []ServiceCheckStatus) {
+		reportMu.Lock()
+		defer reportMu.Unlock()
+		// Checks finishing after timeout are no longer reported
+		if !reportClosed {
+			report.Services = append(report.Services, statuses...)
+		}
+	}
 
 	// Perform checks based on mode
 	switch checkMode {
 	case models.CheckModeADBOnly:
-		return s.checkViaADBWithContext(ctx, &phone)
+		statuses, err := s.checkViaADBWithContext(ctx, &phone)
+		addStatuses(statuses)
+		report.Err = err
 
 	case models.CheckModeAPIOnly:
-		return s.checkViaAPIWithContext(ctx, &phone)
+		statuses, err := s.checkViaAPIWithContext(ctx, &phone)
+		addStatuses(statuses)
+		report.Err = err
 
 	case models.CheckModeBoth:
+		// Create error channel to collect errors
+		errChan := make(chan error, 2)
+		var wg sync.WaitGroup
+
 		// Check both ADB and API concurrently
 		wg.Add(2)
 
 		go func() {
 			defer wg.Done()
-			if err := s.checkViaADBWithContext(ctx, &phone); err != nil {
+			statuses, err := s.checkViaADBWithContext(ctx, &phone)
+			addStatuses(statuses)
+			if err != nil {
 				errChan <- fmt.Errorf("ADB: %w", err)
 			}
 		}()
 
 		go func() {
 			defer wg.Done()
-			if err := s.checkViaAPIWithContext(ctx, &phone); err != nil {
+			statuses, err := s.checkViaAPIWithContext(ctx, &phone)
+			addStatuses(statuses)
+			if err != nil {
 				errChan <- fmt.Errorf("API: %w", err)
 			}
 		}()
@@ -229,73 +330,175 @@ func (s *CheckService) CheckPhoneNumber(phoneID uint) error {
 		select {
 		case <-done:
 			close(errChan)
+
+			// Collect errors
+			var errors []error
+			for err := range errChan {
+				errors = append(errors, err)
+			}
+
+			// Error only if both failed
+			if len(errors) == 2 {
+				report.Err = fmt.Errorf("both checks failed: %v", errors)
+			}
 		case <-ctx.Done():
-			return fmt.Errorf("check timeout for phone %s", phone.Number)
+			report.Err = fmt.Errorf("check timeout for phone %s", logger.FormatPhone(phone.Number))
 		}
 
-		// Collect errors
-		var errors []error
-		for err := range errChan {
-			errors = append(errors, err)
+	default:
+		return nil, fmt.Errorf("unknown check mode: %s", checkMode)
+	}
+
+	reportMu.Lock()
+	reportClosed = true
+	reportMu.Unlock()
+	s.finalizeCheckReport(ctx, report)
+	report.PersistedAfterRetry = int(tally.retried.Load())
+	report.Journaled = int(tally.journaled.Load())
+
+	return report, nil
+}
+
+// finalizeCheckReport merges duplicate statuses and marks services that were not checked
+func (s *CheckService) finalizeCheckReport(ctx context.Context, report *PhoneCheckReport) {
+	merged := make([]ServiceCheckStatus, 0, len(report.Services))
+	index := make(map[string]int)
+	for _, status := range report.Services {
+		key := status.Service + "/" + status.Source
+		if i, exists := index[key]; exists {
+			// Any successful gateway makes the service successful
+			if merged[i].Status != ServiceStatusOK && status.Status == ServiceStatusOK {
+				merged[i] = status
+			}
+			continue
 		}
+		index[key] = len(merged)
+		merged = append(merged, status)
+	}
 
-		// Return error only if both failed
-		if len(errors) == 2 {
-			return fmt.Errorf("both checks failed: %v", errors)
+	// Active services without any outcome were either not reached in time or have no checker
+	checked := make(map[string]bool, len(merged))
+	for _, status := range merged {
+		checked[status.Service] = true
+	}
+
+	excluded := serviceExclusionsFromContext(ctx)
+	serviceFilter := serviceFilterFromContext(ctx)
+	var activeServices []models.SpamService
+	if err := s.db.Where("is_active = ?", true).Find(&activeServices).Error; err == nil {
+		for _, service := range activeServices {
+			if checked[service.Code] {
+				continue
+			}
+			status := ServiceStatusUnsupported
+			if excluded[service.Code] && (serviceFilter == "" || serviceFilter == service.Code) {
+				status = ServiceStatusExcluded
+			} else if ctx.Err() != nil {
+				status = ServiceStatusTimeout
+			}
+			merged = append(merged, ServiceCheckStatus{
+				Service: service.Code,
+				Status:  status,
+			})
 		}
+	}
 
-		return nil
+	report.Services = merged
+	report.Degraded = false
+	for _, status := range merged {
+		if status.Status != ServiceStatusOK && status.Status != ServiceStatusExcluded {
+			report.Degraded = true
+			break
+		}
+	}
+}
 
-	default:
-		return fmt.Errorf("unknown check mode: %s", checkMode)
+// serviceStatusFromError maps check error to service status
+func serviceStatusFromError(err error) string {
+	if err == nil {
+		return ServiceStatusOK
+	}
+	if errors.Is(err, context.DeadlineExceeded) || strings.Contains(strings.ToLower(err.Error()), "timeout") {
+		return ServiceStatusTimeout
 	}
+	return ServiceStatusFailed
 }
 
 // checkViaADBWithContext checks phone via ADB with context
-func (s *CheckService) checkViaADBWithContext(ctx context.Context, phone *models.PhoneNumber) error {
+func (s *CheckService) checkViaADBWithContext(ctx context.Context, phone *models.PhoneNumber) ([]ServiceCheckStatus, error) {
 	// Check context before starting
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	return s.checkViaADB(phone)
+	return s.checkViaADB(ctx, phone)
 }
 
 // checkViaAPIWithContext checks phone via API with context
-func (s *CheckService) checkViaAPIWithContext(ctx context.Context, phone *models.PhoneNumber) error {
+func (s *CheckService) checkViaAPIWithContext(ctx context.Context, phone *models.PhoneNumber) ([]ServiceCheckStatus, error) {
 	// Check context before starting
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	return s.checkViaAPI(phone)
+	return s.checkViaAPI(ctx, phone)
 }
 
 // checkViaADB checks phone via ADB
-func (s *CheckService) checkViaADB(phone *models.PhoneNumber) error {
-	log := s.log.WithFields(logrus.Fields{
+func (s *CheckService) checkViaADB(parent context.Context, phone *models.PhoneNumber) ([]ServiceCheckStatus, error) {
+	log := logger.EntryWithContext(s.log, parent).WithFields(logrus.Fields{
 		"method": "checkViaADB",
-		"phone":  phone.Number,
+		"phone":  logger.FormatPhone(phone.Number),
 	})
 
 	// Get active gateways
 	gateways, err := s.adbService.GetActiveGateways()
 	if err != nil {
-		return fmt.Errorf("failed to get active gateways: %w", err)
+		return nil, fmt.Errorf("failed to get active gateways: %w", err)
 	}
 
+	if serviceCode := serviceFilterFromContext(parent); serviceCode != "" {
+		gateways = filterGatewaysByService(gateways, serviceCode)
+		if len(gateways) == 0 {
+			return nil, fmt.Errorf("no active ADB gateways available for service %s", serviceCode)
+		}
+	}
+
+	if len(gateways) == 0 {
+		return nil, fmt.Errorf("no active ADB gateways available")
+	}
+
+	gateways, excludedStatuses := skipExcludedGateways(gateways, serviceExclusionsFromContext(parent))
 	if len(gateways) == 0 {
-		return fmt.Errorf("no active ADB gateways available")
+		log.Infof("Phone %s is excluded from all services of active gateways", logger.FormatPhone(phone.Number))
+		return excludedStatuses, nil
+	}
+
+	// Gateways that don't report back in time are considered timed out
+	statuses := make(map[uint]*ServiceCheckStatus, len(gateways))
+	for _, gateway := range gateways {
+		statuses[gateway.ID] = &ServiceCheckStatus{
+			Service: gateway.ServiceCode,
+			Source:  "adb",
+			Status:  ServiceStatusTimeout,
+		}
+	}
+	collectStatuses := func() []ServiceCheckStatus {
+		result := make([]ServiceCheckStatus, 0, len(gateways)+len(excludedStatuses))
+		for _, gateway := range gateways {
+			result = append(result, *statuses[gateway.ID])
+		}
+		return append(result, excludedStatuses...)
 	}
 
-	log.Infof("Starting ADB check for phone %s across %d gateways", phone.Number, len(gateways))
+	log.Infof("Starting ADB check for phone %s across %d gateways", logger.FormatPhone(phone.Number), len(gateways))
 
-	// Create context for this ADB check
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	// Create context for this ADB check, keeping trace ID but not parent deadline
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), 3*time.Minute)
 	defer cancel()
 
 	// Create task channels
@@ -303,7 +506,7 @@ func (s *CheckService) checkViaADB(phone *models.PhoneNumber) error {
 	resultChan := make(chan ConcurrentCheckResult, len(gateways))
 
 	// Worker pool size (limit concurrent checks)
-	maxWorkers := 5
+	maxWorkers := s.tuning.MaxWorkers()
 	if len(gateways) < maxWorkers {
 		maxWorkers = len(gateways)
 	}
@@ -347,135 +550,141 @@ func (s *CheckService) checkViaADB(phone *models.PhoneNumber) error {
 				// Channel closed, all results collected
 				goto done
 			}
+			if status, exists := statuses[result.GatewayID]; exists {
+				status.Status = serviceStatusFromError(result.Error)
+				if result.Error != nil {
+					status.Error = result.Error.Error()
+				}
+			}
 			if result.Error != nil {
 				errorCount++
 				lastError = result.Error
-				log.Errorf("Check failed on gateway %s: %v", result.Gateway.Name, result.Error)
+				if result.Gateway != nil {
+					log.Errorf("Check failed on gateway %s: %v", result.Gateway.Name, result.Error)
+				} else {
+					log.Errorf("Check failed on gateway %d: %v", result.GatewayID, result.Error)
+				}
 			} else {
 				successCount++
 				log.Infof("Check succeeded on gateway %s", result.Gateway.Name)
 			}
 		case <-ctx.Done():
-			log.Errorf("ADB check timeout for phone %s", phone.Number)
-			return fmt.Errorf("ADB check timeout")
+			log.Errorf("ADB check timeout for phone %s", logger.FormatPhone(phone.Number))
+			return collectStatuses(), fmt.Errorf("ADB check timeout")
 		}
 	}
 
 done:
 	log.Infof("ADB check completed for phone %s: %d successful, %d failed",
-		phone.Number, successCount, errorCount)
+		logger.FormatPhone(phone.Number), successCount, errorCount)
 
 	if successCount == 0 && errorCount > 0 {
-		return fmt.Errorf("all ADB checks failed: %v", lastError)
+		return collectStatuses(), fmt.Errorf("all ADB checks failed: %v", lastError)
 	}
 
-	return nil
+	return collectStatuses(), nil
 }
 
 // checkViaAPI checks phone via API
-func (s *CheckService) checkViaAPI(phone *models.PhoneNumber) error {
-	log := s.log.WithFields(logrus.Fields{
+func (s *CheckService) checkViaAPI(parent context.Context, phone *models.PhoneNumber) ([]ServiceCheckStatus, error) {
+	log := logger.EntryWithContext(s.log, parent).WithFields(logrus.Fields{
 		"method": "checkViaAPI",
-		"phone":  phone.Number,
+		"phone":  logger.FormatPhone(phone.Number),
 	})
 
 	// Get active API services
 	apiServices, err := s.apiService.GetActiveAPIServices()
 	if err != nil {
-		return fmt.Errorf("failed to get active API services: %w", err)
+		return nil, fmt.Errorf("failed to get active API services: %w", err)
+	}
+
+	if serviceCode := serviceFilterFromContext(parent); serviceCode != "" {
+		apiServices = filterAPIServicesByService(apiServices, serviceCode)
+		if len(apiServices) == 0 {
+			return nil, fmt.Errorf("no active API services available for service %s", serviceCode)
+		}
 	}
 
 	if len(apiServices) == 0 {
-		return fmt.Errorf("no active API services available")
+		return nil, fmt.Errorf("no active API services available")
 	}
 
-	log.Infof("Starting API check for phone %s across %d services", phone.Number, len(apiServices))
+	apiServices, excludedStatuses := skipExcludedAPIServices(apiServices, serviceExclusionsFromContext(parent))
+	if len(apiServices) == 0 {
+		log.Infof("Phone %s is excluded from all services of active API providers", logger.FormatPhone(phone.Number))
+		return excludedStatuses, nil
+	}
+
+	policies, err := s.apiService.GetAPIPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	// API services that don't report back in time are considered timed out.
+	// Fallback providers of a first-success group are only reported once called.
+	statuses := make(map[uint]*ServiceCheckStatus, len(apiServices))
+	for _, group := range groupAPIServices(apiServices) {
+		for i, api := range group {
+			if i > 0 && policies[api.ServiceCode] == models.APIPolicyFirstSuccess {
+				break
+			}
+			statuses[api.ID] = &ServiceCheckStatus{
+				Service: api.ServiceCode,
+				Source:  "api",
+				Status:  ServiceStatusTimeout,
+			}
+		}
+	}
+	collectStatuses := func() []ServiceCheckStatus {
+		result := make([]ServiceCheckStatus, 0, len(apiServices)+len(excludedStatuses))
+		for _, api := range apiServices {
+			if status, exists := statuses[api.ID]; exists {
+				result = append(result, *status)
+			}
+		}
+		return append(result, excludedStatuses...)
+	}
+
+	log.Infof("Starting API check for phone %s across %d services", logger.FormatPhone(phone.Number), len(apiServices))
 
 	// Create context for this API check
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), 2*time.Minute)
 	defer cancel()
 
+	policy := s.retryPolicyFromContext(ctx)
+
 	// Create result channel
 	resultChan := make(chan APICheckResult, len(apiServices))
 	var wg sync.WaitGroup
 
-	// Check on each API service concurrently
-	for _, apiService := range apiServices {
-		wg.Add(1)
-		go func(api models.APIService) {
-			defer wg.Done()
-
-			// Check context
-			select {
-			case <-ctx.Done():
-				resultChan <- APICheckResult{
-					PhoneID:    phone.ID,
-					APIService: &api,
-					Error:      ctx.Err(),
-				}
-				return
-			default:
-			}
-
-			result := APICheckResult{
-				PhoneID:    phone.ID,
-				APIService: &api,
+	// Services of the same code are called either all at once or one by one by priority
+	for _, group := range groupAPIServices(apiServices) {
+		if policies[group[0].ServiceCode] != models.APIPolicyFirstSuccess || len(group) == 1 {
+			for _, apiService := range group {
+				wg.Add(1)
+				go func(api models.APIService) {
+					defer wg.Done()
+					resultChan <- s.checkAPIServiceWithRetries(ctx, phone, api, policy)
+				}(apiService)
 			}
+			continue
+		}
 
-			// Perform check with retries (but with context awareness)
-			var checkResult *models.CheckResult
-			var lastErr error
+		wg.Add(1)
+		go func(providers []models.APIService) {
+			defer wg.Done()
 
-			for retry := 0; retry <= s.maxRetries; retry++ {
-				// Check context before retry
-				select {
-				case <-ctx.Done():
-					result.Error = ctx.Err()
-					resultChan <- result
+			for i, api := range providers {
+				result := s.checkAPIServiceWithRetries(ctx, phone, api, policy)
+				resultChan <- result
+				if result.Error == nil || ctx.Err() != nil {
 					return
-				default:
 				}
-
-				log.Infof("Checking phone %s via API %s (attempt %d/%d)",
-					phone.Number, api.Name, retry+1, s.maxRetries+1)
-
-				checkResult, err = s.apiService.CheckPhoneViaAPI(phone, &api)
-				if err != nil {
-					lastErr = err
-					if retry < s.maxRetries && s.isRetryableError(err) {
-						log.Warnf("API check failed, retrying: %v", err)
-						time.Sleep(s.retryDelay)
-						continue
-					}
-					result.Error = err
-					break
-				} else {
-					result.Result = checkResult
-
-					// Get service info after successful check
-					var service models.SpamService
-					if err := s.db.Where("code = ?", api.ServiceCode).First(&service).Error; err == nil {
-						result.Service = &service
-
-						// Update statistics in transaction
-						s.db.Transaction(func(tx *gorm.DB) error {
-							return s.updateStatisticsInTx(tx, phone.ID, service.ID, checkResult.IsSpam)
-						})
-					} else {
-						log.Warnf("Failed to get service after check: %v", err)
-					}
-
-					break
+				if i+1 < len(providers) {
+					log.Warnf("API %s failed, falling back to %s: %v", api.Name, providers[i+1].Name, result.Error)
 				}
 			}
-
-			// If all retries failed, set the last error
-			if checkResult == nil && lastErr != nil {
-				result.Error = lastErr
-			}
-
-			resultChan <- result
-		}(apiService)
+		}(group)
 	}
 
 	// Close channel when all done
@@ -497,6 +706,18 @@ func (s *CheckService) checkViaAPI(phone *models.PhoneNumber) error {
 				// Channel closed, all results collected
 				goto done
 			}
+			status, exists := statuses[result.APIService.ID]
+			if !exists {
+				status = &ServiceCheckStatus{
+					Service: result.APIService.ServiceCode,
+					Source:  "api",
+				}
+				statuses[result.APIService.ID] = status
+			}
+			status.Status = serviceStatusFromError(result.Error)
+			if result.Error != nil {
+				status.Error = result.Error.Error()
+			}
 			if result.Error != nil {
 				errorCount++
 				lastError = result.Error
@@ -511,20 +732,99 @@ func (s *CheckService) checkViaAPI(phone *models.PhoneNumber) error {
 				}
 			}
 		case <-ctx.Done():
-			log.Errorf("API check timeout for phone %s", phone.Number)
-			return fmt.Errorf("API check timeout")
+			log.Errorf("API check timeout for phone %s", logger.FormatPhone(phone.Number))
+			return collectStatuses(), fmt.Errorf("API check timeout")
 		}
 	}
 
 done:
 	log.Infof("API check completed for phone %s: %d successful, %d failed, spam detected: %v",
-		phone.Number, successCount, errorCount, hasSpamDetection)
+		logger.FormatPhone(phone.Number), successCount, errorCount, hasSpamDetection)
 
 	if successCount == 0 && errorCount > 0 {
-		return fmt.Errorf("all API checks failed: %v", lastError)
+		return collectStatuses(), fmt.Errorf("all API checks failed: %v", lastError)
 	}
 
-	return nil
+	return collectStatuses(), nil
+}
+
+// checkAPIServiceWithRetries checks phone with a single API service, retrying on retryable errors
+func (s *CheckService) checkAPIServiceWithRetries(ctx context.Context, phone *models.PhoneNumber, api models.APIService, policy *checkRetryPolicy) APICheckResult {
+	log := logger.EntryWithContext(s.log, ctx).WithFields(logrus.Fields{
+		"method": "checkAPIServiceWithRetries",
+		"phone":  logger.FormatPhone(phone.Number),
+		"api":    api.Name,
+	})
+
+	result := APICheckResult{
+		PhoneID:    phone.ID,
+		APIService: &api,
+	}
+
+	// Check context
+	select {
+	case <-ctx.Done():
+		result.Error = ctx.Err()
+		return result
+	default:
+	}
+
+	timeline := s.newCheckTimeline(ctx, phone.ID, api.ServiceCode, nil, &api.ID)
+	timeline.record(CheckEventStarted, "API %s", api.Name)
+	ctx = contextWithCheckTimeline(ctx, timeline)
+
+	for retry := 0; retry <= policy.APIMaxRetries; retry++ {
+		// Check context before retry
+		select {
+		case <-ctx.Done():
+			result.Error = ctx.Err()
+			return result
+		default:
+		}
+
+		log.Infof("Checking phone %s via API %s (attempt %d/%d)",
+			logger.FormatPhone(phone.Number), api.Name, retry+1, policy.APIMaxRetries+1)
+
+		// Slot is held only for the call itself, not while waiting to retry
+		if err := sharedAPICheckLimiter.acquire(ctx, s.tuning.APIMaxConcurrency()); err != nil {
+			result.Error = err
+			return result
+		}
+		checkResult, err := s.apiService.WithContext(ctx).CheckPhoneViaAPI(phone, &api)
+		sharedAPICheckLimiter.release()
+		if err != nil {
+			if retry < policy.APIMaxRetries && s.isRetryableError(err) {
+				if policy.takeRetry() {
+					log.Warnf("API check failed, retrying: %v", err)
+					timeline.record(CheckEventRetry, "attempt %d failed: %v", retry+1, err)
+					time.Sleep(s.tuning.RetryDelay())
+					continue
+				}
+				log.Warnf("Retry budget exhausted, not retrying API %s: %v", api.Name, err)
+			}
+			result.Error = err
+			if service, lookupErr := s.getServiceByCode(api.ServiceCode); lookupErr == nil {
+				s.recordCheckError(ctx, phone.ID, service.ID, &api.ID, err)
+			}
+			return result
+		}
+
+		result.Result = checkResult
+		timeline.record(CheckEventVerdict, "%s, keywords: %v", checkResult.Status, []string(checkResult.FoundKeywords))
+		timeline.attach(checkResult.ID)
+
+		// Statistics were updated along with the result, service is only attached for the caller
+		var service models.SpamService
+		if err := s.db.First(&service, checkResult.ServiceID).Error; err == nil {
+			result.Service = &service
+		} else {
+			log.Warnf("Failed to get service after check: %v", err)
+		}
+
+		return result
+	}
+
+	return result
 }
 
 // adbCheckWorker processes ADB check tasks
@@ -536,15 +836,17 @@ func (s *CheckService) adbCheckWorker(taskChan <-chan CheckTask, resultChan chan
 		select {
 		case <-task.Context.Done():
 			resultChan <- ConcurrentCheckResult{
-				PhoneID: task.PhoneID,
-				Error:   task.Context.Err(),
+				PhoneID:   task.PhoneID,
+				GatewayID: task.GatewayID,
+				Error:     task.Context.Err(),
 			}
 			continue
 		default:
 		}
 
 		result := ConcurrentCheckResult{
-			PhoneID: task.PhoneID,
+			PhoneID:   task.PhoneID,
+			GatewayID: task.GatewayID,
 		}
 
 		// Get gateway
@@ -565,10 +867,15 @@ func (s *CheckService) adbCheckWorker(taskChan <-chan CheckTask, resultChan chan
 		}
 		result.Service = &service
 
+		timeline := s.newCheckTimeline(task.Context, task.Phone.ID, service.Code, &gateway.ID, nil)
+		timeline.record(CheckEventStarted, "gateway %s", gateway.Name)
+		ctx := contextWithCheckTimeline(task.Context, timeline)
+
 		// Try to perform check with retries (non-recursive)
-		err = s.checkOnGatewayWithRetryNonRecursive(task.Context, task.Phone, gateway, &service)
+		err = s.checkOnGatewayWithRetryNonRecursive(ctx, task.Phone, gateway, &service)
 		if err != nil {
 			result.Error = err
+			s.recordCheckError(ctx, task.Phone.ID, service.ID, nil, err)
 		} else {
 			// Get the created result
 			var checkResult models.CheckResult
@@ -584,16 +891,15 @@ func (s *CheckService) adbCheckWorker(taskChan <-chan CheckTask, resultChan chan
 
 // checkOnGatewayWithRetryNonRecursive performs check on gateway with retry logic (non-recursive)
 func (s *CheckService) checkOnGatewayWithRetryNonRecursive(ctx context.Context, phone *models.PhoneNumber, gateway *models.ADBGateway, service *models.SpamService) error {
-	log := s.log.WithFields(logrus.Fields{
+	log := logger.EntryWithContext(s.log, ctx).WithFields(logrus.Fields{
 		"method":  "checkOnGatewayWithRetryNonRecursive",
-		"phone":   phone.Number,
+		"phone":   logger.FormatPhone(phone.Number),
 		"gateway": gateway.Name,
 	})
 
-	// Get gateway queue (acts as a semaphore)
-	queue := s.getGatewayQueue(gateway.ID)
+	policy := s.retryPolicyFromContext(ctx)
 
-	for retry := 0; retry <= s.maxRetries; retry++ {
+	for retry := 0; retry <= policy.ADBMaxRetries; retry++ {
 		// Check context
 		select {
 		case <-ctx.Done():
@@ -607,156 +913,235 @@ func (s *CheckService) checkOnGatewayWithRetryNonRecursive(ctx context.Context,
 			maxWaitTime = 10 * time.Second // Shorter wait on retries
 		}
 
-		select {
-		case queue <- struct{}{}:
-			// Successfully acquired slot
-			log.Infof("Acquired gateway %s for checking %s (attempt %d/%d)",
-				gateway.Name, phone.Number, retry+1, s.maxRetries+1)
-
-			// Perform the actual check
-			err := s.performGatewayCheck(phone, gateway, service)
-
-			// Release slot
-			<-queue
-
-			if err != nil {
-				// Check if we should retry
-				if retry < s.maxRetries && s.isRetryableError(err) {
-					log.Warnf("Check failed on gateway %s, will retry: %v", gateway.Name, err)
-					time.Sleep(s.retryDelay)
-					continue // Try next iteration
-				}
-				return err
-			}
-
-			// Success
-			return nil
-
-		case <-time.After(maxWaitTime):
+		release, err := s.gatewayLocks.Acquire(ctx, gateway.ID, maxWaitTime)
+		if errors.Is(err, errLockTimeout) {
 			// Timeout waiting for gateway
 			log.Warnf("Timeout waiting for gateway %s (attempt %d/%d)",
-				gateway.Name, retry+1, s.maxRetries+1)
+				gateway.Name, retry+1, policy.ADBMaxRetries+1)
 
-			if retry < s.maxRetries {
-				time.Sleep(s.retryDelay)
+			if retry < policy.ADBMaxRetries && policy.takeRetry() {
+				checkTimelineFromContext(ctx).record(CheckEventRetry, "gateway busy on attempt %d", retry+1)
+				time.Sleep(s.tuning.RetryDelay())
 				continue // Try next iteration
 			}
 
-			return fmt.Errorf("gateway %s is busy after %d retries", gateway.Name, s.maxRetries)
+			return fmt.Errorf("gateway %s is busy after %d retries", gateway.Name, retry)
+		}
+		if err != nil {
+			return err
+		}
 
-		case <-ctx.Done():
-			return ctx.Err()
+		log.Infof("Acquired gateway %s for checking %s (attempt %d/%d)",
+			gateway.Name, logger.FormatPhone(phone.Number), retry+1, policy.ADBMaxRetries+1)
+
+		// Perform the actual check
+		err = s.performGatewayCheck(ctx, phone, gateway, service)
+		release()
+
+		if err != nil {
+			// Check if we should retry
+			if retry < policy.ADBMaxRetries && s.isRetryableError(err) {
+				if policy.takeRetry() {
+					log.Warnf("Check failed on gateway %s, will retry: %v", gateway.Name, err)
+					checkTimelineFromContext(ctx).record(CheckEventRetry, "attempt %d failed: %v", retry+1, err)
+					time.Sleep(s.tuning.RetryDelay())
+					continue // Try next iteration
+				}
+				log.Warnf("Retry budget exhausted, not retrying gateway %s: %v", gateway.Name, err)
+			}
+			return err
 		}
+
+		// Success
+		return nil
 	}
 
-	return fmt.Errorf("failed after %d retries", s.maxRetries)
+	return fmt.Errorf("failed after %d retries", policy.ADBMaxRetries)
 }
 
 // performGatewayCheck performs the actual check on gateway
-func (s *CheckService) performGatewayCheck(phone *models.PhoneNumber, gateway *models.ADBGateway, service *models.SpamService) error {
-	log := s.log.WithFields(logrus.Fields{
-		"method":  "performGatewayCheck",
-		"phone":   phone.Number,
-		"gateway": gateway.Name,
-	})
-
-	// Ensure app is running
-	appPackage, appActivity := s.getAppInfo(gateway.ServiceCode)
-	if appPackage != "" && appActivity != "" {
-		if err := s.adbService.StartApp(gateway.ID, appPackage, appActivity); err != nil {
-			log.Warnf("Failed to start app: %v", err)
-		}
-		time.Sleep(2 * time.Second)
+func (s *CheckService) performGatewayCheck(ctx context.Context, phone *models.PhoneNumber, gateway *models.ADBGateway, service *models.SpamService) error {
+	// Call left over from a previous check would cover the app's caller screen
+	if _, err := s.adbService.WithContext(ctx).EnsureCallIdle(gateway.ID); err != nil {
+		return fmt.Errorf("gateway %s is not ready for a new call: %w", gateway.Name, err)
 	}
 
-	// Simulate incoming call
-	log.Infof("Simulating incoming call from %s", phone.Number)
-	if err := s.adbService.SimulateIncomingCall(gateway.ID, phone.Number); err != nil {
-		return fmt.Errorf("failed to simulate incoming call: %w", err)
+	// Calls in quick succession may be merged or ignored by the caller-ID app
+	if err := s.awaitCallSlot(ctx, gateway); err != nil {
+		return err
 	}
 
-	// Wait for the service to process
-	time.Sleep(5 * time.Second)
-
-	// Take screenshot
-	screenshot, err := s.adbService.TakeScreenshot(gateway.ID)
+	// Run service check script (defaults to call simulation flow)
+	screenshot, err := s.runCheckScript(ctx, s.getCheckScript(service), phone, gateway)
+	if markErr := s.callSpacing.markCall(gateway.ID, time.Now()); markErr != nil {
+		logger.EntryWithContext(s.log, ctx).Warnf("%v", markErr)
+	}
 	if err != nil {
-		log.Errorf("Failed to take screenshot: %v", err)
-		screenshot = []byte{}
+		return err
 	}
 
-	// End the call
-	if err := s.adbService.EndCall(gateway.ID, onlyDigits(phone.Number)); err != nil {
-		log.Warnf("Failed to end call: %v", err)
+	// Crash dialog on screen means the screenshot has no caller information
+	if crash := detectCrashDialog(s.adbService.gatewayExecutor(gateway)); crash != nil {
+		s.handleAppCrash(gateway, service, crash)
+		return crash
 	}
 
 	// Process and save results
-	return s.processCheckResult(phone, service, screenshot)
+	err = s.processCheckResult(ctx, phone, service, gateway, screenshot)
+	var crash *AppCrash
+	if errors.As(err, &crash) {
+		s.handleAppCrash(gateway, service, crash)
+	}
+	return err
 }
 
 // processCheckResult processes and saves check result
-func (s *CheckService) processCheckResult(phone *models.PhoneNumber, service *models.SpamService, screenshot []byte) error {
-	log := s.log.WithFields(logrus.Fields{
+func (s *CheckService) processCheckResult(ctx context.Context, phone *models.PhoneNumber, service *models.SpamService, gateway *models.ADBGateway, screenshot []byte) error {
+	log := logger.EntryWithContext(s.log, ctx).WithFields(logrus.Fields{
 		"method":  "processCheckResult",
-		"phone":   phone.Number,
+		"phone":   logger.FormatPhone(phone.Number),
 		"service": service.Name,
 	})
 
-	// Save screenshot
-	var screenshotPath string
+	// Save screenshot, a storage failure is recorded on the result and does not fail the check
+	var screenshotRef, screenshotError string
 	if len(screenshot) > 0 {
+		screenshot = normalizeScreenshot(screenshot)
 		var err error
-		screenshotPath, err = s.saveScreenshot(screenshot, phone.Number, service.Code)
+		screenshotRef, err = s.saveScreenshot(ctx, screenshot, phone.Number, service.Code)
 		if err != nil {
 			log.Errorf("Failed to save screenshot: %v", err)
+			screenshotError = err.Error()
 		}
 	}
 
 	// Perform OCR
+	serviceConfig := s.serviceConfig(service)
+	timeline := checkTimelineFromContext(ctx)
 	var ocrText string
-	if screenshotPath != "" {
+	if len(screenshot) > 0 {
 		var err error
-		ocrText, err = s.performOCR(screenshotPath)
+		if err = sharedOCRLimiter.acquire(ctx, ocrMaxConcurrency(s.db)); err == nil {
+			ocrText, err = s.performOCR(screenshot, serviceConfig.OCR.Language)
+			sharedOCRLimiter.release()
+		} else {
+			err = fmt.Errorf("waiting for OCR slot: %w", err)
+		}
 		if err != nil {
 			log.Errorf("Failed to perform OCR: %v", err)
+			timeline.record(CheckEventOCRDone, "OCR failed: %v", err)
+		} else {
+			timeline.record(CheckEventOCRDone, "%d characters recognized", utf8.RuneCountInString(ocrText))
 		}
 	}
 
-	// Check for spam keywords
-	isSpam, foundKeywords := s.checkForSpamKeywords(ocrText, service.ID)
+	// Crash dialog text must not be judged as caller information
+	if crash := detectCrashText(ocrText, serviceConfig.OCR.BlockPhrases); crash != nil {
+		return crash
+	}
+
+	// App explicitly marks the number safe, keywords matched elsewhere on screen are incidental
+	var isSpam bool
+	var foundKeywords []string
+	var keywordMatches []models.KeywordMatch
+	cleanPhrases := matchPhrases(ocrText, serviceConfig.OCR.CleanPhrases)
+	if len(cleanPhrases) == 0 {
+		isSpam, foundKeywords, keywordMatches = s.checkForSpamKeywords(ocrText, service.ID)
+	}
 
 	// Create result
 	result := &models.CheckResult{
-		PhoneNumberID: phone.ID,
-		ServiceID:     service.ID,
-		IsSpam:        isSpam,
-		FoundKeywords: models.StringArray(foundKeywords),
-		Screenshot:    screenshotPath,
-		RawText:       ocrText,
-		CheckedAt:     time.Now(),
-	}
-
-	// Use transaction to ensure atomic write
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		// Save result
-		if err := tx.Create(result).Error; err != nil {
-			return fmt.Errorf("failed to save check result: %w", err)
+		PhoneNumberID:   phone.ID,
+		ServiceID:       service.ID,
+		IsSpam:          isSpam,
+		Confidence:      ConfidenceOCR,
+		FoundKeywords:   models.StringArray(foundKeywords),
+		KeywordMatches:  models.KeywordMatches(keywordMatches),
+		CleanPhrases:    models.StringArray(cleanPhrases),
+		Screenshot:      screenshotRef,
+		ScreenshotError: screenshotError,
+		RawText:         ocrText,
+		GatewayID:       &gateway.ID,
+		CheckedAt:       time.Now(),
+	}
+
+	// Decision rules of the service override the keyword verdict, clean phrases still win
+	if len(cleanPhrases) == 0 && len(serviceConfig.DecisionRules) > 0 {
+		applyDecisionRules(result, serviceConfig.DecisionRules, decisionEnv("", ocrText, foundKeywords))
+		if result.DecisionError != "" {
+			log.Warnf("Decision rules of %s failed, keyword verdict kept: %s", service.Name, result.DecisionError)
 		}
+	}
 
-		// Update statistics
-		return s.updateStatisticsInTx(tx, phone.ID, service.ID, isSpam)
-	})
+	// Short OCR output is usually a bad read, don't trust it as clean
+	minTextLength := *serviceConfig.OCR.MinTextLength
+	textLength := utf8.RuneCountInString(strings.TrimSpace(ocrText))
+	inconclusive := !result.IsSpam && len(cleanPhrases) == 0 && (textLength == 0 || textLength < minTextLength)
+	result.Inconclusive = inconclusive
 
+	// Save result with statistics, a briefly unavailable database delays or journals it instead of failing the check
+	persisted, err := persistCheckResult(ctx, s.db, result)
 	if err != nil {
 		return err
 	}
+	if persisted == PersistJournaled {
+		timeline.record(CheckEventVerdict, "%s, result journaled until the database is back", result.Status)
+		log.Warnf("Check completed for %s on %s, result journaled: isSpam=%v, keywords=%v",
+			logger.FormatPhone(phone.Number), service.Name, result.IsSpam, foundKeywords)
+		return nil
+	}
+
+	switch {
+	case len(cleanPhrases) > 0:
+		timeline.record(CheckEventVerdict, "%s, clean phrases: %v", result.Status, cleanPhrases)
+	case result.DecisionRule != "":
+		timeline.record(CheckEventVerdict, "%s, decision rule %q, keywords: %v", result.Status, result.DecisionRule, foundKeywords)
+	default:
+		timeline.record(CheckEventVerdict, "%s, keywords: %v", result.Status, foundKeywords)
+	}
+	timeline.attach(result.ID)
+
+	if inconclusive {
+		log.Warnf("Check inconclusive for %s on %s: OCR text shorter than %d characters",
+			logger.FormatPhone(phone.Number), service.Name, minTextLength)
+		return nil
+	}
+
+	if len(cleanPhrases) > 0 {
+		log.Infof("Check completed for %s on %s: clean by app label %v", logger.FormatPhone(phone.Number), service.Name, cleanPhrases)
+		return nil
+	}
 
 	log.Infof("Check completed for %s on %s: isSpam=%v, keywords=%v",
-		phone.Number, service.Name, isSpam, foundKeywords)
+		logger.FormatPhone(phone.Number), service.Name, result.IsSpam, foundKeywords)
 
 	return nil
 }
 
+// recordCheckError stores failed check with error status. Cancelled checks are not recorded.
+func (s *CheckService) recordCheckError(ctx context.Context, phoneID, serviceID uint, apiServiceID *uint, checkErr error) {
+	timeline := checkTimelineFromContext(ctx)
+	timeline.record(CheckEventFailed, "%v", checkErr)
+	if errors.Is(checkErr, context.Canceled) {
+		return
+	}
+	result, err := recordCheckError(s.db, phoneID, serviceID, apiServiceID, checkErr)
+	if err != nil {
+		logger.EntryWithContext(s.log, ctx).Errorf("Failed to record check error: %v", err)
+		return
+	}
+	timeline.attach(result.ID)
+}
+
+// getServiceByCode gets spam service by code
+func (s *CheckService) getServiceByCode(code string) (*models.SpamService, error) {
+	var service models.SpamService
+	if err := s.db.Where("code = ?", code).First(&service).Error; err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
 // isRetryableError determines if an error should trigger a retry
 func (s *CheckService) isRetryableError(err error) bool {
 	if err == nil {
@@ -772,6 +1157,7 @@ func (s *CheckService) isRetryableError(err error) bool {
 		"device not responding",
 		"failed to take screenshot",
 		"failed to simulate call",
+		"app crashed during check", // App is restarted after the crash dialog is dismissed
 		"connection refused",
 		"deadline exceeded",
 		"temporary failure",
@@ -792,42 +1178,22 @@ func (s *CheckService) isRetryableError(err error) bool {
 	return false
 }
 
-// updateStatisticsInTx updates statistics within a transaction
-func (s *CheckService) updateStatisticsInTx(tx *gorm.DB, phoneID, serviceID uint, isSpam bool) error {
+// updateStatisticsAtInTx counts a check made at checkedAt, which may be in the past for imported results.
+// Status is one of spam, clean, inconclusive, suspected or error, see countStatisticsResult.
+func updateStatisticsAtInTx(tx *gorm.DB, phoneID, serviceID uint, status string, checkedAt time.Time) error {
 	var stats models.Statistics
-
-	// Try to find existing statistics
 	err := tx.Where("phone_number_id = ? AND service_id = ?", phoneID, serviceID).First(&stats).Error
-
-	if err == gorm.ErrRecordNotFound {
-		// Create new statistics
-		stats = models.Statistics{
-			PhoneNumberID: phoneID,
-			ServiceID:     serviceID,
-			TotalChecks:   1,
-			LastCheckDate: time.Now(),
-		}
-		if isSpam {
-			stats.SpamCount = 1
-			now := time.Now()
-			stats.FirstSpamDate = &now
-		}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		stats = models.Statistics{PhoneNumberID: phoneID, ServiceID: serviceID}
+		countStatisticsResult(&stats, status, checkedAt)
 		return tx.Create(&stats).Error
-	} else if err == nil {
-		// Update existing statistics
-		stats.TotalChecks++
-		stats.LastCheckDate = time.Now()
-		if isSpam {
-			stats.SpamCount++
-			if stats.FirstSpamDate == nil {
-				now := time.Now()
-				stats.FirstSpamDate = &now
-			}
-		}
-		return tx.Save(&stats).Error
+	case err != nil:
+		return err
 	}
 
-	return err
+	countStatisticsResult(&stats, status, checkedAt)
+	return tx.Save(&stats).Error
 }
 
 // CheckAllPhones checks all active phone numbers with proper queue management
@@ -846,14 +1212,7 @@ func (s *CheckService) CheckAllPhones() error {
 		return nil
 	}
 
-	// Get max concurrent phone checks setting
-	var maxConcurrent int = 3
-	if setting, err := NewSettingsService(s.db).GetSettingValue("max_concurrent_checks"); err == nil {
-		if val, ok := setting.(int); ok && val > 0 {
-			maxConcurrent = val
-		}
-	}
-
+	maxConcurrent := s.maxConcurrentChecks()
 	log.Infof("Starting check for %d phones with max %d concurrent checks", len(phones), maxConcurrent)
 
 	// Create context with timeout for all checks
@@ -886,18 +1245,18 @@ func (s *CheckService) CheckAllPhones() error {
 				default:
 				}
 
-				log.Infof("[Worker %d] Starting check for phone: %s", workerID, phone.Number)
+				log.Infof("[Worker %d] Starting check for phone: %s", workerID, logger.FormatPhone(phone.Number))
 
 				if err := s.CheckPhoneNumber(phone.ID); err != nil {
 					// Don't count "already being checked" as error
 					if !strings.Contains(err.Error(), "already being checked") {
-						errorChan <- fmt.Errorf("phone %s: %w", phone.Number, err)
-						log.Errorf("[Worker %d] Failed to check phone %s: %v", workerID, phone.Number, err)
+						errorChan <- fmt.Errorf("phone %s: %w", logger.FormatPhone(phone.Number), err)
+						log.Errorf("[Worker %d] Failed to check phone %s: %v", workerID, logger.FormatPhone(phone.Number), err)
 					} else {
-						log.Warnf("[Worker %d] Phone %s is already being checked", workerID, phone.Number)
+						log.Warnf("[Worker %d] Phone %s is already being checked", workerID, logger.FormatPhone(phone.Number))
 					}
 				} else {
-					log.Infof("[Worker %d] Completed check for phone: %s", workerID, phone.Number)
+					log.Infof("[Worker %d] Completed check for phone: %s", workerID, logger.FormatPhone(phone.Number))
 				}
 			}
 		}(i)
@@ -921,25 +1280,21 @@ func (s *CheckService) CheckAllPhones() error {
 	return nil
 }
 
+// maxConcurrentChecks returns number of phones checked at once by bulk checks
+func (s *CheckService) maxConcurrentChecks() int {
+	if setting, err := NewSettingsService(s.db).GetSettingValue("max_concurrent_checks"); err == nil {
+		if val, ok := setting.(int); ok && val > 0 {
+			return val
+		}
+	}
+	return 3
+}
+
 // GetDB returns database instance
 func (s *CheckService) GetDB() *gorm.DB {
 	return s.db
 }
 
-// getPhoneCheckLock returns a lock for specific phone's checks
-func (s *CheckService) getPhoneCheckLock(phoneID uint) *sync.Mutex {
-	s.phoneCheckMu.Lock()
-	defer s.phoneCheckMu.Unlock()
-
-	if lock, exists := s.phoneCheckLocks[phoneID]; exists {
-		return lock
-	}
-
-	lock := &sync.Mutex{}
-	s.phoneCheckLocks[phoneID] = lock
-	return lock
-}
-
 // Helper methods
 func (s *CheckService) getCheckMode() models.CheckMode {
 	var setting models.SystemSettings
@@ -949,68 +1304,120 @@ func (s *CheckService) getCheckMode() models.CheckMode {
 	return models.CheckMode(setting.Value)
 }
 
-func (s *CheckService) saveScreenshot(data []byte, phoneNumber, serviceCode string) (string, error) {
-	dir := filepath.Join("screenshots", serviceCode)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %w", err)
+// validateCheckMode checks that mode is one of known check modes
+func validateCheckMode(mode models.CheckMode) error {
+	switch mode {
+	case models.CheckModeADBOnly, models.CheckModeAPIOnly, models.CheckModeBoth:
+		return nil
 	}
+	return fmt.Errorf("check mode must be one of %s, %s, %s", models.CheckModeADBOnly, models.CheckModeAPIOnly, models.CheckModeBoth)
+}
 
-	filename := fmt.Sprintf("%s_%s_%d.png", phoneNumber, serviceCode, time.Now().Unix())
-	path := filepath.Join(dir, filename)
-
+// normalizeScreenshot re-encodes captured image as PNG, data that cannot be decoded is kept as is
+func normalizeScreenshot(data []byte) []byte {
 	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
-		if err := os.WriteFile(path, data, 0644); err != nil {
-			return "", fmt.Errorf("failed to save screenshot: %w", err)
-		}
-		return path, nil
+		return data
 	}
 
-	file, err := os.Create(path)
-	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return data
 	}
-	defer file.Close()
+	return buf.Bytes()
+}
 
-	if err := png.Encode(file, img); err != nil {
-		return "", fmt.Errorf("failed to encode image: %w", err)
-	}
+// saveScreenshot stores screenshot in configured storage and returns its reference
+func (s *CheckService) saveScreenshot(ctx context.Context, data []byte, phoneNumber, serviceCode string) (string, error) {
+	key := path.Join("screenshots", serviceCode, fmt.Sprintf("%s_%s_%d.png", phoneNumber, serviceCode, time.Now().Unix()))
+	return s.storage.Save(ctx, key, data, "image/png")
+}
 
-	return path, nil
+// GetScreenshot reads screenshot of a check result from storage
+func (s *CheckService) GetScreenshot(ctx context.Context, resultID uint) ([]byte, error) {
+	var result models.CheckResult
+	if err := s.db.Select("id", "screenshot").First(&result, resultID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("result not found")
+		}
+		return nil, fmt.Errorf("failed to get result: %w", err)
+	}
+	if result.Screenshot == "" {
+		return nil, ErrStoredFileNotFound
+	}
+	return s.storage.Open(ctx, result.Screenshot)
 }
 
-func (s *CheckService) performOCR(imagePath string) (string, error) {
-	cmd := exec.Command(s.cfg.OCR.TesseractPath, imagePath, "stdout", "-l", s.cfg.OCR.Language)
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("OCR failed: %w", err)
+// performOCR recognizes screenshot text, image is piped to tesseract so it works with any storage backend.
+// Non-empty language overrides the configured one.
+func (s *CheckService) performOCR(image []byte, language string) (string, error) {
+	// Development mode has no tesseract, return canned text
+	if s.cfg.App.DevMode {
+		s.log.Debug("Development mode: skipping OCR")
+		return devOCRText, nil
+	}
+
+	// Read on every call so OCR config updates apply to the next check
+	ocr := s.ocrConfig()
+	if language != "" {
+		ocr.Language = language
 	}
-	return string(output), nil
+	return sharedOCRPool.recognize(ocrEngine(s.db), ocr.TesseractPath, ocr.Language, image, ocrMaxConcurrency(s.db))
 }
 
-func (s *CheckService) checkForSpamKeywords(text string, serviceID uint) (bool, []string) {
+// matchPhrases returns phrases found in OCR text, case-insensitive
+func matchPhrases(text string, phrases []string) []string {
 	text = strings.ToLower(text)
-	var foundKeywords []string
+	var matched []string
+	for _, phrase := range phrases {
+		if strings.Contains(text, strings.ToLower(phrase)) {
+			matched = append(matched, phrase)
+		}
+	}
+	return matched
+}
 
+// checkForSpamKeywords finds spam keywords of service in OCR text along with where they occur
+func (s *CheckService) checkForSpamKeywords(text string, serviceID uint) (bool, []string, []models.KeywordMatch) {
 	var keywords []models.SpamKeyword
 	query := s.db.Where("is_active = ?", true)
 	query = query.Where("service_id IS NULL OR service_id = ?", serviceID)
 
 	if err := query.Find(&keywords).Error; err != nil {
 		s.log.Errorf("Failed to get spam keywords: %v", err)
-		return false, foundKeywords
+		return false, nil, nil
 	}
 
+	found := newFoundKeywords()
+	searched := newKeywordText(text, models.KeywordMatchText)
+	negations := globalKeywordNegations(s.db)
 	for _, keyword := range keywords {
-		if strings.Contains(text, strings.ToLower(keyword.Keyword)) {
-			foundKeywords = append(foundKeywords, keyword.Keyword)
+		if matches := searched.find(keyword.Keyword, spamKeywordNegations(negations, keyword), keyword.WholeWord); len(matches) > 0 {
+			found.add(keyword.Keyword, matches)
 		}
 	}
 
-	return len(foundKeywords) > 0, foundKeywords
+	return len(found.keywords) > 0, found.keywords, found.matches
+}
+
+// serviceConfig returns effective config of service, read on every call so updates apply to the next check
+func (s *CheckService) serviceConfig(service *models.SpamService) ServiceConfig {
+	defaults := loadServiceConfigDefaults(s.db, s.tuning, s.ocrConfig())
+	return storedServiceConfig(service).effective(service.Code, defaults)
 }
 
+// getAppInfo returns package and activity of caller-ID app configured for a service
 func (s *CheckService) getAppInfo(serviceCode string) (string, string) {
+	service, err := s.getServiceByCode(serviceCode)
+	if err != nil {
+		return defaultAppInfo(serviceCode)
+	}
+	cfg := storedServiceConfig(service)
+	return cfg.appInfo(serviceCode)
+}
+
+// defaultAppInfo returns built-in caller-ID app package and activity for a service
+func defaultAppInfo(serviceCode string) (string, string) {
 	switch serviceCode {
 	case "yandex_aon":
 		return "ru.yandex.whocalls", "ru.yandex.whocalls.MainActivity"
@@ -1040,7 +1447,7 @@ func (s *CheckService) CheckPhoneRealtime(phoneNumber string) (map[string]interf
 
 	// Check if phone already exists
 	var existingPhone models.PhoneNumber
-	err := s.db.Where("number = ?", phoneNumber).First(&existingPhone).Error
+	err := s.db.Where("number = ? OR normalized_number = ?", phoneNumber, phoneNumber).First(&existingPhone).Error
 
 	if err == nil {
 		// Phone exists - check if we have recent results
@@ -1056,6 +1463,7 @@ func (s *CheckService) CheckPhoneRealtime(phoneNumber string) (map[string]interf
 			latestCheck := recentResults[0].CheckedAt
 			if time.Since(latestCheck) < time.Hour {
 				// Return cached results
+				freshness := LoadResultFreshness(s.db).ForPhone(s.db, existingPhone.ID)
 				results := make(map[string]interface{})
 				results["phone_number"] = phoneNumber
 				results["checked_at"] = latestCheck
@@ -1066,8 +1474,12 @@ func (s *CheckService) CheckPhoneRealtime(phoneNumber string) (map[string]interf
 					serviceResult := map[string]interface{}{
 						"service":        result.Service.Name,
 						"is_spam":        result.IsSpam,
+						"inconclusive":   result.Inconclusive,
+						"status":         result.Status,
+						"confidence":     result.Confidence,
 						"found_keywords": []string(result.FoundKeywords),
 						"checked_at":     result.CheckedAt,
+						"is_stale":       freshness.IsStale(result.ServiceID, result.CheckedAt),
 					}
 
 					// Add source information
@@ -1086,26 +1498,38 @@ func (s *CheckService) CheckPhoneRealtime(phoneNumber string) (map[string]interf
 					serviceResults = append(serviceResults, serviceResult)
 				}
 				results["results"] = serviceResults
+				results["verdict_freshness"] = freshness.Verdict(recentResults)
+				results["degraded"] = false
 
-				log.Infof("Returning cached results for phone %s", phoneNumber)
+				log.Infof("Returning cached results for phone %s", logger.FormatPhone(phoneNumber))
 				return results, nil
 			}
 		}
 
 		// Results are old or don't exist - perform new check
-		log.Infof("Phone %s exists but results are old, performing new check", phoneNumber)
-		if err := s.CheckPhoneNumber(existingPhone.ID); err != nil {
+		log.Infof("Phone %s exists but results are old, performing new check", logger.FormatPhone(phoneNumber))
+		report, err := s.CheckPhoneNumberDetailed(existingPhone.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check phone: %w", err)
+		}
+		results, err := s.getPhoneResults(&existingPhone)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyCheckReport(results, report); err != nil {
 			return nil, fmt.Errorf("failed to check phone: %w", err)
 		}
-		return s.getPhoneResults(&existingPhone)
+		return results, nil
 	}
 
-	// Phone doesn't exist - create temporary phone for realtime check
+	// Phone doesn't exist - create temporary phone for realtime check, swept by SweepRealtimePhones
 	tempPhone := &models.PhoneNumber{
-		Number:      phoneNumber,
-		Description: "Realtime check",
-		IsActive:    false, // Don't include in scheduled checks
-		CreatedBy:   1,     // System user ID
+		Number:           phoneNumber,
+		NormalizedNumber: &phoneNumber,
+		Description:      realtimePhoneDescription,
+		Origin:           models.PhoneOriginRealtime,
+		IsActive:         false, // Don't include in scheduled checks
+		CreatedBy:        1,     // System user ID
 	}
 
 	// Save phone record
@@ -1114,19 +1538,101 @@ func (s *CheckService) CheckPhoneRealtime(phoneNumber string) (map[string]interf
 	}
 
 	// Perform check
-	checkErr := s.CheckPhoneNumber(tempPhone.ID)
+	report, checkErr := s.CheckPhoneNumberDetailed(tempPhone.ID)
 
 	// Get results
 	results, _ := s.getPhoneResults(tempPhone)
+	if checkErr == nil && results != nil {
+		checkErr = applyCheckReport(results, report)
+	} else if checkErr == nil {
+		checkErr = report.Err
+	}
+
+	// Clean up temporary phone only if nothing was obtained
+	if checkErr != nil {
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			return deletePhones(tx, []uint{tempPhone.ID})
+		}); err != nil {
+			log.Warnf("Failed to clean up realtime phone %d: %v", tempPhone.ID, err)
+		}
+		return nil, checkErr
+	}
+
+	return results, nil
+}
+
+// CheckPhoneNow runs a check for a single phone synchronously and returns fresh results
+func (s *CheckService) CheckPhoneNow(phoneID uint) (map[string]interface{}, error) {
+	log := s.log.WithFields(logrus.Fields{
+		"method":  "CheckPhoneNow",
+		"phoneID": phoneID,
+	})
+
+	var phone models.PhoneNumber
+	if err := s.db.First(&phone, phoneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPhoneNotFound
+		}
+		return nil, fmt.Errorf("failed to get phone: %w", err)
+	}
+
+	// Run check with an upper bound on total wait (lock acquisition + check)
+	type checkOutcome struct {
+		report *PhoneCheckReport
+		err    error
+	}
+	outcomeChan := make(chan checkOutcome, 1)
+	go func() {
+		report, err := s.CheckPhoneNumberDetailed(phoneID)
+		outcomeChan <- checkOutcome{report: report, err: err}
+	}()
+
+	var report *PhoneCheckReport
+	select {
+	case outcome := <-outcomeChan:
+		if outcome.err != nil {
+			if errors.Is(outcome.err, ErrCheckInProgress) || errors.Is(outcome.err, ErrPhoneBlocked) {
+				return nil, outcome.err
+			}
+			log.Errorf("Check failed for phone %s: %v", logger.FormatPhone(phone.Number), outcome.err)
+			return nil, fmt.Errorf("check failed: %w", outcome.err)
+		}
+		report = outcome.report
+	case <-time.After(s.checkTimeout + 15*time.Second):
+		return nil, fmt.Errorf("check timeout for phone %s", logger.FormatPhone(phone.Number))
+	}
 
-	// If check failed and phone is temporary, clean it up
-	if checkErr != nil && !tempPhone.IsActive {
-		// Delete check results and phone
-		s.db.Where("phone_number_id = ?", tempPhone.ID).Delete(&models.CheckResult{})
-		s.db.Delete(tempPhone)
+	results, err := s.getPhoneResults(&phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyCheckReport(results, report); err != nil {
+		log.Errorf("Check failed for phone %s: %v", logger.FormatPhone(phone.Number), err)
+		return nil, fmt.Errorf("check failed: %w", err)
 	}
 
-	return results, checkErr
+	return results, nil
+}
+
+// applyCheckReport adds per-service statuses to results.
+// Error is returned only if nothing could be checked.
+func applyCheckReport(results map[string]interface{}, report *PhoneCheckReport) error {
+	results["service_status"] = report.Services
+	results["degraded"] = report.Degraded
+
+	if report.Err == nil {
+		return nil
+	}
+
+	serviceResults, _ := results["results"].([]map[string]interface{})
+	if len(serviceResults) == 0 && report.SucceededCount() == 0 {
+		return report.Err
+	}
+
+	// Partial results are still returned, failure is reflected in the degraded flag
+	results["degraded"] = true
+	return nil
 }
 
 func (s *CheckService) getPhoneResults(phone *models.PhoneNumber) (map[string]interface{}, error) {
@@ -1144,14 +1650,28 @@ func (s *CheckService) getPhoneResults(phone *models.PhoneNumber) (map[string]in
 	if err != nil {
 		return nil, fmt.Errorf("failed to get results: %w", err)
 	}
+	attachResultSources(s.db, checkResults)
 
+	freshness := LoadResultFreshness(s.db).ForPhone(s.db, phone.ID)
 	var serviceResults []map[string]interface{}
 	for _, result := range checkResults {
 		serviceResult := map[string]interface{}{
 			"service":        result.Service.Name,
 			"is_spam":        result.IsSpam,
+			"inconclusive":   result.Inconclusive,
+			"status":         result.Status,
+			"confidence":     result.Confidence,
 			"found_keywords": []string(result.FoundKeywords),
 			"checked_at":     result.CheckedAt,
+			"is_stale":       freshness.IsStale(result.ServiceID, result.CheckedAt),
+		}
+		if result.GatewayID != nil {
+			serviceResult["gateway_id"] = *result.GatewayID
+			serviceResult["gateway_name"] = result.GatewayName
+		}
+		if result.APIServiceID != nil {
+			serviceResult["api_service_id"] = *result.APIServiceID
+			serviceResult["api_service_name"] = result.APIServiceName
 		}
 
 		// Add extracted text if available (from API response)
@@ -1170,57 +1690,88 @@ func (s *CheckService) getPhoneResults(phone *models.PhoneNumber) (map[string]in
 		serviceResults = append(serviceResults, serviceResult)
 	}
 	results["results"] = serviceResults
+	results["verdict_freshness"] = freshness.Verdict(checkResults)
 
 	return results, nil
 }
 
-// GetCheckResults gets check results with filters
-func (s *CheckService) GetCheckResults(phoneID uint, serviceID uint, limit int) ([]models.CheckResult, error) {
+// CheckResultsFilter narrows check results, zero fields do not filter. Zero Limit returns all rows.
+type CheckResultsFilter struct {
+	PhoneID       uint
+	ServiceID     uint
+	GatewayID     uint // Results produced by this ADB gateway
+	APIServiceID  uint // Results produced by this API service
+	Source        string
+	Status        string
+	Keyword       string // Results that found this spam keyword
+	CheckedAfter  *time.Time
+	CheckedBefore *time.Time
+	Offset        int
+	Limit         int
+}
+
+// GetCheckResults gets check results with filters and total count
+func (s *CheckService) GetCheckResults(filter CheckResultsFilter) ([]models.CheckResult, int64, error) {
 	var results []models.CheckResult
 
 	query := s.db.Preload("Service")
 
-	if phoneID > 0 {
-		query = query.Where("phone_number_id = ?", phoneID)
+	if filter.PhoneID > 0 {
+		query = query.Where("phone_number_id = ?", filter.PhoneID)
 	}
 
-	if serviceID > 0 {
-		query = query.Where("service_id = ?", serviceID)
+	if filter.ServiceID > 0 {
+		query = query.Where("service_id = ?", filter.ServiceID)
 	}
 
-	if err := query.Order("checked_at DESC").Limit(limit).Find(&results).Error; err != nil {
-		return nil, fmt.Errorf("failed to get check results: %w", err)
+	if filter.GatewayID > 0 {
+		query = query.Where("gateway_id = ?", filter.GatewayID)
 	}
 
-	return results, nil
+	if filter.APIServiceID > 0 {
+		query = query.Where("api_service_id = ?", filter.APIServiceID)
+	}
+
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.Keyword != "" {
+		query = whereFoundKeyword(query, filter.Keyword)
+	}
+
+	if filter.CheckedAfter != nil {
+		query = query.Where("checked_at >= ?", *filter.CheckedAfter)
+	}
+
+	if filter.CheckedBefore != nil {
+		query = query.Where("checked_at < ?", *filter.CheckedBefore)
+	}
+
+	total, err := findPage(query.Order("checked_at DESC, id DESC"), filter.Offset, filter.Limit, &results)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get check results: %w", err)
+	}
+	attachResultSources(s.db, results)
+
+	return results, total, nil
 }
 
-// GetLatestResults gets latest results for all phones
-func (s *CheckService) GetLatestResults() ([]map[string]interface{}, error) {
-	var results []map[string]interface{}
-
-	query := `
-		SELECT DISTINCT ON (cr.phone_number_id, cr.service_id)
-			pn.id as phone_id,
-			pn.number as phone_number,
-			pn.description,
-			ss.id as service_id,
-			ss.name as service_name,
-			cr.is_spam,
-			cr.found_keywords,
-			cr.checked_at
-		FROM check_results cr
-		JOIN phone_numbers pn ON pn.id = cr.phone_number_id
-		JOIN spam_services ss ON ss.id = cr.service_id
-		WHERE pn.deleted_at IS NULL
-		ORDER BY cr.phone_number_id, cr.service_id, cr.checked_at DESC
-	`
-
-	if err := s.db.Raw(query).Scan(&results).Error; err != nil {
-		return nil, fmt.Errorf("failed to get latest results: %w", err)
+// whereFoundKeyword narrows query to results with keyword among found keywords. Postgres uses
+// array containment, which the GIN index serves; SQLite stores the array literal as text, so the
+// encoded element is searched between delimiters instead.
+func whereFoundKeyword(query *gorm.DB, keyword string) *gorm.DB {
+	if query.Dialector.Name() == "postgres" {
+		return query.Where("found_keywords @> ?", models.StringArray{keyword})
 	}
 
-	return results, nil
+	literal, _ := models.StringArray{keyword}.Value()
+	element := strings.TrimSuffix(strings.TrimPrefix(literal.(string), "{"), "}")
+	return query.Where("instr(',' || substr(found_keywords, 2, length(found_keywords) - 2) || ',', ?) > 0", ","+element+",")
 }
 
 // GetGatewayStatuses returns current status of all gateways
@@ -1232,10 +1783,9 @@ func (s *CheckService) GetGatewayStatuses() ([]map[string]interface{}, error) {
 
 	statuses := make([]map[string]interface{}, len(gateways))
 	for i, gateway := range gateways {
-		// Check queue status
-		queue := s.getGatewayQueue(gateway.ID)
-		queueLen := len(queue)
-		isBusy := queueLen > 0
+		// Holder and waiters of gateway lock
+		queueLen := s.gatewayLocks.Users(gateway.ID)
+		isBusy := s.gatewayLocks.IsHeld(gateway.ID)
 
 		// Determine actual status
 		actualStatus := gateway.Status
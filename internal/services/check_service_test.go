@@ -0,0 +1,17 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"spam-checker/internal/logger"
+)
+
+func TestCheckPhoneNowMissingPhone(t *testing.T) {
+	db := newTestDB(t)
+	service := &CheckService{db: db, log: logger.WithField("service", "CheckService")}
+
+	if _, err := service.CheckPhoneNow(999999); !errors.Is(err, ErrPhoneNotFound) {
+		t.Errorf("CheckPhoneNow() of missing phone = %v, want ErrPhoneNotFound", err)
+	}
+}
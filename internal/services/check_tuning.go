@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+	"spam-checker/internal/config"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tuningSettingRanges limits runtime check pipeline settings
+var tuningSettingRanges = map[string][2]int{
+	"check_phone_timeout_seconds": {5, 1800},
+	"adb_check_max_workers":       {1, 50},
+	"check_retry_delay_ms":        {0, 60000},
+	"adb_check_max_retries":       {0, 10},
+	"api_check_max_retries":       {0, 10},
+	"api_check_max_concurrent":    {1, 500},
+}
+
+// validateTuningSetting checks runtime check pipeline setting is within its range
+func validateTuningSetting(key string, value int) error {
+	limits, ok := tuningSettingRanges[key]
+	if !ok {
+		return nil
+	}
+	if value < limits[0] || value > limits[1] {
+		return fmt.Errorf("%s must be between %d and %d", key, limits[0], limits[1])
+	}
+	return nil
+}
+
+// CheckTuning provides check pipeline timings and limits.
+// Startup knobs come from configuration, runtime knobs are read from cached settings
+// on every call and fall back to configuration when missing or out of range.
+type CheckTuning struct {
+	cfg      config.CheckTuningConfig
+	settings *SettingsService
+}
+
+func NewCheckTuning(db *gorm.DB, cfg *config.Config) *CheckTuning {
+	return &CheckTuning{
+		cfg:      cfg.Check,
+		settings: NewSettingsService(db),
+	}
+}
+
+// runtimeInt returns setting value if it is within range, fallback otherwise
+func (t *CheckTuning) runtimeInt(key string, fallback int) int {
+	value := t.settings.GetCachedInt(key, fallback)
+	if validateTuningSetting(key, value) != nil {
+		return fallback
+	}
+	return value
+}
+
+// ContainerStartupWait returns wait after starting emulator container before its setup
+func (t *CheckTuning) ContainerStartupWait() time.Duration {
+	return t.cfg.ContainerStartupWait
+}
+
+// AppStartWait returns wait after launching app in the default check script
+func (t *CheckTuning) AppStartWait() time.Duration {
+	return t.cfg.AppStartWait
+}
+
+// PostCallWait returns wait between incoming call and screenshot in the default check script
+func (t *CheckTuning) PostCallWait() time.Duration {
+	return t.cfg.PostCallWait
+}
+
+// InterPhoneDelay returns pause between phones of a scheduled run
+func (t *CheckTuning) InterPhoneDelay() time.Duration {
+	return t.cfg.InterPhoneDelay
+}
+
+// PhoneCheckTimeout returns scheduled run timeout for one phone
+func (t *CheckTuning) PhoneCheckTimeout() time.Duration {
+	seconds := t.runtimeInt("check_phone_timeout_seconds", int(t.cfg.PhoneCheckTimeout/time.Second))
+	return time.Duration(seconds) * time.Second
+}
+
+// MaxWorkers returns number of gateways checked in parallel for one phone
+func (t *CheckTuning) MaxWorkers() int {
+	return t.runtimeInt("adb_check_max_workers", t.cfg.MaxWorkers)
+}
+
+// RetryDelay returns pause before a retry
+func (t *CheckTuning) RetryDelay() time.Duration {
+	ms := t.runtimeInt("check_retry_delay_ms", int(t.cfg.RetryDelay/time.Millisecond))
+	return time.Duration(ms) * time.Millisecond
+}
+
+// ADBMaxRetries returns retries allowed per gateway
+func (t *CheckTuning) ADBMaxRetries() int {
+	return t.runtimeInt("adb_check_max_retries", t.cfg.MaxRetries)
+}
+
+// APIMaxRetries returns retries allowed per API service
+func (t *CheckTuning) APIMaxRetries() int {
+	return t.runtimeInt("api_check_max_retries", t.cfg.MaxRetries)
+}
+
+// APIMaxConcurrency returns number of outbound API check calls allowed at once across all phones
+func (t *CheckTuning) APIMaxConcurrency() int {
+	return t.runtimeInt("api_check_max_concurrent", t.cfg.MaxAPIConcurrency)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ocrMaxConcurrentSettingKey is the setting holding number of OCR runs allowed at once, 0 is one per CPU
+const ocrMaxConcurrentSettingKey = "ocr_max_concurrent"
+
+// sharedAPICheckLimiter bounds outbound API check calls of the whole process,
+// across phones checked in parallel and API services of each phone
+var sharedAPICheckLimiter = newConcurrencyLimiter()
+
+// sharedOCRLimiter bounds tesseract runs of the whole process, so parallel checks
+// of many gateways do not saturate CPU
+var sharedOCRLimiter = newConcurrencyLimiter()
+
+// ocrMaxConcurrency returns number of OCR runs allowed at once
+func ocrMaxConcurrency(db *gorm.DB) int {
+	if limit := NewSettingsService(db).GetCachedInt(ocrMaxConcurrentSettingKey, 0); limit > 0 {
+		return limit
+	}
+	return runtime.NumCPU()
+}
+
+// concurrencyLimiter is a counting semaphore whose size is read on every acquire,
+// so lowering the setting takes effect as soon as running holders finish
+type concurrencyLimiter struct {
+	mu       sync.Mutex
+	active   int
+	waiting  int
+	released chan struct{} // Closed and replaced on every release to wake waiters
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{released: make(chan struct{})}
+}
+
+// acquire waits for a free slot while fewer than limit holders are active
+func (l *concurrencyLimiter) acquire(ctx context.Context, limit int) error {
+	counted := false
+	defer func() {
+		if counted {
+			l.mu.Lock()
+			l.waiting--
+			l.mu.Unlock()
+		}
+	}()
+
+	for {
+		l.mu.Lock()
+		if l.active < limit {
+			l.active++
+			l.mu.Unlock()
+			return nil
+		}
+		if !counted {
+			l.waiting++
+			counted = true
+		}
+		wake := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release frees a slot taken by acquire
+func (l *concurrencyLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// stats returns number of running and waiting holders
+func (l *concurrencyLimiter) stats() (int, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active, l.waiting
+}
@@ -0,0 +1,1140 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ConfigBundleVersion is format version written to exported bundles
+const ConfigBundleVersion = 1
+
+// Config bundle import actions
+const (
+	BundleActionCreate = "create"
+	BundleActionUpdate = "update"
+	BundleActionSkip   = "skip"
+)
+
+// errBundleDryRun rolls back import transaction of a dry run
+var errBundleDryRun = errors.New("dry run")
+
+// ConfigBundle is full configuration of an installation for disaster recovery.
+// Entities reference each other by natural keys (service code, names), never by database IDs,
+// and carry no container runtime state, which is regenerated on the host importing the bundle.
+type ConfigBundle struct {
+	Version         int                        `json:"version"`
+	ExportedAt      time.Time                  `json:"exported_at"`
+	SecretsIncluded bool                       `json:"secrets_included"` // API headers and secrets, bot tokens and SMTP passwords
+	SpamServices    []BundleSpamService        `json:"spam_services"`
+	Keywords        []BundleSpamKeyword        `json:"keywords"`
+	Gateways        []BundleGateway            `json:"gateways"`
+	APIServices     []BundleAPIService         `json:"api_services"`
+	Schedules       []BundleCheckSchedule      `json:"schedules"`
+	Notifications   []BundleNotificationConfig `json:"notifications"`
+	Settings        []BundleSetting            `json:"settings"`
+}
+
+// BundleSpamService is spam service keyed by code
+type BundleSpamService struct {
+	Name              string `json:"name"`
+	Code              string `json:"code"`
+	IsActive          bool   `json:"is_active"`
+	IsCustom          bool   `json:"is_custom"`
+	CheckScript       string `json:"check_script,omitempty"`
+	ReadinessProbe    bool   `json:"readiness_probe"`
+	AppPackage        string `json:"app_package,omitempty"`
+	APIPolicy         string `json:"api_policy,omitempty"`
+	MaxResultAgeHours int    `json:"max_result_age_hours,omitempty"`
+	Config            string `json:"config,omitempty"` // Bundles without it fall back to the legacy fields
+}
+
+func (b BundleSpamService) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"name":                 b.Name,
+		"is_active":            b.IsActive,
+		"is_custom":            b.IsCustom,
+		"check_script":         b.CheckScript,
+		"readiness_probe":      b.ReadinessProbe,
+		"app_package":          b.AppPackage,
+		"api_policy":           b.APIPolicy,
+		"max_result_age_hours": b.MaxResultAgeHours,
+		"config":               b.Config,
+	}
+}
+
+// BundleSpamKeyword is spam keyword keyed by keyword and service code, empty code for global keywords
+type BundleSpamKeyword struct {
+	Keyword     string `json:"keyword"`
+	ServiceCode string `json:"service_code,omitempty"`
+	Negations   string `json:"negations,omitempty"`
+	WholeWord   bool   `json:"whole_word,omitempty"`
+	IsActive    bool   `json:"is_active"`
+}
+
+func (b BundleSpamKeyword) key() string {
+	return b.ServiceCode + "/" + b.Keyword
+}
+
+// BundleGateway is gateway keyed by name. Docker gateways keep no host, port or device,
+// their containers are created on import.
+type BundleGateway struct {
+	Name        string `json:"name"`
+	IsDocker    bool   `json:"is_docker"`
+	Host        string `json:"host,omitempty"`
+	Port        int    `json:"port,omitempty"`
+	DeviceID    string `json:"device_id,omitempty"`
+	DockerHost  string `json:"docker_host,omitempty"` // Empty for the default Docker host
+	ServiceCode string `json:"service_code"`
+	IsActive    bool   `json:"is_active"`
+}
+
+func (b BundleGateway) columns() map[string]interface{} {
+	columns := map[string]interface{}{
+		"service_code": b.ServiceCode,
+		"is_active":    b.IsActive,
+	}
+	if !b.IsDocker {
+		columns["host"] = b.Host
+		columns["port"] = b.Port
+		columns["device_id"] = b.DeviceID
+		columns["docker_host"] = b.DockerHost
+	}
+	return columns
+}
+
+// BundleAPIService is API service keyed by name
+type BundleAPIService struct {
+	Name         string `json:"name"`
+	ServiceCode  string `json:"service_code"`
+	Type         string `json:"type,omitempty"`
+	APIURL       string `json:"api_url"`
+	BotConfig    string `json:"bot_config,omitempty"`
+	Headers      string `json:"headers,omitempty"` // Empty when secrets are excluded
+	Method       string `json:"method"`
+	RequestBody  string `json:"request_body,omitempty"`
+	Secret       string `json:"secret,omitempty"` // Empty when secrets are excluded
+	IsActive     bool   `json:"is_active"`
+	Timeout      int    `json:"timeout"`
+	KeywordPaths string `json:"keyword_paths,omitempty"`
+	ResponsePath string `json:"response_path,omitempty"`
+	Priority     int    `json:"priority"`
+	CacheTTL     int    `json:"cache_ttl"`
+}
+
+func (b BundleAPIService) columns() map[string]interface{} {
+	// Bundles exported before API service types have no type
+	serviceType := b.Type
+	if serviceType == "" {
+		serviceType = models.APIServiceTypeHTTP
+	}
+	return map[string]interface{}{
+		"service_code":  b.ServiceCode,
+		"type":          serviceType,
+		"api_url":       b.APIURL,
+		"bot_config":    b.BotConfig,
+		"headers":       b.Headers,
+		"method":        b.Method,
+		"request_body":  b.RequestBody,
+		"secret":        b.Secret,
+		"is_active":     b.IsActive,
+		"timeout":       b.Timeout,
+		"keyword_paths": b.KeywordPaths,
+		"response_path": b.ResponsePath,
+		"priority":      b.Priority,
+		"cache_ttl":     b.CacheTTL,
+	}
+}
+
+// BundleCheckSchedule is check schedule keyed by name, phones are referenced by number
+type BundleCheckSchedule struct {
+	Name                    string   `json:"name"`
+	CronExpression          string   `json:"cron_expression"`
+	IsActive                bool     `json:"is_active"`
+	CheckMode               string   `json:"check_mode,omitempty"`
+	ServiceCode             string   `json:"service_code,omitempty"`
+	OverlapPolicy           string   `json:"overlap_policy,omitempty"`
+	SampleSize              int      `json:"sample_size,omitempty"`
+	SamplePercent           int      `json:"sample_percent,omitempty"`
+	SampleMaxStalenessHours int      `json:"sample_max_staleness_hours,omitempty"`
+	Phones                  []string `json:"phones,omitempty"`
+}
+
+func (b BundleCheckSchedule) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"cron_expression":            b.CronExpression,
+		"is_active":                  b.IsActive,
+		"check_mode":                 b.CheckMode,
+		"service_code":               b.ServiceCode,
+		"overlap_policy":             b.overlapPolicy(),
+		"sample_size":                b.SampleSize,
+		"sample_percent":             b.SamplePercent,
+		"sample_max_staleness_hours": b.SampleMaxStalenessHours,
+	}
+}
+
+// overlapPolicy returns schedule overlap policy, bundles exported before policies existed mean skip
+func (b BundleCheckSchedule) overlapPolicy() string {
+	if b.OverlapPolicy == "" {
+		return models.ScheduleOverlapSkip
+	}
+	return b.OverlapPolicy
+}
+
+// BundleNotificationConfig is notification channel keyed by type and destination
+type BundleNotificationConfig struct {
+	Type     string `json:"type"`
+	Config   string `json:"config"` // Secret fields are empty when secrets are excluded
+	IsActive bool   `json:"is_active"`
+}
+
+// BundleSetting is system setting keyed by key
+type BundleSetting struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+	Category    string `json:"category"`
+	Description string `json:"description,omitempty"`
+}
+
+func (b BundleSetting) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"value":       b.Value,
+		"type":        b.Type,
+		"category":    b.Category,
+		"description": b.Description,
+	}
+}
+
+// ConfigBundleChange describes what import does with one bundle entity
+type ConfigBundleChange struct {
+	Kind    string   `json:"kind"` // spam_service, keyword, gateway, api_service, schedule, notification, setting
+	Key     string   `json:"key"`
+	Action  string   `json:"action"`           // create, update or skip
+	Fields  []string `json:"fields,omitempty"` // Changed columns of an update
+	Message string   `json:"message,omitempty"`
+}
+
+// ConfigBundleImportReport describes result of a bundle import or a dry run
+type ConfigBundleImportReport struct {
+	DryRun   bool                 `json:"dry_run"`
+	Created  int                  `json:"created"`
+	Updated  int                  `json:"updated"`
+	Skipped  int                  `json:"skipped"`
+	Changes  []ConfigBundleChange `json:"changes"`
+	Warnings []string             `json:"warnings,omitempty"`
+	Errors   []string             `json:"errors,omitempty"` // Docker gateways that failed to provision after commit
+}
+
+func (r *ConfigBundleImportReport) add(change ConfigBundleChange) {
+	switch change.Action {
+	case BundleActionCreate:
+		r.Created++
+	case BundleActionUpdate:
+		r.Updated++
+	default:
+		r.Skipped++
+	}
+	r.Changes = append(r.Changes, change)
+}
+
+// ConfigBundleError lists problems that prevent a bundle from being imported
+type ConfigBundleError struct {
+	Problems []string
+}
+
+func (e *ConfigBundleError) Error() string {
+	return "invalid config bundle: " + strings.Join(e.Problems, "; ")
+}
+
+type ConfigBundleService struct {
+	db         *gorm.DB
+	adbService *ADBService
+	settings   *SettingsService
+	log        *logrus.Entry
+}
+
+func NewConfigBundleService(db *gorm.DB, adbService *ADBService) *ConfigBundleService {
+	return &ConfigBundleService{
+		db:         db,
+		adbService: adbService,
+		settings:   NewSettingsService(db),
+		log:        logger.WithField("service", "ConfigBundleService"),
+	}
+}
+
+// ExportBundle collects full configuration, secrets are blanked unless includeSecrets is set
+func (s *ConfigBundleService) ExportBundle(includeSecrets bool) (*ConfigBundle, error) {
+	bundle := &ConfigBundle{
+		Version:         ConfigBundleVersion,
+		ExportedAt:      time.Now(),
+		SecretsIncluded: includeSecrets,
+	}
+
+	var spamServices []models.SpamService
+	if err := s.db.Order("code").Find(&spamServices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get spam services: %w", err)
+	}
+	codes := make(map[uint]string, len(spamServices))
+	for _, service := range spamServices {
+		codes[service.ID] = service.Code
+		bundle.SpamServices = append(bundle.SpamServices, bundleSpamService(service))
+	}
+
+	var keywords []models.SpamKeyword
+	if err := s.db.Order("keyword, id").Find(&keywords).Error; err != nil {
+		return nil, fmt.Errorf("failed to get spam keywords: %w", err)
+	}
+	for _, keyword := range keywords {
+		bundle.Keywords = append(bundle.Keywords, bundleSpamKeyword(keyword, codes))
+	}
+
+	var gateways []models.ADBGateway
+	if err := s.db.Order("name").Find(&gateways).Error; err != nil {
+		return nil, fmt.Errorf("failed to get gateways: %w", err)
+	}
+	for _, gateway := range gateways {
+		bundle.Gateways = append(bundle.Gateways, bundleGateway(gateway))
+	}
+
+	var apiServices []models.APIService
+	if err := s.db.Order("name").Find(&apiServices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API services: %w", err)
+	}
+	for _, service := range apiServices {
+		item := bundleAPIService(service)
+		if !includeSecrets {
+			item.Headers = ""
+			item.Secret = ""
+		}
+		bundle.APIServices = append(bundle.APIServices, item)
+	}
+
+	var schedules []models.CheckSchedule
+	if err := s.db.Order("name, id").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get check schedules: %w", err)
+	}
+	for _, schedule := range schedules {
+		phones, err := s.schedulePhoneNumbers(s.db, schedule.ID)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Schedules = append(bundle.Schedules, BundleCheckSchedule{
+			Name:                    schedule.Name,
+			CronExpression:          schedule.CronExpression,
+			IsActive:                schedule.IsActive,
+			CheckMode:               string(schedule.CheckMode),
+			ServiceCode:             schedule.ServiceCode,
+			OverlapPolicy:           schedule.OverlapPolicy,
+			SampleSize:              schedule.SampleSize,
+			SamplePercent:           schedule.SamplePercent,
+			SampleMaxStalenessHours: schedule.SampleMaxStalenessHours,
+			Phones:                  phones,
+		})
+	}
+
+	var notifications []models.Notification
+	if err := s.db.Order("id").Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	for _, notification := range notifications {
+		config := notification.Config
+		if !includeSecrets {
+			config = withNotificationSecrets(notification.Type, config, "")
+		}
+		bundle.Notifications = append(bundle.Notifications, BundleNotificationConfig{
+			Type:     notification.Type,
+			Config:   config,
+			IsActive: notification.IsActive,
+		})
+	}
+
+	settings, err := s.settings.GetAllSettings()
+	if err != nil {
+		return nil, err
+	}
+	for _, setting := range settings {
+		bundle.Settings = append(bundle.Settings, bundleSetting(setting))
+	}
+
+	return bundle, nil
+}
+
+func bundleSpamService(service models.SpamService) BundleSpamService {
+	return BundleSpamService{
+		Name:              service.Name,
+		Code:              service.Code,
+		IsActive:          service.IsActive,
+		IsCustom:          service.IsCustom,
+		CheckScript:       service.CheckScript,
+		ReadinessProbe:    service.ReadinessProbe,
+		AppPackage:        service.AppPackage,
+		APIPolicy:         service.APIPolicy,
+		MaxResultAgeHours: service.MaxResultAgeHours,
+		Config:            service.Config,
+	}
+}
+
+func bundleSpamKeyword(keyword models.SpamKeyword, codes map[uint]string) BundleSpamKeyword {
+	item := BundleSpamKeyword{Keyword: keyword.Keyword, Negations: keyword.Negations, WholeWord: keyword.WholeWord, IsActive: keyword.IsActive}
+	if keyword.ServiceID != nil {
+		item.ServiceCode = codes[*keyword.ServiceID]
+	}
+	return item
+}
+
+func bundleGateway(gateway models.ADBGateway) BundleGateway {
+	item := BundleGateway{
+		Name:        gateway.Name,
+		IsDocker:    gateway.IsDocker,
+		DockerHost:  gateway.DockerHost,
+		ServiceCode: gateway.ServiceCode,
+		IsActive:    gateway.IsActive,
+	}
+	// Host, port and device of Docker gateway belong to its container
+	if !gateway.IsDocker {
+		item.Host = gateway.Host
+		item.Port = gateway.Port
+		item.DeviceID = gateway.DeviceID
+	}
+	return item
+}
+
+func bundleAPIService(service models.APIService) BundleAPIService {
+	return BundleAPIService{
+		Name:         service.Name,
+		ServiceCode:  service.ServiceCode,
+		Type:         service.Type,
+		APIURL:       service.APIURL,
+		BotConfig:    service.BotConfig,
+		Headers:      service.Headers,
+		Method:       service.Method,
+		RequestBody:  service.RequestBody,
+		Secret:       service.Secret,
+		IsActive:     service.IsActive,
+		Timeout:      service.Timeout,
+		KeywordPaths: service.KeywordPaths,
+		ResponsePath: service.ResponsePath,
+		Priority:     service.Priority,
+		CacheTTL:     service.CacheTTL,
+	}
+}
+
+func bundleSetting(setting models.SystemSettings) BundleSetting {
+	return BundleSetting{
+		Key:         setting.Key,
+		Value:       setting.Value,
+		Type:        setting.Type,
+		Category:    setting.Category,
+		Description: setting.Description,
+	}
+}
+
+// schedulePhoneNumbers returns sorted numbers of schedule's explicit phones
+func (s *ConfigBundleService) schedulePhoneNumbers(db *gorm.DB, scheduleID uint) ([]string, error) {
+	var numbers []string
+	if err := db.Table("schedule_phones sp").
+		Joins("JOIN phone_numbers pn ON pn.id = sp.phone_number_id AND pn.deleted_at IS NULL").
+		Where("sp.schedule_id = ?", scheduleID).
+		Order("pn.number").
+		Pluck("pn.number", &numbers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get schedule phones: %w", err)
+	}
+	return numbers, nil
+}
+
+// notificationSecretField returns config field holding channel secret
+func notificationSecretField(notificationType string) string {
+	switch notificationType {
+	case "telegram":
+		return "bot_token"
+	case "email":
+		return "smtp_password"
+	}
+	return ""
+}
+
+// withNotificationSecrets returns channel config with secret field set to secret.
+// Config that cannot be parsed is returned unchanged.
+func withNotificationSecrets(notificationType, configJSON, secret string) string {
+	field := notificationSecretField(notificationType)
+	var config map[string]interface{}
+	if field == "" || json.Unmarshal([]byte(configJSON), &config) != nil {
+		return configJSON
+	}
+	config[field] = secret
+	data, err := json.Marshal(config)
+	if err != nil {
+		return configJSON
+	}
+	return string(data)
+}
+
+// notificationSecret returns secret stored in channel config
+func notificationSecret(notificationType, configJSON string) string {
+	var config map[string]interface{}
+	if json.Unmarshal([]byte(configJSON), &config) != nil {
+		return ""
+	}
+	secret, _ := config[notificationSecretField(notificationType)].(string)
+	return secret
+}
+
+// notificationKey identifies channel by its destination: telegram chat and topic, email server and recipients
+func notificationKey(notificationType, configJSON string) string {
+	switch notificationType {
+	case "telegram":
+		var config TelegramConfig
+		json.Unmarshal([]byte(configJSON), &config)
+		return fmt.Sprintf("telegram:%s:%s", config.ChatID, config.MessageThreadID)
+	case "email":
+		var config EmailConfig
+		json.Unmarshal([]byte(configJSON), &config)
+		recipients := append([]string(nil), config.ToEmails...)
+		sort.Strings(recipients)
+		return fmt.Sprintf("email:%s:%s:%s", config.SMTPHost, config.SMTPPort, strings.Join(recipients, ","))
+	}
+	return notificationType + ":" + configJSON
+}
+
+// validateBundle checks bundle is complete and its references resolve, collecting all problems at once
+func (s *ConfigBundleService) validateBundle(bundle *ConfigBundle) error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if bundle.Version != ConfigBundleVersion {
+		addProblem("unsupported bundle version %d, expected %d", bundle.Version, ConfigBundleVersion)
+		return &ConfigBundleError{Problems: problems}
+	}
+
+	// Service codes known after import: those in database plus those in bundle
+	var existingCodes []string
+	if err := s.db.Model(&models.SpamService{}).Pluck("code", &existingCodes).Error; err != nil {
+		return fmt.Errorf("failed to get spam services: %w", err)
+	}
+	knownCodes := make(map[string]bool)
+	for _, code := range existingCodes {
+		knownCodes[code] = true
+	}
+
+	names := make(map[string]bool)
+	for _, service := range bundle.SpamServices {
+		if service.Code == "" || service.Name == "" {
+			addProblem("spam service %q: name and code are required", service.Code)
+			continue
+		}
+		if names[service.Code] {
+			addProblem("spam service %q is listed twice", service.Code)
+		}
+		if err := validateResultMaxAge(service.MaxResultAgeHours); err != nil {
+			addProblem("spam service %q: %v", service.Code, err)
+		}
+		if service.Config != "" {
+			if _, err := ParseServiceConfig(service.Code, []byte(service.Config)); err != nil {
+				addProblem("spam service %q: config %v", service.Code, err)
+			}
+		}
+		names[service.Code] = true
+		knownCodes[service.Code] = true
+	}
+
+	checkCode := func(kind, key, code string) {
+		if code != "" && !knownCodes[code] {
+			addProblem("%s %q references unknown spam service %q", kind, key, code)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, keyword := range bundle.Keywords {
+		if strings.TrimSpace(keyword.Keyword) == "" {
+			addProblem("keyword is required")
+			continue
+		}
+		if seen[keyword.key()] {
+			addProblem("keyword %q is listed twice", keyword.key())
+		}
+		seen[keyword.key()] = true
+		checkCode("keyword", keyword.Keyword, keyword.ServiceCode)
+	}
+
+	seen = make(map[string]bool)
+	for _, gateway := range bundle.Gateways {
+		if gateway.Name == "" {
+			addProblem("gateway name is required")
+			continue
+		}
+		if seen[gateway.Name] {
+			addProblem("gateway %q is listed twice", gateway.Name)
+		}
+		seen[gateway.Name] = true
+		if !gateway.IsDocker && (gateway.Host == "" || gateway.Port == 0) {
+			addProblem("gateway %q: host and port are required", gateway.Name)
+		}
+		if gateway.IsDocker && gateway.ServiceCode == "" {
+			addProblem("Docker gateway %q: service code is required", gateway.Name)
+		}
+		checkCode("gateway", gateway.Name, gateway.ServiceCode)
+	}
+
+	seen = make(map[string]bool)
+	for _, service := range bundle.APIServices {
+		if service.Name == "" || service.ServiceCode == "" || service.APIURL == "" {
+			addProblem("API service %q: name, service code and API URL are required", service.Name)
+			continue
+		}
+		if seen[service.Name] {
+			addProblem("API service %q is listed twice", service.Name)
+		}
+		seen[service.Name] = true
+		checkCode("API service", service.Name, service.ServiceCode)
+		candidate := &models.APIService{Type: service.Type, APIURL: service.APIURL, BotConfig: service.BotConfig, RequestBody: service.RequestBody, Secret: service.Secret}
+		if err := validateAPIServiceType(candidate); err != nil {
+			addProblem("API service %q: %v", service.Name, err)
+		} else if err := validateAPIRequestTemplates(candidate); err != nil {
+			addProblem("API service %q: %v", service.Name, err)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for _, schedule := range bundle.Schedules {
+		if schedule.Name == "" {
+			addProblem("schedule name is required")
+			continue
+		}
+		if seen[schedule.Name] {
+			addProblem("schedule %q is listed twice", schedule.Name)
+		}
+		seen[schedule.Name] = true
+		if err := s.settings.validateCronExpression(schedule.CronExpression); err != nil {
+			addProblem("schedule %q: invalid cron expression: %v", schedule.Name, err)
+		}
+		if schedule.CheckMode != "" {
+			if err := validateCheckMode(models.CheckMode(schedule.CheckMode)); err != nil {
+				addProblem("schedule %q: %v", schedule.Name, err)
+			}
+		}
+		checkCode("schedule", schedule.Name, schedule.ServiceCode)
+		if err := validateOverlapPolicy(schedule.overlapPolicy()); err != nil {
+			addProblem("schedule %q: %v", schedule.Name, err)
+		}
+		if err := validateCheckSampling(schedule.SampleSize, schedule.SamplePercent, schedule.SampleMaxStalenessHours); err != nil {
+			addProblem("schedule %q: %v", schedule.Name, err)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for _, notification := range bundle.Notifications {
+		if notificationSecretField(notification.Type) == "" {
+			addProblem("unsupported notification type %q", notification.Type)
+			continue
+		}
+		if !json.Valid([]byte(notification.Config)) {
+			addProblem("%s notification: config is not valid JSON", notification.Type)
+			continue
+		}
+		key := notificationKey(notification.Type, notification.Config)
+		if seen[key] {
+			addProblem("notification %q is listed twice", key)
+		}
+		seen[key] = true
+	}
+
+	seen = make(map[string]bool)
+	for _, setting := range bundle.Settings {
+		if setting.Key == "" {
+			addProblem("setting key is required")
+			continue
+		}
+		if seen[setting.Key] {
+			addProblem("setting %q is listed twice", setting.Key)
+		}
+		seen[setting.Key] = true
+		if err := s.settings.validateSettingValue(setting.Type, setting.Value); err != nil {
+			addProblem("setting %q: %v", setting.Key, err)
+			continue
+		}
+		if err := validateSettingRules(setting.Key, setting.Type, setting.Value); err != nil {
+			addProblem("setting %q: %v", setting.Key, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ConfigBundleError{Problems: problems}
+	}
+	return nil
+}
+
+// ImportBundle validates bundle and applies it in one transaction. Entities are matched by natural
+// keys, existing ones are updated, nothing is deleted. Dry run reports the same changes and rolls back.
+// Docker gateways are created after commit so their containers and ports are allocated on this host.
+func (s *ConfigBundleService) ImportBundle(bundle *ConfigBundle, dryRun bool) (*ConfigBundleImportReport, error) {
+	if err := s.validateBundle(bundle); err != nil {
+		return nil, err
+	}
+
+	report := &ConfigBundleImportReport{DryRun: dryRun}
+	var dockerGateways []BundleGateway
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		steps := []func(*gorm.DB, *ConfigBundle, *ConfigBundleImportReport) error{
+			s.importSpamServices,
+			s.importKeywords,
+			s.importAPIServices,
+			s.importSchedules,
+			s.importNotifications,
+			s.importSettings,
+		}
+		for _, step := range steps {
+			if err := step(tx, bundle, report); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		dockerGateways, err = s.importGateways(tx, bundle, report)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errBundleDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBundleDryRun) {
+		return nil, err
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	sharedSettingsCache.invalidate()
+
+	for _, item := range dockerGateways {
+		gateway := &models.ADBGateway{
+			Name:        item.Name,
+			ServiceCode: item.ServiceCode,
+			IsActive:    item.IsActive,
+			Status:      "creating",
+			IsDocker:    true,
+			DockerHost:  item.DockerHost,
+		}
+		if err := s.adbService.CreateDockerGateway(gateway, nil, nil); err != nil {
+			s.log.Errorf("Failed to provision Docker gateway %s: %v", item.Name, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("gateway %q: %v", item.Name, err))
+		}
+	}
+
+	s.log.Infof("Config bundle imported: %d created, %d updated, %d skipped",
+		report.Created, report.Updated, report.Skipped)
+	return report, nil
+}
+
+// changedColumns returns desired columns that differ from current ones with their names sorted
+func changedColumns(current, desired map[string]interface{}) (map[string]interface{}, []string) {
+	updates := make(map[string]interface{})
+	var fields []string
+	for column, value := range desired {
+		if fmt.Sprint(current[column]) != fmt.Sprint(value) {
+			updates[column] = value
+			fields = append(fields, column)
+		}
+	}
+	sort.Strings(fields)
+	return updates, fields
+}
+
+// applyUpdates updates model if columns changed and records the change
+func applyUpdates(tx *gorm.DB, model interface{}, kind, key string, current, desired map[string]interface{}, report *ConfigBundleImportReport) error {
+	updates, fields := changedColumns(current, desired)
+	if len(updates) == 0 {
+		report.add(ConfigBundleChange{Kind: kind, Key: key, Action: BundleActionSkip})
+		return nil
+	}
+	if err := tx.Model(model).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update %s %s: %w", kind, key, err)
+	}
+	report.add(ConfigBundleChange{Kind: kind, Key: key, Action: BundleActionUpdate, Fields: fields})
+	return nil
+}
+
+func (s *ConfigBundleService) importSpamServices(tx *gorm.DB, bundle *ConfigBundle, report *ConfigBundleImportReport) error {
+	for _, item := range bundle.SpamServices {
+		var existing models.SpamService
+		err := tx.Where("code = ?", item.Code).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			service := models.SpamService{Name: item.Name, Code: item.Code}
+			if err := tx.Create(&service).Error; err != nil {
+				return fmt.Errorf("failed to create spam service %s: %w", item.Code, err)
+			}
+			// Zero values like is_active=false are only written by map updates
+			if err := tx.Model(&service).Updates(item.columns()).Error; err != nil {
+				return fmt.Errorf("failed to create spam service %s: %w", item.Code, err)
+			}
+			report.add(ConfigBundleChange{Kind: "spam_service", Key: item.Code, Action: BundleActionCreate})
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get spam service %s: %w", item.Code, err)
+		}
+		if err := applyUpdates(tx, &existing, "spam_service", item.Code,
+			bundleSpamService(existing).columns(), item.columns(), report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spamServiceIDs maps service codes to IDs within transaction
+func spamServiceIDs(tx *gorm.DB) (map[string]uint, error) {
+	var services []models.SpamService
+	if err := tx.Select("id, code").Find(&services).Error; err != nil {
+		return nil, fmt.Errorf("failed to get spam services: %w", err)
+	}
+	ids := make(map[string]uint, len(services))
+	for _, service := range services {
+		ids[service.Code] = service.ID
+	}
+	return ids, nil
+}
+
+func (s *ConfigBundleService) importKeywords(tx *gorm.DB, bundle *ConfigBundle, report *ConfigBundleImportReport) error {
+	ids, err := spamServiceIDs(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range bundle.Keywords {
+		query := tx.Where("keyword = ?", item.Keyword)
+		var serviceID *uint
+		if item.ServiceCode == "" {
+			query = query.Where("service_id IS NULL")
+		} else {
+			id := ids[item.ServiceCode]
+			serviceID = &id
+			query = query.Where("service_id = ?", id)
+		}
+
+		var existing models.SpamKeyword
+		err := query.First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			keyword := models.SpamKeyword{Keyword: item.Keyword, Negations: item.Negations, WholeWord: item.WholeWord, ServiceID: serviceID}
+			if err := tx.Create(&keyword).Error; err != nil {
+				return fmt.Errorf("failed to create keyword %s: %w", item.key(), err)
+			}
+			if err := tx.Model(&keyword).Update("is_active", item.IsActive).Error; err != nil {
+				return fmt.Errorf("failed to create keyword %s: %w", item.key(), err)
+			}
+			report.add(ConfigBundleChange{Kind: "keyword", Key: item.key(), Action: BundleActionCreate})
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get keyword %s: %w", item.key(), err)
+		}
+		if err := applyUpdates(tx, &existing, "keyword", item.key(),
+			map[string]interface{}{"is_active": existing.IsActive, "negations": existing.Negations, "whole_word": existing.WholeWord},
+			map[string]interface{}{"is_active": item.IsActive, "negations": item.Negations, "whole_word": item.WholeWord}, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importGateways applies gateways and returns Docker gateways to provision after commit
+func (s *ConfigBundleService) importGateways(tx *gorm.DB, bundle *ConfigBundle, report *ConfigBundleImportReport) ([]BundleGateway, error) {
+	var provision []BundleGateway
+	for _, item := range bundle.Gateways {
+		var existing models.ADBGateway
+		err := tx.Where("name = ?", item.Name).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if item.IsDocker {
+				provision = append(provision, item)
+				report.add(ConfigBundleChange{Kind: "gateway", Key: item.Name, Action: BundleActionCreate,
+					Message: "container and ports are allocated on this host"})
+				continue
+			}
+			gateway := models.ADBGateway{Name: item.Name, Host: item.Host, Port: item.Port}
+			if err := tx.Create(&gateway).Error; err != nil {
+				return nil, fmt.Errorf("failed to create gateway %s: %w", item.Name, err)
+			}
+			if err := tx.Model(&gateway).Updates(item.columns()).Error; err != nil {
+				return nil, fmt.Errorf("failed to create gateway %s: %w", item.Name, err)
+			}
+			report.add(ConfigBundleChange{Kind: "gateway", Key: item.Name, Action: BundleActionCreate})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gateway %s: %w", item.Name, err)
+		}
+		if existing.IsDocker != item.IsDocker {
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"gateway %q exists with different Docker mode, runtime fields are left as is", item.Name))
+			item.IsDocker = true
+		}
+		if err := applyUpdates(tx, &existing, "gateway", item.Name,
+			bundleGateway(existing).columns(), item.columns(), report); err != nil {
+			return nil, err
+		}
+	}
+	return provision, nil
+}
+
+func (s *ConfigBundleService) importAPIServices(tx *gorm.DB, bundle *ConfigBundle, report *ConfigBundleImportReport) error {
+	for _, item := range bundle.APIServices {
+		desired := item.columns()
+
+		var existing models.APIService
+		err := tx.Where("name = ?", item.Name).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if item.Headers == "" {
+				// Headers column is jsonb, empty string is not valid JSON
+				desired["headers"] = "{}"
+				if !bundle.SecretsIncluded {
+					report.Warnings = append(report.Warnings, fmt.Sprintf(
+						"API service %q is created without headers, set its credentials manually", item.Name))
+				}
+			}
+			service := models.APIService{
+				Name:        item.Name,
+				ServiceCode: item.ServiceCode,
+				APIURL:      item.APIURL,
+				Headers:     desired["headers"].(string),
+			}
+			if err := tx.Create(&service).Error; err != nil {
+				return fmt.Errorf("failed to create API service %s: %w", item.Name, err)
+			}
+			if err := tx.Model(&service).Updates(desired).Error; err != nil {
+				return fmt.Errorf("failed to create API service %s: %w", item.Name, err)
+			}
+			report.add(ConfigBundleChange{Kind: "api_service", Key: item.Name, Action: BundleActionCreate})
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get API service %s: %w", item.Name, err)
+		}
+		// Keep existing credentials when bundle has none
+		if !bundle.SecretsIncluded {
+			delete(desired, "headers")
+			delete(desired, "secret")
+		}
+		if err := applyUpdates(tx, &existing, "api_service", item.Name,
+			bundleAPIService(existing).columns(), desired, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ConfigBundleService) importSchedules(tx *gorm.DB, bundle *ConfigBundle, report *ConfigBundleImportReport) error {
+	for _, item := range bundle.Schedules {
+		var existing models.CheckSchedule
+		err := tx.Where("name = ?", item.Name).First(&existing).Error
+		action := BundleActionSkip
+		var fields []string
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			existing = models.CheckSchedule{
+				Name:                    item.Name,
+				CronExpression:          item.CronExpression,
+				CheckMode:               models.CheckMode(item.CheckMode),
+				ServiceCode:             item.ServiceCode,
+				OverlapPolicy:           item.overlapPolicy(),
+				SampleSize:              item.SampleSize,
+				SamplePercent:           item.SamplePercent,
+				SampleMaxStalenessHours: item.SampleMaxStalenessHours,
+			}
+			if err := tx.Create(&existing).Error; err != nil {
+				return fmt.Errorf("failed to create schedule %s: %w", item.Name, err)
+			}
+			if err := tx.Model(&existing).Update("is_active", item.IsActive).Error; err != nil {
+				return fmt.Errorf("failed to create schedule %s: %w", item.Name, err)
+			}
+			action = BundleActionCreate
+		} else if err != nil {
+			return fmt.Errorf("failed to get schedule %s: %w", item.Name, err)
+		} else {
+			current := map[string]interface{}{
+				"cron_expression":            existing.CronExpression,
+				"is_active":                  existing.IsActive,
+				"check_mode":                 existing.CheckMode,
+				"service_code":               existing.ServiceCode,
+				"overlap_policy":             existing.OverlapPolicy,
+				"sample_size":                existing.SampleSize,
+				"sample_percent":             existing.SamplePercent,
+				"sample_max_staleness_hours": existing.SampleMaxStalenessHours,
+			}
+			var updates map[string]interface{}
+			updates, fields = changedColumns(current, item.columns())
+			if len(updates) > 0 {
+				if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+					return fmt.Errorf("failed to update schedule %s: %w", item.Name, err)
+				}
+				action = BundleActionUpdate
+			}
+		}
+
+		phonesChanged, err := s.importSchedulePhones(tx, existing.ID, item, report)
+		if err != nil {
+			return err
+		}
+		if phonesChanged {
+			fields = append(fields, "phones")
+			if action == BundleActionSkip {
+				action = BundleActionUpdate
+			}
+		}
+
+		report.add(ConfigBundleChange{Kind: "schedule", Key: item.Name, Action: action, Fields: fields})
+	}
+	return nil
+}
+
+// importSchedulePhones adds bundle phones missing from schedule, numbers unknown here are reported as warnings
+func (s *ConfigBundleService) importSchedulePhones(tx *gorm.DB, scheduleID uint, item BundleCheckSchedule, report *ConfigBundleImportReport) (bool, error) {
+	if len(item.Phones) == 0 {
+		return false, nil
+	}
+
+	var phones []models.PhoneNumber
+	if err := tx.Select("id, number").Where("number IN ?", item.Phones).Find(&phones).Error; err != nil {
+		return false, fmt.Errorf("failed to get schedule phones: %w", err)
+	}
+	found := make(map[string]bool, len(phones))
+	for _, phone := range phones {
+		found[phone.Number] = true
+	}
+	var missing []string
+	for _, number := range item.Phones {
+		if !found[number] {
+			missing = append(missing, number)
+		}
+	}
+	if len(missing) > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"schedule %q: phones not found and skipped: %s", item.Name, strings.Join(missing, ", ")))
+	}
+
+	changed := false
+	for _, phone := range phones {
+		var count int64
+		if err := tx.Model(&models.SchedulePhone{}).
+			Where("schedule_id = ? AND phone_number_id = ?", scheduleID, phone.ID).
+			Count(&count).Error; err != nil {
+			return false, fmt.Errorf("failed to get schedule phones: %w", err)
+		}
+		if count > 0 {
+			continue
+		}
+		if err := tx.Create(&models.SchedulePhone{ScheduleID: scheduleID, PhoneNumberID: phone.ID}).Error; err != nil {
+			return false, fmt.Errorf("failed to add phone to schedule %s: %w", item.Name, err)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+func (s *ConfigBundleService) importNotifications(tx *gorm.DB, bundle *ConfigBundle, report *ConfigBundleImportReport) error {
+	var existing []models.Notification
+	if err := tx.Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to get notifications: %w", err)
+	}
+	byKey := make(map[string]*models.Notification, len(existing))
+	for i := range existing {
+		byKey[notificationKey(existing[i].Type, existing[i].Config)] = &existing[i]
+	}
+
+	for _, item := range bundle.Notifications {
+		key := notificationKey(item.Type, item.Config)
+		config := item.Config
+		hasSecret := notificationSecret(item.Type, config) != ""
+
+		current, exists := byKey[key]
+		if !exists {
+			isActive := item.IsActive
+			if !hasSecret && isActive {
+				// Channel cannot deliver without its secret
+				isActive = false
+				report.Warnings = append(report.Warnings, fmt.Sprintf(
+					"notification %q is created inactive because its %s is missing", key, notificationSecretField(item.Type)))
+			}
+			notification := models.Notification{Type: item.Type, Config: config}
+			if err := tx.Create(&notification).Error; err != nil {
+				return fmt.Errorf("failed to create notification %s: %w", key, err)
+			}
+			if err := tx.Model(&notification).Update("is_active", isActive).Error; err != nil {
+				return fmt.Errorf("failed to create notification %s: %w", key, err)
+			}
+			report.add(ConfigBundleChange{Kind: "notification", Key: key, Action: BundleActionCreate})
+			continue
+		}
+
+		// Keep existing secret when bundle has none
+		if !hasSecret {
+			config = withNotificationSecrets(item.Type, config, notificationSecret(current.Type, current.Config))
+		}
+		currentColumns := map[string]interface{}{
+			"config":    canonicalJSON(current.Config),
+			"is_active": current.IsActive,
+		}
+		desired := map[string]interface{}{
+			"config":    canonicalJSON(config),
+			"is_active": item.IsActive,
+		}
+		if err := applyUpdates(tx, current, "notification", key, currentColumns, desired, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canonicalJSON re-encodes JSON so equal documents compare equal regardless of key order and spacing
+func canonicalJSON(value string) string {
+	var decoded interface{}
+	if json.Unmarshal([]byte(value), &decoded) != nil {
+		return value
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return value
+	}
+	return string(data)
+}
+
+func (s *ConfigBundleService) importSettings(tx *gorm.DB, bundle *ConfigBundle, report *ConfigBundleImportReport) error {
+	for _, item := range bundle.Settings {
+		var existing models.SystemSettings
+		err := tx.Where("key = ?", item.Key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			setting := models.SystemSettings{
+				Key:         item.Key,
+				Value:       item.Value,
+				Type:        item.Type,
+				Category:    item.Category,
+				Description: item.Description,
+			}
+			if err := tx.Create(&setting).Error; err != nil {
+				return fmt.Errorf("failed to create setting %s: %w", item.Key, err)
+			}
+			report.add(ConfigBundleChange{Kind: "setting", Key: item.Key, Action: BundleActionCreate})
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get setting %s: %w", item.Key, err)
+		}
+		if err := applyUpdates(tx, &existing, "setting", item.Key,
+			bundleSetting(existing).columns(), item.columns(), report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
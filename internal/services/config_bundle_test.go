@@ -0,0 +1,205 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"spam-checker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const bundleTestPhone = "79001112233"
+
+// seedBundleSource fills database with one entity of every bundle kind, secrets included
+func seedBundleSource(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	var kaspersky models.SpamService
+	if err := db.Where("code = ?", "kaspersky").First(&kaspersky).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	records := []interface{}{
+		&models.SpamKeyword{Keyword: "мошенник", ServiceID: &kaspersky.ID, IsActive: true},
+		&models.SpamKeyword{Keyword: "автообзвон", Negations: "не", WholeWord: true, IsActive: true},
+		&models.ADBGateway{Name: "gw-1", Host: "10.0.0.5", Port: 5555, DeviceID: "emulator-5554", ServiceCode: "kaspersky", IsActive: true},
+		&models.APIService{
+			Name:        "lookup",
+			ServiceCode: "kaspersky",
+			APIURL:      "https://example.com/lookup",
+			Headers:     `{"X-Api-Key":"key-123"}`,
+			Method:      "GET",
+			Secret:      "signing-secret",
+			IsActive:    true,
+			Timeout:     15,
+			Priority:    1,
+		},
+		&models.Notification{Type: "telegram", Config: `{"bot_token":"bot-token","chat_id":"42"}`, IsActive: true},
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("create %T: %v", record, err)
+		}
+	}
+
+	createBundlePhone(t, db)
+	schedule := models.CheckSchedule{Name: "nightly", CronExpression: "0 3 * * *", IsActive: true, OverlapPolicy: models.ScheduleOverlapSkip}
+	if err := db.Create(&schedule).Error; err != nil {
+		t.Fatal(err)
+	}
+	var phone models.PhoneNumber
+	if err := db.Where("number = ?", bundleTestPhone).First(&phone).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.SchedulePhone{ScheduleID: schedule.ID, PhoneNumberID: phone.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Model(&models.SystemSettings{}).Where("key = ?", "max_concurrent_checks").Update("value", "7").Error; err != nil {
+		t.Fatal(err)
+	}
+	sharedSettingsCache.invalidate()
+}
+
+// createBundlePhone adds the phone bundle schedules reference, phones are not part of a bundle
+func createBundlePhone(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	var admin models.User
+	if err := db.Where("role = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.PhoneNumber{Number: bundleTestPhone, IsActive: true, CreatedBy: admin.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// reencodeBundle passes bundle through JSON as it travels between installations
+func reencodeBundle(t *testing.T, bundle *ConfigBundle) *ConfigBundle {
+	t.Helper()
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ConfigBundle
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return &decoded
+}
+
+func exportBundle(t *testing.T, service *ConfigBundleService, includeSecrets bool) *ConfigBundle {
+	t.Helper()
+
+	bundle, err := service.ExportBundle(includeSecrets)
+	if err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+	bundle.ExportedAt = time.Time{}
+	return bundle
+}
+
+func TestConfigBundleRoundTrip(t *testing.T) {
+	sourceDB := newTestDB(t)
+	seedBundleSource(t, sourceDB)
+	source := NewConfigBundleService(sourceDB, nil)
+	exported := exportBundle(t, source, true)
+
+	targetDB := newTestDB(t)
+	createBundlePhone(t, targetDB)
+	target := NewConfigBundleService(targetDB, nil)
+
+	report, err := target.ImportBundle(reencodeBundle(t, exported), false)
+	if err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if len(report.Warnings) > 0 || len(report.Errors) > 0 {
+		t.Fatalf("warnings = %v, errors = %v", report.Warnings, report.Errors)
+	}
+	if report.Created == 0 {
+		t.Fatal("import into empty installation created nothing")
+	}
+
+	reexported := exportBundle(t, target, true)
+	if !reflect.DeepEqual(exported, reexported) {
+		want, _ := json.MarshalIndent(exported, "", "  ")
+		got, _ := json.MarshalIndent(reexported, "", "  ")
+		t.Fatalf("bundle changed after round trip\nwant %s\ngot  %s", want, got)
+	}
+
+	// Importing the same bundle again must be a no-op
+	report, err = target.ImportBundle(reencodeBundle(t, exported), false)
+	if err != nil {
+		t.Fatalf("second ImportBundle: %v", err)
+	}
+	if report.Created != 0 || report.Updated != 0 {
+		t.Fatalf("second import created %d and updated %d, changes = %+v", report.Created, report.Updated, report.Changes)
+	}
+}
+
+func TestConfigBundleWithoutSecretsKeepsExistingCredentials(t *testing.T) {
+	db := newTestDB(t)
+	seedBundleSource(t, db)
+	service := NewConfigBundleService(db, nil)
+
+	bundle := exportBundle(t, service, false)
+	for _, item := range bundle.APIServices {
+		if item.Secret != "" || item.Headers != "" {
+			t.Fatalf("API service %q exported with secrets", item.Name)
+		}
+	}
+	for _, item := range bundle.Notifications {
+		if notificationSecret(item.Type, item.Config) != "" {
+			t.Fatalf("%s notification exported with secret", item.Type)
+		}
+	}
+
+	report, err := service.ImportBundle(reencodeBundle(t, bundle), false)
+	if err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if report.Created != 0 || report.Updated != 0 {
+		t.Fatalf("import without secrets created %d and updated %d, changes = %+v", report.Created, report.Updated, report.Changes)
+	}
+
+	var apiService models.APIService
+	if err := db.Where("name = ?", "lookup").First(&apiService).Error; err != nil {
+		t.Fatal(err)
+	}
+	if apiService.Secret != "signing-secret" || apiService.Headers != `{"X-Api-Key":"key-123"}` {
+		t.Fatalf("API credentials lost: secret = %q, headers = %q", apiService.Secret, apiService.Headers)
+	}
+	var notification models.Notification
+	if err := db.Where("type = ?", "telegram").First(&notification).Error; err != nil {
+		t.Fatal(err)
+	}
+	if notificationSecret(notification.Type, notification.Config) != "bot-token" {
+		t.Fatalf("bot token lost: config = %s", notification.Config)
+	}
+}
+
+func TestConfigBundleDryRunChangesNothing(t *testing.T) {
+	sourceDB := newTestDB(t)
+	seedBundleSource(t, sourceDB)
+	exported := exportBundle(t, NewConfigBundleService(sourceDB, nil), true)
+
+	targetDB := newTestDB(t)
+	target := NewConfigBundleService(targetDB, nil)
+	before := exportBundle(t, target, true)
+
+	report, err := target.ImportBundle(reencodeBundle(t, exported), true)
+	if err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if !report.DryRun || report.Created == 0 {
+		t.Fatalf("dry run report = %+v", report)
+	}
+
+	if after := exportBundle(t, target, true); !reflect.DeepEqual(before, after) {
+		t.Fatal("dry run changed the database")
+	}
+}
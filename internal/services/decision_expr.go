@@ -0,0 +1,782 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits keeping decision expressions cheap: they run on every check result
+const (
+	maxDecisionExprLength  = 1000
+	maxDecisionExprNodes   = 300
+	maxDecisionExprSteps   = 10000 // Nodes evaluated and items scanned per rule set
+	maxDecisionStringBytes = 16 * 1024
+	maxDecisionPatternLen  = 200
+	maxDecisionTrace       = 100 // Intermediate values kept per rule by the test harness
+	decisionEvalTimeout    = 50 * time.Millisecond
+)
+
+// ErrInvalidDecisionExpr is returned for expressions that do not parse
+var ErrInvalidDecisionExpr = errors.New("invalid decision expression")
+
+// errDecisionBudget is returned when evaluation runs out of steps or time
+var errDecisionBudget = errors.New("decision expression exceeded its evaluation budget")
+
+type exprKind int
+
+const (
+	exprLiteral exprKind = iota
+	exprIdent
+	exprUnary
+	exprBinary
+	exprCall
+)
+
+// exprNode is a parsed decision expression. Identifiers are paths into the evaluation
+// environment, e.g. data.tags[0], and calls are limited to decisionFunctions.
+type exprNode struct {
+	kind   exprKind
+	op     string        // Operator or function name
+	value  interface{}   // Literal value
+	path   []interface{} // Identifier path of string keys and int indexes
+	args   []*exprNode
+	source string // Expression text of the node, shown in traces
+}
+
+// decisionFunctions lists functions available to expressions with their argument count
+var decisionFunctions = map[string]int{
+	"lower":    1, // Lowercase string
+	"len":      1, // Length of string, list or object
+	"number":   1, // Number from number or numeric string, null otherwise
+	"contains": 2, // Case-insensitive substring, or list membership
+	"matches":  2, // Regular expression match of string
+}
+
+type exprToken struct {
+	kind  string // num, str, ident, op or eof
+	text  string
+	value interface{}
+	pos   int
+}
+
+// tokenizeDecisionExpr splits expression into tokens
+func tokenizeDecisionExpr(input string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r >= '0' && r <= '9':
+			start := i
+			for i < len(input) && (input[i] >= '0' && input[i] <= '9' || input[i] == '.') {
+				i++
+			}
+			value, err := strconv.ParseFloat(input[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid number %q at %d", ErrInvalidDecisionExpr, input[start:i], start)
+			}
+			tokens = append(tokens, exprToken{kind: "num", text: input[start:i], value: value, pos: start})
+		case r == '\'' || r == '"':
+			start := i
+			var text strings.Builder
+			i += size
+			closed := false
+			for i < len(input) {
+				c, n := utf8.DecodeRuneInString(input[i:])
+				i += n
+				if c == '\\' && i < len(input) {
+					escaped, m := utf8.DecodeRuneInString(input[i:])
+					i += m
+					text.WriteRune(escaped)
+					continue
+				}
+				if c == r {
+					closed = true
+					break
+				}
+				text.WriteRune(c)
+			}
+			if !closed {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrInvalidDecisionExpr, start)
+			}
+			tokens = append(tokens, exprToken{kind: "str", text: input[start:i], value: text.String(), pos: start})
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			start := i
+			for i < len(input) {
+				c, n := utf8.DecodeRuneInString(input[i:])
+				if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '$' {
+					break
+				}
+				i += n
+			}
+			tokens = append(tokens, exprToken{kind: "ident", text: input[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ",", "."} {
+				if strings.HasPrefix(input[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("%w: unexpected %q at %d", ErrInvalidDecisionExpr, r, i)
+			}
+			tokens = append(tokens, exprToken{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: "eof", pos: len(input)}), nil
+}
+
+// exprParser is a recursive descent parser, lowest precedence first:
+// or, and, not, comparison and in, + -, * /, unary minus, primary
+type exprParser struct {
+	input  string
+	tokens []exprToken
+	pos    int
+	nodes  int
+}
+
+// parseDecisionExpr parses expression and checks its size limits
+func parseDecisionExpr(input string) (*exprNode, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, fmt.Errorf("%w: expression is empty", ErrInvalidDecisionExpr)
+	}
+	if len(input) > maxDecisionExprLength {
+		return nil, fmt.Errorf("%w: expression is longer than %d characters", ErrInvalidDecisionExpr, maxDecisionExprLength)
+	}
+
+	tokens, err := tokenizeDecisionExpr(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{input: input, tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.kind != "eof" {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrInvalidDecisionExpr, token.text, token.pos)
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	token := p.tokens[p.pos]
+	if token.kind != "eof" {
+		p.pos++
+	}
+	return token
+}
+
+// accept consumes next token if it is one of operators or keywords
+func (p *exprParser) accept(texts ...string) (string, bool) {
+	token := p.peek()
+	if token.kind != "op" && token.kind != "ident" {
+		return "", false
+	}
+	for _, text := range texts {
+		if token.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		token := p.peek()
+		return fmt.Errorf("%w: expected %q at %d", ErrInvalidDecisionExpr, text, token.pos)
+	}
+	return nil
+}
+
+// node creates node spanning source from token start to the current position
+func (p *exprParser) node(start int, node *exprNode) (*exprNode, error) {
+	p.nodes++
+	if p.nodes > maxDecisionExprNodes {
+		return nil, fmt.Errorf("%w: expression has more than %d terms", ErrInvalidDecisionExpr, maxDecisionExprNodes)
+	}
+	end := p.tokens[p.pos].pos
+	node.source = strings.TrimSpace(p.input[p.tokens[start].pos:end])
+	return node, nil
+}
+
+func (p *exprParser) parseBinary(operand func() (*exprNode, error), ops map[string]string) (*exprNode, error) {
+	start := p.pos
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		op, ok := ops[token.text]
+		if !ok || (token.kind != "op" && token.kind != "ident") {
+			return left, nil
+		}
+		p.pos++
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = p.node(start, &exprNode{kind: exprBinary, op: op, args: []*exprNode{left, right}}); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *exprParser) parseOr() (*exprNode, error) {
+	return p.parseBinary(p.parseAnd, map[string]string{"||": "||", "or": "||"})
+}
+
+func (p *exprParser) parseAnd() (*exprNode, error) {
+	return p.parseBinary(p.parseNot, map[string]string{"&&": "&&", "and": "&&"})
+}
+
+func (p *exprParser) parseNot() (*exprNode, error) {
+	start := p.pos
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return p.node(start, &exprNode{kind: exprUnary, op: "!", args: []*exprNode{operand}})
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (*exprNode, error) {
+	start := p.pos
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "in")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return p.node(start, &exprNode{kind: exprBinary, op: op, args: []*exprNode{left, right}})
+}
+
+func (p *exprParser) parseAdditive() (*exprNode, error) {
+	return p.parseBinary(p.parseMultiplicative, map[string]string{"+": "+", "-": "-"})
+}
+
+func (p *exprParser) parseMultiplicative() (*exprNode, error) {
+	return p.parseBinary(p.parseUnary, map[string]string{"*": "*", "/": "/"})
+}
+
+func (p *exprParser) parseUnary() (*exprNode, error) {
+	start := p.pos
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return p.node(start, &exprNode{kind: exprUnary, op: "-", args: []*exprNode{operand}})
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (*exprNode, error) {
+	start := p.pos
+	token := p.next()
+	switch token.kind {
+	case "num", "str":
+		return p.node(start, &exprNode{kind: exprLiteral, value: token.value})
+	case "op":
+		if token.text != "(" {
+			return nil, fmt.Errorf("%w: unexpected %q at %d", ErrInvalidDecisionExpr, token.text, token.pos)
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case "ident":
+		switch token.text {
+		case "true", "false":
+			return p.node(start, &exprNode{kind: exprLiteral, value: token.text == "true"})
+		case "null":
+			return p.node(start, &exprNode{kind: exprLiteral})
+		case "and", "or", "not", "in":
+			return nil, fmt.Errorf("%w: unexpected %q at %d", ErrInvalidDecisionExpr, token.text, token.pos)
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(start, token)
+		}
+		return p.parsePath(start, token)
+	}
+	return nil, fmt.Errorf("%w: unexpected end of expression", ErrInvalidDecisionExpr)
+}
+
+func (p *exprParser) parseCall(start int, name exprToken) (*exprNode, error) {
+	arity, known := decisionFunctions[name.text]
+	if !known {
+		return nil, fmt.Errorf("%w: unknown function %q at %d", ErrInvalidDecisionExpr, name.text, name.pos)
+	}
+
+	var args []*exprNode
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	if len(args) != arity {
+		return nil, fmt.Errorf("%w: %s takes %d arguments, got %d", ErrInvalidDecisionExpr, name.text, arity, len(args))
+	}
+	return p.node(start, &exprNode{kind: exprCall, op: name.text, args: args})
+}
+
+func (p *exprParser) parsePath(start int, name exprToken) (*exprNode, error) {
+	path := []interface{}{name.text}
+	for {
+		if _, ok := p.accept("."); ok {
+			field := p.next()
+			if field.kind != "ident" {
+				return nil, fmt.Errorf("%w: expected field name at %d", ErrInvalidDecisionExpr, field.pos)
+			}
+			path = append(path, field.text)
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			key := p.next()
+			switch value := key.value.(type) {
+			case float64:
+				if value != math.Trunc(value) || value < 0 {
+					return nil, fmt.Errorf("%w: invalid index %s at %d", ErrInvalidDecisionExpr, key.text, key.pos)
+				}
+				path = append(path, int(value))
+			case string:
+				path = append(path, value)
+			default:
+				return nil, fmt.Errorf("%w: expected index or quoted key at %d", ErrInvalidDecisionExpr, key.pos)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return p.node(start, &exprNode{kind: exprIdent, path: path})
+	}
+}
+
+// DecisionTraceEntry is an intermediate value computed while evaluating an expression
+type DecisionTraceEntry struct {
+	Expr  string      `json:"expr"`
+	Value interface{} `json:"value"`
+}
+
+// exprEvaluator evaluates expressions against JSON-like environment within a step and time budget
+type exprEvaluator struct {
+	env      map[string]interface{}
+	steps    int
+	deadline time.Time
+	tracing  bool
+	trace    []DecisionTraceEntry
+	patterns map[string]*regexp.Regexp
+}
+
+func newExprEvaluator(env map[string]interface{}, tracing bool) *exprEvaluator {
+	return &exprEvaluator{
+		env:      env,
+		deadline: time.Now().Add(decisionEvalTimeout),
+		tracing:  tracing,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+}
+
+// spend charges steps against the budget
+func (e *exprEvaluator) spend(steps int) error {
+	e.steps += steps
+	if e.steps > maxDecisionExprSteps || time.Now().After(e.deadline) {
+		return errDecisionBudget
+	}
+	return nil
+}
+
+func (e *exprEvaluator) eval(node *exprNode) (interface{}, error) {
+	if err := e.spend(1); err != nil {
+		return nil, err
+	}
+
+	value, err := e.evalNode(node)
+	if err != nil {
+		return nil, err
+	}
+	if e.tracing && node.kind != exprLiteral && len(e.trace) < maxDecisionTrace {
+		e.trace = append(e.trace, DecisionTraceEntry{Expr: node.source, Value: value})
+	}
+	return value, nil
+}
+
+func (e *exprEvaluator) evalNode(node *exprNode) (interface{}, error) {
+	switch node.kind {
+	case exprLiteral:
+		return node.value, nil
+	case exprIdent:
+		return e.resolve(node.path), nil
+	case exprUnary:
+		operand, err := e.eval(node.args[0])
+		if err != nil {
+			return nil, err
+		}
+		if node.op == "!" {
+			return !exprTruthy(operand), nil
+		}
+		number, ok := exprNumber(operand)
+		if !ok {
+			return nil, fmt.Errorf("%s: cannot negate %s", node.source, exprTypeName(operand))
+		}
+		return -number, nil
+	case exprBinary:
+		return e.evalBinary(node)
+	case exprCall:
+		return e.evalCall(node)
+	}
+	return nil, fmt.Errorf("%s: unsupported expression", node.source)
+}
+
+// resolve returns value at path of environment, null when any part is missing
+func (e *exprEvaluator) resolve(path []interface{}) interface{} {
+	var current interface{} = e.env
+	for _, part := range path {
+		switch key := part.(type) {
+		case string:
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			current = object[key]
+		case int:
+			list, ok := current.([]interface{})
+			if !ok || key >= len(list) {
+				return nil
+			}
+			current = list[key]
+		}
+	}
+	return current
+}
+
+func (e *exprEvaluator) evalBinary(node *exprNode) (interface{}, error) {
+	left, err := e.eval(node.args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators short-circuit
+	switch node.op {
+	case "&&":
+		if !exprTruthy(left) {
+			return false, nil
+		}
+		right, err := e.eval(node.args[1])
+		return err == nil && exprTruthy(right), err
+	case "||":
+		if exprTruthy(left) {
+			return true, nil
+		}
+		right, err := e.eval(node.args[1])
+		return err == nil && exprTruthy(right), err
+	}
+
+	right, err := e.eval(node.args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.op {
+	case "==":
+		return exprEqual(left, right), nil
+	case "!=":
+		return !exprEqual(left, right), nil
+	case "in":
+		return e.contains(right, left, false)
+	case "+":
+		if leftText, ok := left.(string); ok {
+			return e.concat(leftText, exprString(right))
+		}
+		if rightText, ok := right.(string); ok {
+			return e.concat(exprString(left), rightText)
+		}
+	}
+
+	a, aok := exprNumber(left)
+	b, bok := exprNumber(right)
+	if !aok || !bok {
+		// Strings compare lexically, anything else with null is false
+		leftText, lok := left.(string)
+		rightText, rok := right.(string)
+		if lok && rok {
+			switch node.op {
+			case "<":
+				return leftText < rightText, nil
+			case "<=":
+				return leftText <= rightText, nil
+			case ">":
+				return leftText > rightText, nil
+			case ">=":
+				return leftText >= rightText, nil
+			}
+		}
+		if left == nil || right == nil {
+			switch node.op {
+			case "<", "<=", ">", ">=":
+				return false, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: cannot apply %s to %s and %s", node.source, node.op, exprTypeName(left), exprTypeName(right))
+	}
+
+	switch node.op {
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("%s: division by zero", node.source)
+		}
+		return a / b, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported operator %s", node.source, node.op)
+}
+
+func (e *exprEvaluator) evalCall(node *exprNode) (interface{}, error) {
+	args := make([]interface{}, len(node.args))
+	for i, arg := range node.args {
+		value, err := e.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	switch node.op {
+	case "lower":
+		if err := e.spend(len(exprString(args[0])) / 64); err != nil {
+			return nil, err
+		}
+		return strings.ToLower(exprString(args[0])), nil
+	case "len":
+		switch value := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(value)), nil
+		case []interface{}:
+			return float64(len(value)), nil
+		case map[string]interface{}:
+			return float64(len(value)), nil
+		case nil:
+			return float64(0), nil
+		}
+		return nil, fmt.Errorf("%s: len of %s", node.source, exprTypeName(args[0]))
+	case "number":
+		if number, ok := exprNumber(args[0]); ok {
+			return number, nil
+		}
+		return nil, nil
+	case "contains":
+		return e.contains(args[0], args[1], true)
+	case "matches":
+		pattern := exprString(args[1])
+		re, err := e.pattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", node.source, err)
+		}
+		text := exprString(args[0])
+		if err := e.spend(len(text) / 64); err != nil {
+			return nil, err
+		}
+		return re.MatchString(text), nil
+	}
+	return nil, fmt.Errorf("%s: unknown function %s", node.source, node.op)
+}
+
+// contains reports whether container holds item: substring of a string, element of a list
+// or key of an object. Strings are compared case-insensitively when foldCase is set.
+func (e *exprEvaluator) contains(container, item interface{}, foldCase bool) (bool, error) {
+	switch value := container.(type) {
+	case string:
+		if err := e.spend(len(value) / 64); err != nil {
+			return false, err
+		}
+		needle := exprString(item)
+		if foldCase {
+			return strings.Contains(strings.ToLower(value), strings.ToLower(needle)), nil
+		}
+		return strings.Contains(value, needle), nil
+	case []interface{}:
+		if err := e.spend(len(value)); err != nil {
+			return false, err
+		}
+		for _, element := range value {
+			if exprEqual(element, item) {
+				return true, nil
+			}
+			if foldCase {
+				a, aok := element.(string)
+				b, bok := item.(string)
+				if aok && bok && strings.EqualFold(a, b) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		_, exists := value[exprString(item)]
+		return exists, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("cannot search in %s", exprTypeName(container))
+}
+
+// concat joins strings within the string size limit
+func (e *exprEvaluator) concat(a, b string) (interface{}, error) {
+	if len(a)+len(b) > maxDecisionStringBytes {
+		return nil, fmt.Errorf("string longer than %d bytes", maxDecisionStringBytes)
+	}
+	return a + b, nil
+}
+
+// pattern compiles regular expression once per evaluation. Go regular expressions run
+// in linear time, so a hostile pattern cannot stall the evaluator.
+func (e *exprEvaluator) pattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := e.patterns[pattern]; ok {
+		return re, nil
+	}
+	if len(pattern) > maxDecisionPatternLen {
+		return nil, fmt.Errorf("pattern longer than %d characters", maxDecisionPatternLen)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	e.patterns[pattern] = re
+	return re, nil
+}
+
+// exprTruthy reports whether value counts as true: non-empty, non-zero and not false
+func exprTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// exprNumber converts numbers and numeric strings, APIs often return counters as strings
+func exprNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// exprEqual compares values, a number equals a numeric string of the same value
+func exprEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	_, aNumber := a.(float64)
+	_, bNumber := b.(float64)
+	if aNumber || bNumber {
+		x, xok := exprNumber(a)
+		y, yok := exprNumber(b)
+		return xok && yok && x == y
+	}
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	return false
+}
+
+// exprString renders value for string operations
+func exprString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func exprTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"spam-checker/internal/models"
+)
+
+// evalDecisionExpr parses and evaluates expression, failing the test on panic or if it does not
+// return well within the evaluation timeout
+func evalDecisionExpr(t *testing.T, expr string, env map[string]interface{}) (interface{}, error) {
+	t.Helper()
+
+	type outcome struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		node, err := parseDecisionExpr(expr)
+		if err != nil {
+			done <- outcome{err: err}
+			return
+		}
+		value, err := newExprEvaluator(env, false).eval(node)
+		done <- outcome{value, err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil && strings.HasPrefix(result.err.Error(), "panic: ") {
+			t.Fatalf("%.60q panicked: %v", expr, result.err)
+		}
+		return result.value, result.err
+	case <-time.After(time.Second):
+		t.Fatalf("%.60q did not finish within 1s", expr)
+		return nil, nil
+	}
+}
+
+func decisionTestEnv() map[string]interface{} {
+	return decisionEnv(`{
+		"category": "telemarketing",
+		"reports": 120,
+		"count": "42",
+		"nothing": null,
+		"verified": false,
+		"tags": ["Spam", "robot"],
+		"data": {"score": 0.7, "owner": {"name": "Acme"}, "odd key": "yes", "empty": null}
+	}`, "Возможно спам: Telemarketing", []string{"спам"})
+}
+
+func TestDecisionExprEvaluation(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		// Precedence: * before +, comparison before not, and before or
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"12 / 3 / 2", 2.0},
+		{"-2 * 3", -6.0},
+		{"--2", 2.0},
+		{"1 + 2 * 3 == 7", true},
+		{"!1 == 2", true},
+		{"not reports > 100", false},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"false or true and true", true},
+		{"category == 'telemarketing' && reports > 50", true},
+
+		// Short-circuit skips operands that would fail
+		{"false && 1 / 0 > 0", false},
+		{"true || 1 / 0 > 0", true},
+		{"verified && -category", false},
+		{"nothing || reports > 100", true},
+
+		// Mismatched types
+		{"count == 42", true},
+		{"42 == count", true},
+		{"count > 40", true},
+		{"count + 1", "421"}, // + joins when either side is a string
+		{"'1' + 1", "11"},
+		{"category + 1", "telemarketing1"},
+		{"true == 1", false},
+		{"'true' == true", false},
+		{"nothing == 0", false},
+		{"nothing == null", true},
+		{"nothing < 1", false},
+		{"1 >= nothing", false},
+		{"'abc' < 'abd'", true},
+		{"tags == tags", false},
+		{"category != 5", true},
+
+		// Paths into missing and null fields are null
+		{"data.score", 0.7},
+		{"data.owner.name", "Acme"},
+		{"data['odd key']", "yes"},
+		{"tags[1]", "robot"},
+		{"tags[5]", nil},
+		{"missing", nil},
+		{"missing.deep.field", nil},
+		{"nothing.field", nil},
+		{"data.empty.field[0]", nil},
+		{"category.length", nil},
+		{"tags.first", nil},
+		{"data[0]", nil},
+		{"len(missing)", 0.0},
+		{"len(tags)", 2.0},
+		{"len(data)", 4.0},
+		{"len('спам')", 4.0},
+		{"number(missing)", nil},
+		{"number(count) * 2", 84.0},
+		{"number('abc')", nil},
+		{"contains(missing, 'x')", false},
+		{"'x' in missing", false},
+
+		// Functions and reserved variables
+		{"contains(tags, 'spam')", true},
+		{"'spam' in tags", false},
+		{"'Spam' in tags", true},
+		{"'owner' in data", true},
+		{"contains($text, 'TELEMARKETING')", true},
+		{"matches($text, '^Возможно')", true},
+		{"lower(category) == 'telemarketing'", true},
+		{"'спам' in $keywords", true},
+		{"$length", 28.0},
+		{"$response.reports", 120.0},
+	}
+	env := decisionTestEnv()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evalDecisionExpr(t, tt.expr, env)
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("= %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecisionExprErrors(t *testing.T) {
+	bigList := make([]interface{}, maxDecisionExprSteps+1)
+	for i := range bigList {
+		bigList[i] = float64(i)
+	}
+	env := decisionTestEnv()
+	env["big"] = bigList
+	env["huge"] = strings.Repeat("a", maxDecisionStringBytes)
+
+	tests := []struct {
+		name    string
+		expr    string
+		invalid bool  // Rejected by parser
+		target  error // Expected error kind, nil for any evaluation error
+	}{
+		{"empty", "  ", true, nil},
+		{"unterminated string", "category == 'abc", true, nil},
+		{"unknown character", "reports # 1", true, nil},
+		{"unknown function", "exec('rm')", true, nil},
+		{"wrong arity", "lower(category, 1)", true, nil},
+		{"dangling operator", "reports >", true, nil},
+		{"chained comparison", "1 < 2 < 3", true, nil},
+		{"unclosed paren", "(1 + 2", true, nil},
+		{"negative index", "tags[-1]", true, nil},
+		{"fractional index", "tags[1.5]", true, nil},
+		{"keyword as operand", "in == 1", true, nil},
+		{"length limit", strings.Repeat("1+", maxDecisionExprLength/2) + "1", true, nil},
+		{"node limit", strings.Repeat("1+", maxDecisionExprNodes/2) + "1", true, nil},
+		{"nesting node limit", strings.Repeat("-", maxDecisionExprNodes) + "1", true, nil},
+
+		{"division by zero", "reports / 0", false, nil},
+		{"string compared to number", "category < 5", false, nil},
+		{"list arithmetic", "tags * 2", false, nil},
+		{"negate string", "-category", false, nil},
+		{"len of number", "len(reports)", false, nil},
+		{"search in number", "'1' in reports", false, nil},
+		{"step limit scanning list", "'x' in big", false, errDecisionBudget},
+		{"step limit in function", "contains(big, 1)", false, errDecisionBudget},
+		{"string size limit", "huge + 'a'", false, nil},
+		{"string size limit of number", "1 + huge", false, nil},
+		{"pattern size limit", "matches($text, '" + strings.Repeat("a", maxDecisionPatternLen+1) + "')", false, nil},
+		{"invalid pattern", "matches($text, '(')", false, nil},
+		{"error after short-circuit", "true && 1 / 0 > 0", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := evalDecisionExpr(t, tt.expr, env)
+			if err == nil {
+				t.Fatalf("= %#v, want error", value)
+			}
+			if invalid := errors.Is(err, ErrInvalidDecisionExpr); invalid != tt.invalid {
+				t.Errorf("error %v, parse error = %v, want %v", err, invalid, tt.invalid)
+			}
+			if tt.target != nil && !errors.Is(err, tt.target) {
+				t.Errorf("error %v, want %v", err, tt.target)
+			}
+		})
+	}
+}
+
+func TestDecisionExprLimitsAcceptBoundary(t *testing.T) {
+	env := decisionTestEnv()
+	env["list"] = make([]interface{}, maxDecisionExprSteps-10)
+
+	for _, expr := range []string{
+		strings.Repeat("1+", (maxDecisionExprNodes-1)/2) + "1",
+		"matches($text, '" + strings.Repeat("a?", maxDecisionPatternLen/2) + "')",
+		"'x' in list",
+	} {
+		if _, err := evalDecisionExpr(t, expr, env); err != nil {
+			t.Errorf("%.40q at the limit: %v", expr, err)
+		}
+	}
+}
+
+func TestDecisionExprTimeout(t *testing.T) {
+	node, err := parseDecisionExpr("reports > 50")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluator := newExprEvaluator(decisionTestEnv(), false)
+	if remaining := time.Until(evaluator.deadline); remaining <= 0 || remaining > decisionEvalTimeout {
+		t.Errorf("deadline in %s, want within %s", remaining, decisionEvalTimeout)
+	}
+
+	evaluator.deadline = time.Now().Add(-time.Millisecond)
+	if _, err := evaluator.eval(node); !errors.Is(err, errDecisionBudget) {
+		t.Errorf("eval() after deadline = %v, want budget error", err)
+	}
+}
+
+func TestDecisionExprWorstCaseFinishesInTime(t *testing.T) {
+	env := decisionTestEnv()
+	env["$text"] = strings.Repeat("abcdefgh", maxDecisionStringBytes/8)
+
+	// Largest expression of the most expensive calls over the largest string
+	call := "contains(lower($text), 'zz')"
+	parts := []string{call}
+	for len(strings.Join(parts, "||"))+len(call)+2 <= maxDecisionExprLength {
+		parts = append(parts, call)
+	}
+	started := time.Now()
+	_, err := evalDecisionExpr(t, strings.Join(parts, "||"), env)
+	if elapsed := time.Since(started); elapsed > 10*decisionEvalTimeout {
+		t.Errorf("evaluation took %s", elapsed)
+	}
+	if err != nil && !errors.Is(err, errDecisionBudget) && !errors.Is(err, ErrInvalidDecisionExpr) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestApplyDecisionRules(t *testing.T) {
+	env := decisionTestEnv()
+	env["big"] = make([]interface{}, maxDecisionExprSteps+1)
+
+	tests := []struct {
+		name        string
+		rules       []DecisionRule
+		keywordSpam bool
+		wantSource  string
+		wantRule    string
+		wantSpam    bool
+		wantConf    float64
+		wantError   string // Substring of recorded decision error
+	}{
+		{
+			name:       "no rules keep keywords untouched",
+			wantSource: "", keywordSpam: true, wantSpam: true, wantConf: 1,
+		},
+		{
+			name: "first firing rule decides with score",
+			rules: []DecisionRule{
+				{Name: "few reports", When: "reports < 10"},
+				{Name: "many reports", When: "reports > 50", Score: "reports / 200"},
+				{Name: "telemarketing", When: "category == 'telemarketing'"},
+			},
+			wantSource: DecisionSourceRules, wantRule: "many reports", wantSpam: true, wantConf: 0.6,
+		},
+		{
+			name:        "no rule fires overrides keyword spam",
+			rules:       []DecisionRule{{Name: "verified", When: "verified"}},
+			keywordSpam: true,
+			wantSource:  DecisionSourceRules, wantSpam: false, wantConf: 1,
+		},
+		{
+			name:  "score is clamped",
+			rules: []DecisionRule{{Name: "tiny", When: "true", Score: "-5"}},
+			// Zero confidence means unset, so the lowest score stays positive
+			wantSource: DecisionSourceRules, wantRule: "tiny", wantSpam: true, wantConf: 0.01,
+		},
+		{
+			name: "failing rule falls back to keywords",
+			rules: []DecisionRule{
+				{Name: "broken", When: "reports / 0 > 1"},
+				{Name: "telemarketing", When: "category == 'telemarketing'"},
+			},
+			keywordSpam: true,
+			wantSource:  DecisionSourceKeywords, wantSpam: true, wantConf: 1, wantError: `rule "broken"`,
+		},
+		{
+			name:       "budget exhaustion falls back to keywords",
+			rules:      []DecisionRule{{Name: "scan", When: "'x' in big"}},
+			wantSource: DecisionSourceKeywords, wantSpam: false, wantConf: 1, wantError: errDecisionBudget.Error(),
+		},
+		{
+			name:        "non-numeric score falls back to keywords",
+			rules:       []DecisionRule{{Name: "bad score", When: "true", Score: "category"}},
+			keywordSpam: true,
+			wantSource:  DecisionSourceKeywords, wantSpam: true, wantConf: 1, wantError: "not a number",
+		},
+		{
+			name: "rule fired before a failing one decides",
+			rules: []DecisionRule{
+				{Name: "telemarketing", When: "category == 'telemarketing'"},
+				{Name: "broken", When: "reports / 0 > 1"},
+			},
+			wantSource: DecisionSourceRules, wantRule: "telemarketing", wantSpam: true, wantConf: 1,
+		},
+		{
+			name:        "invalid rules fall back to keywords",
+			rules:       []DecisionRule{{Name: "", When: "true"}},
+			keywordSpam: true,
+			wantSource:  DecisionSourceKeywords, wantSpam: true, wantConf: 1, wantError: ErrInvalidDecisionRules.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &models.CheckResult{IsSpam: tt.keywordSpam, Confidence: 1}
+			applyDecisionRules(result, tt.rules, env)
+
+			if result.DecisionSource != tt.wantSource {
+				t.Errorf("source = %q, want %q", result.DecisionSource, tt.wantSource)
+			}
+			if result.DecisionRule != tt.wantRule {
+				t.Errorf("rule = %q, want %q", result.DecisionRule, tt.wantRule)
+			}
+			if result.IsSpam != tt.wantSpam {
+				t.Errorf("spam = %v, want %v", result.IsSpam, tt.wantSpam)
+			}
+			if result.Confidence != tt.wantConf {
+				t.Errorf("confidence = %v, want %v", result.Confidence, tt.wantConf)
+			}
+			if tt.wantError == "" && result.DecisionError != "" || !strings.Contains(result.DecisionError, tt.wantError) {
+				t.Errorf("decision error = %q, want containing %q", result.DecisionError, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestDecisionRulesHarnessTracesEveryRule(t *testing.T) {
+	outcome, err := TestDecisionRules([]DecisionRule{
+		{Name: "broken", When: "reports / 0 > 1"},
+		{Name: "many reports", When: "reports > 50"},
+	}, `{"reports": 80}`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Rules) != 2 {
+		t.Fatalf("%d rules traced, want 2", len(outcome.Rules))
+	}
+	if outcome.Rules[0].Error == "" || outcome.Rules[0].Fired {
+		t.Errorf("broken rule = %+v", outcome.Rules[0])
+	}
+	if !outcome.Rules[1].Fired || len(outcome.Rules[1].Trace) == 0 {
+		t.Errorf("firing rule = %+v", outcome.Rules[1])
+	}
+	// Checks would fall back to keywords, so the harness reports no rule decision
+	if outcome.Rule != "" || outcome.IsSpam {
+		t.Errorf("outcome = %+v, want no decision after a failing rule", outcome)
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDecisionRules limits rules of a single service
+const maxDecisionRules = 20
+
+// Decision sources of a check result with decision rules configured
+const (
+	DecisionSourceRules    = "rules"    // Rules decided the verdict
+	DecisionSourceKeywords = "keywords" // Rules failed, keywords decided the verdict
+)
+
+// ErrInvalidDecisionRules is returned for rule sets that do not validate
+var ErrInvalidDecisionRules = errors.New("invalid decision rules")
+
+// DecisionRule marks result spam when its When expression is true. Rules are tried in order,
+// the first one that fires decides; when none fires the result is clean. Expressions see fields
+// of the JSON response as variables along with $response, $text, $keywords and $length.
+type DecisionRule struct {
+	Name  string `json:"name"`
+	When  string `json:"when"`            // Boolean expression, e.g. category == 'telemarketing' && reports > 50
+	Score string `json:"score,omitempty"` // Numeric expression from 0 to 1 used as confidence, 1 when empty
+}
+
+// DecisionRuleEvaluation is outcome of a single rule shown by the test harness
+type DecisionRuleEvaluation struct {
+	Name  string               `json:"name"`
+	Fired bool                 `json:"fired"`
+	Score *float64             `json:"score,omitempty"`
+	Error string               `json:"error,omitempty"`
+	Trace []DecisionTraceEntry `json:"trace,omitempty"`
+}
+
+// DecisionOutcome is verdict decided by rules
+type DecisionOutcome struct {
+	IsSpam bool                     `json:"is_spam"`
+	Rule   string                   `json:"rule,omitempty"` // Rule that fired, empty when none did
+	Score  float64                  `json:"score"`
+	Rules  []DecisionRuleEvaluation `json:"rules,omitempty"` // Every rule with intermediate values, test harness only
+}
+
+// compiledDecisionRule is a rule with parsed expressions
+type compiledDecisionRule struct {
+	name  string
+	when  *exprNode
+	score *exprNode
+}
+
+// compileDecisionRules validates rules and parses their expressions
+func compileDecisionRules(rules []DecisionRule) ([]compiledDecisionRule, error) {
+	if len(rules) > maxDecisionRules {
+		return nil, fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidDecisionRules, maxDecisionRules)
+	}
+
+	compiled := make([]compiledDecisionRule, 0, len(rules))
+	names := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		name := strings.TrimSpace(rule.Name)
+		if name == "" || utf8.RuneCountInString(name) > 100 {
+			return nil, fmt.Errorf("%w: rule %d must have a name of 1 to 100 characters", ErrInvalidDecisionRules, i+1)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("%w: duplicate rule name %q", ErrInvalidDecisionRules, name)
+		}
+		names[name] = true
+
+		when, err := parseDecisionExpr(rule.When)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rule %q when: %v", ErrInvalidDecisionRules, name, err)
+		}
+		current := compiledDecisionRule{name: name, when: when}
+		if strings.TrimSpace(rule.Score) != "" {
+			if current.score, err = parseDecisionExpr(rule.Score); err != nil {
+				return nil, fmt.Errorf("%w: rule %q score: %v", ErrInvalidDecisionRules, name, err)
+			}
+		}
+		compiled = append(compiled, current)
+	}
+	return compiled, nil
+}
+
+// ValidateDecisionRules checks rules without evaluating them
+func ValidateDecisionRules(rules []DecisionRule) error {
+	_, err := compileDecisionRules(rules)
+	return err
+}
+
+// ParseDecisionRules parses JSON array of rules stored on an API service, empty text has no rules
+func ParseDecisionRules(data string) ([]DecisionRule, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+
+	var rules []DecisionRule
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDecisionRules, err)
+	}
+	if err := ValidateDecisionRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// decisionEnv builds variables of rule expressions. Fields of a JSON object response are
+// variables themselves, reserved names start with $ so they never clash with them.
+func decisionEnv(response, text string, keywords []string) map[string]interface{} {
+	env := make(map[string]interface{})
+
+	var decoded interface{}
+	if json.Unmarshal([]byte(response), &decoded) == nil {
+		if object, ok := decoded.(map[string]interface{}); ok {
+			for key, value := range object {
+				env[key] = value
+			}
+		}
+	}
+
+	keywordValues := make([]interface{}, len(keywords))
+	for i, keyword := range keywords {
+		keywordValues[i] = keyword
+	}
+
+	env["$response"] = decoded
+	env["$text"] = text
+	env["$keywords"] = keywordValues
+	env["$length"] = float64(utf8.RuneCountInString(strings.TrimSpace(text)))
+	return env
+}
+
+// evaluateDecisionRules decides verdict by rules. Evaluation stops at the first rule that fires
+// unless tracing, which evaluates every rule and keeps their intermediate values.
+// Any rule failing before one fires fails the whole decision.
+func evaluateDecisionRules(rules []DecisionRule, env map[string]interface{}, tracing bool) (*DecisionOutcome, error) {
+	compiled, err := compileDecisionRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := &DecisionOutcome{Score: 1}
+	evaluator := newExprEvaluator(env, tracing)
+	var firstErr error
+	for _, rule := range compiled {
+		evaluator.trace = nil
+		evaluation := DecisionRuleEvaluation{Name: rule.name}
+
+		fired, score, err := evaluateDecisionRule(evaluator, rule)
+		evaluation.Fired = fired
+		evaluation.Trace = evaluator.trace
+		if err != nil {
+			evaluation.Error = err.Error()
+			if firstErr == nil && outcome.Rule == "" {
+				firstErr = fmt.Errorf("rule %q: %w", rule.name, err)
+			}
+		}
+		if fired {
+			evaluation.Score = &score
+			if outcome.Rule == "" && firstErr == nil {
+				outcome.IsSpam = true
+				outcome.Rule = rule.name
+				outcome.Score = score
+			}
+		}
+
+		if tracing {
+			outcome.Rules = append(outcome.Rules, evaluation)
+			continue
+		}
+		if firstErr != nil || outcome.Rule != "" {
+			break
+		}
+	}
+
+	return outcome, firstErr
+}
+
+// evaluateDecisionRule returns whether rule fires along with its score
+func evaluateDecisionRule(evaluator *exprEvaluator, rule compiledDecisionRule) (bool, float64, error) {
+	value, err := evaluator.eval(rule.when)
+	if err != nil {
+		return false, 0, err
+	}
+	if !exprTruthy(value) {
+		return false, 0, nil
+	}
+	if rule.score == nil {
+		return true, 1, nil
+	}
+
+	value, err = evaluator.eval(rule.score)
+	if err != nil {
+		return true, 0, fmt.Errorf("score: %w", err)
+	}
+	score, ok := exprNumber(value)
+	if !ok {
+		return true, 0, fmt.Errorf("score is %s, not a number", exprTypeName(value))
+	}
+	// Zero confidence means unset for results, the lowest score is still a weak spam flag
+	return true, min(max(score, 0.01), 1), nil
+}
+
+// applyDecisionRules lets rules decide verdict of result instead of keywords. When rules fail,
+// the keyword verdict already on result is kept and the failure is recorded on it.
+func applyDecisionRules(result *models.CheckResult, rules []DecisionRule, env map[string]interface{}) {
+	if len(rules) == 0 {
+		return
+	}
+
+	outcome, err := evaluateDecisionRules(rules, env, false)
+	if err != nil {
+		result.DecisionSource = DecisionSourceKeywords
+		result.DecisionError = err.Error()
+		return
+	}
+
+	result.DecisionSource = DecisionSourceRules
+	result.DecisionRule = outcome.Rule
+	result.IsSpam = outcome.IsSpam
+	if outcome.IsSpam {
+		result.Confidence = outcome.Score
+	}
+}
+
+// TestDecisionRules evaluates rules against a sample response or text and returns every rule
+// with its intermediate values. Keywords are the ones the keyword scan would have found.
+func TestDecisionRules(rules []DecisionRule, response, text string, keywords []string) (*DecisionOutcome, error) {
+	if err := ValidateDecisionRules(rules); err != nil {
+		return nil, err
+	}
+	// Failures are reported per rule, checks would fall back to keywords on them
+	outcome, _ := evaluateDecisionRules(rules, decisionEnv(response, text, keywords), true)
+	return outcome, nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"spam-checker/internal/logger"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// dockerAPI is the subset of Docker client used by ADBService
+type dockerAPI interface {
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+	ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error)
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+	ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error)
+	ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (container.ExecCreateResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error)
+	ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error)
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+	CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error)
+	Ping(ctx context.Context) (types.Ping, error)
+	ClientVersion() string
+	Close() error
+}
+
+// devScreenshotPNG is a 1x1 PNG returned as screenshot in development mode
+const devScreenshotPNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+
+// mockDockerClient is a no-op Docker client for development mode.
+// It logs intended actions and pretends every container is a running emulator.
+type mockDockerClient struct {
+	mu    sync.Mutex
+	execs map[string][]string
+	seq   int
+	log   *logrus.Entry
+}
+
+func newMockDockerClient() *mockDockerClient {
+	return &mockDockerClient{
+		execs: make(map[string][]string),
+		log:   logger.WithField("service", "MockDockerClient"),
+	}
+}
+
+func (m *mockDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	m.log.Infof("Would create container %s from image %s", containerName, config.Image)
+	return container.CreateResponse{ID: "dev-" + containerName}, nil
+}
+
+func (m *mockDockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	m.log.Infof("Would start container %s", containerID)
+	return nil
+}
+
+func (m *mockDockerClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	m.log.Infof("Would stop container %s", containerID)
+	return nil
+}
+
+func (m *mockDockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	m.log.Infof("Would remove container %s", containerID)
+	return nil
+}
+
+func (m *mockDockerClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	m.log.Infof("Would remove volume %s", volumeID)
+	return nil
+}
+
+func (m *mockDockerClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	return container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:    containerID,
+			Name:  "/" + containerID,
+			State: &container.State{Status: "running", Running: true},
+		},
+	}, nil
+}
+
+func (m *mockDockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	return []container.Summary{}, nil
+}
+
+func (m *mockDockerClient) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	m.log.Infof("Would read logs of %s (tail %s)", containerID, options.Tail)
+	return io.NopCloser(strings.NewReader("dev mode: no container logs\n")), nil
+}
+
+func (m *mockDockerClient) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (container.ExecCreateResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+	execID := fmt.Sprintf("dev-exec-%d", m.seq)
+	m.execs[execID] = options.Cmd
+
+	m.log.Infof("Would execute in %s: %s", containerID, strings.Join(options.Cmd, " "))
+	return container.ExecCreateResponse{ID: execID}, nil
+}
+
+func (m *mockDockerClient) ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error) {
+	m.mu.Lock()
+	cmd := m.execs[execID]
+	m.mu.Unlock()
+
+	output := mockExecOutput(cmd)
+
+	// Serve canned output through an in-memory connection
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		defer serverConn.Close()
+		serverConn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		io.WriteString(serverConn, output)
+	}()
+
+	return types.NewHijackedResponse(clientConn, ""), nil
+}
+
+func (m *mockDockerClient) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	m.mu.Lock()
+	delete(m.execs, execID)
+	m.mu.Unlock()
+
+	return container.ExecInspect{ExecID: execID, ExitCode: 0}, nil
+}
+
+func (m *mockDockerClient) CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+	m.log.Infof("Would copy files to %s:%s", containerID, dstPath)
+	return nil
+}
+
+func (m *mockDockerClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	data, err := base64.StdEncoding.DecodeString(devScreenshotPNG)
+	if err != nil {
+		return nil, container.PathStat{}, err
+	}
+
+	// Docker returns files as tar archive
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	name := srcPath[strings.LastIndex(srcPath, "/")+1:]
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}); err != nil {
+		return nil, container.PathStat{}, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, container.PathStat{}, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, container.PathStat{}, err
+	}
+
+	return io.NopCloser(&buf), container.PathStat{Name: name, Size: int64(len(data))}, nil
+}
+
+func (m *mockDockerClient) Ping(ctx context.Context) (types.Ping, error) {
+	return types.Ping{APIVersion: "dev"}, nil
+}
+
+func (m *mockDockerClient) ClientVersion() string {
+	return "dev"
+}
+
+func (m *mockDockerClient) Close() error {
+	return nil
+}
+
+// mockExecOutput returns canned output for ADB commands
+func mockExecOutput(cmd []string) string {
+	command := strings.Join(cmd, " ")
+
+	switch {
+	case command == "adb devices":
+		return "List of devices attached\nemulator-5554\tdevice\n"
+	case command == "adb get-state":
+		return "device\n"
+	case strings.HasPrefix(command, "adb shell getprop sys.boot_completed"):
+		return "1\n"
+	case strings.HasPrefix(command, "adb shell getprop"):
+		return "dev\n"
+	case strings.HasPrefix(command, "adb shell dumpsys battery"):
+		return "Current Battery Service state:\n  level: 100\n  status: 2\n"
+	case strings.HasPrefix(command, "adb shell wm size"):
+		return "Physical size: 1080x1920\n"
+	case strings.HasPrefix(command, "adb shell pm list packages"):
+		return "package:com.android.dev\n"
+	case strings.HasPrefix(command, "adb shell pm path"):
+		return "package:/data/app/" + cmd[len(cmd)-1] + "/base.apk\n"
+	case strings.HasPrefix(command, "adb shell cmd package resolve-activity"):
+		return "priority=0 preferredOrder=0 match=0x108000 specificIndex=-1 isDefault=true\n" + cmd[len(cmd)-1] + "/.MainActivity\n"
+	case strings.HasPrefix(command, "adb install"):
+		return "Success\n"
+	case strings.HasPrefix(command, "adb emu"):
+		return "OK\n"
+	case strings.HasPrefix(command, "adb shell am start"):
+		return "Starting: Intent\n"
+	case strings.HasPrefix(command, "adb logcat"):
+		return "--------- beginning of main\nI/dev: mock logcat output\n"
+	default:
+		return ""
+	}
+}
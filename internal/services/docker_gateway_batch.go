@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// maxDockerGatewayBatch limits how many emulators a single batch may create
+const maxDockerGatewayBatch = 20
+
+// Docker gateway batch statuses
+const (
+	GatewayBatchCreating  = "creating"
+	GatewayBatchCompleted = "completed"
+
+	GatewayBatchItemPending = "pending"
+	GatewayBatchItemStarted = "started"
+	GatewayBatchItemFailed  = "failed"
+)
+
+// DockerGatewayBatchItem represents creation progress of a single gateway in batch
+type DockerGatewayBatchItem struct {
+	GatewayID     uint         `json:"gateway_id"`
+	Name          string       `json:"name"`
+	Ports         GatewayPorts `json:"ports"`
+	Status        string       `json:"status"`                   // pending, started or failed
+	GatewayStatus string       `json:"gateway_status,omitempty"` // Live gateway status once started
+	Error         string       `json:"error,omitempty"`
+}
+
+// DockerGatewayBatch represents asynchronous creation of several Docker gateways
+type DockerGatewayBatch struct {
+	ID          string                   `json:"id"`
+	ServiceCode string                   `json:"service_code"`
+	Status      string                   `json:"status"`
+	Gateways    []DockerGatewayBatchItem `json:"gateways"`
+	CreatedAt   time.Time                `json:"created_at"`
+	CompletedAt *time.Time               `json:"completed_at,omitempty"`
+}
+
+// dockerGatewayBatches keeps batches in memory, they are only useful while containers are starting
+type dockerGatewayBatches struct {
+	mu      sync.Mutex
+	batches map[string]*DockerGatewayBatch
+}
+
+func newDockerGatewayBatches() *dockerGatewayBatches {
+	return &dockerGatewayBatches{batches: make(map[string]*DockerGatewayBatch)}
+}
+
+// CreateDockerGatewayBatch reserves count gateways named namePrefix-N with distinct ports on
+// Docker host and starts their containers in background. Either all gateways are reserved or none.
+func (s *ADBService) CreateDockerGatewayBatch(serviceCode, namePrefix, dockerHost string, count int, apkData []byte, apkID *uint) (*DockerGatewayBatch, error) {
+	if s.dockerClient == nil {
+		return nil, errors.New("Docker client is not initialized")
+	}
+	if count < 1 || count > maxDockerGatewayBatch {
+		return nil, fmt.Errorf("count must be between 1 and %d", maxDockerGatewayBatch)
+	}
+	if namePrefix == "" {
+		namePrefix = serviceCode
+	}
+
+	var libraryAPK *models.APKFile
+	if len(apkData) == 0 {
+		var err error
+		libraryAPK, err = s.resolveGatewayAPK(serviceCode, apkID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	batch := &DockerGatewayBatch{
+		ID:          uuid.New().String(),
+		ServiceCode: serviceCode,
+		Status:      GatewayBatchCreating,
+		CreatedAt:   time.Now(),
+	}
+
+	reserved := make([]*models.ADBGateway, 0, count)
+	for suffix := 1; len(reserved) < count; suffix++ {
+		name := fmt.Sprintf("%s-%d", namePrefix, suffix)
+
+		var existing int64
+		if err := s.db.Model(&models.ADBGateway{}).Where("name = ?", name).Count(&existing).Error; err != nil {
+			s.releaseBatch(reserved)
+			return nil, fmt.Errorf("failed to check gateway name: %w", err)
+		}
+		if existing > 0 {
+			continue
+		}
+
+		gateway := &models.ADBGateway{
+			Name:        name,
+			ServiceCode: serviceCode,
+			IsActive:    true,
+			Status:      "creating",
+			IsDocker:    true,
+			DockerHost:  dockerHost,
+		}
+		if err := s.reserveDockerGateway(gateway); err != nil {
+			s.releaseBatch(reserved)
+			return nil, err
+		}
+
+		reserved = append(reserved, gateway)
+		batch.Gateways = append(batch.Gateways, DockerGatewayBatchItem{
+			GatewayID: gateway.ID,
+			Name:      gateway.Name,
+			Ports:     s.portManager.gatewayPorts(gateway),
+			Status:    GatewayBatchItemPending,
+		})
+	}
+
+	s.batches.mu.Lock()
+	s.batches.batches[batch.ID] = batch
+	s.batches.mu.Unlock()
+
+	go s.runDockerGatewayBatch(batch, reserved, apkData, libraryAPK)
+
+	return s.GetDockerGatewayBatch(batch.ID)
+}
+
+// releaseBatch undoes reservation of already reserved gateways
+func (s *ADBService) releaseBatch(gateways []*models.ADBGateway) {
+	for _, gateway := range gateways {
+		s.releaseDockerGateway(gateway)
+	}
+}
+
+// runDockerGatewayBatch starts containers one by one so the Docker host is not flooded
+func (s *ADBService) runDockerGatewayBatch(batch *DockerGatewayBatch, gateways []*models.ADBGateway, apkData []byte, libraryAPK *models.APKFile) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "runDockerGatewayBatch",
+		"batch":  batch.ID,
+	})
+
+	for i, gateway := range gateways {
+		err := s.startDockerGateway(gateway, apkData, libraryAPK)
+
+		s.batches.mu.Lock()
+		if err != nil {
+			batch.Gateways[i].Status = GatewayBatchItemFailed
+			batch.Gateways[i].Error = err.Error()
+		} else {
+			batch.Gateways[i].Status = GatewayBatchItemStarted
+		}
+		s.batches.mu.Unlock()
+
+		if err != nil {
+			log.Errorf("Failed to start gateway %s: %v", gateway.Name, err)
+		}
+	}
+
+	now := time.Now()
+	s.batches.mu.Lock()
+	batch.Status = GatewayBatchCompleted
+	batch.CompletedAt = &now
+	s.batches.mu.Unlock()
+
+	log.Infof("Docker gateway batch completed (%d gateways)", len(gateways))
+}
+
+// GetDockerGatewayBatch returns batch progress with live status of started gateways
+func (s *ADBService) GetDockerGatewayBatch(id string) (*DockerGatewayBatch, error) {
+	s.batches.mu.Lock()
+	stored, exists := s.batches.batches[id]
+	if !exists {
+		s.batches.mu.Unlock()
+		return nil, errors.New("batch not found")
+	}
+	batch := *stored
+	batch.Gateways = append([]DockerGatewayBatchItem(nil), stored.Gateways...)
+	s.batches.mu.Unlock()
+
+	for i, item := range batch.Gateways {
+		if item.Status != GatewayBatchItemStarted {
+			continue
+		}
+		if gateway, err := s.GetGatewayByID(item.GatewayID); err == nil {
+			batch.Gateways[i].GatewayStatus = gateway.Status
+		}
+	}
+
+	return &batch, nil
+}
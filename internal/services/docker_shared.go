@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"spam-checker/internal/config"
+	"spam-checker/internal/logger"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// DockerClient is a Docker client shared by all services.
+// The underlying client is created lazily and rebuilt once when an
+// operation fails with a transport error, after which the operation is retried.
+type DockerClient struct {
+	mu            sync.Mutex
+	api           dockerAPI
+	newAPI        func() (dockerAPI, error)
+	host          string
+	lastError     string
+	reconnects    int
+	lastReconnect *time.Time
+	log           *logrus.Entry
+
+	// Clients of other Docker hosts running gateway containers, keyed by host
+	hostsMu  sync.Mutex
+	hosts    map[string]*DockerClient
+	devMode  bool
+	hostPort string // Port used for hosts given without one
+}
+
+// DockerClientStatus represents connection state of the shared Docker client
+type DockerClientStatus struct {
+	Connected        bool       `json:"connected"`
+	Host             string     `json:"host"`
+	APIVersion       string     `json:"api_version,omitempty"`
+	ServerAPIVersion string     `json:"server_api_version,omitempty"`
+	Reconnects       int        `json:"reconnects"`
+	LastReconnect    *time.Time `json:"last_reconnect,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	// Other Docker hosts gateways run on, reported by the default client only
+	Hosts []DockerClientStatus `json:"hosts,omitempty"`
+}
+
+// NewDockerClient creates shared Docker client from configuration
+func NewDockerClient(cfg *config.Config) *DockerClient {
+	hostPort := "2375"
+	if cfg != nil && cfg.Docker.Port != "" {
+		hostPort = cfg.Docker.Port
+	}
+
+	if cfg != nil && cfg.App.DevMode {
+		logger.WithField("service", "DockerClient").Warn("Development mode: using mock Docker client")
+		d := newDockerClientForHost("mock", true)
+		d.hostPort = hostPort
+		return d
+	}
+
+	host := "unix:///var/run/docker.sock"
+	if cfg != nil && cfg.Docker.Host != "" {
+		host = fmt.Sprintf("tcp://%s:%s", cfg.Docker.Host, cfg.Docker.Port)
+	}
+
+	d := newDockerClientForHost(host, false)
+	d.hostPort = hostPort
+	if _, err := d.current(); err != nil {
+		d.log.Errorf("Failed to create Docker client: %v", err)
+	}
+
+	return d
+}
+
+// newDockerClientForHost creates client of Docker daemon at host, connected on first use
+func newDockerClientForHost(host string, devMode bool) *DockerClient {
+	d := &DockerClient{
+		host:    host,
+		devMode: devMode,
+		log:     logger.WithFields(logrus.Fields{"service": "DockerClient", "docker_host": host}),
+	}
+
+	if devMode {
+		d.newAPI = func() (dockerAPI, error) {
+			return newMockDockerClient(), nil
+		}
+		return d
+	}
+
+	d.newAPI = func() (dockerAPI, error) {
+		return client.NewClientWithOpts(
+			client.WithHost(host),
+			client.WithAPIVersionNegotiation(),
+		)
+	}
+	return d
+}
+
+// normalizeDockerHost turns Docker host given as host, host:port or URL into a URL
+// the client accepts. Empty host stays empty and means the default host.
+func normalizeDockerHost(value, defaultPort string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(value, "unix://") || strings.HasPrefix(value, "npipe://") {
+		return value, nil
+	}
+
+	if !strings.Contains(value, "://") {
+		value = "tcp://" + value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Hostname() == "" {
+		return "", fmt.Errorf("invalid Docker host %q", value)
+	}
+	if parsed.Scheme != "tcp" && parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("unsupported Docker host scheme %q", parsed.Scheme)
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return fmt.Sprintf("%s://%s", parsed.Scheme, net.JoinHostPort(parsed.Hostname(), port)), nil
+}
+
+// NormalizeHost returns normalized Docker host of a gateway, empty when it is the default host
+func (d *DockerClient) NormalizeHost(value string) (string, error) {
+	host, err := normalizeDockerHost(value, d.hostPort)
+	if err != nil || host == d.host {
+		return "", err
+	}
+	return host, nil
+}
+
+// ForHost returns client of Docker daemon at normalized host, the default client for empty host.
+// Clients are created on first use and shared by all services.
+func (d *DockerClient) ForHost(host string) *DockerClient {
+	if host == "" || host == d.host {
+		return d
+	}
+
+	d.hostsMu.Lock()
+	defer d.hostsMu.Unlock()
+
+	if d.hosts == nil {
+		d.hosts = make(map[string]*DockerClient)
+	}
+	hostClient, ok := d.hosts[host]
+	if !ok {
+		hostClient = newDockerClientForHost(host, d.devMode)
+		d.hosts[host] = hostClient
+	}
+	return hostClient
+}
+
+// hostClients returns clients of other Docker hosts created so far
+func (d *DockerClient) hostClients() []*DockerClient {
+	d.hostsMu.Lock()
+	defer d.hostsMu.Unlock()
+
+	clients := make([]*DockerClient, 0, len(d.hosts))
+	for _, hostClient := range d.hosts {
+		clients = append(clients, hostClient)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].host < clients[j].host })
+	return clients
+}
+
+// current returns underlying client, creating it if needed
+func (d *DockerClient) current() (dockerAPI, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.api != nil {
+		return d.api, nil
+	}
+
+	api, err := d.newAPI()
+	if err != nil {
+		d.lastError = err.Error()
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	d.api = api
+	return api, nil
+}
+
+// reconnect replaces failed client with a new one. If another caller already
+// replaced it, the newer client is returned without rebuilding again.
+func (d *DockerClient) reconnect(failed dockerAPI, cause error) (dockerAPI, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastError = cause.Error()
+
+	if d.api != nil && d.api != failed {
+		return d.api, nil
+	}
+
+	d.log.Warnf("Docker transport error, reconnecting: %v", cause)
+
+	if failed != nil {
+		failed.Close()
+	}
+	d.api = nil
+
+	api, err := d.newAPI()
+	if err != nil {
+		d.lastError = err.Error()
+		return nil, fmt.Errorf("failed to recreate Docker client: %w", err)
+	}
+
+	now := time.Now()
+	d.api = api
+	d.reconnects++
+	d.lastReconnect = &now
+
+	return api, nil
+}
+
+// isDockerTransportError reports whether request never reached Docker daemon,
+// so it is safe to repeat it on a new client
+func isDockerTransportError(err error) bool {
+	return client.IsErrConnectionFailed(err) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// withDockerReconnect runs operation, rebuilding client and retrying once on transport error
+func withDockerReconnect[T any](d *DockerClient, op func(api dockerAPI) (T, error)) (T, error) {
+	api, err := d.current()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	result, err := op(api)
+	if err == nil || !isDockerTransportError(err) {
+		return result, err
+	}
+
+	api, reconnectErr := d.reconnect(api, err)
+	if reconnectErr != nil {
+		d.log.Errorf("Docker reconnect failed: %v", reconnectErr)
+		return result, err
+	}
+
+	return op(api)
+}
+
+// Status pings Docker daemon and returns connection state
+func (d *DockerClient) Status(ctx context.Context) DockerClientStatus {
+	ping, err := d.Ping(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := DockerClientStatus{
+		Connected:        err == nil,
+		Host:             d.host,
+		ServerAPIVersion: ping.APIVersion,
+		Reconnects:       d.reconnects,
+		LastReconnect:    d.lastReconnect,
+		LastError:        d.lastError,
+	}
+	if d.api != nil {
+		status.APIVersion = d.api.ClientVersion()
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	}
+
+	return status
+}
+
+// StatusWithHosts returns connection state of default client along with other Docker hosts
+func (d *DockerClient) StatusWithHosts(ctx context.Context) DockerClientStatus {
+	status := d.Status(ctx)
+	for _, hostClient := range d.hostClients() {
+		status.Hosts = append(status.Hosts, hostClient.Status(ctx))
+	}
+	return status
+}
+
+func (d *DockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	return withDockerReconnect(d, func(api dockerAPI) (container.CreateResponse, error) {
+		return api.ContainerCreate(ctx, config, hostConfig, networkingConfig, platform, containerName)
+	})
+}
+
+func (d *DockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	_, err := withDockerReconnect(d, func(api dockerAPI) (struct{}, error) {
+		return struct{}{}, api.ContainerStart(ctx, containerID, options)
+	})
+	return err
+}
+
+func (d *DockerClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	_, err := withDockerReconnect(d, func(api dockerAPI) (struct{}, error) {
+		return struct{}{}, api.ContainerStop(ctx, containerID, options)
+	})
+	return err
+}
+
+func (d *DockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	_, err := withDockerReconnect(d, func(api dockerAPI) (struct{}, error) {
+		return struct{}{}, api.ContainerRemove(ctx, containerID, options)
+	})
+	return err
+}
+
+func (d *DockerClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	_, err := withDockerReconnect(d, func(api dockerAPI) (struct{}, error) {
+		return struct{}{}, api.VolumeRemove(ctx, volumeID, force)
+	})
+	return err
+}
+
+func (d *DockerClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	return withDockerReconnect(d, func(api dockerAPI) (container.InspectResponse, error) {
+		return api.ContainerInspect(ctx, containerID)
+	})
+}
+
+func (d *DockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	return withDockerReconnect(d, func(api dockerAPI) ([]container.Summary, error) {
+		return api.ContainerList(ctx, options)
+	})
+}
+
+func (d *DockerClient) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	return withDockerReconnect(d, func(api dockerAPI) (io.ReadCloser, error) {
+		return api.ContainerLogs(ctx, containerID, options)
+	})
+}
+
+func (d *DockerClient) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (container.ExecCreateResponse, error) {
+	return withDockerReconnect(d, func(api dockerAPI) (container.ExecCreateResponse, error) {
+		return api.ContainerExecCreate(ctx, containerID, options)
+	})
+}
+
+func (d *DockerClient) ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error) {
+	return withDockerReconnect(d, func(api dockerAPI) (types.HijackedResponse, error) {
+		return api.ContainerExecAttach(ctx, execID, config)
+	})
+}
+
+func (d *DockerClient) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	return withDockerReconnect(d, func(api dockerAPI) (container.ExecInspect, error) {
+		return api.ContainerExecInspect(ctx, execID)
+	})
+}
+
+func (d *DockerClient) CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+	_, err := withDockerReconnect(d, func(api dockerAPI) (struct{}, error) {
+		return struct{}{}, api.CopyToContainer(ctx, containerID, dstPath, content, options)
+	})
+	return err
+}
+
+func (d *DockerClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	type copyResult struct {
+		reader io.ReadCloser
+		stat   container.PathStat
+	}
+
+	result, err := withDockerReconnect(d, func(api dockerAPI) (copyResult, error) {
+		reader, stat, err := api.CopyFromContainer(ctx, containerID, srcPath)
+		return copyResult{reader: reader, stat: stat}, err
+	})
+	return result.reader, result.stat, err
+}
+
+func (d *DockerClient) Ping(ctx context.Context) (types.Ping, error) {
+	return withDockerReconnect(d, func(api dockerAPI) (types.Ping, error) {
+		return api.Ping(ctx)
+	})
+}
+
+// ClientVersion returns API version negotiated by the underlying client
+func (d *DockerClient) ClientVersion() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.api == nil {
+		return ""
+	}
+	return d.api.ClientVersion()
+}
+
+// Close closes the underlying client and clients of other hosts.
+// Only the owner of the shared client should call it.
+func (d *DockerClient) Close() error {
+	var errs []error
+	for _, hostClient := range d.hostClients() {
+		if err := hostClient.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hostClient.host, err))
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.api != nil {
+		if err := d.api.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		d.api = nil
+	}
+	return errors.Join(errs...)
+}
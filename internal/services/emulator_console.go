@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// consoleSpacingSettingKey is the setting with minimum milliseconds between emulator console commands on a gateway
+const consoleSpacingSettingKey = "emulator_console_min_spacing_ms"
+
+// defaultConsoleSpacing is used when the setting is missing
+const defaultConsoleSpacing = 500 * time.Millisecond
+
+// ErrEmulatorConsole is returned when emulator console rejected a command or could not be reached
+var ErrEmulatorConsole = errors.New("emulator console failure")
+
+// consoleFailureMarkers are fragments of console output meaning it is wedged or unreachable
+var consoleFailureMarkers = []string{"KO:", "connection refused", "could not connect"}
+
+// emulatorConsoles serializes emulator console commands per gateway. The qemu console of
+// budtmo containers wedges when gsm commands arrive in rapid succession, so commands wait
+// for each other and keep minimum spacing regardless of which check or retry sends them.
+type emulatorConsoles struct {
+	mu       sync.Mutex
+	gateways map[uint]*emulatorConsole
+}
+
+// emulatorConsole is console state of one gateway
+type emulatorConsole struct {
+	mu          sync.Mutex
+	lastCommand time.Time
+}
+
+// sharedEmulatorConsoles is shared by all ADB service instances, as each of them talks to the same containers
+var sharedEmulatorConsoles = &emulatorConsoles{gateways: make(map[uint]*emulatorConsole)}
+
+// get returns console of gateway
+func (c *emulatorConsoles) get(gatewayID uint) *emulatorConsole {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	console, ok := c.gateways[gatewayID]
+	if !ok {
+		console = &emulatorConsole{}
+		c.gateways[gatewayID] = console
+	}
+	return console
+}
+
+// isConsoleCommand reports whether adb command goes to emulator console
+func isConsoleCommand(cmd []string) bool {
+	return len(cmd) > 1 && cmd[0] == "adb" && cmd[1] == "emu"
+}
+
+// consoleFailure returns marker found in console command output or error, empty if the command went through
+func consoleFailure(output string, err error) string {
+	text := output
+	if err != nil {
+		text += " " + err.Error()
+	}
+	for _, marker := range consoleFailureMarkers {
+		if strings.Contains(text, marker) {
+			return marker
+		}
+	}
+	return ""
+}
+
+// consoleSpacing returns minimum time between console commands on a gateway
+func (s *ADBService) consoleSpacing() time.Duration {
+	ms := NewSettingsService(s.db).GetCachedInt(consoleSpacingSettingKey, int(defaultConsoleSpacing/time.Millisecond))
+	return time.Duration(max(ms, 0)) * time.Millisecond
+}
+
+// runConsoleCommand sends emulator console command to gateway one at a time with minimum spacing.
+// A wedged or unreachable console is reconnected before the error is returned, so the next
+// attempt starts with a fresh connection.
+func (s *ADBService) runConsoleCommand(gateway *models.ADBGateway, cmd []string) (string, error) {
+	console := sharedEmulatorConsoles.get(gateway.ID)
+	console.mu.Lock()
+	defer console.mu.Unlock()
+
+	if wait := time.Until(console.lastCommand.Add(s.consoleSpacing())); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	output, err := s.executeInContainer(gateway, cmd)
+	console.lastCommand = time.Now()
+
+	marker := consoleFailure(output, err)
+	if marker == "" {
+		return output, err
+	}
+
+	message := fmt.Sprintf("%q failed with %q", strings.Join(cmd[1:], " "), strings.TrimSpace(output))
+	if err != nil {
+		message = fmt.Sprintf("%q failed: %v", strings.Join(cmd[1:], " "), err)
+	}
+	s.log.Warnf("Emulator console of gateway %s failed, reconnecting: %s", gateway.Name, message)
+
+	if reconnectErr := s.reconnectConsole(gateway); reconnectErr != nil {
+		message += fmt.Sprintf(", reconnect failed: %v", reconnectErr)
+	} else {
+		message += ", console reconnected"
+	}
+	console.lastCommand = time.Now()
+	s.recordConsoleFailure(gateway.ID, message)
+
+	if err != nil {
+		return output, fmt.Errorf("%w: %w", ErrEmulatorConsole, err)
+	}
+	return output, fmt.Errorf("%w: %s", ErrEmulatorConsole, strings.TrimSpace(output))
+}
+
+// reconnectConsole restarts adb server inside gateway container, dropping its console connection
+func (s *ADBService) reconnectConsole(gateway *models.ADBGateway) error {
+	if _, err := s.executeInContainer(gateway, []string{"adb", "kill-server"}); err != nil {
+		return fmt.Errorf("failed to stop adb server: %w", err)
+	}
+	if _, err := s.executeInContainer(gateway, []string{"adb", "start-server"}); err != nil {
+		return fmt.Errorf("failed to start adb server: %w", err)
+	}
+	return nil
+}
+
+// recordConsoleFailure counts console failure on gateway and records it as a gateway event
+func (s *ADBService) recordConsoleFailure(gatewayID uint, message string) {
+	now := time.Now()
+	if err := s.db.Model(&models.ADBGateway{}).Where("id = ?", gatewayID).Updates(map[string]interface{}{
+		"console_failures":        gorm.Expr("console_failures + 1"),
+		"last_console_failure_at": now,
+	}).Error; err != nil {
+		s.log.Warnf("Failed to count console failure of gateway %d: %v", gatewayID, err)
+	}
+	s.recordGatewayEvent(gatewayID, GatewayEventConsoleReconnect, message)
+}
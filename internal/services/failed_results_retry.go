@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// retryableStatuses are statuses of latest results retried by retry_errors jobs
+var retryableStatuses = []string{models.SpamStatusError, models.SpamStatusInconclusive}
+
+// failedResult is latest result of a phone on a service that gave no verdict
+type failedResult struct {
+	ResultID      uint
+	PhoneNumberID uint
+	ServiceID     uint
+	ServiceCode   string
+}
+
+// failedPhone is a phone with services to retry
+type failedPhone struct {
+	ID      uint
+	Results []failedResult
+}
+
+// RetryFailedResults starts background re-check of services whose latest result of an active phone
+// is error or inconclusive, of all services or only of serviceCode. Each phone is re-checked only on
+// those services. While such a job runs it is returned along with ErrCheckJobRunning.
+func (s *CheckService) RetryFailedResults(serviceCode string, userID uint) (*models.CheckJob, error) {
+	if serviceCode != "" {
+		if _, err := s.getServiceByCode(serviceCode); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("service not found")
+			}
+			return nil, fmt.Errorf("failed to get service: %w", err)
+		}
+	}
+
+	s.checkJobMutex.Lock()
+	defer s.checkJobMutex.Unlock()
+
+	if running, err := s.runningCheckJob(models.CheckJobRetryErrors); err != nil || running != nil {
+		return running, err
+	}
+
+	results, err := s.failedResults(serviceCode)
+	if err != nil {
+		return nil, err
+	}
+	var phones []failedPhone
+	for _, result := range results {
+		if len(phones) == 0 || phones[len(phones)-1].ID != result.PhoneNumberID {
+			phones = append(phones, failedPhone{ID: result.PhoneNumberID})
+		}
+		phone := &phones[len(phones)-1]
+		phone.Results = append(phone.Results, result)
+	}
+
+	job := &models.CheckJob{
+		Kind:         models.CheckJobRetryErrors,
+		Status:       models.CheckJobPending,
+		TotalPhones:  len(phones),
+		ServiceCode:  serviceCode,
+		TotalResults: len(results),
+		Instance:     instanceID,
+		CreatedBy:    userID,
+	}
+	if len(phones) == 0 {
+		now := time.Now()
+		job.Status = models.CheckJobCompleted
+		job.StartedAt = &now
+		job.CompletedAt = &now
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create check job: %w", err)
+	}
+
+	if len(phones) > 0 {
+		go s.runRetryJob(*job, phones)
+	}
+	return job, nil
+}
+
+// failedResults returns latest results without verdict of active phones on active services they are not excluded from
+func (s *CheckService) failedResults(serviceCode string) ([]failedResult, error) {
+	latest := s.db.Model(&models.CheckResult{}).
+		Select("MAX(id)").
+		Where("source <> ?", models.CheckSourceImport).
+		Group("phone_number_id, service_id")
+
+	query := s.db.Table("check_results AS cr").
+		Select("cr.id AS result_id, cr.phone_number_id, cr.service_id, ss.code AS service_code").
+		Joins("JOIN phone_numbers pn ON pn.id = cr.phone_number_id").
+		Joins("JOIN spam_services ss ON ss.id = cr.service_id").
+		Where("cr.id IN (?)", latest).
+		Where("cr.status IN ?", retryableStatuses).
+		Where("pn.is_active = ? AND pn.blocked = ? AND pn.deleted_at IS NULL", true, false).
+		Where("ss.is_active = ?", true).
+		Where("NOT EXISTS (SELECT 1 FROM phone_service_exclusions pse WHERE pse.phone_number_id = cr.phone_number_id AND pse.service_id = cr.service_id)")
+	if serviceCode != "" {
+		query = query.Where("ss.code = ?", serviceCode)
+	}
+
+	var results []failedResult
+	if err := query.Order("cr.phone_number_id, cr.service_id").Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get failed results: %w", err)
+	}
+	return results, nil
+}
+
+// runRetryJob re-checks phones of job on their failed services with max_concurrent_checks workers.
+// Services of one phone are checked one after another, the phone is locked for each check.
+func (s *CheckService) runRetryJob(job models.CheckJob, phones []failedPhone) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "runRetryJob",
+		"job_id": job.ID,
+		"kind":   job.Kind,
+	})
+
+	s.startCheckJob(log, &job)
+
+	log.Infof("Retrying %d failed results of %d phones", job.TotalResults, len(phones))
+
+	workChan := make(chan failedPhone, len(phones))
+	for _, phone := range phones {
+		workChan <- phone
+	}
+	close(workChan)
+
+	var mu sync.Mutex // Guards job counters
+	var wg sync.WaitGroup
+	for i := 0; i < s.maxConcurrentChecks(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for phone := range workChan {
+				phoneFailed := false
+				recovered := 0
+				for _, result := range phone.Results {
+					err := s.CheckPhoneNumberWithOptions(phone.ID, PhoneCheckOptions{ServiceCode: result.ServiceCode})
+					if err != nil {
+						phoneFailed = true
+						if !errors.Is(err, ErrCheckInProgress) {
+							log.Warnf("Failed to retry phone %d on %s: %v", phone.ID, result.ServiceCode, err)
+						}
+					}
+					if s.resultRecovered(result) {
+						recovered++
+					}
+				}
+
+				mu.Lock()
+				job.CheckedPhones++
+				if phoneFailed {
+					job.FailedPhones++
+				}
+				job.Recovered += recovered
+				job.StillFailing += len(phone.Results) - recovered
+				progress := map[string]interface{}{
+					"checked_phones": job.CheckedPhones,
+					"failed_phones":  job.FailedPhones,
+					"recovered":      job.Recovered,
+					"still_failing":  job.StillFailing,
+				}
+				mu.Unlock()
+
+				if err := s.db.Model(&models.CheckJob{}).Where("id = ?", job.ID).Updates(progress).Error; err != nil {
+					log.Warnf("Failed to update check job progress: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.completeCheckJob(log, &job)
+	log.Infof("Retry of failed results completed: %d recovered, %d still failing", job.Recovered, job.StillFailing)
+}
+
+// resultRecovered reports whether a result newer than failed one gave the phone a verdict on its service
+func (s *CheckService) resultRecovered(failed failedResult) bool {
+	var latest models.CheckResult
+	err := s.db.Select("id, status").
+		Where("phone_number_id = ? AND service_id = ? AND source <> ?", failed.PhoneNumberID, failed.ServiceID, models.CheckSourceImport).
+		Order("id DESC").
+		First(&latest).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.log.Warnf("Failed to get latest result of phone %d on service %d: %v", failed.PhoneNumberID, failed.ServiceID, err)
+		}
+		return false
+	}
+	return latest.ID > failed.ResultID && latest.Status != models.SpamStatusError && latest.Status != models.SpamStatusInconclusive
+}
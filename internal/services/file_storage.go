@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"spam-checker/internal/config"
+	"strings"
+)
+
+// Storage backend schemes used as prefix of stored file references
+const (
+	StorageSchemeLocal = "local"
+	StorageSchemeS3    = "s3"
+)
+
+// ErrStoredFileNotFound is returned when referenced file is missing from its backend
+var ErrStoredFileNotFound = errors.New("stored file not found")
+
+// ObjectStorage keeps files under slash separated keys
+type ObjectStorage interface {
+	Scheme() string
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	Size(ctx context.Context, key string) (int64, error)
+}
+
+// localStorage keeps files in a directory, matching layout used before storage backends existed
+type localStorage struct {
+	root string
+}
+
+func newLocalStorage(root string) *localStorage {
+	if root == "" {
+		root = "."
+	}
+	return &localStorage{root: root}
+}
+
+func (s *localStorage) Scheme() string {
+	return StorageSchemeLocal
+}
+
+// path resolves key inside storage root, keys escaping the root are rejected
+func (s *localStorage) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid storage key: %q", key)
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+func (s *localStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+func (s *localStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrStoredFileNotFound
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *localStorage) Size(ctx context.Context, key string) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, ErrStoredFileNotFound
+		}
+		return 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// FileStorage writes new files to the configured backend and reads references of any known backend,
+// so results stored before a backend switch stay reachable during migration
+type FileStorage struct {
+	primary  ObjectStorage
+	backends map[string]ObjectStorage
+}
+
+// NewFileStorage creates storage from validated configuration. Local backend is always available
+// for reading, S3 backend whenever bucket settings are present.
+func NewFileStorage(cfg config.StorageConfig) *FileStorage {
+	local := newLocalStorage(cfg.LocalPath)
+	storage := &FileStorage{
+		primary:  local,
+		backends: map[string]ObjectStorage{StorageSchemeLocal: local},
+	}
+
+	if cfg.S3.Configured() {
+		s3 := newS3Storage(cfg.S3)
+		storage.backends[StorageSchemeS3] = s3
+		if cfg.Backend == StorageSchemeS3 {
+			storage.primary = s3
+		}
+	}
+	return storage
+}
+
+// Backend returns storage registered for scheme, nil if it is not configured
+func (s *FileStorage) Backend(scheme string) ObjectStorage {
+	return s.backends[scheme]
+}
+
+// Primary returns backend new files are written to
+func (s *FileStorage) Primary() ObjectStorage {
+	return s.primary
+}
+
+// StorageRef builds stored file reference from backend scheme and key
+func StorageRef(scheme, key string) string {
+	return scheme + "://" + key
+}
+
+// ParseStorageRef splits stored file reference into scheme and key.
+// References without a scheme are paths written before storage backends existed and are local.
+func ParseStorageRef(ref string) (string, string) {
+	if scheme, key, found := strings.Cut(ref, "://"); found {
+		return scheme, key
+	}
+	return StorageSchemeLocal, ref
+}
+
+// Save writes file to the primary backend and returns its reference
+func (s *FileStorage) Save(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if err := s.primary.Put(ctx, key, data, contentType); err != nil {
+		return "", fmt.Errorf("failed to store %s in %s storage: %w", key, s.primary.Scheme(), err)
+	}
+	return StorageRef(s.primary.Scheme(), key), nil
+}
+
+// resolve returns backend and key of reference
+func (s *FileStorage) resolve(ref string) (ObjectStorage, string, error) {
+	scheme, key := ParseStorageRef(ref)
+	backend, exists := s.backends[scheme]
+	if !exists {
+		return nil, "", fmt.Errorf("storage backend %q is not configured", scheme)
+	}
+	return backend, key, nil
+}
+
+// Open reads referenced file
+func (s *FileStorage) Open(ctx context.Context, ref string) ([]byte, error) {
+	backend, key, err := s.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Get(ctx, key)
+}
+
+// Size returns size of referenced file in bytes
+func (s *FileStorage) Size(ctx context.Context, ref string) (int64, error) {
+	backend, key, err := s.resolve(ref)
+	if err != nil {
+		return 0, err
+	}
+	return backend.Size(ctx, key)
+}
+
+// Remove deletes referenced file, missing files are not an error
+func (s *FileStorage) Remove(ctx context.Context, ref string) error {
+	backend, key, err := s.resolve(ref)
+	if err != nil {
+		return err
+	}
+	return backend.Delete(ctx, key)
+}
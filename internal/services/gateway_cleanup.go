@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// CleanedFile is a stored file removed with a gateway
+type CleanedFile struct {
+	Ref   string `json:"ref"`
+	Bytes int64  `json:"bytes"`
+}
+
+// GatewayCleanup reports what deletion of a gateway removed. Failed steps don't stop the
+// deletion and are listed in Failures for manual cleanup.
+type GatewayCleanup struct {
+	GatewayID  uint          `json:"gateway_id"`
+	Container  string        `json:"container,omitempty"` // Removed container ID
+	Volume     string        `json:"volume,omitempty"`    // Removed data volume
+	Ports      []int         `json:"ports,omitempty"`     // Released host ports
+	Files      []CleanedFile `json:"files,omitempty"`     // Screenshots removed by purge
+	FilesBytes int64         `json:"files_bytes"`
+	Failures   []string      `json:"failures,omitempty"`
+}
+
+func (c *GatewayCleanup) fail(format string, args ...interface{}) {
+	c.Failures = append(c.Failures, fmt.Sprintf(format, args...))
+}
+
+// dockerGatewayVolumeName returns named data volume of Docker gateway
+func dockerGatewayVolumeName(gatewayName string) string {
+	return fmt.Sprintf("android_%s_data", strings.ToLower(strings.ReplaceAll(gatewayName, " ", "_")))
+}
+
+// DeleteDockerGateway removes container and data volume of Docker gateway and releases its ports.
+// Missing container or volume is not a failure.
+func (s *ADBService) DeleteDockerGateway(gateway *models.ADBGateway, report *GatewayCleanup) {
+	log := s.log.WithField("method", "DeleteDockerGateway")
+
+	if !gateway.IsDocker {
+		return
+	}
+
+	ctx := context.Background()
+	docker := s.dockerFor(gateway)
+
+	if gateway.ContainerID != "" {
+		if err := docker.ContainerStop(ctx, gateway.ContainerID, container.StopOptions{}); err != nil && !client.IsErrNotFound(err) {
+			log.Warnf("Failed to stop container: %v", err)
+		}
+
+		err := docker.ContainerRemove(ctx, gateway.ContainerID, container.RemoveOptions{
+			Force:         true,
+			RemoveVolumes: true,
+		})
+		switch {
+		case err == nil:
+			report.Container = gateway.ContainerID
+		case !client.IsErrNotFound(err):
+			log.Warnf("Failed to remove container: %v", err)
+			report.fail("container %s: %v", gateway.ContainerID, err)
+		}
+	}
+
+	// Named volume outlives the container, RemoveVolumes only drops anonymous ones
+	volume := dockerGatewayVolumeName(gateway.Name)
+	err := docker.VolumeRemove(ctx, volume, true)
+	switch {
+	case err == nil:
+		report.Volume = volume
+	case !client.IsErrNotFound(err):
+		log.Warnf("Failed to remove volume %s: %v", volume, err)
+		report.fail("volume %s: %v", volume, err)
+	}
+
+	s.portManager.ReleasePorts(gateway.DockerHost, gateway.VNCPort, gateway.ADBPort1, gateway.ADBPort2)
+	for _, port := range []int{gateway.VNCPort, gateway.ADBPort1, gateway.ADBPort2} {
+		if port > 0 {
+			report.Ports = append(report.Ports, port)
+		}
+	}
+
+	log.Infof("Deleted Docker container for gateway %s", gateway.Name)
+}
+
+// purgeGatewayScreenshots removes screenshots of results checked on gateway and clears their references.
+// Results themselves are kept. Results saved before gateways were recorded on them are not found.
+func (s *ADBService) purgeGatewayScreenshots(gatewayID uint, report *GatewayCleanup) {
+	if s.cfg == nil {
+		report.fail("screenshots: storage is not configured")
+		return
+	}
+
+	var results []models.CheckResult
+	if err := s.db.Select("id, screenshot").
+		Where("gateway_id = ? AND screenshot <> ''", gatewayID).
+		Find(&results).Error; err != nil {
+		report.fail("screenshots: %v", err)
+		return
+	}
+
+	ctx := context.Background()
+	storage := NewFileStorage(s.cfg.Storage)
+	removed := make([]uint, 0, len(results))
+	for _, result := range results {
+		size, err := storage.Size(ctx, result.Screenshot)
+		if err != nil && !errors.Is(err, ErrStoredFileNotFound) {
+			report.fail("screenshot %s: %v", result.Screenshot, err)
+			continue
+		}
+		if err == nil {
+			if err := storage.Remove(ctx, result.Screenshot); err != nil {
+				report.fail("screenshot %s: %v", result.Screenshot, err)
+				continue
+			}
+			report.Files = append(report.Files, CleanedFile{Ref: result.Screenshot, Bytes: size})
+			report.FilesBytes += size
+		}
+		removed = append(removed, result.ID)
+	}
+
+	if len(removed) > 0 {
+		if err := s.db.Model(&models.CheckResult{}).Where("id IN ?", removed).Update("screenshot", "").Error; err != nil {
+			report.fail("screenshot references: %v", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+	"spam-checker/internal/models"
+	"time"
+)
+
+// Gateway event types
+const (
+	// GatewayEventLingeringCall is recorded when a check found a call left over on the gateway
+	GatewayEventLingeringCall = "lingering_call"
+	// GatewayEventCallForceStopped is recorded when a call could be ended only by force-stopping in-call packages
+	GatewayEventCallForceStopped = "call_force_stopped"
+	// GatewayEventAppCrash is recorded when caller-ID app crashed during a check
+	GatewayEventAppCrash = "app_crash"
+	// GatewayEventAppNotResponding is recorded when caller-ID app stopped responding during a check
+	GatewayEventAppNotResponding = "app_anr"
+	// GatewayEventConsoleReconnect is recorded when emulator console failed and adb server was restarted
+	GatewayEventConsoleReconnect = "console_reconnect"
+)
+
+// MaxGatewayEvents limits number of events returned at once
+const MaxGatewayEvents = 500
+
+// recordGatewayEvent stores gateway event, failures are only logged
+func (s *ADBService) recordGatewayEvent(gatewayID uint, eventType, message string) {
+	event := models.GatewayEvent{
+		GatewayID: gatewayID,
+		Type:      eventType,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.Create(&event).Error; err != nil {
+		s.log.Warnf("Failed to record %s event of gateway %d: %v", eventType, gatewayID, err)
+	}
+}
+
+// ListGatewayEvents returns latest events of a gateway, newest first
+func (s *ADBService) ListGatewayEvents(gatewayID uint, limit int) ([]models.GatewayEvent, error) {
+	if _, err := s.GetGatewayByID(gatewayID); err != nil {
+		return nil, err
+	}
+
+	var events []models.GatewayEvent
+	if err := s.db.Where("gateway_id = ?", gatewayID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get gateway events: %w", err)
+	}
+	return events, nil
+}
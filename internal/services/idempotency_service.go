@@ -0,0 +1,158 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyKeyTTLSettingKey is the setting holding how long responses of idempotent requests are kept
+const idempotencyKeyTTLSettingKey = "idempotency_key_ttl_hours"
+
+// maxIdempotencyKeyTTLHours limits idempotency key TTL to 30 days
+const maxIdempotencyKeyTTLHours = 720
+
+var (
+	// ErrIdempotencyKeyMismatch is returned when a key is reused for a different request
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used for a different request")
+	// ErrIdempotencyKeyInProgress is returned while the first request with a key is still executing
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+// validateIdempotencyKeyTTL checks idempotency key TTL in hours
+func validateIdempotencyKeyTTL(hours int) error {
+	if hours < 1 || hours > maxIdempotencyKeyTTLHours {
+		return fmt.Errorf("idempotency key TTL must be between 1 and %d hours", maxIdempotencyKeyTTLHours)
+	}
+	return nil
+}
+
+// IdempotencyService persists idempotency keys of mutating requests with their responses
+type IdempotencyService struct {
+	db  *gorm.DB
+	log *logrus.Entry
+}
+
+func NewIdempotencyService(db *gorm.DB) *IdempotencyService {
+	return &IdempotencyService{
+		db:  db,
+		log: logger.WithField("service", "IdempotencyService"),
+	}
+}
+
+// ttl returns how long a key is kept after its first use
+func (s *IdempotencyService) ttl() time.Duration {
+	hours := NewSettingsService(s.db).GetCachedInt(idempotencyKeyTTLSettingKey, 24)
+	if validateIdempotencyKeyTTL(hours) != nil {
+		hours = 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// Begin reserves key of a user for the request. It returns nil when the caller should execute
+// the request, or the stored record when the same request was already completed and its
+// response must be replayed. The unique index on user and key guarantees that of concurrent
+// requests with one key only a single one gets the reservation.
+func (s *IdempotencyService) Begin(userID uint, key, method, path string, body []byte) (*models.IdempotencyKey, error) {
+	requestHash := hashIdempotentRequest(method, path, body)
+
+	// Second attempt follows removal of an expired key
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		record := models.IdempotencyKey{
+			Key:         key,
+			UserID:      userID,
+			Method:      method,
+			Path:        path,
+			RequestHash: requestHash,
+			ExpiresAt:   now.Add(s.ttl()),
+		}
+
+		result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing models.IdempotencyKey
+		if err := s.db.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Released concurrently, try to reserve again
+				continue
+			}
+			return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+
+		if existing.ExpiresAt.Before(now) {
+			if err := s.db.Where("id = ? AND expires_at < ?", existing.ID, now).
+				Delete(&models.IdempotencyKey{}).Error; err != nil {
+				return nil, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+			}
+			continue
+		}
+
+		if existing.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyMismatch
+		}
+		if !existing.Completed {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		return &existing, nil
+	}
+
+	return nil, ErrIdempotencyKeyInProgress
+}
+
+// Complete stores response of the request holding the key
+func (s *IdempotencyService) Complete(userID uint, key string, statusCode int, contentType string, body []byte) error {
+	if err := s.db.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ? AND completed = ?", userID, key, false).
+		Updates(map[string]interface{}{
+			"completed":     true,
+			"status_code":   statusCode,
+			"content_type":  contentType,
+			"response_body": body,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release removes reservation of a request that failed without a response worth replaying,
+// so the client may retry with the same key
+func (s *IdempotencyService) Release(userID uint, key string) {
+	if err := s.db.Where("user_id = ? AND key = ? AND completed = ?", userID, key, false).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		s.log.Warnf("Failed to release idempotency key %q of user %d: %v", key, userID, err)
+	}
+}
+
+// DeleteExpired removes keys past their TTL
+func (s *IdempotencyService) DeleteExpired() (int64, error) {
+	result := s.db.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// hashIdempotentRequest fingerprints request so a key cannot be replayed for a different one
+func hashIdempotentRequest(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// intervalAlignmentSettingKey is the setting deciding how default interval check runs are placed in time
+const intervalAlignmentSettingKey = "check_interval_alignment"
+
+// Default interval check alignment modes
+const (
+	// IntervalAlignmentRelative runs the check every interval counted from scheduler start or interval change
+	IntervalAlignmentRelative = "relative"
+	// IntervalAlignmentWallClock runs the check on wall-clock boundaries: top of the hour for 60 minutes,
+	// :00 and :30 for 30 minutes and so on
+	IntervalAlignmentWallClock = "wall_clock"
+)
+
+// validateIntervalAlignment checks interval alignment setting value
+func validateIntervalAlignment(alignment string) error {
+	switch alignment {
+	case IntervalAlignmentRelative, IntervalAlignmentWallClock:
+		return nil
+	}
+	return fmt.Errorf("check interval alignment must be %s or %s", IntervalAlignmentRelative, IntervalAlignmentWallClock)
+}
+
+// GetIntervalAlignment returns alignment mode of the default interval check from settings cache.
+// Missing or invalid setting means relative alignment.
+func (s *SettingsService) GetIntervalAlignment() string {
+	value, err := s.GetCachedSettingValue(intervalAlignmentSettingKey)
+	if err != nil {
+		return IntervalAlignmentRelative
+	}
+
+	alignment, ok := value.(string)
+	if !ok || validateIntervalAlignment(alignment) != nil {
+		s.log.Warnf("Ignoring %s setting: invalid value %v", intervalAlignmentSettingKey, value)
+		return IntervalAlignmentRelative
+	}
+	return alignment
+}
+
+// NextIntervalBoundary returns the first wall-clock boundary of the interval strictly after now.
+// Boundaries are multiples of the interval counted from local midnight, so intervals that do not
+// divide a day evenly get a shorter last slot before midnight. Intervals of a day or longer are
+// aligned to midnight.
+func NextIntervalBoundary(now time.Time, intervalMinutes int) time.Time {
+	if intervalMinutes <= 0 || intervalMinutes >= 24*60 {
+		return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	}
+
+	// Counted in wall-clock minutes so boundaries stay on the same clock time across DST changes
+	elapsed := now.Hour()*60 + now.Minute()
+	slot := (elapsed/intervalMinutes + 1) * intervalMinutes
+	if slot > 24*60 {
+		slot = 24 * 60
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, slot, 0, 0, now.Location())
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"spam-checker/internal/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// keywordNegationsSettingKey lists comma-separated tokens cancelling an adjacent spam keyword of any keyword
+const keywordNegationsSettingKey = "keyword_negations"
+
+// keywordNegationSeparators may stand between negation and keyword, e.g. "не спам", "спам: нет".
+// Sentence punctuation is not one of them, so negation of the next sentence does not apply.
+const keywordNegationSeparators = "-–—:\"'«»"
+
+// parseKeywordNegations splits comma-separated negation tokens, lowercased
+func parseKeywordNegations(value string) []string {
+	var negations []string
+	for _, token := range strings.Split(value, ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if token != "" {
+			negations = append(negations, token)
+		}
+	}
+	return negations
+}
+
+// globalKeywordNegations returns negation tokens applied to every spam keyword
+func globalKeywordNegations(db *gorm.DB) []string {
+	value, err := NewSettingsService(db).GetCachedSettingValue(keywordNegationsSettingKey)
+	if err != nil {
+		return nil
+	}
+	text, _ := value.(string)
+	return parseKeywordNegations(text)
+}
+
+// spamKeywordNegations returns global negations along with ones of the keyword
+func spamKeywordNegations(global []string, keyword models.SpamKeyword) []string {
+	own := parseKeywordNegations(keyword.Negations)
+	if len(own) == 0 {
+		return global
+	}
+	return append(append([]string(nil), global...), own...)
+}
+
+// Limits of stored keyword matches, the verdict only needs the first one
+const (
+	maxKeywordMatches       = 5  // Occurrences kept per keyword and text
+	maxResultKeywordMatches = 50 // Occurrences kept per check result
+	keywordSnippetRadius    = 40 // Characters kept on each side of a match
+)
+
+// keywordText is text searched for spam keywords along with its lowercase form
+type keywordText struct {
+	source string
+	lower  string
+	runes  []rune // Original text, ToLower keeps the number of runes
+}
+
+func newKeywordText(text, source string) *keywordText {
+	return &keywordText{source: source, lower: strings.ToLower(text), runes: []rune(text)}
+}
+
+// find returns occurrences of keyword without a negation token right before or after them,
+// at most maxKeywordMatches. Negations match whole words only, keyword does too when wholeWord is set.
+func (t *keywordText) find(keyword string, negations []string, wholeWord bool) []models.KeywordMatch {
+	lowerKeyword := strings.ToLower(keyword)
+	if lowerKeyword == "" {
+		return nil
+	}
+
+	var matches []models.KeywordMatch
+	text := t.lower
+	for offset := 0; offset < len(text) && len(matches) < maxKeywordMatches; {
+		index := strings.Index(text[offset:], lowerKeyword)
+		if index < 0 {
+			break
+		}
+		start := offset + index
+		end := start + len(lowerKeyword)
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+
+		if wholeWord && (!wordBoundary(text[:start], true) || !wordBoundary(text[end:], false)) {
+			continue
+		}
+		if len(negations) > 0 && negatedKeyword(text, start, end, negations) {
+			continue
+		}
+		runeStart := utf8.RuneCountInString(text[:start])
+		matches = append(matches, t.match(keyword, runeStart, runeStart+utf8.RuneCountInString(lowerKeyword)))
+	}
+	return matches
+}
+
+// match returns occurrence of keyword at rune offsets of the text with a snippet around it
+func (t *keywordText) match(keyword string, start, end int) models.KeywordMatch {
+	from := max(start-keywordSnippetRadius, 0)
+	to := min(end+keywordSnippetRadius, len(t.runes))
+	return models.KeywordMatch{
+		Keyword: keyword,
+		Source:  t.source,
+		Start:   start,
+		End:     end,
+		Snippet: strings.Join(strings.Fields(string(t.runes[from:to])), " "),
+	}
+}
+
+// foundKeywords collects found keywords without duplicates along with their occurrences
+type foundKeywords struct {
+	keywords []string
+	seen     map[string]bool // Lowercase keywords
+	matches  []models.KeywordMatch
+}
+
+func newFoundKeywords() *foundKeywords {
+	return &foundKeywords{seen: make(map[string]bool)}
+}
+
+// add records keyword unless already found and keeps its occurrences within the result limit
+func (f *foundKeywords) add(keyword string, matches []models.KeywordMatch) {
+	if lower := strings.ToLower(keyword); !f.seen[lower] {
+		f.seen[lower] = true
+		f.keywords = append(f.keywords, keyword)
+	}
+	for _, match := range matches {
+		if len(f.matches) == maxResultKeywordMatches {
+			return
+		}
+		f.matches = append(f.matches, match)
+	}
+}
+
+// negatedKeyword reports whether keyword occurrence text[start:end] is preceded or followed by a negation
+func negatedKeyword(text string, start, end int, negations []string) bool {
+	before := strings.TrimRightFunc(text[:start], isKeywordNegationSeparator)
+	after := strings.TrimLeftFunc(text[end:], isKeywordNegationSeparator)
+	separatedBefore := len(before) < start
+	separatedAfter := len(after) < len(text)-end
+
+	for _, negation := range negations {
+		if separatedBefore && strings.HasSuffix(before, negation) && wordBoundary(before[:len(before)-len(negation)], true) {
+			return true
+		}
+		if separatedAfter && strings.HasPrefix(after, negation) && wordBoundary(after[len(negation):], false) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordBoundary reports whether text adjacent to a token does not continue its word,
+// last rune of text is checked when it precedes the token, first one otherwise
+func wordBoundary(text string, precedes bool) bool {
+	if text == "" {
+		return true
+	}
+	var r rune
+	if precedes {
+		r, _ = utf8.DecodeLastRuneInString(text)
+	} else {
+		r, _ = utf8.DecodeRuneInString(text)
+	}
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func isKeywordNegationSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(keywordNegationSeparators, r)
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KeywordEvaluation represents a single keyword of the snapshot and whether it matched
+type KeywordEvaluation struct {
+	Keyword string                `json:"keyword"`
+	Matched bool                  `json:"matched"`
+	Matches []models.KeywordMatch `json:"matches,omitempty"` // Where the keyword occurred, empty for results saved before matches were stored
+}
+
+// CheckEvaluation explains why a check result was considered spam or clean
+type CheckEvaluation struct {
+	ResultID       uint                  `json:"result_id"`
+	PhoneNumberID  uint                  `json:"phone_number_id"`
+	ServiceID      uint                  `json:"service_id"`
+	IsSpam         bool                  `json:"is_spam"`
+	Text           string                `json:"text"`
+	RawResponse    string                `json:"raw_response,omitempty"`
+	SnapshotHash   string                `json:"keywords_snapshot_hash,omitempty"`
+	KeywordsCount  int                   `json:"keywords_count"`
+	Keywords       []KeywordEvaluation   `json:"keywords"`
+	OtherMatches   []string              `json:"other_matches,omitempty"`   // Found keywords outside the snapshot, e.g. returned by API
+	KeywordMatches []models.KeywordMatch `json:"keyword_matches,omitempty"` // Every stored occurrence of found keywords
+	SnapshotFound  bool                  `json:"snapshot_found"`
+	CheckedAt      time.Time             `json:"checked_at"`
+}
+
+// keywordsSnapshotHash returns hash identifying a keyword set
+func keywordsSnapshotHash(keywords []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(keywords, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// activeKeywordsForService returns sorted distinct active keywords used for a service
+func activeKeywordsForService(tx *gorm.DB, serviceID uint) ([]string, error) {
+	var keywords []string
+	if err := tx.Model(&models.SpamKeyword{}).
+		Where("is_active = ?", true).
+		Where("service_id IS NULL OR service_id = ?", serviceID).
+		Distinct().
+		Pluck("keyword", &keywords).Error; err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keywords)
+	return keywords, nil
+}
+
+// attachKeywordSnapshot stamps result with the active keyword set, writing a
+// snapshot row only when the set has not been seen before
+func attachKeywordSnapshot(tx *gorm.DB, result *models.CheckResult) error {
+	keywords, err := activeKeywordsForService(tx, result.ServiceID)
+	if err != nil {
+		return fmt.Errorf("failed to get active keywords: %w", err)
+	}
+
+	snapshot := &models.KeywordSnapshot{
+		Hash:     keywordsSnapshotHash(keywords),
+		Keywords: models.StringArray(keywords),
+		Count:    len(keywords),
+	}
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(snapshot).Error; err != nil {
+		return fmt.Errorf("failed to save keyword snapshot: %w", err)
+	}
+
+	result.KeywordsHash = snapshot.Hash
+	result.KeywordsCount = snapshot.Count
+	return nil
+}
+
+// GetCheckEvaluation returns text seen by the check and evaluation of every keyword
+// from the snapshot active at check time
+func (s *CheckService) GetCheckEvaluation(resultID uint) (*CheckEvaluation, error) {
+	var result models.CheckResult
+	if err := s.db.First(&result, resultID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("result not found")
+		}
+		return nil, fmt.Errorf("failed to get check result: %w", err)
+	}
+
+	evaluation := &CheckEvaluation{
+		ResultID:       result.ID,
+		PhoneNumberID:  result.PhoneNumberID,
+		ServiceID:      result.ServiceID,
+		IsSpam:         result.IsSpam,
+		Text:           result.RawText,
+		SnapshotHash:   result.KeywordsHash,
+		KeywordsCount:  result.KeywordsCount,
+		Keywords:       []KeywordEvaluation{},
+		KeywordMatches: result.KeywordMatches,
+		CheckedAt:      result.CheckedAt,
+	}
+
+	// Raw response may carry provider credentials, full body is only available redacted
+	evaluation.RawResponse, _ = s.redactAPIResponse(result.RawResponse, result.APIServiceID)
+
+	found := make(map[string]bool, len(result.FoundKeywords))
+	for _, keyword := range result.FoundKeywords {
+		found[strings.ToLower(keyword)] = true
+	}
+	occurrences := make(map[string][]models.KeywordMatch)
+	for _, match := range result.KeywordMatches {
+		lower := strings.ToLower(match.Keyword)
+		occurrences[lower] = append(occurrences[lower], match)
+	}
+
+	// Results saved before snapshots existed have no hash
+	if result.KeywordsHash != "" {
+		var snapshot models.KeywordSnapshot
+		err := s.db.Where("hash = ?", result.KeywordsHash).First(&snapshot).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get keyword snapshot: %w", err)
+		}
+		if err == nil {
+			evaluation.SnapshotFound = true
+			for _, keyword := range snapshot.Keywords {
+				lower := strings.ToLower(keyword)
+				evaluation.Keywords = append(evaluation.Keywords, KeywordEvaluation{
+					Keyword: keyword,
+					Matched: found[lower],
+					Matches: occurrences[lower],
+				})
+				delete(found, lower)
+			}
+		}
+	}
+
+	for _, keyword := range result.FoundKeywords {
+		if found[strings.ToLower(keyword)] {
+			evaluation.OtherMatches = append(evaluation.OtherMatches, keyword)
+		}
+	}
+
+	return evaluation, nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"spam-checker/internal/models"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// latestResultColumnExprs maps output column of latest results to its SQL expression
+var latestResultColumnExprs = map[string]string{
+	"phone_id":       "pn.id",
+	"phone_number":   "pn.number",
+	"description":    "pn.description",
+	"service_id":     "ss.id",
+	"service_name":   "ss.name",
+	"is_spam":        "cr.is_spam",
+	"status":         "cr.status",
+	"confidence":     "cr.confidence",
+	"found_keywords": "cr.found_keywords",
+	"checked_at":     "cr.checked_at",
+	// Attribution, empty for results of deleted or unknown sources
+	"gateway_id":       "cr.gateway_id",
+	"gateway_name":     "gw.name",
+	"api_service_id":   "cr.api_service_id",
+	"api_service_name": "api.name",
+}
+
+// LatestResultColumns lists latest results columns in their default order
+var LatestResultColumns = []string{
+	"phone_id",
+	"phone_number",
+	"description",
+	"service_id",
+	"service_name",
+	"is_spam",
+	"status",
+	"confidence",
+	"found_keywords",
+	"checked_at",
+	"gateway_id",
+	"gateway_name",
+	"api_service_id",
+	"api_service_name",
+}
+
+// IsLatestResultColumn reports whether column can be requested from latest results
+func IsLatestResultColumn(column string) bool {
+	_, ok := latestResultColumnExprs[column]
+	return ok
+}
+
+// LatestResultsFilter narrows latest results. Zero Limit returns all rows.
+type LatestResultsFilter struct {
+	Columns      []string   // Output columns, all when empty
+	CheckedAfter *time.Time // Only results checked after this time
+	Offset       int
+	Limit        int
+}
+
+func (f LatestResultsFilter) columns() []string {
+	if len(f.Columns) == 0 {
+		return LatestResultColumns
+	}
+	return f.Columns
+}
+
+// latestResultsQuery returns latest non-error result per phone and service.
+// Latest result is picked by MAX(id) so the query does not depend on DISTINCT ON.
+func (s *CheckService) latestResultsQuery(filter LatestResultsFilter) *gorm.DB {
+	latest := s.db.Model(&models.CheckResult{}).
+		Select("MAX(id) AS max_id").
+		Where("status <> ?", models.SpamStatusError).
+		Group("phone_number_id, service_id")
+
+	query := s.db.Table("check_results cr").
+		Joins("JOIN (?) latest ON cr.id = latest.max_id", latest).
+		Joins("JOIN phone_numbers pn ON pn.id = cr.phone_number_id").
+		Joins("JOIN spam_services ss ON ss.id = cr.service_id").
+		Joins("LEFT JOIN adb_gateways gw ON gw.id = cr.gateway_id").
+		Joins("LEFT JOIN api_services api ON api.id = cr.api_service_id").
+		Where("pn.deleted_at IS NULL")
+
+	if filter.CheckedAfter != nil {
+		query = query.Where("cr.checked_at > ?", *filter.CheckedAfter)
+	}
+
+	return query
+}
+
+// selectLatestResults applies projection, order and pagination to latest results query
+func (s *CheckService) selectLatestResults(filter LatestResultsFilter) *gorm.DB {
+	columns := filter.columns()
+	selects := make([]string, len(columns))
+	for i, column := range columns {
+		selects[i] = fmt.Sprintf("%s AS %s", latestResultColumnExprs[column], column)
+	}
+
+	query := s.latestResultsQuery(filter).
+		Select(selects).
+		Order("cr.phone_number_id, cr.service_id")
+
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	return query
+}
+
+// GetLatestResults gets latest results for all phones with total count before pagination
+func (s *CheckService) GetLatestResults(filter LatestResultsFilter) ([]map[string]interface{}, int64, error) {
+	var total int64
+	if err := s.latestResultsQuery(filter).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count latest results: %w", err)
+	}
+
+	results := []map[string]interface{}{}
+	if err := s.selectLatestResults(filter).Scan(&results).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get latest results: %w", err)
+	}
+
+	return results, total, nil
+}
+
+// ExportLatestResults writes latest results as CSV row by row without loading them all
+func (s *CheckService) ExportLatestResults(writer io.Writer, filter LatestResultsFilter) error {
+	columns := filter.columns()
+
+	csvWriter := csv.NewWriter(writer)
+	defer csvWriter.Flush()
+
+	if err := csvWriter.Write(columns); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	rows, err := s.selectLatestResults(filter).Rows()
+	if err != nil {
+		return fmt.Errorf("failed to get latest results: %w", err)
+	}
+	defer rows.Close()
+
+	record := make([]string, len(columns))
+	for rows.Next() {
+		row := map[string]interface{}{}
+		if err := s.db.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("failed to read latest result: %w", err)
+		}
+
+		for i, column := range columns {
+			record[i] = formatCSVValue(row[column])
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	return rows.Err()
+}
+
+// formatCSVValue renders scanned database value as CSV field
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeCSVFormula(v)
+	case []byte:
+		return escapeCSVFormula(string(v))
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeCSVFormula prefixes text that spreadsheets would evaluate as a formula
+// with a quote, so exported descriptions or OCR text cannot run formulas
+func escapeCSVFormula(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
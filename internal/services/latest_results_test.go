@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"spam-checker/internal/models"
+)
+
+func TestEscapeCSVFormula(t *testing.T) {
+	tests := map[string]string{
+		"":               "",
+		"plain":          "plain",
+		"79001234567":    "79001234567",
+		"=HYPERLINK(1)":  "'=HYPERLINK(1)",
+		"+7 900":         "'+7 900",
+		"-1+1":           "'-1+1",
+		"@SUM(A1)":       "'@SUM(A1)",
+		"\tcmd":          "'\tcmd",
+		"a=b":            "a=b",
+		"Спам звонок":    "Спам звонок",
+		"1,2":            "1,2",
+		"\"quoted\" str": "\"quoted\" str",
+	}
+	for in, want := range tests {
+		if got := escapeCSVFormula(in); got != want {
+			t.Errorf("escapeCSVFormula(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExportLatestResultsEscaping(t *testing.T) {
+	db := newTestDB(t)
+	service := &CheckService{db: db}
+
+	var spamService models.SpamService
+	if err := db.Where("code = ?", "kaspersky").First(&spamService).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var admin models.User
+	if err := db.Where("role = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	descriptions := []string{
+		"comma, separated",
+		"has \"quotes\"",
+		"multi\nline",
+		"=cmd|' /C calc'!A0",
+		"+SUM(1,2)",
+		"Обычный номер",
+	}
+	for i, description := range descriptions {
+		phone := models.PhoneNumber{Number: "7900000000" + string(rune('0'+i)), Description: description, IsActive: true, CreatedBy: admin.ID}
+		if err := db.Create(&phone).Error; err != nil {
+			t.Fatal(err)
+		}
+		result := models.CheckResult{PhoneNumberID: phone.ID, ServiceID: spamService.ID, Status: models.SpamStatusClean}
+		if err := db.Create(&result).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	filter := LatestResultsFilter{Columns: []string{"phone_number", "description", "status"}}
+	if err := service.ExportLatestResults(&buf, filter); err != nil {
+		t.Fatalf("ExportLatestResults: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("exported CSV does not parse: %v\n%s", err, buf.String())
+	}
+	if len(records) != len(descriptions)+1 {
+		t.Fatalf("got %d records, want %d", len(records), len(descriptions)+1)
+	}
+	if got := records[0]; len(got) != 3 || got[0] != "phone_number" || got[1] != "description" || got[2] != "status" {
+		t.Fatalf("header = %q", got)
+	}
+
+	want := []string{
+		"comma, separated",
+		"has \"quotes\"",
+		"multi\nline",
+		"'=cmd|' /C calc'!A0",
+		"'+SUM(1,2)",
+		"Обычный номер",
+	}
+	for i, record := range records[1:] {
+		if record[1] != want[i] {
+			t.Errorf("row %d description = %q, want %q", i, record[1], want[i])
+		}
+		if record[2] != models.SpamStatusClean {
+			t.Errorf("row %d status = %q", i, record[2])
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"spam-checker/internal/logger"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Instance roles
+const (
+	RoleLeader   = "leader"
+	RoleFollower = "follower"
+)
+
+// leaderLeaseTTL is how long the leader keeps its role without renewing, failover takes at most this long
+const leaderLeaseTTL = 30 * time.Second
+
+// LeaderStatus describes role of this instance in the election
+type LeaderStatus struct {
+	Role     string     `json:"role"`
+	Instance string     `json:"instance"`
+	Leader   string     `json:"leader,omitempty"` // Instance holding the lease, empty when nobody does
+	Since    *time.Time `json:"since,omitempty"`  // When the current leader was elected
+}
+
+// LeaderElector elects one of instances sharing the database through a lease. The leader renews it
+// every third of its TTL, another instance takes over once it expires. The elector also renews the
+// liveness lease of this instance, so other instances know its jobs are still running.
+type LeaderElector struct {
+	db       *gorm.DB
+	name     string
+	instance string // Holder of the lease, instanceID
+	ttl      time.Duration
+	now      func() time.Time
+	log      *logrus.Entry
+
+	mu       sync.Mutex
+	leader   bool
+	renewed  time.Time // Last successful renewal while leader
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewLeaderElector creates elector of lease name
+func NewLeaderElector(db *gorm.DB, name string) *LeaderElector {
+	return &LeaderElector{
+		db:       db,
+		name:     name,
+		instance: instanceID,
+		ttl:      leaderLeaseTTL,
+		now:      time.Now,
+		log:      logger.WithFields(logrus.Fields{"service": "LeaderElector", "lease": name}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run campaigns for leadership in background until Stop. onElected is called when this instance
+// becomes leader and onDemoted when it loses the lease; both run on the election goroutine.
+func (e *LeaderElector) Run(onElected, onDemoted func()) {
+	go func() {
+		defer close(e.done)
+
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			e.campaign(onElected, onDemoted)
+
+			select {
+			case <-e.stop:
+				e.resign(onDemoted)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// campaign renews liveness and tries to take or keep the lease
+func (e *LeaderElector) campaign(onElected, onDemoted func()) {
+	now := e.now()
+	if _, err := acquireLeaseAt(e.db, instanceLeasePrefix+e.instance, e.instance, e.ttl, now); err != nil {
+		e.log.Warnf("Failed to renew instance liveness: %v", err)
+	}
+
+	acquired, err := acquireLeaseAt(e.db, e.name, e.instance, e.ttl, now)
+
+	e.mu.Lock()
+	wasLeader := e.leader
+	switch {
+	case err != nil:
+		// Lease may still be ours, the role is given up only once it surely expired
+		e.log.Warnf("Failed to renew leadership: %v", err)
+		if wasLeader && now.Sub(e.renewed) >= e.ttl {
+			e.leader = false
+		}
+	case acquired:
+		e.leader = true
+		e.renewed = now
+	default:
+		e.leader = false
+	}
+	isLeader := e.leader
+	e.mu.Unlock()
+
+	switch {
+	case isLeader && !wasLeader:
+		e.log.Infof("Instance %s elected leader", e.instance)
+		onElected()
+	case !isLeader && wasLeader:
+		e.log.Warnf("Instance %s lost leadership", e.instance)
+		onDemoted()
+	}
+}
+
+// resign gives up leadership on shutdown, so another instance does not wait for the lease to expire
+func (e *LeaderElector) resign(onDemoted func()) {
+	e.mu.Lock()
+	wasLeader := e.leader
+	e.leader = false
+	e.mu.Unlock()
+
+	if wasLeader {
+		onDemoted()
+		if err := releaseLease(e.db, e.name, e.instance); err != nil {
+			e.log.Warnf("%v", err)
+		}
+	}
+	if err := releaseLease(e.db, instanceLeasePrefix+e.instance, e.instance); err != nil {
+		e.log.Warnf("%v", err)
+	}
+}
+
+// Stop resigns and waits for the election goroutine to exit
+func (e *LeaderElector) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stop)
+	})
+	<-e.done
+}
+
+// IsLeader reports whether this instance is the leader
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Status returns role of this instance along with the current leader
+func (e *LeaderElector) Status() LeaderStatus {
+	status := LeaderStatus{Role: RoleFollower, Instance: e.instance}
+	if e.IsLeader() {
+		status.Role = RoleLeader
+	}
+
+	lease, err := currentLeaseAt(e.db, e.name, e.now())
+	if err != nil {
+		e.log.Warnf("%v", err)
+	}
+	if lease != nil {
+		status.Leader = lease.Holder
+		since := lease.AcquiredAt
+		status.Since = &since
+	}
+	return status
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeClock is time of electors under test, moved only by Advance
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// testElector is elector of one simulated instance counting role changes
+type testElector struct {
+	*LeaderElector
+	elected, demoted int
+}
+
+func newTestElector(db *gorm.DB, clock *fakeClock, instance string) *testElector {
+	elector := NewLeaderElector(db, "test-leader")
+	elector.instance = instance
+	elector.now = clock.Now
+	return &testElector{LeaderElector: elector}
+}
+
+func (e *testElector) campaign() {
+	e.LeaderElector.campaign(func() { e.elected++ }, func() { e.demoted++ })
+}
+
+func (e *testElector) resign() {
+	e.LeaderElector.resign(func() { e.demoted++ })
+}
+
+func TestLeaderElectionContentionAndTakeover(t *testing.T) {
+	db := newTestDB(t)
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	a := newTestElector(db, clock, "instance-a")
+	b := newTestElector(db, clock, "instance-b")
+	step := leaderLeaseTTL / 3
+
+	a.campaign()
+	b.campaign()
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("leaders after first campaign: a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+	if a.elected != 1 || b.elected != 0 {
+		t.Errorf("elected callbacks: a=%d b=%d, want 1 and 0", a.elected, b.elected)
+	}
+	status := b.Status()
+	if status.Role != RoleFollower || status.Instance != "instance-b" || status.Leader != "instance-a" {
+		t.Errorf("follower status = %+v", status)
+	}
+
+	// Renewing leader keeps the role past the first lease term
+	for i := 0; i < 6; i++ {
+		clock.Advance(step)
+		a.campaign()
+		b.campaign()
+	}
+	if !a.IsLeader() || b.IsLeader() || a.elected != 1 || a.demoted != 0 {
+		t.Fatalf("renewal changed roles: a=%v b=%v elected=%d demoted=%d", a.IsLeader(), b.IsLeader(), a.elected, a.demoted)
+	}
+	if !instanceAliveAt(t, db, "instance-a", clock.Now()) {
+		t.Error("leader liveness lease is not renewed")
+	}
+
+	// Leader stops renewing, follower waits until the lease expires
+	clock.Advance(leaderLeaseTTL - time.Second)
+	b.campaign()
+	if b.IsLeader() {
+		t.Fatal("follower took over before lease expired")
+	}
+	clock.Advance(2 * time.Second)
+	b.campaign()
+	if !b.IsLeader() || b.elected != 1 {
+		t.Fatalf("follower did not take over expired lease: leader=%v elected=%d", b.IsLeader(), b.elected)
+	}
+
+	// Former leader learns it lost the lease on its next campaign
+	a.campaign()
+	if a.IsLeader() || a.demoted != 1 {
+		t.Errorf("former leader: leader=%v demoted=%d, want false and 1", a.IsLeader(), a.demoted)
+	}
+	if status := a.Status(); status.Leader != "instance-b" || status.Since == nil || !status.Since.Equal(clock.Now()) {
+		t.Errorf("status after takeover = %+v", status)
+	}
+}
+
+func TestLeaderElectionResignHandsOverImmediately(t *testing.T) {
+	db := newTestDB(t)
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	a := newTestElector(db, clock, "instance-a")
+	b := newTestElector(db, clock, "instance-b")
+
+	a.campaign()
+	b.campaign()
+	a.resign()
+	if a.IsLeader() || a.demoted != 1 {
+		t.Fatalf("resigned elector: leader=%v demoted=%d", a.IsLeader(), a.demoted)
+	}
+	if instanceAliveAt(t, db, "instance-a", clock.Now()) {
+		t.Error("resigned instance is still alive")
+	}
+
+	b.campaign()
+	if !b.IsLeader() {
+		t.Error("follower did not take released lease without waiting for expiry")
+	}
+}
+
+func TestLeaderElectionKeepsRoleOnDatabaseErrorUntilExpiry(t *testing.T) {
+	db := newTestDB(t)
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	a := newTestElector(db, clock, "instance-a")
+
+	a.campaign()
+	if !a.IsLeader() {
+		t.Fatal("single elector did not become leader")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+
+	// Lease may still be ours while it has not expired
+	clock.Advance(leaderLeaseTTL - time.Second)
+	a.campaign()
+	if !a.IsLeader() || a.demoted != 0 {
+		t.Fatalf("leader demoted on database error before expiry: demoted=%d", a.demoted)
+	}
+	clock.Advance(time.Second)
+	a.campaign()
+	if a.IsLeader() || a.demoted != 1 {
+		t.Errorf("leader kept role after its lease expired: leader=%v demoted=%d", a.IsLeader(), a.demoted)
+	}
+}
+
+// instanceAliveAt reports whether liveness lease of instance is held at now
+func instanceAliveAt(t *testing.T, db *gorm.DB, instance string, now time.Time) bool {
+	t.Helper()
+	lease, err := currentLeaseAt(db, instanceLeasePrefix+instance, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lease != nil
+}
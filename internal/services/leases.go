@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lease timings. Clocks of instances sharing the database are expected to be in sync
+// to well within the TTLs.
+const (
+	lockLeaseTTL          = time.Minute            // Phone and gateway locks, renewed while held
+	lockLeasePollInterval = 500 * time.Millisecond // How often a lock held by another instance is retried
+	instanceLeasePrefix   = "instance:"            // Liveness lease of every running instance
+)
+
+// Prefixes of distributed lock leases, a lease is named prefix:key
+const (
+	phoneLockPrefix   = "phone"
+	gatewayLockPrefix = "gateway"
+)
+
+// instanceID identifies this process among instances sharing the database
+var instanceID = newInstanceID()
+
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8])
+}
+
+// InstanceID returns identifier of this application instance
+func InstanceID() string {
+	return instanceID
+}
+
+// acquireLease takes lease for holder until ttl passes, or extends it when holder already has it.
+// Lease of another holder is taken over only after it expired.
+func acquireLease(db *gorm.DB, name, holder string, ttl time.Duration) (bool, error) {
+	return acquireLeaseAt(db, name, holder, ttl, time.Now())
+}
+
+// acquireLeaseAt is acquireLease as of now
+func acquireLeaseAt(db *gorm.DB, name, holder string, ttl time.Duration, now time.Time) (bool, error) {
+	lease := models.Lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl), AcquiredAt: now}
+	created := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease)
+	if created.Error != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, created.Error)
+	}
+	if created.RowsAffected == 1 {
+		return true, nil
+	}
+
+	updated := db.Model(&models.Lease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", name, holder, now).
+		Updates(map[string]interface{}{
+			"acquired_at": gorm.Expr("CASE WHEN holder = ? THEN acquired_at ELSE ? END", holder, now),
+			"holder":      holder,
+			"expires_at":  now.Add(ttl),
+		})
+	if updated.Error != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, updated.Error)
+	}
+	return updated.RowsAffected == 1, nil
+}
+
+// releaseLease frees lease unless another holder took it over meanwhile
+func releaseLease(db *gorm.DB, name, holder string) error {
+	if err := db.Where("name = ? AND holder = ?", name, holder).Delete(&models.Lease{}).Error; err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// currentLease returns unexpired lease, nil when nobody holds it
+func currentLease(db *gorm.DB, name string) (*models.Lease, error) {
+	return currentLeaseAt(db, name, time.Now())
+}
+
+// currentLeaseAt is currentLease as of now
+func currentLeaseAt(db *gorm.DB, name string, now time.Time) (*models.Lease, error) {
+	var lease models.Lease
+	err := db.Where("name = ? AND expires_at >= ?", name, now).First(&lease).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease %s: %w", name, err)
+	}
+	return &lease, nil
+}
+
+// InstanceAlive reports whether instance still renews its liveness lease.
+// Errors are reported as alive, so work of a running instance is never taken over by mistake.
+func InstanceAlive(db *gorm.DB, instance string) bool {
+	if instance == "" {
+		return false
+	}
+	lease, err := currentLease(db, instanceLeasePrefix+instance)
+	if err != nil {
+		logger.WithField("service", "Leases").Warnf("Failed to check instance %s: %v", instance, err)
+		return true
+	}
+	return lease != nil
+}
+
+// heldLease is a lease of this instance renewed in background until released
+type heldLease struct {
+	db      *gorm.DB
+	name    string
+	ttl     time.Duration
+	stop    chan struct{}
+	done    chan struct{}
+	release sync.Once
+}
+
+// holdLease acquires lease for this instance and keeps renewing it, false when another instance holds it
+func holdLease(db *gorm.DB, name string, ttl time.Duration) (*heldLease, bool, error) {
+	acquired, err := acquireLease(db, name, instanceID, ttl)
+	if err != nil || !acquired {
+		return nil, false, err
+	}
+
+	lease := &heldLease{
+		db:   db,
+		name: name,
+		ttl:  ttl,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go lease.renew()
+	return lease, true, nil
+}
+
+func (l *heldLease) renew() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			renewed, err := acquireLease(l.db, l.name, instanceID, l.ttl)
+			switch {
+			case err != nil:
+				logger.WithField("service", "Leases").Warnf("Failed to renew lease %s: %v", l.name, err)
+			case !renewed:
+				logger.WithField("service", "Leases").Errorf("Lease %s was taken over by another instance", l.name)
+				return
+			}
+		}
+	}
+}
+
+// Release stops renewal and frees the lease, safe to call more than once
+func (l *heldLease) Release() {
+	l.release.Do(func() {
+		close(l.stop)
+		<-l.done
+		if err := releaseLease(l.db, l.name, instanceID); err != nil {
+			logger.WithField("service", "Leases").Warnf("%v, it expires in %s", err, l.ttl)
+		}
+	})
+}
+
+// distributedLocks are keyed locks shared by instances. Goroutines of this process queue on
+// the local registry, and a lease keeps other instances off the key while it is held.
+type distributedLocks struct {
+	local  *lockRegistry
+	db     *gorm.DB
+	prefix string
+}
+
+func newDistributedLocks(db *gorm.DB, prefix string) *distributedLocks {
+	return &distributedLocks{local: newLockRegistry(), db: db, prefix: prefix}
+}
+
+func (l *distributedLocks) leaseName(key uint) string {
+	return lockLeaseName(l.prefix, key)
+}
+
+// lockLeaseName returns name of lease guarding key of distributed locks with prefix
+func lockLeaseName(prefix string, key uint) string {
+	return fmt.Sprintf("%s:%d", prefix, key)
+}
+
+// leaseReleaser returns function releasing lease and local lock, safe to call more than once
+func leaseReleaser(lease *heldLease, releaseLocal func()) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			lease.Release()
+			releaseLocal()
+		})
+	}
+}
+
+// TryAcquire acquires lock of key without waiting, false when this or another instance holds it
+func (l *distributedLocks) TryAcquire(key uint) (func(), bool, error) {
+	releaseLocal, acquired := l.local.TryAcquire(key)
+	if !acquired {
+		return nil, false, nil
+	}
+
+	lease, acquired, err := holdLease(l.db, l.leaseName(key), lockLeaseTTL)
+	if err != nil || !acquired {
+		releaseLocal()
+		return nil, false, err
+	}
+	return leaseReleaser(lease, releaseLocal), true, nil
+}
+
+// Acquire waits for lock of key until timeout or context cancellation
+func (l *distributedLocks) Acquire(ctx context.Context, key uint, timeout time.Duration) (func(), error) {
+	deadline := time.Now().Add(timeout)
+	releaseLocal, err := l.local.Acquire(ctx, key, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		lease, acquired, err := holdLease(l.db, l.leaseName(key), lockLeaseTTL)
+		if err != nil {
+			releaseLocal()
+			return nil, err
+		}
+		if acquired {
+			return leaseReleaser(lease, releaseLocal), nil
+		}
+
+		wait := min(lockLeasePollInterval, time.Until(deadline))
+		if wait <= 0 {
+			releaseLocal()
+			return nil, errLockTimeout
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			releaseLocal()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// IsHeld reports whether lock of key is held by this or another instance
+func (l *distributedLocks) IsHeld(key uint) bool {
+	if l.local.IsHeld(key) {
+		return true
+	}
+	lease, err := currentLease(l.db, l.leaseName(key))
+	return err == nil && lease != nil
+}
+
+// Users returns number of goroutines of this instance holding or waiting for lock of key
+func (l *distributedLocks) Users(key uint) int {
+	return l.local.Users(key)
+}
+
+// Len returns number of keys currently tracked by this instance
+func (l *distributedLocks) Len() int {
+	return l.local.Len()
+}
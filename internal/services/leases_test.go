@@ -0,0 +1,88 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAcquireLeaseContentionAndExpiry(t *testing.T) {
+	db := newTestDB(t)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	const name, ttl = "test-lease", time.Minute
+
+	acquire := func(holder string, at time.Time) bool {
+		t.Helper()
+		acquired, err := acquireLeaseAt(db, name, holder, ttl, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return acquired
+	}
+	holder := func(at time.Time) string {
+		t.Helper()
+		lease, err := currentLeaseAt(db, name, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lease == nil {
+			return ""
+		}
+		return lease.Holder
+	}
+
+	if !acquire("a", start) {
+		t.Fatal("a did not get free lease")
+	}
+	if acquire("b", start.Add(time.Second)) {
+		t.Fatal("b took lease held by a")
+	}
+
+	// Renewal extends the lease and keeps acquisition time
+	if !acquire("a", start.Add(30*time.Second)) {
+		t.Fatal("a could not renew its lease")
+	}
+	if acquire("b", start.Add(ttl+time.Second)) {
+		t.Fatal("b took renewed lease before it expired")
+	}
+	lease, err := currentLeaseAt(db, name, start.Add(ttl+time.Second))
+	if err != nil || lease == nil {
+		t.Fatalf("currentLeaseAt() = %v, %v", lease, err)
+	}
+	if !lease.AcquiredAt.Equal(start) {
+		t.Errorf("renewal moved acquired_at to %s, want %s", lease.AcquiredAt, start)
+	}
+
+	// Expired lease is taken over and restarts acquisition time
+	expired := start.Add(30*time.Second + ttl + time.Second)
+	if got := holder(expired); got != "" {
+		t.Errorf("expired lease is held by %q", got)
+	}
+	if !acquire("b", expired) {
+		t.Fatal("b did not take over expired lease")
+	}
+	if got := holder(expired); got != "b" {
+		t.Errorf("holder after takeover = %q, want b", got)
+	}
+	lease, _ = currentLeaseAt(db, name, expired)
+	if lease == nil || !lease.AcquiredAt.Equal(expired) {
+		t.Errorf("takeover acquired_at = %v, want %s", lease, expired)
+	}
+
+	// Old holder cannot release or renew lease taken over from it
+	if err := releaseLease(db, name, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := holder(expired); got != "b" {
+		t.Errorf("release by previous holder freed the lease, holder = %q", got)
+	}
+	if acquire("a", expired.Add(time.Second)) {
+		t.Error("previous holder renewed lease taken over from it")
+	}
+
+	if err := releaseLease(db, name, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if !acquire("a", expired.Add(time.Second)) {
+		t.Error("released lease was not free")
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// lockRegistryShards is the number of independently locked shards of a registry
+const lockRegistryShards = 16
+
+// errLockTimeout is returned when a keyed lock is not acquired in time
+var errLockTimeout = errors.New("timeout acquiring lock")
+
+// keyedLock is a single-slot semaphore shared by holder and waiters of a key
+type keyedLock struct {
+	sem  chan struct{}
+	refs int // holder and waiters, entry is removed when it drops to zero
+}
+
+type lockRegistryShard struct {
+	mu    sync.Mutex
+	locks map[uint]*keyedLock
+}
+
+// lockRegistry hands out per-key exclusive locks. Entries are reference counted
+// and removed as soon as nobody holds or waits for them, so the registry only
+// tracks keys that are in use.
+type lockRegistry struct {
+	shards [lockRegistryShards]lockRegistryShard
+}
+
+func newLockRegistry() *lockRegistry {
+	r := &lockRegistry{}
+	for i := range r.shards {
+		r.shards[i].locks = make(map[uint]*keyedLock)
+	}
+	return r
+}
+
+func (r *lockRegistry) shard(key uint) *lockRegistryShard {
+	return &r.shards[key%lockRegistryShards]
+}
+
+// ref returns lock of key, creating it, and counts caller as its user
+func (r *lockRegistry) ref(key uint) *keyedLock {
+	shard := r.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	lock, exists := shard.locks[key]
+	if !exists {
+		lock = &keyedLock{sem: make(chan struct{}, 1)}
+		shard.locks[key] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+// unref drops caller's reference and removes unused lock
+func (r *lockRegistry) unref(key uint, lock *keyedLock) {
+	shard := r.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(shard.locks, key)
+	}
+}
+
+// releaser returns function releasing held lock, safe to call more than once
+func (r *lockRegistry) releaser(key uint, lock *keyedLock) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-lock.sem
+			r.unref(key, lock)
+		})
+	}
+}
+
+// TryAcquire acquires lock of key without waiting
+func (r *lockRegistry) TryAcquire(key uint) (func(), bool) {
+	lock := r.ref(key)
+	select {
+	case lock.sem <- struct{}{}:
+		return r.releaser(key, lock), true
+	default:
+		r.unref(key, lock)
+		return nil, false
+	}
+}
+
+// Acquire waits for lock of key until timeout or context cancellation
+func (r *lockRegistry) Acquire(ctx context.Context, key uint, timeout time.Duration) (func(), error) {
+	lock := r.ref(key)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case lock.sem <- struct{}{}:
+		return r.releaser(key, lock), nil
+	case <-timer.C:
+		r.unref(key, lock)
+		return nil, errLockTimeout
+	case <-ctx.Done():
+		r.unref(key, lock)
+		return nil, ctx.Err()
+	}
+}
+
+// IsHeld reports whether lock of key is currently held
+func (r *lockRegistry) IsHeld(key uint) bool {
+	shard := r.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	lock, exists := shard.locks[key]
+	return exists && len(lock.sem) > 0
+}
+
+// Users returns number of goroutines holding or waiting for lock of key
+func (r *lockRegistry) Users(key uint) int {
+	shard := r.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if lock, exists := shard.locks[key]; exists {
+		return lock.refs
+	}
+	return 0
+}
+
+// Len returns number of keys currently tracked
+func (r *lockRegistry) Len() int {
+	total := 0
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mu.Lock()
+		total += len(shard.locks)
+		shard.mu.Unlock()
+	}
+	return total
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLockRegistryReleaseDropsEntry(t *testing.T) {
+	r := newLockRegistry()
+
+	release, ok := r.TryAcquire(7)
+	if !ok {
+		t.Fatal("TryAcquire on free key failed")
+	}
+	if !r.IsHeld(7) || r.Users(7) != 1 || r.Len() != 1 {
+		t.Fatalf("held = %v, users = %d, len = %d", r.IsHeld(7), r.Users(7), r.Len())
+	}
+
+	if _, ok := r.TryAcquire(7); ok {
+		t.Fatal("TryAcquire on held key succeeded")
+	}
+	if r.Users(7) != 1 {
+		t.Fatalf("failed TryAcquire leaked a reference, users = %d", r.Users(7))
+	}
+
+	release()
+	release() // second call must not unref again
+	if r.IsHeld(7) || r.Users(7) != 0 || r.Len() != 0 {
+		t.Fatalf("after release held = %v, users = %d, len = %d", r.IsHeld(7), r.Users(7), r.Len())
+	}
+}
+
+func TestLockRegistryAcquireTimeoutAndCancel(t *testing.T) {
+	r := newLockRegistry()
+
+	release, ok := r.TryAcquire(1)
+	if !ok {
+		t.Fatal("TryAcquire failed")
+	}
+	defer release()
+
+	if _, err := r.Acquire(context.Background(), 1, 10*time.Millisecond); !errors.Is(err, errLockTimeout) {
+		t.Fatalf("Acquire error = %v, want timeout", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.Acquire(ctx, 1, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Acquire error = %v, want context.Canceled", err)
+	}
+
+	if r.Users(1) != 1 {
+		t.Fatalf("abandoned waiters leaked references, users = %d", r.Users(1))
+	}
+}
+
+func TestLockRegistryWaiterKeepsEntryAlive(t *testing.T) {
+	r := newLockRegistry()
+
+	release, _ := r.TryAcquire(3)
+
+	acquired := make(chan func())
+	go func() {
+		next, err := r.Acquire(context.Background(), 3, time.Minute)
+		if err != nil {
+			t.Error(err)
+			close(acquired)
+			return
+		}
+		acquired <- next
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for r.Users(3) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiter not registered, users = %d", r.Users(3))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The entry must survive the holder's release while a waiter references it
+	release()
+	next := <-acquired
+	if next == nil {
+		return
+	}
+	if !r.IsHeld(3) || r.Users(3) != 1 {
+		t.Fatalf("handoff held = %v, users = %d", r.IsHeld(3), r.Users(3))
+	}
+	next()
+	if r.Len() != 0 {
+		t.Fatalf("len = %d after last release", r.Len())
+	}
+}
+
+func TestLockRegistryShardContention(t *testing.T) {
+	r := newLockRegistry()
+
+	const (
+		keys       = lockRegistryShards * 3 // several keys per shard
+		goroutines = 8
+		rounds     = 200
+	)
+	var inside [keys]int32
+	var wg sync.WaitGroup
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				key := uint((g + i) % keys)
+				release, err := r.Acquire(context.Background(), key, time.Minute)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if n := atomic.AddInt32(&inside[key], 1); n != 1 {
+					t.Errorf("key %d held by %d goroutines", key, n)
+				}
+				atomic.AddInt32(&inside[key], -1)
+				release()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if r.Len() != 0 {
+		t.Fatalf("len = %d after all releases, entries leaked", r.Len())
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"spam-checker/internal/config"
+	"spam-checker/internal/database"
+	"spam-checker/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Initialize(logger.Config{Level: "error", Format: "text", Output: "stderr"}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestDB returns migrated sqlite database private to the test
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.Connect(config.DatabaseConfig{
+		Driver:     "sqlite",
+		SQLitePath: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
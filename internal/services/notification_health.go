@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+)
+
+// notificationConfigErrorMarkers are fragments of send errors caused by channel configuration
+// rather than by delivery conditions, retrying such channel will not help
+var notificationConfigErrorMarkers = []string{
+	"invalid bot token",
+	"forbidden",
+	"bad request",
+	"telegram API not found",
+	"invalid telegram config",
+	"invalid telegram message thread ID",
+	"invalid email config",
+	"bot token and chat ID are required",
+	"email configuration is incomplete",
+}
+
+// isNotificationConfigError reports whether send error is caused by channel configuration.
+// Timeouts, rate limits and 5xx responses are transient and return false.
+func isNotificationConfigError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Permanent SMTP failures: authentication, rejected sender or recipient
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 500
+	}
+
+	message := err.Error()
+	// Retries are only made for transient failures
+	if strings.HasPrefix(message, "failed after") {
+		return false
+	}
+	for _, marker := range notificationConfigErrorMarkers {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// healthUpdates returns channel health columns after a send attempt.
+// Success resets counters and degraded state, configuration failures degrade channel after threshold.
+func (s *NotificationService) healthUpdates(notification *models.Notification, sendErr error) map[string]interface{} {
+	if sendErr == nil {
+		if notification.Degraded {
+			s.log.Infof("Notification channel %d (%s) recovered", notification.ID, notification.Type)
+		}
+		return map[string]interface{}{
+			"consecutive_failures":        0,
+			"consecutive_config_failures": 0,
+			"degraded":                    false,
+			"degraded_at":                 nil,
+		}
+	}
+
+	updates := map[string]interface{}{
+		"consecutive_failures": notification.ConsecutiveFailures + 1,
+	}
+	if !isNotificationConfigError(sendErr) {
+		updates["consecutive_config_failures"] = 0
+		return updates
+	}
+
+	configFailures := notification.ConsecutiveConfigFailures + 1
+	updates["consecutive_config_failures"] = configFailures
+
+	threshold := NewSettingsService(s.db).GetCachedInt("notification_degrade_after_failures", 3)
+	if !notification.Degraded && threshold > 0 && configFailures >= threshold {
+		now := time.Now()
+		updates["degraded"] = true
+		updates["degraded_at"] = &now
+		s.log.Warnf("Notification channel %d (%s) marked degraded after %d configuration failures: %v",
+			notification.ID, notification.Type, configFailures, sendErr)
+	}
+
+	return updates
+}
+
+// GetDegradedNotifications returns active channels disabled by health monitoring
+func (s *NotificationService) GetDegradedNotifications() ([]models.Notification, error) {
+	var notifications []models.Notification
+	if err := s.db.Where("is_active = ? AND degraded = ?", true, true).
+		Order("id ASC").
+		Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get degraded notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// SendDegradedSummary notifies healthy channels about degraded ones, nothing is sent if all are healthy
+func (s *NotificationService) SendDegradedSummary() error {
+	degraded, err := s.GetDegradedNotifications()
+	if err != nil {
+		return err
+	}
+	if len(degraded) == 0 {
+		return nil
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("⚠️ Отключены каналы уведомлений: %d\n\n", len(degraded)))
+	for _, notification := range degraded {
+		since := ""
+		if notification.DegradedAt != nil {
+			since = notification.DegradedAt.Format("2006-01-02 15:04")
+		}
+		message.WriteString(fmt.Sprintf("  • #%d %s с %s: %s\n",
+			notification.ID, notification.Type, since, notification.LastError))
+	}
+	message.WriteString("\nИсправьте настройки канала и отправьте тестовое уведомление, чтобы включить его снова.")
+
+	return s.SendNotification("SpamChecker: неисправные каналы уведомлений", message.String())
+}
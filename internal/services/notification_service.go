@@ -23,8 +23,9 @@ type NotificationService struct {
 }
 
 type TelegramConfig struct {
-	BotToken string `json:"bot_token"`
-	ChatID   string `json:"chat_id"`
+	BotToken        string `json:"bot_token"`
+	ChatID          string `json:"chat_id"`
+	MessageThreadID string `json:"message_thread_id,omitempty"` // Forum topic ID (optional)
 }
 
 type EmailConfig struct {
@@ -43,14 +44,19 @@ func NewNotificationService(db *gorm.DB) *NotificationService {
 	}
 }
 
-// SendNotification sends notification to all active channels
+// SendNotification sends notification to all active channels.
+// Phone numbers left in subject or message are masked when privacy mode is enabled.
 func (s *NotificationService) SendNotification(subject, message string) error {
 	log := s.log.WithFields(logrus.Fields{
 		"method": "SendNotification",
 	})
 
+	subject = logger.ScrubPhones(subject)
+	message = logger.ScrubPhones(message)
+
 	var notifications []models.Notification
-	if err := s.db.Where("is_active = ?", true).Find(&notifications).Error; err != nil {
+	// Degraded channels are skipped until they pass a test
+	if err := s.db.Where("is_active = ? AND degraded = ?", true, false).Find(&notifications).Error; err != nil {
 		return fmt.Errorf("failed to get active notifications: %w", err)
 	}
 
@@ -74,11 +80,11 @@ func (s *NotificationService) SendNotification(subject, message string) error {
 			continue
 		}
 
+		s.recordDelivery(&notification, subject, false, err)
+
 		if err != nil {
 			// Check if it's a configuration error (don't log as error)
-			if strings.Contains(err.Error(), "invalid bot token") ||
-				strings.Contains(err.Error(), "forbidden") ||
-				strings.Contains(err.Error(), "bad request") {
+			if isNotificationConfigError(err) {
 				log.Warnf("Notification configuration issue for %s: %v", notification.Type, err)
 				errors = append(errors, fmt.Sprintf("%s (config issue): %v", notification.Type, err))
 			} else {
@@ -125,6 +131,15 @@ func (s *NotificationService) sendTelegramNotification(configJSON string, messag
 		"parse_mode": "HTML",
 	}
 
+	// Route message to a forum topic if configured
+	if config.MessageThreadID != "" {
+		threadID, err := strconv.ParseInt(config.MessageThreadID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid telegram message thread ID: %s", config.MessageThreadID)
+		}
+		reqBody["message_thread_id"] = threadID
+	}
+
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
@@ -307,13 +322,83 @@ func (s *NotificationService) formatEmailBody(subject, message string) string {
 	`, subject, htmlMessage)
 }
 
-// GetNotifications gets all notifications
-func (s *NotificationService) GetNotifications() ([]models.Notification, error) {
+// GetNotifications gets notification channels with optional type and active status filters
+func (s *NotificationService) GetNotifications(offset, limit int, notificationType string, isActive *bool) ([]models.Notification, int64, error) {
 	var notifications []models.Notification
-	if err := s.db.Find(&notifications).Error; err != nil {
-		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	var total int64
+
+	query := s.db.Model(&models.Notification{})
+
+	// Apply filters
+	if notificationType != "" {
+		query = query.Where("type = ?", notificationType)
 	}
-	return notifications, nil
+
+	if isActive != nil {
+		query = query.Where("is_active = ?", *isActive)
+	}
+
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	if err := query.
+		Offset(offset).
+		Limit(limit).
+		Order("id ASC").
+		Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	return notifications, total, nil
+}
+
+// recordDelivery logs send attempt and stores its time, outcome and channel health
+func (s *NotificationService) recordDelivery(notification *models.Notification, subject string, isTest bool, sendErr error) {
+	delivery := &models.NotificationDelivery{
+		NotificationID: notification.ID,
+		Type:           notification.Type,
+		Subject:        subject,
+		Status:         models.DeliveryStatusSuccess,
+		IsTest:         isTest,
+		SentAt:         time.Now(),
+	}
+	if sendErr != nil {
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := s.db.Create(delivery).Error; err != nil {
+		s.log.Warnf("Failed to log delivery of notification %d: %v", notification.ID, err)
+	}
+
+	updates := s.healthUpdates(notification, sendErr)
+	updates["last_sent_at"] = &delivery.SentAt
+	updates["last_status"] = delivery.Status
+	updates["last_error"] = delivery.Error
+
+	// UpdateColumns keeps updated_at reflecting configuration changes only
+	if err := s.db.Model(&models.Notification{}).Where("id = ?", notification.ID).UpdateColumns(updates).Error; err != nil {
+		s.log.Warnf("Failed to record delivery of notification %d: %v", notification.ID, err)
+	}
+}
+
+// GetDeliveries gets recent delivery attempts of a notification channel, newest first
+func (s *NotificationService) GetDeliveries(notificationID uint, limit int) ([]models.NotificationDelivery, error) {
+	if _, err := s.GetNotificationByID(notificationID); err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.NotificationDelivery
+	if err := s.db.Where("notification_id = ?", notificationID).
+		Order("sent_at DESC, id DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+
+	return deliveries, nil
 }
 
 // GetNotificationByID gets notification by ID
@@ -337,6 +422,11 @@ func (s *NotificationService) CreateNotification(notification *models.Notificati
 		if config.BotToken == "" || config.ChatID == "" {
 			return fmt.Errorf("telegram bot token and chat ID are required")
 		}
+		if config.MessageThreadID != "" {
+			if _, err := strconv.ParseInt(config.MessageThreadID, 10, 64); err != nil {
+				return fmt.Errorf("telegram message thread ID must be numeric")
+			}
+		}
 	case "email":
 		var config EmailConfig
 		if err := json.Unmarshal([]byte(notification.Config), &config); err != nil {
@@ -384,15 +474,20 @@ func (s *NotificationService) UpdateNotification(id uint, updates map[string]int
 	return nil
 }
 
-// DeleteNotification deletes a notification channel
+// DeleteNotification deletes a notification channel with its delivery log
 func (s *NotificationService) DeleteNotification(id uint) error {
-	if err := s.db.Delete(&models.Notification{}, id).Error; err != nil {
-		return fmt.Errorf("failed to delete notification: %w", err)
-	}
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("notification_id = ?", id).Delete(&models.NotificationDelivery{}).Error; err != nil {
+			return fmt.Errorf("failed to delete deliveries: %w", err)
+		}
+		if err := tx.Delete(&models.Notification{}, id).Error; err != nil {
+			return fmt.Errorf("failed to delete notification: %w", err)
+		}
+		return nil
+	})
 }
 
-// TestNotification tests a notification channel
+// TestNotification tests a notification channel, success re-enables a degraded channel
 func (s *NotificationService) TestNotification(id uint) error {
 	notification, err := s.GetNotificationByID(id)
 	if err != nil {
@@ -403,12 +498,15 @@ func (s *NotificationService) TestNotification(id uint) error {
 
 	switch notification.Type {
 	case "telegram":
-		return s.sendTelegramNotification(notification.Config, testMessage)
+		err = s.sendTelegramNotification(notification.Config, testMessage)
 	case "email":
-		return s.sendEmailNotification(notification.Config, "SpamChecker Test Notification", testMessage)
+		err = s.sendEmailNotification(notification.Config, "SpamChecker Test Notification", testMessage)
 	default:
 		return fmt.Errorf("unsupported notification type: %s", notification.Type)
 	}
+
+	s.recordDelivery(notification, "SpamChecker Test Notification", true, err)
+	return err
 }
 
 // validateNotificationConfig validates notification configuration
@@ -422,6 +520,11 @@ func (s *NotificationService) validateNotificationConfig(notification *models.No
 		if config.BotToken == "" || config.ChatID == "" {
 			return fmt.Errorf("telegram bot token and chat ID are required")
 		}
+		if config.MessageThreadID != "" {
+			if _, err := strconv.ParseInt(config.MessageThreadID, 10, 64); err != nil {
+				return fmt.Errorf("telegram message thread ID must be numeric")
+			}
+		}
 	case "email":
 		var config EmailConfig
 		if err := json.Unmarshal([]byte(notification.Config), &config); err != nil {
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"spam-checker/internal/models"
+	"time"
+)
+
+// ServiceVerification is the latest clean verdict of a spam service for an allocated number
+type ServiceVerification struct {
+	Service    string    `json:"service"`
+	Code       string    `json:"code"`
+	CheckedAt  time.Time `json:"checked_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+	Stale      bool      `json:"stale"` // Older than result max age of the service
+}
+
+// NumberVerification summarizes verdicts an allocated number was considered clean on,
+// so the dialplan can decide how far to trust it
+type NumberVerification struct {
+	LastVerifiedAt    *time.Time            `json:"last_verified_at,omitempty"` // Most recent clean verdict of any service
+	Services          []ServiceVerification `json:"services"`
+	UncheckedServices []string              `json:"unchecked_services,omitempty"` // Active services without a clean verdict
+	// Share of active services holding a fresh clean verdict, from 0 to 1
+	Confidence float64 `json:"confidence"`
+}
+
+// numberVerification builds verification of phone from the latest successful result of each active service
+func (s *AsteriskService) numberVerification(phoneID uint) (*NumberVerification, error) {
+	var services []models.SpamService
+	if err := s.db.Where("is_active = ?", true).Order("name").Find(&services).Error; err != nil {
+		return nil, fmt.Errorf("failed to get spam services: %w", err)
+	}
+
+	latest := s.db.Model(&models.CheckResult{}).
+		Select("MAX(id)").
+		Where("phone_number_id = ? AND status <> ?", phoneID, models.SpamStatusError).
+		Group("service_id")
+	var results []models.CheckResult
+	if err := s.db.Where("id IN (?)", latest).Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get latest results: %w", err)
+	}
+	byService := make(map[uint]models.CheckResult, len(results))
+	for _, result := range results {
+		byService[result.ServiceID] = result
+	}
+
+	freshness := LoadResultFreshness(s.db)
+	now := time.Now()
+	verification := &NumberVerification{Services: []ServiceVerification{}}
+	fresh := 0
+	for _, service := range services {
+		result, ok := byService[service.ID]
+		if !ok || result.IsSpam {
+			verification.UncheckedServices = append(verification.UncheckedServices, service.Name)
+			continue
+		}
+
+		stale := freshness.IsStale(service.ID, result.CheckedAt)
+		if !stale {
+			fresh++
+		}
+		verification.Services = append(verification.Services, ServiceVerification{
+			Service:    service.Name,
+			Code:       service.Code,
+			CheckedAt:  result.CheckedAt,
+			AgeSeconds: int64(now.Sub(result.CheckedAt).Seconds()),
+			Stale:      stale,
+		})
+		if verification.LastVerifiedAt == nil || result.CheckedAt.After(*verification.LastVerifiedAt) {
+			checkedAt := result.CheckedAt
+			verification.LastVerifiedAt = &checkedAt
+		}
+	}
+	sort.SliceStable(verification.Services, func(i, j int) bool {
+		return verification.Services[i].CheckedAt.After(verification.Services[j].CheckedAt)
+	})
+
+	if len(services) > 0 {
+		verification.Confidence = math.Round(float64(fresh)/float64(len(services))*100) / 100
+	}
+	return verification, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"spam-checker/internal/config"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	tesseractPathSettingKey = "tesseract_path"
+	ocrLanguageSettingKey   = "ocr_language"
+)
+
+// ocrLanguagePattern matches tesseract language list such as rus+eng
+var ocrLanguagePattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\+[A-Za-z0-9_]+)*$`)
+
+// OCRConfigState shows persisted OCR settings next to values the next check will use
+type OCRConfigState struct {
+	Persisted map[string]interface{} `json:"persisted"`
+	Effective map[string]interface{} `json:"effective"`
+	Drift     bool                   `json:"drift"`    // Effective binary or language differs from persisted one
+	DevMode   bool                   `json:"dev_mode"` // Checks use canned OCR text
+}
+
+// validateOCRSettingFormat checks OCR binary path and language settings without running tesseract
+func validateOCRSettingFormat(key, value string) error {
+	switch key {
+	case tesseractPathSettingKey:
+		if strings.TrimSpace(value) == "" {
+			return errors.New("tesseract path must not be empty")
+		}
+	case ocrLanguageSettingKey:
+		if !ocrLanguagePattern.MatchString(value) {
+			return fmt.Errorf("invalid OCR language %q: expected codes joined with +, e.g. rus+eng", value)
+		}
+	}
+	return nil
+}
+
+// ocrConfig returns OCR binary and language from cached settings, falling back to configuration
+func (s *CheckService) ocrConfig() config.OCRConfig {
+	return loadOCRConfig(s.db, s.cfg.OCR)
+}
+
+// loadOCRConfig overrides OCR configuration with valid cached settings
+func loadOCRConfig(db *gorm.DB, ocr config.OCRConfig) config.OCRConfig {
+	settings := NewSettingsService(db)
+
+	if value, err := settings.GetCachedSettingValue(tesseractPathSettingKey); err == nil {
+		if path, _ := value.(string); validateOCRSettingFormat(tesseractPathSettingKey, path) == nil {
+			ocr.TesseractPath = strings.TrimSpace(path)
+		}
+	}
+	if value, err := settings.GetCachedSettingValue(ocrLanguageSettingKey); err == nil {
+		if language, _ := value.(string); validateOCRSettingFormat(ocrLanguageSettingKey, language) == nil {
+			ocr.Language = language
+		}
+	}
+
+	return ocr
+}
+
+// GetOCRConfigState returns persisted OCR settings and values currently in effect
+func (s *CheckService) GetOCRConfigState() (*OCRConfigState, error) {
+	persisted, err := NewSettingsService(s.db).GetOCRConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	ocr := s.ocrConfig()
+	state := &OCRConfigState{
+		Persisted: persisted,
+		Effective: map[string]interface{}{
+			tesseractPathSettingKey: ocr.TesseractPath,
+			ocrLanguageSettingKey:   ocr.Language,
+		},
+		DevMode: s.cfg.App.DevMode,
+	}
+	for _, key := range []string{tesseractPathSettingKey, ocrLanguageSettingKey} {
+		if persisted[key] != state.Effective[key] {
+			state.Drift = true
+		}
+	}
+
+	return state, nil
+}
+
+// UpdateOCRConfig validates new tesseract binary and language by running tesseract
+// before persisting them, so a broken config never reaches checks
+func (s *CheckService) UpdateOCRConfig(updates map[string]interface{}) error {
+	current := s.ocrConfig()
+	path, language := current.TesseractPath, current.Language
+	changed := false
+
+	for _, key := range []string{tesseractPathSettingKey, ocrLanguageSettingKey} {
+		raw, exists := updates[key]
+		if !exists {
+			continue
+		}
+		value, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", key)
+		}
+		value = strings.TrimSpace(value)
+		if err := validateOCRSettingFormat(key, value); err != nil {
+			return err
+		}
+		updates[key] = value
+		changed = true
+
+		if key == tesseractPathSettingKey {
+			path = value
+		} else {
+			language = value
+		}
+	}
+
+	if changed {
+		if s.cfg.App.DevMode {
+			s.log.Warn("Development mode: skipping tesseract validation of OCR config")
+		} else if err := validateOCRConfig(path, language); err != nil {
+			return err
+		}
+	}
+
+	return NewSettingsService(s.db).UpdateOCRConfig(updates)
+}
+
+// validateOCRConfig checks tesseract binary runs and has data for every language
+func validateOCRConfig(path, language string) error {
+	binaryPath, err := exec.LookPath(path)
+	if err != nil {
+		return fmt.Errorf("tesseract binary %q not found", path)
+	}
+
+	output, err := runTesseract(binaryPath, "--list-langs")
+	if err != nil {
+		return fmt.Errorf("tesseract binary %s cannot be run: %w", binaryPath, err)
+	}
+
+	if missing := missingTesseractLanguages(parseTesseractLanguages(output), language); len(missing) > 0 {
+		return errors.New(missingLanguagesMessage(missing))
+	}
+	return nil
+}
+
+// missingTesseractLanguages returns languages of a tesseract language list that have no traineddata
+func missingTesseractLanguages(available []string, language string) []string {
+	var missing []string
+	for _, code := range strings.Split(language, "+") {
+		if code = strings.TrimSpace(code); code != "" && !slices.Contains(available, code) {
+			missing = append(missing, code)
+		}
+	}
+	return missing
+}
+
+// missingLanguagesMessage explains how to install missing traineddata
+func missingLanguagesMessage(missing []string) string {
+	return fmt.Sprintf(
+		"missing traineddata for %s: install tesseract-ocr-%s package or put %s.traineddata into TESSDATA_PREFIX directory",
+		strings.Join(missing, ", "), missing[0], missing[0])
+}
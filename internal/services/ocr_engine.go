@@ -0,0 +1,212 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"spam-checker/internal/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ocrEngineSettingKey selects how screenshots are recognized
+const ocrEngineSettingKey = "ocr_engine"
+
+// OCR engines
+const (
+	OCREngineExec       = "exec"       // New tesseract process per screenshot
+	OCREnginePersistent = "persistent" // Long-lived libtesseract instances, requires build with gosseract tag
+)
+
+// ocrStatsLogInterval is number of OCR runs between latency summaries in logs
+const ocrStatsLogInterval = 100
+
+// persistentOCR is a loaded tesseract instance reused for many screenshots of one language.
+// Instances are not safe for concurrent use.
+type persistentOCR interface {
+	Text(image []byte) (string, error)
+	Close() error
+}
+
+// newPersistentOCR creates persistent tesseract instance, nil when the binary is built without a persistent backend
+var newPersistentOCR func(language string) (persistentOCR, error)
+
+// ocrPool reuses persistent tesseract instances between screenshots. Number of instances in use is
+// bounded by sharedOCRLimiter, idle ones are kept per language up to the OCR concurrency limit.
+type ocrPool struct {
+	mu          sync.Mutex
+	idle        map[string][]persistentOCR
+	unavailable sync.Once // Warns once that persistent engine is not built in
+
+	runs      atomic.Int64
+	failures  atomic.Int64
+	fallbacks atomic.Int64
+	latencyNs atomic.Int64
+}
+
+// sharedOCRPool serves OCR of the whole process
+var sharedOCRPool = &ocrPool{idle: make(map[string][]persistentOCR)}
+
+// OCRStats represents OCR throughput since start
+type OCRStats struct {
+	Engine       string  `json:"engine"`
+	Runs         int64   `json:"runs"`
+	Failures     int64   `json:"failures"`
+	Fallbacks    int64   `json:"fallbacks"` // Runs of persistent engine done by tesseract process instead
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	IdleWorkers  int     `json:"idle_workers"` // Persistent instances waiting for the next screenshot
+}
+
+// ocrEngine returns configured OCR engine
+func ocrEngine(db *gorm.DB) string {
+	if engine, err := NewSettingsService(db).GetCachedSettingValue(ocrEngineSettingKey); err == nil {
+		if value, _ := engine.(string); value == OCREnginePersistent {
+			return OCREnginePersistent
+		}
+	}
+	return OCREngineExec
+}
+
+// recognize runs OCR of image with the engine, falling back to tesseract process when persistent
+// engine is not built in or its instance cannot be created. Caller holds a sharedOCRLimiter slot.
+func (p *ocrPool) recognize(engine, tesseractPath, language string, image []byte, limit int) (string, error) {
+	started := time.Now()
+	text, err := p.run(engine, tesseractPath, language, image, limit)
+	p.record(time.Since(started), err)
+	return text, err
+}
+
+func (p *ocrPool) run(engine, tesseractPath, language string, image []byte, limit int) (string, error) {
+	if engine != OCREnginePersistent {
+		p.closeIdle()
+		return execTesseract(tesseractPath, language, image)
+	}
+
+	worker, err := p.take(language)
+	if err != nil {
+		p.fallbacks.Add(1)
+		return execTesseract(tesseractPath, language, image)
+	}
+
+	text, err := worker.Text(image)
+	if err != nil {
+		// Instance state is unknown after a failure, it is not reused
+		worker.Close()
+		return "", fmt.Errorf("OCR failed: %w", err)
+	}
+	p.put(language, worker, limit)
+	return text, nil
+}
+
+// take returns idle instance of language or creates a new one
+func (p *ocrPool) take(language string) (persistentOCR, error) {
+	p.mu.Lock()
+	if workers := p.idle[language]; len(workers) > 0 {
+		worker := workers[len(workers)-1]
+		p.idle[language] = workers[:len(workers)-1]
+		p.mu.Unlock()
+		return worker, nil
+	}
+	p.mu.Unlock()
+
+	if newPersistentOCR == nil {
+		p.unavailable.Do(func() {
+			logger.WithField("service", "OCRPool").Warn("Persistent OCR engine is not built in, using tesseract process per screenshot")
+		})
+		return nil, fmt.Errorf("persistent OCR engine is not available")
+	}
+
+	worker, err := newPersistentOCR(language)
+	if err != nil {
+		logger.WithField("service", "OCRPool").Warnf("Failed to start persistent OCR for %s, using tesseract process: %v", language, err)
+		return nil, err
+	}
+	return worker, nil
+}
+
+// put keeps instance for reuse unless enough instances of all languages are idle already
+func (p *ocrPool) put(language string, worker persistentOCR, limit int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.idleCountLocked() >= limit {
+		worker.Close()
+		return
+	}
+	p.idle[language] = append(p.idle[language], worker)
+}
+
+// closeIdle frees instances left after switching to another engine
+func (p *ocrPool) closeIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for language, workers := range p.idle {
+		for _, worker := range workers {
+			worker.Close()
+		}
+		delete(p.idle, language)
+	}
+}
+
+func (p *ocrPool) idleCountLocked() int {
+	count := 0
+	for _, workers := range p.idle {
+		count += len(workers)
+	}
+	return count
+}
+
+// record accounts OCR run and periodically logs throughput with queue depth
+func (p *ocrPool) record(latency time.Duration, err error) {
+	runs := p.runs.Add(1)
+	p.latencyNs.Add(int64(latency))
+	if err != nil {
+		p.failures.Add(1)
+	}
+
+	if runs%ocrStatsLogInterval == 0 {
+		active, waiting := sharedOCRLimiter.stats()
+		logger.WithField("service", "OCRPool").Infof(
+			"OCR: %d runs, average latency %.0f ms, %d failures, %d fallbacks, %d running, %d queued",
+			runs, p.avgLatencyMs(), p.failures.Load(), p.fallbacks.Load(), active, waiting)
+	}
+}
+
+func (p *ocrPool) avgLatencyMs() float64 {
+	runs := p.runs.Load()
+	if runs == 0 {
+		return 0
+	}
+	return float64(p.latencyNs.Load()) / float64(runs) / float64(time.Millisecond)
+}
+
+// stats returns OCR throughput since start
+func (p *ocrPool) stats(engine string) OCRStats {
+	p.mu.Lock()
+	idle := p.idleCountLocked()
+	p.mu.Unlock()
+
+	return OCRStats{
+		Engine:       engine,
+		Runs:         p.runs.Load(),
+		Failures:     p.failures.Load(),
+		Fallbacks:    p.fallbacks.Load(),
+		AvgLatencyMs: p.avgLatencyMs(),
+		IdleWorkers:  idle,
+	}
+}
+
+// execTesseract recognizes image with a new tesseract process
+func execTesseract(tesseractPath, language string, image []byte) (string, error) {
+	cmd := exec.Command(tesseractPath, "stdin", "stdout", "-l", language)
+	cmd.Stdin = bytes.NewReader(image)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("OCR failed: %w", err)
+	}
+	return string(output), nil
+}
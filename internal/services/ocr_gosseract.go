@@ -0,0 +1,39 @@
+//go:build gosseract
+
+package services
+
+import (
+	"strings"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+// Persistent OCR engine links libtesseract through gosseract, build with -tags gosseract
+func init() {
+	newPersistentOCR = newGosseractOCR
+}
+
+// gosseractOCR keeps libtesseract with loaded language data between screenshots
+type gosseractOCR struct {
+	client *gosseract.Client
+}
+
+func newGosseractOCR(language string) (persistentOCR, error) {
+	client := gosseract.NewClient()
+	if err := client.SetLanguage(strings.Split(language, "+")...); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &gosseractOCR{client: client}, nil
+}
+
+func (o *gosseractOCR) Text(image []byte) (string, error) {
+	if err := o.client.SetImageFromBytes(image); err != nil {
+		return "", err
+	}
+	return o.client.Text()
+}
+
+func (o *gosseractOCR) Close() error {
+	return o.client.Close()
+}
@@ -0,0 +1,91 @@
+//go:build gosseract
+
+package services
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Compare engines with: go test -tags gosseract -run '^$' -bench OCR ./internal/services
+
+const benchmarkOCRLanguage = "eng"
+
+func requireTesseract(tb testing.TB) string {
+	tb.Helper()
+	path, err := exec.LookPath("tesseract")
+	if err != nil {
+		tb.Skip("tesseract binary is not installed")
+	}
+	return path
+}
+
+func TestGosseractOCRRecognizesSample(t *testing.T) {
+	worker, err := newGosseractOCR(benchmarkOCRLanguage)
+	if err != nil {
+		t.Skipf("libtesseract is not usable: %v", err)
+	}
+	defer worker.Close()
+
+	for i := 0; i < 2; i++ {
+		text, err := worker.Text(ocrSampleImage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(strings.ToUpper(text), ocrSampleText) {
+			t.Errorf("run %d recognized %q, want %q", i, text, ocrSampleText)
+		}
+	}
+}
+
+func BenchmarkOCRExec(b *testing.B) {
+	tesseractPath := requireTesseract(b)
+	pool := &ocrPool{idle: make(map[string][]persistentOCR)}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pool.recognize(OCREngineExec, tesseractPath, benchmarkOCRLanguage, ocrSampleImage, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOCRPersistent(b *testing.B) {
+	tesseractPath := requireTesseract(b)
+	pool := &ocrPool{idle: make(map[string][]persistentOCR)}
+	defer pool.closeIdle()
+
+	// Language data is loaded once, as after the first screenshot in production
+	if _, err := pool.recognize(OCREnginePersistent, tesseractPath, benchmarkOCRLanguage, ocrSampleImage, 1); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pool.recognize(OCREnginePersistent, tesseractPath, benchmarkOCRLanguage, ocrSampleImage, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	if fallbacks := pool.fallbacks.Load(); fallbacks > 0 {
+		b.Fatalf("%d runs fell back to tesseract process", fallbacks)
+	}
+}
+
+func BenchmarkOCRPersistentParallel(b *testing.B) {
+	tesseractPath := requireTesseract(b)
+	pool := &ocrPool{idle: make(map[string][]persistentOCR)}
+	defer pool.closeIdle()
+	limit := runtime.GOMAXPROCS(0) // Idle instances kept, as with ocr_max_concurrent 0
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := pool.recognize(OCREnginePersistent, tesseractPath, benchmarkOCRLanguage, ocrSampleImage, limit); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
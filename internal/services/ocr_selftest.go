@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ocrSampleImage is rendered text the OCR self-test must recognize
+//
+//go:embed assets/ocr_sample.png
+var ocrSampleImage []byte
+
+// ocrSampleText is text drawn on ocrSampleImage
+const ocrSampleText = "SPAM CHECK 42"
+
+// ocrSelfTestTimeout limits each tesseract invocation of the self-test
+const ocrSelfTestTimeout = 30 * time.Second
+
+// OCRSelfTestResult reports OCR pipeline health
+type OCRSelfTestResult struct {
+	Passed             bool     `json:"passed"`
+	BinaryFound        bool     `json:"binary_found"`
+	BinaryPath         string   `json:"binary_path,omitempty"`
+	Version            string   `json:"version,omitempty"`
+	Language           string   `json:"language"`
+	AvailableLanguages []string `json:"available_languages,omitempty"`
+	MissingLanguages   []string `json:"missing_languages,omitempty"`
+	ExpectedText       string   `json:"expected_text"`
+	RecognizedText     string   `json:"recognized_text,omitempty"`
+	TextMatched        bool     `json:"text_matched"`
+	DevMode            bool     `json:"dev_mode"` // Checks use canned OCR text, tesseract is still tested
+	Errors             []string `json:"errors,omitempty"`
+}
+
+// RunOCRSelfTest runs configured tesseract against bundled sample image and reports
+// whether binary, language data and recognition work
+func (s *CheckService) RunOCRSelfTest() (*OCRSelfTestResult, error) {
+	ocr := s.ocrConfig()
+	result := &OCRSelfTestResult{
+		Language:     ocr.Language,
+		ExpectedText: ocrSampleText,
+		DevMode:      s.cfg.App.DevMode,
+	}
+
+	binaryPath, err := exec.LookPath(ocr.TesseractPath)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf(
+			"tesseract binary %q not found: install tesseract-ocr or set TESSERACT_PATH to its full path",
+			ocr.TesseractPath))
+		return result, nil
+	}
+	result.BinaryFound = true
+	result.BinaryPath = binaryPath
+
+	if output, err := runTesseract(binaryPath, "--version"); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("tesseract binary %s cannot be run: %v", binaryPath, err))
+		return result, nil
+	} else if lines := strings.SplitN(strings.TrimSpace(output), "\n", 2); len(lines) > 0 {
+		result.Version = strings.TrimSpace(lines[0])
+	}
+
+	languages, err := runTesseract(binaryPath, "--list-langs")
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to list tesseract languages: %v", err))
+	} else {
+		result.AvailableLanguages = parseTesseractLanguages(languages)
+		result.MissingLanguages = missingTesseractLanguages(result.AvailableLanguages, ocr.Language)
+		if len(result.MissingLanguages) > 0 {
+			result.Errors = append(result.Errors, missingLanguagesMessage(result.MissingLanguages))
+			return result, nil
+		}
+	}
+
+	sample, err := os.CreateTemp("", "ocr-selftest-*.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sample image: %w", err)
+	}
+	defer os.Remove(sample.Name())
+
+	if _, err := sample.Write(ocrSampleImage); err != nil {
+		sample.Close()
+		return nil, fmt.Errorf("failed to write sample image: %w", err)
+	}
+	sample.Close()
+
+	recognized, err := runTesseract(binaryPath, sample.Name(), "stdout", "-l", ocr.Language)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("OCR of sample image failed: %v", err))
+		return result, nil
+	}
+	result.RecognizedText = strings.TrimSpace(recognized)
+	result.TextMatched = strings.Contains(normalizeOCRText(recognized), normalizeOCRText(ocrSampleText))
+
+	if !result.TextMatched {
+		message := fmt.Sprintf("recognized text %q does not match expected %q", result.RecognizedText, ocrSampleText)
+		if !slices.Contains(strings.Split(ocr.Language, "+"), "eng") {
+			message += ": sample text is Latin, add eng to OCR_LANGUAGE (e.g. rus+eng)"
+		}
+		result.Errors = append(result.Errors, message)
+	}
+
+	result.Passed = len(result.Errors) == 0
+	return result, nil
+}
+
+// runTesseract runs tesseract and returns its output, error includes tesseract diagnostics
+func runTesseract(binaryPath string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ocrSelfTestTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("timed out after %s", ocrSelfTestTimeout)
+	}
+	if err != nil {
+		if details := strings.TrimSpace(stderr.String()); details != "" {
+			return "", fmt.Errorf("%w: %s", err, details)
+		}
+		return "", err
+	}
+
+	// Older tesseract versions print version and languages to stderr
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return stderr.String(), nil
+	}
+	return string(output), nil
+}
+
+// parseTesseractLanguages extracts language codes from tesseract --list-langs output
+func parseTesseractLanguages(output string) []string {
+	var languages []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "List of available languages") {
+			continue
+		}
+		languages = append(languages, line)
+	}
+	return languages
+}
+
+// normalizeOCRText keeps only upper-cased letters and digits so spacing and punctuation noise is ignored
+func normalizeOCRText(text string) string {
+	var normalized strings.Builder
+	for _, r := range strings.ToUpper(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized.WriteRune(r)
+		}
+	}
+	return normalized.String()
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"gorm.io/gorm"
+)
+
+// findPage loads a page of query into dest and returns total count of rows before pagination.
+// Zero limit loads all rows without a separate count.
+func findPage[T any](query *gorm.DB, offset, limit int, dest *[]T) (int64, error) {
+	if limit <= 0 {
+		if err := query.Find(dest).Error; err != nil {
+			return 0, err
+		}
+		return int64(len(*dest)), nil
+	}
+
+	var total int64
+	if err := query.Model(new(T)).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	if err := query.Offset(offset).Limit(limit).Find(dest).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
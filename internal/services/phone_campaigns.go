@@ -0,0 +1,225 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// campaignPatternSettingKey is the setting holding regex that extracts campaign from phone description
+const campaignPatternSettingKey = "phone_campaign_description_pattern"
+
+// UncategorizedCampaign groups phones without a campaign in statistics and filters
+const UncategorizedCampaign = "uncategorized"
+
+// maxCampaignLength matches size of PhoneNumber.Campaign column
+const maxCampaignLength = 100
+
+// campaignBackfillBatchSize is number of phones loaded per query during backfill
+const campaignBackfillBatchSize = 500
+
+// NormalizeCampaign trims campaign name and checks its length
+func NormalizeCampaign(campaign string) (string, error) {
+	campaign = strings.TrimSpace(campaign)
+	if utf8.RuneCountInString(campaign) > maxCampaignLength {
+		return "", fmt.Errorf("campaign must be at most %d characters", maxCampaignLength)
+	}
+	return campaign, nil
+}
+
+// validateCampaignPattern checks campaign pattern setting value, empty pattern disables backfill
+func validateCampaignPattern(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid campaign pattern: %w", err)
+	}
+	return nil
+}
+
+// campaignFromDescription extracts campaign from description: first capture group if pattern has one,
+// whole match otherwise. Empty result means description does not name a campaign.
+func campaignFromDescription(pattern *regexp.Regexp, description string) string {
+	match := pattern.FindStringSubmatch(description)
+	if match == nil {
+		return ""
+	}
+
+	campaign := match[0]
+	if len(match) > 1 {
+		campaign = match[1]
+	}
+	campaign = strings.TrimSpace(campaign)
+	if utf8.RuneCountInString(campaign) > maxCampaignLength {
+		campaign = string([]rune(campaign)[:maxCampaignLength])
+	}
+	return campaign
+}
+
+// campaignPattern returns compiled campaign pattern from settings, nil if unset or invalid
+func (s *PhoneService) campaignPattern() *regexp.Regexp {
+	value, err := NewSettingsService(s.db).GetCachedSettingValue(campaignPatternSettingKey)
+	if err != nil {
+		return nil
+	}
+	pattern, _ := value.(string)
+	if pattern == "" {
+		return nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		s.log.Warnf("Ignoring invalid %s setting %q: %v", campaignPatternSettingKey, pattern, err)
+		return nil
+	}
+	return compiled
+}
+
+// BackfillCampaigns copies campaign from description of phones without one, using the pattern
+// from settings. Phones with a campaign are never overwritten, so the backfill can run repeatedly.
+func (s *PhoneService) BackfillCampaigns() (int, error) {
+	pattern := s.campaignPattern()
+	if pattern == nil {
+		return 0, nil
+	}
+
+	updated := 0
+	var lastID uint
+	for {
+		var phones []models.PhoneNumber
+		if err := s.db.Select("id", "description").
+			Where("id > ? AND (campaign IS NULL OR campaign = '') AND description <> ''", lastID).
+			Order("id").
+			Limit(campaignBackfillBatchSize).
+			Find(&phones).Error; err != nil {
+			return updated, fmt.Errorf("failed to load phones: %w", err)
+		}
+		if len(phones) == 0 {
+			return updated, nil
+		}
+
+		for _, phone := range phones {
+			lastID = phone.ID
+			campaign := campaignFromDescription(pattern, phone.Description)
+			if campaign == "" {
+				continue
+			}
+			if err := s.db.Model(&models.PhoneNumber{}).Where("id = ?", phone.ID).
+				Update("campaign", campaign).Error; err != nil {
+				return updated, fmt.Errorf("failed to set campaign of phone %d: %w", phone.ID, err)
+			}
+			updated++
+		}
+	}
+}
+
+// CampaignStats holds spam statistics of phones sharing a campaign
+type CampaignStats struct {
+	Campaign      string  `json:"campaign"`
+	Phones        int64   `json:"phones"`
+	CheckedPhones int64   `json:"checked_phones"` // Phones with at least one successful check
+	SpamPhones    int64   `json:"spam_phones"`    // Phones whose latest verdict of any service is spam
+	SpamRate      float64 `json:"spam_rate"`      // Percent of checked phones that are spam now
+
+	// Trend compares spam share of checks in the window with the window before it
+	WindowChecks         int64   `json:"window_checks"`
+	WindowSpamRate       float64 `json:"window_spam_rate"`
+	PreviousWindowChecks int64   `json:"previous_window_checks"`
+	PreviousSpamRate     float64 `json:"previous_window_spam_rate"`
+	SpamRateChange       float64 `json:"spam_rate_change"` // Percentage points, positive means more spam
+}
+
+// campaignStatsRow is raw aggregate of one campaign
+type campaignStatsRow struct {
+	Campaign      string
+	Phones        int64
+	CheckedPhones int64
+	SpamPhones    int64
+	WindowChecks  int64
+	WindowSpam    int64
+	PrevChecks    int64
+	PrevSpam      int64
+}
+
+// GetCampaignStats returns per-campaign spam statistics, trend compares the last windowDays with the
+// preceding period of the same length. Phones without a campaign are grouped as uncategorized.
+func (s *StatisticsService) GetCampaignStats(windowDays int) ([]CampaignStats, error) {
+	now := time.Now()
+	windowStart := now.AddDate(0, 0, -windowDays)
+	previousStart := windowStart.AddDate(0, 0, -windowDays)
+
+	query := `
+		WITH phone_state AS (
+			SELECT phone_number_id, MAX(CASE WHEN is_spam THEN 1 ELSE 0 END) AS is_spam
+			FROM check_results
+			WHERE id IN (
+				SELECT MAX(id) FROM check_results
+				WHERE source <> 'import' AND status <> 'error'
+				GROUP BY phone_number_id, service_id
+			)
+			GROUP BY phone_number_id
+		),
+		window_checks AS (
+			SELECT
+				phone_number_id,
+				SUM(CASE WHEN checked_at >= ? THEN 1 ELSE 0 END) AS window_checks,
+				SUM(CASE WHEN checked_at >= ? AND is_spam THEN 1 ELSE 0 END) AS window_spam,
+				SUM(CASE WHEN checked_at < ? THEN 1 ELSE 0 END) AS prev_checks,
+				SUM(CASE WHEN checked_at < ? AND is_spam THEN 1 ELSE 0 END) AS prev_spam
+			FROM check_results
+			WHERE checked_at >= ? AND source <> 'import' AND status <> 'error'
+			GROUP BY phone_number_id
+		)
+		SELECT
+			COALESCE(NULLIF(pn.campaign, ''), ?) AS campaign,
+			COUNT(*) AS phones,
+			COUNT(ps.phone_number_id) AS checked_phones,
+			COALESCE(SUM(ps.is_spam), 0) AS spam_phones,
+			COALESCE(SUM(wc.window_checks), 0) AS window_checks,
+			COALESCE(SUM(wc.window_spam), 0) AS window_spam,
+			COALESCE(SUM(wc.prev_checks), 0) AS prev_checks,
+			COALESCE(SUM(wc.prev_spam), 0) AS prev_spam
+		FROM phone_numbers pn
+		LEFT JOIN phone_state ps ON ps.phone_number_id = pn.id
+		LEFT JOIN window_checks wc ON wc.phone_number_id = pn.id
+		WHERE pn.deleted_at IS NULL
+		GROUP BY 1
+		ORDER BY campaign
+	`
+
+	var rows []campaignStatsRow
+	if err := s.db.Raw(query,
+		windowStart, windowStart, windowStart, windowStart, previousStart,
+		UncategorizedCampaign,
+	).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get campaign statistics: %w", err)
+	}
+
+	percent := func(part, total int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(part) / float64(total) * 100
+	}
+
+	stats := make([]CampaignStats, len(rows))
+	for i, row := range rows {
+		stats[i] = CampaignStats{
+			Campaign:             row.Campaign,
+			Phones:               row.Phones,
+			CheckedPhones:        row.CheckedPhones,
+			SpamPhones:           row.SpamPhones,
+			SpamRate:             percent(row.SpamPhones, row.CheckedPhones),
+			WindowChecks:         row.WindowChecks,
+			WindowSpamRate:       percent(row.WindowSpam, row.WindowChecks),
+			PreviousWindowChecks: row.PrevChecks,
+			PreviousSpamRate:     percent(row.PrevSpam, row.PrevChecks),
+		}
+		stats[i].SpamRateChange = stats[i].WindowSpamRate - stats[i].PreviousSpamRate
+	}
+	return stats, nil
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DuplicatePhone represents a single row of a duplicate group
+type DuplicatePhone struct {
+	ID          uint      `json:"id"`
+	Number      string    `json:"number"`
+	Description string    `json:"description"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DuplicatePhoneGroup represents phones whose normalized numbers collide.
+// KeepID is the oldest row which survives the merge.
+type DuplicatePhoneGroup struct {
+	NormalizedNumber string           `json:"normalized_number"`
+	KeepID           uint             `json:"keep_id"`
+	MergeIDs         []uint           `json:"merge_ids"`
+	Phones           []DuplicatePhone `json:"phones"`
+}
+
+// PhoneMergeReport represents result of duplicate merge
+type PhoneMergeReport struct {
+	DryRun       bool                  `json:"dry_run"`
+	Groups       []DuplicatePhoneGroup `json:"groups"`
+	MergedGroups int                   `json:"merged_groups"`
+	RemovedRows  int                   `json:"removed_rows"`
+	Errors       []string              `json:"errors,omitempty"`
+}
+
+// FindDuplicatePhones finds phone rows whose normalized numbers collide
+func (s *PhoneService) FindDuplicatePhones() ([]DuplicatePhoneGroup, error) {
+	var phones []models.PhoneNumber
+	if err := s.db.Order("created_at ASC, id ASC").Find(&phones).Error; err != nil {
+		return nil, fmt.Errorf("failed to load phones: %w", err)
+	}
+
+	grouped := make(map[string][]models.PhoneNumber)
+	var order []string
+	for _, phone := range phones {
+		normalized := s.normalizePhoneNumber(phone.Number)
+		if _, exists := grouped[normalized]; !exists {
+			order = append(order, normalized)
+		}
+		grouped[normalized] = append(grouped[normalized], phone)
+	}
+
+	var groups []DuplicatePhoneGroup
+	for _, normalized := range order {
+		rows := grouped[normalized]
+		if len(rows) < 2 {
+			continue
+		}
+
+		group := DuplicatePhoneGroup{
+			NormalizedNumber: normalized,
+			KeepID:           rows[0].ID,
+		}
+		for i, row := range rows {
+			group.Phones = append(group.Phones, DuplicatePhone{
+				ID:          row.ID,
+				Number:      row.Number,
+				Description: row.Description,
+				IsActive:    row.IsActive,
+				CreatedAt:   row.CreatedAt,
+			})
+			if i > 0 {
+				group.MergeIDs = append(group.MergeIDs, row.ID)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].KeepID < groups[j].KeepID
+	})
+
+	return groups, nil
+}
+
+// MergeDuplicatePhones merges duplicate phone rows into the oldest one.
+// In dry run mode only proposed merges are returned.
+func (s *PhoneService) MergeDuplicatePhones(dryRun bool) (*PhoneMergeReport, error) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "MergeDuplicatePhones",
+		"dryRun": dryRun,
+	})
+
+	groups, err := s.FindDuplicatePhones()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PhoneMergeReport{
+		DryRun: dryRun,
+		Groups: groups,
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, group := range groups {
+		if err := s.mergePhoneGroup(group); err != nil {
+			log.Errorf("Failed to merge duplicates of %s: %v", group.NormalizedNumber, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", group.NormalizedNumber, err))
+			continue
+		}
+		report.MergedGroups++
+		report.RemovedRows += len(group.MergeIDs)
+	}
+
+	log.Infof("Merged %d duplicate groups, removed %d rows", report.MergedGroups, report.RemovedRows)
+
+	return report, nil
+}
+
+// mergePhoneGroup repoints related records to the kept phone and soft-deletes duplicates
+func (s *PhoneService) mergePhoneGroup(group DuplicatePhoneGroup) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		keepID := group.KeepID
+		mergeIDs := group.MergeIDs
+
+		// Check history
+		if err := tx.Model(&models.CheckResult{}).Where("phone_number_id IN ?", mergeIDs).
+			Update("phone_number_id", keepID).Error; err != nil {
+			return fmt.Errorf("failed to move check results: %w", err)
+		}
+
+		// Check timelines
+		if err := tx.Model(&models.CheckEvent{}).Where("phone_number_id IN ?", mergeIDs).
+			Update("phone_number_id", keepID).Error; err != nil {
+			return fmt.Errorf("failed to move check events: %w", err)
+		}
+
+		// Status history
+		if err := tx.Model(&models.SpamStatusTransition{}).Where("phone_number_id IN ?", mergeIDs).
+			Update("phone_number_id", keepID).Error; err != nil {
+			return fmt.Errorf("failed to move status transitions: %w", err)
+		}
+
+		// Spam flags waiting for re-check
+		if err := tx.Model(&models.SpamVerification{}).Where("phone_number_id IN ?", mergeIDs).
+			Update("phone_number_id", keepID).Error; err != nil {
+			return fmt.Errorf("failed to move spam verifications: %w", err)
+		}
+
+		// Allocation history
+		if err := tx.Model(&models.NumberAllocation{}).Where("phone_number_id IN ?", mergeIDs).
+			Update("phone_number_id", keepID).Error; err != nil {
+			return fmt.Errorf("failed to move allocations: %w", err)
+		}
+
+		// Statistics are unique per phone and service, so counters are summed
+		if err := s.mergePhoneStatistics(tx, keepID, mergeIDs); err != nil {
+			return err
+		}
+
+		// Schedule memberships, skipping schedules the kept phone already belongs to
+		var keptSchedules []uint
+		if err := tx.Model(&models.SchedulePhone{}).Where("phone_number_id = ?", keepID).
+			Pluck("schedule_id", &keptSchedules).Error; err != nil {
+			return fmt.Errorf("failed to load schedule memberships: %w", err)
+		}
+		var memberships []models.SchedulePhone
+		if err := tx.Where("phone_number_id IN ?", mergeIDs).Find(&memberships).Error; err != nil {
+			return fmt.Errorf("failed to load schedule memberships: %w", err)
+		}
+		seen := make(map[uint]bool, len(keptSchedules))
+		for _, scheduleID := range keptSchedules {
+			seen[scheduleID] = true
+		}
+		for _, membership := range memberships {
+			if seen[membership.ScheduleID] {
+				if err := tx.Delete(&membership).Error; err != nil {
+					return fmt.Errorf("failed to delete schedule membership: %w", err)
+				}
+				continue
+			}
+			seen[membership.ScheduleID] = true
+			if err := tx.Model(&membership).Update("phone_number_id", keepID).Error; err != nil {
+				return fmt.Errorf("failed to move schedule membership: %w", err)
+			}
+		}
+
+		// Service exclusions, skipping services the kept phone is already excluded from
+		var keptExclusions []uint
+		if err := tx.Model(&models.PhoneServiceExclusion{}).Where("phone_number_id = ?", keepID).
+			Pluck("service_id", &keptExclusions).Error; err != nil {
+			return fmt.Errorf("failed to load service exclusions: %w", err)
+		}
+		var exclusions []models.PhoneServiceExclusion
+		if err := tx.Where("phone_number_id IN ?", mergeIDs).Find(&exclusions).Error; err != nil {
+			return fmt.Errorf("failed to load service exclusions: %w", err)
+		}
+		excludedServices := make(map[uint]bool, len(keptExclusions))
+		for _, serviceID := range keptExclusions {
+			excludedServices[serviceID] = true
+		}
+		for _, exclusion := range exclusions {
+			if excludedServices[exclusion.ServiceID] {
+				if err := tx.Delete(&exclusion).Error; err != nil {
+					return fmt.Errorf("failed to delete service exclusion: %w", err)
+				}
+				continue
+			}
+			excludedServices[exclusion.ServiceID] = true
+			if err := tx.Model(&exclusion).Update("phone_number_id", keepID).Error; err != nil {
+				return fmt.Errorf("failed to move service exclusion: %w", err)
+			}
+		}
+
+		// Concatenate distinct descriptions, keep phone active if any copy was active
+		var descriptions []string
+		seenDescriptions := make(map[string]bool)
+		isActive := false
+		for _, phone := range group.Phones {
+			description := strings.TrimSpace(phone.Description)
+			if description != "" && !seenDescriptions[description] {
+				seenDescriptions[description] = true
+				descriptions = append(descriptions, description)
+			}
+			isActive = isActive || phone.IsActive
+		}
+
+		// Free normalized value before assigning it to the kept row
+		if err := tx.Model(&models.PhoneNumber{}).Where("id IN ?", mergeIDs).
+			Update("normalized_number", nil).Error; err != nil {
+			return fmt.Errorf("failed to clear normalized numbers: %w", err)
+		}
+
+		if err := tx.Delete(&models.PhoneNumber{}, mergeIDs).Error; err != nil {
+			return fmt.Errorf("failed to delete duplicates: %w", err)
+		}
+
+		normalized := group.NormalizedNumber
+		if err := tx.Model(&models.PhoneNumber{}).Where("id = ?", keepID).Updates(map[string]interface{}{
+			"description":       strings.Join(descriptions, "; "),
+			"is_active":         isActive,
+			"normalized_number": &normalized,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update kept phone: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// mergePhoneStatistics merges statistics rows of duplicates into the kept phone
+func (s *PhoneService) mergePhoneStatistics(tx *gorm.DB, keepID uint, mergeIDs []uint) error {
+	var stats []models.Statistics
+	if err := tx.Where("phone_number_id IN ?", mergeIDs).Find(&stats).Error; err != nil {
+		return fmt.Errorf("failed to load statistics: %w", err)
+	}
+
+	for _, stat := range stats {
+		var kept models.Statistics
+		err := tx.Where("phone_number_id = ? AND service_id = ?", keepID, stat.ServiceID).First(&kept).Error
+		if err == gorm.ErrRecordNotFound {
+			if err := tx.Model(&stat).Update("phone_number_id", keepID).Error; err != nil {
+				return fmt.Errorf("failed to move statistics: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load statistics: %w", err)
+		}
+
+		kept.TotalChecks += stat.TotalChecks
+		kept.SpamCount += stat.SpamCount
+		if stat.FirstSpamDate != nil && (kept.FirstSpamDate == nil || stat.FirstSpamDate.Before(*kept.FirstSpamDate)) {
+			kept.FirstSpamDate = stat.FirstSpamDate
+		}
+		if stat.LastCheckDate.After(kept.LastCheckDate) {
+			kept.LastCheckDate = stat.LastCheckDate
+		}
+
+		if err := tx.Save(&kept).Error; err != nil {
+			return fmt.Errorf("failed to update statistics: %w", err)
+		}
+		if err := tx.Delete(&stat).Error; err != nil {
+			return fmt.Errorf("failed to delete statistics: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// BackfillNormalizedNumbers fills normalized_number for rows created before it existed.
+// Rows colliding with an existing normalized value are left empty until duplicates are merged.
+func (s *PhoneService) BackfillNormalizedNumbers() (int, error) {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "BackfillNormalizedNumbers",
+	})
+
+	var phones []models.PhoneNumber
+	if err := s.db.Where("normalized_number IS NULL").Order("created_at ASC, id ASC").Find(&phones).Error; err != nil {
+		return 0, fmt.Errorf("failed to load phones: %w", err)
+	}
+
+	updated := 0
+	skipped := 0
+	for _, phone := range phones {
+		normalized := s.normalizePhoneNumber(phone.Number)
+
+		var count int64
+		if err := s.db.Model(&models.PhoneNumber{}).Where("normalized_number = ?", normalized).Count(&count).Error; err != nil {
+			return updated, fmt.Errorf("failed to check normalized number: %w", err)
+		}
+		if count > 0 {
+			skipped++
+			continue
+		}
+
+		if err := s.db.Model(&models.PhoneNumber{}).Where("id = ?", phone.ID).
+			Update("normalized_number", normalized).Error; err != nil {
+			return updated, fmt.Errorf("failed to update phone %d: %w", phone.ID, err)
+		}
+		updated++
+	}
+
+	if skipped > 0 {
+		log.Warnf("%d phones share normalized numbers with other rows, merge duplicates to fix", skipped)
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MaxGeneratedPhones caps number of phones created by a single GenerateRange call
+const MaxGeneratedPhones = 10000
+
+// generatedPhoneDescription tags synthetic phones so they can be found and purged
+const generatedPhoneDescription = "Generated test number"
+
+// generatedPhoneDigits is length of a normalized number, prefix is padded with zeros to it
+const generatedPhoneDigits = 11
+
+// generateBatchSize bounds number of phones looked up and inserted at once
+const generateBatchSize = 500
+
+// ErrInvalidPhoneRange is returned when prefix or count cannot produce a range of numbers
+var ErrInvalidPhoneRange = errors.New("invalid phone range")
+
+// PhoneGenerationReport summarizes generated phone range
+type PhoneGenerationReport struct {
+	Created int    `json:"created"`
+	Skipped int    `json:"skipped"` // Numbers that already existed
+	First   string `json:"first"`
+	Last    string `json:"last"`
+}
+
+// GenerateRange creates count sequential synthetic phones starting from prefix padded with zeros
+// to a full number, e.g. prefix 7900123 gives 79001230000, 79001230001 and so on. Existing numbers
+// are skipped. Phones are active so checks pick them up, and tagged with generated origin and description.
+func (s *PhoneService) GenerateRange(prefix string, count int, userID uint) (*PhoneGenerationReport, error) {
+	prefix = strings.TrimPrefix(strings.TrimSpace(prefix), "+")
+	if prefix == "" || strings.Trim(prefix, "0123456789") != "" {
+		return nil, fmt.Errorf("%w: prefix must contain only digits", ErrInvalidPhoneRange)
+	}
+	if len(prefix) > generatedPhoneDigits {
+		return nil, fmt.Errorf("%w: prefix must be at most %d digits", ErrInvalidPhoneRange, generatedPhoneDigits)
+	}
+	if count < 1 || count > MaxGeneratedPhones {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidPhoneRange, MaxGeneratedPhones)
+	}
+
+	base, err := strconv.ParseUint(prefix+strings.Repeat("0", generatedPhoneDigits-len(prefix)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPhoneRange, err)
+	}
+	last := base + uint64(count) - 1
+	if len(strconv.FormatUint(last, 10)) > generatedPhoneDigits {
+		return nil, fmt.Errorf("%w: range overflows %d digits", ErrInvalidPhoneRange, generatedPhoneDigits)
+	}
+
+	format := fmt.Sprintf("%%0%dd", generatedPhoneDigits)
+	report := &PhoneGenerationReport{
+		First: fmt.Sprintf(format, base),
+		Last:  fmt.Sprintf(format, last),
+	}
+
+	for start := 0; start < count; start += generateBatchSize {
+		numbers := make([]string, 0, generateBatchSize)
+		for i := start; i < count && i < start+generateBatchSize; i++ {
+			numbers = append(numbers, fmt.Sprintf(format, base+uint64(i)))
+		}
+
+		created, err := s.createGeneratedPhones(numbers, userID)
+		if err != nil {
+			return report, err
+		}
+		report.Created += created
+		report.Skipped += len(numbers) - created
+	}
+
+	s.log.Infof("Generated %d phones from %s to %s, %d skipped", report.Created, report.First, report.Last, report.Skipped)
+	return report, nil
+}
+
+// createGeneratedPhones creates phones for numbers that do not exist yet, including soft-deleted ones
+// whose number is still unique
+func (s *PhoneService) createGeneratedPhones(numbers []string, userID uint) (int, error) {
+	var existing []string
+	if err := s.db.Unscoped().Model(&models.PhoneNumber{}).
+		Where("number IN ?", numbers).
+		Pluck("number", &existing).Error; err != nil {
+		return 0, fmt.Errorf("failed to check existing phones: %w", err)
+	}
+	var existingNormalized []string
+	if err := s.db.Model(&models.PhoneNumber{}).
+		Where("normalized_number IN ?", numbers).
+		Pluck("normalized_number", &existingNormalized).Error; err != nil {
+		return 0, fmt.Errorf("failed to check existing phones: %w", err)
+	}
+
+	skip := make(map[string]bool, len(existing)+len(existingNormalized))
+	for _, number := range append(existing, existingNormalized...) {
+		skip[number] = true
+	}
+
+	phones := make([]models.PhoneNumber, 0, len(numbers))
+	for _, number := range numbers {
+		if skip[number] {
+			continue
+		}
+		normalized := number
+		phones = append(phones, models.PhoneNumber{
+			Number:           number,
+			NormalizedNumber: &normalized,
+			Description:      generatedPhoneDescription,
+			Origin:           models.PhoneOriginGenerated,
+			IsActive:         true,
+			CreatedBy:        userID,
+		})
+	}
+	if len(phones) == 0 {
+		return 0, nil
+	}
+
+	if err := s.db.CreateInBatches(&phones, generateBatchSize).Error; err != nil {
+		return 0, fmt.Errorf("failed to create phones: %w", err)
+	}
+	return len(phones), nil
+}
+
+// PurgeGeneratedPhones deletes all phones created by GenerateRange along with their results
+func (s *PhoneService) PurgeGeneratedPhones() (int, error) {
+	var ids []uint
+	if err := s.db.Model(&models.PhoneNumber{}).
+		Where("origin = ?", models.PhoneOriginGenerated).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to find generated phones: %w", err)
+	}
+
+	for start := 0; start < len(ids); start += generateBatchSize {
+		batch := ids[start:min(start+generateBatchSize, len(ids))]
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			return deletePhones(tx, batch)
+		}); err != nil {
+			return start, fmt.Errorf("failed to delete generated phones: %w", err)
+		}
+	}
+	return len(ids), nil
+}
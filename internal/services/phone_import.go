@@ -0,0 +1,419 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"spam-checker/internal/config"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// phoneImportBatchSize is the number of rows processed between progress saves
+const phoneImportBatchSize = 500
+
+// phoneImportPollInterval is how often worker looks for jobs without being woken
+const phoneImportPollInterval = time.Minute
+
+// ErrImportFileElsewhere is returned for import files kept on disk of another running instance
+var ErrImportFileElsewhere = errors.New("import file is stored on another instance")
+
+// PhoneImportService imports large phone CSV files in the background.
+// Jobs are processed one at a time and resume from their cursor after restart.
+type PhoneImportService struct {
+	db           *gorm.DB
+	phoneService *PhoneService
+	storagePath  string
+	log          *logrus.Entry
+
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func NewPhoneImportService(db *gorm.DB, cfg *config.Config) *PhoneImportService {
+	return &PhoneImportService{
+		db:           db,
+		phoneService: NewPhoneService(db),
+		storagePath:  cfg.Import.StoragePath,
+		log:          logger.WithField("service", "PhoneImportService"),
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start launches worker, picking up jobs left unfinished by previous run
+func (s *PhoneImportService) Start() {
+	go s.worker()
+}
+
+// Stop asks worker to save progress and waits for it to exit
+func (s *PhoneImportService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+// SyncMaxRows returns row count up to which imports run within the request
+func (s *PhoneImportService) SyncMaxRows() int {
+	return NewSettingsService(s.db).GetCachedInt("phone_import_sync_max_rows", 1000)
+}
+
+// SaveUpload stores uploaded CSV and returns its path and estimated data row count
+func (s *PhoneImportService) SaveUpload(src io.Reader) (string, int, error) {
+	if err := os.MkdirAll(s.storagePath, 0o755); err != nil {
+		return "", 0, fmt.Errorf("failed to create import storage: %w", err)
+	}
+
+	file, err := os.CreateTemp(s.storagePath, "phones-*.csv")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create import file: %w", err)
+	}
+	defer file.Close()
+
+	// Count lines while copying, quoted multi-line fields make this an estimate
+	lines := 0
+	lastByte := byte('\n')
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+			lines += bytes.Count(chunk, []byte{'\n'})
+			lastByte = chunk[n-1]
+			if _, err := file.Write(chunk); err != nil {
+				os.Remove(file.Name())
+				return "", 0, fmt.Errorf("failed to write import file: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			os.Remove(file.Name())
+			return "", 0, fmt.Errorf("failed to read upload: %w", readErr)
+		}
+	}
+	if lastByte != '\n' {
+		lines++
+	}
+
+	// First line is the header
+	rows := lines - 1
+	if rows < 0 {
+		rows = 0
+	}
+	return file.Name(), rows, nil
+}
+
+// ImportStored imports saved CSV synchronously and removes it
+func (s *PhoneImportService) ImportStored(path string, userID uint) (int, []string, error) {
+	defer os.Remove(path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	return s.phoneService.ImportPhones(file, userID)
+}
+
+// CreateJob queues saved CSV for background import
+func (s *PhoneImportService) CreateJob(fileName, path string, rows int, userID uint) (*models.PhoneImportJob, error) {
+	job := &models.PhoneImportJob{
+		Status:    models.ImportJobPending,
+		FileName:  filepath.Base(fileName),
+		FilePath:  path,
+		Instance:  instanceID,
+		TotalRows: rows,
+		CreatedBy: userID,
+	}
+
+	if err := s.db.Create(job).Error; err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	s.log.Infof("Queued phone import job %d (%s, ~%d rows)", job.ID, job.FileName, rows)
+	return job, nil
+}
+
+// GetJob returns import job by ID
+func (s *PhoneImportService) GetJob(id uint) (*models.PhoneImportJob, error) {
+	var job models.PhoneImportJob
+	if err := s.db.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("import job not found")
+		}
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+	return &job, nil
+}
+
+// ListJobs returns latest import jobs
+func (s *PhoneImportService) ListJobs(limit int) ([]models.PhoneImportJob, error) {
+	var jobs []models.PhoneImportJob
+	if err := s.db.Order("id DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list import jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// ErrorReportPath returns path of job's failed lines CSV, empty if job had no failures.
+// Report kept on disk of another instance is ErrImportFileElsewhere.
+func (s *PhoneImportService) ErrorReportPath(job *models.PhoneImportJob) (string, error) {
+	if job.FailedRows == 0 || job.ErrorReportPath == "" {
+		return "", nil
+	}
+	if _, err := os.Stat(job.ErrorReportPath); err != nil {
+		if job.Instance != "" && job.Instance != instanceID {
+			return "", fmt.Errorf("%w: %s", ErrImportFileElsewhere, job.Instance)
+		}
+		return "", nil
+	}
+	return job.ErrorReportPath, nil
+}
+
+func (s *PhoneImportService) worker() {
+	defer close(s.done)
+
+	for {
+		job, lease, err := s.claimJob()
+		if err != nil {
+			s.log.Errorf("Failed to get next import job: %v", err)
+		}
+		if job != nil {
+			stopped := s.runJob(job)
+			lease.Release()
+			if stopped {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-time.After(phoneImportPollInterval):
+		}
+	}
+}
+
+// claimJob returns the oldest unfinished job not processed by another instance, leased to this one
+// until released. Nil job when there is nothing to do.
+func (s *PhoneImportService) claimJob() (*models.PhoneImportJob, *heldLease, error) {
+	var jobs []models.PhoneImportJob
+	if err := s.db.Where("status IN ?", []string{models.ImportJobPending, models.ImportJobRunning}).
+		Order("id").
+		Find(&jobs).Error; err != nil {
+		return nil, nil, err
+	}
+
+	for i := range jobs {
+		if !s.canRunJob(&jobs[i]) {
+			continue
+		}
+
+		lease, acquired, err := holdLease(s.db, fmt.Sprintf("phone_import:%d", jobs[i].ID), lockLeaseTTL)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !acquired {
+			continue
+		}
+
+		// Error report is written next to the upload, so it follows the job to this instance
+		if jobs[i].Instance != instanceID {
+			if err := s.db.Model(&jobs[i]).Update("instance", instanceID).Error; err != nil {
+				lease.Release()
+				return nil, nil, fmt.Errorf("failed to take over import job %d: %w", jobs[i].ID, err)
+			}
+		}
+		return &jobs[i], lease, nil
+	}
+	return nil, nil, nil
+}
+
+// canRunJob reports whether upload of job is readable here: the job was queued by this instance,
+// or by an instance that stopped and shared this disk, e.g. this instance before restart
+func (s *PhoneImportService) canRunJob(job *models.PhoneImportJob) bool {
+	if job.Instance == instanceID {
+		return true
+	}
+	if job.Instance != "" && InstanceAlive(s.db, job.Instance) {
+		return false
+	}
+	_, err := os.Stat(job.FilePath)
+	return err == nil
+}
+
+// runJob processes job from its cursor. Returns true if interrupted by Stop.
+func (s *PhoneImportService) runJob(job *models.PhoneImportJob) bool {
+	log := s.log.WithFields(logrus.Fields{
+		"method": "runJob",
+		"job_id": job.ID,
+	})
+
+	if job.Cursor > 0 {
+		log.Infof("Resuming phone import job after line %d", job.Cursor)
+	} else {
+		log.Infof("Starting phone import job (%s)", job.FileName)
+	}
+
+	if job.Status != models.ImportJobRunning {
+		now := time.Now()
+		job.Status = models.ImportJobRunning
+		job.StartedAt = &now
+		if err := s.db.Model(job).Updates(map[string]interface{}{
+			"status":     job.Status,
+			"started_at": job.StartedAt,
+		}).Error; err != nil {
+			log.Errorf("Failed to mark import job running: %v", err)
+		}
+	}
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		s.failJob(job, fmt.Errorf("failed to open import file: %w", err))
+		return false
+	}
+	defer file.Close()
+
+	csvReader := csv.NewReader(bufio.NewReader(file))
+	header, err := csvReader.Read()
+	if err != nil {
+		s.failJob(job, fmt.Errorf("failed to read CSV header: %w", err))
+		return false
+	}
+	columns, err := parsePhoneImportHeader(header)
+	if err != nil {
+		s.failJob(job, err)
+		return false
+	}
+
+	if job.ErrorReportPath == "" {
+		job.ErrorReportPath = filepath.Join(s.storagePath, fmt.Sprintf("job-%d-errors.csv", job.ID))
+	}
+	report, err := os.OpenFile(job.ErrorReportPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		s.failJob(job, fmt.Errorf("failed to open error report: %w", err))
+		return false
+	}
+	defer report.Close()
+
+	reportWriter := csv.NewWriter(report)
+	if info, err := report.Stat(); err == nil && info.Size() == 0 {
+		reportWriter.Write([]string{"line", "number", "error"})
+	}
+
+	saveProgress := func(cursor int) {
+		reportWriter.Flush()
+		job.Cursor = cursor
+		if err := s.db.Model(job).Updates(map[string]interface{}{
+			"cursor":            job.Cursor,
+			"processed_rows":    job.ProcessedRows,
+			"imported_rows":     job.ImportedRows,
+			"failed_rows":       job.FailedRows,
+			"error_report_path": job.ErrorReportPath,
+		}).Error; err != nil {
+			log.Errorf("Failed to save import progress: %v", err)
+		}
+	}
+
+	lineNum := 1
+	batch := 0
+	for {
+		record, readErr := csvReader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		lineNum++
+
+		// Rows up to cursor were handled before restart
+		if lineNum <= job.Cursor {
+			continue
+		}
+
+		select {
+		case <-s.stop:
+			saveProgress(lineNum - 1)
+			log.Infof("Phone import job interrupted after line %d", job.Cursor)
+			return true
+		default:
+		}
+
+		number := ""
+		err := readErr
+		if err == nil {
+			number, err = s.phoneService.importPhoneRecord(record, columns, job.CreatedBy)
+		}
+
+		job.ProcessedRows++
+		if err != nil {
+			job.FailedRows++
+			reportWriter.Write([]string{strconv.Itoa(lineNum), number, err.Error()})
+		} else {
+			job.ImportedRows++
+		}
+
+		batch++
+		if batch >= phoneImportBatchSize {
+			saveProgress(lineNum)
+			batch = 0
+		}
+	}
+
+	saveProgress(lineNum)
+
+	now := time.Now()
+	job.Status = models.ImportJobCompleted
+	job.CompletedAt = &now
+	if err := s.db.Model(job).Updates(map[string]interface{}{
+		"status":       job.Status,
+		"completed_at": job.CompletedAt,
+	}).Error; err != nil {
+		log.Errorf("Failed to mark import job completed: %v", err)
+	}
+
+	// Uploaded file is no longer needed, error report is kept for download
+	os.Remove(job.FilePath)
+
+	log.Infof("Phone import job completed: %d imported, %d failed", job.ImportedRows, job.FailedRows)
+	return false
+}
+
+// failJob marks job as failed, keeping its progress
+func (s *PhoneImportService) failJob(job *models.PhoneImportJob, cause error) {
+	s.log.WithField("job_id", job.ID).Errorf("Phone import job failed: %v", cause)
+
+	now := time.Now()
+	if err := s.db.Model(job).Updates(map[string]interface{}{
+		"status":       models.ImportJobFailed,
+		"error":        cause.Error(),
+		"completed_at": &now,
+	}).Error; err != nil {
+		s.log.Errorf("Failed to mark import job failed: %v", err)
+	}
+	os.Remove(job.FilePath)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"spam-checker/internal/config"
+	"spam-checker/internal/models"
+)
+
+func TestClaimJobOnlyTakesReadableUploads(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	service := NewPhoneImportService(db, &config.Config{Import: config.ImportConfig{StoragePath: dir}})
+
+	upload := func(name string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("number\n79001234567\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	createJob := func(instance, path string) *models.PhoneImportJob {
+		t.Helper()
+		job := &models.PhoneImportJob{Status: models.ImportJobPending, FilePath: path, Instance: instance, TotalRows: 1}
+		if err := db.Create(job).Error; err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+
+	// Upload of a running replica is on its disk, even if a file with the same path exists here
+	if ok, err := acquireLease(db, instanceLeasePrefix+"replica-b", "replica-b", time.Hour); err != nil || !ok {
+		t.Fatalf("acquireLease() = %v, %v", ok, err)
+	}
+	createJob("replica-b", upload("live.csv"))
+	// Stopped replica whose disk is not shared
+	createJob("replica-c", filepath.Join(dir, "missing.csv"))
+	// Previous run of this instance on the same disk
+	restarted := createJob("replica-before-restart", upload("restarted.csv"))
+
+	job, lease, err := service.claimJob()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job == nil || job.ID != restarted.ID {
+		t.Fatalf("claimJob() = %+v, want job %d", job, restarted.ID)
+	}
+	lease.Release()
+
+	var stored models.PhoneImportJob
+	if err := db.First(&stored, restarted.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Instance != instanceID {
+		t.Errorf("taken over job instance = %q, want %q", stored.Instance, instanceID)
+	}
+
+	if err := db.Model(&stored).Update("status", models.ImportJobCompleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if job, _, err := service.claimJob(); err != nil || job != nil {
+		t.Errorf("claimJob() = %+v, %v, want no job readable here", job, err)
+	}
+}
+
+func TestErrorReportPathOfAnotherInstance(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	service := NewPhoneImportService(db, &config.Config{Import: config.ImportConfig{StoragePath: dir}})
+
+	local := filepath.Join(dir, "job-1-errors.csv")
+	if err := os.WriteFile(local, []byte("line,number,error\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path, err := service.ErrorReportPath(&models.PhoneImportJob{FailedRows: 1, ErrorReportPath: local, Instance: instanceID})
+	if err != nil || path != local {
+		t.Errorf("local report = %q, %v", path, err)
+	}
+
+	_, err = service.ErrorReportPath(&models.PhoneImportJob{
+		FailedRows:      1,
+		ErrorReportPath: filepath.Join(dir, "job-2-errors.csv"),
+		Instance:        "replica-b",
+	})
+	if !errors.Is(err, ErrImportFileElsewhere) {
+		t.Errorf("remote report error = %v, want ErrImportFileElsewhere", err)
+	}
+
+	if path, err := service.ErrorReportPath(&models.PhoneImportJob{Instance: "replica-b"}); path != "" || err != nil {
+		t.Errorf("job without failures = %q, %v", path, err)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"spam-checker/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Settings of default phone list order
+const (
+	phoneListSortSettingKey  = "phone_list_default_sort"
+	phoneListOrderSettingKey = "phone_list_default_order"
+)
+
+// Phone list sort columns
+const (
+	PhoneSortCreatedAt   = "created_at"
+	PhoneSortNumber      = "number"
+	PhoneSortSpamStatus  = "spam_status"
+	PhoneSortLastChecked = "last_checked"
+	PhoneSortAllocations = "allocations"
+)
+
+// Phone list spam filters
+const (
+	PhoneSpamFilterSpam      = "spam"
+	PhoneSpamFilterClean     = "clean"
+	PhoneSpamFilterUnchecked = "unchecked"
+)
+
+// phoneSortExprs maps phone list sort column to its SQL expression.
+// Unchecked phones have spam status -1 and sort below clean ones.
+var phoneSortExprs = map[string]string{
+	PhoneSortCreatedAt:   "phone_numbers.created_at",
+	PhoneSortNumber:      "phone_numbers.number",
+	PhoneSortSpamStatus:  "COALESCE(pv.is_spam, -1)",
+	PhoneSortLastChecked: "pv.last_checked_at",
+	PhoneSortAllocations: "COALESCE(pa.allocations, 0)",
+}
+
+// PhoneSortColumns lists sort columns accepted by phone listing
+var PhoneSortColumns = []string{PhoneSortCreatedAt, PhoneSortNumber, PhoneSortSpamStatus, PhoneSortLastChecked, PhoneSortAllocations}
+
+// PhoneSpamFilters lists spam filters accepted by phone listing
+var PhoneSpamFilters = []string{PhoneSpamFilterSpam, PhoneSpamFilterClean, PhoneSpamFilterUnchecked}
+
+// PhoneListOptions sorts and filters phone listing. Empty Sort and Order use
+// phone_list_default_sort and phone_list_default_order settings.
+type PhoneListOptions struct {
+	Sort            string
+	Order           string // asc or desc
+	Spam            string // Overall verdict: spam, clean or unchecked
+	IncludeRealtime bool   // Include inactive phones created by realtime checks
+	OwnerID         uint   // Only phones created by this user, 0 lists all
+}
+
+// Validate checks sort column, order and spam filter
+func (o PhoneListOptions) Validate() error {
+	if o.Sort != "" && !slices.Contains(PhoneSortColumns, o.Sort) {
+		return fmt.Errorf("sort must be one of %s", strings.Join(PhoneSortColumns, ", "))
+	}
+	if o.Order != "" && o.Order != "asc" && o.Order != "desc" {
+		return fmt.Errorf("order must be asc or desc")
+	}
+	if o.Spam != "" && !slices.Contains(PhoneSpamFilters, o.Spam) {
+		return fmt.Errorf("spam must be one of %s", strings.Join(PhoneSpamFilters, ", "))
+	}
+	return nil
+}
+
+// phoneVerdictsQuery returns overall verdict of every checked phone. A phone is spam when the latest
+// result of any service is spam, errored and imported results are skipped, the same way phone
+// details and campaign statistics aggregate results.
+func phoneVerdictsQuery(db *gorm.DB) *gorm.DB {
+	latest := db.Model(&models.CheckResult{}).
+		Select("MAX(id)").
+		Where("source <> ? AND status <> ?", models.CheckSourceImport, models.SpamStatusError).
+		Group("phone_number_id, service_id")
+
+	return db.Model(&models.CheckResult{}).
+		Select("phone_number_id, MAX(CASE WHEN is_spam THEN 1 ELSE 0 END) AS is_spam, MAX(checked_at) AS last_checked_at").
+		Where("id IN (?)", latest).
+		Group("phone_number_id")
+}
+
+// phoneListDefaults returns default sort column and order from settings, created_at desc if unset or invalid
+func (s *PhoneService) phoneListDefaults() (string, string) {
+	settings := NewSettingsService(s.db)
+	sortColumn, order := PhoneSortCreatedAt, "desc"
+	if value, err := settings.GetCachedSettingValue(phoneListSortSettingKey); err == nil {
+		if column, _ := value.(string); slices.Contains(PhoneSortColumns, column) {
+			sortColumn = column
+		}
+	}
+	if value, err := settings.GetCachedSettingValue(phoneListOrderSettingKey); err == nil {
+		if direction, _ := value.(string); direction == "asc" || direction == "desc" {
+			order = direction
+		}
+	}
+	return sortColumn, order
+}
+
+// applyPhoneListOptions joins verdicts and allocation counts the options need, applies spam filter
+// and returns the query along with its order clauses
+func (s *PhoneService) applyPhoneListOptions(query *gorm.DB, opts PhoneListOptions) (*gorm.DB, []string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	sortColumn, order := s.phoneListDefaults()
+	if opts.Sort != "" {
+		sortColumn = opts.Sort
+	}
+	if opts.Order != "" {
+		order = opts.Order
+	}
+
+	if !opts.IncludeRealtime {
+		query = excludeRealtimePhones(query)
+	}
+	query = ownedPhones(query, opts.OwnerID)
+
+	if opts.Spam != "" || sortColumn == PhoneSortSpamStatus || sortColumn == PhoneSortLastChecked {
+		query = query.Joins("LEFT JOIN (?) pv ON pv.phone_number_id = phone_numbers.id", phoneVerdictsQuery(s.db))
+	}
+	if sortColumn == PhoneSortAllocations {
+		allocations := s.db.Model(&models.NumberAllocation{}).
+			Select("phone_number_id, COUNT(*) AS allocations").
+			Group("phone_number_id")
+		query = query.Joins("LEFT JOIN (?) pa ON pa.phone_number_id = phone_numbers.id", allocations)
+	}
+
+	switch opts.Spam {
+	case PhoneSpamFilterSpam:
+		query = query.Where("pv.is_spam = 1")
+	case PhoneSpamFilterClean:
+		query = query.Where("pv.is_spam = 0")
+	case PhoneSpamFilterUnchecked:
+		query = query.Where("pv.phone_number_id IS NULL")
+	}
+
+	var orders []string
+	if sortColumn == PhoneSortLastChecked {
+		// Never checked phones go last in both directions
+		orders = append(orders, "pv.last_checked_at IS NULL")
+	}
+	// Phone ID keeps pages stable when sort values repeat
+	orders = append(orders,
+		fmt.Sprintf("%s %s", phoneSortExprs[sortColumn], strings.ToUpper(order)),
+		fmt.Sprintf("phone_numbers.id %s", strings.ToUpper(order)),
+	)
+	return query, orders, nil
+}
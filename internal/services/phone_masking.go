@@ -0,0 +1,27 @@
+package services
+
+import (
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+)
+
+// maskPhoneNumbersSettingKey enables masking of phone numbers in logs and notifications.
+// Database rows and authenticated API responses keep full numbers.
+const maskPhoneNumbersSettingKey = "mask_phone_numbers"
+
+// SyncPhoneMasking applies privacy setting to the logger, so masking is active before the first check
+func (s *SettingsService) SyncPhoneMasking() {
+	logger.SetPhoneMasking(s.GetCachedBool(maskPhoneNumbersSettingKey, false))
+}
+
+// applyPhoneMasking applies privacy setting from freshly loaded settings.
+// The logger cannot read settings itself, its hooks would recurse into database logging.
+func applyPhoneMasking(settings map[string]models.SystemSettings) {
+	enabled := false
+	if setting, ok := settings[maskPhoneNumbersSettingKey]; ok {
+		if value, err := convertSettingValue(&setting); err == nil {
+			enabled, _ = value.(bool)
+		}
+	}
+	logger.SetPhoneMasking(enabled)
+}
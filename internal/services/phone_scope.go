@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Phone scopes of listings and statistics
+const (
+	PhoneScopeAll  = "all"  // Every phone
+	PhoneScopeMine = "mine" // Phones created by the current user
+)
+
+// ErrInvalidPhoneScope is returned for unknown scope
+var ErrInvalidPhoneScope = errors.New("invalid scope")
+
+// PhoneOwnerFromScope returns owner filter of scope for the current user, 0 when all phones are in scope
+func PhoneOwnerFromScope(scope string, userID uint) (uint, error) {
+	switch scope {
+	case "", PhoneScopeAll:
+		return 0, nil
+	case PhoneScopeMine:
+		if userID == 0 {
+			return 0, fmt.Errorf("%w: %s requires an authenticated user", ErrInvalidPhoneScope, PhoneScopeMine)
+		}
+		return userID, nil
+	default:
+		return 0, fmt.Errorf("%w: must be %s or %s", ErrInvalidPhoneScope, PhoneScopeAll, PhoneScopeMine)
+	}
+}
+
+// ownedPhones limits phone query to phones created by owner, 0 keeps all phones
+func ownedPhones(query *gorm.DB, ownerID uint) *gorm.DB {
+	if ownerID == 0 {
+		return query
+	}
+	return query.Where("phone_numbers.created_by = ?", ownerID)
+}
+
+// ownedResults limits check result query to results of phones created by owner, 0 keeps all results
+func ownedResults(db, query *gorm.DB, ownerID uint) *gorm.DB {
+	if ownerID == 0 {
+		return query
+	}
+	return query.Where("check_results.phone_number_id IN (?)",
+		db.Model(&models.PhoneNumber{}).Select("id").Where("created_by = ?", ownerID))
+}
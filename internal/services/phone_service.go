@@ -9,6 +9,7 @@ import (
 	"spam-checker/internal/logger"
 	"spam-checker/internal/models"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -35,6 +36,30 @@ func NewPhoneService(db *gorm.DB) *PhoneService {
 func (s *PhoneService) CreatePhone(phone *models.PhoneNumber) error {
 	// Normalize phone number
 	phone.Number = s.normalizePhoneNumber(phone.Number)
+	normalized := phone.Number
+	phone.NormalizedNumber = &normalized
+
+	campaign, err := NormalizeCampaign(phone.Campaign)
+	if err != nil {
+		return err
+	}
+	phone.Campaign = campaign
+	if phone.Campaign == "" {
+		if pattern := s.campaignPattern(); pattern != nil {
+			phone.Campaign = campaignFromDescription(pattern, phone.Description)
+		}
+	}
+
+	// Legacy rows may store the same line in another format
+	var count int64
+	if err := s.db.Model(&models.PhoneNumber{}).
+		Where("number = ? OR normalized_number = ?", phone.Number, normalized).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check phone number: %w", err)
+	}
+	if count > 0 {
+		return errors.New("phone number already exists")
+	}
 
 	if err := s.db.Create(phone).Error; err != nil {
 		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
@@ -71,17 +96,23 @@ func (s *PhoneService) GetPhoneByID(id uint) (*models.PhoneNumber, error) {
 		// Don't fail the whole request if we can't load results
 		phone.CheckResults = []models.CheckResult{}
 	} else {
+		attachResultSources(s.db, checkResults)
 		phone.CheckResults = checkResults
 	}
 
 	return &phone, nil
 }
 
+// ResultFreshness returns current max ages of check results of the phone
+func (s *PhoneService) ResultFreshness(phoneID uint) *ResultFreshness {
+	return LoadResultFreshness(s.db).ForPhone(s.db, phoneID)
+}
+
 // GetPhoneByNumber gets phone by number
 func (s *PhoneService) GetPhoneByNumber(number string) (*models.PhoneNumber, error) {
 	number = s.normalizePhoneNumber(number)
 	var phone models.PhoneNumber
-	if err := s.db.Where("number = ?", number).First(&phone).Error; err != nil {
+	if err := s.db.Where("number = ? OR normalized_number = ?", number, number).First(&phone).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("phone number not found")
 		}
@@ -91,7 +122,7 @@ func (s *PhoneService) GetPhoneByNumber(number string) (*models.PhoneNumber, err
 }
 
 // ListPhones lists all phones with pagination and latest check results
-func (s *PhoneService) ListPhones(offset, limit int, search string, isActive *bool) ([]models.PhoneNumber, int64, error) {
+func (s *PhoneService) ListPhones(offset, limit int, search string, isActive *bool, opts PhoneListOptions) ([]models.PhoneNumber, int64, error) {
 	var phones []models.PhoneNumber
 	var total int64
 
@@ -100,11 +131,16 @@ func (s *PhoneService) ListPhones(offset, limit int, search string, isActive *bo
 	// Apply filters
 	if search != "" {
 		search = "%" + search + "%"
-		query = query.Where("number LIKE ? OR description LIKE ?", search, search)
+		query = query.Where("phone_numbers.number LIKE ? OR phone_numbers.description LIKE ?", search, search)
 	}
 
 	if isActive != nil {
-		query = query.Where("is_active = ?", *isActive)
+		query = query.Where("phone_numbers.is_active = ?", *isActive)
+	}
+
+	query, orders, err := s.applyPhoneListOptions(query, opts)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Count total
@@ -113,10 +149,13 @@ func (s *PhoneService) ListPhones(offset, limit int, search string, isActive *bo
 	}
 
 	// Get phones
+	for _, order := range orders {
+		query = query.Order(order)
+	}
 	if err := query.
+		Select("phone_numbers.*").
 		Offset(offset).
 		Limit(limit).
-		Order("created_at DESC").
 		Find(&phones).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to list phones: %w", err)
 	}
@@ -128,7 +167,7 @@ func (s *PhoneService) ListPhones(offset, limit int, search string, isActive *bo
 		// Get latest result for each service
 		subQuery := s.db.Model(&models.CheckResult{}).
 			Select("MAX(id) as id").
-			Where("phone_number_id = ?", phones[i].ID).
+			Where("phone_number_id = ? AND source <> ? AND status <> ?", phones[i].ID, models.CheckSourceImport, models.SpamStatusError).
 			Group("service_id")
 
 		err := s.db.
@@ -149,7 +188,7 @@ func (s *PhoneService) ListPhones(offset, limit int, search string, isActive *bo
 }
 
 // ListPhonesWithDetails returns phones with additional computed fields
-func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, isActive *bool) ([]map[string]interface{}, int64, error) {
+func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, isActive *bool, campaign string, opts PhoneListOptions) ([]map[string]interface{}, int64, error) {
 	var phones []models.PhoneNumber
 	var total int64
 
@@ -158,11 +197,24 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 	// Apply filters
 	if search != "" {
 		search = "%" + search + "%"
-		query = query.Where("number LIKE ? OR description LIKE ?", search, search)
+		query = query.Where("phone_numbers.number LIKE ? OR phone_numbers.description LIKE ?", search, search)
+	}
+
+	switch campaign {
+	case "":
+	case UncategorizedCampaign:
+		query = query.Where("phone_numbers.campaign IS NULL OR phone_numbers.campaign = ''")
+	default:
+		query = query.Where("phone_numbers.campaign = ?", campaign)
 	}
 
 	if isActive != nil {
-		query = query.Where("is_active = ?", *isActive)
+		query = query.Where("phone_numbers.is_active = ?", *isActive)
+	}
+
+	query, orders, err := s.applyPhoneListOptions(query, opts)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Count total
@@ -171,10 +223,13 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 	}
 
 	// Get phones
+	for _, order := range orders {
+		query = query.Order(order)
+	}
 	if err := query.
+		Select("phone_numbers.*").
 		Offset(offset).
 		Limit(limit).
-		Order("created_at DESC").
 		Find(&phones).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to list phones: %w", err)
 	}
@@ -187,7 +242,9 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 			"id":          phone.ID,
 			"number":      phone.Number,
 			"description": phone.Description,
+			"campaign":    phone.Campaign,
 			"is_active":   phone.IsActive,
+			"blocked":     phone.Blocked,
 			"created_by":  phone.CreatedBy,
 			"created_at":  phone.CreatedAt,
 			"updated_at":  phone.UpdatedAt,
@@ -195,12 +252,15 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 
 		// Get latest check results with service details
 		var checkResults []struct {
-			ServiceID     uint   `json:"service_id"`
-			ServiceName   string `json:"service_name"`
-			ServiceCode   string `json:"service_code"`
-			IsSpam        bool   `json:"is_spam"`
-			FoundKeywords string `json:"found_keywords"`
-			CheckedAt     string `json:"checked_at"`
+			ServiceID     uint    `json:"service_id"`
+			ServiceName   string  `json:"service_name"`
+			ServiceCode   string  `json:"service_code"`
+			IsSpam        bool    `json:"is_spam"`
+			Inconclusive  bool    `json:"inconclusive"`
+			Status        string  `json:"status"`
+			Confidence    float64 `json:"confidence"`
+			FoundKeywords string  `json:"found_keywords"`
+			CheckedAt     string  `json:"checked_at"`
 		}
 
 		err := s.db.Table("check_results").
@@ -209,6 +269,9 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 				spam_services.name as service_name,
 				spam_services.code as service_code,
 				check_results.is_spam,
+				check_results.inconclusive,
+				check_results.status,
+				check_results.confidence,
 				check_results.found_keywords,
 				check_results.checked_at
 			`).
@@ -216,7 +279,7 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 			Where("check_results.phone_number_id = ?", phone.ID).
 			Where(`check_results.id IN (
 				SELECT MAX(id) FROM check_results 
-				WHERE phone_number_id = ? 
+				WHERE phone_number_id = ? AND source <> 'import' AND status <> 'error'
 				GROUP BY service_id
 			)`, phone.ID).
 			Order("check_results.checked_at DESC").
@@ -250,6 +313,9 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 						"code": result.ServiceCode,
 					},
 					"is_spam":        result.IsSpam,
+					"inconclusive":   result.Inconclusive,
+					"status":         result.Status,
+					"confidence":     result.Confidence,
 					"found_keywords": keywords,
 					"checked_at":     result.CheckedAt,
 				}
@@ -263,7 +329,7 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 			Where("phone_number_id = ? AND is_spam = ?", phone.ID, true).
 			Where(`id IN (
 				SELECT MAX(id) FROM check_results 
-				WHERE phone_number_id = ? 
+				WHERE phone_number_id = ? AND source <> 'import' AND status <> 'error'
 				GROUP BY service_id
 			)`, phone.ID).
 			Count(&spamCount)
@@ -281,7 +347,27 @@ func (s *PhoneService) ListPhonesWithDetails(offset, limit int, search string, i
 func (s *PhoneService) UpdatePhone(id uint, updates map[string]interface{}) error {
 	// Normalize phone number if it's being updated
 	if number, ok := updates["number"].(string); ok {
-		updates["number"] = s.normalizePhoneNumber(number)
+		normalized := s.normalizePhoneNumber(number)
+		updates["number"] = normalized
+		updates["normalized_number"] = normalized
+
+		var count int64
+		if err := s.db.Model(&models.PhoneNumber{}).
+			Where("(number = ? OR normalized_number = ?) AND id != ?", normalized, normalized, id).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check phone number: %w", err)
+		}
+		if count > 0 {
+			return errors.New("phone number already exists")
+		}
+	}
+
+	if campaign, ok := updates["campaign"].(string); ok {
+		normalized, err := NormalizeCampaign(campaign)
+		if err != nil {
+			return err
+		}
+		updates["campaign"] = normalized
 	}
 
 	if err := s.db.Model(&models.PhoneNumber{}).Where("id = ?", id).Updates(updates).Error; err != nil {
@@ -296,25 +382,121 @@ func (s *PhoneService) UpdatePhone(id uint, updates map[string]interface{}) erro
 
 // DeletePhone soft deletes a phone
 func (s *PhoneService) DeletePhone(id uint) error {
-	// Start transaction
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		// Delete related check results first
-		if err := tx.Where("phone_number_id = ?", id).Delete(&models.CheckResult{}).Error; err != nil {
-			return fmt.Errorf("failed to delete check results: %w", err)
-		}
+		return deletePhones(tx, []uint{id})
+	})
+}
+
+// deletePhones soft deletes phones along with their results, history and schedule memberships
+func deletePhones(tx *gorm.DB, ids []uint) error {
+	// Delete related check results first
+	if err := tx.Where("phone_number_id IN ?", ids).Delete(&models.CheckResult{}).Error; err != nil {
+		return fmt.Errorf("failed to delete check results: %w", err)
+	}
+
+	// Delete check timelines
+	if err := tx.Where("phone_number_id IN ?", ids).Delete(&models.CheckEvent{}).Error; err != nil {
+		return fmt.Errorf("failed to delete check events: %w", err)
+	}
+
+	// Delete related statistics
+	if err := tx.Where("phone_number_id IN ?", ids).Delete(&models.Statistics{}).Error; err != nil {
+		return fmt.Errorf("failed to delete statistics: %w", err)
+	}
+
+	// Delete status history
+	if err := tx.Where("phone_number_id IN ?", ids).Delete(&models.SpamStatusTransition{}).Error; err != nil {
+		return fmt.Errorf("failed to delete status transitions: %w", err)
+	}
 
-		// Delete related statistics
-		if err := tx.Where("phone_number_id = ?", id).Delete(&models.Statistics{}).Error; err != nil {
-			return fmt.Errorf("failed to delete statistics: %w", err)
+	// Drop spam flags waiting for re-check
+	if err := tx.Where("phone_number_id IN ?", ids).Delete(&models.SpamVerification{}).Error; err != nil {
+		return fmt.Errorf("failed to delete spam verifications: %w", err)
+	}
+
+	// Remove phone from schedule lists
+	if err := tx.Where("phone_number_id IN ?", ids).Delete(&models.SchedulePhone{}).Error; err != nil {
+		return fmt.Errorf("failed to delete schedule memberships: %w", err)
+	}
+
+	// Drop service exclusions
+	if err := tx.Where("phone_number_id IN ?", ids).Delete(&models.PhoneServiceExclusion{}).Error; err != nil {
+		return fmt.Errorf("failed to delete service exclusions: %w", err)
+	}
+
+	// Free normalized number, unique index also covers soft-deleted rows
+	if err := tx.Model(&models.PhoneNumber{}).Where("id IN ?", ids).Update("normalized_number", nil).Error; err != nil {
+		return fmt.Errorf("failed to clear normalized number: %w", err)
+	}
+
+	// Delete the phone
+	if err := tx.Delete(&models.PhoneNumber{}, ids).Error; err != nil {
+		return fmt.Errorf("failed to delete phone: %w", err)
+	}
+
+	return nil
+}
+
+// phoneImportColumns represents positions of known columns in import CSV
+type phoneImportColumns struct {
+	number      int
+	description int
+	campaign    int
+}
+
+// parsePhoneImportHeader finds phone number, description and campaign columns in CSV header
+func parsePhoneImportHeader(header []string) (phoneImportColumns, error) {
+	columns := phoneImportColumns{number: -1, description: -1, campaign: -1}
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if col == "number" || col == "phone" || col == "phone_number" || col == "номер" || col == "телефон" {
+			columns.number = i
+		} else if col == "description" || col == "desc" || col == "описание" || col == "name" || col == "имя" {
+			columns.description = i
+		} else if col == "campaign" || col == "кампания" {
+			columns.campaign = i
 		}
+	}
+
+	if columns.number == -1 {
+		return columns, errors.New("phone number column not found in CSV")
+	}
+	return columns, nil
+}
+
+// importPhoneRecord creates phone from CSV record, returning the raw number for error reports
+func (s *PhoneService) importPhoneRecord(record []string, columns phoneImportColumns, userID uint) (string, error) {
+	if len(record) <= columns.number {
+		return "", errors.New("insufficient columns")
+	}
+
+	number := strings.TrimSpace(record[columns.number])
+	if number == "" {
+		return "", errors.New("empty phone number")
+	}
+
+	description := ""
+	if columns.description != -1 && len(record) > columns.description {
+		description = strings.TrimSpace(record[columns.description])
+	}
 
-		// Delete the phone
-		if err := tx.Delete(&models.PhoneNumber{}, id).Error; err != nil {
-			return fmt.Errorf("failed to delete phone: %w", err)
+	campaign := ""
+	if columns.campaign != -1 && len(record) > columns.campaign {
+		var err error
+		if campaign, err = NormalizeCampaign(record[columns.campaign]); err != nil {
+			return number, err
 		}
+	}
 
-		return nil
-	})
+	phone := &models.PhoneNumber{
+		Number:      number,
+		Description: description,
+		Campaign:    campaign,
+		CreatedBy:   userID,
+		IsActive:    true,
+	}
+
+	return number, s.CreatePhone(phone)
 }
 
 // ImportPhones imports phones from CSV
@@ -327,20 +509,9 @@ func (s *PhoneService) ImportPhones(reader io.Reader, userID uint) (int, []strin
 		return 0, nil, fmt.Errorf("failed to read CSV header: %w", err)
 	}
 
-	// Find column indices
-	numberIdx := -1
-	descriptionIdx := -1
-	for i, col := range header {
-		col = strings.ToLower(strings.TrimSpace(col))
-		if col == "number" || col == "phone" || col == "phone_number" || col == "номер" || col == "телефон" {
-			numberIdx = i
-		} else if col == "description" || col == "desc" || col == "описание" || col == "name" || col == "имя" {
-			descriptionIdx = i
-		}
-	}
-
-	if numberIdx == -1 {
-		return 0, nil, errors.New("phone number column not found in CSV")
+	columns, err := parsePhoneImportHeader(header)
+	if err != nil {
+		return 0, nil, err
 	}
 
 	imported := 0
@@ -357,31 +528,13 @@ func (s *PhoneService) ImportPhones(reader io.Reader, userID uint) (int, []strin
 			continue
 		}
 
-		if len(record) <= numberIdx {
-			errors = append(errors, fmt.Sprintf("Line %d: insufficient columns", lineNum))
-			continue
-		}
-
-		number := strings.TrimSpace(record[numberIdx])
-		if number == "" {
-			errors = append(errors, fmt.Sprintf("Line %d: empty phone number", lineNum))
-			continue
-		}
-
-		description := ""
-		if descriptionIdx != -1 && len(record) > descriptionIdx {
-			description = strings.TrimSpace(record[descriptionIdx])
-		}
-
-		phone := &models.PhoneNumber{
-			Number:      number,
-			Description: description,
-			CreatedBy:   userID,
-			IsActive:    true,
-		}
-
-		if err := s.CreatePhone(phone); err != nil {
-			errors = append(errors, fmt.Sprintf("Line %d (%s): %v", lineNum, number, err))
+		number, err := s.importPhoneRecord(record, columns, userID)
+		if err != nil {
+			if number == "" {
+				errors = append(errors, fmt.Sprintf("Line %d: %v", lineNum, err))
+			} else {
+				errors = append(errors, fmt.Sprintf("Line %d (%s): %v", lineNum, number, err))
+			}
 			continue
 		}
 
@@ -397,7 +550,7 @@ func (s *PhoneService) ExportPhones(writer io.Writer, isActive *bool) error {
 	defer csvWriter.Flush()
 
 	// Write header
-	if err := csvWriter.Write([]string{"Number", "Description", "Status", "Last Check", "Is Spam", "Services Checked"}); err != nil {
+	if err := csvWriter.Write([]string{"Number", "Description", "Campaign", "Status", "Last Check", "Is Spam", "Services Checked"}); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
@@ -406,7 +559,7 @@ func (s *PhoneService) ExportPhones(writer io.Writer, isActive *bool) error {
 	limit := 100
 
 	for {
-		phones, _, err := s.ListPhonesWithDetails(offset, limit, "", isActive)
+		phones, _, err := s.ListPhonesWithDetails(offset, limit, "", isActive, "", PhoneListOptions{Sort: PhoneSortCreatedAt, Order: "desc"})
 		if err != nil {
 			return fmt.Errorf("failed to get phones: %w", err)
 		}
@@ -447,6 +600,7 @@ func (s *PhoneService) ExportPhones(writer io.Writer, isActive *bool) error {
 			row := []string{
 				phoneData["number"].(string),
 				phoneData["description"].(string),
+				phoneData["campaign"].(string),
 				status,
 				lastCheck,
 				isSpam,
@@ -464,40 +618,156 @@ func (s *PhoneService) ExportPhones(writer io.Writer, isActive *bool) error {
 	return nil
 }
 
+// SetPhoneBlocked adds phone to or removes it from the blocklist.
+// Blocked phones keep their history but are never checked or allocated.
+func (s *PhoneService) SetPhoneBlocked(id uint, blocked bool, reason string) error {
+	var phone models.PhoneNumber
+	if err := s.db.First(&phone, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("phone not found")
+		}
+		return fmt.Errorf("failed to get phone: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"blocked":        blocked,
+		"blocked_reason": "",
+		"blocked_at":     nil,
+	}
+	if blocked {
+		now := time.Now()
+		updates["blocked_reason"] = reason
+		updates["blocked_at"] = &now
+	}
+
+	if err := s.db.Model(&phone).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update phone blocklist: %w", err)
+	}
+
+	s.log.Infof("Phone %s blocked=%t", phone.Number, blocked)
+	return nil
+}
+
+// ListBlockedPhones returns all blocked phones
+func (s *PhoneService) ListBlockedPhones() ([]models.PhoneNumber, error) {
+	var phones []models.PhoneNumber
+	if err := s.db.Where("blocked = ?", true).Order("blocked_at DESC").Find(&phones).Error; err != nil {
+		return nil, fmt.Errorf("failed to list blocked phones: %w", err)
+	}
+	return phones, nil
+}
+
 // GetActivePhones gets all active phones for checking
 func (s *PhoneService) GetActivePhones() ([]models.PhoneNumber, error) {
 	var phones []models.PhoneNumber
-	if err := s.db.Where("is_active = ?", true).Find(&phones).Error; err != nil {
+	if err := s.db.Where("is_active = ? AND blocked = ?", true, false).Find(&phones).Error; err != nil {
 		return nil, fmt.Errorf("failed to get active phones: %w", err)
 	}
 	return phones, nil
 }
 
-// GetPhoneStats gets phone statistics
-func (s *PhoneService) GetPhoneStats() (map[string]interface{}, error) {
+// GetActivePhonesForSchedule returns active phones from schedule's explicit list,
+// or all active phones if the schedule has no explicit list
+func (s *PhoneService) GetActivePhonesForSchedule(scheduleID uint) ([]models.PhoneNumber, error) {
+	var memberCount int64
+	if err := s.db.Model(&models.SchedulePhone{}).Where("schedule_id = ?", scheduleID).Count(&memberCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count schedule phones: %w", err)
+	}
+
+	if memberCount == 0 {
+		return s.GetActivePhones()
+	}
+
+	var phones []models.PhoneNumber
+	err := s.db.Where("is_active = ? AND blocked = ?", true, false).
+		Where("id IN (?)", s.db.Model(&models.SchedulePhone{}).Select("phone_number_id").Where("schedule_id = ?", scheduleID)).
+		Find(&phones).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule phones: %w", err)
+	}
+
+	return phones, nil
+}
+
+// ScheduleIncludesPhone reports whether schedule checks the phone.
+// Schedules without explicit phone list check all active phones.
+func (s *PhoneService) ScheduleIncludesPhone(scheduleID, phoneID uint) (bool, error) {
+	var memberCount int64
+	if err := s.db.Model(&models.SchedulePhone{}).Where("schedule_id = ?", scheduleID).Count(&memberCount).Error; err != nil {
+		return false, fmt.Errorf("failed to count schedule phones: %w", err)
+	}
+
+	if memberCount == 0 {
+		return true, nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.SchedulePhone{}).
+		Where("schedule_id = ? AND phone_number_id = ?", scheduleID, phoneID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check schedule phone: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// GetPhoneStats gets phone statistics, limited to phones created by ownerID unless it is 0
+func (s *PhoneService) GetPhoneStats(ownerID uint) (map[string]interface{}, error) {
+	phones := func() *gorm.DB {
+		return ownedPhones(s.db.Model(&models.PhoneNumber{}), ownerID)
+	}
+
 	var totalPhones int64
 	var activePhones int64
 	var spamPhones int64
 	var checkedPhones int64
 
 	// Total phones
-	if err := s.db.Model(&models.PhoneNumber{}).Count(&totalPhones).Error; err != nil {
+	if err := phones().Count(&totalPhones).Error; err != nil {
 		return nil, fmt.Errorf("failed to count total phones: %w", err)
 	}
 
 	// Active phones
-	if err := s.db.Model(&models.PhoneNumber{}).Where("is_active = ?", true).Count(&activePhones).Error; err != nil {
+	if err := phones().Where("is_active = ?", true).Count(&activePhones).Error; err != nil {
 		return nil, fmt.Errorf("failed to count active phones: %w", err)
 	}
 
+	// Blocked phones
+	var blockedPhones int64
+	if err := phones().Where("blocked = ?", true).Count(&blockedPhones).Error; err != nil {
+		return nil, fmt.Errorf("failed to count blocked phones: %w", err)
+	}
+
 	// Phones with at least one check
-	if err := s.db.Model(&models.PhoneNumber{}).
+	if err := phones().
 		Joins("JOIN check_results ON check_results.phone_number_id = phone_numbers.id").
 		Distinct("phone_numbers.id").
 		Count(&checkedPhones).Error; err != nil {
 		return nil, fmt.Errorf("failed to count checked phones: %w", err)
 	}
 
+	// Realtime-created phones without results are lookups, not unchecked inventory
+	var realtimePhones, uncheckedRealtimePhones int64
+	if err := phones().
+		Where(realtimePhonesCondition, realtimePhonesArgs...).
+		Count(&realtimePhones).Error; err != nil {
+		return nil, fmt.Errorf("failed to count realtime phones: %w", err)
+	}
+	if err := phones().
+		Where(realtimePhonesCondition, realtimePhonesArgs...).
+		Where("NOT EXISTS (SELECT 1 FROM check_results WHERE check_results.phone_number_id = phone_numbers.id)").
+		Count(&uncheckedRealtimePhones).Error; err != nil {
+		return nil, fmt.Errorf("failed to count unchecked realtime phones: %w", err)
+	}
+
+	// Raw queries below are limited to owner's phones the same way
+	ownerFilter := ""
+	var ownerArgs []interface{}
+	if ownerID > 0 {
+		ownerFilter = " AND phone_numbers.created_by = ?"
+		ownerArgs = append(ownerArgs, ownerID)
+	}
+
 	// Phones marked as spam (at least one service detected spam in latest check)
 	query := `
 		SELECT COUNT(DISTINCT phone_numbers.id)
@@ -507,23 +777,56 @@ func (s *PhoneService) GetPhoneStats() (map[string]interface{}, error) {
 		AND cr1.id IN (
 			SELECT MAX(cr2.id)
 			FROM check_results cr2
-			WHERE cr2.phone_number_id = cr1.phone_number_id
+			WHERE cr2.phone_number_id = cr1.phone_number_id AND cr2.source <> 'import' AND cr2.status <> 'error'
 			GROUP BY cr2.service_id
 		)
 		AND phone_numbers.deleted_at IS NULL
 	`
 
-	if err := s.db.Raw(query).Scan(&spamPhones).Error; err != nil {
+	if err := s.db.Raw(query+ownerFilter, ownerArgs...).Scan(&spamPhones).Error; err != nil {
 		return nil, fmt.Errorf("failed to count spam phones: %w", err)
 	}
 
+	// Phones without spam whose latest check of some service was inconclusive
+	var inconclusivePhones int64
+	inconclusiveQuery := `
+		SELECT COUNT(DISTINCT phone_numbers.id)
+		FROM phone_numbers
+		JOIN check_results cr1 ON cr1.phone_number_id = phone_numbers.id
+		WHERE cr1.inconclusive = true
+		AND cr1.id IN (
+			SELECT MAX(cr2.id)
+			FROM check_results cr2
+			WHERE cr2.phone_number_id = cr1.phone_number_id AND cr2.source <> 'import' AND cr2.status <> 'error'
+			GROUP BY cr2.service_id
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM check_results cr3
+			WHERE cr3.phone_number_id = phone_numbers.id AND cr3.is_spam = true
+			AND cr3.id IN (
+				SELECT MAX(cr4.id)
+				FROM check_results cr4
+				WHERE cr4.phone_number_id = cr3.phone_number_id AND cr4.source <> 'import' AND cr4.status <> 'error'
+				GROUP BY cr4.service_id
+			)
+		)
+		AND phone_numbers.deleted_at IS NULL
+	`
+
+	if err := s.db.Raw(inconclusiveQuery+ownerFilter, ownerArgs...).Scan(&inconclusivePhones).Error; err != nil {
+		return nil, fmt.Errorf("failed to count inconclusive phones: %w", err)
+	}
+
 	return map[string]interface{}{
-		"total_phones":     totalPhones,
-		"active_phones":    activePhones,
-		"checked_phones":   checkedPhones,
-		"spam_phones":      spamPhones,
-		"clean_phones":     checkedPhones - spamPhones,
-		"unchecked_phones": totalPhones - checkedPhones,
+		"total_phones":        totalPhones,
+		"active_phones":       activePhones,
+		"blocked_phones":      blockedPhones,
+		"checked_phones":      checkedPhones,
+		"spam_phones":         spamPhones,
+		"inconclusive_phones": inconclusivePhones,
+		"clean_phones":        checkedPhones - spamPhones - inconclusivePhones,
+		"unchecked_phones":    totalPhones - checkedPhones - uncheckedRealtimePhones,
+		"realtime_phones":     realtimePhones,
 	}, nil
 }
 
@@ -567,7 +870,7 @@ func (s *PhoneService) GetPhonesWithLatestResults(phoneIDs []uint) ([]models.Pho
 	var results []models.CheckResult
 	subQuery := s.db.Model(&models.CheckResult{}).
 		Select("phone_number_id, service_id, MAX(id) as max_id").
-		Where("phone_number_id IN ?", phoneIDs).
+		Where("phone_number_id IN ? AND source <> ? AND status <> ?", phoneIDs, models.CheckSourceImport, models.SpamStatusError).
 		Group("phone_number_id, service_id")
 
 	err := s.db.
@@ -580,6 +883,7 @@ func (s *PhoneService) GetPhonesWithLatestResults(phoneIDs []uint) ([]models.Pho
 		s.log.Errorf("Failed to load check results: %v", err)
 		return phones, nil
 	}
+	attachResultSources(s.db, results)
 
 	// Assign results to phones
 	for _, result := range results {
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxPhoneTimelineDays limits range of a phone timeline
+const MaxPhoneTimelineDays = 366
+
+// PhoneTimelineCounts counts check results by status
+type PhoneTimelineCounts struct {
+	Spam         int `json:"spam"`
+	Clean        int `json:"clean"`
+	Inconclusive int `json:"inconclusive"`
+	Suspected    int `json:"suspected"`
+	Errors       int `json:"errors"`
+}
+
+func (c *PhoneTimelineCounts) add(status string) {
+	switch status {
+	case models.SpamStatusSpam:
+		c.Spam++
+	case models.SpamStatusClean:
+		c.Clean++
+	case models.SpamStatusInconclusive:
+		c.Inconclusive++
+	case models.SpamStatusSuspected:
+		c.Suspected++
+	case models.SpamStatusError:
+		c.Errors++
+	}
+}
+
+// PhoneTimelineService is results of one spam service during a day
+type PhoneTimelineService struct {
+	ServiceID   uint   `json:"service_id"`
+	ServiceName string `json:"service_name"`
+	PhoneTimelineCounts
+}
+
+// PhoneTimelineDay is results of a phone during a day, days without checks have zero counts
+type PhoneTimelineDay struct {
+	Date string `json:"date"` // YYYY-MM-DD in server time zone
+	PhoneTimelineCounts
+	Services []PhoneTimelineService `json:"services"`
+}
+
+// PhoneTimeline is check results of a phone bucketed by day
+type PhoneTimeline struct {
+	PhoneID     uint               `json:"phone_id"`
+	PhoneNumber string             `json:"phone_number"`
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	Days        []PhoneTimelineDay `json:"days"`
+}
+
+// GetPhoneTimeline returns results of a phone between the days of from and to, both inclusive,
+// with per-day counts by status for each spam service. Every day of the range is listed.
+func (s *StatisticsService) GetPhoneTimeline(phoneID uint, from, to time.Time) (*PhoneTimeline, error) {
+	from = startOfDay(from)
+	to = startOfDay(to)
+	if from.After(to) {
+		return nil, errors.New("from must not be after to")
+	}
+	days := int(to.Sub(from).Hours()/24+0.5) + 1
+	if days > MaxPhoneTimelineDays {
+		return nil, fmt.Errorf("timeline range must not exceed %d days", MaxPhoneTimelineDays)
+	}
+
+	var phone models.PhoneNumber
+	if err := s.db.Select("id", "number").First(&phone, phoneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("phone number not found")
+		}
+		return nil, fmt.Errorf("failed to get phone number: %w", err)
+	}
+
+	var results []models.CheckResult
+	if err := s.db.Select("service_id, is_spam, inconclusive, status, checked_at").
+		Where("phone_number_id = ? AND checked_at >= ? AND checked_at < ?", phoneID, from, to.AddDate(0, 0, 1)).
+		Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get check results: %w", err)
+	}
+
+	serviceNames := make(map[uint]string)
+	var spamServices []models.SpamService
+	if err := s.db.Select("id, name").Find(&spamServices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	for _, service := range spamServices {
+		serviceNames[service.ID] = service.Name
+	}
+
+	timeline := &PhoneTimeline{
+		PhoneID:     phone.ID,
+		PhoneNumber: phone.Number,
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		Days:        make([]PhoneTimelineDay, 0, days),
+	}
+	dayIndex := make(map[string]int, days)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		dayIndex[date] = len(timeline.Days)
+		timeline.Days = append(timeline.Days, PhoneTimelineDay{
+			Date:     date,
+			Services: []PhoneTimelineService{},
+		})
+	}
+
+	perService := make(map[string]map[uint]*PhoneTimelineService)
+	for i := range results {
+		result := &results[i]
+		date := result.CheckedAt.In(time.Local).Format("2006-01-02")
+		index, ok := dayIndex[date]
+		if !ok {
+			continue
+		}
+		status := resultStatusOf(result)
+		timeline.Days[index].add(status)
+
+		if perService[date] == nil {
+			perService[date] = make(map[uint]*PhoneTimelineService)
+		}
+		counts, exists := perService[date][result.ServiceID]
+		if !exists {
+			name, known := serviceNames[result.ServiceID]
+			if !known {
+				name = fmt.Sprintf("service %d", result.ServiceID)
+			}
+			counts = &PhoneTimelineService{ServiceID: result.ServiceID, ServiceName: name}
+			perService[date][result.ServiceID] = counts
+		}
+		counts.add(status)
+	}
+
+	for date, services := range perService {
+		day := &timeline.Days[dayIndex[date]]
+		for _, counts := range services {
+			day.Services = append(day.Services, *counts)
+		}
+		sort.Slice(day.Services, func(i, j int) bool {
+			return day.Services[i].ServiceName < day.Services[j].ServiceName
+		})
+	}
+
+	return timeline, nil
+}
+
+// startOfDay returns midnight of the day of t in server time zone
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"spam-checker/internal/config"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// portProbeTimeout limits connection attempt when probing ports on a remote Docker host
+const portProbeTimeout = 300 * time.Millisecond
+
+// portSlotLeaseTTL keeps ports allocated by an instance off other instances until the gateway
+// is saved with them, an allocation that was never saved is freed once it expires
+const portSlotLeaseTTL = 15 * time.Minute
+
+// portSlotLease names lease of ports slot identified by its VNC port on Docker host
+func portSlotLease(dockerHost string, vncPort int) string {
+	return fmt.Sprintf("ports:%s:%d", dockerHost, vncPort)
+}
+
+// dockerPortConflictPattern matches host port in Docker bind errors like
+// "Bind for 0.0.0.0:6081 failed: port is already allocated" or
+// "listen tcp4 0.0.0.0:5555: bind: address already in use"
+var dockerPortConflictPattern = regexp.MustCompile(`(?:Bind for \S*:(\d+) failed: port is already allocated|listen tcp\d? \S*:(\d+): bind: address already in use)`)
+
+// GatewayPorts represents host ports bound by a Docker gateway container
+type GatewayPorts struct {
+	DockerHost string `json:"docker_host"`
+	VNCPort    int    `json:"vnc_port"`
+	ADBPort1   int    `json:"adb_port1"` // Emulator console
+	ADBPort2   int    `json:"adb_port2"` // ADB
+}
+
+// gatewayPorts returns ports of gateway with its Docker host named
+func (pm *PortManager) gatewayPorts(gateway *models.ADBGateway) GatewayPorts {
+	return GatewayPorts{
+		DockerHost: pm.hostLabel(gateway.DockerHost),
+		VNCPort:    gateway.VNCPort,
+		ADBPort1:   gateway.ADBPort1,
+		ADBPort2:   gateway.ADBPort2,
+	}
+}
+
+// PortAssignment represents ports held by a gateway
+type PortAssignment struct {
+	GatewayID uint   `json:"gateway_id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	GatewayPorts
+	InRange bool `json:"in_range"` // False for ports assigned before the ranges were moved
+}
+
+// PortReservation represents a port allocated for a gateway that is not saved yet
+type PortReservation struct {
+	DockerHost string `json:"docker_host"`
+	Port       int    `json:"port"`
+}
+
+// PortAssignments represents configured port ranges and ports currently in use by gateways
+type PortAssignments struct {
+	VNCRange  string            `json:"vnc_range"`
+	ADBRange  string            `json:"adb_range"`
+	RangeSize int               `json:"range_size"`
+	Gateways  []PortAssignment  `json:"gateways"`
+	Pending   []PortReservation `json:"pending"`
+}
+
+// hostPort is a port on a Docker host, empty host is the default one
+type hostPort struct {
+	host string
+	port int
+}
+
+// PortManager manages port allocation for containers. Ports are tracked per Docker host,
+// so gateways on different hosts may use the same ports.
+// Ports of existing gateways are reloaded from the database on every allocation, and
+// free candidates are probed on the Docker host so ports owned by other processes are skipped.
+type PortManager struct {
+	mu          sync.Mutex
+	db          *gorm.DB
+	usedPorts   map[hostPort]bool // Ports saved on gateways plus pending
+	pending     map[hostPort]bool // Allocated ports not yet saved on a gateway
+	baseVNC     int
+	baseADB     int
+	rangeSize   int // Number of gateway slots, slot N uses VNC baseVNC+N and ADB baseADB+2N, baseADB+2N+1
+	defaultHost string
+	probeHosts  bool
+	probes      map[string]func(port int) bool // Port probe of each Docker host, created on first allocation
+	log         *logrus.Entry
+}
+
+var (
+	portManagerOnce   sync.Once
+	portManagerShared *PortManager
+)
+
+// sharedPortManager returns port manager shared by all ADBService instances,
+// so concurrent gateway creations never receive the same ports
+func sharedPortManager(db *gorm.DB, cfg *config.Config) *PortManager {
+	portManagerOnce.Do(func() {
+		portManagerShared = NewPortManager(db, cfg)
+	})
+	return portManagerShared
+}
+
+func NewPortManager(db *gorm.DB, cfg *config.Config) *PortManager {
+	pm := &PortManager{
+		db:        db,
+		usedPorts: make(map[hostPort]bool),
+		pending:   make(map[hostPort]bool),
+		baseVNC:   6080,
+		baseADB:   5554,
+		rangeSize: 100,
+		probes:    make(map[string]func(port int) bool),
+		log:       logger.WithField("service", "PortManager"),
+	}
+	if cfg != nil && cfg.Docker.PortRangeSize > 0 {
+		pm.baseVNC = cfg.Docker.VNCBasePort
+		pm.baseADB = cfg.Docker.ADBBasePort
+		pm.rangeSize = cfg.Docker.PortRangeSize
+	}
+
+	if cfg != nil {
+		pm.defaultHost = cfg.Docker.Host
+	}
+
+	// Mock Docker client binds nothing, probing ports would only slow development down
+	pm.probeHosts = cfg == nil || !cfg.App.DevMode
+
+	pm.loadGatewayPorts()
+	return pm
+}
+
+// hostPortProbe returns check of whether port is taken on Docker host.
+// On local host the port is bound briefly, on remote host a connection is attempted.
+func hostPortProbe(dockerHost string) func(port int) bool {
+	host := dockerHostname(dockerHost)
+
+	if host == "" || host == "localhost" || host == "127.0.0.1" || host == "::1" {
+		return func(port int) bool {
+			listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+			if err != nil {
+				return true
+			}
+			listener.Close()
+			return false
+		}
+	}
+
+	return func(port int) bool {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), portProbeTimeout)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+}
+
+// dockerHostname extracts hostname from DOCKER_HOST value like tcp://host:2375 or host,
+// empty for unix socket
+func dockerHostname(dockerHost string) string {
+	if strings.HasPrefix(dockerHost, "unix://") || strings.HasPrefix(dockerHost, "npipe://") {
+		return ""
+	}
+	if !strings.Contains(dockerHost, "://") {
+		dockerHost = "tcp://" + dockerHost
+	}
+	parsed, err := url.Parse(dockerHost)
+	if err != nil {
+		return ""
+	}
+	return parsed.Hostname()
+}
+
+// loadGatewayPorts rebuilds used ports from gateways and pending allocations.
+// Ports of existing gateways are honored even if they are outside configured ranges.
+// Must be called with mu held or before sharing.
+func (pm *PortManager) loadGatewayPorts() {
+	if pm.db == nil {
+		return
+	}
+
+	var gateways []models.ADBGateway
+	if err := pm.db.Select("docker_host, vnc_port, adb_port1, adb_port2").Find(&gateways).Error; err != nil {
+		// Keep previous state rather than handing out ports of unknown gateways
+		pm.log.Warnf("Failed to load gateway ports: %v", err)
+		return
+	}
+
+	used := make(map[hostPort]bool, len(gateways)*3+len(pm.pending))
+	for _, gw := range gateways {
+		for _, port := range []int{gw.VNCPort, gw.ADBPort1, gw.ADBPort2} {
+			if port > 0 {
+				key := hostPort{host: gw.DockerHost, port: port}
+				used[key] = true
+				// Saved on a gateway, database tracks it from now on
+				if pm.pending[key] && port == gw.VNCPort {
+					pm.releaseSlotLease(gw.DockerHost, port)
+				}
+				delete(pm.pending, key)
+			}
+		}
+	}
+	for key := range pm.pending {
+		used[key] = true
+	}
+	pm.usedPorts = used
+}
+
+// AllocatePorts reserves ports for gateway on Docker host, empty host is the default one
+func (pm *PortManager) AllocatePorts(dockerHost string, gatewayID uint) (vncPort, adbPort1, adbPort2 int, err error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	// Pick up gateways saved by another instance or before restart
+	pm.loadGatewayPorts()
+
+	// Start from slot derived from gateway ID and wrap around the range
+	start := int(gatewayID % uint(pm.rangeSize))
+	busyOnHost := 0
+
+	// Find available ports
+	for i := 0; i < pm.rangeSize; i++ {
+		slot := (start + i) % pm.rangeSize
+		vncPort = pm.baseVNC + slot
+		adbPort1 = pm.baseADB + slot*2
+		adbPort2 = adbPort1 + 1
+
+		if pm.usedPorts[hostPort{dockerHost, vncPort}] || pm.usedPorts[hostPort{dockerHost, adbPort1}] || pm.usedPorts[hostPort{dockerHost, adbPort2}] {
+			continue
+		}
+
+		if port := pm.firstPortInUse(dockerHost, vncPort, adbPort1, adbPort2); port > 0 {
+			pm.log.Warnf("Port %d is in use on Docker host %s by another process, skipping", port, pm.hostLabel(dockerHost))
+			busyOnHost++
+			continue
+		}
+
+		// Another instance may be creating a gateway on this slot right now
+		if pm.db != nil {
+			acquired, err := acquireLease(pm.db, portSlotLease(dockerHost, vncPort), instanceID, portSlotLeaseTTL)
+			if err != nil {
+				return 0, 0, 0, fmt.Errorf("failed to reserve ports on Docker host %s: %w", pm.hostLabel(dockerHost), err)
+			}
+			if !acquired {
+				pm.log.Debugf("Ports slot %d on Docker host %s is being allocated by another instance, skipping", vncPort, pm.hostLabel(dockerHost))
+				continue
+			}
+		}
+
+		for _, port := range []int{vncPort, adbPort1, adbPort2} {
+			pm.usedPorts[hostPort{dockerHost, port}] = true
+			pm.pending[hostPort{dockerHost, port}] = true
+		}
+		return vncPort, adbPort1, adbPort2, nil
+	}
+
+	return 0, 0, 0, fmt.Errorf("no available ports on Docker host %s: VNC range %d-%d and ADB range %d-%d are exhausted (%d slots taken by other processes on Docker host)",
+		pm.hostLabel(dockerHost), pm.baseVNC, pm.baseVNC+pm.rangeSize-1, pm.baseADB, pm.baseADB+pm.rangeSize*2-1, busyOnHost)
+}
+
+// Assignments returns ports of all Docker gateways along with configured ranges
+func (pm *PortManager) Assignments() (*PortAssignments, error) {
+	var gateways []models.ADBGateway
+	if err := pm.db.Where("is_docker = ?", true).Order("docker_host, vnc_port").Find(&gateways).Error; err != nil {
+		return nil, fmt.Errorf("failed to load gateways: %w", err)
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	result := &PortAssignments{
+		VNCRange:  fmt.Sprintf("%d-%d", pm.baseVNC, pm.baseVNC+pm.rangeSize-1),
+		ADBRange:  fmt.Sprintf("%d-%d", pm.baseADB, pm.baseADB+pm.rangeSize*2-1),
+		RangeSize: pm.rangeSize,
+		Gateways:  make([]PortAssignment, 0, len(gateways)),
+		Pending:   make([]PortReservation, 0, len(pm.pending)),
+	}
+	for i := range gateways {
+		gw := &gateways[i]
+		result.Gateways = append(result.Gateways, PortAssignment{
+			GatewayID:    gw.ID,
+			Name:         gw.Name,
+			Status:       gw.Status,
+			GatewayPorts: pm.gatewayPorts(gw),
+			InRange:      pm.inRange(gw.VNCPort, gw.ADBPort1, gw.ADBPort2),
+		})
+	}
+	for key := range pm.pending {
+		result.Pending = append(result.Pending, PortReservation{DockerHost: pm.hostLabel(key.host), Port: key.port})
+	}
+	sort.Slice(result.Pending, func(i, j int) bool {
+		if result.Pending[i].DockerHost != result.Pending[j].DockerHost {
+			return result.Pending[i].DockerHost < result.Pending[j].DockerHost
+		}
+		return result.Pending[i].Port < result.Pending[j].Port
+	})
+
+	return result, nil
+}
+
+// inRange checks that gateway ports belong to configured ranges
+func (pm *PortManager) inRange(vncPort, adbPort1, adbPort2 int) bool {
+	return vncPort >= pm.baseVNC && vncPort < pm.baseVNC+pm.rangeSize &&
+		adbPort1 >= pm.baseADB && adbPort2 < pm.baseADB+pm.rangeSize*2
+}
+
+// portConflictError explains Docker failure to bind a host port of gateway container,
+// naming the port and the setting that moves its range. Other errors are returned as is.
+func (pm *PortManager) portConflictError(gateway *models.ADBGateway, err error) error {
+	match := dockerPortConflictPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+
+	portText := match[1]
+	if portText == "" {
+		portText = match[2]
+	}
+	port, _ := strconv.Atoi(portText)
+
+	var kind, setting string
+	switch port {
+	case gateway.VNCPort:
+		kind, setting = "VNC", "DOCKER_VNC_BASE_PORT"
+	case gateway.ADBPort1, gateway.ADBPort2:
+		kind, setting = "ADB", "DOCKER_ADB_BASE_PORT"
+	default:
+		return fmt.Errorf("port %d is already in use on Docker host %s, free it or move port ranges with DOCKER_VNC_BASE_PORT and DOCKER_ADB_BASE_PORT: %w",
+			port, pm.hostLabel(gateway.DockerHost), err)
+	}
+
+	return fmt.Errorf("%s port %d is already in use on Docker host %s by another service, free it or move the range with %s: %w",
+		kind, port, pm.hostLabel(gateway.DockerHost), setting, err)
+}
+
+// hostLabel names Docker host in messages
+func (pm *PortManager) hostLabel(dockerHost string) string {
+	if dockerHost == "" {
+		return pm.defaultHost
+	}
+	return dockerHost
+}
+
+// firstPortInUse returns first of ports taken on Docker host, 0 if all are free
+func (pm *PortManager) firstPortInUse(dockerHost string, ports ...int) int {
+	if !pm.probeHosts {
+		return 0
+	}
+
+	portInUse, ok := pm.probes[dockerHost]
+	if !ok {
+		portInUse = hostPortProbe(pm.hostLabel(dockerHost))
+		pm.probes[dockerHost] = portInUse
+	}
+	for _, port := range ports {
+		if portInUse(port) {
+			return port
+		}
+	}
+	return 0
+}
+
+// ReleasePorts frees ports of gateway on Docker host
+func (pm *PortManager) ReleasePorts(dockerHost string, vncPort, adbPort1, adbPort2 int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, port := range []int{vncPort, adbPort1, adbPort2} {
+		delete(pm.usedPorts, hostPort{dockerHost, port})
+		delete(pm.pending, hostPort{dockerHost, port})
+	}
+	pm.releaseSlotLease(dockerHost, vncPort)
+}
+
+// releaseSlotLease lets other instances allocate ports slot again, must be called with mu held
+func (pm *PortManager) releaseSlotLease(dockerHost string, vncPort int) {
+	if pm.db == nil || vncPort <= 0 {
+		return
+	}
+	if err := releaseLease(pm.db, portSlotLease(dockerHost, vncPort), instanceID); err != nil {
+		pm.log.Warnf("%v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// publicStatusTTL defines how long public status snapshot is served before recomputing
+const publicStatusTTL = time.Minute
+
+// RunSummary represents outcome of a completed scheduler run
+type RunSummary struct {
+	CheckType           string    `json:"-"`
+	CompletedAt         time.Time `json:"completed_at"`
+	PhonesChecked       int       `json:"phones_checked"`
+	SpamFound           int       `json:"spam_found"`
+	PendingVerification int64     `json:"pending_verification"`  // Spam flags waiting for re-check, not counted in SpamFound
+	ChecksExcluded      int       `json:"checks_excluded"`       // Service checks skipped because the phone is excluded from the service
+	PersistedAfterRetry int       `json:"persisted_after_retry"` // Results saved once the database came back
+	Journaled           int       `json:"journaled"`             // Results kept in the journal until the database is back
+	Sampled             bool      `json:"sampled"`               // Run checked a random sample of phones
+}
+
+// SchedulerStatusSource provides scheduler state for public status
+type SchedulerStatusSource interface {
+	IsRunning() bool
+	LastCompletedRun() (RunSummary, bool)
+	LeaderStatus() LeaderStatus
+}
+
+// PublicStatus represents non-sensitive aggregates shown without authentication
+type PublicStatus struct {
+	SchedulerRunning bool        `json:"scheduler_running"`
+	LastRun          *RunSummary `json:"last_run,omitempty"`
+	OnlineGateways   int64       `json:"online_gateways"`
+	Checks24h        int64       `json:"checks_24h"`
+	SpamRate24h      float64     `json:"spam_rate_24h"`
+	GeneratedAt      time.Time   `json:"generated_at"`
+}
+
+// PublicStatusSnapshot is a rendered public status with cache validators
+type PublicStatusSnapshot struct {
+	Body         []byte
+	ETag         string
+	LastModified time.Time
+	ExpiresAt    time.Time
+}
+
+// PublicStatusService serves public status from a snapshot recomputed at most once per TTL
+type PublicStatusService struct {
+	db        *gorm.DB
+	scheduler SchedulerStatusSource
+	log       *logrus.Entry
+
+	mu       sync.Mutex
+	snapshot *PublicStatusSnapshot
+}
+
+func NewPublicStatusService(db *gorm.DB, scheduler SchedulerStatusSource) *PublicStatusService {
+	return &PublicStatusService{
+		db:        db,
+		scheduler: scheduler,
+		log:       logger.WithField("service", "PublicStatusService"),
+	}
+}
+
+// IsEnabled reports whether public status endpoint is enabled in settings
+func (s *PublicStatusService) IsEnabled() bool {
+	return NewSettingsService(s.db).GetCachedBool("public_status_enabled", true)
+}
+
+// GetSnapshot returns cached snapshot, recomputing it when expired.
+// Concurrent callers wait for a single recomputation.
+func (s *PublicStatusService) GetSnapshot() (*PublicStatusSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.snapshot != nil && now.Before(s.snapshot.ExpiresAt) {
+		return s.snapshot, nil
+	}
+
+	status, err := s.compute(now)
+	if err != nil {
+		// Stale data is better than an error on a status page
+		if s.snapshot != nil {
+			s.log.Warnf("Failed to refresh public status, serving stale snapshot: %v", err)
+			return s.snapshot, nil
+		}
+		return nil, err
+	}
+
+	body, err := json.Marshal(status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public status: %w", err)
+	}
+
+	// Validators only change when the content changes, not on every recomputation
+	content := *status
+	content.GeneratedAt = time.Time{}
+	contentJSON, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public status: %w", err)
+	}
+	hash := sha256.Sum256(contentJSON)
+	etag := `"` + hex.EncodeToString(hash[:8]) + `"`
+	lastModified := now
+	if s.snapshot != nil && s.snapshot.ETag == etag {
+		lastModified = s.snapshot.LastModified
+	}
+
+	s.snapshot = &PublicStatusSnapshot{
+		Body:         body,
+		ETag:         etag,
+		LastModified: lastModified,
+		ExpiresAt:    now.Add(publicStatusTTL),
+	}
+	return s.snapshot, nil
+}
+
+// compute collects public status aggregates
+func (s *PublicStatusService) compute(now time.Time) (*PublicStatus, error) {
+	// Jobs run on the elected instance, which may be another one than this
+	status := &PublicStatus{
+		SchedulerRunning: s.scheduler.IsRunning() || s.scheduler.LeaderStatus().Leader != "",
+		GeneratedAt:      now.UTC(),
+	}
+
+	if run, ok := s.scheduler.LastCompletedRun(); ok {
+		status.LastRun = &run
+	}
+
+	if err := s.db.Model(&models.ADBGateway{}).Where("status = ?", "online").Count(&status.OnlineGateways).Error; err != nil {
+		return nil, fmt.Errorf("failed to count online gateways: %w", err)
+	}
+
+	var counts struct {
+		Total int64
+		Spam  int64
+	}
+	if err := s.db.Model(&models.CheckResult{}).
+		Select("COUNT(*) as total, COALESCE(SUM(CASE WHEN is_spam THEN 1 ELSE 0 END), 0) as spam").
+		Where("checked_at >= ? AND source <> ? AND status <> ?", now.Add(-24*time.Hour), models.CheckSourceImport, models.SpamStatusError).
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count recent checks: %w", err)
+	}
+
+	status.Checks24h = counts.Total
+	if counts.Total > 0 {
+		status.SpamRate24h = float64(counts.Spam) / float64(counts.Total) * 100
+	}
+
+	return status, nil
+}
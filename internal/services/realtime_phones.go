@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Realtime-created phones are swept after realtime_phone_ttl_hours, 0 keeps them forever
+const (
+	realtimePhoneTTLSettingKey = "realtime_phone_ttl_hours"
+	defaultRealtimePhoneTTL    = 168
+	maxRealtimePhoneTTLHours   = 8760
+)
+
+// realtimePhoneDescription is given to phones created by a realtime check of an unknown number
+const realtimePhoneDescription = "Realtime check"
+
+// realtimeSweepBatchSize bounds number of phones deleted in a single transaction
+const realtimeSweepBatchSize = 500
+
+// realtimePhonesCondition matches realtime-created phones nobody activated, they are hidden from
+// listings and unchecked inventory. Arguments are given by realtimePhonesArgs.
+const realtimePhonesCondition = "phone_numbers.origin = ? AND phone_numbers.is_active = ?"
+
+var realtimePhonesArgs = []interface{}{models.PhoneOriginRealtime, false}
+
+// excludeRealtimePhones hides realtime-created inactive phones from query
+func excludeRealtimePhones(query *gorm.DB) *gorm.DB {
+	return query.Where("NOT ("+realtimePhonesCondition+")", realtimePhonesArgs...)
+}
+
+// realtimeSweeperHolder holds phone leases while the sweeper deletes phones. It differs from
+// instanceID so a check running on this instance is not mistaken for the sweeper's own lease.
+var realtimeSweeperHolder = instanceID + "/realtime-sweeper"
+
+// realtimeSweepCandidates selects realtime-created phones older than cutoff that an operator has not
+// taken over: still inactive and not blocked, without campaign, edited description or schedule membership,
+// and not checked since cutoff. Phones with a live lock lease, i.e. a check in flight on any instance,
+// are skipped.
+func realtimeSweepCandidates(db *gorm.DB, cutoff, now time.Time) *gorm.DB {
+	recentResults := db.Model(&models.CheckResult{}).
+		Select("1").
+		Where("check_results.phone_number_id = phone_numbers.id AND check_results.checked_at >= ?", cutoff)
+	scheduled := db.Model(&models.SchedulePhone{}).
+		Select("1").
+		Where("schedule_phones.phone_number_id = phone_numbers.id")
+	leased := db.Model(&models.Lease{}).
+		Select("1").
+		Where("leases.name = ? || CAST(phone_numbers.id AS TEXT) AND leases.expires_at >= ?", phoneLockPrefix+":", now)
+
+	return db.Model(&models.PhoneNumber{}).
+		Where(realtimePhonesCondition, realtimePhonesArgs...).
+		Where("phone_numbers.blocked = ?", false).
+		Where("COALESCE(phone_numbers.campaign, '') = ''").
+		Where("phone_numbers.description = ?", realtimePhoneDescription).
+		Where("phone_numbers.created_at < ?", cutoff).
+		Where("NOT EXISTS (?)", recentResults).
+		Where("NOT EXISTS (?)", scheduled).
+		Where("NOT EXISTS (?)", leased)
+}
+
+// SweepRealtimePhones deletes realtime-created phones and their results older than
+// realtime_phone_ttl_hours. Returns number of deleted phones.
+func (s *PhoneService) SweepRealtimePhones(now time.Time) (int, error) {
+	ttlHours := NewSettingsService(s.db).GetCachedInt(realtimePhoneTTLSettingKey, defaultRealtimePhoneTTL)
+	if ttlHours <= 0 {
+		return 0, nil
+	}
+	cutoff := now.Add(-time.Duration(ttlHours) * time.Hour)
+
+	deleted := 0
+	for {
+		var ids []uint
+		if err := realtimeSweepCandidates(s.db, cutoff, now).
+			Order("phone_numbers.id").
+			Limit(realtimeSweepBatchSize).
+			Pluck("phone_numbers.id", &ids).Error; err != nil {
+			return deleted, fmt.Errorf("failed to find realtime phones: %w", err)
+		}
+		if len(ids) == 0 {
+			return deleted, nil
+		}
+
+		claimed, err := s.claimRealtimePhones(ids)
+		if len(claimed) > 0 {
+			err = errors.Join(err, s.db.Transaction(func(tx *gorm.DB) error {
+				return deletePhones(tx, claimed)
+			}))
+			s.releaseRealtimePhones(claimed)
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete realtime phones: %w", err)
+		}
+		deleted += len(claimed)
+
+		// Phones a check locked meanwhile stay for the next sweep
+		if len(claimed) == 0 || len(ids) < realtimeSweepBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// claimRealtimePhones takes lock leases of phones about to be swept, so no check starts on them
+// while they are deleted. Phones whose lease is held by a check are left out.
+func (s *PhoneService) claimRealtimePhones(ids []uint) ([]uint, error) {
+	claimed := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		acquired, err := acquireLease(s.db, lockLeaseName(phoneLockPrefix, id), realtimeSweeperHolder, lockLeaseTTL)
+		if err != nil {
+			return claimed, err
+		}
+		if acquired {
+			claimed = append(claimed, id)
+		}
+	}
+	return claimed, nil
+}
+
+// releaseRealtimePhones frees lock leases taken by claimRealtimePhones
+func (s *PhoneService) releaseRealtimePhones(ids []uint) {
+	for _, id := range ids {
+		if err := releaseLease(s.db, lockLeaseName(phoneLockPrefix, id), realtimeSweeperHolder); err != nil {
+			s.log.Warnf("%v, it expires in %s", err, lockLeaseTTL)
+		}
+	}
+}
+
+// BackfillPhoneOrigins marks phones created by realtime checks before origin was recorded
+func (s *PhoneService) BackfillPhoneOrigins() (int64, error) {
+	result := s.db.Model(&models.PhoneNumber{}).
+		Where("origin = ? OR origin IS NULL OR origin = ''", models.PhoneOriginManual).
+		Where("description = ? AND is_active = ? AND created_by = ?", realtimePhoneDescription, false, 1).
+		Update("origin", models.PhoneOriginRealtime)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to backfill phone origins: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"spam-checker/internal/models"
+)
+
+func TestSweepRealtimePhonesSkipsLockedPhones(t *testing.T) {
+	db := newTestDB(t)
+	service := NewPhoneService(db)
+	now := time.Now()
+
+	var admin models.User
+	if err := db.Where("role = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	createPhone := func(number string) models.PhoneNumber {
+		t.Helper()
+		phone := models.PhoneNumber{
+			Number:      number,
+			Description: realtimePhoneDescription,
+			Origin:      models.PhoneOriginRealtime,
+			CreatedBy:   admin.ID,
+		}
+		if err := db.Create(&phone).Error; err != nil {
+			t.Fatal(err)
+		}
+		// is_active defaults to true in the schema, so zero value is not inserted
+		if err := db.Model(&phone).UpdateColumns(map[string]interface{}{
+			"is_active":  false,
+			"created_at": now.Add(-200 * time.Hour),
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return phone
+	}
+
+	stale := createPhone("79000000001")
+	leased := createPhone("79000000002")
+	inFlight := createPhone("79000000003")
+	expired := createPhone("79000000004")
+	active := createPhone("79000000005")
+	blocked := createPhone("79000000006")
+
+	if err := db.Model(&active).Update("is_active", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&blocked).Update("blocked", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Check running on another instance
+	if ok, err := acquireLease(db, lockLeaseName(phoneLockPrefix, leased.ID), "other-instance", time.Hour); err != nil || !ok {
+		t.Fatalf("acquireLease() = %v, %v", ok, err)
+	}
+	// Check running on this instance
+	locks := newDistributedLocks(db, phoneLockPrefix)
+	release, ok, err := locks.TryAcquire(inFlight.ID)
+	if err != nil || !ok {
+		t.Fatalf("TryAcquire() = %v, %v", ok, err)
+	}
+	defer release()
+	// Crashed instance left its lease behind
+	if err := db.Create(&models.Lease{
+		Name:       lockLeaseName(phoneLockPrefix, expired.ID),
+		Holder:     "crashed-instance",
+		AcquiredAt: now.Add(-2 * time.Hour),
+		ExpiresAt:  now.Add(-time.Hour),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := service.SweepRealtimePhones(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Errorf("SweepRealtimePhones() deleted %d phones, want 2", deleted)
+	}
+
+	kept := map[uint]bool{}
+	var ids []uint
+	if err := db.Model(&models.PhoneNumber{}).Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		kept[id] = true
+	}
+	for name, phone := range map[string]models.PhoneNumber{
+		"leased": leased, "in flight": inFlight, "active": active, "blocked": blocked,
+	} {
+		if !kept[phone.ID] {
+			t.Errorf("%s phone was swept", name)
+		}
+	}
+	for name, phone := range map[string]models.PhoneNumber{"stale": stale, "expired lease": expired} {
+		if kept[phone.ID] {
+			t.Errorf("%s phone was kept", name)
+		}
+	}
+
+	var sweeperLeases int64
+	if err := db.Model(&models.Lease{}).Where("holder = ?", realtimeSweeperHolder).Count(&sweeperLeases).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sweeperLeases != 0 {
+		t.Errorf("sweeper left %d leases", sweeperLeases)
+	}
+}
+
+func TestSweepRealtimePhonesBlocksChecks(t *testing.T) {
+	db := newTestDB(t)
+
+	// While the sweeper holds a phone, checks on any instance cannot lock it
+	if ok, err := acquireLease(db, lockLeaseName(phoneLockPrefix, 42), realtimeSweeperHolder, lockLeaseTTL); err != nil || !ok {
+		t.Fatalf("acquireLease() = %v, %v", ok, err)
+	}
+	if _, ok, err := newDistributedLocks(db, phoneLockPrefix).TryAcquire(42); err != nil || ok {
+		t.Errorf("TryAcquire() = %v, %v on phone held by the sweeper", ok, err)
+	}
+}
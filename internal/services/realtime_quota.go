@@ -0,0 +1,295 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	realtimeQuotaDailySettingKey        = "realtime_quota_daily"
+	realtimeQuotaPerMinuteSettingKey    = "realtime_quota_per_minute"
+	realtimeQuotaCachedWeightSettingKey = "realtime_quota_cached_weight_percent"
+)
+
+// Realtime quota windows reported when a limit is exceeded
+const (
+	RealtimeQuotaWindowDaily     = "daily"
+	RealtimeQuotaWindowPerMinute = "per_minute"
+)
+
+// realtimeQuotaDayFormat is the layout of RealtimeQuotaUsage.Day
+const realtimeQuotaDayFormat = "2006-01-02"
+
+// validateRealtimeQuotaSetting checks realtime quota limits and cached result weight
+func validateRealtimeQuotaSetting(key string, value int) error {
+	switch key {
+	case realtimeQuotaDailySettingKey, realtimeQuotaPerMinuteSettingKey:
+		if value < 0 {
+			return fmt.Errorf("%s must not be negative", key)
+		}
+	case realtimeQuotaCachedWeightSettingKey:
+		if value < 0 || value > 100 {
+			return fmt.Errorf("%s must be between 0 and 100", key)
+		}
+	}
+	return nil
+}
+
+// RealtimeQuotaWindow is consumption of one quota window
+type RealtimeQuotaWindow struct {
+	Limit     int       `json:"limit"` // 0 means unlimited
+	Used      float64   `json:"used"`
+	Remaining float64   `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// RealtimeQuotaStatus is realtime check quota state of a user
+type RealtimeQuotaStatus struct {
+	UserID       uint                `json:"user_id"`
+	Daily        RealtimeQuotaWindow `json:"daily"`
+	PerMinute    RealtimeQuotaWindow `json:"per_minute"`
+	CachedWeight float64             `json:"cached_weight"` // Cost of cached result relative to a full check
+	Checks       int                 `json:"checks"`        // Full checks today
+	CachedChecks int                 `json:"cached_checks"` // Cached results today
+	Exceeded     string              `json:"exceeded,omitempty"`
+}
+
+// RealtimeQuotaReservation holds quota taken for a realtime check until its cost is known
+type RealtimeQuotaReservation struct {
+	userID uint
+	day    string
+	minute time.Time
+}
+
+// RealtimeQuotaService enforces daily and per-minute realtime check quotas of users. Consumption is
+// kept in the database and changed by conditional updates, so instances sharing it enforce one limit.
+type RealtimeQuotaService struct {
+	db  *gorm.DB
+	now func() time.Time
+	log *logrus.Entry
+}
+
+func NewRealtimeQuotaService(db *gorm.DB) *RealtimeQuotaService {
+	return &RealtimeQuotaService{
+		db:  db,
+		now: time.Now,
+		log: logger.WithField("service", "RealtimeQuotaService"),
+	}
+}
+
+// limits returns daily and per-minute limits of a user, user overrides win over settings
+func (s *RealtimeQuotaService) limits(userID uint) (int, int, float64) {
+	settings := NewSettingsService(s.db)
+	daily := max(settings.GetCachedInt(realtimeQuotaDailySettingKey, 1000), 0)
+	perMinute := max(settings.GetCachedInt(realtimeQuotaPerMinuteSettingKey, 20), 0)
+	weight := settings.GetCachedInt(realtimeQuotaCachedWeightSettingKey, 20)
+	if validateRealtimeQuotaSetting(realtimeQuotaCachedWeightSettingKey, weight) != nil {
+		weight = 20
+	}
+
+	var user models.User
+	if err := s.db.Select("id, realtime_daily_limit, realtime_per_minute_limit").First(&user, userID).Error; err == nil {
+		if user.RealtimeDailyLimit != nil {
+			daily = *user.RealtimeDailyLimit
+		}
+		if user.RealtimePerMinuteLimit != nil {
+			perMinute = *user.RealtimePerMinuteLimit
+		}
+	}
+
+	return daily, perMinute, float64(weight) / 100
+}
+
+// realtimeQuotaPeriod returns UTC day and minute of now
+func realtimeQuotaPeriod(now time.Time) (string, time.Time) {
+	now = now.UTC()
+	return now.Format(realtimeQuotaDayFormat), now.Truncate(time.Minute)
+}
+
+// usage returns consumption of a user during day, zero when nothing was used
+func (s *RealtimeQuotaService) usage(userID uint, day string) (*models.RealtimeQuotaUsage, error) {
+	usage := models.RealtimeQuotaUsage{UserID: userID, Day: day}
+	err := s.db.Where("user_id = ? AND day = ?", userID, day).First(&usage).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get realtime quota usage: %w", err)
+	}
+	return &usage, nil
+}
+
+// realtimeQuotaStatus describes usage during minute against limits
+func realtimeQuotaStatus(usage *models.RealtimeQuotaUsage, minute time.Time, daily, perMinute int, weight float64) *RealtimeQuotaStatus {
+	minuteUsed := 0.0
+	if usage.Minute != nil && usage.Minute.Equal(minute) {
+		minuteUsed = usage.MinuteUsed
+	}
+	dayStart, _ := time.Parse(realtimeQuotaDayFormat, usage.Day)
+	return &RealtimeQuotaStatus{
+		UserID:       usage.UserID,
+		Daily:        realtimeQuotaWindow(daily, usage.Used, dayStart.Add(24*time.Hour)),
+		PerMinute:    realtimeQuotaWindow(perMinute, minuteUsed, minute.Add(time.Minute)),
+		CachedWeight: weight,
+		Checks:       usage.Checks,
+		CachedChecks: usage.CachedChecks,
+	}
+}
+
+func realtimeQuotaWindow(limit int, used float64, resetAt time.Time) RealtimeQuotaWindow {
+	window := RealtimeQuotaWindow{
+		Limit:   limit,
+		Used:    math.Round(used*100) / 100,
+		ResetAt: resetAt,
+	}
+	if limit > 0 {
+		window.Remaining = math.Max(0, math.Round((float64(limit)-used)*100)/100)
+	}
+	return window
+}
+
+// Reserve takes a full check worth of quota before a realtime check. When a limit is
+// already reached it returns status with Exceeded set and no reservation. The full cost is
+// taken up front by one conditional update, so concurrent requests to any instance cannot
+// overshoot the limits.
+func (s *RealtimeQuotaService) Reserve(userID uint) (*RealtimeQuotaReservation, *RealtimeQuotaStatus, error) {
+	daily, perMinute, weight := s.limits(userID)
+	day, minute := realtimeQuotaPeriod(s.now())
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "day"}},
+		DoNothing: true,
+	}).Create(&models.RealtimeQuotaUsage{UserID: userID, Day: day}).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create realtime quota usage: %w", err)
+	}
+
+	// Every SET expression sees the row before the update, so minute_used restarts on a new minute
+	query := s.db.Model(&models.RealtimeQuotaUsage{}).Where("user_id = ? AND day = ?", userID, day)
+	if daily > 0 {
+		query = query.Where("used < ?", daily)
+	}
+	if perMinute > 0 {
+		query = query.Where("(minute IS NULL OR minute <> ? OR minute_used < ?)", minute, perMinute)
+	}
+	reserved := query.Updates(map[string]interface{}{
+		"used":        gorm.Expr("used + 1"),
+		"minute_used": gorm.Expr("CASE WHEN minute = ? THEN minute_used + 1 ELSE 1 END", minute),
+		"minute":      minute,
+		"updated_at":  s.now(),
+	})
+	if reserved.Error != nil {
+		return nil, nil, fmt.Errorf("failed to reserve realtime quota: %w", reserved.Error)
+	}
+
+	usage, err := s.usage(userID, day)
+	if err != nil {
+		return nil, nil, err
+	}
+	status := realtimeQuotaStatus(usage, minute, daily, perMinute, weight)
+	if reserved.RowsAffected == 0 {
+		status.Exceeded = RealtimeQuotaWindowPerMinute
+		if daily > 0 && usage.Used >= float64(daily) {
+			status.Exceeded = RealtimeQuotaWindowDaily
+		}
+		return nil, status, nil
+	}
+	return &RealtimeQuotaReservation{userID: userID, day: day, minute: minute}, status, nil
+}
+
+// Commit charges a reserved check: full cost when it hit gateways or APIs, cached weight
+// when it was answered from recent results or failed. The rest of the reservation is refunded.
+func (s *RealtimeQuotaService) Commit(reservation *RealtimeQuotaReservation, fullCheck bool) *RealtimeQuotaStatus {
+	daily, perMinute, weight := s.limits(reservation.userID)
+	refund := 1 - weight
+	checks, cachedChecks := 0, 1
+	if fullCheck {
+		refund = 0
+		checks, cachedChecks = 1, 0
+	}
+
+	// Usage reset since the reservation is left as is
+	if err := s.db.Model(&models.RealtimeQuotaUsage{}).
+		Where("user_id = ? AND day = ?", reservation.userID, reservation.day).
+		Updates(map[string]interface{}{
+			"used":          gorm.Expr("used - ?", refund),
+			"checks":        gorm.Expr("checks + ?", checks),
+			"cached_checks": gorm.Expr("cached_checks + ?", cachedChecks),
+			"minute_used":   gorm.Expr("CASE WHEN minute = ? THEN minute_used - ? ELSE minute_used END", reservation.minute, refund),
+			"updated_at":    s.now(),
+		}).Error; err != nil {
+		s.log.Errorf("Failed to save realtime quota usage of user %d: %v", reservation.userID, err)
+	}
+
+	day, minute := realtimeQuotaPeriod(s.now())
+	usage, err := s.usage(reservation.userID, day)
+	if err != nil {
+		s.log.Errorf("Failed to get realtime quota of user %d: %v", reservation.userID, err)
+		return nil
+	}
+	return realtimeQuotaStatus(usage, minute, daily, perMinute, weight)
+}
+
+// GetUsage returns realtime quota consumption of a user
+func (s *RealtimeQuotaService) GetUsage(userID uint) (*RealtimeQuotaStatus, error) {
+	if err := s.ensureUser(userID); err != nil {
+		return nil, err
+	}
+	daily, perMinute, weight := s.limits(userID)
+
+	day, minute := realtimeQuotaPeriod(s.now())
+	usage, err := s.usage(userID, day)
+	if err != nil {
+		return nil, err
+	}
+	return realtimeQuotaStatus(usage, minute, daily, perMinute, weight), nil
+}
+
+// Reset clears today's and current minute consumption of a user
+func (s *RealtimeQuotaService) Reset(userID uint) error {
+	if err := s.ensureUser(userID); err != nil {
+		return err
+	}
+
+	day, _ := realtimeQuotaPeriod(s.now())
+	if err := s.db.Where("user_id = ? AND day = ?", userID, day).
+		Delete(&models.RealtimeQuotaUsage{}).Error; err != nil {
+		return fmt.Errorf("failed to reset realtime quota usage: %w", err)
+	}
+
+	s.log.Infof("Realtime quota of user %d reset", userID)
+	return nil
+}
+
+// SetUserLimits sets daily and per-minute limit overrides of a user, nil restores settings defaults
+func (s *RealtimeQuotaService) SetUserLimits(userID uint, daily, perMinute *int) error {
+	if err := s.ensureUser(userID); err != nil {
+		return err
+	}
+	if daily != nil && *daily < 0 || perMinute != nil && *perMinute < 0 {
+		return errors.New("quota limits must not be negative")
+	}
+
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"realtime_daily_limit":      daily,
+		"realtime_per_minute_limit": perMinute,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update realtime quota limits: %w", err)
+	}
+	return nil
+}
+
+func (s *RealtimeQuotaService) ensureUser(userID uint) error {
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if count == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
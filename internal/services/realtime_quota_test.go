@@ -0,0 +1,150 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"spam-checker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// newReplicaQuotaServices returns quota services of two instances sharing db, both at time of clock
+func newReplicaQuotaServices(db *gorm.DB, clock *fakeClock) (*RealtimeQuotaService, *RealtimeQuotaService) {
+	a, b := NewRealtimeQuotaService(db), NewRealtimeQuotaService(db)
+	a.now, b.now = clock.Now, clock.Now
+	return a, b
+}
+
+func quotaTestUser(t *testing.T, db *gorm.DB, daily, perMinute int) uint {
+	t.Helper()
+	var admin models.User
+	if err := db.Where("role = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRealtimeQuotaService(db).SetUserLimits(admin.ID, &daily, &perMinute); err != nil {
+		t.Fatal(err)
+	}
+	return admin.ID
+}
+
+func TestRealtimeQuotaSharedAcrossInstances(t *testing.T) {
+	db := newTestDB(t)
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)}
+	a, b := newReplicaQuotaServices(db, clock)
+	userID := quotaTestUser(t, db, 5, 3)
+
+	reserve := func(service *RealtimeQuotaService) *RealtimeQuotaStatus {
+		t.Helper()
+		reservation, status, err := service.Reserve(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reservation != nil {
+			service.Commit(reservation, true)
+		}
+		return status
+	}
+
+	// Per-minute limit counts reservations of both instances
+	for i, service := range []*RealtimeQuotaService{a, b, a} {
+		if status := reserve(service); status.Exceeded != "" {
+			t.Fatalf("reservation %d exceeded %s", i, status.Exceeded)
+		}
+	}
+	if status := reserve(b); status.Exceeded != RealtimeQuotaWindowPerMinute {
+		t.Fatalf("fourth reservation in a minute exceeded %q, want per_minute", status.Exceeded)
+	}
+
+	// Next minute starts a new window, daily limit still counts both instances
+	clock.Advance(time.Minute)
+	for i, service := range []*RealtimeQuotaService{b, a} {
+		if status := reserve(service); status.Exceeded != "" {
+			t.Fatalf("reservation %d of next minute exceeded %s", i, status.Exceeded)
+		}
+	}
+	status := reserve(b)
+	if status.Exceeded != RealtimeQuotaWindowDaily {
+		t.Fatalf("sixth reservation of the day exceeded %q, want daily", status.Exceeded)
+	}
+	if status.Daily.Used != 5 || status.Checks != 5 || status.PerMinute.Used != 2 {
+		t.Errorf("status = %+v", status)
+	}
+
+	// New day resets the daily window
+	clock.Advance(24 * time.Hour)
+	if status := reserve(a); status.Exceeded != "" {
+		t.Errorf("first reservation of a new day exceeded %s", status.Exceeded)
+	}
+}
+
+func TestRealtimeQuotaCommitRefundsCachedChecks(t *testing.T) {
+	db := newTestDB(t)
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)}
+	a, b := newReplicaQuotaServices(db, clock)
+	userID := quotaTestUser(t, db, 10, 10)
+
+	reservation, status, err := a.Reserve(userID)
+	if err != nil || reservation == nil {
+		t.Fatalf("Reserve() = %v, %+v, %v", reservation, status, err)
+	}
+	if status.Daily.Used != 1 || status.PerMinute.Used != 1 {
+		t.Errorf("reserved status = %+v, want full check held", status)
+	}
+
+	// Default cached weight is 20%
+	status = a.Commit(reservation, false)
+	if status.Daily.Used != 0.2 || status.PerMinute.Used != 0.2 || status.CachedChecks != 1 || status.Checks != 0 {
+		t.Errorf("committed status = %+v", status)
+	}
+	if usage, err := b.GetUsage(userID); err != nil || usage.Daily.Used != 0.2 {
+		t.Errorf("usage seen by another instance = %+v, %v", usage, err)
+	}
+
+	if err := b.Reset(userID); err != nil {
+		t.Fatal(err)
+	}
+	if usage, err := a.GetUsage(userID); err != nil || usage.Daily.Used != 0 || usage.PerMinute.Used != 0 {
+		t.Errorf("usage after reset = %+v, %v", usage, err)
+	}
+}
+
+func TestRealtimeQuotaConcurrentReservations(t *testing.T) {
+	db := newTestDB(t)
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)}
+	a, b := newReplicaQuotaServices(db, clock)
+	const limit = 4
+	userID := quotaTestUser(t, db, limit, 0)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < 12; i++ {
+		service := a
+		if i%2 == 1 {
+			service = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation, _, err := service.Reserve(userID)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if reservation != nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != limit {
+		t.Errorf("%d reservations granted, want %d", reserved, limit)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// resultSourceName is ID and name of a gateway or API service
+type resultSourceName struct {
+	ID   uint
+	Name string
+}
+
+// attachResultSources fills names of gateways and API services that produced results.
+// Results of deleted sources keep their IDs without a name.
+func attachResultSources(db *gorm.DB, results []models.CheckResult) {
+	var gatewayIDs, apiServiceIDs []uint
+	for _, result := range results {
+		if result.GatewayID != nil {
+			gatewayIDs = append(gatewayIDs, *result.GatewayID)
+		}
+		if result.APIServiceID != nil {
+			apiServiceIDs = append(apiServiceIDs, *result.APIServiceID)
+		}
+	}
+
+	gatewayNames := loadResultSourceNames(db, &models.ADBGateway{}, gatewayIDs)
+	apiServiceNames := loadResultSourceNames(db, &models.APIService{}, apiServiceIDs)
+	for i := range results {
+		if results[i].GatewayID != nil {
+			results[i].GatewayName = gatewayNames[*results[i].GatewayID]
+		}
+		if results[i].APIServiceID != nil {
+			results[i].APIServiceName = apiServiceNames[*results[i].APIServiceID]
+		}
+	}
+}
+
+// loadResultSourceNames returns names of model rows by ID, empty on failure as names are informational
+func loadResultSourceNames(db *gorm.DB, model interface{}, ids []uint) map[uint]string {
+	names := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return names
+	}
+
+	var rows []resultSourceName
+	if err := db.Model(model).Select("id, name").Where("id IN ?", ids).Scan(&rows).Error; err != nil {
+		logger.WithField("service", "CheckService").Warnf("Failed to load result source names: %v", err)
+		return names
+	}
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	return names
+}
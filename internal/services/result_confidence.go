@@ -0,0 +1,44 @@
+package services
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// minSpamConfidenceSettingKey is the setting holding confidence in percent a spam detection needs to count as spam
+const minSpamConfidenceSettingKey = "min_spam_confidence"
+
+// Confidence of spam detection by how the matched keyword was found. Structured API fields are
+// the most reliable, OCR reads of caller-ID screens the noisiest.
+const (
+	ConfidenceStructuredField = 0.9 // Keyword in a field selected by keyword_paths
+	ConfidenceExtractedText   = 0.7 // Keyword in text selected by response_path or in a bot reply
+	ConfidenceRawResponse     = 0.6 // Keyword anywhere in an API response body
+	ConfidenceOCR             = 0.5 // Keyword in OCR text of a screenshot
+)
+
+// minSpamConfidence returns confidence below which spam detections are saved as suspected, 0 counts all
+func minSpamConfidence(db *gorm.DB) float64 {
+	percent := NewSettingsService(db).GetCachedInt(minSpamConfidenceSettingKey, 0)
+	return float64(min(max(percent, 0), 100)) / 100
+}
+
+// apiDetectionConfidence returns confidence of an API spam detection from where found keywords matched
+func apiDetectionConfidence(extractedKeywords, foundKeywords []string, hasPathExtraction bool) float64 {
+	if len(foundKeywords) == 0 {
+		return 1
+	}
+	for _, extracted := range extractedKeywords {
+		extracted = strings.ToLower(extracted)
+		for _, found := range foundKeywords {
+			if strings.Contains(extracted, strings.ToLower(found)) {
+				return ConfidenceStructuredField
+			}
+		}
+	}
+	if hasPathExtraction {
+		return ConfidenceExtractedText
+	}
+	return ConfidenceRawResponse
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ResultSnapshot is the latest result of a service at a point in time
+type ResultSnapshot struct {
+	ResultID      uint      `json:"result_id"`
+	Status        string    `json:"status"` // spam, clean or inconclusive
+	IsSpam        bool      `json:"is_spam"`
+	FoundKeywords []string  `json:"found_keywords"`
+	Source        string    `json:"source"` // check or import
+	CheckedAt     time.Time `json:"checked_at"`
+	HasScreenshot bool      `json:"has_screenshot"` // Served by GET /checks/screenshot/{result_id}
+}
+
+// ServiceResultDiff compares results of one service at both points in time.
+// From is null when the service had not checked the phone yet at that time.
+type ServiceResultDiff struct {
+	ServiceID       uint            `json:"service_id"`
+	ServiceCode     string          `json:"service_code"`
+	ServiceName     string          `json:"service_name"`
+	From            *ResultSnapshot `json:"from"`
+	To              *ResultSnapshot `json:"to"`
+	SameResult      bool            `json:"same_result"` // No newer result between the two points
+	VerdictChanged  bool            `json:"verdict_changed"`
+	KeywordsAdded   []string        `json:"keywords_added"`
+	KeywordsRemoved []string        `json:"keywords_removed"`
+}
+
+// ResultDiff compares verdicts of every service that checked a phone at two points in time
+type ResultDiff struct {
+	PhoneID     uint                `json:"phone_id"`
+	PhoneNumber string              `json:"phone_number"`
+	From        time.Time           `json:"from"`
+	To          time.Time           `json:"to"`
+	Services    []ServiceResultDiff `json:"services"`
+}
+
+// DiffResults compares the latest result of each service at or before from with the one at or before to.
+// Failed checks are not verdicts and are skipped, imported results count as regular history.
+func (s *PhoneService) DiffResults(phoneID uint, from, to time.Time) (*ResultDiff, error) {
+	if from.After(to) {
+		return nil, errors.New("from must not be after to")
+	}
+
+	var phone models.PhoneNumber
+	if err := s.db.Select("id", "number").First(&phone, phoneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("phone number not found")
+		}
+		return nil, fmt.Errorf("failed to get phone number: %w", err)
+	}
+
+	fromResults, err := s.latestResultsAt(phoneID, from)
+	if err != nil {
+		return nil, err
+	}
+	toResults, err := s.latestResultsAt(phoneID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ResultDiff{
+		PhoneID:     phone.ID,
+		PhoneNumber: phone.Number,
+		From:        from,
+		To:          to,
+		Services:    make([]ServiceResultDiff, 0, len(toResults)),
+	}
+
+	// A service with a result before from always has one before to
+	for serviceID, toResult := range toResults {
+		fromResult := fromResults[serviceID]
+		diff.Services = append(diff.Services, diffServiceResults(fromResult, toResult))
+	}
+	slices.SortFunc(diff.Services, func(a, b ServiceResultDiff) int {
+		return cmp.Compare(a.ServiceID, b.ServiceID)
+	})
+
+	return diff, nil
+}
+
+// latestResultsAt returns latest non-error result of each service checked at or before at, keyed by service ID.
+// Results are ordered by check time, ID breaks ties of results checked at the same moment.
+func (s *PhoneService) latestResultsAt(phoneID uint, at time.Time) (map[uint]*models.CheckResult, error) {
+	latestTimes := s.db.Model(&models.CheckResult{}).
+		Select("service_id, MAX(checked_at) AS checked_at").
+		Where("phone_number_id = ? AND checked_at <= ? AND status <> ?", phoneID, at, models.SpamStatusError).
+		Group("service_id")
+
+	latestIDs := s.db.Table("check_results cr").
+		Select("MAX(cr.id)").
+		Joins("JOIN (?) latest ON latest.service_id = cr.service_id AND latest.checked_at = cr.checked_at", latestTimes).
+		Where("cr.phone_number_id = ? AND cr.status <> ?", phoneID, models.SpamStatusError).
+		Group("cr.service_id")
+
+	var results []models.CheckResult
+	if err := s.db.Where("id IN (?)", latestIDs).
+		Preload("Service").
+		Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get check results: %w", err)
+	}
+
+	byService := make(map[uint]*models.CheckResult, len(results))
+	for i := range results {
+		byService[results[i].ServiceID] = &results[i]
+	}
+	return byService, nil
+}
+
+// diffServiceResults compares two results of a service, from may be nil
+func diffServiceResults(from, to *models.CheckResult) ServiceResultDiff {
+	diff := ServiceResultDiff{
+		ServiceID:       to.ServiceID,
+		ServiceCode:     to.Service.Code,
+		ServiceName:     to.Service.Name,
+		To:              resultSnapshotOf(to),
+		KeywordsAdded:   []string{},
+		KeywordsRemoved: []string{},
+	}
+
+	if from == nil {
+		diff.VerdictChanged = true
+		diff.KeywordsAdded = append(diff.KeywordsAdded, diff.To.FoundKeywords...)
+		return diff
+	}
+
+	diff.From = resultSnapshotOf(from)
+	diff.SameResult = from.ID == to.ID
+	diff.VerdictChanged = diff.From.Status != diff.To.Status
+
+	for _, keyword := range diff.To.FoundKeywords {
+		if !slices.Contains(diff.From.FoundKeywords, keyword) {
+			diff.KeywordsAdded = append(diff.KeywordsAdded, keyword)
+		}
+	}
+	for _, keyword := range diff.From.FoundKeywords {
+		if !slices.Contains(diff.To.FoundKeywords, keyword) {
+			diff.KeywordsRemoved = append(diff.KeywordsRemoved, keyword)
+		}
+	}
+	return diff
+}
+
+// resultSnapshotOf describes check result for comparison
+func resultSnapshotOf(result *models.CheckResult) *ResultSnapshot {
+	keywords := []string(result.FoundKeywords)
+	if keywords == nil {
+		keywords = []string{}
+	}
+	return &ResultSnapshot{
+		ResultID:      result.ID,
+		Status:        resultStatusOf(result),
+		IsSpam:        result.IsSpam,
+		FoundKeywords: keywords,
+		Source:        result.Source,
+		CheckedAt:     result.CheckedAt,
+		HasScreenshot: result.Screenshot != "",
+	}
+}
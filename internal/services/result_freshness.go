@@ -0,0 +1,262 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// resultMaxAgeSettingKey is the setting holding default age after which a verdict is stale
+const resultMaxAgeSettingKey = "result_max_age_hours"
+
+// maxResultAgeHours limits configured result max age to a year
+const maxResultAgeHours = 8760
+
+// validateResultMaxAge checks result max age in hours, 0 disables staleness
+func validateResultMaxAge(hours int) error {
+	if hours < 0 || hours > maxResultAgeHours {
+		return fmt.Errorf("result max age must be between 0 and %d hours", maxResultAgeHours)
+	}
+	return nil
+}
+
+// ResultFreshness decides whether check results are too old to describe a phone's current state.
+// Each spam service may override the default max age, zero max age means results never go stale.
+// With sampled runs a phone is checked only once per staleness window of the sampling, so max age
+// is never shorter than that window.
+type ResultFreshness struct {
+	defaultMaxAge time.Duration
+	samplingAge   time.Duration // Longest staleness window of sampled runs, 0 without sampling
+	serviceMaxAge map[uint]time.Duration
+	serviceNames  map[uint]string
+	notApplicable map[uint]bool // Services the phone is excluded from, set by ForPhone
+	now           time.Time
+}
+
+// LoadResultFreshness loads max ages from settings and spam services.
+// On database error only the default is used, so freshness never fails a response.
+func LoadResultFreshness(db *gorm.DB) *ResultFreshness {
+	hours := NewSettingsService(db).GetCachedInt(resultMaxAgeSettingKey, 48)
+	if validateResultMaxAge(hours) != nil {
+		hours = 48
+	}
+
+	freshness := &ResultFreshness{
+		defaultMaxAge: time.Duration(hours) * time.Hour,
+		samplingAge:   samplingMaxStaleness(db),
+		serviceMaxAge: make(map[uint]time.Duration),
+		serviceNames:  make(map[uint]string),
+		now:           time.Now(),
+	}
+
+	var services []models.SpamService
+	if err := db.Select("id, name, code, max_result_age_hours, config").Find(&services).Error; err != nil {
+		logger.WithField("service", "ResultFreshness").Warnf("Failed to load service result max ages: %v", err)
+		return freshness
+	}
+	for i := range services {
+		service := &services[i]
+		freshness.serviceNames[service.ID] = service.Name
+		if cfg := storedServiceConfig(service); cfg.MaxResultAgeHours > 0 {
+			freshness.serviceMaxAge[service.ID] = time.Duration(cfg.MaxResultAgeHours) * time.Hour
+		}
+	}
+
+	return freshness
+}
+
+// ForPhone returns freshness treating services the phone is excluded from as not applicable:
+// their results never go stale and are left out of the verdict.
+// On database error exclusions are ignored, so freshness never fails a response.
+func (f *ResultFreshness) ForPhone(db *gorm.DB, phoneID uint) *ResultFreshness {
+	serviceIDs, err := excludedServiceIDs(db, phoneID)
+	if err != nil {
+		logger.WithField("service", "ResultFreshness").Warnf("Failed to load service exclusions of phone %d: %v", phoneID, err)
+		return f
+	}
+	if len(serviceIDs) == 0 {
+		return f
+	}
+
+	phoneFreshness := *f
+	phoneFreshness.notApplicable = make(map[uint]bool, len(serviceIDs))
+	for _, serviceID := range serviceIDs {
+		phoneFreshness.notApplicable[serviceID] = true
+	}
+	return &phoneFreshness
+}
+
+// MaxAge returns age after which results of a service are stale, 0 if they never are
+func (f *ResultFreshness) MaxAge(serviceID uint) time.Duration {
+	maxAge, ok := f.serviceMaxAge[serviceID]
+	if !ok {
+		maxAge = f.defaultMaxAge
+	}
+	if maxAge > 0 && maxAge < f.samplingAge {
+		return f.samplingAge
+	}
+	return maxAge
+}
+
+// IsStale reports whether result of a service checked at checkedAt is too old.
+// Results of services not applicable to the phone are never stale.
+func (f *ResultFreshness) IsStale(serviceID uint, checkedAt time.Time) bool {
+	if f.notApplicable[serviceID] {
+		return false
+	}
+	maxAge := f.MaxAge(serviceID)
+	return maxAge > 0 && f.now.Sub(checkedAt) > maxAge
+}
+
+// VerdictFreshness describes whether a phone's verdict rests on stale per-service results
+type VerdictFreshness struct {
+	HasStaleComponents bool     `json:"has_stale_components"`     // At least one service verdict is stale
+	AllStale           bool     `json:"all_stale"`                // Every service verdict is stale
+	StaleServices      []string `json:"stale_services,omitempty"` // Services whose verdict is stale
+	// Services the phone is excluded from, their results are not part of the verdict
+	NotApplicableServices []string `json:"not_applicable_services,omitempty"`
+}
+
+// Verdict evaluates latest verdict of each service among results.
+// Error and inconclusive results are not verdicts and are ignored.
+func (f *ResultFreshness) Verdict(results []models.CheckResult) VerdictFreshness {
+	latest := make(map[uint]time.Time)
+	for _, result := range results {
+		if !isVerdictStatus(result.Status) || f.notApplicable[result.ServiceID] {
+			continue
+		}
+		if checkedAt, ok := latest[result.ServiceID]; !ok || result.CheckedAt.After(checkedAt) {
+			latest[result.ServiceID] = result.CheckedAt
+		}
+	}
+
+	verdict := VerdictFreshness{}
+	for serviceID, checkedAt := range latest {
+		if f.IsStale(serviceID, checkedAt) {
+			verdict.StaleServices = append(verdict.StaleServices, f.serviceName(serviceID))
+		}
+	}
+	sort.Strings(verdict.StaleServices)
+
+	for serviceID := range f.notApplicable {
+		verdict.NotApplicableServices = append(verdict.NotApplicableServices, f.serviceName(serviceID))
+	}
+	sort.Strings(verdict.NotApplicableServices)
+
+	verdict.HasStaleComponents = len(verdict.StaleServices) > 0
+	verdict.AllStale = len(latest) > 0 && len(verdict.StaleServices) == len(latest)
+	return verdict
+}
+
+func (f *ResultFreshness) serviceName(serviceID uint) string {
+	if name, ok := f.serviceNames[serviceID]; ok {
+		return name
+	}
+	return fmt.Sprintf("service %d", serviceID)
+}
+
+// CountStaleVerdictPhones counts active phones having verdicts that are all stale, limited to phones
+// created by ownerID unless it is 0. Verdicts of services a phone is excluded from are not counted.
+func (f *ResultFreshness) CountStaleVerdictPhones(db *gorm.DB, ownerID uint) (int64, error) {
+	excluded := db.Model(&models.PhoneServiceExclusion{}).
+		Select("1").
+		Where("phone_service_exclusions.phone_number_id = check_results.phone_number_id AND phone_service_exclusions.service_id = check_results.service_id")
+	latest := db.Model(&models.CheckResult{}).
+		Select("MAX(id) AS max_id").
+		Where("status IN ?", []string{models.SpamStatusSpam, models.SpamStatusClean}).
+		Where("NOT EXISTS (?)", excluded).
+		Group("phone_number_id, service_id")
+
+	query := db.Table("check_results cr").
+		Select("cr.phone_number_id, cr.service_id, cr.checked_at").
+		Joins("JOIN (?) latest ON cr.id = latest.max_id", latest).
+		Joins("JOIN phone_numbers pn ON pn.id = cr.phone_number_id").
+		Where("pn.deleted_at IS NULL AND pn.is_active = ?", true)
+	if ownerID > 0 {
+		query = query.Where("pn.created_by = ?", ownerID)
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest verdicts: %w", err)
+	}
+	defer rows.Close()
+
+	// Phone is counted while all of its verdicts seen so far are stale
+	allStale := make(map[uint]bool)
+	for rows.Next() {
+		var result models.CheckResult
+		if err := db.ScanRows(rows, &result); err != nil {
+			return 0, fmt.Errorf("failed to read latest verdict: %w", err)
+		}
+		stale := f.IsStale(result.ServiceID, result.CheckedAt)
+		if previous, seen := allStale[result.PhoneNumberID]; seen {
+			allStale[result.PhoneNumberID] = previous && stale
+		} else {
+			allStale[result.PhoneNumberID] = stale
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get latest verdicts: %w", err)
+	}
+
+	var count int64
+	for _, stale := range allStale {
+		if stale {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ServicesWithoutFreshResults returns names of services expected to be checked by a run with the given
+// options that produced no successful result since the given time, usually because all their gateways
+// or API providers were down
+func (s *CheckService) ServicesWithoutFreshResults(since time.Time, opts PhoneCheckOptions) ([]string, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = s.getCheckMode()
+	}
+
+	var expected []models.SpamService
+	query := s.db.Where("is_active = ?", true)
+	switch mode {
+	case models.CheckModeAPIOnly:
+		query = query.Where("code IN (?)", s.db.Model(&models.APIService{}).Select("service_code").Where("is_active = ?", true))
+	case models.CheckModeBoth:
+		query = query.Where("code IN (?) OR code IN (?)",
+			s.db.Model(&models.ADBGateway{}).Select("service_code").Where("is_active = ?", true),
+			s.db.Model(&models.APIService{}).Select("service_code").Where("is_active = ?", true))
+	default:
+		query = query.Where("code IN (?)", s.db.Model(&models.ADBGateway{}).Select("service_code").Where("is_active = ?", true))
+	}
+	if opts.ServiceCode != "" {
+		query = query.Where("code = ?", opts.ServiceCode)
+	}
+	if err := query.Order("name").Find(&expected).Error; err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+
+	var checked []uint
+	if err := s.db.Model(&models.CheckResult{}).
+		Where("checked_at >= ? AND status <> ? AND source <> ?", since, models.SpamStatusError, models.CheckSourceImport).
+		Distinct().
+		Pluck("service_id", &checked).Error; err != nil {
+		return nil, fmt.Errorf("failed to get checked services: %w", err)
+	}
+	fresh := make(map[uint]bool, len(checked))
+	for _, id := range checked {
+		fresh[id] = true
+	}
+
+	var missing []string
+	for _, service := range expected {
+		if !fresh[service.ID] {
+			missing = append(missing, service.Name)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,415 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"spam-checker/internal/config"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outcomes of persisting a check result
+const (
+	PersistSaved     = "saved"                 // Saved on the first attempt
+	PersistRetried   = "persisted_after_retry" // Saved once the database came back
+	PersistJournaled = "journaled"             // Kept in the journal until the database is back
+)
+
+// resultPersistDelays are pauses between save attempts while the database is unavailable
+var resultPersistDelays = []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+
+// transientDBErrorCodes are SQLSTATE codes of errors that pass once the database recovers:
+// serialization failure, deadlock, too many connections, admin shutdown, cannot connect now
+var transientDBErrorCodes = []string{"40001", "40P01", "53300", "57P01", "57P03"}
+
+// transientDBErrorMessages are driver messages of a database that is briefly unreachable or busy
+var transientDBErrorMessages = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"bad connection",
+	"unexpected eof",
+	"too many connections",
+	"too many clients",
+	"database is locked",
+	"i/o timeout",
+}
+
+// isTransientDBError reports whether a failed save is worth retrying
+func isTransientDBError(err error) bool {
+	if err == nil || errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, gorm.ErrDuplicatedKey) {
+		return false
+	}
+
+	message := strings.ToLower(err.Error())
+	// Class 08 is connection exception
+	if strings.Contains(message, "(sqlstate 08") {
+		return true
+	}
+	for _, code := range transientDBErrorCodes {
+		if strings.Contains(message, "(sqlstate "+strings.ToLower(code)+")") {
+			return true
+		}
+	}
+	for _, text := range transientDBErrorMessages {
+		if strings.Contains(message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// persistTally counts results of a phone check that needed retries or the journal
+type persistTally struct {
+	retried   atomic.Int64
+	journaled atomic.Int64
+}
+
+type persistTallyKey struct{}
+
+// contextWithPersistTally returns context counting persistence outcomes of the check
+func contextWithPersistTally(ctx context.Context, tally *persistTally) context.Context {
+	return context.WithValue(ctx, persistTallyKey{}, tally)
+}
+
+// persistTallyFromContext returns tally of the check, nil outside of a phone check
+func persistTallyFromContext(ctx context.Context) *persistTally {
+	if ctx == nil {
+		return nil
+	}
+	tally, _ := ctx.Value(persistTallyKey{}).(*persistTally)
+	return tally
+}
+
+// saveCheckResultWithStatistics saves result and counts it in statistics in one transaction
+func saveCheckResultWithStatistics(db *gorm.DB, result *models.CheckResult) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := saveCheckResultInTx(tx, result); err != nil {
+			return err
+		}
+		return updateStatisticsAtInTx(tx, result.PhoneNumberID, result.ServiceID, result.Status, result.CheckedAt)
+	})
+}
+
+// savedByCorrelation loads result already saved under correlation ID of result, a commit
+// whose acknowledgement was lost looks like a failed save
+func savedByCorrelation(db *gorm.DB, result *models.CheckResult) bool {
+	if result.CorrelationID == nil {
+		return false
+	}
+	var saved models.CheckResult
+	if err := db.Where("correlation_id = ?", *result.CorrelationID).First(&saved).Error; err != nil {
+		return false
+	}
+	*result = saved
+	return true
+}
+
+// persistCheckResult saves result along with statistics, retrying with backoff while the database
+// is briefly unavailable. Once retries run out the result goes to the journal and PersistJournaled
+// is returned with a nil error; result then has no ID. Other failures are returned as is.
+func persistCheckResult(ctx context.Context, db *gorm.DB, result *models.CheckResult) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	log := logger.EntryWithContext(logger.WithField("service", "ResultJournal"), ctx)
+
+	if result.CorrelationID == nil {
+		correlationID := uuid.New().String()
+		result.CorrelationID = &correlationID
+	}
+	// Save mutates result, every attempt starts from the same values
+	original := *result
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		*result = original
+		err = saveCheckResultWithStatistics(db, result)
+		if err == nil || (attempt > 0 && savedByCorrelation(db, result)) {
+			outcome := PersistSaved
+			if attempt > 0 {
+				outcome = PersistRetried
+				log.Infof("Check result saved after %d retries", attempt)
+				if tally := persistTallyFromContext(ctx); tally != nil {
+					tally.retried.Add(1)
+				}
+			}
+			sharedResultJournal.replayInBackground(db)
+			return outcome, nil
+		}
+		if !isTransientDBError(err) {
+			return "", err
+		}
+		if attempt == len(resultPersistDelays) {
+			break
+		}
+
+		log.Warnf("Database unavailable while saving check result, retrying in %s: %v", resultPersistDelays[attempt], err)
+		select {
+		case <-time.After(resultPersistDelays[attempt]):
+			continue
+		case <-ctx.Done():
+		}
+		// Check ran out of time, the result is not worth losing because of it
+		break
+	}
+
+	*result = original
+	if journalErr := sharedResultJournal.append(original, err); journalErr != nil {
+		return "", fmt.Errorf("%w, journal: %v", err, journalErr)
+	}
+	result.Status = resultStatusOf(result)
+	log.Warnf("Database unavailable, check result %s journaled: %v", *original.CorrelationID, err)
+	if tally := persistTallyFromContext(ctx); tally != nil {
+		tally.journaled.Add(1)
+	}
+	return PersistJournaled, nil
+}
+
+// resultJournalEntry is a check result waiting in the journal. Correlation ID is kept
+// separately because the result does not serialize it.
+type resultJournalEntry struct {
+	CorrelationID string             `json:"correlation_id"`
+	JournaledAt   time.Time          `json:"journaled_at"`
+	Error         string             `json:"error"` // Why the database refused the result
+	Result        models.CheckResult `json:"result"`
+}
+
+// ResultJournalReplay is outcome of replaying the journal into the database
+type ResultJournalReplay struct {
+	Replayed  int `json:"replayed"`  // Saved now
+	Duplicate int `json:"duplicate"` // Already saved, e.g. by an earlier replay that was interrupted
+	Dropped   int `json:"dropped"`   // Unreadable or refused by the database for good
+	Remaining int `json:"remaining"` // Kept because the database went away again
+}
+
+// resultJournal is an append-only JSON lines file of check results the database could not take
+type resultJournal struct {
+	mu        sync.Mutex // Guards the file
+	path      string
+	maxSize   int64
+	pending   atomic.Bool // Journal may hold entries
+	replaying atomic.Bool
+}
+
+// sharedResultJournal is the journal of all check services of the process
+var sharedResultJournal = &resultJournal{path: "data/result-journal.jsonl", maxSize: 64 * 1024 * 1024}
+
+// ConfigureResultJournal sets location and size cap of the result journal, before any check runs
+func ConfigureResultJournal(cfg config.CheckTuningConfig) {
+	sharedResultJournal.mu.Lock()
+	defer sharedResultJournal.mu.Unlock()
+
+	if cfg.ResultJournalPath != "" {
+		sharedResultJournal.path = cfg.ResultJournalPath
+	}
+	if cfg.ResultJournalMaxSize > 0 {
+		sharedResultJournal.maxSize = cfg.ResultJournalMaxSize
+	}
+	if info, err := os.Stat(sharedResultJournal.path); err == nil && info.Size() > 0 {
+		sharedResultJournal.pending.Store(true)
+	}
+}
+
+// ReplayResultJournal saves journaled check results to the database, results saved before are skipped
+func ReplayResultJournal(db *gorm.DB) (ResultJournalReplay, error) {
+	return sharedResultJournal.replay(db)
+}
+
+// append journals result, refusing it once the journal reaches its size cap
+func (j *resultJournal) append(result models.CheckResult, cause error) error {
+	entry := resultJournalEntry{
+		CorrelationID: *result.CorrelationID,
+		JournaledAt:   time.Now(),
+		Result:        result,
+	}
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat journal: %w", err)
+	}
+	if info.Size()+int64(len(line)) > j.maxSize {
+		return fmt.Errorf("journal is full (%d bytes)", j.maxSize)
+	}
+	if _, err := file.Write(line); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	j.pending.Store(true)
+	return nil
+}
+
+// replayInBackground replays the journal after a successful save when it may hold entries
+func (j *resultJournal) replayInBackground(db *gorm.DB) {
+	if !j.pending.Load() || j.replaying.Load() {
+		return
+	}
+	go func() {
+		stats, err := j.replay(db)
+		log := logger.WithField("service", "ResultJournal")
+		if err != nil {
+			log.Errorf("Failed to replay result journal: %v", err)
+			return
+		}
+		if stats.Replayed+stats.Duplicate+stats.Dropped > 0 {
+			log.Infof("Result journal replayed: %d saved, %d already saved, %d dropped, %d remaining",
+				stats.Replayed, stats.Duplicate, stats.Dropped, stats.Remaining)
+		}
+	}()
+}
+
+// replay saves journaled results in journal order and rewrites the journal with the ones left.
+// Entries are idempotent, a correlation ID already in the database or seen earlier in the journal is skipped.
+func (j *resultJournal) replay(db *gorm.DB) (ResultJournalReplay, error) {
+	var stats ResultJournalReplay
+	if !j.replaying.CompareAndSwap(false, true) {
+		return stats, nil
+	}
+	defer j.replaying.Store(false)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	log := logger.WithField("service", "ResultJournal")
+
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		j.pending.Store(false)
+		return stats, nil
+	}
+	if err != nil {
+		return stats, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var remaining [][]byte
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), int(j.maxSize))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		// Database went away again, the rest waits for the next replay
+		if len(remaining) > 0 {
+			remaining = append(remaining, append([]byte(nil), line...))
+			continue
+		}
+
+		var entry resultJournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil || entry.CorrelationID == "" {
+			log.Errorf("Dropping unreadable result journal entry: %v", err)
+			stats.Dropped++
+			continue
+		}
+		if seen[entry.CorrelationID] {
+			stats.Duplicate++
+			continue
+		}
+		seen[entry.CorrelationID] = true
+
+		result := entry.Result
+		result.ID = 0
+		result.PhoneNumber = models.PhoneNumber{}
+		result.Service = models.SpamService{}
+		result.CorrelationID = &entry.CorrelationID
+
+		if savedByCorrelation(db, &result) {
+			stats.Duplicate++
+			continue
+		}
+		err := saveCheckResultWithStatistics(db, &result)
+		switch {
+		case err == nil:
+			stats.Replayed++
+		case savedByCorrelation(db, &result):
+			stats.Duplicate++
+		case isTransientDBError(err):
+			remaining = append(remaining, append([]byte(nil), line...))
+		default:
+			log.Errorf("Dropping result journal entry %s refused by the database: %v", entry.CorrelationID, err)
+			stats.Dropped++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("failed to read journal: %w", err)
+	}
+	stats.Remaining = len(remaining)
+
+	if err := j.rewrite(remaining); err != nil {
+		return stats, err
+	}
+	j.pending.Store(len(remaining) > 0)
+	return stats, nil
+}
+
+// rewrite atomically replaces the journal with entries, removing it when there are none
+func (j *resultJournal) rewrite(entries [][]byte) error {
+	if len(entries) == 0 {
+		if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove journal: %w", err)
+		}
+		return nil
+	}
+
+	tmpPath := j.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	for _, entry := range entries {
+		writer.Write(entry)
+		writer.WriteByte('\n')
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("failed to replace journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// redactedValue replaces secrets in raw API responses
+const redactedValue = "[REDACTED]"
+
+// secretFieldPattern matches names of JSON fields and query parameters holding credentials
+var secretFieldPattern = regexp.MustCompile(`(?i)(token|secret|password|passwd|api[_-]?key|apikey|authorization|session|cookie|signature)`)
+
+// secretAssignmentPattern matches key=value and "key": "value" credentials in non-JSON text
+var secretAssignmentPattern = regexp.MustCompile(`(?i)((?:access_|refresh_|api_|auth_)?(?:token|secret|password|api[_-]?key|apikey|authorization)["']?\s*[:=]\s*["']?)([^"'\s&,;}]+)`)
+
+// CheckResultRaw is text a check result was classified on
+type CheckResultRaw struct {
+	ResultID      uint      `json:"result_id"`
+	PhoneNumberID uint      `json:"phone_number_id"`
+	ServiceID     uint      `json:"service_id"`
+	APIServiceID  *uint     `json:"api_service_id,omitempty"`
+	Source        string    `json:"source"` // adb, api or import
+	Status        string    `json:"status"`
+	OCRText       string    `json:"ocr_text,omitempty"`       // Text recognized on ADB screenshot
+	RawResponse   string    `json:"raw_response,omitempty"`   // API response body with credentials redacted
+	ExtractedText string    `json:"extracted_text,omitempty"` // Text extracted from API response and matched against keywords
+	Redacted      bool      `json:"redacted"`                 // Something was removed from raw response
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// GetCheckResultRaw returns stored OCR text or API response of a result with credentials redacted
+func (s *CheckService) GetCheckResultRaw(resultID uint) (*CheckResultRaw, error) {
+	var result models.CheckResult
+	if err := s.db.First(&result, resultID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("result not found")
+		}
+		return nil, fmt.Errorf("failed to get check result: %w", err)
+	}
+
+	raw := &CheckResultRaw{
+		ResultID:      result.ID,
+		PhoneNumberID: result.PhoneNumberID,
+		ServiceID:     result.ServiceID,
+		APIServiceID:  result.APIServiceID,
+		Status:        result.Status,
+		CheckedAt:     result.CheckedAt,
+	}
+
+	switch {
+	case result.Source == models.CheckSourceImport:
+		raw.Source = "import"
+		raw.ExtractedText = result.RawText
+	case result.RawResponse != "" || result.APIServiceID != nil:
+		raw.Source = "api"
+		raw.ExtractedText = result.RawText
+		raw.RawResponse, raw.Redacted = s.redactAPIResponse(result.RawResponse, result.APIServiceID)
+	default:
+		raw.Source = "adb"
+		raw.OCRText = result.RawText
+	}
+
+	return raw, nil
+}
+
+// redactAPIResponse removes credentials from API response: values of the provider's configured
+// headers and URL query parameters, secret-looking JSON fields and key=value pairs
+func (s *CheckService) redactAPIResponse(response string, apiServiceID *uint) (string, bool) {
+	if response == "" {
+		return "", false
+	}
+	redacted := response
+
+	// Provider may echo its own credentials back
+	if apiServiceID != nil {
+		var apiService models.APIService
+		if err := s.db.First(&apiService, *apiServiceID).Error; err == nil {
+			for _, secret := range apiServiceSecrets(&apiService) {
+				redacted = strings.ReplaceAll(redacted, secret, redactedValue)
+			}
+		}
+	}
+
+	var document interface{}
+	if err := json.Unmarshal([]byte(redacted), &document); err == nil {
+		if redactJSONSecrets(document) {
+			if data, err := json.Marshal(document); err == nil {
+				redacted = string(data)
+			}
+		}
+	} else {
+		redacted = secretAssignmentPattern.ReplaceAllString(redacted, "${1}"+redactedValue)
+	}
+
+	return redacted, redacted != response
+}
+
+// apiServiceSecrets returns configured header values and URL query values of a provider
+func apiServiceSecrets(apiService *models.APIService) []string {
+	var secrets []string
+
+	if apiService.Headers != "" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(apiService.Headers), &headers); err == nil {
+			for _, value := range headers {
+				// Bearer prefix alone is not a secret
+				value = strings.TrimSpace(strings.TrimPrefix(value, "Bearer "))
+				if len(value) >= 6 {
+					secrets = append(secrets, value)
+				}
+			}
+		}
+	}
+
+	if parsed, err := url.Parse(apiService.APIURL); err == nil {
+		for key, values := range parsed.Query() {
+			if !secretFieldPattern.MatchString(key) {
+				continue
+			}
+			for _, value := range values {
+				if len(value) >= 6 {
+					secrets = append(secrets, value)
+				}
+			}
+		}
+	}
+
+	return secrets
+}
+
+// redactJSONSecrets replaces values of secret-looking fields in decoded JSON, reports whether any was found
+func redactJSONSecrets(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if _, nested := item.(map[string]interface{}); !nested && secretFieldPattern.MatchString(key) {
+				if item != nil && item != redactedValue {
+					v[key] = redactedValue
+					changed = true
+				}
+				continue
+			}
+			if redactJSONSecrets(item) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if redactJSONSecrets(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
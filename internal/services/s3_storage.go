@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"spam-checker/internal/config"
+	"strings"
+	"time"
+)
+
+// s3RequestTimeout bounds a single object request
+const s3RequestTimeout = 30 * time.Second
+
+// s3Storage keeps files in an S3-compatible bucket. Requests are signed with AWS Signature Version 4.
+type s3Storage struct {
+	cfg    config.S3Config
+	client *http.Client
+}
+
+func newS3Storage(cfg config.S3Config) *s3Storage {
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &s3Storage{
+		cfg:    cfg,
+		client: &http.Client{Timeout: s3RequestTimeout},
+	}
+}
+
+func (s *s3Storage) Scheme() string {
+	return StorageSchemeS3
+}
+
+// objectKey prepends configured prefix to key
+func (s *s3Storage) objectKey(key string) string {
+	if s.cfg.Prefix == "" {
+		return key
+	}
+	return path.Join(s.cfg.Prefix, key)
+}
+
+// objectURL returns URL of object in path-style or virtual-hosted-style addressing
+func (s *s3Storage) objectURL(key string) (*url.URL, error) {
+	endpoint, err := url.Parse(s.cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %q", s.cfg.Endpoint)
+	}
+
+	objectPath := "/" + s.objectKey(key)
+	if s.cfg.UsePathStyle {
+		objectPath = "/" + s.cfg.Bucket + objectPath
+	} else {
+		endpoint.Host = s.cfg.Bucket + "." + endpoint.Host
+	}
+	endpoint.Path = objectPath
+	endpoint.RawPath = s3EscapePath(objectPath)
+	return endpoint, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3ResponseError(resp)
+	}
+	return nil
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrStoredFileNotFound
+	default:
+		return nil, s3ResponseError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 object: %w", err)
+	}
+	return data, nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3ResponseError(resp)
+	}
+	return nil
+}
+
+func (s *s3Storage) Size(ctx context.Context, key string) (int64, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, "")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.ContentLength, nil
+	case http.StatusNotFound:
+		return 0, ErrStoredFileNotFound
+	default:
+		return 0, s3ResponseError(resp)
+	}
+}
+
+// do sends signed object request
+func (s *s3Storage) do(ctx context.Context, method, key string, body []byte, contentType string) (*http.Response, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// sign adds Signature Version 4 authorization headers to request
+func (s *s3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// s3EscapePath percent-encodes every byte of path except unreserved characters and slashes,
+// as required by the canonical request
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3ResponseError turns unexpected S3 response into error carrying status and error code
+func s3ResponseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	details := strings.TrimSpace(string(body))
+	if details == "" {
+		return fmt.Errorf("S3 returned status %d", resp.StatusCode)
+	}
+	return fmt.Errorf("S3 returned status %d: %s", resp.StatusCode, details)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MaxScheduleRuns limits number of schedule runs returned at once
+const MaxScheduleRuns = 500
+
+// validateOverlapPolicy checks schedule overlap policy
+func validateOverlapPolicy(policy string) error {
+	switch policy {
+	case models.ScheduleOverlapSkip, models.ScheduleOverlapQueue, models.ScheduleOverlapCancelRestart:
+		return nil
+	}
+	return fmt.Errorf("overlap policy must be one of %s, %s, %s",
+		models.ScheduleOverlapSkip, models.ScheduleOverlapQueue, models.ScheduleOverlapCancelRestart)
+}
+
+// GetScheduleRuns returns latest triggers of a schedule with overlap policy decisions, newest first
+func (s *SettingsService) GetScheduleRuns(scheduleID uint, limit int) ([]models.ScheduleRun, error) {
+	var schedule models.CheckSchedule
+	if err := s.db.Select("id").First(&schedule, scheduleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("schedule not found")
+		}
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	var runs []models.ScheduleRun
+	if err := s.db.Where("schedule_id = ?", scheduleID).
+		Order("triggered_at DESC, id DESC").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get schedule runs: %w", err)
+	}
+	return runs, nil
+}
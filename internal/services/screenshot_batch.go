@@ -0,0 +1,110 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxScreenshotBatch limits how many gateways a single batch may capture
+const maxScreenshotBatch = 50
+
+// Timeout of a single gateway screenshot in a batch
+const (
+	defaultScreenshotBatchTimeout = 30 * time.Second
+	maxScreenshotBatchTimeout     = 120 * time.Second
+)
+
+// ErrInvalidScreenshotBatch is returned for empty, too large or malformed batch requests
+var ErrInvalidScreenshotBatch = errors.New("invalid screenshot batch")
+
+// GatewayScreenshot is current screen of a gateway captured in a batch
+type GatewayScreenshot struct {
+	GatewayID  uint   `json:"gateway_id"`
+	Name       string `json:"name,omitempty"`
+	Image      string `json:"image,omitempty"` // Base64 PNG
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// CaptureScreenshots takes screenshots of gateways concurrently, at most gateway_status_parallelism
+// at a time. A gateway not answering within timeout is reported with an error, others are not affected.
+func (s *ADBService) CaptureScreenshots(gatewayIDs []uint, timeout time.Duration) (map[uint]*GatewayScreenshot, error) {
+	if len(gatewayIDs) == 0 || len(gatewayIDs) > maxScreenshotBatch {
+		return nil, fmt.Errorf("%w: gateway_ids must contain 1 to %d gateways", ErrInvalidScreenshotBatch, maxScreenshotBatch)
+	}
+	if timeout <= 0 {
+		timeout = defaultScreenshotBatchTimeout
+	}
+	if timeout > maxScreenshotBatchTimeout {
+		return nil, fmt.Errorf("%w: timeout must be at most %s", ErrInvalidScreenshotBatch, maxScreenshotBatchTimeout)
+	}
+
+	screenshots := make(map[uint]*GatewayScreenshot, len(gatewayIDs))
+	workChan := make(chan *GatewayScreenshot, len(gatewayIDs))
+	for _, id := range gatewayIDs {
+		if _, exists := screenshots[id]; exists {
+			continue
+		}
+		screenshots[id] = &GatewayScreenshot{GatewayID: id}
+		workChan <- screenshots[id]
+	}
+	close(workChan)
+
+	parallelism, _, _ := s.getStatusRefreshSettings()
+	parallelism = min(parallelism, len(screenshots))
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for screenshot := range workChan {
+				s.captureScreenshot(screenshot, timeout)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return screenshots, nil
+}
+
+// captureScreenshot fills screenshot of a single gateway. TakeScreenshot cannot be cancelled,
+// on timeout its result is dropped once the device answers.
+func (s *ADBService) captureScreenshot(screenshot *GatewayScreenshot, timeout time.Duration) {
+	started := time.Now()
+	defer func() {
+		screenshot.DurationMs = time.Since(started).Milliseconds()
+	}()
+
+	gateway, err := s.GetGatewayByID(screenshot.GatewayID)
+	if err != nil {
+		screenshot.Error = err.Error()
+		return
+	}
+	screenshot.Name = gateway.Name
+
+	type capture struct {
+		data []byte
+		err  error
+	}
+	done := make(chan capture, 1)
+	go func() {
+		data, err := s.TakeScreenshot(gateway.ID)
+		done <- capture{data, err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			screenshot.Error = result.err.Error()
+			return
+		}
+		screenshot.Image = base64.StdEncoding.EncodeToString(result.data)
+	case <-time.After(timeout):
+		screenshot.Error = fmt.Sprintf("screenshot timed out after %s", timeout)
+		s.log.Warnf("Screenshot of gateway %s timed out after %s", gateway.Name, timeout)
+	}
+}
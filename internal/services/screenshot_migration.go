@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// screenshotMigrationBatchSize is number of results loaded per query during migration
+const screenshotMigrationBatchSize = 200
+
+// ScreenshotMigrationStats summarizes upload of local screenshots to the bucket
+type ScreenshotMigrationStats struct {
+	Scanned  int `json:"scanned"`
+	Uploaded int `json:"uploaded"`
+	Missing  int `json:"missing"` // Local file no longer exists, reference is left as is
+	Failed   int `json:"failed"`
+}
+
+// MigrateScreenshotsToS3 uploads screenshots referenced by check results from local storage to the bucket
+// and rewrites their references. Local files are kept so the migration can be repeated safely,
+// results already pointing to the bucket are skipped.
+func MigrateScreenshotsToS3(ctx context.Context, db *gorm.DB, storage *FileStorage) (*ScreenshotMigrationStats, error) {
+	log := logger.WithField("service", "ScreenshotMigration")
+
+	local := storage.Backend(StorageSchemeLocal)
+	bucket := storage.Backend(StorageSchemeS3)
+	if bucket == nil {
+		return nil, errors.New("S3 storage is not configured")
+	}
+
+	stats := &ScreenshotMigrationStats{}
+	var lastID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		var results []models.CheckResult
+		if err := db.Select("id", "screenshot").
+			Where("id > ? AND screenshot <> '' AND screenshot NOT LIKE ?", lastID, StorageSchemeS3+"://%").
+			Order("id").
+			Limit(screenshotMigrationBatchSize).
+			Find(&results).Error; err != nil {
+			return stats, fmt.Errorf("failed to load check results: %w", err)
+		}
+		if len(results) == 0 {
+			return stats, nil
+		}
+
+		for _, result := range results {
+			lastID = result.ID
+			stats.Scanned++
+
+			scheme, key := ParseStorageRef(result.Screenshot)
+			if scheme != StorageSchemeLocal {
+				log.Warnf("Skipping result %d: unknown storage scheme %q", result.ID, scheme)
+				stats.Failed++
+				continue
+			}
+
+			data, err := local.Get(ctx, key)
+			if err != nil {
+				if errors.Is(err, ErrStoredFileNotFound) {
+					stats.Missing++
+					continue
+				}
+				log.Errorf("Failed to read screenshot of result %d: %v", result.ID, err)
+				stats.Failed++
+				continue
+			}
+
+			if err := bucket.Put(ctx, key, data, "image/png"); err != nil {
+				log.Errorf("Failed to upload screenshot of result %d: %v", result.ID, err)
+				stats.Failed++
+				continue
+			}
+
+			if err := db.Model(&models.CheckResult{}).Where("id = ?", result.ID).
+				Update("screenshot", StorageRef(StorageSchemeS3, key)).Error; err != nil {
+				log.Errorf("Failed to update screenshot reference of result %d: %v", result.ID, err)
+				stats.Failed++
+				continue
+			}
+			stats.Uploaded++
+		}
+
+		log.Infof("Screenshot migration progress: %d scanned, %d uploaded, %d missing, %d failed",
+			stats.Scanned, stats.Uploaded, stats.Missing, stats.Failed)
+	}
+}
@@ -0,0 +1,499 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"spam-checker/internal/config"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Service config limits
+const (
+	maxServicePhrases       = 50
+	maxServicePhraseRunes   = 100
+	maxServiceMinTextLength = 1000
+)
+
+var (
+	appPackagePattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z][A-Za-z0-9_]*)+$`)
+	appActivityPattern = regexp.MustCompile(`^\.?[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z][A-Za-z0-9_$]*)*$`)
+)
+
+// ServiceAppConfig describes caller-ID app of a spam service
+type ServiceAppConfig struct {
+	Package        string `json:"package,omitempty"`        // Built-in services default to their app
+	Activity       string `json:"activity,omitempty"`       // Launch activity, full or relative to package
+	ReadinessProbe bool   `json:"readiness_probe"`          // Verify app before marking gateway online
+	APKSourceURL   string `json:"apk_source_url,omitempty"` // Direct link to the latest APK, used by APK update jobs
+}
+
+// ServiceCallConfig describes timings of the default check script, zero uses check tuning
+type ServiceCallConfig struct {
+	AppStartWaitMs int `json:"app_start_wait_ms,omitempty"`
+	PostCallWaitMs int `json:"post_call_wait_ms,omitempty"`
+}
+
+// ServiceOCRConfig describes how screenshots of a service are read
+type ServiceOCRConfig struct {
+	Language      string `json:"language,omitempty"`        // Tesseract languages, e.g. rus+eng
+	MinTextLength *int   `json:"min_text_length,omitempty"` // Shorter clean text is inconclusive
+	// Phrases meaning the app shows an error or block screen instead of caller information,
+	// handled like a crash dialog in addition to the built-in ones
+	BlockPhrases []string `json:"block_phrases,omitempty"`
+	// Labels the app shows for verified-safe numbers, e.g. "Доверенный". A match marks
+	// the result clean without the keyword scan.
+	CleanPhrases []string `json:"clean_phrases,omitempty"`
+}
+
+// ServiceConfig is check behavior of a spam service. Unset fields fall back to settings
+// and built-in values, see effective config of the service for values in use.
+type ServiceConfig struct {
+	App               ServiceAppConfig  `json:"app"`
+	Call              ServiceCallConfig `json:"call"`
+	OCR               ServiceOCRConfig  `json:"ocr"`
+	CheckScript       []CheckScriptStep `json:"check_script,omitempty"` // Replaces the default script built from call timings
+	APIPolicy         string            `json:"api_policy,omitempty"`   // call_all or first_success
+	MaxResultAgeHours int               `json:"max_result_age_hours,omitempty"`
+	DecisionRules     []DecisionRule    `json:"decision_rules,omitempty"` // Decide OCR verdict instead of keywords, see DecisionRule
+}
+
+// ServiceConfigError is a validation error of a service config field
+type ServiceConfigError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *ServiceConfigError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+func serviceConfigError(field, format string, args ...interface{}) error {
+	return &ServiceConfigError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// ParseServiceConfig parses and validates service config JSON, unknown fields are rejected
+func ParseServiceConfig(serviceCode string, data []byte) (*ServiceConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var cfg ServiceConfig
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, serviceConfigDecodeError(err, data)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, serviceConfigError("", "unexpected data after config object")
+	}
+
+	if err := cfg.Validate(serviceCode); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// serviceConfigDecodeError maps JSON decoding error of data to the field it is about
+func serviceConfigDecodeError(err error, data []byte) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field == "":
+		return serviceConfigError("", "config must be a JSON object")
+	case errors.As(err, &typeErr):
+		return serviceConfigError(typeErr.Field, "must be %s, got %s", typeErr.Type, typeErr.Value)
+	case errors.As(err, &syntaxErr):
+		return serviceConfigError("", "invalid JSON at offset %d: %v", syntaxErr.Offset, syntaxErr)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		var raw interface{}
+		if json.Unmarshal(data, &raw) == nil {
+			if path := unknownFieldPath(raw, reflect.TypeOf(ServiceConfig{}), ""); path != "" {
+				field = path
+			}
+		}
+		return serviceConfigError(field, "unknown field")
+	case errors.Is(err, io.EOF):
+		return serviceConfigError("", "config must be a JSON object")
+	default:
+		return serviceConfigError("", "invalid config: %v", err)
+	}
+}
+
+// unknownFieldPath returns path of the first key in decoded JSON value that typ has no field for.
+// Decoder reports only the key name, the path tells which section it was found in.
+func unknownFieldPath(value interface{}, typ reflect.Type, path string) string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		fields := make(map[string]reflect.Type, typ.NumField())
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields[name] = typ.Field(i).Type
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			fieldType, ok := fields[key]
+			if !ok {
+				return fieldPath
+			}
+			if unknown := unknownFieldPath(object[key], fieldType, fieldPath); unknown != "" {
+				return unknown
+			}
+		}
+	case reflect.Slice:
+		items, _ := value.([]interface{})
+		for i, item := range items {
+			if unknown := unknownFieldPath(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i)); unknown != "" {
+				return unknown
+			}
+		}
+	}
+	return ""
+}
+
+// Validate checks config of service with code serviceCode
+func (c *ServiceConfig) Validate(serviceCode string) error {
+	if c.App.Package != "" && !appPackagePattern.MatchString(c.App.Package) {
+		return serviceConfigError("app.package", "invalid Android package %q", c.App.Package)
+	}
+	if c.App.Activity != "" && !appActivityPattern.MatchString(c.App.Activity) {
+		return serviceConfigError("app.activity", "invalid activity %q", c.App.Activity)
+	}
+	defaultPackage, _ := defaultAppInfo(serviceCode)
+	if c.App.Activity != "" && c.App.Package == "" && defaultPackage == "" {
+		return serviceConfigError("app.activity", "requires app.package for services without built-in app")
+	}
+	if c.App.ReadinessProbe && c.App.Package == "" && defaultPackage == "" {
+		return serviceConfigError("app.readiness_probe", "requires app.package for services without built-in app")
+	}
+	if c.App.APKSourceURL != "" {
+		if parsed, err := url.Parse(c.App.APKSourceURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return serviceConfigError("app.apk_source_url", "must be an http or https URL")
+		}
+	}
+
+	maxWait := int(maxScriptWait / time.Millisecond)
+	if c.Call.AppStartWaitMs < 0 || c.Call.AppStartWaitMs > maxWait {
+		return serviceConfigError("call.app_start_wait_ms", "must be between 0 and %d", maxWait)
+	}
+	if c.Call.PostCallWaitMs < 0 || c.Call.PostCallWaitMs > maxWait {
+		return serviceConfigError("call.post_call_wait_ms", "must be between 0 and %d", maxWait)
+	}
+
+	if c.OCR.Language != "" && !ocrLanguagePattern.MatchString(c.OCR.Language) {
+		return serviceConfigError("ocr.language", "invalid OCR language %q: expected codes joined with +, e.g. rus+eng", c.OCR.Language)
+	}
+	if c.OCR.MinTextLength != nil && (*c.OCR.MinTextLength < 0 || *c.OCR.MinTextLength > maxServiceMinTextLength) {
+		return serviceConfigError("ocr.min_text_length", "must be between 0 and %d", maxServiceMinTextLength)
+	}
+	if err := validateServicePhrases("ocr.block_phrases", c.OCR.BlockPhrases); err != nil {
+		return err
+	}
+	if err := validateServicePhrases("ocr.clean_phrases", c.OCR.CleanPhrases); err != nil {
+		return err
+	}
+
+	if c.CheckScript != nil {
+		if err := ValidateCheckScript(c.CheckScript); err != nil {
+			return serviceConfigError("check_script", "%v", err)
+		}
+	}
+
+	if c.APIPolicy != "" && c.APIPolicy != models.APIPolicyCallAll && c.APIPolicy != models.APIPolicyFirstSuccess {
+		return serviceConfigError("api_policy", "must be %s or %s", models.APIPolicyCallAll, models.APIPolicyFirstSuccess)
+	}
+	if err := validateResultMaxAge(c.MaxResultAgeHours); err != nil {
+		return serviceConfigError("max_result_age_hours", "must be between 0 and %d", maxResultAgeHours)
+	}
+	if err := ValidateDecisionRules(c.DecisionRules); err != nil {
+		return serviceConfigError("decision_rules", "%v", err)
+	}
+
+	return nil
+}
+
+// validateServicePhrases checks OCR phrase list of service config
+func validateServicePhrases(field string, phrases []string) error {
+	if len(phrases) > maxServicePhrases {
+		return serviceConfigError(field, "must contain at most %d phrases", maxServicePhrases)
+	}
+	for i, phrase := range phrases {
+		if strings.TrimSpace(phrase) == "" {
+			return serviceConfigError(fmt.Sprintf("%s[%d]", field, i), "must not be empty")
+		}
+		if len([]rune(phrase)) > maxServicePhraseRunes {
+			return serviceConfigError(fmt.Sprintf("%s[%d]", field, i), "must be at most %d characters", maxServicePhraseRunes)
+		}
+	}
+	return nil
+}
+
+// serviceConfigFromColumns builds config of a service saved before the config column existed
+func serviceConfigFromColumns(service *models.SpamService) ServiceConfig {
+	cfg := ServiceConfig{
+		App: ServiceAppConfig{
+			Package:        service.AppPackage,
+			ReadinessProbe: service.ReadinessProbe,
+		},
+		APIPolicy:         service.APIPolicy,
+		MaxResultAgeHours: service.MaxResultAgeHours,
+	}
+	if strings.TrimSpace(service.CheckScript) != "" {
+		if steps, err := ParseCheckScript(service.CheckScript); err == nil {
+			cfg.CheckScript = steps
+		}
+	}
+	return cfg
+}
+
+// storedServiceConfig returns config saved for service. Services without config, or with
+// config that no longer validates, are read from the legacy columns.
+func storedServiceConfig(service *models.SpamService) ServiceConfig {
+	if strings.TrimSpace(service.Config) == "" {
+		return serviceConfigFromColumns(service)
+	}
+
+	cfg, err := ParseServiceConfig(service.Code, []byte(service.Config))
+	if err != nil {
+		logger.WithField("service", "ServiceConfig").Warnf("Invalid config of service %s, using legacy columns: %v", service.Code, err)
+		return serviceConfigFromColumns(service)
+	}
+	return *cfg
+}
+
+// appInfo returns package and activity used to start app of service with code serviceCode
+func (c *ServiceConfig) appInfo(serviceCode string) (string, string) {
+	defaultPackage, defaultActivity := defaultAppInfo(serviceCode)
+
+	appPackage := c.App.Package
+	if appPackage == "" {
+		appPackage = defaultPackage
+	}
+	activity := c.App.Activity
+	if activity == "" && appPackage == defaultPackage {
+		activity = defaultActivity
+	}
+	if strings.HasPrefix(activity, ".") {
+		activity = appPackage + activity
+	}
+	return appPackage, activity
+}
+
+// apiPolicy returns how API services of the service are called
+func (c *ServiceConfig) apiPolicy() string {
+	if c.APIPolicy == "" {
+		return models.APIPolicyCallAll
+	}
+	return c.APIPolicy
+}
+
+// serviceConfigDefaults are values used for fields a service config leaves unset
+type serviceConfigDefaults struct {
+	AppStartWait      time.Duration
+	PostCallWait      time.Duration
+	OCRLanguage       string
+	MinTextLength     int
+	MaxResultAgeHours int
+}
+
+// loadServiceConfigDefaults reads defaults of service configs from tuning and cached settings
+func loadServiceConfigDefaults(db *gorm.DB, tuning *CheckTuning, ocr config.OCRConfig) serviceConfigDefaults {
+	settings := NewSettingsService(db)
+	return serviceConfigDefaults{
+		AppStartWait:      tuning.AppStartWait(),
+		PostCallWait:      tuning.PostCallWait(),
+		OCRLanguage:       ocr.Language,
+		MinTextLength:     settings.GetCachedInt("ocr_min_text_length", 20),
+		MaxResultAgeHours: settings.GetCachedInt(resultMaxAgeSettingKey, 48),
+	}
+}
+
+// effective returns config with unset fields filled from defaults, as checks of the service use it
+func (c ServiceConfig) effective(serviceCode string, defaults serviceConfigDefaults) ServiceConfig {
+	effective := c
+	effective.App.Package, effective.App.Activity = c.appInfo(serviceCode)
+
+	if effective.Call.AppStartWaitMs == 0 {
+		effective.Call.AppStartWaitMs = int(defaults.AppStartWait / time.Millisecond)
+	}
+	if effective.Call.PostCallWaitMs == 0 {
+		effective.Call.PostCallWaitMs = int(defaults.PostCallWait / time.Millisecond)
+	}
+
+	if effective.OCR.Language == "" {
+		effective.OCR.Language = defaults.OCRLanguage
+	}
+	if effective.OCR.MinTextLength == nil {
+		minTextLength := defaults.MinTextLength
+		effective.OCR.MinTextLength = &minTextLength
+	}
+	if effective.OCR.BlockPhrases == nil {
+		effective.OCR.BlockPhrases = []string{}
+	}
+	if effective.OCR.CleanPhrases == nil {
+		effective.OCR.CleanPhrases = []string{}
+	}
+
+	if effective.CheckScript == nil {
+		effective.CheckScript = buildDefaultCheckScript(
+			time.Duration(effective.Call.AppStartWaitMs)*time.Millisecond,
+			time.Duration(effective.Call.PostCallWaitMs)*time.Millisecond,
+		)
+	}
+	effective.APIPolicy = c.apiPolicy()
+	if effective.MaxResultAgeHours == 0 {
+		effective.MaxResultAgeHours = defaults.MaxResultAgeHours
+	}
+
+	return effective
+}
+
+// updateServiceConfigInTx applies change to stored config of service and saves it
+func updateServiceConfigInTx(tx *gorm.DB, service *models.SpamService, change func(cfg *ServiceConfig)) error {
+	cfg := storedServiceConfig(service)
+	change(&cfg)
+	if err := cfg.Validate(service.Code); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal service config: %w", err)
+	}
+	if err := tx.Model(service).Update("config", string(data)).Error; err != nil {
+		return fmt.Errorf("failed to update service config: %w", err)
+	}
+	service.Config = string(data)
+	return nil
+}
+
+// ServiceConfigState is stored config of a service along with the config checks use
+type ServiceConfigState struct {
+	ServiceID uint          `json:"service_id"`
+	Service   string        `json:"service"`
+	Stored    ServiceConfig `json:"stored"`
+	Effective ServiceConfig `json:"effective"`
+}
+
+// ServiceConfigService manages check configuration of spam services
+type ServiceConfigService struct {
+	db     *gorm.DB
+	cfg    *config.Config
+	tuning *CheckTuning
+	log    *logrus.Entry
+}
+
+// NewServiceConfigService creates new service config service
+func NewServiceConfigService(db *gorm.DB, cfg *config.Config) *ServiceConfigService {
+	return &ServiceConfigService{
+		db:     db,
+		cfg:    cfg,
+		tuning: NewCheckTuning(db, cfg),
+		log:    logger.WithField("service", "ServiceConfigService"),
+	}
+}
+
+func (s *ServiceConfigService) getService(serviceID uint) (*models.SpamService, error) {
+	var service models.SpamService
+	if err := s.db.First(&service, serviceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("service not found")
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	return &service, nil
+}
+
+func (s *ServiceConfigService) state(service *models.SpamService) *ServiceConfigState {
+	stored := storedServiceConfig(service)
+	defaults := loadServiceConfigDefaults(s.db, s.tuning, loadOCRConfig(s.db, s.cfg.OCR))
+	return &ServiceConfigState{
+		ServiceID: service.ID,
+		Service:   service.Code,
+		Stored:    stored,
+		Effective: stored.effective(service.Code, defaults),
+	}
+}
+
+// GetServiceConfig returns stored and effective config of a spam service
+func (s *ServiceConfigService) GetServiceConfig(serviceID uint) (*ServiceConfigState, error) {
+	service, err := s.getService(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.state(service), nil
+}
+
+// UpdateServiceConfig replaces config of a spam service with validated JSON.
+// Validation failures are returned as *ServiceConfigError naming the field.
+func (s *ServiceConfigService) UpdateServiceConfig(serviceID uint, data []byte) (*ServiceConfigState, error) {
+	service, err := s.getService(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := ParseServiceConfig(service.Code, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := updateServiceConfigInTx(s.db, service, func(stored *ServiceConfig) { *stored = *cfg }); err != nil {
+		return nil, err
+	}
+
+	s.log.Infof("Config of service %s updated", service.Code)
+	return s.state(service), nil
+}
+
+// BackfillServiceConfigs saves config of services that have none, built from legacy columns
+// and built-in app of the service. Returns number of services updated.
+func (s *ServiceConfigService) BackfillServiceConfigs() (int, error) {
+	var spamServices []models.SpamService
+	if err := s.db.Where("config IS NULL OR config = ''").Find(&spamServices).Error; err != nil {
+		return 0, fmt.Errorf("failed to get services without config: %w", err)
+	}
+
+	updated := 0
+	for i := range spamServices {
+		service := &spamServices[i]
+		err := updateServiceConfigInTx(s.db, service, func(cfg *ServiceConfig) {
+			if cfg.App.Package == "" {
+				cfg.App.Package, cfg.App.Activity = defaultAppInfo(service.Code)
+			}
+		})
+		if err != nil {
+			s.log.Warnf("Failed to backfill config of service %s: %v", service.Code, err)
+			continue
+		}
+		updated++
+	}
+	return updated, nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidServiceExclusion is returned when exclusion refers to unknown spam service or campaign
+var ErrInvalidServiceExclusion = errors.New("invalid service exclusion")
+
+// ErrServiceExclusionNotFound is returned when phone or its exclusion does not exist
+var ErrServiceExclusionNotFound = errors.New("service exclusion not found")
+
+// ServiceExclusionBulkReport summarizes exclusion applied to or removed from phones of a campaign
+type ServiceExclusionBulkReport struct {
+	Campaign    string `json:"campaign"`
+	ServiceCode string `json:"service_code"`
+	Phones      int    `json:"phones"`  // Phones of the campaign
+	Changed     int    `json:"changed"` // Exclusions created or deleted, the rest were already in place
+}
+
+// serviceExclusionsKey is context key of spam service codes a phone must not be checked with
+type serviceExclusionsKey struct{}
+
+// contextWithServiceExclusions returns context skipping gateways and API services of excluded spam services
+func contextWithServiceExclusions(ctx context.Context, excluded map[string]bool) context.Context {
+	return context.WithValue(ctx, serviceExclusionsKey{}, excluded)
+}
+
+// serviceExclusionsFromContext returns spam service codes excluded from the check, nil when none are
+func serviceExclusionsFromContext(ctx context.Context) map[string]bool {
+	if ctx == nil {
+		return nil
+	}
+	excluded, _ := ctx.Value(serviceExclusionsKey{}).(map[string]bool)
+	return excluded
+}
+
+// excludedServiceCodes loads codes of spam services the phone must not be checked with
+func excludedServiceCodes(db *gorm.DB, phoneID uint) (map[string]bool, error) {
+	var codes []string
+	if err := db.Model(&models.PhoneServiceExclusion{}).
+		Joins("JOIN spam_services ON spam_services.id = phone_service_exclusions.service_id").
+		Where("phone_service_exclusions.phone_number_id = ?", phoneID).
+		Pluck("spam_services.code", &codes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load service exclusions: %w", err)
+	}
+
+	excluded := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		excluded[code] = true
+	}
+	return excluded, nil
+}
+
+// excludedServiceIDs loads IDs of spam services the phone must not be checked with
+func excludedServiceIDs(db *gorm.DB, phoneID uint) ([]uint, error) {
+	var ids []uint
+	if err := db.Model(&models.PhoneServiceExclusion{}).
+		Where("phone_number_id = ?", phoneID).
+		Pluck("service_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to load service exclusions: %w", err)
+	}
+	return ids, nil
+}
+
+// skipExcludedGateways removes gateways of excluded services, reporting each of them as excluded
+func skipExcludedGateways(gateways []models.ADBGateway, excluded map[string]bool) ([]models.ADBGateway, []ServiceCheckStatus) {
+	if len(excluded) == 0 {
+		return gateways, nil
+	}
+
+	kept := make([]models.ADBGateway, 0, len(gateways))
+	var skipped []ServiceCheckStatus
+	for _, gateway := range gateways {
+		if excluded[gateway.ServiceCode] {
+			skipped = append(skipped, ServiceCheckStatus{
+				Service: gateway.ServiceCode,
+				Source:  "adb",
+				Status:  ServiceStatusExcluded,
+			})
+			continue
+		}
+		kept = append(kept, gateway)
+	}
+	return kept, skipped
+}
+
+// skipExcludedAPIServices removes API providers of excluded services, reporting each of them as excluded
+func skipExcludedAPIServices(apiServices []models.APIService, excluded map[string]bool) ([]models.APIService, []ServiceCheckStatus) {
+	if len(excluded) == 0 {
+		return apiServices, nil
+	}
+
+	kept := make([]models.APIService, 0, len(apiServices))
+	var skipped []ServiceCheckStatus
+	for _, api := range apiServices {
+		if excluded[api.ServiceCode] {
+			skipped = append(skipped, ServiceCheckStatus{
+				Service: api.ServiceCode,
+				Source:  "api",
+				Status:  ServiceStatusExcluded,
+			})
+			continue
+		}
+		kept = append(kept, api)
+	}
+	return kept, skipped
+}
+
+// exclusionService resolves spam service of an exclusion by code
+func (s *PhoneService) exclusionService(serviceCode string) (*models.SpamService, error) {
+	serviceCode = strings.TrimSpace(serviceCode)
+	if serviceCode == "" {
+		return nil, fmt.Errorf("%w: service_code is required", ErrInvalidServiceExclusion)
+	}
+
+	var service models.SpamService
+	if err := s.db.Where("code = ?", serviceCode).First(&service).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: spam service %q not found", ErrInvalidServiceExclusion, serviceCode)
+		}
+		return nil, fmt.Errorf("failed to get spam service: %w", err)
+	}
+	return &service, nil
+}
+
+// ListServiceExclusions gets spam services the phone is never checked with
+func (s *PhoneService) ListServiceExclusions(phoneID uint) ([]models.PhoneServiceExclusion, error) {
+	exclusions := []models.PhoneServiceExclusion{}
+	if err := s.db.Where("phone_number_id = ?", phoneID).
+		Preload("Service").
+		Order("id").
+		Find(&exclusions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list service exclusions: %w", err)
+	}
+	return exclusions, nil
+}
+
+// AddServiceExclusion excludes spam service from checks of the phone, updating reason of an existing exclusion
+func (s *PhoneService) AddServiceExclusion(phoneID uint, serviceCode, reason string, userID uint) (*models.PhoneServiceExclusion, error) {
+	var phone models.PhoneNumber
+	if err := s.db.Select("id").First(&phone, phoneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("phone %d: %w", phoneID, ErrServiceExclusionNotFound)
+		}
+		return nil, fmt.Errorf("failed to get phone number: %w", err)
+	}
+
+	service, err := s.exclusionService(serviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusion := models.PhoneServiceExclusion{
+		PhoneNumberID: phoneID,
+		ServiceID:     service.ID,
+	}
+	if err := s.db.Where(&exclusion).
+		Assign(map[string]interface{}{"reason": reason}).
+		Attrs(models.PhoneServiceExclusion{CreatedBy: userID}).
+		FirstOrCreate(&exclusion).Error; err != nil {
+		return nil, fmt.Errorf("failed to save service exclusion: %w", err)
+	}
+	exclusion.Service = service
+
+	s.log.Infof("Phone %d excluded from %s checks", phoneID, service.Code)
+	return &exclusion, nil
+}
+
+// RemoveServiceExclusion allows checking the phone with spam service again
+func (s *PhoneService) RemoveServiceExclusion(phoneID uint, serviceCode string) error {
+	service, err := s.exclusionService(serviceCode)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Where("phone_number_id = ? AND service_id = ?", phoneID, service.ID).
+		Delete(&models.PhoneServiceExclusion{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete service exclusion: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("phone %d, service %s: %w", phoneID, service.Code, ErrServiceExclusionNotFound)
+	}
+
+	s.log.Infof("Phone %d allowed for %s checks again", phoneID, service.Code)
+	return nil
+}
+
+// campaignPhoneIDs gets IDs of phones in campaign, uncategorized selects phones without one
+func (s *PhoneService) campaignPhoneIDs(campaign string) ([]uint, error) {
+	campaign = strings.TrimSpace(campaign)
+	if campaign == "" {
+		return nil, fmt.Errorf("%w: campaign is required", ErrInvalidServiceExclusion)
+	}
+
+	query := s.db.Model(&models.PhoneNumber{})
+	if campaign == UncategorizedCampaign {
+		query = query.Where("COALESCE(campaign, '') = ''")
+	} else {
+		query = query.Where("campaign = ?", campaign)
+	}
+
+	var ids []uint
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get campaign phones: %w", err)
+	}
+	return ids, nil
+}
+
+// BulkAddServiceExclusions excludes spam service from checks of every phone in campaign.
+// Phones added to the campaign later are not excluded automatically.
+func (s *PhoneService) BulkAddServiceExclusions(campaign, serviceCode, reason string, userID uint) (*ServiceExclusionBulkReport, error) {
+	service, err := s.exclusionService(serviceCode)
+	if err != nil {
+		return nil, err
+	}
+	phoneIDs, err := s.campaignPhoneIDs(campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ServiceExclusionBulkReport{Campaign: campaign, ServiceCode: service.Code, Phones: len(phoneIDs)}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(phoneIDs); start += generateBatchSize {
+			batch := phoneIDs[start:min(start+generateBatchSize, len(phoneIDs))]
+
+			var existing []uint
+			if err := tx.Model(&models.PhoneServiceExclusion{}).
+				Where("service_id = ? AND phone_number_id IN ?", service.ID, batch).
+				Pluck("phone_number_id", &existing).Error; err != nil {
+				return fmt.Errorf("failed to load service exclusions: %w", err)
+			}
+			skip := make(map[uint]bool, len(existing))
+			for _, id := range existing {
+				skip[id] = true
+			}
+
+			exclusions := make([]models.PhoneServiceExclusion, 0, len(batch))
+			for _, phoneID := range batch {
+				if skip[phoneID] {
+					continue
+				}
+				exclusions = append(exclusions, models.PhoneServiceExclusion{
+					PhoneNumberID: phoneID,
+					ServiceID:     service.ID,
+					Reason:        reason,
+					CreatedBy:     userID,
+				})
+			}
+			if len(exclusions) == 0 {
+				continue
+			}
+			if err := tx.Create(&exclusions).Error; err != nil {
+				return fmt.Errorf("failed to save service exclusions: %w", err)
+			}
+			report.Changed += len(exclusions)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infof("Excluded %d phones of campaign %s from %s checks", report.Changed, campaign, service.Code)
+	return report, nil
+}
+
+// BulkRemoveServiceExclusions allows checking every phone in campaign with spam service again
+func (s *PhoneService) BulkRemoveServiceExclusions(campaign, serviceCode string) (*ServiceExclusionBulkReport, error) {
+	service, err := s.exclusionService(serviceCode)
+	if err != nil {
+		return nil, err
+	}
+	phoneIDs, err := s.campaignPhoneIDs(campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ServiceExclusionBulkReport{Campaign: campaign, ServiceCode: service.Code, Phones: len(phoneIDs)}
+	for start := 0; start < len(phoneIDs); start += generateBatchSize {
+		batch := phoneIDs[start:min(start+generateBatchSize, len(phoneIDs))]
+		result := s.db.Where("service_id = ? AND phone_number_id IN ?", service.ID, batch).
+			Delete(&models.PhoneServiceExclusion{})
+		if result.Error != nil {
+			return report, fmt.Errorf("failed to delete service exclusions: %w", result.Error)
+		}
+		report.Changed += int(result.RowsAffected)
+	}
+
+	s.log.Infof("Removed %s exclusion from %d phones of campaign %s", service.Code, report.Changed, campaign)
+	return report, nil
+}
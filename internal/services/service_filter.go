@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// serviceFilterKey is context key of the spam service a check is limited to
+type serviceFilterKey struct{}
+
+// contextWithServiceFilter returns context limiting the check to gateways and API services of one spam service
+func contextWithServiceFilter(ctx context.Context, serviceCode string) context.Context {
+	return context.WithValue(ctx, serviceFilterKey{}, serviceCode)
+}
+
+// serviceFilterFromContext returns spam service code the check is limited to, empty when it checks all
+func serviceFilterFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	serviceCode, _ := ctx.Value(serviceFilterKey{}).(string)
+	return serviceCode
+}
+
+// filterGatewaysByService keeps gateways of the spam service
+func filterGatewaysByService(gateways []models.ADBGateway, serviceCode string) []models.ADBGateway {
+	filtered := make([]models.ADBGateway, 0, len(gateways))
+	for _, gateway := range gateways {
+		if gateway.ServiceCode == serviceCode {
+			filtered = append(filtered, gateway)
+		}
+	}
+	return filtered
+}
+
+// filterAPIServicesByService keeps API providers of the spam service
+func filterAPIServicesByService(apiServices []models.APIService, serviceCode string) []models.APIService {
+	filtered := make([]models.APIService, 0, len(apiServices))
+	for _, api := range apiServices {
+		if api.ServiceCode == serviceCode {
+			filtered = append(filtered, api)
+		}
+	}
+	return filtered
+}
+
+// validateServiceCodeExists checks that spam service with the code exists
+func validateServiceCodeExists(db *gorm.DB, serviceCode string) error {
+	var service models.SpamService
+	if err := db.Select("id").Where("code = ?", serviceCode).First(&service).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("spam service %q not found", serviceCode)
+		}
+		return fmt.Errorf("failed to get spam service: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"sync"
+	"time"
+)
+
+// settingsCacheTTL defines how long cached settings are used before reloading
+const settingsCacheTTL = time.Minute
+
+// settingsCache holds all system settings loaded with a single query.
+// It is shared by all SettingsService instances and invalidated on every write.
+type settingsCache struct {
+	mu       sync.RWMutex
+	settings map[string]models.SystemSettings
+	loadedAt time.Time
+}
+
+var sharedSettingsCache = &settingsCache{}
+
+// invalidate drops cached settings so the next read reloads them
+func (c *settingsCache) invalidate() {
+	c.mu.Lock()
+	c.settings = nil
+	c.mu.Unlock()
+}
+
+// GetCachedSettingValue gets setting value with type conversion from the settings cache
+func (s *SettingsService) GetCachedSettingValue(key string) (interface{}, error) {
+	cache := sharedSettingsCache
+
+	cache.mu.RLock()
+	fresh := cache.settings != nil && time.Since(cache.loadedAt) < settingsCacheTTL
+	setting, exists := cache.settings[key]
+	cache.mu.RUnlock()
+
+	if !fresh {
+		var settings []models.SystemSettings
+		if err := s.db.Find(&settings).Error; err != nil {
+			return nil, fmt.Errorf("failed to load settings: %w", err)
+		}
+
+		loaded := make(map[string]models.SystemSettings, len(settings))
+		for _, item := range settings {
+			loaded[item.Key] = item
+		}
+
+		cache.mu.Lock()
+		cache.settings = loaded
+		cache.loadedAt = time.Now()
+		cache.mu.Unlock()
+		applyPhoneMasking(loaded)
+
+		setting, exists = loaded[key]
+	}
+
+	if !exists {
+		return nil, errors.New("setting not found")
+	}
+
+	return convertSettingValue(&setting)
+}
+
+// GetCachedBool returns cached bool setting or default value if missing or invalid
+func (s *SettingsService) GetCachedBool(key string, defaultValue bool) bool {
+	value, err := s.GetCachedSettingValue(key)
+	if err != nil {
+		return defaultValue
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		// Settings stored with non-bool type
+		return v != "false" && v != "0" && v != ""
+	default:
+		return defaultValue
+	}
+}
+
+// GetCachedInt returns cached int setting or default value if missing or invalid
+func (s *SettingsService) GetCachedInt(key string, defaultValue int) int {
+	value, err := s.GetCachedSettingValue(key)
+	if err != nil {
+		return defaultValue
+	}
+
+	if v, ok := value.(int); ok {
+		return v
+	}
+	return defaultValue
+}
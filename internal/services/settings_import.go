@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"spam-checker/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Settings import actions
+const (
+	SettingsImportCreate    = "create"
+	SettingsImportUpdate    = "update"
+	SettingsImportUnchanged = "unchanged"
+	SettingsImportInvalid   = "invalid"
+)
+
+// settingTypes are types a setting may be declared with
+var settingTypes = []string{"string", "int", "bool", "float", "json"}
+
+// SettingsImportChange describes what import does with one setting
+type SettingsImportChange struct {
+	Key      string `json:"key"`
+	Action   string `json:"action"` // create, update, unchanged or invalid
+	Type     string `json:"type,omitempty"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SettingsImportReport summarizes settings import or its preview
+type SettingsImportReport struct {
+	DryRun    bool                   `json:"dry_run"`
+	Applied   bool                   `json:"applied"`
+	Created   int                    `json:"created"`
+	Updated   int                    `json:"updated"`
+	Unchanged int                    `json:"unchanged"`
+	Invalid   int                    `json:"invalid"`
+	Changes   []SettingsImportChange `json:"changes"`
+}
+
+// ErrSettingsImportInvalid is returned when import contains invalid settings, nothing is applied then
+var ErrSettingsImportInvalid = errors.New("settings import contains invalid settings")
+
+// ImportSettings validates every setting against its declared type and setting rules and
+// applies them in one transaction. Invalid settings reject the whole import. With dryRun
+// only the report of what would change is returned.
+func (s *SettingsService) ImportSettings(data []byte, dryRun bool) (*SettingsImportReport, error) {
+	var settings []models.SystemSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	}
+
+	var existingSettings []models.SystemSettings
+	if err := s.db.Find(&existingSettings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	existing := make(map[string]models.SystemSettings, len(existingSettings))
+	for _, setting := range existingSettings {
+		existing[setting.Key] = setting
+	}
+
+	report := &SettingsImportReport{DryRun: dryRun, Changes: make([]SettingsImportChange, 0, len(settings))}
+	seen := make(map[string]bool, len(settings))
+	for _, setting := range settings {
+		change := s.previewSettingImport(setting, existing, seen)
+		switch change.Action {
+		case SettingsImportCreate:
+			report.Created++
+		case SettingsImportUpdate:
+			report.Updated++
+		case SettingsImportUnchanged:
+			report.Unchanged++
+		case SettingsImportInvalid:
+			report.Invalid++
+		}
+		report.Changes = append(report.Changes, change)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if report.Invalid > 0 {
+		return report, ErrSettingsImportInvalid
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for i, change := range report.Changes {
+			switch change.Action {
+			case SettingsImportCreate:
+				setting := settings[i]
+				setting.ID = 0
+				setting.Type = change.Type
+				if err := tx.Create(&setting).Error; err != nil {
+					return fmt.Errorf("failed to create %s: %w", change.Key, err)
+				}
+			case SettingsImportUpdate:
+				if err := tx.Model(&models.SystemSettings{}).
+					Where("key = ?", change.Key).
+					Update("value", change.NewValue).Error; err != nil {
+					return fmt.Errorf("failed to update %s: %w", change.Key, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sharedSettingsCache.invalidate()
+
+	report.Applied = true
+	s.log.Infof("Imported settings: %d created, %d updated, %d unchanged", report.Created, report.Updated, report.Unchanged)
+	return report, nil
+}
+
+// previewSettingImport validates one imported setting and decides what import does with it.
+// Existing settings keep their type, value is validated against it.
+func (s *SettingsService) previewSettingImport(setting models.SystemSettings, existing map[string]models.SystemSettings, seen map[string]bool) SettingsImportChange {
+	change := SettingsImportChange{
+		Key:      strings.TrimSpace(setting.Key),
+		Type:     setting.Type,
+		NewValue: setting.Value,
+		Action:   SettingsImportInvalid,
+	}
+
+	if change.Key == "" {
+		change.Error = "key is required"
+		return change
+	}
+	if seen[change.Key] {
+		change.Error = "duplicate key in import"
+		return change
+	}
+	seen[change.Key] = true
+
+	current, exists := existing[change.Key]
+	if exists {
+		if setting.Type != "" && setting.Type != current.Type {
+			change.Error = fmt.Sprintf("type %q does not match existing type %q", setting.Type, current.Type)
+			return change
+		}
+		change.Type = current.Type
+		change.OldValue = current.Value
+	} else if !slices.Contains(settingTypes, setting.Type) {
+		change.Error = fmt.Sprintf("unknown type %q, expected one of %s", setting.Type, strings.Join(settingTypes, ", "))
+		return change
+	}
+
+	if err := validateSettingRules(change.Key, change.Type, setting.Value); err != nil {
+		change.Error = err.Error()
+		return change
+	}
+	if err := s.validateSettingValue(change.Type, setting.Value); err != nil {
+		change.Error = err.Error()
+		return change
+	}
+
+	switch {
+	case !exists:
+		change.Action = SettingsImportCreate
+	case current.Value == setting.Value:
+		change.Action = SettingsImportUnchanged
+	default:
+		change.Action = SettingsImportUpdate
+	}
+	return change
+}
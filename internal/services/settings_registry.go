@@ -0,0 +1,192 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"spam-checker/internal/models"
+	"strconv"
+	"strings"
+)
+
+// SettingConstraints describes values accepted by a known setting
+type SettingConstraints struct {
+	Type    string   `json:"type"`
+	Min     *int     `json:"min,omitempty"`
+	Max     *int     `json:"max,omitempty"`
+	Allowed []string `json:"allowed,omitempty"`
+
+	// validate checks format that cannot be expressed as range or allowed values
+	validate func(value string) error
+}
+
+// SettingWithConstraints is a setting along with values it accepts.
+// Unknown settings are not validated beyond their declared type.
+type SettingWithConstraints struct {
+	models.SystemSettings
+	Validated   bool                `json:"validated"`
+	Constraints *SettingConstraints `json:"constraints,omitempty"`
+}
+
+func intSetting(minValue, maxValue int) SettingConstraints {
+	return SettingConstraints{Type: "int", Min: &minValue, Max: &maxValue}
+}
+
+func minIntSetting(minValue int) SettingConstraints {
+	return SettingConstraints{Type: "int", Min: &minValue}
+}
+
+func boolSetting() SettingConstraints {
+	return SettingConstraints{Type: "bool"}
+}
+
+func enumSetting(allowed ...string) SettingConstraints {
+	return SettingConstraints{Type: "string", Allowed: allowed}
+}
+
+func formatSetting(settingType string, validate func(value string) error) SettingConstraints {
+	return SettingConstraints{Type: settingType, validate: validate}
+}
+
+// settingRegistry lists settings the application reads along with their constraints.
+// Ranges follow what the readers accept, so a value saved here is never silently replaced by a default.
+var settingRegistry = map[string]SettingConstraints{
+	// Scheduler
+	"check_interval_minutes":     intSetting(1, 1440),
+	"scheduler_watchdog_minutes": intSetting(0, 10080),
+	intervalAlignmentSettingKey:  enumSetting(IntervalAlignmentRelative, IntervalAlignmentWallClock),
+	sampleSizeSettingKey:         intSetting(0, maxSampleSize),
+	samplePercentSettingKey:      intSetting(0, 100),
+	sampleMaxStalenessSettingKey: intSetting(1, maxSampleMaxStalenessHours),
+	activeWindowSettingKey: formatSetting("json", func(value string) error {
+		_, err := parseActiveWindow(value)
+		return err
+	}),
+
+	// Performance
+	"max_concurrent_checks":          intSetting(1, 50),
+	"gateway_status_parallelism":     intSetting(1, 64),
+	"gateway_status_timeout_seconds": intSetting(1, 600),
+	"gateway_status_jitter_seconds":  intSetting(0, 3600),
+	"check_retry_budget":             intSetting(0, 1000),
+	minCallIntervalSettingKey:        intSetting(0, maxMinCallIntervalSeconds),
+	callIntervalPolicySettingKey:     enumSetting(CallIntervalPolicyWait, CallIntervalPolicyFail),
+	consoleSpacingSettingKey:         intSetting(0, 10000),
+
+	// OCR
+	"screenshot_quality":       intSetting(1, 100),
+	"ocr_confidence_threshold": intSetting(0, 100),
+	"ocr_min_text_length":      intSetting(0, 1000),
+	ocrMaxConcurrentSettingKey: intSetting(0, 256),
+	ocrEngineSettingKey:        enumSetting(OCREngineExec, OCREnginePersistent),
+	tesseractPathSettingKey: formatSetting("string", func(value string) error {
+		return validateOCRSettingFormat(tesseractPathSettingKey, value)
+	}),
+	ocrLanguageSettingKey: formatSetting("string", func(value string) error {
+		return validateOCRSettingFormat(ocrLanguageSettingKey, value)
+	}),
+
+	// Notifications
+	"enable_notifications":                boolSetting(),
+	"notify_on_spam_detection":            boolSetting(),
+	"notify_on_clean_runs":                boolSetting(),
+	"notify_on_run_completion":            boolSetting(),
+	"notify_on_errors":                    boolSetting(),
+	"notification_batch_size":             intSetting(1, 1000),
+	"notify_error_count_threshold":        intSetting(0, 100000),
+	"notify_error_rate_percent":           intSetting(0, 100),
+	"notification_degrade_after_failures": intSetting(1, 100),
+	spamVerificationSettingKey:            boolSetting(),
+	spamVerificationDelaySettingKey:       intSetting(1, maxSpamVerificationDelayMinutes),
+	anomalyDetectionSettingKey:            boolSetting(),
+	anomalyBaselineRunsSettingKey:         intSetting(minAnomalyRuns, maxAnomalyBaselineRuns),
+	anomalyMinRunsSettingKey:              intSetting(minAnomalyRuns, maxAnomalyBaselineRuns),
+	anomalyThresholdSettingKey:            intSetting(1, maxAnomalyThreshold),
+	anomalyMinChangeSettingKey:            intSetting(0, 100),
+
+	// General
+	"check_mode":                        enumSetting(string(models.CheckModeADBOnly), string(models.CheckModeAPIOnly), string(models.CheckModeBoth)),
+	restartAppOnCrashSettingKey:         boolSetting(),
+	checkEventsSettingKey:               boolSetting(),
+	"public_status_enabled":             boolSetting(),
+	"phone_import_sync_max_rows":        intSetting(0, 1000000),
+	campaignPatternSettingKey:           formatSetting("string", validateCampaignPattern),
+	realtimeQuotaDailySettingKey:        minIntSetting(0),
+	realtimeQuotaPerMinuteSettingKey:    minIntSetting(0),
+	realtimeQuotaCachedWeightSettingKey: intSetting(0, 100),
+	realtimePhoneTTLSettingKey:          intSetting(0, maxRealtimePhoneTTLHours),
+	idempotencyKeyTTLSettingKey:         intSetting(1, maxIdempotencyKeyTTLHours),
+	resultMaxAgeSettingKey:              intSetting(0, maxResultAgeHours),
+	minSpamConfidenceSettingKey:         intSetting(0, 100),
+	keywordNegationsSettingKey:          formatSetting("string", nil),
+	phoneListSortSettingKey:             enumSetting(PhoneSortColumns...),
+	phoneListOrderSettingKey:            enumSetting("asc", "desc"),
+	maskPhoneNumbersSettingKey:          boolSetting(),
+
+	// Asterisk
+	erroredNumberPolicySettingKey: enumSetting(ErroredNumberPolicyAllow, ErroredNumberPolicyExclude),
+	requireCleanCheckSettingKey:   boolSetting(),
+	allocationStrategySettingKey: enumSetting(AllocationStrategyWeighted, AllocationStrategyRoundRobin,
+		AllocationStrategyLRU, AllocationStrategyRandom),
+}
+
+func init() {
+	// Check pipeline tuning keeps its ranges next to the code reading it
+	for key, limits := range tuningSettingRanges {
+		settingRegistry[key] = intSetting(limits[0], limits[1])
+	}
+}
+
+// lookupSettingConstraints returns constraints of a known setting
+func lookupSettingConstraints(key string) (*SettingConstraints, bool) {
+	constraints, ok := settingRegistry[key]
+	if !ok {
+		return nil, false
+	}
+	return &constraints, true
+}
+
+// check validates value of setting key against constraints
+func (c *SettingConstraints) check(key, settingType, value string) error {
+	if settingType != c.Type {
+		return fmt.Errorf("%s must have type %s, not %s", key, c.Type, settingType)
+	}
+
+	switch c.Type {
+	case "int":
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", key, value)
+		}
+		switch {
+		case c.Min != nil && c.Max != nil && (intValue < *c.Min || intValue > *c.Max):
+			return fmt.Errorf("%s must be between %d and %d, got %d", key, *c.Min, *c.Max, intValue)
+		case c.Min != nil && intValue < *c.Min:
+			return fmt.Errorf("%s must be at least %d, got %d", key, *c.Min, intValue)
+		case c.Max != nil && intValue > *c.Max:
+			return fmt.Errorf("%s must be at most %d, got %d", key, *c.Max, intValue)
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", key, value)
+		}
+	}
+
+	if len(c.Allowed) > 0 && !slices.Contains(c.Allowed, value) {
+		return fmt.Errorf("%s must be one of %s, got %q", key, strings.Join(c.Allowed, ", "), value)
+	}
+
+	if c.validate != nil {
+		return c.validate(value)
+	}
+	return nil
+}
+
+// DescribeSettings attaches constraints of known settings to settings list
+func DescribeSettings(settings []models.SystemSettings) []SettingWithConstraints {
+	described := make([]SettingWithConstraints, len(settings))
+	for i, setting := range settings {
+		described[i].SystemSettings = setting
+		described[i].Constraints, described[i].Validated = lookupSettingConstraints(setting.Key)
+	}
+	return described
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+
+	"spam-checker/internal/models"
+)
+
+func TestSettingRegistryIntBoundaries(t *testing.T) {
+	for key, constraints := range settingRegistry {
+		if constraints.Type != "int" {
+			continue
+		}
+		t.Run(key, func(t *testing.T) {
+			check := func(value int) error {
+				return validateSettingRules(key, "int", strconv.Itoa(value))
+			}
+
+			if constraints.Min != nil {
+				if err := check(*constraints.Min); err != nil {
+					t.Errorf("minimum %d rejected: %v", *constraints.Min, err)
+				}
+				if err := check(*constraints.Min - 1); err == nil {
+					t.Errorf("one below minimum %d accepted", *constraints.Min)
+				}
+			}
+			if constraints.Max != nil {
+				if err := check(*constraints.Max); err != nil {
+					t.Errorf("maximum %d rejected: %v", *constraints.Max, err)
+				}
+				if err := check(*constraints.Max + 1); err == nil {
+					t.Errorf("one above maximum %d accepted", *constraints.Max)
+				}
+			} else if err := check(1 << 30); err != nil {
+				t.Errorf("large value rejected without maximum: %v", err)
+			}
+
+			zeroAllowed := (constraints.Min == nil || *constraints.Min <= 0) && (constraints.Max == nil || *constraints.Max >= 0)
+			if err := check(0); (err == nil) != zeroAllowed {
+				t.Errorf("zero accepted = %v, want %v", err == nil, zeroAllowed)
+			}
+		})
+	}
+}
+
+func TestSettingRegistryRejectsMalformedValues(t *testing.T) {
+	tests := []struct {
+		key, settingType, value string
+	}{
+		{"max_concurrent_checks", "int", ""},
+		{"max_concurrent_checks", "int", "1.5"},
+		{"max_concurrent_checks", "int", " 3"},
+		{"max_concurrent_checks", "string", "3"},
+		{maskPhoneNumbersSettingKey, "bool", "yes"},
+		{maskPhoneNumbersSettingKey, "int", "1"},
+		{"check_mode", "string", ""},
+		{"check_mode", "string", "ADB_ONLY"},
+		{phoneListOrderSettingKey, "string", "descending"},
+	}
+	for _, tt := range tests {
+		if err := validateSettingRules(tt.key, tt.settingType, tt.value); err == nil {
+			t.Errorf("%s = %q (%s) accepted", tt.key, tt.value, tt.settingType)
+		}
+	}
+}
+
+func TestSettingRegistryAllowsUnknownSettings(t *testing.T) {
+	if err := validateSettingRules("custom_operator_note", "string", "anything"); err != nil {
+		t.Fatalf("unknown setting rejected: %v", err)
+	}
+}
+
+func TestSeededSettingsPassRegistry(t *testing.T) {
+	db := newTestDB(t)
+
+	var settings []models.SystemSettings
+	if err := db.Find(&settings).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(settings) == 0 {
+		t.Fatal("no settings seeded")
+	}
+	for _, setting := range settings {
+		if err := validateSettingRules(setting.Key, setting.Type, setting.Value); err != nil {
+			t.Errorf("seeded %s = %q is invalid: %v", setting.Key, setting.Value, err)
+		}
+	}
+}
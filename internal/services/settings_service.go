@@ -8,10 +8,14 @@ import (
 	"spam-checker/internal/logger"
 	"spam-checker/internal/models"
 	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
+// MaxSchedulePhones limits size of explicit phone list per schedule
+const MaxSchedulePhones = 500
+
 type SettingsService struct {
 	db  *gorm.DB
 	log *logrus.Entry
@@ -43,6 +47,11 @@ func (s *SettingsService) GetSettingValue(key string) (interface{}, error) {
 		return nil, err
 	}
 
+	return convertSettingValue(setting)
+}
+
+// convertSettingValue converts stored string value according to setting type
+func convertSettingValue(setting *models.SystemSettings) (interface{}, error) {
 	switch setting.Type {
 	case "int":
 		return strconv.Atoi(setting.Value)
@@ -97,6 +106,11 @@ func (s *SettingsService) UpdateSetting(key string, value interface{}) error {
 		stringValue = fmt.Sprintf("%v", value)
 	}
 
+	// Known settings are checked against their constraints first, so errors name the setting
+	if err := validateSettingRules(key, setting.Type, stringValue); err != nil {
+		return err
+	}
+
 	// Validate value based on type
 	if err := s.validateSettingValue(setting.Type, stringValue); err != nil {
 		return err
@@ -106,13 +120,30 @@ func (s *SettingsService) UpdateSetting(key string, value interface{}) error {
 	if err := s.db.Model(setting).Update("value", stringValue).Error; err != nil {
 		return fmt.Errorf("failed to update setting: %w", err)
 	}
+	sharedSettingsCache.invalidate()
+	if key == maskPhoneNumbersSettingKey {
+		s.SyncPhoneMasking()
+	}
 
 	return nil
 }
 
+// validateSettingRules checks value of a known setting against its registered constraints.
+// Unknown settings are only checked against their declared type.
+func validateSettingRules(key, settingType, value string) error {
+	constraints, ok := lookupSettingConstraints(key)
+	if !ok {
+		return nil
+	}
+	return constraints.check(key, settingType, value)
+}
+
 // CreateSetting creates a new setting
 func (s *SettingsService) CreateSetting(setting *models.SystemSettings) error {
 	// Validate value
+	if err := validateSettingRules(setting.Key, setting.Type, setting.Value); err != nil {
+		return err
+	}
 	if err := s.validateSettingValue(setting.Type, setting.Value); err != nil {
 		return err
 	}
@@ -123,6 +154,7 @@ func (s *SettingsService) CreateSetting(setting *models.SystemSettings) error {
 		}
 		return fmt.Errorf("failed to create setting: %w", err)
 	}
+	sharedSettingsCache.invalidate()
 
 	return nil
 }
@@ -133,6 +165,7 @@ func (s *SettingsService) DeleteSetting(key string) error {
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete setting: %w", result.Error)
 	}
+	sharedSettingsCache.invalidate()
 	if result.RowsAffected == 0 {
 		return errors.New("setting not found")
 	}
@@ -253,37 +286,6 @@ func (s *SettingsService) GetSettingsGroups() (map[string][]models.SystemSetting
 	return groups, nil
 }
 
-// ImportSettings imports settings from JSON
-func (s *SettingsService) ImportSettings(data []byte) error {
-	var settings []models.SystemSettings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return fmt.Errorf("failed to parse settings: %w", err)
-	}
-
-	for _, setting := range settings {
-		// Validate setting
-		if err := s.validateSettingValue(setting.Type, setting.Value); err != nil {
-			return fmt.Errorf("invalid value for %s: %w", setting.Key, err)
-		}
-
-		// Update or create setting
-		_, err := s.GetSetting(setting.Key)
-		if err == nil {
-			// Update existing
-			if err := s.UpdateSetting(setting.Key, setting.Value); err != nil {
-				return fmt.Errorf("failed to update %s: %w", setting.Key, err)
-			}
-		} else {
-			// Create new
-			if err := s.CreateSetting(&setting); err != nil {
-				return fmt.Errorf("failed to create %s: %w", setting.Key, err)
-			}
-		}
-	}
-
-	return nil
-}
-
 // ExportSettings exports all settings to JSON
 func (s *SettingsService) ExportSettings() ([]byte, error) {
 	settings, err := s.GetAllSettings()
@@ -294,13 +296,14 @@ func (s *SettingsService) ExportSettings() ([]byte, error) {
 	return json.MarshalIndent(settings, "", "  ")
 }
 
-// GetSpamKeywords gets all spam keywords
-func (s *SettingsService) GetSpamKeywords() ([]models.SpamKeyword, error) {
+// GetSpamKeywords gets spam keywords with total count, zero limit gets all
+func (s *SettingsService) GetSpamKeywords(offset, limit int) ([]models.SpamKeyword, int64, error) {
 	var keywords []models.SpamKeyword
-	if err := s.db.Preload("Service").Order("keyword").Find(&keywords).Error; err != nil {
-		return nil, fmt.Errorf("failed to get spam keywords: %w", err)
+	total, err := findPage(s.db.Preload("Service").Order("keyword, id"), offset, limit, &keywords)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get spam keywords: %w", err)
 	}
-	return keywords, nil
+	return keywords, total, nil
 }
 
 // CreateSpamKeyword creates a new spam keyword
@@ -378,13 +381,14 @@ func (s *SettingsService) DeleteSpamKeyword(id uint) error {
 	return nil
 }
 
-// GetCheckSchedules gets all check schedules
-func (s *SettingsService) GetCheckSchedules() ([]models.CheckSchedule, error) {
+// GetCheckSchedules gets check schedules with total count, zero limit gets all
+func (s *SettingsService) GetCheckSchedules(offset, limit int) ([]models.CheckSchedule, int64, error) {
 	var schedules []models.CheckSchedule
-	if err := s.db.Order("name").Find(&schedules).Error; err != nil {
-		return nil, fmt.Errorf("failed to get check schedules: %w", err)
+	total, err := findPage(s.db.Order("name, id"), offset, limit, &schedules)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get check schedules: %w", err)
 	}
-	return schedules, nil
+	return schedules, total, nil
 }
 
 // CreateCheckSchedule creates a new check schedule
@@ -394,6 +398,26 @@ func (s *SettingsService) CreateCheckSchedule(schedule *models.CheckSchedule) er
 		return fmt.Errorf("invalid cron expression: %w", err)
 	}
 
+	if schedule.CheckMode != "" {
+		if err := validateCheckMode(schedule.CheckMode); err != nil {
+			return err
+		}
+	}
+	if schedule.ServiceCode != "" {
+		if err := validateServiceCodeExists(s.db, schedule.ServiceCode); err != nil {
+			return err
+		}
+	}
+	if schedule.OverlapPolicy == "" {
+		schedule.OverlapPolicy = models.ScheduleOverlapSkip
+	}
+	if err := validateOverlapPolicy(schedule.OverlapPolicy); err != nil {
+		return err
+	}
+	if err := validateCheckSampling(schedule.SampleSize, schedule.SamplePercent, schedule.SampleMaxStalenessHours); err != nil {
+		return err
+	}
+
 	// Check if name already exists
 	var existing models.CheckSchedule
 	if err := s.db.Where("name = ?", schedule.Name).First(&existing).Error; err == nil {
@@ -425,6 +449,38 @@ func (s *SettingsService) UpdateCheckSchedule(id uint, updates map[string]interf
 		}
 	}
 
+	// Empty check mode or service code clears the override
+	if mode, ok := updates["check_mode"].(models.CheckMode); ok && mode != "" {
+		if err := validateCheckMode(mode); err != nil {
+			return err
+		}
+	}
+	if serviceCode, ok := updates["service_code"].(string); ok && serviceCode != "" {
+		if err := validateServiceCodeExists(s.db, serviceCode); err != nil {
+			return err
+		}
+	}
+	if policy, ok := updates["overlap_policy"].(string); ok {
+		if err := validateOverlapPolicy(policy); err != nil {
+			return err
+		}
+	}
+
+	// Sampling is validated as a whole, so switching from size to percent must clear the size
+	size, percent, maxStaleness := schedule.SampleSize, schedule.SamplePercent, schedule.SampleMaxStalenessHours
+	if value, ok := updates["sample_size"].(int); ok {
+		size = value
+	}
+	if value, ok := updates["sample_percent"].(int); ok {
+		percent = value
+	}
+	if value, ok := updates["sample_max_staleness_hours"].(int); ok {
+		maxStaleness = value
+	}
+	if err := validateCheckSampling(size, percent, maxStaleness); err != nil {
+		return err
+	}
+
 	// Check for duplicate name if name is being updated
 	if newName, ok := updates["name"].(string); ok && newName != schedule.Name {
 		var existing models.CheckSchedule
@@ -442,14 +498,274 @@ func (s *SettingsService) UpdateCheckSchedule(id uint, updates map[string]interf
 
 // DeleteCheckSchedule deletes a check schedule
 func (s *SettingsService) DeleteCheckSchedule(id uint) error {
-	result := s.db.Delete(&models.CheckSchedule{}, id)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", id).Delete(&models.SchedulePhone{}).Error; err != nil {
+			return fmt.Errorf("failed to delete schedule phones: %w", err)
+		}
+		if err := tx.Where("schedule_id = ?", id).Delete(&models.ScheduleRun{}).Error; err != nil {
+			return fmt.Errorf("failed to delete schedule runs: %w", err)
+		}
+
+		result := tx.Delete(&models.CheckSchedule{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete check schedule: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("schedule not found")
+		}
+		return nil
+	})
+}
+
+// GetCheckScheduleDetails gets check schedule with its explicit phone list and resolved phone count
+func (s *SettingsService) GetCheckScheduleDetails(id uint) (map[string]interface{}, error) {
+	var schedule models.CheckSchedule
+	if err := s.db.First(&schedule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("schedule not found")
+		}
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	phoneIDs := []uint{}
+	if err := s.db.Model(&models.SchedulePhone{}).
+		Where("schedule_id = ?", id).
+		Order("phone_number_id").
+		Pluck("phone_number_id", &phoneIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get schedule phones: %w", err)
+	}
+
+	phones, err := NewPhoneService(s.db).GetActivePhonesForSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"schedule":             schedule,
+		"phone_ids":            phoneIDs,
+		"resolved_phone_count": len(phones),
+	}, nil
+}
+
+// AddSchedulePhones adds phones to schedule's explicit phone list
+func (s *SettingsService) AddSchedulePhones(scheduleID uint, phoneIDs []uint) (int, error) {
+	if len(phoneIDs) == 0 {
+		return 0, errors.New("phone_ids cannot be empty")
+	}
+
+	var schedule models.CheckSchedule
+	if err := s.db.First(&schedule, scheduleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("schedule not found")
+		}
+		return 0, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	// Deduplicate and skip phones already in the list
+	var existingIDs []uint
+	if err := s.db.Model(&models.SchedulePhone{}).Where("schedule_id = ?", scheduleID).Pluck("phone_number_id", &existingIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to get schedule phones: %w", err)
+	}
+	seen := make(map[uint]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		seen[id] = true
+	}
+
+	var newIDs []uint
+	for _, id := range phoneIDs {
+		if !seen[id] {
+			seen[id] = true
+			newIDs = append(newIDs, id)
+		}
+	}
+
+	if len(newIDs) == 0 {
+		return 0, nil
+	}
+
+	if len(existingIDs)+len(newIDs) > MaxSchedulePhones {
+		return 0, fmt.Errorf("schedule phone list cannot exceed %d phones", MaxSchedulePhones)
+	}
+
+	// All phones must exist
+	var found int64
+	if err := s.db.Model(&models.PhoneNumber{}).Where("id IN ?", newIDs).Count(&found).Error; err != nil {
+		return 0, fmt.Errorf("failed to validate phones: %w", err)
+	}
+	if int(found) != len(newIDs) {
+		return 0, errors.New("one or more phones not found")
+	}
+
+	memberships := make([]models.SchedulePhone, len(newIDs))
+	for i, id := range newIDs {
+		memberships[i] = models.SchedulePhone{
+			ScheduleID:    scheduleID,
+			PhoneNumberID: id,
+		}
+	}
+
+	if err := s.db.Create(&memberships).Error; err != nil {
+		return 0, fmt.Errorf("failed to add schedule phones: %w", err)
+	}
+
+	return len(newIDs), nil
+}
+
+// RemoveSchedulePhones removes phones from schedule's explicit phone list
+func (s *SettingsService) RemoveSchedulePhones(scheduleID uint, phoneIDs []uint) (int64, error) {
+	if len(phoneIDs) == 0 {
+		return 0, errors.New("phone_ids cannot be empty")
+	}
+
+	result := s.db.Where("schedule_id = ? AND phone_number_id IN ?", scheduleID, phoneIDs).Delete(&models.SchedulePhone{})
 	if result.Error != nil {
-		return fmt.Errorf("failed to delete check schedule: %w", result.Error)
+		return 0, fmt.Errorf("failed to remove schedule phones: %w", result.Error)
 	}
-	if result.RowsAffected == 0 {
-		return errors.New("schedule not found")
+
+	return result.RowsAffected, nil
+}
+
+// GetServiceCheckScript gets ADB check script for a spam service, defaultScript is used when service has none
+func (s *SettingsService) GetServiceCheckScript(serviceID uint, defaultScript []CheckScriptStep) (map[string]interface{}, error) {
+	var service models.SpamService
+	if err := s.db.First(&service, serviceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("service not found")
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
 	}
-	return nil
+
+	steps := defaultScript
+	isDefault := true
+	if cfg := storedServiceConfig(&service); cfg.CheckScript != nil {
+		steps = cfg.CheckScript
+		isDefault = false
+	}
+
+	return map[string]interface{}{
+		"service_id": service.ID,
+		"service":    service.Code,
+		"steps":      steps,
+		"is_default": isDefault,
+	}, nil
+}
+
+// UpdateServiceCheckScript sets ADB check script for a spam service, empty steps reset it to default
+func (s *SettingsService) UpdateServiceCheckScript(serviceID uint, steps []CheckScriptStep) error {
+	var service models.SpamService
+	if err := s.db.First(&service, serviceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("service not found")
+		}
+		return fmt.Errorf("failed to get service: %w", err)
+	}
+
+	if len(steps) > 0 {
+		if err := ValidateCheckScript(steps); err != nil {
+			return err
+		}
+	} else {
+		steps = nil
+	}
+
+	return updateServiceConfigInTx(s.db, &service, func(cfg *ServiceConfig) {
+		cfg.CheckScript = steps
+	})
+}
+
+// GetServiceReadinessProbe gets gateway readiness probe configuration of a spam service
+func (s *SettingsService) GetServiceReadinessProbe(serviceID uint) (map[string]interface{}, error) {
+	var service models.SpamService
+	if err := s.db.First(&service, serviceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("service not found")
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+
+	defaultPackage, _ := defaultAppInfo(service.Code)
+	cfg := storedServiceConfig(&service)
+	appPackage, _ := cfg.appInfo(service.Code)
+
+	return map[string]interface{}{
+		"service_id":      service.ID,
+		"service":         service.Code,
+		"enabled":         cfg.App.ReadinessProbe,
+		"app_package":     appPackage,
+		"default_package": defaultPackage,
+	}, nil
+}
+
+// UpdateServiceReadinessProbe enables or disables gateway readiness probe of a spam service.
+// Empty app package falls back to the built-in package of the service.
+func (s *SettingsService) UpdateServiceReadinessProbe(serviceID uint, enabled bool, appPackage string) error {
+	var service models.SpamService
+	if err := s.db.First(&service, serviceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("service not found")
+		}
+		return fmt.Errorf("failed to get service: %w", err)
+	}
+
+	appPackage = strings.TrimSpace(appPackage)
+	if defaultPackage, _ := defaultAppInfo(service.Code); enabled && appPackage == "" && defaultPackage == "" {
+		return errors.New("app package is required for services without built-in app")
+	}
+
+	return updateServiceConfigInTx(s.db, &service, func(cfg *ServiceConfig) {
+		if cfg.App.Package != appPackage {
+			// Activity belongs to the previous package
+			cfg.App.Activity = ""
+		}
+		cfg.App.ReadinessProbe = enabled
+		cfg.App.Package = appPackage
+	})
+}
+
+// GetServiceMaxResultAge gets age after which check results of a spam service are stale
+func (s *SettingsService) GetServiceMaxResultAge(serviceID uint) (map[string]interface{}, error) {
+	var service models.SpamService
+	if err := s.db.First(&service, serviceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("service not found")
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+
+	defaultHours := s.GetCachedInt(resultMaxAgeSettingKey, 48)
+	hours := storedServiceConfig(&service).MaxResultAgeHours
+	effectiveHours := hours
+	if effectiveHours == 0 {
+		effectiveHours = defaultHours
+	}
+
+	return map[string]interface{}{
+		"service_id":           service.ID,
+		"service":              service.Code,
+		"max_result_age_hours": hours,
+		"default_hours":        defaultHours,
+		"effective_hours":      effectiveHours,
+	}, nil
+}
+
+// UpdateServiceMaxResultAge sets age after which check results of a spam service are stale.
+// Zero falls back to result_max_age_hours setting.
+func (s *SettingsService) UpdateServiceMaxResultAge(serviceID uint, hours int) error {
+	if err := validateResultMaxAge(hours); err != nil {
+		return err
+	}
+
+	var service models.SpamService
+	if err := s.db.First(&service, serviceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("service not found")
+		}
+		return fmt.Errorf("failed to get service: %w", err)
+	}
+
+	return updateServiceConfigInTx(s.db, &service, func(cfg *ServiceConfig) {
+		cfg.MaxResultAgeHours = hours
+	})
 }
 
 // validateCronExpression validates a cron expression
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Settings of spam rate anomaly detection
+const (
+	anomalyDetectionSettingKey    = "anomaly_detection_enabled"
+	anomalyBaselineRunsSettingKey = "anomaly_baseline_runs"
+	anomalyMinRunsSettingKey      = "anomaly_min_runs"
+	anomalyThresholdSettingKey    = "anomaly_threshold_mad"
+	anomalyMinChangeSettingKey    = "anomaly_min_change_percent"
+)
+
+// Anomaly detection defaults and limits
+const (
+	defaultAnomalyBaselineRuns = 20
+	defaultAnomalyMinRuns      = 10
+	defaultAnomalyThreshold    = 5
+	defaultAnomalyMinChange    = 10
+	minAnomalyRuns             = 3
+	maxAnomalyBaselineRuns     = 500
+	maxAnomalyThreshold        = 100
+)
+
+// madScale makes MAD comparable to standard deviation of normally distributed rates
+const madScale = 1.4826
+
+// minAnomalyScale is the smallest spread in percentage points a deviation is measured in,
+// so a baseline of identical rates does not turn every change into an infinite deviation
+const minAnomalyScale = 0.5
+
+// ServiceSpamRate is spam rate of one spam service in a run
+type ServiceSpamRate struct {
+	Checked int     `json:"checked"`
+	Spam    int     `json:"spam"`
+	Rate    float64 `json:"rate"` // Percent of checked phones
+}
+
+// RunSpamSample is spam counts of a completed run
+type RunSpamSample struct {
+	CheckType     string
+	ScheduleID    uint
+	PhonesChecked int
+	SpamPhones    int
+	Services      map[string]ServiceSpamRate // By service name, rates are computed by RecordRun
+}
+
+// SpamRateBaseline is median and median absolute deviation of spam rates of previous runs
+type SpamRateBaseline struct {
+	Runs   int     `json:"runs"`
+	Median float64 `json:"median"`
+	MAD    float64 `json:"mad"`
+}
+
+// SpamBaseline is current baseline of runs of one check type and schedule
+type SpamBaseline struct {
+	CheckType    string                      `json:"check_type"`
+	ScheduleID   uint                        `json:"schedule_id,omitempty"`
+	ScheduleName string                      `json:"schedule_name,omitempty"`
+	Ready        bool                        `json:"ready"` // Enough runs to alert, see anomaly_min_runs
+	LastRunAt    *time.Time                  `json:"last_run_at,omitempty"`
+	Overall      SpamRateBaseline            `json:"overall"`
+	Services     map[string]SpamRateBaseline `json:"services"`
+}
+
+// SpamRateDeviation is spam rate of a run that deviated from its baseline
+type SpamRateDeviation struct {
+	Service   string  `json:"service,omitempty"` // Empty for the run as a whole
+	Rate      float64 `json:"rate"`
+	Median    float64 `json:"median"`
+	Change    float64 `json:"change"`    // Percentage points, positive means more spam
+	Deviation float64 `json:"deviation"` // Change in scaled MADs
+}
+
+// SpamAnomaly describes a run whose spam rates deviated from the baseline
+type SpamAnomaly struct {
+	CheckType  string              `json:"check_type"`
+	ScheduleID uint                `json:"schedule_id,omitempty"`
+	Deviations []SpamRateDeviation `json:"deviations"`
+}
+
+// anomalySettings are thresholds of anomaly detection read from settings
+type anomalySettings struct {
+	enabled      bool
+	baselineRuns int
+	minRuns      int
+	threshold    float64
+	minChange    float64
+}
+
+// SpamAnomalyDetector compares spam rates of completed runs with median and MAD of previous
+// runs of the same check type and schedule
+type SpamAnomalyDetector struct {
+	db  *gorm.DB
+	log *logrus.Entry
+}
+
+func NewSpamAnomalyDetector(db *gorm.DB) *SpamAnomalyDetector {
+	return &SpamAnomalyDetector{
+		db:  db,
+		log: logger.WithField("service", "SpamAnomalyDetector"),
+	}
+}
+
+// settings reads anomaly detection settings, minimum history never exceeds the baseline window
+func (d *SpamAnomalyDetector) settings() anomalySettings {
+	settings := NewSettingsService(d.db)
+	baselineRuns := settings.GetCachedInt(anomalyBaselineRunsSettingKey, defaultAnomalyBaselineRuns)
+	if baselineRuns < minAnomalyRuns || baselineRuns > maxAnomalyBaselineRuns {
+		baselineRuns = defaultAnomalyBaselineRuns
+	}
+	minRuns := settings.GetCachedInt(anomalyMinRunsSettingKey, defaultAnomalyMinRuns)
+	if minRuns < minAnomalyRuns {
+		minRuns = defaultAnomalyMinRuns
+	}
+	threshold := settings.GetCachedInt(anomalyThresholdSettingKey, defaultAnomalyThreshold)
+	if threshold < 1 || threshold > maxAnomalyThreshold {
+		threshold = defaultAnomalyThreshold
+	}
+	minChange := settings.GetCachedInt(anomalyMinChangeSettingKey, defaultAnomalyMinChange)
+	if minChange < 0 || minChange > 100 {
+		minChange = defaultAnomalyMinChange
+	}
+
+	return anomalySettings{
+		enabled:      settings.GetCachedBool(anomalyDetectionSettingKey, true),
+		baselineRuns: baselineRuns,
+		minRuns:      min(minRuns, baselineRuns),
+		threshold:    float64(threshold),
+		minChange:    float64(minChange),
+	}
+}
+
+// RecordRun compares run with the baseline of its check type and schedule, then adds it to run history.
+// It returns the anomaly when the run deviated, nil when it did not, detection is disabled or there
+// are fewer than anomaly_min_runs previous runs. Runs without checked phones are not recorded.
+func (d *SpamAnomalyDetector) RecordRun(sample RunSpamSample) (*SpamAnomaly, error) {
+	if sample.PhonesChecked == 0 {
+		return nil, nil
+	}
+
+	for name, rate := range sample.Services {
+		rate.Rate = spamRate(rate.Spam, rate.Checked)
+		sample.Services[name] = rate
+	}
+
+	settings := d.settings()
+	history, err := d.history(sample.CheckType, sample.ScheduleID, settings.baselineRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	var anomaly *SpamAnomaly
+	if settings.enabled && len(history) >= settings.minRuns {
+		anomaly = detectSpamAnomaly(sample, history, settings)
+	}
+
+	services, err := json.Marshal(sample.Services)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode service spam rates: %w", err)
+	}
+	record := &models.RunSpamRate{
+		CheckType:     sample.CheckType,
+		ScheduleID:    sample.ScheduleID,
+		PhonesChecked: sample.PhonesChecked,
+		SpamPhones:    sample.SpamPhones,
+		SpamRate:      spamRate(sample.SpamPhones, sample.PhonesChecked),
+		ServiceRates:  string(services),
+		Anomaly:       anomaly != nil,
+		CompletedAt:   time.Now(),
+	}
+	if err := d.db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to save run spam rate: %w", err)
+	}
+
+	// Runs beyond the largest baseline window never enter a baseline
+	var oldest []uint
+	if err := d.db.Model(&models.RunSpamRate{}).
+		Where("check_type = ? AND schedule_id = ?", sample.CheckType, sample.ScheduleID).
+		Order("id DESC").
+		Offset(maxAnomalyBaselineRuns).
+		Limit(1).
+		Pluck("id", &oldest).Error; err == nil && len(oldest) > 0 {
+		if err := d.db.Where("check_type = ? AND schedule_id = ? AND id <= ?", sample.CheckType, sample.ScheduleID, oldest[0]).
+			Delete(&models.RunSpamRate{}).Error; err != nil {
+			d.log.Warnf("Failed to trim run spam rate history: %v", err)
+		}
+	}
+
+	return anomaly, nil
+}
+
+// detectSpamAnomaly lists overall and per-service rates of sample deviating from history by more than
+// the threshold in scaled MADs and by at least the minimum change in percentage points
+func detectSpamAnomaly(sample RunSpamSample, history []models.RunSpamRate, settings anomalySettings) *SpamAnomaly {
+	overall, services := baselinesOf(history)
+	anomaly := &SpamAnomaly{CheckType: sample.CheckType, ScheduleID: sample.ScheduleID}
+
+	if deviation, ok := deviates(spamRate(sample.SpamPhones, sample.PhonesChecked), overall, settings); ok {
+		anomaly.Deviations = append(anomaly.Deviations, deviation)
+	}
+
+	names := make([]string, 0, len(sample.Services))
+	for name := range sample.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		baseline, ok := services[name]
+		if !ok || baseline.Runs < settings.minRuns || sample.Services[name].Checked == 0 {
+			continue
+		}
+		if deviation, ok := deviates(sample.Services[name].Rate, baseline, settings); ok {
+			deviation.Service = name
+			anomaly.Deviations = append(anomaly.Deviations, deviation)
+		}
+	}
+
+	if len(anomaly.Deviations) == 0 {
+		return nil
+	}
+	return anomaly
+}
+
+// deviates reports whether rate is anomalous against baseline
+func deviates(rate float64, baseline SpamRateBaseline, settings anomalySettings) (SpamRateDeviation, bool) {
+	change := rate - baseline.Median
+	deviation := math.Abs(change) / math.Max(baseline.MAD*madScale, minAnomalyScale)
+	return SpamRateDeviation{
+		Rate:      rate,
+		Median:    baseline.Median,
+		Change:    math.Round(change*10) / 10,
+		Deviation: math.Round(deviation*10) / 10,
+	}, deviation >= settings.threshold && math.Abs(change) >= settings.minChange
+}
+
+// history returns up to limit latest recorded runs of check type and schedule
+func (d *SpamAnomalyDetector) history(checkType string, scheduleID uint, limit int) ([]models.RunSpamRate, error) {
+	var runs []models.RunSpamRate
+	if err := d.db.Where("check_type = ? AND schedule_id = ?", checkType, scheduleID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get run spam rates: %w", err)
+	}
+	return runs, nil
+}
+
+// Baselines returns current baselines of every check type and schedule with recorded runs
+func (d *SpamAnomalyDetector) Baselines() ([]SpamBaseline, error) {
+	var keys []struct {
+		CheckType  string
+		ScheduleID uint
+	}
+	if err := d.db.Model(&models.RunSpamRate{}).
+		Select("check_type, schedule_id").
+		Group("check_type, schedule_id").
+		Order("check_type, schedule_id").
+		Scan(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get run spam rate baselines: %w", err)
+	}
+
+	settings := d.settings()
+	baselines := make([]SpamBaseline, 0, len(keys))
+	for _, key := range keys {
+		history, err := d.history(key.CheckType, key.ScheduleID, settings.baselineRuns)
+		if err != nil {
+			return nil, err
+		}
+		overall, services := baselinesOf(history)
+		baseline := SpamBaseline{
+			CheckType:  key.CheckType,
+			ScheduleID: key.ScheduleID,
+			Ready:      len(history) >= settings.minRuns,
+			Overall:    overall,
+			Services:   services,
+		}
+		if len(history) > 0 {
+			baseline.LastRunAt = &history[0].CompletedAt
+		}
+		if key.ScheduleID > 0 {
+			var schedule models.CheckSchedule
+			if err := d.db.Select("name").First(&schedule, key.ScheduleID).Error; err == nil {
+				baseline.ScheduleName = schedule.Name
+			}
+		}
+		baselines = append(baselines, baseline)
+	}
+	return baselines, nil
+}
+
+// baselinesOf computes overall and per-service baselines of runs
+func baselinesOf(runs []models.RunSpamRate) (SpamRateBaseline, map[string]SpamRateBaseline) {
+	overall := make([]float64, 0, len(runs))
+	perService := make(map[string][]float64)
+	for _, run := range runs {
+		overall = append(overall, run.SpamRate)
+
+		var services map[string]ServiceSpamRate
+		if run.ServiceRates == "" || json.Unmarshal([]byte(run.ServiceRates), &services) != nil {
+			continue
+		}
+		for name, rate := range services {
+			if rate.Checked > 0 {
+				perService[name] = append(perService[name], rate.Rate)
+			}
+		}
+	}
+
+	services := make(map[string]SpamRateBaseline, len(perService))
+	for name, rates := range perService {
+		services[name] = baselineOf(rates)
+	}
+	return baselineOf(overall), services
+}
+
+// baselineOf returns median and median absolute deviation of rates
+func baselineOf(rates []float64) SpamRateBaseline {
+	if len(rates) == 0 {
+		return SpamRateBaseline{}
+	}
+	median := medianOf(rates)
+	deviations := make([]float64, len(rates))
+	for i, rate := range rates {
+		deviations[i] = math.Abs(rate - median)
+	}
+	return SpamRateBaseline{
+		Runs:   len(rates),
+		Median: math.Round(median*100) / 100,
+		MAD:    math.Round(medianOf(deviations)*100) / 100,
+	}
+}
+
+// medianOf returns median of values, values are not modified
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// spamRate returns spam percent of checked phones rounded to one decimal
+func spamRate(spam, checked int) float64 {
+	if checked == 0 {
+		return 0
+	}
+	return math.Round(float64(spam)*1000/float64(checked)) / 10
+}
+
+// GetSpamBaselines returns current spam rate baselines used by anomaly detection
+func (s *StatisticsService) GetSpamBaselines() ([]SpamBaseline, error) {
+	return NewSpamAnomalyDetector(s.db).Baselines()
+}
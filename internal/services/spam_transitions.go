@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// spamStatusOf returns status name for verdict
+func spamStatusOf(isSpam bool) string {
+	if isSpam {
+		return models.SpamStatusSpam
+	}
+	return models.SpamStatusClean
+}
+
+// resultStatusOf returns status name of a check result
+func resultStatusOf(result *models.CheckResult) string {
+	if result.Status != "" {
+		return result.Status
+	}
+	if result.Inconclusive {
+		return models.SpamStatusInconclusive
+	}
+	return spamStatusOf(result.IsSpam)
+}
+
+// isVerdictStatus reports whether status is a spam or clean verdict
+func isVerdictStatus(status string) bool {
+	return status == models.SpamStatusSpam || status == models.SpamStatusClean
+}
+
+// saveCheckResultInTx saves check result and records a status transition if the
+// phone's verdict for the service differs from the previous result.
+// Inconclusive, suspected and error results are saved but never change the verdict.
+// A new spam flag waits for a re-check instead when verification is enabled, see SpamVerification.
+func saveCheckResultInTx(tx *gorm.DB, result *models.CheckResult) error {
+	result.Status = resultStatusOf(result)
+	if result.Confidence <= 0 || result.Status != models.SpamStatusSpam {
+		result.Confidence = 1
+	}
+	// Detection too unreliable to count as spam is kept for review
+	if result.Status == models.SpamStatusSpam && result.Confidence < minSpamConfidence(tx) {
+		result.Status = models.SpamStatusSuspected
+		result.IsSpam = false
+	}
+	result.Inconclusive = result.Status == models.SpamStatusInconclusive
+	if result.Status == models.SpamStatusError {
+		result.IsSpam = false
+	}
+
+	if !isVerdictStatus(result.Status) {
+		if err := attachKeywordSnapshot(tx, result); err != nil {
+			return err
+		}
+		if err := tx.Create(result).Error; err != nil {
+			return fmt.Errorf("failed to save check result: %w", err)
+		}
+		return nil
+	}
+
+	// Previous verdict is the latest conclusive result for the same phone and service,
+	// flags waiting for re-check or not confirmed by it are not verdicts
+	fromStatus := models.SpamStatusUnknown
+	var previous models.CheckResult
+	err := tx.Where("phone_number_id = ? AND service_id = ? AND status IN ? AND COALESCE(verification, '') NOT IN ?",
+		result.PhoneNumberID, result.ServiceID,
+		[]string{models.SpamStatusSpam, models.SpamStatusClean}, ignoredVerifications).
+		Order("checked_at DESC, id DESC").
+		First(&previous).Error
+	if err == nil {
+		fromStatus = spamStatusOf(previous.IsSpam)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get previous check result: %w", err)
+	}
+
+	toStatus := spamStatusOf(result.IsSpam)
+
+	// Verdict of a flagged phone resolves the flag, otherwise a new flag may wait for re-check
+	pending, err := pendingSpamVerificationInTx(tx, result.PhoneNumberID, result.ServiceID)
+	if err != nil {
+		return err
+	}
+	hold := pending == nil && toStatus == models.SpamStatusSpam && fromStatus != models.SpamStatusSpam && spamVerificationEnabled(tx)
+	if hold {
+		result.Verification = models.VerificationPending
+	}
+
+	if err := attachKeywordSnapshot(tx, result); err != nil {
+		return err
+	}
+
+	if err := tx.Create(result).Error; err != nil {
+		return fmt.Errorf("failed to save check result: %w", err)
+	}
+
+	if hold {
+		return holdSpamFlagInTx(tx, result, fromStatus)
+	}
+	if pending != nil {
+		if err := resolveSpamVerificationInTx(tx, pending, toStatus); err != nil {
+			return err
+		}
+	}
+
+	// First clean verdict is not a change worth tracking
+	if fromStatus == toStatus || (fromStatus == models.SpamStatusUnknown && toStatus == models.SpamStatusClean) {
+		return nil
+	}
+
+	transition := &models.SpamStatusTransition{
+		PhoneNumberID: result.PhoneNumberID,
+		ServiceID:     result.ServiceID,
+		CheckResultID: result.ID,
+		FromStatus:    fromStatus,
+		ToStatus:      toStatus,
+		Keywords:      result.FoundKeywords,
+		CreatedAt:     result.CheckedAt,
+	}
+
+	if err := tx.Create(transition).Error; err != nil {
+		return fmt.Errorf("failed to save status transition: %w", err)
+	}
+
+	return nil
+}
+
+// recordCheckError saves a failed check as a result with error status
+func recordCheckError(db *gorm.DB, phoneID, serviceID uint, apiServiceID *uint, checkErr error) (*models.CheckResult, error) {
+	result := &models.CheckResult{
+		PhoneNumberID: phoneID,
+		ServiceID:     serviceID,
+		APIServiceID:  apiServiceID,
+		Status:        models.SpamStatusError,
+		Error:         checkErr.Error(),
+		CheckedAt:     time.Now(),
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := saveCheckResultInTx(tx, result); err != nil {
+			return err
+		}
+		return updateStatisticsAtInTx(tx, phoneID, serviceID, models.SpamStatusError, result.CheckedAt)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetSpamTransitions gets spam status transitions of a phone, newest first
+func (s *PhoneService) GetSpamTransitions(phoneID uint, limit int) ([]models.SpamStatusTransition, error) {
+	if err := s.db.Select("id").First(&models.PhoneNumber{}, phoneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("phone number not found")
+		}
+		return nil, fmt.Errorf("failed to get phone number: %w", err)
+	}
+
+	var transitions []models.SpamStatusTransition
+	if err := s.db.Where("phone_number_id = ?", phoneID).
+		Preload("Service").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&transitions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get status transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// GetPendingSpamTransitions gets "became spam" transitions not yet notified about
+func (s *PhoneService) GetPendingSpamTransitions() ([]models.SpamStatusTransition, error) {
+	var transitions []models.SpamStatusTransition
+	if err := s.db.Where("to_status = ? AND notified_at IS NULL", models.SpamStatusSpam).
+		Preload("PhoneNumber").
+		Preload("Service").
+		Order("created_at ASC, id ASC").
+		Find(&transitions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pending transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// MarkSpamTransitionsNotified marks transitions as notified
+func (s *PhoneService) MarkSpamTransitionsNotified(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&models.SpamStatusTransition{}).Where("id IN ?", ids).
+		Update("notified_at", &now).Error; err != nil {
+		return fmt.Errorf("failed to mark transitions notified: %w", err)
+	}
+
+	return nil
+}
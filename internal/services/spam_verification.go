@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Settings controlling re-check of new spam flags before notification
+const (
+	spamVerificationSettingKey      = "spam_verification_enabled"
+	spamVerificationDelaySettingKey = "spam_verification_delay_minutes"
+)
+
+// defaultSpamVerificationDelayMinutes is used when the setting is missing
+const defaultSpamVerificationDelayMinutes = 10
+
+// maxSpamVerificationDelayMinutes bounds the delay, a flag older than a day is no longer news
+const maxSpamVerificationDelayMinutes = 1440
+
+// maxSpamVerificationAttempts is number of re-checks without a verdict after which the flag
+// is notified unverified, so a gateway outage does not hide real spam
+const maxSpamVerificationAttempts = 3
+
+// ignoredVerifications are verification states of results that do not count as a verdict
+var ignoredVerifications = []string{models.VerificationPending, models.VerificationUnconfirmed}
+
+// spamVerificationEnabled reports whether new spam flags wait for a re-check
+func spamVerificationEnabled(db *gorm.DB) bool {
+	return NewSettingsService(db).GetCachedBool(spamVerificationSettingKey, true)
+}
+
+// spamVerificationDelay returns time between a new spam flag and its re-check
+func spamVerificationDelay(db *gorm.DB) time.Duration {
+	minutes := NewSettingsService(db).GetCachedInt(spamVerificationDelaySettingKey, defaultSpamVerificationDelayMinutes)
+	return time.Duration(max(minutes, 1)) * time.Minute
+}
+
+// pendingSpamVerificationInTx returns flag of phone on service waiting for re-check, nil if there is none
+func pendingSpamVerificationInTx(tx *gorm.DB, phoneID, serviceID uint) (*models.SpamVerification, error) {
+	var verification models.SpamVerification
+	err := tx.Where("phone_number_id = ? AND service_id = ?", phoneID, serviceID).
+		Order("id ASC").
+		First(&verification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending spam verification: %w", err)
+	}
+	return &verification, nil
+}
+
+// holdSpamFlagInTx queues re-check of a saved result that flagged the phone as spam
+func holdSpamFlagInTx(tx *gorm.DB, result *models.CheckResult, fromStatus string) error {
+	verification := &models.SpamVerification{
+		PhoneNumberID: result.PhoneNumberID,
+		ServiceID:     result.ServiceID,
+		CheckResultID: result.ID,
+		FromStatus:    fromStatus,
+		DueAt:         result.CheckedAt.Add(spamVerificationDelay(tx)),
+	}
+	if err := tx.Create(verification).Error; err != nil {
+		return fmt.Errorf("failed to save spam verification: %w", err)
+	}
+	return nil
+}
+
+// resolveSpamVerificationInTx marks flagged result confirmed or unconfirmed by the verdict of
+// a newer result and removes the flag from the queue
+func resolveSpamVerificationInTx(tx *gorm.DB, verification *models.SpamVerification, toStatus string) error {
+	state := models.VerificationUnconfirmed
+	if toStatus == models.SpamStatusSpam {
+		state = models.VerificationConfirmed
+	}
+
+	if err := tx.Model(&models.CheckResult{}).Where("id = ?", verification.CheckResultID).
+		Update("verification", state).Error; err != nil {
+		return fmt.Errorf("failed to update flagged check result: %w", err)
+	}
+	if err := tx.Delete(&models.SpamVerification{}, verification.ID).Error; err != nil {
+		return fmt.Errorf("failed to delete spam verification: %w", err)
+	}
+	return nil
+}
+
+// expireSpamVerificationInTx gives up re-checking a flag and records its status transition,
+// so it is notified as if verification was disabled
+func expireSpamVerificationInTx(tx *gorm.DB, verification *models.SpamVerification) error {
+	var flagged models.CheckResult
+	if err := tx.First(&flagged, verification.CheckResultID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get flagged check result: %w", err)
+		}
+	} else {
+		if err := tx.Model(&flagged).Update("verification", models.VerificationUnverified).Error; err != nil {
+			return fmt.Errorf("failed to update flagged check result: %w", err)
+		}
+
+		transition := &models.SpamStatusTransition{
+			PhoneNumberID: flagged.PhoneNumberID,
+			ServiceID:     flagged.ServiceID,
+			CheckResultID: flagged.ID,
+			FromStatus:    verification.FromStatus,
+			ToStatus:      models.SpamStatusSpam,
+			Keywords:      flagged.FoundKeywords,
+			CreatedAt:     flagged.CheckedAt,
+		}
+		if err := tx.Create(transition).Error; err != nil {
+			return fmt.Errorf("failed to save status transition: %w", err)
+		}
+	}
+
+	if err := tx.Delete(&models.SpamVerification{}, verification.ID).Error; err != nil {
+		return fmt.Errorf("failed to delete spam verification: %w", err)
+	}
+	return nil
+}
+
+// VerifySpamFlags re-checks phones whose spam flags are due for verification.
+// Saving the re-check verdict resolves the flag; a re-check without verdict is retried
+// after the delay, and the flag is notified unverified once attempts run out.
+// Returns number of flags resolved.
+func (s *CheckService) VerifySpamFlags(now time.Time) (int, error) {
+	var due []models.SpamVerification
+	if err := s.db.Where("due_at <= ?", now).
+		Preload("Service").
+		Order("due_at ASC, id ASC").
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to get due spam verifications: %w", err)
+	}
+
+	resolved := 0
+	for _, verification := range due {
+		err := s.CheckPhoneNumberWithOptions(verification.PhoneNumberID, PhoneCheckOptions{ServiceCode: verification.Service.Code})
+		if errors.Is(err, ErrCheckInProgress) {
+			// Running check may resolve the flag itself, otherwise it is picked up next time
+			continue
+		}
+		if err != nil {
+			s.log.Warnf("Verification re-check of phone %d on %s failed: %v", verification.PhoneNumberID, verification.Service.Name, err)
+		}
+
+		var remaining models.SpamVerification
+		if err := s.db.First(&remaining, verification.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				resolved++
+				continue
+			}
+			return resolved, fmt.Errorf("failed to get spam verification: %w", err)
+		}
+
+		remaining.Attempts++
+		if remaining.Attempts >= maxSpamVerificationAttempts {
+			s.log.Warnf("Spam flag of phone %d on %s not verified after %d re-checks, notifying anyway",
+				remaining.PhoneNumberID, verification.Service.Name, remaining.Attempts)
+			if err := s.db.Transaction(func(tx *gorm.DB) error {
+				return expireSpamVerificationInTx(tx, &remaining)
+			}); err != nil {
+				return resolved, err
+			}
+			resolved++
+			continue
+		}
+
+		if err := s.db.Model(&remaining).Updates(map[string]interface{}{
+			"attempts": remaining.Attempts,
+			"due_at":   time.Now().Add(spamVerificationDelay(s.db)),
+		}).Error; err != nil {
+			return resolved, fmt.Errorf("failed to postpone spam verification: %w", err)
+		}
+	}
+
+	return resolved, nil
+}
+
+// CountPendingSpamVerifications returns number of spam flags waiting for re-check
+func (s *PhoneService) CountPendingSpamVerifications() (int64, error) {
+	var count int64
+	if err := s.db.Model(&models.SpamVerification{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count spam verifications: %w", err)
+	}
+	return count, nil
+}
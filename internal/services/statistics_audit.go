@@ -0,0 +1,230 @@
+package services
+
+import (
+	"fmt"
+	"spam-checker/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// statisticsDriftSampleLimit caps discrepancies listed in the report, all of them are counted and repaired
+const statisticsDriftSampleLimit = 100
+
+// StatisticsDiscrepancy is statistics row of a phone and service that does not match its check results
+type StatisticsDiscrepancy struct {
+	PhoneNumberID uint               `json:"phone_number_id"`
+	ServiceID     uint               `json:"service_id"`
+	Fields        []string           `json:"fields"`             // Differing columns, missing_row or orphaned_row
+	Stored        *models.Statistics `json:"stored,omitempty"`   // Nil when the row is missing
+	Expected      *models.Statistics `json:"expected,omitempty"` // Nil when the pair has no results
+}
+
+// StatisticsReconcileReport summarizes statistics compared with check results
+type StatisticsReconcileReport struct {
+	DryRun        bool                    `json:"dry_run"`
+	Phones        int                     `json:"phones"`
+	Pairs         int                     `json:"pairs"` // Phone and service pairs compared
+	Discrepancies int                     `json:"discrepancies"`
+	Repaired      int                     `json:"repaired"`
+	Samples       []StatisticsDiscrepancy `json:"samples,omitempty"` // First discrepancies found
+	Duration      string                  `json:"duration"`
+}
+
+// lockStatistics keeps checks from updating statistics rows being replaced until the transaction ends
+func lockStatistics(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := tx.Exec("LOCK TABLE statistics IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+		return fmt.Errorf("failed to lock statistics: %w", err)
+	}
+	return nil
+}
+
+// Reconcile recomputes statistics of every phone and service pair from check results and reports
+// rows that differ. Unless dryRun is set, differing rows are repaired batch by batch, each batch in
+// its own transaction, so checks are blocked only while their batch is written.
+func (s *StatisticsService) Reconcile(dryRun bool) (*StatisticsReconcileReport, error) {
+	started := time.Now()
+	report := &StatisticsReconcileReport{DryRun: dryRun}
+
+	var phoneIDs []uint
+	if err := s.db.Raw("SELECT phone_number_id FROM check_results UNION SELECT phone_number_id FROM statistics ORDER BY 1").
+		Scan(&phoneIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get phones with statistics: %w", err)
+	}
+	report.Phones = len(phoneIDs)
+
+	for start := 0; start < len(phoneIDs); start += statisticsRebuildBatchSize {
+		batch := phoneIDs[start:min(start+statisticsRebuildBatchSize, len(phoneIDs))]
+		if dryRun {
+			if _, err := s.reconcileBatch(s.db, batch, report, false); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := lockStatistics(tx); err != nil {
+				return err
+			}
+			repaired, err := s.reconcileBatch(tx, batch, report, true)
+			report.Repaired += repaired
+			return err
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	report.Duration = time.Since(started).Round(time.Millisecond).String()
+	if report.Discrepancies > 0 {
+		s.log.Warnf("Statistics drift: %d of %d phone/service pairs differ from check results, %d repaired",
+			report.Discrepancies, report.Pairs, report.Repaired)
+	} else {
+		s.log.Infof("Statistics match check results for %d phone/service pairs", report.Pairs)
+	}
+
+	return report, nil
+}
+
+// reconcileBatch compares statistics of phones with their results, repairing differing rows when asked
+func (s *StatisticsService) reconcileBatch(tx *gorm.DB, phoneIDs []uint, report *StatisticsReconcileReport, repair bool) (int, error) {
+	rows, err := statisticsResults(tx, phoneIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	var stored []models.Statistics
+	if err := tx.Where("phone_number_id IN ?", phoneIDs).Find(&stored).Error; err != nil {
+		return 0, fmt.Errorf("failed to get statistics: %w", err)
+	}
+
+	type pairKey struct{ phoneID, serviceID uint }
+	storedPairs := make(map[pairKey]*models.Statistics, len(stored))
+	for i := range stored {
+		storedPairs[pairKey{stored[i].PhoneNumberID, stored[i].ServiceID}] = &stored[i]
+	}
+
+	repaired := 0
+	for _, expected := range aggregateStatistics(rows) {
+		key := pairKey{expected.PhoneNumberID, expected.ServiceID}
+		current := storedPairs[key]
+		delete(storedPairs, key)
+		report.Pairs++
+
+		var fields []string
+		if current == nil {
+			fields = []string{"missing_row"}
+		} else {
+			fields = statisticsDrift(current, &expected)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		report.addDiscrepancy(StatisticsDiscrepancy{
+			PhoneNumberID: key.phoneID,
+			ServiceID:     key.serviceID,
+			Fields:        fields,
+			Stored:        current,
+			Expected:      &expected,
+		})
+		if !repair {
+			continue
+		}
+
+		if current != nil {
+			expected.ID = current.ID
+		}
+		if err := tx.Save(&expected).Error; err != nil {
+			return repaired, fmt.Errorf("failed to repair statistics of phone %d, service %d: %w", key.phoneID, key.serviceID, err)
+		}
+		repaired++
+	}
+
+	// Rows left have no results at all, e.g. results deleted without statistics
+	for key, current := range storedPairs {
+		report.Pairs++
+		report.addDiscrepancy(StatisticsDiscrepancy{
+			PhoneNumberID: key.phoneID,
+			ServiceID:     key.serviceID,
+			Fields:        []string{"orphaned_row"},
+			Stored:        current,
+		})
+		if !repair {
+			continue
+		}
+		if err := tx.Delete(&models.Statistics{}, current.ID).Error; err != nil {
+			return repaired, fmt.Errorf("failed to delete statistics of phone %d, service %d: %w", key.phoneID, key.serviceID, err)
+		}
+		repaired++
+	}
+
+	return repaired, nil
+}
+
+func (r *StatisticsReconcileReport) addDiscrepancy(discrepancy StatisticsDiscrepancy) {
+	r.Discrepancies++
+	if len(r.Samples) < statisticsDriftSampleLimit {
+		r.Samples = append(r.Samples, discrepancy)
+	}
+}
+
+// statisticsDrift lists columns of stored statistics that differ from the recomputed ones
+func statisticsDrift(stored, expected *models.Statistics) []string {
+	var fields []string
+	if stored.TotalChecks != expected.TotalChecks {
+		fields = append(fields, "total_checks")
+	}
+	if stored.SpamCount != expected.SpamCount {
+		fields = append(fields, "spam_count")
+	}
+	if stored.InconclusiveCount != expected.InconclusiveCount {
+		fields = append(fields, "inconclusive_count")
+	}
+	if stored.SuspectedCount != expected.SuspectedCount {
+		fields = append(fields, "suspected_count")
+	}
+	if stored.ErrorCount != expected.ErrorCount {
+		fields = append(fields, "error_count")
+	}
+	if !sameStatisticsTime(stored.FirstSpamDate, expected.FirstSpamDate) {
+		fields = append(fields, "first_spam_date")
+	}
+	if !sameStatisticsTime(&stored.LastCheckDate, &expected.LastCheckDate) {
+		fields = append(fields, "last_check_date")
+	}
+	return fields
+}
+
+// sameStatisticsTime compares times ignoring precision lost when the database stores them
+func sameStatisticsTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Truncate(time.Millisecond).Equal(b.Truncate(time.Millisecond))
+}
+
+// SendStatisticsAudit reports statistics drift found by a dry run, nothing is sent if statistics match
+func (s *NotificationService) SendStatisticsAudit(report *StatisticsReconcileReport) error {
+	if report.Discrepancies == 0 {
+		return nil
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("⚠️ Статистика расходится с результатами проверок: %d из %d пар номер/сервис\n\n",
+		report.Discrepancies, report.Pairs))
+	for i, discrepancy := range report.Samples {
+		if i == 10 {
+			message.WriteString(fmt.Sprintf("  … и ещё %d\n", report.Discrepancies-i))
+			break
+		}
+		message.WriteString(fmt.Sprintf("  • номер #%d, сервис #%d: %s\n",
+			discrepancy.PhoneNumberID, discrepancy.ServiceID, strings.Join(discrepancy.Fields, ", ")))
+	}
+	message.WriteString("\nИсправить: POST /api/v1/statistics/reconcile или команда reconcile-statistics.")
+
+	return s.SendNotification("SpamChecker: расхождение статистики", message.String())
+}
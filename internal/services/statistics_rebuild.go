@@ -0,0 +1,163 @@
+package services
+
+import (
+	"fmt"
+	"spam-checker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// statisticsRebuildBatchSize is the number of phones whose results are aggregated at once
+const statisticsRebuildBatchSize = 500
+
+// StatisticsRebuildResult summarizes statistics recomputed from check results
+type StatisticsRebuildResult struct {
+	Phones   int    `json:"phones"`
+	Rows     int    `json:"rows"`    // Phone and service statistics rows written
+	Results  int64  `json:"results"` // Check results aggregated
+	Removed  int64  `json:"removed"` // Statistics rows replaced
+	Duration string `json:"duration"`
+}
+
+// statisticsResultRow is part of check result statistics are computed from
+type statisticsResultRow struct {
+	PhoneNumberID uint
+	ServiceID     uint
+	Status        string
+	CheckedAt     time.Time
+}
+
+// Rebuild replaces statistics with counters recomputed from check results, phones are
+// processed in batches. Runs in one transaction, so statistics stay consistent if it fails
+// and checks finishing meanwhile wait for it instead of updating rows being replaced.
+func (s *StatisticsService) Rebuild() (*StatisticsRebuildResult, error) {
+	started := time.Now()
+	result := &StatisticsRebuildResult{}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockStatistics(tx); err != nil {
+			return err
+		}
+
+		removed := tx.Where("1 = 1").Delete(&models.Statistics{})
+		if removed.Error != nil {
+			return fmt.Errorf("failed to clear statistics: %w", removed.Error)
+		}
+		result.Removed = removed.RowsAffected
+
+		var phoneIDs []uint
+		if err := tx.Model(&models.CheckResult{}).
+			Distinct("phone_number_id").
+			Order("phone_number_id").
+			Pluck("phone_number_id", &phoneIDs).Error; err != nil {
+			return fmt.Errorf("failed to get checked phones: %w", err)
+		}
+		result.Phones = len(phoneIDs)
+
+		for start := 0; start < len(phoneIDs); start += statisticsRebuildBatchSize {
+			end := min(start+statisticsRebuildBatchSize, len(phoneIDs))
+			rows, results, err := rebuildPhoneStatisticsInTx(tx, phoneIDs[start:end])
+			if err != nil {
+				return err
+			}
+			result.Rows += rows
+			result.Results += results
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Duration = time.Since(started).Round(time.Millisecond).String()
+	s.log.Infof("Rebuilt statistics: %d rows for %d phones from %d results, replaced %d rows",
+		result.Rows, result.Phones, result.Results, result.Removed)
+
+	return result, nil
+}
+
+// statisticsResults returns results of phones statistics are computed from
+func statisticsResults(tx *gorm.DB, phoneIDs []uint) ([]statisticsResultRow, error) {
+	var rows []statisticsResultRow
+	if err := tx.Model(&models.CheckResult{}).
+		Select("phone_number_id, service_id, status, checked_at").
+		Where("phone_number_id IN ?", phoneIDs).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get check results: %w", err)
+	}
+	return rows, nil
+}
+
+// rebuildPhoneStatisticsInTx replaces statistics of phones with counters recomputed from their results.
+// Returns number of statistics rows written and results aggregated.
+func rebuildPhoneStatisticsInTx(tx *gorm.DB, phoneIDs []uint) (int, int64, error) {
+	if err := tx.Where("phone_number_id IN ?", phoneIDs).Delete(&models.Statistics{}).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to clear statistics: %w", err)
+	}
+
+	rows, err := statisticsResults(tx, phoneIDs)
+	if err != nil {
+		return 0, 0, err
+	}
+	stats := aggregateStatistics(rows)
+	if len(stats) == 0 {
+		return 0, int64(len(rows)), nil
+	}
+	if err := tx.CreateInBatches(stats, 100).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to save statistics: %w", err)
+	}
+	return len(stats), int64(len(rows)), nil
+}
+
+// aggregateStatistics counts results per phone and service, see countStatisticsResult
+func aggregateStatistics(rows []statisticsResultRow) []models.Statistics {
+	type pairKey struct{ phoneID, serviceID uint }
+	pairs := make(map[pairKey]*models.Statistics)
+	var order []pairKey
+
+	for _, row := range rows {
+		key := pairKey{row.PhoneNumberID, row.ServiceID}
+		stats, exists := pairs[key]
+		if !exists {
+			stats = &models.Statistics{PhoneNumberID: row.PhoneNumberID, ServiceID: row.ServiceID}
+			pairs[key] = stats
+			order = append(order, key)
+		}
+		countStatisticsResult(stats, row.Status, row.CheckedAt)
+	}
+
+	result := make([]models.Statistics, 0, len(order))
+	for _, key := range order {
+		result = append(result, *pairs[key])
+	}
+	return result
+}
+
+// countStatisticsResult adds a result with status checked at checkedAt to statistics. This is the only
+// place deciding how a status is counted, checks, imports, rebuilds and audits all go through it.
+// Errors are not counted in TotalChecks.
+func countStatisticsResult(stats *models.Statistics, status string, checkedAt time.Time) {
+	switch status {
+	case models.SpamStatusError:
+		stats.ErrorCount++
+	case models.SpamStatusSpam:
+		stats.TotalChecks++
+		stats.SpamCount++
+		if stats.FirstSpamDate == nil || checkedAt.Before(*stats.FirstSpamDate) {
+			stats.FirstSpamDate = &checkedAt
+		}
+	case models.SpamStatusInconclusive:
+		stats.TotalChecks++
+		stats.InconclusiveCount++
+	case models.SpamStatusSuspected:
+		stats.TotalChecks++
+		stats.SuspectedCount++
+	default:
+		stats.TotalChecks++
+	}
+	if checkedAt.After(stats.LastCheckDate) {
+		stats.LastCheckDate = checkedAt
+	}
+}
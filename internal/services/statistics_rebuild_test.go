@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"spam-checker/internal/logger"
+	"spam-checker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestDeleteImportedResultsMatchesRebuild(t *testing.T) {
+	db := newTestDB(t)
+	service := &CheckService{db: db, log: logger.WithField("service", "CheckService")}
+
+	var spamService models.SpamService
+	if err := db.Where("code = ?", "kaspersky").First(&spamService).Error; err != nil {
+		t.Fatal(err)
+	}
+	var admin models.User
+	if err := db.Where("role = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	phone := models.PhoneNumber{Number: "79001112233", IsActive: true, CreatedBy: admin.ID}
+	if err := db.Create(&phone).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	results := []struct {
+		source, status string
+		checkedAt      time.Time
+	}{
+		{models.CheckSourceImport, models.SpamStatusSpam, base},
+		{models.CheckSourceImport, models.SpamStatusError, base.Add(time.Hour)},
+		{models.CheckSourceImport, models.SpamStatusClean, base.Add(5 * time.Hour)},
+		{models.CheckSourceCheck, models.SpamStatusSuspected, base.Add(2 * time.Hour)},
+		{models.CheckSourceCheck, models.SpamStatusSpam, base.Add(3 * time.Hour)},
+		// Inconclusive without the flag set is counted by its status only
+		{models.CheckSourceCheck, models.SpamStatusInconclusive, base.Add(4 * time.Hour)},
+		{models.CheckSourceCheck, models.SpamStatusError, base.Add(30 * time.Minute)},
+	}
+	for _, r := range results {
+		result := models.CheckResult{
+			PhoneNumberID: phone.ID,
+			ServiceID:     spamService.ID,
+			Status:        r.status,
+			IsSpam:        r.status == models.SpamStatusSpam,
+			Source:        r.source,
+			CheckedAt:     r.checkedAt,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&result).Error; err != nil {
+				return err
+			}
+			return updateStatisticsAtInTx(tx, phone.ID, spamService.ID, r.status, r.checkedAt)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := service.DeleteImportedResults("", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 {
+		t.Errorf("DeleteImportedResults() deleted %d results, want 3", deleted)
+	}
+
+	var stats models.Statistics
+	if err := db.Where("phone_number_id = ? AND service_id = ?", phone.ID, spamService.ID).First(&stats).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalChecks != 3 || stats.SpamCount != 1 || stats.SuspectedCount != 1 ||
+		stats.InconclusiveCount != 1 || stats.ErrorCount != 1 {
+		t.Errorf("statistics after delete = %+v", stats)
+	}
+	if stats.FirstSpamDate == nil || !stats.FirstSpamDate.Equal(base.Add(3*time.Hour)) {
+		t.Errorf("first spam date = %v, want %v", stats.FirstSpamDate, base.Add(3*time.Hour))
+	}
+	if !stats.LastCheckDate.Equal(base.Add(4 * time.Hour)) {
+		t.Errorf("last check date = %v, want %v", stats.LastCheckDate, base.Add(4*time.Hour))
+	}
+
+	report, err := NewStatisticsService(db).Reconcile(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Discrepancies != 0 {
+		t.Errorf("audit after delete found %d discrepancies: %+v", report.Discrepancies, report.Samples)
+	}
+}
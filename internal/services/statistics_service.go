@@ -22,38 +22,58 @@ func NewStatisticsService(db *gorm.DB) *StatisticsService {
 	}
 }
 
-// GetOverviewStats gets general overview statistics
-func (s *StatisticsService) GetOverviewStats() (map[string]interface{}, error) {
+// GetOverviewStats gets general overview statistics. Phone and check counters are limited to phones
+// created by ownerID unless it is 0, services and gateways are always counted in full.
+func (s *StatisticsService) GetOverviewStats(ownerID uint) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
+	phones := func() *gorm.DB {
+		return ownedPhones(s.db.Model(&models.PhoneNumber{}), ownerID)
+	}
+	results := func() *gorm.DB {
+		return ownedResults(s.db, s.db.Model(&models.CheckResult{}), ownerID)
+	}
 
 	// Total phones
 	var totalPhones int64
-	if err := s.db.Model(&models.PhoneNumber{}).Count(&totalPhones).Error; err != nil {
+	if err := phones().Count(&totalPhones).Error; err != nil {
 		return nil, fmt.Errorf("failed to count phones: %w", err)
 	}
 	stats["total_phones"] = totalPhones
 
 	// Active phones
 	var activePhones int64
-	if err := s.db.Model(&models.PhoneNumber{}).Where("is_active = ?", true).Count(&activePhones).Error; err != nil {
+	if err := phones().Where("is_active = ?", true).Count(&activePhones).Error; err != nil {
 		return nil, fmt.Errorf("failed to count active phones: %w", err)
 	}
 	stats["active_phones"] = activePhones
 
-	// Total checks
+	// Total checks, failed checks are counted separately
 	var totalChecks int64
-	if err := s.db.Model(&models.CheckResult{}).Count(&totalChecks).Error; err != nil {
+	if err := results().Where("status <> ?", models.SpamStatusError).Count(&totalChecks).Error; err != nil {
 		return nil, fmt.Errorf("failed to count checks: %w", err)
 	}
 	stats["total_checks"] = totalChecks
 
+	var errorChecks int64
+	if err := results().Where("status = ?", models.SpamStatusError).Count(&errorChecks).Error; err != nil {
+		return nil, fmt.Errorf("failed to count failed checks: %w", err)
+	}
+	stats["error_checks"] = errorChecks
+
 	// Spam detections
 	var spamDetections int64
-	if err := s.db.Model(&models.CheckResult{}).Where("is_spam = ?", true).Count(&spamDetections).Error; err != nil {
+	if err := results().Where("is_spam = ?", true).Count(&spamDetections).Error; err != nil {
 		return nil, fmt.Errorf("failed to count spam detections: %w", err)
 	}
 	stats["spam_detections"] = spamDetections
 
+	// Inconclusive checks are neither spam nor verified clean
+	var inconclusiveChecks int64
+	if err := results().Where("inconclusive = ?", true).Count(&inconclusiveChecks).Error; err != nil {
+		return nil, fmt.Errorf("failed to count inconclusive checks: %w", err)
+	}
+	stats["inconclusive_checks"] = inconclusiveChecks
+
 	// Calculate spam rate
 	spamRate := float64(0)
 	if totalChecks > 0 {
@@ -75,6 +95,13 @@ func (s *StatisticsService) GetOverviewStats() (map[string]interface{}, error) {
 	}
 	stats["active_gateways"] = activeGateways
 
+	// Phones whose every service verdict is older than its max age
+	stalePhones, err := LoadResultFreshness(s.db).CountStaleVerdictPhones(s.db, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	stats["stale_verdict_phones"] = stalePhones
+
 	return stats, nil
 }
 
@@ -97,15 +124,24 @@ func (s *StatisticsService) GetTimeSeriesStats(days int) ([]map[string]interface
 
 		if dailyStats[dateKey] == nil {
 			dailyStats[dateKey] = map[string]int{
-				"total_checks": 0,
-				"spam_count":   0,
-				"clean_count":  0,
+				"total_checks":       0,
+				"spam_count":         0,
+				"clean_count":        0,
+				"inconclusive_count": 0,
+				"error_count":        0,
 			}
 		}
 
+		if result.Status == models.SpamStatusError {
+			dailyStats[dateKey]["error_count"]++
+			continue
+		}
+
 		dailyStats[dateKey]["total_checks"]++
 		if result.IsSpam {
 			dailyStats[dateKey]["spam_count"]++
+		} else if result.Inconclusive {
+			dailyStats[dateKey]["inconclusive_count"]++
 		} else {
 			dailyStats[dateKey]["clean_count"]++
 		}
@@ -122,11 +158,13 @@ func (s *StatisticsService) GetTimeSeriesStats(days int) ([]map[string]interface
 		if dayData == nil {
 			// No data for this day
 			stats = append(stats, map[string]interface{}{
-				"date":         dateKey,
-				"total_checks": 0,
-				"spam_count":   0,
-				"clean_count":  0,
-				"spam_rate":    float64(0),
+				"date":               dateKey,
+				"total_checks":       0,
+				"spam_count":         0,
+				"clean_count":        0,
+				"inconclusive_count": 0,
+				"error_count":        0,
+				"spam_rate":          float64(0),
 			})
 		} else {
 			spamRate := float64(0)
@@ -135,11 +173,13 @@ func (s *StatisticsService) GetTimeSeriesStats(days int) ([]map[string]interface
 			}
 
 			stats = append(stats, map[string]interface{}{
-				"date":         dateKey,
-				"total_checks": dayData["total_checks"],
-				"spam_count":   dayData["spam_count"],
-				"clean_count":  dayData["clean_count"],
-				"spam_rate":    spamRate,
+				"date":               dateKey,
+				"total_checks":       dayData["total_checks"],
+				"spam_count":         dayData["spam_count"],
+				"clean_count":        dayData["clean_count"],
+				"inconclusive_count": dayData["inconclusive_count"],
+				"error_count":        dayData["error_count"],
+				"spam_rate":          spamRate,
 			})
 		}
 	}
@@ -159,12 +199,18 @@ func (s *StatisticsService) GetServiceStats() ([]map[string]interface{}, error)
 	for _, service := range services {
 		var totalChecks int64
 		var spamCount int64
+		var errorCount int64
 
 		// Count total checks for this service
-		if err := s.db.Model(&models.CheckResult{}).Where("service_id = ?", service.ID).Count(&totalChecks).Error; err != nil {
+		if err := s.db.Model(&models.CheckResult{}).Where("service_id = ? AND status <> ?", service.ID, models.SpamStatusError).Count(&totalChecks).Error; err != nil {
 			return nil, fmt.Errorf("failed to count checks for service %s: %w", service.Name, err)
 		}
 
+		// Count failed checks for this service
+		if err := s.db.Model(&models.CheckResult{}).Where("service_id = ? AND status = ?", service.ID, models.SpamStatusError).Count(&errorCount).Error; err != nil {
+			return nil, fmt.Errorf("failed to count failed checks for service %s: %w", service.Name, err)
+		}
+
 		// Count spam detections for this service
 		if err := s.db.Model(&models.CheckResult{}).Where("service_id = ? AND is_spam = ?", service.ID, true).Count(&spamCount).Error; err != nil {
 			return nil, fmt.Errorf("failed to count spam for service %s: %w", service.Name, err)
@@ -181,6 +227,7 @@ func (s *StatisticsService) GetServiceStats() ([]map[string]interface{}, error)
 			"service_code": service.Code,
 			"total_checks": totalChecks,
 			"spam_count":   spamCount,
+			"error_count":  errorCount,
 			"spam_rate":    spamRate,
 		})
 	}
@@ -264,6 +311,9 @@ func (s *StatisticsService) GetPhoneSpamHistory(phoneID uint) ([]map[string]inte
 			"checked_at":     result.CheckedAt,
 			"service_name":   result.Service.Name,
 			"is_spam":        result.IsSpam,
+			"inconclusive":   result.Inconclusive,
+			"status":         result.Status,
+			"confidence":     result.Confidence,
 			"found_keywords": keywords,
 		}
 	}
@@ -323,6 +373,10 @@ func (s *StatisticsService) GetSpamTrends(interval string) ([]map[string]interfa
 			}
 		}
 
+		if result.Status == models.SpamStatusError {
+			continue
+		}
+
 		periodStats[periodKey]["total_checks"]++
 		if result.IsSpam {
 			periodStats[periodKey]["spam_count"]++
@@ -411,12 +465,12 @@ func (s *StatisticsService) GetPhoneStatistics(phoneID uint) (*models.Statistics
 	return &stats, nil
 }
 
-// GetDashboardStats gets statistics specifically for dashboard
-func (s *StatisticsService) GetDashboardStats() (map[string]interface{}, error) {
+// GetDashboardStats gets statistics specifically for dashboard, limited to phones created by ownerID unless it is 0
+func (s *StatisticsService) GetDashboardStats(ownerID uint) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
 	// Get phone statistics
-	phoneStats, err := NewPhoneService(s.db).GetPhoneStats()
+	phoneStats, err := NewPhoneService(s.db).GetPhoneStats(ownerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get phone stats: %w", err)
 	}
@@ -427,15 +481,18 @@ func (s *StatisticsService) GetDashboardStats() (map[string]interface{}, error)
 
 	// Get check statistics for today
 	today := time.Now().Truncate(24 * time.Hour)
+	results := func() *gorm.DB {
+		return ownedResults(s.db, s.db.Model(&models.CheckResult{}), ownerID)
+	}
 
 	var todayChecks int64
-	if err := s.db.Model(&models.CheckResult{}).Where("checked_at >= ?", today).Count(&todayChecks).Error; err != nil {
+	if err := results().Where("checked_at >= ? AND status <> ?", today, models.SpamStatusError).Count(&todayChecks).Error; err != nil {
 		return nil, fmt.Errorf("failed to count today's checks: %w", err)
 	}
 	stats["today_checks"] = todayChecks
 
 	var todaySpam int64
-	if err := s.db.Model(&models.CheckResult{}).Where("checked_at >= ? AND is_spam = ?", today, true).Count(&todaySpam).Error; err != nil {
+	if err := results().Where("checked_at >= ? AND is_spam = ?", today, true).Count(&todaySpam).Error; err != nil {
 		return nil, fmt.Errorf("failed to count today's spam: %w", err)
 	}
 	stats["today_spam"] = todaySpam
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"spam-checker/internal/models"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Telegram bot limits
+const (
+	defaultBotMinInterval = 10 * time.Second
+	maxBotMinInterval     = time.Hour
+	maxBotKeywords        = 50
+	defaultBotTemplate    = "{phone}"
+)
+
+// ErrBotRateLimited is returned when a bot was queried less than its min interval ago
+var ErrBotRateLimited = errors.New("bot query rate limited")
+
+// TelegramBotConfig is bot_config of a telegram_bot API service. The Telegram session is held by
+// a relay at api_url: a user-bot or a bot API bridge which sends the message to the bot and waits
+// for its reply. Relay credentials (API ID and hash, session or bot token) are sent as headers.
+type TelegramBotConfig struct {
+	BotUsername        string   `json:"bot_username"`                   // e.g. @some_lookup_bot
+	MessageTemplate    string   `json:"message_template,omitempty"`     // Phone placeholders as in API URL, {phone} by default
+	MinIntervalSeconds int      `json:"min_interval_seconds,omitempty"` // One query to the bot per interval, 10 by default
+	SpamPattern        string   `json:"spam_pattern,omitempty"`         // Regex, a match in the reply marks spam
+	CleanPattern       string   `json:"clean_pattern,omitempty"`        // Regex, a match marks clean without keyword scan
+	Keywords           []string `json:"keywords,omitempty"`             // Reply phrases marking spam in addition to spam keywords
+}
+
+// telegramBotRequest is the body sent to relay
+type telegramBotRequest struct {
+	Bot     string `json:"bot"`
+	Text    string `json:"text"`
+	Timeout int    `json:"timeout"` // Seconds to wait for the reply
+}
+
+// telegramBotReply is the relay response
+type telegramBotReply struct {
+	Reply string `json:"reply"`
+}
+
+// parseTelegramBotConfig decodes and validates bot_config
+func parseTelegramBotConfig(raw string) (*TelegramBotConfig, error) {
+	var cfg TelegramBotConfig
+	if strings.TrimSpace(raw) == "" {
+		return nil, errors.New("bot_config is required for telegram_bot services")
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid bot_config JSON: %w", err)
+	}
+
+	cfg.BotUsername = strings.TrimSpace(cfg.BotUsername)
+	if cfg.BotUsername == "" {
+		return nil, errors.New("bot_config.bot_username is required")
+	}
+	if !strings.HasPrefix(cfg.BotUsername, "@") {
+		cfg.BotUsername = "@" + cfg.BotUsername
+	}
+	if cfg.MessageTemplate == "" {
+		cfg.MessageTemplate = defaultBotTemplate
+	}
+	if replacePhonePlaceholder(cfg.MessageTemplate, "0") == cfg.MessageTemplate {
+		return nil, errors.New("bot_config.message_template must contain a phone placeholder")
+	}
+	if cfg.MinIntervalSeconds < 0 || time.Duration(cfg.MinIntervalSeconds)*time.Second > maxBotMinInterval {
+		return nil, fmt.Errorf("bot_config.min_interval_seconds must be between 0 and %d", int(maxBotMinInterval.Seconds()))
+	}
+	if _, err := regexp.Compile(cfg.SpamPattern); err != nil {
+		return nil, fmt.Errorf("invalid bot_config.spam_pattern: %w", err)
+	}
+	if _, err := regexp.Compile(cfg.CleanPattern); err != nil {
+		return nil, fmt.Errorf("invalid bot_config.clean_pattern: %w", err)
+	}
+	if len(cfg.Keywords) > maxBotKeywords {
+		return nil, fmt.Errorf("bot_config.keywords must have at most %d entries", maxBotKeywords)
+	}
+	return &cfg, nil
+}
+
+// minInterval returns pause between queries to the bot
+func (c *TelegramBotConfig) minInterval() time.Duration {
+	if c.MinIntervalSeconds == 0 {
+		return defaultBotMinInterval
+	}
+	return time.Duration(c.MinIntervalSeconds) * time.Second
+}
+
+// validateAPIServiceType checks type of an API service and bot_config of telegram_bot services
+func validateAPIServiceType(service *models.APIService) error {
+	switch service.Type {
+	case "", models.APIServiceTypeHTTP:
+		return nil
+	case models.APIServiceTypeTelegramBot:
+		if service.APIURL == "" {
+			return errors.New("api_url of the bot relay is required for telegram_bot services")
+		}
+		_, err := parseTelegramBotConfig(service.BotConfig)
+		return err
+	default:
+		return fmt.Errorf("invalid type: %s", service.Type)
+	}
+}
+
+// sharedBotRateLimiter spaces queries to each bot across all checks and tests of the process
+var sharedBotRateLimiter = &botRateLimiter{next: make(map[string]time.Time)}
+
+// botRateLimiter hands out query turns per bot, one per min interval
+type botRateLimiter struct {
+	mu   sync.Mutex
+	next map[string]time.Time // Earliest time of the next query by bot username
+}
+
+// wait reserves the next turn of bot and sleeps until it comes. A turn abandoned on
+// cancellation is not given back, the bot just stays idle for it.
+func (l *botRateLimiter) wait(ctx context.Context, bot string, interval time.Duration) error {
+	l.mu.Lock()
+	key := strings.ToLower(bot)
+	turn := time.Now()
+	if next := l.next[key]; next.After(turn) {
+		turn = next
+	}
+	l.next[key] = turn.Add(interval)
+	l.mu.Unlock()
+
+	delay := time.Until(turn)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take reserves the turn of bot only if it is due now, otherwise returns time left until it is
+func (l *botRateLimiter) take(bot string, interval time.Duration) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := strings.ToLower(bot)
+	now := time.Now()
+	if next := l.next[key]; next.After(now) {
+		return next.Sub(now)
+	}
+	l.next[key] = now.Add(interval)
+	return 0
+}
+
+// fetchBotReply waits for the turn of the bot and asks it about phone number
+func (s *APICheckService) fetchBotReply(apiService *models.APIService, cfg *TelegramBotConfig, number string) (string, error) {
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := sharedBotRateLimiter.wait(ctx, cfg.BotUsername, cfg.minInterval()); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBotRateLimited, err)
+	}
+	return s.askTelegramBot(apiService, cfg, number)
+}
+
+// askTelegramBot sends phone query to the bot through relay and returns reply text.
+// Caller must hold a turn of the bot.
+func (s *APICheckService) askTelegramBot(apiService *models.APIService, cfg *TelegramBotConfig, number string) (string, error) {
+	payload, err := json.Marshal(telegramBotRequest{
+		Bot:     cfg.BotUsername,
+		Text:    replacePhonePlaceholder(cfg.MessageTemplate, number),
+		Timeout: apiService.Timeout,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode bot request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(apiService.APIURL, "/")+"/ask", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiService.Headers != "" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(apiService.Headers), &headers); err == nil {
+			for key, value := range headers {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+
+	// Relay waits for the reply up to the service timeout, leave it time to answer
+	client := &http.Client{
+		Timeout: time.Duration(apiService.Timeout)*time.Second + 10*time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("bot relay request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bot relay response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusGatewayTimeout:
+		return "", fmt.Errorf("bot %s reply timeout", cfg.BotUsername)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return "", fmt.Errorf("%w: bot relay HTTP %d", ErrInvalidAPIResponse, resp.StatusCode)
+	}
+
+	var reply telegramBotReply
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return "", fmt.Errorf("%w: bot relay body is not valid JSON", ErrInvalidAPIResponse)
+	}
+	if strings.TrimSpace(reply.Reply) == "" {
+		return "", fmt.Errorf("%w: bot %s sent empty reply", ErrInvalidAPIResponse, cfg.BotUsername)
+	}
+	return reply.Reply, nil
+}
+
+// analyzeBotReply applies parsing rules of the bot to reply text. Clean pattern wins, otherwise
+// spam pattern match, bot keywords and spam keywords of the service are collected along with
+// where they occur.
+func (s *APICheckService) analyzeBotReply(cfg *TelegramBotConfig, reply string, serviceID uint) (bool, []string, []models.KeywordMatch) {
+	if cfg.CleanPattern != "" {
+		if regexp.MustCompile(cfg.CleanPattern).MatchString(reply) {
+			return false, nil, nil
+		}
+	}
+
+	found := newFoundKeywords()
+	text := newKeywordText(reply, models.KeywordMatchText)
+
+	if cfg.SpamPattern != "" {
+		if loc := regexp.MustCompile(cfg.SpamPattern).FindStringIndex(reply); loc != nil {
+			if keyword := strings.TrimSpace(reply[loc[0]:loc[1]]); keyword != "" {
+				start := utf8.RuneCountInString(reply[:loc[0]])
+				found.add(keyword, []models.KeywordMatch{text.match(keyword, start, start+utf8.RuneCountInString(reply[loc[0]:loc[1]]))})
+			}
+		}
+	}
+	negations := globalKeywordNegations(s.db)
+	for _, keyword := range cfg.Keywords {
+		if keyword = strings.TrimSpace(keyword); keyword == "" {
+			continue
+		}
+		if matches := text.find(keyword, negations, false); len(matches) > 0 {
+			found.add(keyword, matches)
+		}
+	}
+	_, keywords, matches := s.analyzeAPIResponse(reply, reply, nil, serviceID, false)
+	for _, keyword := range keywords {
+		var occurrences []models.KeywordMatch
+		for _, match := range matches {
+			if match.Keyword == keyword {
+				occurrences = append(occurrences, match)
+			}
+		}
+		found.add(keyword, occurrences)
+	}
+
+	return len(found.keywords) > 0, found.keywords, found.matches
+}
+
+// testTelegramBot queries the bot for test phone, or only parses given reply, and reports parsing result.
+// Real queries respect the bot rate limit instead of waiting for the turn.
+func (s *APICheckService) testTelegramBot(apiService *models.APIService, serviceID uint, testPhone, reply string) (map[string]interface{}, error) {
+	cfg, err := parseTelegramBotConfig(apiService.BotConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	message := replacePhonePlaceholder(cfg.MessageTemplate, testPhone)
+	startTime := time.Now()
+	if reply == "" {
+		if wait := sharedBotRateLimiter.take(cfg.BotUsername, cfg.minInterval()); wait > 0 {
+			return map[string]interface{}{
+				"success":     false,
+				"error":       ErrBotRateLimited.Error(),
+				"retry_after": int(wait.Seconds()) + 1,
+				"bot":         cfg.BotUsername,
+			}, nil
+		}
+		reply, err = s.askTelegramBot(apiService, cfg, testPhone)
+		if err != nil {
+			return map[string]interface{}{
+				"success":       false,
+				"error":         err.Error(),
+				"response_time": time.Since(startTime).Milliseconds(),
+				"bot":           cfg.BotUsername,
+				"message":       message,
+			}, nil
+		}
+	}
+
+	isSpam, keywords, matches := s.analyzeBotReply(cfg, reply, serviceID)
+	confidence := 1.0
+	if isSpam {
+		confidence = ConfidenceExtractedText
+	}
+	return map[string]interface{}{
+		"confidence":      confidence,
+		"success":         true,
+		"response_time":   time.Since(startTime).Milliseconds(),
+		"response":        reply,
+		"is_spam":         isSpam,
+		"keywords":        keywords,
+		"keyword_matches": matches,
+		"bot":             cfg.BotUsername,
+		"message":         message,
+	}, nil
+}